@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// githubActions holds the files a GitHub Actions step exposes for a job
+// summary and step outputs; either may be empty
+type githubActions struct {
+	summary string
+	output  string
+}
+
+// detectGitHubActions reads the Actions environment, returning nil when
+// ebert isn't running in a workflow step
+func detectGitHubActions() *githubActions {
+	actions := &githubActions{
+		summary: os.Getenv("GITHUB_STEP_SUMMARY"),
+		output:  os.Getenv("GITHUB_OUTPUT"),
+	}
+	if os.Getenv("GITHUB_ACTIONS") != "true" && actions.summary == "" && actions.output == "" {
+		return nil
+	}
+	return actions
+}
+
+// report appends the Markdown report to the job summary and writes the
+// score, risk level and red flag count as step outputs
+func (g *githubActions) report(analysis *ebert.Analysis) error {
+	if g.summary != "" {
+		var buf bytes.Buffer
+		ebert.FprintMarkdown(&buf, analysis)
+		buf.WriteString("\n")
+		if err := appendFile(g.summary, buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to write job summary: %w", err)
+		}
+	}
+
+	if g.output != "" {
+		outputs := fmt.Sprintf("score=%.1f\nrisk_level=%s\nred_flag_count=%d\n",
+			analysis.OverallScore, analysis.RiskLevel, len(analysis.RedFlags))
+		if err := appendFile(g.output, []byte(outputs)); err != nil {
+			return fmt.Errorf("failed to write step outputs: %w", err)
+		}
+	}
+	return nil
+}
+
+// appendFile appends data to the file at path, creating it if needed
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeAnnotations emits a workflow command per warning and red flag so
+// they surface on the run page
+func writeAnnotations(w io.Writer, analysis *ebert.Analysis) {
+	for _, finding := range analysis.Findings {
+		var command string
+		switch finding.Severity {
+		case ebert.SeverityRedFlag:
+			command = "error"
+		case ebert.SeverityWarning:
+			command = "warning"
+		default:
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "::%s title=%s::%s\n", command, propertyEscaper.Replace(finding.Code), messageEscaper.Replace(finding.Message))
+	}
+}
+
+// Workflow commands escape line breaks and percent signs in messages, and
+// additionally colons and commas in property values
+var (
+	messageEscaper  = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
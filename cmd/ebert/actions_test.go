@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+func TestRunGitHubActions(t *testing.T) {
+	api := newCLIAPI(t)
+	api.account("octo", cliRepo)
+	tape := api.record(t, "", "octo")
+
+	dir := t.TempDir()
+	summary := filepath.Join(dir, "summary.md")
+	output := filepath.Join(dir, "output")
+	// The runner shares the files between steps, so ebert appends to them
+	if err := os.WriteFile(output, []byte("earlier=step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_STEP_SUMMARY": summary, "GITHUB_OUTPUT": output}
+
+	code, stdout, stderr := runCLIEnv(t, env, "--replay", tape, "--json", "octo")
+	if code != ebert.ExitOK {
+		t.Fatalf("exit code %d; stderr:\n%s", code, stderr)
+	}
+	var analysis ebert.Analysis
+	if err := json.Unmarshal([]byte(stdout), &analysis); err != nil {
+		t.Fatalf("annotations broke the JSON on stdout: %v\n%s", err, stdout)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("earlier=step\nscore=%.1f\nrisk_level=%s\nred_flag_count=%d\n", analysis.OverallScore, analysis.RiskLevel, len(analysis.RedFlags))
+	if string(data) != want {
+		t.Errorf("step outputs = %q, want %q", data, want)
+	}
+
+	data, err = os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "## ebert: @octo") || !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("job summary = %q, want the Markdown report", data)
+	}
+
+	var annotations []string
+	for _, finding := range analysis.Findings {
+		switch finding.Severity {
+		case ebert.SeverityRedFlag:
+			annotations = append(annotations, "::error title="+finding.Code+"::")
+		case ebert.SeverityWarning:
+			annotations = append(annotations, "::warning title="+finding.Code+"::")
+		}
+	}
+	if len(annotations) == 0 {
+		t.Fatal("the account has no warnings or red flags; the test exercises no annotations")
+	}
+	for _, annotation := range annotations {
+		if !strings.Contains(stderr, annotation) {
+			t.Errorf("stderr lacks the annotation %s:\n%s", annotation, stderr)
+		}
+	}
+}
+
+func TestRunNoGitHubActions(t *testing.T) {
+	api := newCLIAPI(t)
+	api.account("octo", cliRepo)
+	tape := api.record(t, "", "octo")
+
+	dir := t.TempDir()
+	summary := filepath.Join(dir, "summary.md")
+	output := filepath.Join(dir, "output")
+	env := map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_STEP_SUMMARY": summary, "GITHUB_OUTPUT": output}
+
+	code, stdout, stderr := runCLIEnv(t, env, "--replay", tape, "--no-github-actions", "octo")
+	if code != ebert.ExitOK {
+		t.Fatalf("exit code %d; stderr:\n%s", code, stderr)
+	}
+	for _, path := range []string{summary, output} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("--no-github-actions wrote %s", filepath.Base(path))
+		}
+	}
+	if strings.Contains(stdout+stderr, "::warning") || strings.Contains(stdout+stderr, "::error") {
+		t.Errorf("--no-github-actions wrote annotations:\n%s%s", stdout, stderr)
+	}
+}
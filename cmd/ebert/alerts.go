@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// alertSender prints alerts to stderr and posts them to their rules'
+// webhooks. Offline runs only print them.
+type alertSender struct {
+	client  *http.Client
+	offline bool
+	stderr  io.Writer
+}
+
+// send reports alerts, returning the webhooks that couldn't be notified
+func (s *alertSender) send(ctx context.Context, alerts []ebert.Alert) error {
+	posting := false
+	for _, alert := range alerts {
+		_, _ = fmt.Fprintf(s.stderr, "Alert: %s\n", alert)
+		posting = posting || len(alert.Rule.Webhooks) > 0
+	}
+	if !posting {
+		return nil
+	}
+	if s.offline {
+		_, _ = fmt.Fprintln(s.stderr, "Warning: --offline posts no alerts to the config's webhooks")
+		return nil
+	}
+	return ebert.SendAlerts(ctx, s.client, alerts)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// runBatch analyzes every login in a file, streaming each result to
+// stdout as it completes rather than holding the batch in memory. With
+// --raw, each account's report and raw data go into a file of their own
+// under rawDir instead of the stream.
+func runBatch(analyzer *ebert.Analyzer, path, format string, raw bool, rawDir string, allowPartial bool, exp *exporter, stdout, stderr io.Writer) int {
+	if raw && rawDir == "" {
+		_, _ = fmt.Fprintln(stderr, "Error: batch --raw writes a file per account; give the directory with --raw-dir")
+		return ebert.ExitError
+	}
+	logins, err := ebert.LoadLogins(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	writer, err := ebert.NewBatchWriter(stdout, format)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	if raw {
+		if err := os.MkdirAll(rawDir, 0o755); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: failed to create raw directory: %v\n", err)
+			return ebert.ExitError
+		}
+	}
+
+	var firstErr error
+	summary, err := analyzer.AnalyzeBatch(context.Background(), logins, raw, func(result ebert.BatchResult) error {
+		if result.Analysis == nil {
+			firstErr = cmp.Or(firstErr, errors.New(result.Login+": "+result.Error))
+			// Deleted accounts are listed together once the batch is done
+			if format == "text" && result.Status != ebert.MemberNotFound {
+				_, _ = fmt.Fprintf(stderr, "Error: %s: %s\n", result.Login, result.Error)
+			}
+		} else {
+			exp.put(result.Login, result.Analysis.Timestamp, result.Analysis)
+			if raw {
+				if err := writeRawReport(rawDir, result); err != nil {
+					return err
+				}
+			}
+		}
+		return writer.Write(result)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	_ = ebert.WriteBatchSummary(stderr, summary)
+
+	switch {
+	case summary.Analyzed == 0:
+		return failed(stderr, firstErr)
+	case summary.Failed+summary.Partial > 0:
+		_, _ = fmt.Fprintf(stderr, "Partial batch (%s): %d of %d accounts failed, %d analyzed partially\n",
+			ebert.ExitCategory(ebert.ExitPartialData), summary.Failed, summary.Accounts, summary.Partial)
+		if !allowPartial {
+			return ebert.ExitPartialData
+		}
+	}
+	return exp.exitCode()
+}
+
+// writeRawReport saves an account's report with its raw data as
+// <login>.json under dir
+func writeRawReport(dir string, result ebert.BatchResult) error {
+	data, err := json.MarshalIndent(&ebert.DetailedAnalysis{Analysis: result.Analysis, Raw: result.Raw}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode raw report of %s: %w", result.Login, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, result.Login+".json"), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write raw report of %s: %w", result.Login, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// config is the JSON file given by --config: the sub-score weights, and
+// alert rules evaluated alongside --alerts, each posting to webhooks of
+// its own
+type config struct {
+	Weights *ebert.Weights    `json:"weights,omitempty"`
+	Alerts  []ebert.AlertRule `json:"alerts,omitempty"`
+}
+
+// loadConfig reads the config file at path
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	for i, rule := range cfg.Alerts {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("config %s: alerts[%d]: %w", path, i, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// writeConfigWeights sets the weights of the config file at path, creating
+// it if missing and keeping whatever else it holds
+func writeConfigWeights(path string, weights ebert.Weights) error {
+	fields := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read config: %w", err)
+	default:
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	}
+
+	encoded, err := json.Marshal(weights)
+	if err != nil {
+		return fmt.Errorf("failed to encode weights: %w", err)
+	}
+	fields["weights"] = encoded
+	if data, err = json.MarshalIndent(fields, "", "  "); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
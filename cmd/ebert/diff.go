@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// runDiff compares two saved analyses of an account, each a JSON report
+// or an audit bundle: the score and risk level, the findings raised and
+// cleared, and the ebert version and options behind each
+func runDiff(before, after string, stdout, stderr io.Writer) int {
+	old, err := loadReport(before)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	current, err := loadReport(after)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+
+	_, _ = fmt.Fprintf(stdout, "%s: %s -> %s\n", current.User.Login, timestampOf(old.Analysis), timestampOf(current.Analysis))
+	_, _ = fmt.Fprintf(stdout, "Overall score: %.1f -> %.1f (%+.1f)\n", old.OverallScore, current.OverallScore, current.OverallScore-old.OverallScore)
+	if old.RiskLevel != current.RiskLevel {
+		_, _ = fmt.Fprintf(stdout, "Risk level: %s -> %s\n", old.RiskLevel, current.RiskLevel)
+	}
+
+	oldCodes, currentCodes := findingCodes(old.Analysis), findingCodes(current.Analysis)
+	for _, code := range currentCodes {
+		if !slices.Contains(oldCodes, code) {
+			_, _ = fmt.Fprintf(stdout, "+ %s\n", code)
+		}
+	}
+	for _, code := range oldCodes {
+		if !slices.Contains(currentCodes, code) {
+			_, _ = fmt.Fprintf(stdout, "- %s\n", code)
+		}
+	}
+
+	for _, diff := range ebert.MetaDifferences(old.Analysis, current.Analysis) {
+		_, _ = fmt.Fprintf(stdout, "Note: %s\n", diff)
+	}
+	return ebert.ExitOK
+}
+
+// timestampOf formats when an analysis ran
+func timestampOf(analysis *ebert.Analysis) string {
+	if analysis.Timestamp.IsZero() {
+		return "unknown"
+	}
+	return analysis.Timestamp.UTC().Format("2006-01-02 15:04:05")
+}
+
+// findingCodes lists the distinct codes of an analysis's findings, sorted
+func findingCodes(analysis *ebert.Analysis) []string {
+	var codes []string
+	for _, finding := range analysis.Findings {
+		codes = append(codes, finding.Code)
+	}
+	slices.Sort(codes)
+	return slices.Compact(codes)
+}
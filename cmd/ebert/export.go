@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"github.com/JamesWoolfenden/ebert/pkg/ebert/export"
+)
+
+// exporter archives each report to the --export sink. Failures are
+// reported as they happen and only fail the run when strict is set.
+type exporter struct {
+	sink   export.Sink
+	strict bool
+	stderr io.Writer
+	failed bool
+}
+
+// openExporter opens the sink rawURL names, or returns nil when it is empty
+func openExporter(rawURL string, strict bool, stderr io.Writer) (*exporter, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	sink, err := export.Open(context.Background(), rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export sink: %w", err)
+	}
+	return &exporter{sink: sink, strict: strict, stderr: stderr}, nil
+}
+
+// put stores report as <subject>/<timestamp>.json
+func (e *exporter) put(subject string, at time.Time, report any) {
+	if e == nil {
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	name := export.Name(subject, at)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = e.sink.Put(context.Background(), name, data)
+	}
+	if err != nil {
+		e.failed = true
+		_, _ = fmt.Fprintf(e.stderr, "Warning: failed to export %s: %v\n", name, err)
+	}
+}
+
+// exitCode is ebert.ExitError when an export failed under --export-strict
+func (e *exporter) exitCode() int {
+	if e != nil && e.failed && e.strict {
+		return ebert.ExitError
+	}
+	return ebert.ExitOK
+}
+
+// close releases the sink's client, if it holds one
+func (e *exporter) close() {
+	if e == nil {
+		return
+	}
+	if closer, ok := e.sink.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
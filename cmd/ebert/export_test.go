@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// exported reads the reports archived for login under root
+func exported(t *testing.T, root, login string) []ebert.Analysis {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(root, login, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var analyses []ebert.Analysis
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var analysis ebert.Analysis
+		if err := json.Unmarshal(data, &analysis); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		analyses = append(analyses, analysis)
+	}
+	return analyses
+}
+
+func TestRunExport(t *testing.T) {
+	api := newCLIAPI(t)
+	api.account("octo", cliRepo)
+	api.account("hubot", cliRepo)
+	tape := api.record(t, "", "octo", "hubot")
+
+	root := t.TempDir()
+	code, _, stderr := runCLI(t, "--replay", tape, "--export", "file://"+root, "--quiet", "octo")
+	if code != ebert.ExitOK || stderr != "" {
+		t.Fatalf("exit code %d, stderr %q; want a clean pass", code, stderr)
+	}
+	if got := exported(t, root, "octo"); len(got) != 1 || got[0].User.Login != "octo" {
+		t.Errorf("exported %+v, want one analysis of octo", got)
+	}
+
+	// Batch mode exports each analysis
+	logins := filepath.Join(t.TempDir(), "logins.txt")
+	if err := os.WriteFile(logins, []byte("octo\nhubot\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	batchRoot := t.TempDir()
+	if code, _, stderr := runCLI(t, "--replay", tape, "--export", batchRoot, "-q", "batch", logins); code != ebert.ExitOK {
+		t.Fatalf("batch exited %d; stderr:\n%s", code, stderr)
+	}
+	for _, login := range []string{"octo", "hubot"} {
+		if got := exported(t, batchRoot, login); len(got) != 1 || got[0].User.Login != login {
+			t.Errorf("batch exported %+v for %s, want one analysis", got, login)
+		}
+	}
+}
+
+func TestRunExportFailure(t *testing.T) {
+	api := newCLIAPI(t)
+	api.account("octo", cliRepo)
+	tape := api.record(t, "", "octo")
+
+	// A file where the export directory should be
+	blocker := filepath.Join(t.TempDir(), "reports")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"lenient", nil, ebert.ExitOK},
+		{"strict", []string{"--export-strict"}, ebert.ExitError},
+	} {
+		args := append([]string{"--replay", tape, "--export", blocker, "-q"}, tt.args...)
+		code, stdout, stderr := runCLI(t, append(args, "octo")...)
+		if code != tt.code || !strings.Contains(stderr, "Warning: failed to export octo/") {
+			t.Errorf("%s: exit code %d, stderr %q; want %d and the export warning", tt.name, code, stderr, tt.code)
+		}
+		// The report is still written
+		if !strings.HasPrefix(stdout, "octo\t") {
+			t.Errorf("%s: stdout = %q, want the verdict", tt.name, stdout)
+		}
+	}
+
+	code, _, stderr := runCLI(t, "--replay", tape, "--export", "ftp://host/reports", "--export-strict", "octo")
+	if code != ebert.ExitError || !strings.Contains(stderr, `Error: failed to open export sink: unsupported export scheme "ftp"`) {
+		t.Errorf("an unsupported sink under --export-strict exited %d, stderr %q", code, stderr)
+	}
+}
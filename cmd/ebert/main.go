@@ -0,0 +1,972 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"github.com/JamesWoolfenden/ebert/pkg/ebert/redisebert"
+	"github.com/JamesWoolfenden/ebert/pkg/ebert/sigstoreebert"
+	"github.com/JamesWoolfenden/ebert/pkg/ebert/tuiebert"
+)
+
+// replayRequestRate paces replays, which never touch the network
+const replayRequestRate = 1000
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the CLI and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ebert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { printUsage(stderr, fs) }
+
+	jsonOut := fs.Bool("json", false, "print the analysis as JSON")
+	quiet := fs.Bool("quiet", false, "print only \"<login> <score> <risk level> <red flags>\", tab-separated, on one line")
+	fs.BoolVar(quiet, "q", false, "shorthand for --quiet")
+	format := fs.String("format", "text", "output format: text, json or openmetrics (scores for the node-exporter textfile collector, one document for a whole batch); batch also streams jsonl and csv")
+	lang := fs.String("lang", os.Getenv("EBERT_LANG"), fmt.Sprintf("language of the printed report (built in: %s); JSON stays in English. Defaults to EBERT_LANG", strings.Join(ebert.CatalogLanguages(), ", ")))
+	expand := fs.Bool("expand", false, "list every finding with all its evidence in the printed report, instead of one line per code")
+	configPath := fs.String("config", "", "JSON config file to read the sub-score weights, as written by calibrate --tune --write-config, and alert rules with webhooks of their own from")
+	tune := fs.Bool("tune", false, "with calibrate, search for the sub-score weights that best separate the labeled accounts")
+	tuneIterations := fs.Int("tune-iterations", ebert.DefaultTuneIterations, "with calibrate --tune, most weightings to try")
+	seed := fs.Uint64("seed", 0, "sampling seed recorded in the analysis metadata, by default a hash of the login and UTC date; with calibrate --tune, seeds the random weightings, 1 when unset")
+	minWeight := fs.Float64("min-weight", ebert.DefaultMinWeight, "with calibrate --tune, share of the total weight every sub-score keeps")
+	writeConfig := fs.String("write-config", "", "with calibrate --tune, write the tuned weights into this JSON config file")
+	catalogs := fs.String("catalogs", "", "directory of <lang>.json message catalogs consulted before the built-in ones")
+	alertRules := fs.String("alerts", "", "with --baseline-raw or monitor, comma-separated changes to alert on: new_red_flag, score_rise=<points>, risk_band, flagship_archived, new_co_maintainer")
+	alertWebhooks := fs.String("alert-webhook", "", "comma-separated webhook URLs each --alerts alert is posted to as JSON, e.g. a Slack incoming webhook")
+	store := fs.String("store", "", "with monitor, directory each account's latest report and raw data are kept in as <login>.json, the baseline of its next run")
+	interval := fs.Duration("interval", 24*time.Hour, "with monitor, time between runs, randomized by up to a tenth either way")
+	baselineRaw := fs.String("baseline-raw", "", "reanalyze from a report saved with --json --raw, fetching only the user, events and repos changed since")
+	bundle := fs.String("bundle", "", "write an audit bundle of the analysis, its raw data, the requests sent, the options and the ebert version to this .tar.gz; with view, print the report a bundle records")
+	raw := fs.Bool("raw", false, "include the fetched user, repos, events and gists in the JSON under \"raw\"")
+	rawDir := fs.String("raw-dir", "", "with batch --raw, directory each account's report and raw data are written to as <login>.json")
+	stable := fs.Bool("stable", false, "omit the run timestamp from JSON output")
+	warningsAsErrors := fs.Bool("warnings-as-errors", false, "treat warnings as red flags in annotations and step outputs, and exit non-zero on any red flag")
+	maxWarnings := fs.Int("max-warnings", -1, "exit non-zero when the analysis has more than this many warnings; -1 is unlimited")
+	severityThreshold := fs.String("severity-threshold", "positive", "hide findings below this severity from the printed report: positive, info, warning or red_flag; JSON keeps them all")
+	failOnTrust := fs.Float64("fail-on-trust", -1, "exit non-zero when the trust index (takeover risk, 0-100) reaches this; -1 disables")
+	failOnAbandonment := fs.Float64("fail-on-abandonment", -1, "exit non-zero when the abandonment index (bit-rot risk, 0-100) reaches this; -1 disables")
+	checkRun := fs.String("check-run", "", "attach the analysis as a check run on owner/repo@sha, concluding failure when it fails the --fail-on-* policy (needs a token with checks: write)")
+	annotatePath := fs.String("annotate-path", "", "with --check-run, the manifest path:line the findings are annotated on, e.g. go.mod:14")
+	allowPartial := fs.Bool("allow-partial", false, "exit zero when some data sources failed")
+	debug := fs.Bool("debug", false, "include per-endpoint API request statistics in the analysis")
+	noGists := fs.Bool("no-gists", false, "skip the gist activity and secret-leak checks")
+	deep := fs.Bool("deep", false, "run deep checks that cost extra requests")
+	noExternal := fs.Bool("no-external", false, "never contact hosts other than the GitHub API")
+	rings := fs.Bool("rings", false, "with --deep, look for star-for-star and follow-back rings among the flagships' stargazers")
+	noInstallScripts := fs.Bool("no-install-scripts", false, "skip the deep check of published npm install scripts")
+	verifyArtifacts := fs.Bool("verify-artifacts", false, "with --deep, download the latest npm tarballs and PyPI sdists of the top packages and flag files missing from the repo at the release tag; PyPI wheels aren't compared")
+	artifactIgnore := fs.String("artifact-ignore", "", "comma-separated globs of build output --verify-artifacts skips besides the defaults, e.g. generated/,*.wasm")
+	scoringVersion := fs.Int("scoring-version", ebert.ScoringV1, "scoring formulas: 1, or 2 to score maintenance on decaying repo freshness rather than a 30-day cutoff and quality on age-weighted stars")
+	starHorizon := fs.Duration("star-horizon", ebert.DefaultStarHorizon, "how long after a repo's last push its stars count in full toward the weighted star total")
+	starFalloff := fs.String("star-falloff", ebert.StarFalloffLinear, "how stars past --star-horizon are discounted: linear or exponential")
+	allRepos := fs.Bool("all-repos", false, "score quality and maintenance over forks, templates, mirrors and meta repos too")
+	internalPatterns := fs.String("internal-patterns", "", "comma-separated internal package name prefixes to flag as dependency-confusion candidates; /regex/ entries are RE2")
+	tutorialKeywordsFile := fs.String("tutorial-keywords-file", "", "file of repo name and description keywords marking tutorial clones, one per line, replacing the built-in list")
+	internalPatternsFile := fs.String("internal-patterns-file", "", "file of internal package name patterns, one per line, added to --internal-patterns")
+	maxRepos := fs.Int("max-repos", 0, "most repos to analyze, sampling beyond it; 0 is unlimited for users and 1000 for orgs, -1 is unlimited")
+	timeout := fs.Duration("timeout", ebert.DefaultAnalysisTimeout, "overall deadline for the analysis, e.g. 5m; 0 disables it")
+	externalTimeout := fs.Duration("external-timeout", ebert.DefaultExternalTimeout, "deadline for each request to a host other than the GitHub API, such as a package registry")
+	integrationTimeouts := fs.String("integration-timeouts", "", "comma-separated host=duration overrides of --external-timeout, e.g. registry.npmjs.org=10s")
+	breakerThreshold := fs.Int("breaker-threshold", ebert.DefaultBreakerThreshold, "skip an external host's requests, probing it every 5m, after this many consecutive failures; 0 disables")
+	cacheBackend := fs.String("cache-backend", "memory", "where finished analyses are cached: memory, or redis to share them across runs and replicas")
+	redisAddr := fs.String("redis-addr", "localhost:6379", "Redis server for --cache-backend redis")
+	cacheTTL := fs.Duration("cache-ttl", ebert.DefaultResultCacheTTL, "how long a cached analysis is served before the account is analyzed again")
+	denylist := fs.String("denylist", "", "YAML file of extra accounts to treat as known-compromised, merged with the built-in list")
+	maxRPS := fs.Float64("max-rps", ebert.DefaultMaxRequestsPerSecond, "most GitHub API requests per second; pacing spreads the remaining budget below this")
+	recurse := fs.Int("recurse", 0, fmt.Sprintf("also run a shallow analysis of each co-maintainer of the flagship repos, to this many hops (at most %d)", ebert.MaxCoMaintainerDepth))
+	members := fs.Int("members", ebert.DefaultOrgMembers, "with \"org\", how many public members to analyze")
+	annotations := fs.Bool("annotations", false, "emit GitHub Actions ::warning:: and ::error:: commands for each warning and red flag; on by default inside Actions")
+	noActions := fs.Bool("no-github-actions", false, "don't write a job summary, step outputs or annotations when running in GitHub Actions")
+	resolveAuthors := fs.Bool("resolve-authors", false, "with \"local\", map commit author emails to GitHub accounts (needs network and GITHUB_TOKEN)")
+	trustedRoot := fs.String("trusted-root", "", "with --deep, verify release signatures against this Sigstore trusted root JSON instead of the public-good instance's, fetched from tuf-repo-cdn.sigstore.dev; required with --offline")
+	exportURL := fs.String("export", "", "also archive the JSON analysis as <username>/<timestamp>.json under a directory, s3://bucket/prefix or gs://bucket/prefix")
+	exportStrict := fs.Bool("export-strict", false, "exit non-zero when --export fails")
+	dryRun := fs.Bool("dry-run", false, "look up the account, print the planned requests, their estimated cost and the remaining quota, and stop")
+	caBundle := fs.String("ca-bundle", "", "PEM file of extra trusted CA certificates, e.g. a TLS-intercepting proxy's; SSL_CERT_FILE is honored too")
+	insecure := fs.Bool("insecure-skip-verify", false, "DANGEROUS: don't verify TLS certificates, exposing the token to interception; for lab environments only")
+	record := fs.String("record", "", "record every API request and response to this tape file, with the token scrubbed")
+	replay := fs.String("replay", "", "answer every API request from this tape file, failing any it doesn't hold")
+	allowedHosts := fs.String("allowed-hosts", "", "comma-separated hosts requests may go to, e.g. api.github.com,registry.npmjs.org; checks needing any other are skipped")
+	offline := fs.Bool("offline", false, "refuse every network request, answering only from the --replay tape")
+	appID := fs.Int64("app-id", 0, "authenticate as this GitHub App's installation instead of with GITHUB_TOKEN; needs --app-private-key")
+	appPrivateKey := fs.String("app-private-key", "", "PEM private key of the --app-id App")
+	installationID := fs.Int64("installation-id", 0, "with --app-id, the installation to act as; by default the App's installation on the analyzed account or org")
+	strictAuth := fs.Bool("strict-auth", false, "fail when GitHub rejects GITHUB_TOKEN instead of continuing unauthenticated")
+	tui := fs.Bool("tui", false, "explore the analysis in an interactive terminal UI")
+	version := fs.Bool("version", false, "print the ebert version and exit")
+	verbose := fs.Bool("verbose", false, "log diagnostics, such as how each repo was classified, to stderr")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ebert.ExitError
+	}
+
+	if *version {
+		_, _ = fmt.Fprintf(stdout, "ebert %s\n", ebert.BuildVersion())
+		return ebert.ExitOK
+	}
+
+	batchMode := len(positional) > 1 && positional[0] == "batch"
+	switch *format {
+	case "text", "openmetrics":
+	case "json":
+		*jsonOut = true
+	case "jsonl", "csv":
+		if !batchMode {
+			_, _ = fmt.Fprintf(stderr, "Error: --format %s only applies to batch\n", *format)
+			return ebert.ExitError
+		}
+	default:
+		_, _ = fmt.Fprintf(stderr, "Error: --format must be text, json or openmetrics, got %q\n", *format)
+		return ebert.ExitError
+	}
+	metricsOut := *format == "openmetrics"
+	if *quiet && (*jsonOut || metricsOut || *tui || *dryRun) {
+		_, _ = fmt.Fprintln(stderr, "Error: --quiet can't be combined with --json, --format, --tui or --dry-run")
+		return ebert.ExitError
+	}
+
+	policy := ebert.FindingPolicy{WarningsAsErrors: *warningsAsErrors}
+	if *maxWarnings >= 0 {
+		policy.MaxWarnings = maxWarnings
+	}
+	if *failOnTrust >= 0 {
+		policy.FailOnTrust = failOnTrust
+	}
+	if *failOnAbandonment >= 0 {
+		policy.FailOnAbandonment = failOnAbandonment
+	}
+	if err := policy.SeverityThreshold.UnmarshalText([]byte(*severityThreshold)); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: --severity-threshold: %v\n", err)
+		return ebert.ExitError
+	}
+
+	var checkTarget checkRunTarget
+	if *checkRun != "" || *annotatePath != "" {
+		if checkTarget, err = parseCheckRunTarget(*checkRun, *annotatePath); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+	}
+
+	catalog, err := ebert.LoadCatalog(*lang, *catalogs)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+
+	// With a token and no target, the token's own account is analyzed;
+	// App installations have no account of their own
+	self := len(positional) == 0
+	if self && (token == "" || *appID != 0) {
+		fs.Usage()
+		return ebert.ExitError
+	}
+
+	if !self && positional[0] == "rules" {
+		return runRules(*jsonOut, stdout, stderr)
+	}
+	if !self && positional[0] == "view" && *bundle != "" {
+		return runViewBundle(*bundle, stdout, stderr, ebert.ReportOptions{Catalog: catalog, Expand: *expand})
+	}
+	if !self && positional[0] == "diff" {
+		if len(positional) != 3 {
+			_, _ = fmt.Fprintln(stderr, "Error: diff compares two reports or bundles")
+			return ebert.ExitError
+		}
+		return runDiff(positional[1], positional[2], stdout, stderr)
+	}
+	if !self && positional[0] == "view" && len(positional) > 1 {
+		return runView(positional[1], stderr)
+	}
+
+	if *maxRPS <= 0 {
+		_, _ = fmt.Fprintf(stderr, "Error: --max-rps must be positive, got %g\n", *maxRPS)
+		return ebert.ExitError
+	}
+
+	opts := []ebert.Option{
+		ebert.WithRequestStats(*debug),
+		ebert.WithGists(!*noGists),
+		ebert.WithDeepChecks(*deep),
+		ebert.WithExternalChecks(!*noExternal),
+		ebert.WithScoreAllRepos(*allRepos),
+		ebert.WithScoringVersion(*scoringVersion),
+		ebert.WithStarAgeWeighting(*starHorizon, *starFalloff),
+		ebert.WithInstallScripts(!*noInstallScripts),
+		ebert.WithVerifyArtifacts(*verifyArtifacts),
+		ebert.WithEngagementRings(*rings),
+		ebert.WithAnalysisTimeout(max(*timeout, 0)),
+		ebert.WithExternalTimeout(*externalTimeout),
+		ebert.WithCircuitBreaker(*breakerThreshold),
+		ebert.WithMaxRepos(*maxRepos),
+		ebert.WithCoMaintainerDepth(*recurse),
+		ebert.WithStrictAuth(*strictAuth),
+		ebert.WithSeed(*seed),
+		ebert.WithRequestRate(min(ebert.DefaultMinRequestsPerSecond, *maxRPS), *maxRPS),
+	}
+	cfg := &config{}
+	if *configPath != "" {
+		if cfg, err = loadConfig(*configPath); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+		if cfg.Weights != nil {
+			opts = append(opts, ebert.WithWeights(*cfg.Weights))
+		}
+	}
+	for _, glob := range strings.Split(*artifactIgnore, ",") {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			opts = append(opts, ebert.WithArtifactIgnore(glob))
+		}
+	}
+	for _, pattern := range strings.Split(*internalPatterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			opts = append(opts, ebert.WithInternalNamePatterns(pattern))
+		}
+	}
+	if *tutorialKeywordsFile != "" {
+		opts = append(opts, ebert.WithTutorialKeywordsFile(*tutorialKeywordsFile))
+	}
+	if *internalPatternsFile != "" {
+		opts = append(opts, ebert.WithInternalNamePatternsFile(*internalPatternsFile))
+	}
+	for _, override := range strings.Split(*integrationTimeouts, ",") {
+		if override = strings.TrimSpace(override); override == "" {
+			continue
+		}
+		host, value, _ := strings.Cut(override, "=")
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: --integration-timeouts: %q is not host=duration\n", override)
+			return ebert.ExitError
+		}
+		opts = append(opts, ebert.WithIntegrationTimeout(host, timeout))
+	}
+	if *denylist != "" {
+		entries, err := ebert.LoadDenylist(*denylist)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+		opts = append(opts, ebert.WithDenylist(entries...))
+	}
+	switch *cacheBackend {
+	case "memory":
+		opts = append(opts, ebert.WithResultCache(ebert.NewMemoryCache(), *cacheTTL))
+	case "redis":
+		cache := redisebert.New(*redisAddr)
+		defer func() { _ = cache.Close() }()
+		if err := cache.Ping(context.Background()); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: --cache-backend redis: %v\n", err)
+			return ebert.ExitError
+		}
+		opts = append(opts, ebert.WithResultCache(cache, *cacheTTL))
+	default:
+		_, _ = fmt.Fprintf(stderr, "Error: --cache-backend must be memory or redis, got %q\n", *cacheBackend)
+		return ebert.ExitError
+	}
+	if *record != "" && *replay != "" {
+		_, _ = fmt.Fprintln(stderr, "Error: --record and --replay can't be combined")
+		return ebert.ExitError
+	}
+	if *replay != "" && *appID != 0 {
+		_, _ = fmt.Fprintln(stderr, "Error: --replay can't be combined with --app-id")
+		return ebert.ExitError
+	}
+	var hosts []string
+	for _, host := range strings.Split(*allowedHosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	switch {
+	case *offline && *replay == "":
+		_, _ = fmt.Fprintln(stderr, "Error: --offline answers only from a --replay tape")
+		return ebert.ExitError
+	case *offline && len(hosts) > 0:
+		_, _ = fmt.Fprintln(stderr, "Error: --offline can't be combined with --allowed-hosts")
+		return ebert.ExitError
+	case *offline:
+		opts = append(opts, ebert.WithOffline())
+	case len(hosts) > 0:
+		opts = append(opts, ebert.WithAllowedHosts(hosts...))
+	}
+	transportOpts := ebert.TransportOptions{InsecureSkipVerify: *insecure}
+	if *caBundle != "" {
+		transportOpts.CABundles = []string{*caBundle}
+	}
+	transport, err := ebert.NewTransport(transportOpts)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	if *insecure {
+		_, _ = fmt.Fprintln(stderr, "WARNING: --insecure-skip-verify disables TLS certificate checks; the token and every response can be intercepted")
+	}
+	if *deep && !*noExternal {
+		var verifier *sigstoreebert.Verifier
+		switch {
+		case *trustedRoot != "":
+			if verifier, err = sigstoreebert.NewWithTrustedRoot(*trustedRoot); err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return ebert.ExitError
+			}
+		case *offline:
+			_, _ = fmt.Fprintln(stderr, "Error: --deep with --offline verifies release signatures only against a --trusted-root")
+			return ebert.ExitError
+		default:
+			// The public-good trusted root is fetched through the same
+			// transport and allowlist as every other request
+			verifierClient := &http.Client{Timeout: 30 * time.Second, Transport: transport}
+			if len(hosts) > 0 {
+				verifierClient.Transport = ebert.NewHostAllowlist(transport, hosts...)
+			}
+			verifier = sigstoreebert.NewWithClient(verifierClient)
+		}
+		opts = append(opts, ebert.WithProvenanceVerifier(verifier))
+	}
+	var roundTripper http.RoundTripper = transport
+	if *record != "" {
+		recorder := ebert.NewRecorder(transport, token)
+		roundTripper = recorder
+		defer func() {
+			if err := recorder.Save(*record); err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			}
+		}()
+	}
+	// Bundles log every request sent, timed by the replayed clock on replay
+	var requestLog *ebert.RequestLog
+	if *bundle != "" && *replay == "" {
+		requestLog = ebert.NewRequestLog(roundTripper, nil)
+		roundTripper = requestLog
+	}
+	if *replay != "" {
+		replayer, err := ebert.LoadTape(*replay)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+		// The recorded token is scrubbed; a placeholder runs the same
+		// token-gated checks the recording did
+		token = ""
+		if replayer.Authenticated() {
+			token = "replay"
+		}
+		recordedAt := replayer.RecordedAt()
+		var replayTransport http.RoundTripper = replayer
+		if *bundle != "" {
+			requestLog = ebert.NewRequestLog(replayer, func() time.Time { return recordedAt })
+			replayTransport = requestLog
+		}
+		opts = append(opts,
+			ebert.WithHTTPClient(&http.Client{Transport: replayTransport}),
+			ebert.WithClock(func() time.Time { return recordedAt }),
+			ebert.WithRequestRate(replayRequestRate, replayRequestRate),
+		)
+	} else {
+		apiClient := &http.Client{Timeout: 10 * time.Second, Transport: roundTripper}
+		opts = append(opts, ebert.WithHTTPClient(apiClient))
+		if *appID != 0 || *appPrivateKey != "" {
+			tokenClient := apiClient
+			if len(hosts) > 0 {
+				tokenClient = &http.Client{Timeout: apiClient.Timeout, Transport: ebert.NewHostAllowlist(roundTripper, hosts...)}
+			}
+			source, err := newAppTokenSource(*appID, *appPrivateKey, *installationID, positional, tokenClient)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+				return ebert.ExitError
+			}
+			opts = append(opts, ebert.WithTokenSource(source))
+		}
+	}
+	if *verbose {
+		opts = append(opts, ebert.WithLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+
+	exp, err := openExporter(*exportURL, *exportStrict, stderr)
+	if err != nil {
+		if *exportStrict {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+	defer exp.close()
+
+	analyzer, err := ebert.New(token, opts...)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	defer func() {
+		if analyzer.TokenRejected() {
+			_, _ = fmt.Fprintln(stderr, "Warning: GITHUB_TOKEN appears expired or revoked - continued unauthenticated; pass --strict-auth to fail instead")
+		}
+		if tripped := analyzer.TrippedIntegrations(); len(tripped) > 0 {
+			_, _ = fmt.Fprintf(stderr, "Warning: skipped %s after repeated failures; checks relying on them ran without their data\n", strings.Join(tripped, ", "))
+		}
+	}()
+	if self {
+		login, err := analyzer.AuthenticatedLogin(context.Background())
+		if err != nil {
+			return failed(stderr, err)
+		}
+		positional = []string{login}
+	}
+	monitorMode := positional[0] == "monitor" && len(positional) > 1
+	// Rules from the config file apply wherever there is a previous
+	// analysis to compare with; --alerts asks for one
+	rules := slices.Clone(cfg.Alerts)
+	var flagRules int
+	for _, spec := range strings.Split(*alertRules, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		rule, err := ebert.ParseAlertRule(spec)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: --alerts: %v\n", err)
+			return ebert.ExitError
+		}
+		for _, webhook := range strings.Split(*alertWebhooks, ",") {
+			if webhook = strings.TrimSpace(webhook); webhook != "" {
+				rule.Webhooks = append(rule.Webhooks, webhook)
+			}
+		}
+		rules = append(rules, rule)
+		flagRules++
+	}
+	switch {
+	case flagRules > 0 && *baselineRaw == "" && !monitorMode:
+		_, _ = fmt.Fprintln(stderr, "Error: --alerts compares with the previous analysis given by --baseline-raw or kept by monitor")
+		return ebert.ExitError
+	case *alertWebhooks != "" && flagRules == 0:
+		_, _ = fmt.Fprintln(stderr, "Error: --alert-webhook needs --alerts")
+		return ebert.ExitError
+	case *alertWebhooks != "" && *offline:
+		_, _ = fmt.Fprintln(stderr, "Error: --alert-webhook can't be combined with --offline")
+		return ebert.ExitError
+	case *store != "" && !monitorMode:
+		_, _ = fmt.Fprintln(stderr, "Error: --store only applies to monitor")
+		return ebert.ExitError
+	}
+	var alertTransport http.RoundTripper = transport
+	if len(hosts) > 0 {
+		alertTransport = ebert.NewHostAllowlist(transport, hosts...)
+	}
+	alerter := &alertSender{client: &http.Client{Timeout: 10 * time.Second, Transport: alertTransport}, offline: *offline, stderr: stderr}
+
+	if positional[0] == "calibrate" && len(positional) > 1 {
+		if *tune {
+			// An unset seed lets analyses derive theirs; tuning keeps its default of 1
+			tuneOpts := ebert.TuneOptions{Iterations: *tuneIterations, Seed: cmp.Or(*seed, 1), MinWeight: *minWeight}
+			return runTune(analyzer, positional[1], tuneOpts, *writeConfig, *jsonOut, stdout, stderr)
+		}
+		return runCalibrate(analyzer, positional[1], *jsonOut, stdout, stderr)
+	}
+	if batchMode {
+		batchFormat := *format
+		if *jsonOut {
+			batchFormat = "json"
+		}
+		return runBatch(analyzer, positional[1], batchFormat, *raw, *rawDir, *allowPartial, exp, stdout, stderr)
+	}
+	if monitorMode {
+		return runMonitor(analyzer, positional[1], *store, *interval, rules, alerter, exp, stdout, stderr)
+	}
+	if positional[0] == "local" && len(positional) > 1 {
+		return runLocal(analyzer, positional[1], *resolveAuthors, *jsonOut, stdout, stderr)
+	}
+
+	orgMode := positional[0] == "org" && len(positional) > 1
+	if orgMode {
+		positional = positional[1:]
+	}
+
+	username := ebert.NormalizeUsername(positional[0])
+	if err := ebert.ValidateUsername(username); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+
+	if *dryRun {
+		return runDryRun(analyzer, username, orgMode, *members, *jsonOut, stdout, stderr)
+	}
+
+	if metricsOut && (orgMode || *tui) {
+		_, _ = fmt.Fprintln(stderr, "Error: --format openmetrics only applies to single-account and batch analyses")
+		return ebert.ExitError
+	}
+	if *quiet && orgMode {
+		_, _ = fmt.Fprintln(stderr, "Error: --quiet only applies to single-account analyses")
+		return ebert.ExitError
+	}
+	if orgMode && *checkRun != "" {
+		_, _ = fmt.Fprintln(stderr, "Error: --check-run only applies to single-account analyses")
+		return ebert.ExitError
+	}
+	if orgMode && policy != (ebert.FindingPolicy{}) {
+		_, _ = fmt.Fprintln(stderr, "Error: --warnings-as-errors, --max-warnings, --severity-threshold and --fail-on-* only apply to single-account analyses")
+		return ebert.ExitError
+	}
+	if orgMode && *bundle != "" {
+		_, _ = fmt.Fprintln(stderr, "Error: --bundle only applies to single-account analyses")
+		return ebert.ExitError
+	}
+	if orgMode && *baselineRaw != "" {
+		_, _ = fmt.Fprintln(stderr, "Error: --baseline-raw only applies to single-account analyses")
+		return ebert.ExitError
+	}
+	if orgMode {
+		return cmp.Or(runOrg(analyzer, username, *members, *jsonOut, *allowPartial, exp, stdout, stderr), exp.exitCode())
+	}
+
+	// Refuse before spending requests on an analysis that can't be shown
+	if *tui {
+		if err := tuiebert.CheckTerminal(); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+	}
+
+	var baseline *ebert.DetailedAnalysis
+	if *baselineRaw != "" {
+		if baseline, err = loadReport(*baselineRaw); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: --baseline-raw: %v\n", err)
+			return ebert.ExitError
+		}
+		if !strings.EqualFold(baseline.User.Login, username) {
+			_, _ = fmt.Fprintf(stderr, "Error: --baseline-raw is a report on %s, not %s\n", baseline.User.Login, username)
+			return ebert.ExitError
+		}
+	}
+
+	var analysis *ebert.Analysis
+	var rawData *ebert.RawData
+	var detailed *ebert.DetailedAnalysis
+	if *raw || *tui || baseline != nil || *bundle != "" {
+		if baseline != nil {
+			detailed, err = analyzer.Reanalyze(baseline)
+		} else {
+			detailed, err = analyzer.AnalyzeDetailed(username)
+		}
+		if detailed != nil {
+			analysis = detailed.Analysis
+			if *raw || *tui {
+				rawData = detailed.Raw
+			}
+		}
+	} else {
+		analysis, err = analyzer.Analyze(username)
+	}
+	if analysis == nil {
+		return failed(stderr, err)
+	}
+	analysis.SelfAnalysis = self
+
+	if *bundle != "" {
+		if err := writeBundle(*bundle, detailed, requestLog.Requests()); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+	}
+
+	if *tui {
+		return explore(analysis, rawData, stderr)
+	}
+
+	report := analysis
+	if *stable {
+		report = ebert.StableAnalysis(analysis)
+	}
+	var out any = report
+	if rawData != nil {
+		out = &ebert.DetailedAnalysis{Analysis: report, Raw: rawData}
+	}
+	exp.put(username, analysis.Timestamp, out)
+
+	// Write the report in the chosen format
+	switch {
+	case *quiet:
+		if err := ebert.WriteBrief(stdout, report); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+	case metricsOut:
+		if err := ebert.WriteOpenMetrics(stdout, []*ebert.Analysis{report}); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error writing metrics: %v\n", err)
+			return ebert.ExitError
+		}
+	case *jsonOut:
+		jsonData, marshalErr := json.MarshalIndent(out, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", marshalErr)
+			return ebert.ExitError
+		}
+
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+	default:
+		if self {
+			_, _ = fmt.Fprintf(stdout, "Analyzing your own GitHub account: %s\n", username)
+		} else {
+			_, _ = fmt.Fprintf(stdout, "Analyzing GitHub user: %s\n", username)
+		}
+		_, _ = fmt.Fprintln(stdout, "Fetching data from GitHub API...")
+		ebert.FprintReport(stdout, ebert.FilterFindings(analysis, ebert.FindingPolicy{SeverityThreshold: policy.SeverityThreshold}),
+			ebert.ReportOptions{Catalog: catalog, Expand: *expand})
+	}
+
+	// CI consumers see warnings promoted when asked
+	gated := ebert.FilterFindings(analysis, policy)
+
+	var actions *githubActions
+	if !*noActions {
+		actions = detectGitHubActions()
+	}
+	if actions != nil {
+		if err := actions.report(gated); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+	}
+	if *annotations || actions != nil {
+		// Keep JSON on stdout parseable; the runner reads commands from both
+		out := stdout
+		if *jsonOut || metricsOut || *quiet {
+			out = stderr
+		}
+		writeAnnotations(out, gated)
+	}
+
+	if *checkRun != "" {
+		if err := analyzer.CreateCheckRun(context.Background(), checkTarget.owner, checkTarget.repo, checkTarget.sha, analysis, checkTarget.manifest, policy); err != nil {
+			return failed(stderr, err)
+		}
+	}
+
+	if baseline != nil {
+		if err := alerter.send(context.Background(), ebert.EvaluateAlerts(baseline.Analysis, analysis, rules)); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Partial analysis (%s), missing %s: %v\n", ebert.ExitCategory(ebert.PartialExitCode(err)), strings.Join(analysis.MissingSources(), ", "), err)
+	}
+	switch code := ebert.AnalysisExitCode(analysis, err, policy, *allowPartial); code {
+	case ebert.ExitOK:
+		return exp.exitCode()
+	case ebert.ExitPolicyViolation:
+		return failed(stderr, policy.Check(analysis))
+	default:
+		return code
+	}
+}
+
+// newAppTokenSource authenticates as the installation of a GitHub App,
+// discovered on the analyzed account or org when no installation ID is
+// given
+func newAppTokenSource(appID int64, keyPath string, installationID int64, positional []string, client *http.Client) (*ebert.AppTokenSource, error) {
+	if appID == 0 || keyPath == "" {
+		return nil, errors.New("--app-id and --app-private-key must be given together")
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read app private key: %w", err)
+	}
+	source, err := ebert.NewAppTokenSource(appID, key)
+	if err != nil {
+		return nil, err
+	}
+	source.InstallationID = installationID
+	source.HTTPClient = client
+	if len(positional) > 0 {
+		source.Account = positional[0]
+		if positional[0] == "org" && len(positional) > 1 {
+			source.Account = positional[1]
+		}
+	}
+	return source, nil
+}
+
+// checkRunTarget is the commit and manifest line --check-run and
+// --annotate-path name
+type checkRunTarget struct {
+	owner, repo, sha string
+	manifest         ebert.AnnotationTarget
+}
+
+// parseCheckRunTarget parses --check-run owner/repo@sha and the
+// --annotate-path it needs
+func parseCheckRunTarget(checkRun, annotatePath string) (checkRunTarget, error) {
+	if checkRun == "" || annotatePath == "" {
+		return checkRunTarget{}, errors.New("--check-run and --annotate-path must be given together")
+	}
+	repo, sha, _ := strings.Cut(checkRun, "@")
+	owner, name, _ := strings.Cut(repo, "/")
+	if owner == "" || name == "" || sha == "" || strings.Contains(name, "/") {
+		return checkRunTarget{}, fmt.Errorf("--check-run: %q is not owner/repo@sha", checkRun)
+	}
+	manifest, err := ebert.ParseAnnotationTarget(annotatePath)
+	if err != nil {
+		return checkRunTarget{}, fmt.Errorf("--annotate-path: %w", err)
+	}
+	return checkRunTarget{owner: owner, repo: name, sha: sha, manifest: manifest}, nil
+}
+
+// runOrg analyzes an organization and its public members
+func runOrg(analyzer *ebert.Analyzer, org string, members int, jsonOut, allowPartial bool, exp *exporter, stdout, stderr io.Writer) int {
+	result, err := analyzer.AnalyzeOrgMembers(org, members)
+	if result == nil {
+		return failed(stderr, err)
+	}
+
+	var at time.Time
+	if result.Org != nil {
+		at = result.Org.Timestamp
+	}
+	exp.put(org, at, result)
+
+	if jsonOut {
+		jsonData, marshalErr := json.MarshalIndent(result, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", marshalErr)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+	} else {
+		_, _ = fmt.Fprintf(stdout, "Analyzing GitHub organization: %s\n", org)
+		ebert.FprintOrgAnalysis(stdout, result)
+	}
+
+	if err != nil {
+		code := ebert.PartialExitCode(err)
+		_, _ = fmt.Fprintf(stderr, "Partial organization analysis (%s): %v\n", ebert.ExitCategory(code), err)
+		if !allowPartial {
+			return code
+		}
+	}
+	return ebert.ExitOK
+}
+
+// runCalibrate analyzes the accounts in a labels file and reports how
+// well their risk levels match the labels
+func runCalibrate(analyzer *ebert.Analyzer, path string, jsonOut bool, stdout, stderr io.Writer) int {
+	labels, err := ebert.LoadLabels(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+
+	report := ebert.Calibrate(analyzer.AnalyzeLabeled(context.Background(), labels))
+	if jsonOut {
+		jsonData, marshalErr := json.MarshalIndent(report, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", marshalErr)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+	} else {
+		_, _ = fmt.Fprintf(stdout, "Calibrating against %d labeled accounts\n", len(labels))
+		ebert.FprintCalibration(stdout, report)
+	}
+	if report.Analyzed == 0 {
+		_, _ = fmt.Fprintln(stderr, "Error: no labeled account could be analyzed")
+		return ebert.ExitError
+	}
+	return ebert.ExitOK
+}
+
+// runTune analyzes each labeled account once, then searches for the
+// weights that best separate them, optionally saving them to a config file
+func runTune(analyzer *ebert.Analyzer, path string, opts ebert.TuneOptions, configPath string, jsonOut bool, stdout, stderr io.Writer) int {
+	if opts.Iterations <= 0 {
+		_, _ = fmt.Fprintf(stderr, "Error: --tune-iterations must be positive, got %d\n", opts.Iterations)
+		return ebert.ExitError
+	}
+	labels, err := ebert.LoadLabels(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+
+	result, err := ebert.TuneWeights(analyzer.AnalyzeLabeled(context.Background(), labels), opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	if jsonOut {
+		jsonData, marshalErr := json.MarshalIndent(result, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", marshalErr)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+	} else {
+		_, _ = fmt.Fprintf(stdout, "Tuning weights against %d labeled accounts\n", len(labels))
+		ebert.FprintTuning(stdout, result)
+	}
+
+	if configPath != "" {
+		if err := writeConfigWeights(configPath, result.Weights); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+			return ebert.ExitError
+		}
+		if !jsonOut {
+			_, _ = fmt.Fprintf(stdout, "Wrote the tuned weights to %s; pass --config %s to score with them\n", configPath, configPath)
+		}
+	}
+	return ebert.ExitOK
+}
+
+// runLocal analyzes a local git checkout, optionally resolving its
+// authors to GitHub accounts afterwards
+func runLocal(analyzer *ebert.Analyzer, path string, resolveAuthors, jsonOut bool, stdout, stderr io.Writer) int {
+	report, err := analyzer.AnalyzeLocal(path)
+	if err != nil {
+		return failed(stderr, err)
+	}
+
+	if resolveAuthors {
+		if err := analyzer.ResolveLocalAuthors(context.Background(), report); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if jsonOut {
+		jsonData, marshalErr := json.MarshalIndent(report, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", marshalErr)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+	} else {
+		ebert.FprintRepoAnalysis(stdout, report)
+	}
+	return ebert.ExitOK
+}
+
+// runDryRun prints what analyzing username would cost without doing it
+func runDryRun(analyzer *ebert.Analyzer, username string, orgMode bool, members int, jsonOut bool, stdout, stderr io.Writer) int {
+	plan := analyzer.Plan
+	if orgMode {
+		plan = func(ctx context.Context, org string) (*ebert.GitHubUser, ebert.CostEstimate, error) {
+			return analyzer.PlanOrg(ctx, org, members)
+		}
+	}
+	user, estimate, err := plan(context.Background(), username)
+	if err != nil {
+		return failed(stderr, err)
+	}
+
+	if jsonOut {
+		jsonData, marshalErr := json.MarshalIndent(estimate, "", "  ")
+		if marshalErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", marshalErr)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+	} else {
+		ebert.FprintCostEstimate(stdout, user, estimate)
+	}
+	return ebert.ExitOK
+}
+
+// runRules lists the built-in rules and the finding codes they emit
+func runRules(jsonOut bool, stdout, stderr io.Writer) int {
+	rules := ebert.BuiltinRules()
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Code() < rules[j].Code() })
+
+	if jsonOut {
+		type ruleInfo struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		}
+		infos := make([]ruleInfo, 0, len(rules))
+		for _, rule := range rules {
+			infos = append(infos, ruleInfo{Code: rule.Code(), Description: rule.Description()})
+		}
+		jsonData, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error marshaling JSON: %v\n", err)
+			return ebert.ExitError
+		}
+		_, _ = fmt.Fprintln(stdout, string(jsonData))
+		return ebert.ExitOK
+	}
+
+	width := 0
+	for _, rule := range rules {
+		width = max(width, len(rule.Code()))
+	}
+	for _, rule := range rules {
+		_, _ = fmt.Fprintf(stdout, "%-*s  %s\n", width, rule.Code(), rule.Description())
+	}
+	return ebert.ExitOK
+}
+
+// parseArgs parses flags that may appear before or after positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	_, _ = fmt.Fprintln(w, "Usage: ebert [github-username] [flags]")
+	_, _ = fmt.Fprintln(w, "       ebert org <github-org> [--members N] [flags]")
+	_, _ = fmt.Fprintln(w, "       ebert local <path> [--resolve-authors] [flags]")
+	_, _ = fmt.Fprintln(w, "       ebert batch <logins.txt> [--format text|json|jsonl|csv|openmetrics] [--raw --raw-dir <dir>]")
+	_, _ = fmt.Fprintln(w, "       ebert monitor <logins.txt> --store <dir> [--interval 24h] [--alerts ...]")
+	_, _ = fmt.Fprintln(w, "       ebert rules [--json]")
+	_, _ = fmt.Fprintln(w, "       ebert view <report.json|bundle.tar.gz>")
+	_, _ = fmt.Fprintln(w, "       ebert view --bundle <bundle.tar.gz>")
+	_, _ = fmt.Fprintln(w, "       ebert diff <before> <after>")
+	_, _ = fmt.Fprintln(w, "       ebert calibrate <labels.yaml> [--json]")
+	_, _ = fmt.Fprintln(w, "       ebert calibrate --tune <labels.yaml> [--seed N] [--write-config config.json]")
+	_, _ = fmt.Fprintln(w, "Example: ebert modelcontextprotocol")
+	_, _ = fmt.Fprintln(w, "\nOptional: Set GITHUB_TOKEN environment variable for higher rate limits;")
+	_, _ = fmt.Fprintln(w, "with it set and no username, ebert analyzes the token's own account")
+	_, _ = fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+}
+
+// failed prints err under its exit category and returns its exit code
+func failed(stderr io.Writer, err error) int {
+	code := ebert.ExitCode(err)
+	_, _ = fmt.Fprintf(stderr, "Error (%s): %v\n", ebert.ExitCategory(code), err)
+	return code
+}
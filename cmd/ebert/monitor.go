@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// runMonitor reanalyzes the logins in a file every interval until
+// interrupted, alerting on the changes rules describe. Each account's
+// latest report and raw data are kept under store as the baseline of its
+// next run, so a restarted monitor carries on where the last one stopped.
+// SIGINT and SIGTERM stop it between accounts, or cut short the analysis
+// under way, which then leaves its stored baseline as it was.
+func runMonitor(analyzer *ebert.Analyzer, path, store string, interval time.Duration, rules []ebert.AlertRule, alerter *alertSender, exp *exporter, stdout, stderr io.Writer) int {
+	if store == "" {
+		_, _ = fmt.Fprintln(stderr, "Error: monitor compares each run with the last; give the directory it keeps them in with --store")
+		return ebert.ExitError
+	}
+	if interval <= 0 {
+		_, _ = fmt.Fprintf(stderr, "Error: --interval must be positive, got %s\n", interval)
+		return ebert.ExitError
+	}
+	logins, err := ebert.LoadLogins(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	if err := os.MkdirAll(store, 0o755); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: failed to create store directory: %v\n", err)
+		return ebert.ExitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for {
+		for _, login := range logins {
+			if ctx.Err() != nil {
+				break
+			}
+			monitorAccount(ctx, analyzer, store, login, rules, alerter, exp, stdout, stderr)
+		}
+
+		// Monitors started together drift apart instead of hitting the
+		// API at the same moment every run
+		wait := jittered(interval)
+		timer := time.NewTimer(wait)
+		if ctx.Err() == nil {
+			_, _ = fmt.Fprintf(stderr, "Next run at %s\n", time.Now().Add(wait).Format(time.RFC3339))
+		}
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			_, _ = fmt.Fprintln(stderr, "Stopped monitoring")
+			return exp.exitCode()
+		case <-timer.C:
+		}
+	}
+}
+
+// monitorAccount reanalyzes one account from its stored baseline, or
+// analyzes it afresh the first time, alerts on what changed and stores
+// the new analysis. A failed or partial analysis is reported and keeps
+// the previous baseline, so missing data never raises an alert.
+func monitorAccount(ctx context.Context, analyzer *ebert.Analyzer, store, login string, rules []ebert.AlertRule, alerter *alertSender, exp *exporter, stdout, stderr io.Writer) {
+	file := filepath.Join(store, login+".json")
+	baseline, err := loadReport(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		_, _ = fmt.Fprintf(stderr, "Warning: %s: %v; analyzing afresh\n", login, err)
+	case !strings.EqualFold(baseline.User.Login, login) || baseline.Raw == nil:
+		_, _ = fmt.Fprintf(stderr, "Warning: %s: %s isn't its raw report; analyzing afresh\n", login, file)
+		baseline = nil
+	}
+
+	var detailed *ebert.DetailedAnalysis
+	if baseline != nil {
+		detailed, err = analyzer.ReanalyzeContext(ctx, baseline)
+	} else {
+		detailed, err = analyzer.AnalyzeDetailedContext(ctx, login)
+	}
+	switch {
+	case ctx.Err() != nil:
+		return
+	case detailed == nil:
+		_, _ = fmt.Fprintf(stderr, "Error: %s: %v\n", login, err)
+		return
+	case err != nil:
+		_, _ = fmt.Fprintf(stderr, "Warning: %s: partial analysis, kept the previous baseline: %v\n", login, err)
+		return
+	}
+
+	_ = ebert.WriteBrief(stdout, detailed.Analysis)
+	exp.put(login, detailed.Timestamp, detailed.Analysis)
+	if baseline != nil {
+		if err := alerter.send(ctx, ebert.EvaluateAlerts(baseline.Analysis, detailed.Analysis, rules)); err != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: %s: %v\n", login, err)
+		}
+	}
+	if err := writeBaseline(file, detailed); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %s: %v\n", login, err)
+	}
+}
+
+// writeBaseline replaces the stored report at file in one rename, so a
+// monitor stopped mid-write never leaves half a baseline behind
+func writeBaseline(file string, report *ebert.DetailedAnalysis) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// jittered is interval moved by up to a tenth either way
+func jittered(interval time.Duration) time.Duration {
+	return interval + time.Duration((rand.Float64()*2-1)*float64(interval)/10)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"github.com/JamesWoolfenden/ebert/pkg/ebert/tuiebert"
+)
+
+// runView opens a saved JSON report in the terminal explorer. Reports
+// saved with --raw also fill the repos table.
+func runView(path string, stderr io.Writer) int {
+	report, err := loadReport(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	return explore(report.Analysis, report.Raw, stderr)
+}
+
+// runViewBundle prints the report an audit bundle records, as it was
+// printed when the bundle was written
+func runViewBundle(path string, stdout, stderr io.Writer, opts ebert.ReportOptions) int {
+	report, err := loadReport(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	ebert.FprintReport(stdout, report.Analysis, opts)
+	return ebert.ExitOK
+}
+
+// gzipMagic opens every gzip stream, and so every audit bundle
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// loadReport reads a JSON report, with its raw data when saved with --raw,
+// or the report an audit bundle written with --bundle records
+func loadReport(path string) (*ebert.DetailedAnalysis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		bundle, err := ebert.ReadBundle(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return bundle.Report, nil
+	}
+	var report ebert.DetailedAnalysis
+	if err := json.Unmarshal(data, &report); err != nil || report.Analysis == nil {
+		return nil, fmt.Errorf("%s is not an ebert JSON report", path)
+	}
+	return &report, nil
+}
+
+// writeBundle saves the audit bundle of an analysis to path
+func writeBundle(path string, report *ebert.DetailedAnalysis, requests []ebert.LoggedRequest) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	if err := ebert.WriteBundle(f, report, requests); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// explore runs the terminal explorer over an analysis
+func explore(analysis *ebert.Analysis, raw *ebert.RawData, stderr io.Writer) int {
+	var repos []ebert.GitHubRepo
+	if raw != nil {
+		repos = raw.Repos
+	}
+	if err := tuiebert.Run(analysis, repos); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return ebert.ExitError
+	}
+	return ebert.ExitOK
+}
@@ -0,0 +1,260 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AlertKind is the change between two analyses an AlertRule fires on
+type AlertKind string
+
+const (
+	// AlertNewRedFlag fires on a red flag code the previous analysis lacked
+	AlertNewRedFlag AlertKind = "new_red_flag"
+
+	// AlertScoreRise fires when the overall risk score rose by more than
+	// the rule's threshold, the account looking that much riskier
+	AlertScoreRise AlertKind = "score_rise"
+
+	// AlertRiskBand fires when the risk level changed either way
+	AlertRiskBand AlertKind = "risk_band"
+
+	// AlertFlagshipArchived fires on a flagship repo newly archived
+	AlertFlagshipArchived AlertKind = "flagship_archived"
+
+	// AlertNewCoMaintainer fires on an account newly sharing the flagships
+	AlertNewCoMaintainer AlertKind = "new_co_maintainer"
+)
+
+// alertKinds are the kinds ParseAlertRule accepts, in documentation order
+var alertKinds = []AlertKind{AlertNewRedFlag, AlertScoreRise, AlertRiskBand, AlertFlagshipArchived, AlertNewCoMaintainer}
+
+// AlertRule is one change worth a notification. Threshold is the points
+// AlertScoreRise needs; Webhooks are the URLs SendAlerts posts to.
+type AlertRule struct {
+	Kind      AlertKind `json:"kind"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Webhooks  []string  `json:"webhooks,omitempty"`
+}
+
+// ParseAlertRule parses "kind" or "kind=threshold", e.g. "score_rise=10"
+func ParseAlertRule(s string) (AlertRule, error) {
+	name, value, hasValue := strings.Cut(strings.TrimSpace(s), "=")
+	rule := AlertRule{Kind: AlertKind(strings.ToLower(name))}
+	if !slices.Contains(alertKinds, rule.Kind) {
+		return AlertRule{}, unknownAlert(name)
+	}
+
+	switch {
+	case rule.Kind == AlertScoreRise && !hasValue:
+		return AlertRule{}, fmt.Errorf("alert %s needs a threshold, e.g. %s=10", rule.Kind, rule.Kind)
+	case rule.Kind != AlertScoreRise && hasValue:
+		return AlertRule{}, fmt.Errorf("alert %s takes no threshold", rule.Kind)
+	case hasValue:
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 {
+			return AlertRule{}, fmt.Errorf("alert %s threshold %q must be a non-negative number", rule.Kind, value)
+		}
+		rule.Threshold = threshold
+	}
+	return rule, nil
+}
+
+// Validate checks a rule read from a config file: a known kind, a
+// threshold only on AlertScoreRise, and http(s) webhooks
+func (r AlertRule) Validate() error {
+	if !slices.Contains(alertKinds, r.Kind) {
+		return unknownAlert(string(r.Kind))
+	}
+	switch {
+	case r.Threshold < 0:
+		return fmt.Errorf("alert %s threshold must be a non-negative number", r.Kind)
+	case r.Kind != AlertScoreRise && r.Threshold != 0:
+		return fmt.Errorf("alert %s takes no threshold", r.Kind)
+	}
+	for i, webhook := range r.Webhooks {
+		// The URL itself is usually the secret, so only its position is named
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("alert %s webhook %d is not an http(s) URL", r.Kind, i+1)
+		}
+	}
+	return nil
+}
+
+// unknownAlert is the error naming the kinds an unknown one isn't
+func unknownAlert(name string) error {
+	names := make([]string, len(alertKinds))
+	for i, kind := range alertKinds {
+		names[i] = string(kind)
+	}
+	return fmt.Errorf("unknown alert %q; known: %s", name, strings.Join(names, ", "))
+}
+
+// Alert is a rule that fired on an account
+type Alert struct {
+	Rule     AlertRule `json:"rule"`
+	Login    string    `json:"login"`
+	Message  string    `json:"message"`
+	Evidence []string  `json:"evidence,omitempty"`
+}
+
+func (a Alert) String() string {
+	text := fmt.Sprintf("@%s: %s", a.Login, a.Message)
+	if len(a.Evidence) > 0 {
+		text += " (" + strings.Join(a.Evidence, ", ") + ")"
+	}
+	return text
+}
+
+// EvaluateAlerts compares consecutive analyses of one account and returns
+// the alerts its rules raise, in rule order. Without a previous analysis
+// nothing has changed and no alert fires.
+func EvaluateAlerts(prev, curr *Analysis, rules []AlertRule) []Alert {
+	if prev == nil || curr == nil {
+		return nil
+	}
+
+	var alerts []Alert
+	fire := func(rule AlertRule, evidence []string, format string, args ...any) {
+		alerts = append(alerts, Alert{Rule: rule, Login: curr.User.Login, Message: fmt.Sprintf(format, args...), Evidence: evidence})
+	}
+	for _, rule := range rules {
+		switch rule.Kind {
+		case AlertNewRedFlag:
+			if added := newEntries(redFlagCodes(prev), redFlagCodes(curr)); len(added) > 0 {
+				fire(rule, added, "new red flags since the last analysis")
+			}
+		case AlertScoreRise:
+			if rise := curr.OverallScore - prev.OverallScore; rise > rule.Threshold {
+				fire(rule, nil, "risk score rose %.1f points, from %.1f to %.1f", rise, prev.OverallScore, curr.OverallScore)
+			}
+		case AlertRiskBand:
+			if prev.RiskLevel != "" && curr.RiskLevel != prev.RiskLevel {
+				fire(rule, nil, "risk level changed from %s to %s", prev.RiskLevel, curr.RiskLevel)
+			}
+		case AlertFlagshipArchived:
+			if added := newEntries(archivedFlagships(prev), archivedFlagships(curr)); len(added) > 0 {
+				fire(rule, added, "flagship repos archived since the last analysis")
+			}
+		case AlertNewCoMaintainer:
+			if added := newEntries(coMaintainerLogins(prev), coMaintainerLogins(curr)); len(added) > 0 {
+				fire(rule, added, "new co-maintainers of the flagship repos")
+			}
+		}
+	}
+	return alerts
+}
+
+// newEntries lists the entries of curr missing from prev, in curr's order
+func newEntries(prev, curr []string) []string {
+	var added []string
+	for _, entry := range curr {
+		if !slices.Contains(prev, entry) && !slices.Contains(added, entry) {
+			added = append(added, entry)
+		}
+	}
+	return added
+}
+
+// redFlagCodes lists the codes of a's red flags
+func redFlagCodes(a *Analysis) []string {
+	var codes []string
+	for _, finding := range a.Findings {
+		if finding.Severity == SeverityRedFlag {
+			codes = append(codes, finding.Code)
+		}
+	}
+	return codes
+}
+
+// archivedFlagships lists the repos a's FLAGSHIP_ARCHIVED finding names,
+// without the star counts that drift between runs
+func archivedFlagships(a *Analysis) []string {
+	var repos []string
+	for _, finding := range a.Findings {
+		if finding.Code != "FLAGSHIP_ARCHIVED" {
+			continue
+		}
+		for _, item := range finding.Evidence {
+			name, _, _ := strings.Cut(item, " (")
+			repos = append(repos, name)
+		}
+	}
+	return repos
+}
+
+// coMaintainerLogins lists a's co-maintainers
+func coMaintainerLogins(a *Analysis) []string {
+	logins := make([]string, 0, len(a.CoMaintainers))
+	for _, cm := range a.CoMaintainers {
+		logins = append(logins, strings.ToLower(cm.Login))
+	}
+	return logins
+}
+
+// SendAlerts posts each alert as JSON to its rule's webhooks, or through
+// the shared client if hc is nil. The body carries the alert under
+// "alert" and a one-line summary under "text", which Slack and Teams
+// incoming webhooks display as is.
+func SendAlerts(ctx context.Context, hc *http.Client, alerts []Alert) error {
+	if hc == nil {
+		hc = defaultHTTPClient
+	}
+	var errs []error
+	for _, alert := range alerts {
+		// No webhook learns the others
+		payload := alert
+		payload.Rule.Webhooks = nil
+		body, err := json.Marshal(struct {
+			Text  string `json:"text"`
+			Alert Alert  `json:"alert"`
+		}{alert.String(), payload})
+		if err != nil {
+			return fmt.Errorf("failed to encode alert: %w", err)
+		}
+		for _, webhook := range alert.Rule.Webhooks {
+			if err := postWebhook(ctx, hc, webhook, body); err != nil {
+				// The URL itself is usually the secret
+				errs = append(errs, fmt.Errorf("failed to notify %s: %w", externalHost(webhook), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postWebhook sends one JSON body to a webhook
+func postWebhook(ctx context.Context, hc *http.Client, webhook string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", externalUserAgent())
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		// Drop the URL the client error repeats
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return connectionHint(err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// alertSnapshot is an analysis of alice carrying just what rules compare
+func alertSnapshot(score float64, risk string, findings []Finding, coMaintainers ...string) *Analysis {
+	a := &Analysis{User: GitHubUser{Login: "alice"}, OverallScore: score, RiskLevel: risk, Findings: findings}
+	for _, login := range coMaintainers {
+		a.CoMaintainers = append(a.CoMaintainers, CoMaintainer{Login: login})
+	}
+	return a
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	redFlag := func(code string) Finding { return Finding{Code: code, Severity: SeverityRedFlag} }
+	archived := func(repos ...string) Finding {
+		return Finding{Code: "FLAGSHIP_ARCHIVED", Severity: SeverityWarning, Evidence: repos}
+	}
+	base := alertSnapshot(40, "medium", []Finding{redFlag("NO_2FA"), {Code: "FEW_REPOS", Severity: SeverityWarning}, archived("alice/old (120 stars)")}, "bob")
+
+	for _, tt := range []struct {
+		name     string
+		prev     *Analysis
+		curr     *Analysis
+		rule     AlertRule
+		evidence []string // nil when the rule shouldn't fire
+	}{
+		{"new red flag", base, alertSnapshot(40, "medium", []Finding{redFlag("NO_2FA"), redFlag("TAKEOVER")}), AlertRule{Kind: AlertNewRedFlag}, []string{"TAKEOVER"}},
+		{"same red flags", base, alertSnapshot(40, "medium", []Finding{redFlag("NO_2FA")}), AlertRule{Kind: AlertNewRedFlag}, nil},
+		{"warning raised to red flag", base, alertSnapshot(40, "medium", []Finding{redFlag("FEW_REPOS")}), AlertRule{Kind: AlertNewRedFlag}, []string{"FEW_REPOS"}},
+		{"score rose past the threshold", base, alertSnapshot(50.5, "medium", nil), AlertRule{Kind: AlertScoreRise, Threshold: 10}, []string{}},
+		{"score rose by exactly the threshold", base, alertSnapshot(50, "medium", nil), AlertRule{Kind: AlertScoreRise, Threshold: 10}, nil},
+		{"score fell", base, alertSnapshot(10, "low", nil), AlertRule{Kind: AlertScoreRise}, nil},
+		{"any rise with no threshold", base, alertSnapshot(40.1, "medium", nil), AlertRule{Kind: AlertScoreRise}, []string{}},
+		{"risk band up", base, alertSnapshot(40, "high", nil), AlertRule{Kind: AlertRiskBand}, []string{}},
+		{"risk band down", base, alertSnapshot(40, "low", nil), AlertRule{Kind: AlertRiskBand}, []string{}},
+		{"risk band unknown before", alertSnapshot(40, "", nil), alertSnapshot(40, "high", nil), AlertRule{Kind: AlertRiskBand}, nil},
+		// Star counts drift between runs without the repo being new
+		{"flagship newly archived", base, alertSnapshot(40, "medium", []Finding{archived("alice/old (130 stars)", "alice/main (900 stars)")}), AlertRule{Kind: AlertFlagshipArchived}, []string{"alice/main"}},
+		{"flagship archived before", base, alertSnapshot(40, "medium", []Finding{archived("alice/old (130 stars)")}), AlertRule{Kind: AlertFlagshipArchived}, nil},
+		{"new co-maintainer", base, alertSnapshot(40, "medium", nil, "Bob", "mallory", "mallory"), AlertRule{Kind: AlertNewCoMaintainer}, []string{"mallory"}},
+		{"co-maintainer left", base, alertSnapshot(40, "medium", nil), AlertRule{Kind: AlertNewCoMaintainer}, nil},
+		{"no previous analysis", nil, alertSnapshot(90, "high", []Finding{redFlag("TAKEOVER")}), AlertRule{Kind: AlertNewRedFlag}, nil},
+		{"unknown kind", base, alertSnapshot(90, "high", nil), AlertRule{Kind: "score_fall"}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := EvaluateAlerts(tt.prev, tt.curr, []AlertRule{tt.rule})
+			if tt.evidence == nil {
+				if len(alerts) != 0 {
+					t.Errorf("alerts = %v, want none", alerts)
+				}
+				return
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("alerts = %v, want one", alerts)
+			}
+			if alerts[0].Login != "alice" || alerts[0].Rule.Kind != tt.rule.Kind {
+				t.Errorf("alert = %+v, want the %s rule on alice", alerts[0], tt.rule.Kind)
+			}
+			if !slices.Equal(alerts[0].Evidence, tt.evidence) {
+				t.Errorf("evidence = %v, want %v", alerts[0].Evidence, tt.evidence)
+			}
+		})
+	}
+}
+
+func TestEvaluateAlertsRuleOrder(t *testing.T) {
+	prev := alertSnapshot(20, "low", nil)
+	curr := alertSnapshot(70, "high", []Finding{{Code: "TAKEOVER", Severity: SeverityRedFlag}})
+	rules := []AlertRule{
+		{Kind: AlertRiskBand, Webhooks: []string{"https://hooks.example/risk"}},
+		{Kind: AlertNewCoMaintainer},
+		{Kind: AlertScoreRise, Threshold: 30},
+		{Kind: AlertNewRedFlag},
+	}
+	var kinds []AlertKind
+	for _, alert := range EvaluateAlerts(prev, curr, rules) {
+		kinds = append(kinds, alert.Rule.Kind)
+	}
+	if want := []AlertKind{AlertRiskBand, AlertScoreRise, AlertNewRedFlag}; !slices.Equal(kinds, want) {
+		t.Errorf("alerts fired %v, want %v", kinds, want)
+	}
+	if alerts := EvaluateAlerts(prev, curr, rules); !slices.Equal(alerts[0].Rule.Webhooks, rules[0].Webhooks) {
+		t.Errorf("alert rule %+v should keep its webhooks", alerts[0].Rule)
+	}
+}
+
+func TestParseAlertRule(t *testing.T) {
+	for _, tt := range []struct {
+		spec    string
+		want    AlertRule
+		wantErr string
+	}{
+		{"new_red_flag", AlertRule{Kind: AlertNewRedFlag}, ""},
+		{" Risk_Band ", AlertRule{Kind: AlertRiskBand}, ""},
+		{"score_rise=12.5", AlertRule{Kind: AlertScoreRise, Threshold: 12.5}, ""},
+		{"score_rise=0", AlertRule{Kind: AlertScoreRise}, ""},
+		{"score_rise", AlertRule{}, "needs a threshold"},
+		{"score_rise=-1", AlertRule{}, "non-negative"},
+		{"score_rise=lots", AlertRule{}, "non-negative"},
+		{"risk_band=2", AlertRule{}, "takes no threshold"},
+		{"score_drop=5", AlertRule{}, "unknown alert"},
+	} {
+		rule, err := ParseAlertRule(tt.spec)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseAlertRule(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+		case err != nil || rule.Kind != tt.want.Kind || rule.Threshold != tt.want.Threshold:
+			t.Errorf("ParseAlertRule(%q) = %+v, %v; want %+v", tt.spec, rule, err, tt.want)
+		}
+	}
+}
+
+func TestAlertRuleValidate(t *testing.T) {
+	for _, tt := range []struct {
+		rule    AlertRule
+		wantErr string
+	}{
+		{AlertRule{Kind: AlertScoreRise, Threshold: 5, Webhooks: []string{"https://hooks.example/a", "http://localhost:8080/b"}}, ""},
+		{AlertRule{Kind: "stars"}, "unknown alert"},
+		{AlertRule{Kind: AlertScoreRise, Threshold: -5}, "non-negative"},
+		{AlertRule{Kind: AlertRiskBand, Threshold: 5}, "takes no threshold"},
+		{AlertRule{Kind: AlertRiskBand, Webhooks: []string{"https://hooks.example/a", "hooks.example/secret"}}, "webhook 2 is not"},
+		{AlertRule{Kind: AlertRiskBand, Webhooks: []string{"file:///etc/passwd"}}, "webhook 1 is not"},
+	} {
+		err := tt.rule.Validate()
+		if (tt.wantErr == "") != (err == nil) || err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.rule, err, tt.wantErr)
+		}
+		if err != nil && strings.Contains(err.Error(), "secret") {
+			t.Errorf("Validate error %q repeats the webhook URL", err)
+		}
+	}
+}
+
+func TestSendAlertsPerRuleWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], string(body))
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer server.Close()
+
+	alerts := EvaluateAlerts(alertSnapshot(20, "low", nil), alertSnapshot(70, "high", nil), []AlertRule{
+		{Kind: AlertRiskBand, Webhooks: []string{server.URL + "/slack", server.URL + "/teams"}},
+		{Kind: AlertScoreRise, Threshold: 10, Webhooks: []string{server.URL + "/pager"}},
+		{Kind: AlertScoreRise, Threshold: 5},
+	})
+	if len(alerts) != 3 {
+		t.Fatalf("alerts = %v, want three", alerts)
+	}
+	if err := SendAlerts(context.Background(), server.Client(), alerts); err != nil {
+		t.Fatalf("SendAlerts: %v", err)
+	}
+
+	for path, kind := range map[string]AlertKind{"/slack": AlertRiskBand, "/teams": AlertRiskBand, "/pager": AlertScoreRise} {
+		if len(received[path]) != 1 {
+			t.Errorf("%s received %d alerts, want one", path, len(received[path]))
+			continue
+		}
+		var payload struct {
+			Text  string `json:"text"`
+			Alert Alert  `json:"alert"`
+		}
+		if err := json.Unmarshal([]byte(received[path][0]), &payload); err != nil {
+			t.Fatalf("%s payload: %v", path, err)
+		}
+		if payload.Alert.Rule.Kind != kind || !strings.HasPrefix(payload.Text, "@alice: ") {
+			t.Errorf("%s got %+v, want the %s alert", path, payload, kind)
+		}
+		if strings.Contains(received[path][0], server.URL) {
+			t.Errorf("%s payload names the webhooks: %s", path, received[path][0])
+		}
+	}
+
+	broken := []Alert{{Rule: AlertRule{Kind: AlertRiskBand, Webhooks: []string{server.URL + "/broken?token=secret"}}, Login: "alice"}}
+	err := SendAlerts(context.Background(), server.Client(), broken)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 410") || strings.Contains(err.Error(), "secret") {
+		t.Errorf("SendAlerts to a failing webhook = %v, want HTTP 410 without the URL", err)
+	}
+}
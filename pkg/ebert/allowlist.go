@@ -0,0 +1,75 @@
+package ebert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrHostNotAllowed is returned for requests to a host outside the
+// allowlist, which are never sent
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// HostAllowlist is an http.RoundTripper that sends requests only to its
+// hosts and fails the rest with ErrHostNotAllowed, so every host a run
+// talks to can be declared up front. A refused host is recorded against
+// the analysis the request belongs to as a skipped integration, lowering
+// its confidence rather than leaving the data silently missing.
+type HostAllowlist struct {
+	base  http.RoundTripper
+	hosts map[string]bool
+}
+
+// NewHostAllowlist sends requests to hosts, e.g. "api.github.com" or
+// "localhost:8080", through base, or http.DefaultTransport if nil. With no
+// hosts every request is refused.
+func NewHostAllowlist(base http.RoundTripper, hosts ...string) *HostAllowlist {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	allowed := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		allowed[strings.ToLower(strings.TrimSpace(host))] = true
+	}
+	return &HostAllowlist{base: base, hosts: allowed}
+}
+
+// RoundTrip sends req if its host, with or without the port, is allowed
+func (l *HostAllowlist) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Host)
+	if l.hosts[host] || l.hosts[strings.ToLower(req.URL.Hostname())] {
+		return l.base.RoundTrip(req)
+	}
+
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	if skipped := skippedHostsFrom(req.Context()); skipped != nil {
+		skipped.record(host, ErrHostNotAllowed)
+	}
+	return nil, fmt.Errorf("%s: %w", host, ErrHostNotAllowed)
+}
+
+// restrictHosts wraps hc's transport, or the default client's, in an
+// allowlist of hosts. Offline, a Replayer, logged or not, is kept as it
+// is, answering from its tape without the network, and any other
+// transport refuses everything.
+func restrictHosts(hc *http.Client, hosts []string, offline bool) *http.Client {
+	if hc == nil {
+		hc = defaultHTTPClient
+	}
+	transport := hc.Transport
+	if log, ok := transport.(*RequestLog); ok {
+		transport = log.base
+	}
+	if _, replay := transport.(*Replayer); offline && replay {
+		return hc
+	}
+	if offline {
+		hosts = nil
+	}
+	restricted := *hc
+	restricted.Transport = NewHostAllowlist(hc.Transport, hosts...)
+	return &restricted
+}
@@ -0,0 +1,1316 @@
+package ebert
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OutputJSON writes the analysis results as JSON
+func (a *Analyzer) OutputJSON(analysis *Analysis, outputFile string) error {
+	jsonData, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis to JSON: %w", err)
+	}
+
+	if outputFile != "" {
+		return os.WriteFile(outputFile, jsonData, 0644)
+	}
+
+	// Print to stdout if no file specified
+	fmt.Println(string(jsonData))
+	return nil
+}
+
+// GetAnalysisJSON returns the analysis as a JSON string
+func (a *Analyzer) GetAnalysisJSON(analysis *Analysis) (string, error) {
+	jsonData, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal analysis to JSON: %w", err)
+	}
+	return string(jsonData), nil
+}
+
+// StableJSON returns the analysis as JSON without the run timestamp or
+// request timings, so unchanged input data produces byte-identical output
+func StableJSON(analysis *Analysis) ([]byte, error) {
+	jsonData, err := json.MarshalIndent(StableAnalysis(analysis), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal analysis to JSON: %w", err)
+	}
+	return jsonData, nil
+}
+
+// StableAnalysis returns a copy of the analysis without the run timestamp
+// or request timings, for callers marshaling it inside their own output
+func StableAnalysis(analysis *Analysis) *Analysis {
+	stable := *analysis
+	stable.Timestamp = time.Time{}
+	stable.RequestStats = nil
+	return &stable
+}
+
+// Analyzer performs the security analysis. Its configuration is fixed at
+// construction and all per-analysis state lives in values scoped to a single
+// call, so one Analyzer may be shared by any number of goroutines running
+// Analyze, AnalyzeContext and AnalyzeStream concurrently.
+type Analyzer struct {
+	client   *GitHubClient
+	opts     AnalyzerOptions
+	denylist map[string]DenylistEntry
+}
+
+// New builds an Analyzer, returning an error if any option is invalid
+func New(token string, opts ...Option) (*Analyzer, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return nil, err
+		}
+	}
+
+	client := NewGitHubClient(token)
+	client.BaseURL = options.BaseURL
+	client.HTTPClient = options.HTTPClient
+	client.OnRequest = options.OnRequest
+	client.OnResponse = options.OnResponse
+	client.Tracer = options.Tracer
+	client.RequestTimeout = options.RequestTimeout
+	client.ExternalTimeout = options.ExternalTimeout
+	client.IntegrationTimeouts = options.IntegrationTimeouts
+	client.BreakerThreshold = options.BreakerThreshold
+	if options.BreakerThreshold == 0 {
+		client.BreakerThreshold = -1
+	}
+	client.MinRequestsPerSecond = options.MinRequestsPerSecond
+	client.MaxRequestsPerSecond = options.MaxRequestsPerSecond
+	client.StrictAuth = options.StrictAuth
+	client.TokenSource = options.TokenSource
+	if options.Offline || len(options.AllowedHosts) > 0 {
+		client.HTTPClient = restrictHosts(options.HTTPClient, options.AllowedHosts, options.Offline)
+	}
+
+	return &Analyzer{
+		client:   client,
+		opts:     options,
+		denylist: mergeDenylists(options.Denylist),
+	}, nil
+}
+
+// NewAnalyzer is like New but panics if an option is invalid
+func NewAnalyzer(token string, opts ...Option) *Analyzer {
+	analyzer, err := New(token, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("ebert: %v", err))
+	}
+	return analyzer
+}
+
+// TokenRejected reports whether GitHub rejected the analyzer's token and
+// analyses have continued unauthenticated since
+func (a *Analyzer) TokenRejected() bool {
+	return a.client.TokenRejected()
+}
+
+// Options returns a copy of the analyzer's effective configuration. Its
+// lists and maps are copies too, so changing them leaves the analyzer as
+// it was built.
+func (a *Analyzer) Options() AnalyzerOptions {
+	opts := a.opts
+	opts.InternalNamePatterns = slices.Clone(opts.InternalNamePatterns)
+	opts.TutorialKeywords = slices.Clone(opts.TutorialKeywords)
+	opts.ArtifactIgnore = slices.Clone(opts.ArtifactIgnore)
+	opts.AllowedHosts = slices.Clone(opts.AllowedHosts)
+	opts.Denylist = slices.Clone(opts.Denylist)
+	opts.IntegrationTimeouts = maps.Clone(opts.IntegrationTimeouts)
+	return opts
+}
+
+// Analyze fetches and scores the user. If some sources fail after the user
+// itself was fetched, the partial analysis is returned together with the
+// joined source errors.
+func (a *Analyzer) Analyze(username string) (*Analysis, error) {
+	return a.AnalyzeContext(context.Background(), username)
+}
+
+// AnalyzeContext is Analyze with a caller-supplied context
+func (a *Analyzer) AnalyzeContext(ctx context.Context, username string) (*Analysis, error) {
+	return a.cachedAnalyze(ctx, username)
+}
+
+// AuthenticatedLogin returns the login of the token's own account, for
+// analyzing it when no username is given
+func (a *Analyzer) AuthenticatedLogin(ctx context.Context) (string, error) {
+	user, err := a.client.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the authenticated user: %w", err)
+	}
+	return user.Login, nil
+}
+
+// RepoBatch is one page of repos handed to an AnalyzeStream sink
+type RepoBatch struct {
+	Page  int          `json:"page"`
+	Repos []GitHubRepo `json:"repos"`
+}
+
+// AnalyzeStream analyzes the user while handing each page of repos to sink
+// as it arrives. Metrics are accumulated page by page so memory stays
+// proportional to one page rather than the whole account; the result is
+// identical to Analyze over the same data. A sink error aborts the analysis.
+func (a *Analyzer) AnalyzeStream(ctx context.Context, username string, sink func(RepoBatch) error) (*Analysis, error) {
+	return a.analyze(ctx, username, sink, nil, nil)
+}
+
+// analysisRun is the request-scoped state of one analysis
+type analysisRun struct {
+	username     string
+	now          time.Time
+	user         *GitHubUser
+	acc          *metricsAccumulator
+	log          sourceLog
+	findings     []Finding
+	decodeErrors int
+
+	// seed is the run's sampling seed, and rng the source of its random
+	// samples, drawn from that seed alone
+	seed uint64
+	rng  *rand.Rand
+
+	// openPulls and closedPulls hold the PRs fetched per flagship repo, by full name
+	openPulls   map[string][]GitHubPull
+	closedPulls map[string][]GitHubPull
+
+	contentsBudget requestBudget
+	directories    map[string][]ContentEntry
+
+	// raw collects the fetched data for AnalyzeDetailed; nil otherwise
+	raw *RawData
+
+	// events is the events feed, fetched ahead of the repos and folded in
+	// at the events stage
+	events eventsFetch
+
+	// base is the earlier analysis Reanalyze starts from; nil otherwise
+	base *baseline
+
+	// stages lists the pipeline stages that finished before any deadline
+	stages []string
+
+	// npmMaintainers are npm maintainers of the user's packages, and
+	// denylisted is set once the user or one of them matches the denylist
+	npmMaintainers []string
+	denylisted     bool
+
+	// contributors caches the flagship contributor listings by full name;
+	// coMaintainers are the accounts found sharing the flagships
+	contributors  map[string][]GitHubUser
+	coMaintainers []CoMaintainer
+
+	// packageCoMaintainers are the other npm users who can publish the
+	// user's packages
+	packageCoMaintainers []string
+
+	// npmPublished caches the user's published npm manifests, nil until fetched
+	npmPublished []publishedNPM
+
+	// repoPackages are the packages found in the scanned top repos, by
+	// lowercased full name
+	repoPackages map[string][]repoPackage
+
+	// containerPackages are the user's GHCR images from the package check
+	containerPackages []GitHubPackage
+
+	// blocked collects the repos that answered 451 during the analysis
+	blocked *blockedRepos
+
+	// communityProfiles caches the checked repos' community profiles by
+	// full name; repos whose profile couldn't be fetched are absent
+	communityProfiles map[string]*CommunityProfile
+}
+
+// addFinding records a finding produced by a check that isn't a Rule
+func (r *analysisRun) addFinding(finding Finding) {
+	r.findings = append(r.findings, finding)
+}
+
+// analyze runs the pipeline; when raw is non-nil it is filled with the fetched data
+func (a *Analyzer) analyze(ctx context.Context, username string, sink func(RepoBatch) error, raw *RawData, base *baseline) (analysis *Analysis, err error) {
+	ctx, span := a.startAnalysisSpan(ctx, username)
+	defer func() { a.endAnalysisSpan(span, analysis, err) }()
+
+	if err := ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	username = NormalizeUsername(username)
+
+	if a.opts.AnalysisTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, a.opts.AnalysisTimeout, errAnalysisTimeout)
+		defer cancel()
+		ctx, cancel = withGiveUp(ctx)
+		defer cancel()
+	}
+
+	stats := newStatsRecorder()
+	ctx = withStatsRecorder(ctx, stats)
+	blocked := &blockedRepos{}
+	ctx = withBlockedRepos(ctx, blocked)
+	skipped := &skippedHosts{}
+	ctx = withSkippedHosts(ctx, skipped)
+	now := a.opts.now()
+
+	// Fetch data from GitHub
+	user, etag, unchanged, err := a.fetchUser(ctx, username, base)
+	if isNotFound(err) {
+		return nil, fmt.Errorf("failed to fetch user %s: %w: %w", username, ErrAccountNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	seed := sampleSeed(a.opts.Seed, username, now)
+	r := &analysisRun{
+		username:       username,
+		now:            now,
+		seed:           seed,
+		rng:            sampleRand(seed),
+		user:           user,
+		acc:            newMetricsAccumulator(user, now, &a.opts),
+		openPulls:      map[string][]GitHubPull{},
+		closedPulls:    map[string][]GitHubPull{},
+		contentsBudget: requestBudget{remaining: maxContentsRequests},
+		directories:    map[string][]ContentEntry{},
+		contributors:   map[string][]GitHubUser{},
+		raw:            raw,
+		base:           base,
+		blocked:        blocked,
+
+		communityProfiles: map[string]*CommunityProfile{},
+	}
+	if unchanged {
+		r.log.okWith("user", "unchanged since baseline")
+	} else {
+		r.log.ok("user")
+	}
+	r.setETag(etagUser, etag)
+
+	if finding, ok := unassessableAccount(user); ok {
+		analysis = a.unassessableAnalysis(r, finding)
+		a.attachStats(analysis, stats)
+		r.finishRaw()
+		return r.log.finish(analysis)
+	}
+
+	a.prefetchEvents(ctx, r)
+	if err := a.fetchRepos(ctx, r, sink); err != nil {
+		return nil, err
+	}
+	if ctx.Err() == nil {
+		r.completed("repos")
+	}
+
+	stages := []struct {
+		name string
+		run  func()
+	}{
+		{"repo_details", func() { a.enrichTopRepos(ctx, r) }},
+		{"content_only", func() { a.confirmContentOnly(ctx, r) }},
+		{"vendored_code", func() { a.checkVendoredCode(ctx, r) }},
+		{"archive_trend", func() {
+			a.ruleContext(r).countActiveFlagships()
+			a.applyRules(r, flagshipArchivedRule, dormantPopularRule)
+		}},
+		{"confusables", func() { a.applyRules(r, confusableNameRule, lookalikeNameRule) }},
+		{"docs_sites", func() { a.checkDocsSites(ctx, r) }},
+		{"events", func() { a.fetchEvents(ctx, r) }},
+		{"activity_farming", func() { a.checkActivityFarming(ctx, r) }},
+		{"works_in", func() { a.checkWorksIn(ctx, r) }},
+		{"follower_growth", func() { a.checkFollowerGrowth(ctx, r) }},
+		{"engagement_rings", func() { a.checkEngagementRings(ctx, r) }},
+		{"generated_content", func() { a.checkGeneratedContent(ctx, r) }},
+		{"timezone", func() {
+			a.ruleContext(r).inferTimezone()
+			a.applyRules(r, timezoneMismatchRule)
+		}},
+		{"repo_churn", func() {
+			a.ruleContext(r).countRepoChurn()
+			a.applyRules(r, repoChurnRule)
+		}},
+		{"gists", func() { a.fetchGists(ctx, r) }},
+		{"pull_requests", func() { a.checkPullRequests(ctx, r) }},
+		{"dependency_automation", func() { a.checkDependencyAutomation(ctx, r) }},
+		{"community_profile", func() { a.checkCommunityProfiles(ctx, r) }},
+		{"security_policy", func() { a.checkSecurityPolicy(ctx, r) }},
+		{"review_workflow", func() { a.checkReviewWorkflow(ctx, r) }},
+		{"marketplace_actions", func() { a.checkMarketplaceActions(ctx, r) }},
+		{"repo_features", func() { a.checkRepoFeatures(ctx, r) }},
+		{"repo_packages", func() { a.checkRepoPackages(ctx, r) }},
+		{"dependency_confusion", func() { a.checkDependencyConfusion(ctx, r) }},
+		{"install_scripts", func() { a.checkInstallScripts(ctx, r) }},
+		{"artifacts", func() { a.checkArtifacts(ctx, r) }},
+		{"packages", func() { a.checkPackages(ctx, r) }},
+		{"email_identities", func() { a.checkEmailIdentities(r) }},
+		{"package_co_maintainers", func() { a.checkPackageCoMaintainers(ctx, r) }},
+		{"crates", func() { a.checkCrates(ctx, r) }},
+		{"images", func() { a.checkImages(ctx, r) }},
+		{"release_provenance", func() { a.checkReleaseProvenance(ctx, r) }},
+		{"history_rewrite", func() { a.checkHistoryRewrites(ctx, r) }},
+		{"co_maintainers", func() {
+			a.discoverCoMaintainers(ctx, r)
+			a.recurseCoMaintainers(ctx, r)
+			a.checkCoMaintainers(r)
+			a.checkGhostContributors(r)
+		}},
+		{"denylist", func() { a.checkDenylist(ctx, r) }},
+		{"blocked_repos", func() { a.checkBlockedRepos(r) }},
+	}
+	for _, stage := range stages {
+		if ctx.Err() != nil {
+			break
+		}
+		stage.run()
+		if ctx.Err() == nil {
+			r.completed(stage.name)
+		}
+	}
+	a.checkTimeout(ctx, r)
+	if a.client.TokenRejected() {
+		// Token-gated checks were skipped, so results read like an
+		// anonymous run's
+		r.log.fellBack("authentication", errTokenRejected)
+	}
+	for _, host := range skipped.names() {
+		r.log.skipped("integration:"+host, fmt.Errorf("%s: %w", host, skipped.reason(host)))
+	}
+
+	analysis = a.buildAnalysis(r)
+	a.attachStats(analysis, stats)
+	r.finishRaw()
+
+	return r.log.finish(analysis)
+}
+
+// fetchRepos folds each page of repos into the run. Only a sink error is
+// returned; fetch failures are recorded as a missing source.
+//
+// When the account has more repos than the cap, the sample is the top-starred
+// half of the cap from the search API followed by the most recently updated
+// repos from the listing until the cap is reached. Ratios stay meaningful;
+// absolute counts become lower bounds and the analysis is marked sampled.
+func (a *Analyzer) fetchRepos(ctx context.Context, r *analysisRun, sink func(RepoBatch) error) error {
+	var sample *repoSample
+	if limit := a.maxRepos(r.user); limit > 0 && r.user.PublicRepos > limit {
+		sample = &repoSample{limit: limit, seen: map[string]struct{}{}}
+	}
+
+	var sinkErr error
+	batches := 0
+	add := func(repos []GitHubRepo) error {
+		full := false
+		if sample != nil {
+			repos, full = sample.take(repos)
+		}
+
+		batches++
+		r.acc.addRepos(repos)
+		if r.raw != nil {
+			r.raw.Repos = append(r.raw.Repos, repos...)
+		}
+		if sink != nil {
+			if sinkErr = sink(RepoBatch{Page: batches, Repos: repos}); sinkErr != nil {
+				return sinkErr
+			}
+		}
+		if full {
+			return errRepoLimit
+		}
+		return nil
+	}
+
+	if sample != nil {
+		top, bad, err := a.client.SearchTopRepos(ctx, r.username, sample.limit/2)
+		r.decodeErrors += bad
+		if err != nil {
+			r.log.fellBack("repo_search", fmt.Errorf("failed to search top-starred repos: %w", err))
+		} else {
+			r.log.ok("repo_search")
+			// The top half never fills the sample, so only a sink error stops here
+			if err := add(top); err != nil && !errors.Is(err, errRepoLimit) {
+				return err
+			}
+		}
+	}
+
+	if r.base != nil && sample == nil && sink == nil {
+		if merged, pushed, ok := a.reposSince(ctx, r); ok {
+			if err := add(merged); err != nil {
+				return err
+			}
+			r.log.okWith("repos", fmt.Sprintf("%s via baseline, %d pushed since", a.opts.RepoList.String(), pushed))
+			return nil
+		}
+	}
+
+	path, err := a.listRepos(ctx, r, add)
+	if sinkErr != nil {
+		return sinkErr
+	}
+	if err != nil && !errors.Is(err, errRepoLimit) {
+		r.log.failed("repos", fmt.Errorf("failed to fetch repos: %w", err))
+		return nil
+	}
+	r.log.okWith("repos", a.opts.RepoList.String()+" via "+path)
+
+	if sample != nil {
+		metrics := &r.acc.metrics
+		metrics.ReposSampled = true
+		metrics.ReposTotal = r.user.PublicRepos
+		r.log.fellBack("repo_sample", fmt.Errorf("sampled %d of %d repos: top-starred plus most recently updated; counts are lower bounds",
+			metrics.Repos, metrics.ReposTotal))
+	}
+	return nil
+}
+
+// eventsFetch is the outcome of fetching the events feed
+type eventsFetch struct {
+	events    []GitHubEvent
+	etag      string
+	unchanged bool
+	err       error
+}
+
+// prefetchEvents fetches the events feed before the repos are listed, so
+// the accumulator keeps the stars and creation times of only the repos
+// the feed names rather than of every listed repo
+func (a *Analyzer) prefetchEvents(ctx context.Context, r *analysisRun) {
+	// Pages past the activity window only hold events it leaves out
+	since := r.now.Add(-a.opts.ActivityWindow)
+	events, bad, etag, err := a.client.getEvents(ctx, r.username, r.baselineETag(etagEvents), since)
+	r.decodeErrors += bad
+	if errors.Is(err, errNotModified) {
+		events, etag, err = r.base.raw.Events, r.baselineETag(etagEvents), nil
+		r.events.unchanged = true
+	}
+	r.events.events, r.events.etag, r.events.err = events, etag, err
+	r.acc.referenceEvents(events)
+}
+
+// fetchEvents folds the prefetched events feed into the run
+func (a *Analyzer) fetchEvents(ctx context.Context, r *analysisRun) {
+	events, etag, err := r.events.events, r.events.etag, r.events.err
+	switch {
+	case err != nil:
+		r.log.failed("events", fmt.Errorf("failed to fetch events: %w", err))
+		return
+	case r.events.unchanged:
+		r.log.okWith("events", "unchanged since baseline")
+	default:
+		r.log.ok("events")
+	}
+	r.setETag(etagEvents, etag)
+	r.acc.addEvents(events)
+	r.acc.eventsCoverage()
+	if r.raw != nil {
+		r.raw.Events = events
+	}
+	a.countCommits(ctx, r.username, r.now, &r.acc.metrics, &r.log)
+}
+
+func (a *Analyzer) startAnalysisSpan(ctx context.Context, username string) (context.Context, Span) {
+	ctx, span := a.opts.Tracer.Start(ctx, "ebert.Analyze")
+	span.SetAttributes(Attribute{Key: "github.username", Value: username})
+	return ctx, span
+}
+
+func (a *Analyzer) endAnalysisSpan(span Span, analysis *Analysis, err error) {
+	if analysis != nil {
+		span.SetAttributes(
+			Attribute{Key: "ebert.overall_score", Value: analysis.OverallScore},
+			Attribute{Key: "ebert.risk_level", Value: analysis.RiskLevel},
+			Attribute{Key: "ebert.partial", Value: analysis.Partial},
+		)
+	}
+	endSpan(span, err)
+}
+
+// attachStats adds the analysis's request stats, with the per-endpoint
+// breakdown only when it was asked for
+func (a *Analyzer) attachStats(analysis *Analysis, stats *statsRecorder) {
+	snapshot := stats.snapshot()
+	if !a.opts.RequestStats {
+		snapshot.Endpoints = nil
+	}
+	analysis.RequestStats = &snapshot
+}
+
+// buildAnalysis scores the accumulated metrics. Dimensions whose inputs
+// are missing are left uncomputed.
+func (a *Analyzer) buildAnalysis(r *analysisRun) *Analysis {
+	user := r.user
+	metrics := r.acc.metrics
+	metrics.DecodeErrors = r.decodeErrors
+	cov := r.log.coverage()
+	input := scoringInput{user: *user, metrics: metrics, original: r.acc.original, padding: r.acc.padding, cov: cov}
+	scores, overallScore := a.score(input)
+
+	// Determine risk level; a list-based match trumps every heuristic
+	riskLevel := riskBand(overallScore)
+	if r.denylisted {
+		riskLevel = "high"
+	}
+
+	// Generate flags
+	a.applyRules(r, flagRules...)
+	findings := slices.Clone(r.findings)
+	indexFindings(findings)
+	sortFindings(findings)
+	redFlags, warnings, positives := splitFindings(findings)
+	trust, abandonment := CompositeIndices(scores, findings)
+
+	// Checks whose integration was skipped ran without their input
+	confidence := a.confidence(scores, metrics) * math.Pow(skippedIntegrationConfidence, float64(r.log.skippedCount()))
+	if noPublicCode(*user, metrics, cov) {
+		confidence = min(confidence, maxNoCodeConfidence)
+	}
+
+	return &Analysis{
+		User:                 *user,
+		Scores:               scores,
+		OverallScore:         overallScore,
+		RiskLevel:            riskLevel,
+		TrustIndex:           trust,
+		AbandonmentIndex:     abandonment,
+		Confidence:           confidence,
+		Metrics:              metrics,
+		Findings:             findings,
+		RedFlags:             redFlags,
+		Warnings:             warnings,
+		Positives:            positives,
+		TopRemediations:      a.remediate(findings, input, overallScore),
+		CoMaintainers:        r.coMaintainers,
+		PackageCoMaintainers: r.packageCoMaintainers,
+		Timestamp:            r.now,
+		Meta:                 a.runMeta(r),
+	}
+}
+
+// scoringInput is everything the sub-scores are computed from, so the
+// scoring can be re-run on a modified copy
+type scoringInput struct {
+	user     GitHubUser
+	metrics  Metrics
+	original repoTotals
+	padding  repoTotals
+	cov      coverage
+}
+
+// score computes the sub-scores and the weighted overall score. Dimensions
+// whose inputs are missing are left uncomputed.
+func (a *Analyzer) score(in scoringInput) (RiskScores, float64) {
+	metrics := in.metrics
+	scores := RiskScores{
+		Identity: computed(a.calculateIdentityScore(&in.user, metrics)),
+	}
+	if noPublicCode(in.user, metrics, in.cov) {
+		// Without public code only the profile says anything; the rest
+		// stays uncomputed rather than scoring the absence of data
+		scores.Community = computed(a.calculateCommunityScore(metrics))
+	} else {
+		if in.cov.repos && in.cov.events {
+			scores.Activity = computed(a.calculateActivityScore(metrics, metrics.Repos))
+		}
+		if in.cov.repos {
+			// Templates, mirrors and meta repos would skew the per-repo ratios
+			scored := a.scoringMetrics(metrics, in.original)
+			scores.Quality = computed(a.calculateQualityScore(withoutPadding(scored, in.padding)))
+			scores.Maintenance = computed(a.calculateMaintenanceScore(scored, scored.Repos))
+			scores.Community = computed(a.calculateCommunityScore(metrics))
+			scores.Security = computed(a.calculateSecurityScore(metrics))
+		}
+	}
+
+	if isNewAccount(metrics, a.opts.NewAccountThreshold) {
+		capNewAccountScores(&scores)
+	}
+
+	// A truncated events feed makes the activity score less trustworthy
+	weights := a.opts.Weights
+	weights.Activity *= activityCoverage(metrics)
+	return scores, weightedScore(scores, weights)
+}
+
+// riskBand is the risk level an overall score lands in
+func riskBand(score float64) string {
+	switch {
+	case score >= 60:
+		return "high"
+	case score >= 30:
+		return "medium"
+	}
+	return "low"
+}
+
+// scoreWeight pairs a sub-score with its configured weight
+type scoreWeight struct {
+	score  *float64
+	weight float64
+}
+
+// weightedScores lists every sub-score with its weight
+func weightedScores(scores RiskScores, w Weights) []scoreWeight {
+	return []scoreWeight{
+		{scores.Identity, w.Identity},
+		{scores.Activity, w.Activity},
+		{scores.Quality, w.Quality},
+		{scores.Maintenance, w.Maintenance},
+		{scores.Community, w.Community},
+		{scores.Security, w.Security},
+	}
+}
+
+// weightedScore combines the computed sub-scores using the given
+// weights, renormalizing over the dimensions that were computed
+func weightedScore(scores RiskScores, w Weights) float64 {
+	pairs := weightedScores(scores, w)
+
+	sum, total := 0.0, 0.0
+	for _, pair := range pairs {
+		if pair.score == nil {
+			continue
+		}
+		sum += *pair.score * pair.weight
+		total += pair.weight
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// metricsAccumulator folds repos and events into running metric totals.
+// Each analysis owns its accumulator; it is never shared between goroutines.
+type metricsAccumulator struct {
+	login   string
+	now     time.Time
+	window  time.Duration
+	metrics Metrics
+	top     topRepos
+	logger  *slog.Logger
+
+	// original totals only the repos classified as RepoOriginal
+	original repoTotals
+
+	nonForks, issuesEnabled int
+
+	// freshnessSum totals the non-fork repos' freshness over halfLife,
+	// and weightedStars every repo's weighted stars
+	freshnessSum  repoSum
+	weightedStars repoSum
+	halfLife      time.Duration
+
+	// starHorizon and starFalloff weigh each repo's stars into WeightedStars
+	starHorizon time.Duration
+	starFalloff string
+
+	// pushes dedupes push IDs; pushRepos counts the commits pushed to each
+	// repo and pushMessages samples their messages
+	pushes       map[int64]struct{}
+	pushRepos    map[string]int
+	pushMessages map[string][]string
+
+	// contributed counts the commits pushed and pull requests opened on
+	// each repo the user doesn't own
+	contributed map[string]int
+
+	// pushLinks is the before and head of each push, by "<repo> <ref>"
+	pushLinks map[string][]pushLink
+
+	// referenced holds the lowercase full names of the repos the events
+	// feed names. Only their stars are kept in repoStars, and their creation
+	// times in churn, so the listing adds nothing per repo beyond a page.
+	referenced map[string]struct{}
+	repoStars  map[string]int
+
+	internalPatterns *PatternSet
+
+	// tutorialKeywords mark course projects; padding totals the tutorial
+	// clones and content-only repos, and paddingRepos are the first
+	// content-only ones for deep mode to confirm
+	tutorialKeywords *PatternSet
+	padding          repoTotals
+	paddingRepos     []paddingRepo
+	packages         []packageCandidate
+
+	// readmeRepos are the most-starred non-fork repos, whose READMEs the
+	// generated-content check compares
+	readmeRepos topRepos
+
+	// npmRepos are the most-starred original npm repos, whose published
+	// manifests the install-script check inspects
+	npmRepos topRepos
+
+	// crateRepos are the most-starred original Rust repos, whose crates the
+	// crates check looks up
+	crateRepos topRepos
+
+	confusables confusableScan
+
+	// communityRepo is the account's .github community-health repo, if any
+	communityRepo *GitHubRepo
+
+	// disabled names the repos GitHub has disabled
+	disabled []string
+
+	// eventHours is the UTC hour-of-day histogram of every event
+	eventHours [24]int
+
+	churn repoChurn
+
+	// name is the profile name, and commitEmails count the author emails
+	// of the user's own pushed commits
+	name         string
+	commitEmails map[string]int
+
+	// oldestEvent is the earliest event timestamp received
+	oldestEvent time.Time
+}
+
+func newMetricsAccumulator(user *GitHubUser, now time.Time, opts *AnalyzerOptions) *metricsAccumulator {
+	return &metricsAccumulator{
+		login:  user.Login,
+		name:   user.Name,
+		now:    now,
+		window: opts.ActivityWindow,
+		metrics: Metrics{
+			AccountAgeDays:     accountAgeDays(user.CreatedAt, now),
+			Followers:          user.Followers,
+			ActivityWindowDays: int(opts.ActivityWindow.Hours() / 24),
+		},
+		top:      topRepos{limit: opts.TopRepos},
+		logger:   opts.Logger,
+		halfLife: cmp.Or(opts.FreshnessHalfLife, DefaultFreshnessHalfLife),
+
+		starHorizon: cmp.Or(opts.StarHorizon, DefaultStarHorizon),
+		starFalloff: opts.StarFalloff,
+
+		churn:            newRepoChurn(),
+		pushes:           make(map[int64]struct{}),
+		pushRepos:        make(map[string]int),
+		pushMessages:     make(map[string][]string),
+		contributed:      make(map[string]int),
+		pushLinks:        make(map[string][]pushLink),
+		referenced:       make(map[string]struct{}),
+		repoStars:        make(map[string]int),
+		commitEmails:     make(map[string]int),
+		npmRepos:         topRepos{limit: maxInstallScriptPackages},
+		crateRepos:       topRepos{limit: maxCratesChecked},
+		readmeRepos:      topRepos{limit: maxReadmeSamples},
+		internalPatterns: internalNamePatterns(opts.InternalNamePatterns),
+		tutorialKeywords: tutorialKeywords(opts.TutorialKeywords),
+	}
+}
+
+func (m *metricsAccumulator) addRepos(repos []GitHubRepo) {
+	m.metrics.Repos += len(repos)
+
+	// Analyze repos
+	for _, repo := range repos {
+		m.top.add(repo)
+		m.metrics.Stars += repo.StargazersCount
+		m.metrics.Forks += repo.ForksCount
+		weightedStars := float64(repo.StargazersCount) * starWeight(repo, m.now, m.starHorizon, m.starFalloff)
+		m.weightedStars.add(weightedStars)
+		m.metrics.WeightedStars = m.weightedStars.value()
+
+		class := classifyRepo(repo, m.login)
+		m.metrics.RepoClasses.add(class)
+		m.logger.Debug("classified repo", "repo", repo.Name, "class", class)
+		m.addPackageCandidate(repo, class)
+		m.confusables.add("repo", repo.Name)
+		owner, name := repoOwnerAndName(repo, m.login)
+		if key := strings.ToLower(owner + "/" + name); m.isReferenced(key) {
+			m.churn.addRepo(repo, m.login)
+			m.repoStars[key] = repo.StargazersCount
+		}
+		if repo.Disabled {
+			m.disabled = append(m.disabled, repo.FullName)
+		}
+		if strings.EqualFold(repo.Name, ".github") && !repo.Fork {
+			community := repo
+			m.communityRepo = &community
+		}
+		if ecosystem, ok := repoEcosystem(repo); ok && ecosystem == EcosystemNPM && class == RepoOriginal {
+			m.npmRepos.add(repo)
+		}
+		if repo.Language == "Rust" && class == RepoOriginal {
+			m.crateRepos.add(repo)
+		}
+
+		archived := repo.Archived
+		recentlyArchived := archived && m.now.Sub(repo.UpdatedAt) <= recentArchiveWindow
+		recentlyUpdated := m.now.Sub(repo.UpdatedAt).Hours()/24 <= 30
+
+		if archived {
+			m.metrics.Archived++
+		}
+		if recentlyArchived {
+			m.metrics.RecentlyArchived++
+		}
+		if recentlyUpdated {
+			m.metrics.RecentlyUpdated++
+		}
+
+		if class == RepoOriginal {
+			m.original.repos++
+			m.original.stars += repo.StargazersCount
+			m.original.forks += repo.ForksCount
+			m.original.weightedStars.add(weightedStars)
+			m.addPadding(repo, weightedStars)
+			if archived {
+				m.original.archived++
+			}
+			if recentlyArchived {
+				m.original.recentlyArchived++
+			}
+			if recentlyUpdated {
+				m.original.recentlyUpdated++
+			}
+		}
+
+		if repo.HasPages {
+			m.metrics.DocsSites++
+		}
+
+		if !repo.Fork {
+			m.nonForks++
+			m.readmeRepos.add(repo)
+			if repo.DefaultBranch != "" && repo.DefaultBranch != "main" && repo.DefaultBranch != "master" {
+				m.metrics.NonDefaultBranchRepos++
+			}
+			if repo.HasIssues {
+				m.issuesEnabled++
+			}
+			m.metrics.IssuesEnabledRatio = float64(m.issuesEnabled) / float64(m.nonForks)
+			m.freshnessSum.add(freshness(repo, m.now, m.halfLife))
+			m.metrics.MaintenanceFreshness = m.freshnessSum.value() / float64(m.nonForks)
+		}
+		if isUserPagesRepo(repo, m.login) && m.now.Sub(repo.UpdatedAt) <= maintainedDocsWindow {
+			m.metrics.UserPagesSite = true
+		}
+
+		switch repo.Language {
+		case "JavaScript", "TypeScript":
+			m.metrics.NPMPackages++
+		case "Python":
+			m.metrics.PythonPackages++
+		case "Go":
+			m.metrics.GoModules++
+		}
+	}
+}
+
+// referenceEvents notes the repos events name, ahead of the listing
+func (m *metricsAccumulator) referenceEvents(events []GitHubEvent) {
+	for _, event := range events {
+		if event.Repo.Name != "" {
+			m.referenced[strings.ToLower(event.Repo.Name)] = struct{}{}
+		}
+	}
+}
+
+// isReferenced reports whether the events feed names the repo with the
+// lowercase full name key
+func (m *metricsAccumulator) isReferenced(key string) bool {
+	_, ok := m.referenced[key]
+	return ok
+}
+
+func (m *metricsAccumulator) addEvents(events []GitHubEvent) {
+	m.metrics.EventsReceived += len(events)
+
+	for i, event := range events {
+		if !event.CreatedAt.IsZero() {
+			m.eventHours[event.CreatedAt.UTC().Hour()]++
+			if m.oldestEvent.IsZero() || event.CreatedAt.Before(m.oldestEvent) {
+				m.oldestEvent = event.CreatedAt
+			}
+		}
+		m.churn.addEvent(&events[i])
+	}
+
+	// Analyze events within the activity window
+	for i := range events {
+		if m.now.Sub(events[i].CreatedAt) > m.window {
+			continue
+		}
+		switch events[i].Type {
+		case "PushEvent":
+			m.addPush(&events[i])
+		case "PullRequestEvent":
+			m.addPullRequest(&events[i])
+		}
+	}
+}
+
+func (a *Analyzer) calculateIdentityScore(user *GitHubUser, metrics Metrics) float64 {
+	score := 50.0
+	accountAge := metrics.AccountAgeDays
+
+	if accountAge > 730 {
+		score -= 20
+	} else if accountAge > 365 {
+		score -= 10
+	} else if accountAge < 90 {
+		score += 30
+	}
+
+	if user.Company != "" {
+		score -= 10
+	}
+	if user.Email != "" {
+		score -= 5
+	}
+	if user.Blog != "" {
+		score -= 5
+	}
+	if user.TwitterUsername != "" {
+		score -= 5
+	}
+	if user.Name == "" {
+		score += 10
+	}
+
+	// One address everywhere is a settled identity; a leak or a third
+	// address is the hygiene a takeover exploits
+	switch metrics.EmailConsistency {
+	case EmailConsistent:
+		score -= 5
+	case EmailInconsistent:
+		score += 10
+	}
+
+	return clamp(score, 0, 100)
+}
+
+func (a *Analyzer) calculateActivityScore(metrics Metrics, totalRepos int) float64 {
+	score := 50.0
+	// A new account can't have been active for longer than it has existed,
+	// and an events count only spans the days the feed covered
+	commitsPerMonth := perMonth(metrics.RecentCommits-metrics.FarmedCommits, min(metrics.ActivityWindowDays, metrics.AccountAgeDays, commitDays(metrics)))
+
+	if commitsPerMonth > 20 {
+		score -= 20
+	} else if commitsPerMonth > 10 {
+		score -= 10
+	} else if commitsPerMonth < 2 && !metrics.CommitCountLowerBound {
+		score += 20
+	}
+
+	switch {
+	case len(metrics.WorksIn) > 0:
+		// Work done in an organization's repos leaves the user's own idle
+		score -= 10
+	case metrics.RecentlyUpdated == 0 && totalRepos > 0:
+		score += 30
+	case float64(metrics.RecentlyUpdated) > float64(totalRepos)*0.3:
+		score -= 10
+	}
+
+	return clamp(score, 0, 100)
+}
+
+func (a *Analyzer) calculateQualityScore(metrics Metrics) float64 {
+	score := 50.0
+
+	if metrics.Repos == 0 {
+		return score
+	}
+
+	avgStars := float64(metrics.Stars) / float64(metrics.Repos)
+	if a.opts.ScoringVersion >= ScoringV2 {
+		avgStars = metrics.WeightedStars / float64(metrics.Repos)
+	}
+
+	if avgStars > 50 {
+		score -= 20
+	} else if avgStars > 10 {
+		score -= 10
+	} else if avgStars < 1 {
+		score += 10
+	}
+
+	if metrics.Stars > 0 && float64(metrics.Forks) > float64(metrics.Stars)*0.1 {
+		score -= 10
+	}
+
+	if metrics.MaintainedDocsSites > 0 {
+		score -= 5
+	}
+
+	return clamp(score, 0, 100)
+}
+
+func (a *Analyzer) calculateMaintenanceScore(metrics Metrics, totalRepos int) float64 {
+	score := 50.0
+
+	if totalRepos == 0 {
+		return score
+	}
+
+	archivedRatio := float64(metrics.Archived) / float64(totalRepos)
+	recentlyArchivedRatio := float64(metrics.RecentlyArchived) / float64(totalRepos)
+	activeRatio := float64(metrics.RecentlyUpdated) / float64(totalRepos)
+
+	// Recent mass archiving means winding down; old archives only count
+	// against an account that isn't maintaining its flagships
+	if recentlyArchivedRatio > 0.5 {
+		score += 30
+	} else if recentlyArchivedRatio > 0.3 {
+		score += 15
+	} else if metrics.ActiveFlagships == 0 {
+		if archivedRatio > 0.5 {
+			score += 30
+		} else if archivedRatio > 0.3 {
+			score += 15
+		}
+	}
+
+	if a.opts.ScoringVersion >= ScoringV2 {
+		score += freshnessAdjustment(metrics.MaintenanceFreshness)
+	} else if activeRatio > 0.5 {
+		score -= 20
+	} else if activeRatio > 0.3 {
+		score -= 10
+	} else if activeRatio == 0 && totalRepos > 0 {
+		score += 20
+	}
+
+	return clamp(score, 0, 100)
+}
+
+func (a *Analyzer) calculateCommunityScore(metrics Metrics) float64 {
+	score := 50.0
+
+	if metrics.Followers > 500 {
+		score -= 25
+	} else if metrics.Followers > 100 {
+		score -= 15
+	} else if metrics.Followers > 50 {
+		score -= 10
+	} else if metrics.Followers < 10 {
+		score += 15
+	}
+
+	if metrics.Stars > 1000 {
+		score -= 15
+	} else if metrics.Stars > 100 {
+		score -= 10
+	} else if metrics.Stars < 10 && metrics.Repos > 5 && len(metrics.WorksIn) == 0 {
+		score += 10
+	}
+
+	// A regular in a well-known organization project is known to its community
+	if metrics.WorksInStars > popularWorksInStars {
+		score -= 10
+	}
+
+	if metrics.ActiveDiscussions > 0 {
+		score -= 5
+	}
+
+	return clamp(score, 0, 100)
+}
+
+// PrintAnalysis CLI output functions
+func PrintAnalysis(analysis *Analysis) {
+	FprintAnalysis(os.Stdout, analysis)
+}
+
+// FprintAnalysis writes the human-readable report to w
+func FprintAnalysis(w io.Writer, analysis *Analysis) {
+	FprintLocalizedAnalysis(w, analysis, englishCatalog)
+}
+
+// FprintLocalizedAnalysis writes the human-readable report to w with its
+// labels and finding messages from catalog
+func FprintLocalizedAnalysis(w io.Writer, analysis *Analysis, catalog *Catalog) {
+	FprintReport(w, analysis, ReportOptions{Catalog: catalog})
+}
+
+// ReportOptions configures the human-readable report
+type ReportOptions struct {
+	// Catalog supplies the labels and finding messages; nil means English
+	Catalog *Catalog
+
+	// Expand lists every finding with all its evidence, instead of one
+	// line per code with a count and a few examples
+	Expand bool
+}
+
+// FprintReport writes the human-readable report to w. Findings are grouped
+// by severity, highest first, under a count, and ordered by code within
+// each group.
+func FprintReport(w io.Writer, analysis *Analysis, opts ReportOptions) {
+	catalog := opts.Catalog
+	if catalog == nil {
+		catalog = englishCatalog
+	}
+	line := func(key string, args ...any) {
+		fmt.Fprintln(w, catalog.Text(key, args...))
+	}
+	score := func(value *float64) string {
+		if value == nil {
+			return catalog.Text("score.not_computed")
+		}
+		return catalog.Text("score.value", fmt.Sprintf("%.1f", *value))
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+	line("report.title")
+	fmt.Fprintln(w, strings.Repeat("=", 80))
+
+	if missing := analysis.MissingSources(); len(missing) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("!", 80))
+		line("report.partial", strings.Join(missing, ", "))
+		line("report.partial_scores")
+		fmt.Fprintln(w, strings.Repeat("!", 80))
+	}
+
+	// User info
+	fmt.Fprintln(w)
+	line("report.user", analysis.User.Name, analysis.User.Login)
+	if analysis.SelfAnalysis {
+		line("report.self_analysis")
+	}
+	if analysis.User.Bio != "" {
+		line("report.bio", analysis.User.Bio)
+	}
+	line("report.profile", analysis.User.HTMLURL)
+
+	// Overall risk
+	fmt.Fprintln(w)
+	risk, ok := catalog.lookup("risk."+analysis.RiskLevel, 0)
+	if !ok {
+		risk = strings.ToUpper(analysis.RiskLevel)
+	}
+	line("report.overall_risk", risk)
+	line("report.risk_score", fmt.Sprintf("%.1f", analysis.OverallScore))
+	line("report.trust_index", score(analysis.TrustIndex))
+	line("report.abandonment_index", score(analysis.AbandonmentIndex))
+	line("report.confidence", fmt.Sprintf("%.0f", analysis.Confidence*100))
+	if skipped := analysis.SkippedIntegrations(); len(skipped) > 0 {
+		line("report.skipped_integrations", strings.Join(skipped, ", "))
+	}
+	if analysis.NoPublicCode() {
+		line("report.no_public_code")
+	}
+
+	// Key metrics
+	metrics := analysis.Metrics
+	fmt.Fprintln(w)
+	line("report.key_metrics")
+	line("metrics.account_age", metrics.AccountAgeDays/365, (metrics.AccountAgeDays%365)/30)
+	if metrics.ReposSampled {
+		line("metrics.repos_sampled", metrics.Repos, metrics.ReposTotal)
+	} else {
+		line("metrics.repos", metrics.Repos)
+	}
+	classes := metrics.RepoClasses
+	line("metrics.repo_classes", classes.Original, classes.Fork, classes.Template, classes.Mirror, classes.Meta)
+	line("metrics.stars", metrics.Stars)
+	line("metrics.followers", metrics.Followers)
+	if days := metrics.EventsCoverageDays; days > 0 && metrics.CommitCountMethod == CommitCountEvents {
+		line("metrics.recent_commits_partial", metrics.RecentCommits, days, metrics.ActivityWindowDays)
+	} else {
+		line("metrics.recent_commits", metrics.RecentCommits, metrics.ActivityWindowDays)
+	}
+	line("metrics.active_repos", metrics.ActiveRepos)
+	line("metrics.recently_updated", metrics.RecentlyUpdated)
+	line("metrics.freshness", fmt.Sprintf("%.0f", metrics.MaintenanceFreshness*100))
+	line("metrics.archived", metrics.Archived)
+
+	// Detailed scores
+	fmt.Fprintln(w)
+	line("report.detailed_scores")
+	line("scores.identity", score(analysis.Scores.Identity))
+	line("scores.activity", score(analysis.Scores.Activity))
+	line("scores.quality", score(analysis.Scores.Quality))
+	line("scores.maintenance", score(analysis.Scores.Maintenance))
+	line("scores.community", score(analysis.Scores.Community))
+	line("scores.security", score(analysis.Scores.Security))
+
+	// Flags, highest severity first
+	for _, section := range []struct {
+		key      string
+		severity Severity
+	}{
+		{"report.red_flags", SeverityRedFlag},
+		{"report.warnings", SeverityWarning},
+		{"report.info", SeverityInfo},
+		{"report.positives", SeverityPositive},
+	} {
+		var findings []Finding
+		for _, finding := range analysis.Findings {
+			if finding.Severity == section.severity {
+				findings = append(findings, finding)
+			}
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		fmt.Fprintln(w)
+		line(section.key, len(findings))
+		fprintFindings(w, findings, catalog, opts.Expand)
+	}
+
+	if len(analysis.TopRemediations) > 0 {
+		fmt.Fprintln(w)
+		line("report.remediations")
+		for _, item := range analysis.TopRemediations {
+			action := item.Action
+			if translated, ok := catalog.lookup("remediation."+item.Code, 0); ok {
+				action = translated
+			}
+			line("report.remediation", action, fmt.Sprintf("%.1f", item.ScoreImpact), item.Code)
+		}
+	}
+
+	if footer := requestFooter(analysis.RequestStats, catalog); footer != "" {
+		fmt.Fprintf(w, "\n   %s\n", footer)
+	}
+	if analysis.Meta != nil {
+		fmt.Fprintf(w, "\n   %s\n", analysis.Meta.summary(catalog))
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+}
+
+// requestFooter summarizes an analysis's API cost in the catalog's
+// language, e.g. "38 API calls, 4,812 remaining until 16:00 UTC"
+func requestFooter(stats *RequestStats, catalog *Catalog) string {
+	if stats == nil {
+		return ""
+	}
+
+	footer := catalog.Text("footer.requests", formatThousands(stats.Requests))
+	if stats.CacheHits > 0 {
+		footer += catalog.Text("footer.cache_hits", formatThousands(stats.CacheHits))
+	}
+	if limit := stats.RateLimit; limit != nil {
+		footer += catalog.Text("footer.rate_limit", formatThousands(int64(limit.Remaining)), limit.Reset.UTC().Format("15:04 MST"))
+	}
+	return footer
+}
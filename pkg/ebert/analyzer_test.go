@@ -0,0 +1,177 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzeStreamMatchesAnalyze(t *testing.T) {
+	account := syntheticAccount("streamer", 340)
+	f := newFakeGitHub(t, account)
+
+	batch, err := newFakeAnalyzer(f).Analyze("streamer")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	pages, streamed := 0, 0
+	stream, err := newFakeAnalyzer(f).AnalyzeStream(context.Background(), "streamer", func(batch RepoBatch) error {
+		pages++
+		streamed += len(batch.Repos)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeStream: %v", err)
+	}
+	if pages < 4 || streamed != len(account.Repos) {
+		t.Errorf("sink saw %d repos in %d pages, want %d in at least 4", streamed, pages, len(account.Repos))
+	}
+
+	want, err := StableJSON(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := StableJSON(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("streamed analysis differs from the batch one:\nstream: %s\nbatch:  %s", got, want)
+	}
+	if batch.Metrics.RepoChurn == 0 || batch.Metrics.FarmedCommits == 0 {
+		t.Errorf("fixture should exercise churn and farming, got churn %d and farmed %d", batch.Metrics.RepoChurn, batch.Metrics.FarmedCommits)
+	}
+}
+
+func TestAnalyzeStreamSinkError(t *testing.T) {
+	unspaced(t)
+	account := syntheticAccount("sampled", 340)
+	f := newFakeGitHub(t, account)
+	// The sample starts with the top-starred repos from the search API
+	f.route("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": account.Repos[:50]})
+	})
+
+	errFull := errors.New("sink full")
+	pages := 0
+	_, err := newFakeAnalyzer(f, WithMaxRepos(100)).AnalyzeStream(context.Background(), "sampled", func(batch RepoBatch) error {
+		pages++
+		return errFull
+	})
+	if !errors.Is(err, errFull) {
+		t.Errorf("AnalyzeStream = %v, want the sink's error", err)
+	}
+	if pages != 1 {
+		t.Errorf("sink called for %d pages, want the batch to stop at the first", pages)
+	}
+}
+
+func TestAccumulatorKeepsOnlyReferencedRepos(t *testing.T) {
+	account := syntheticAccount("hoarder", 5000)
+	opts := NewAnalyzer("").Options()
+	acc := newMetricsAccumulator(&account.User, fakeNow, &opts)
+	acc.referenceEvents(account.Events)
+	for page := range slices.Chunk(account.Repos, 100) {
+		acc.addRepos(page)
+	}
+
+	if acc.metrics.Repos != len(account.Repos) {
+		t.Fatalf("counted %d repos, want %d", acc.metrics.Repos, len(account.Repos))
+	}
+	// The feed pushes to four repos and auto-commit, and names two more
+	if len(acc.repoStars) > 7 || len(acc.churn.listed) > 7 {
+		t.Errorf("kept %d stars and %d creation times for %d listed repos", len(acc.repoStars), len(acc.churn.listed), len(account.Repos))
+	}
+	if _, ok := acc.repoStars["hoarder/auto-commit"]; !ok {
+		t.Error("stars of a pushed repo should be kept")
+	}
+}
+
+// TestAnalyzerConcurrentUse shares one Analyzer between 20 goroutines, as
+// serve and batch mode do, over the state its client shares: the pacer,
+// the missing-account cache, the token decision and the circuit breaker.
+// It is meant to run under -race.
+func TestAnalyzerConcurrentUse(t *testing.T) {
+	logins := []string{"ada", "brian", "carol", "dmitri", "erin"}
+	var accounts []*fakeAccount
+	for i, login := range logins {
+		accounts = append(accounts, syntheticAccount(login, 40+i*30))
+	}
+
+	for _, tt := range []struct {
+		name   string
+		setup  func(*fakeGitHub)
+		opts   []Option
+		expect func(*testing.T, *Analyzer)
+	}{
+		{
+			name:  "token rejected",
+			setup: func(f *fakeGitHub) { f.rejectTokens.Store(true) },
+			expect: func(t *testing.T, a *Analyzer) {
+				if !a.TokenRejected() {
+					t.Error("token should have been rejected")
+				}
+			},
+		},
+		{
+			name: "registry down",
+			setup: func(f *fakeGitHub) {
+				f.host("registry.npmjs.org", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				})
+			},
+			opts: []Option{WithDeepChecks(true), WithExternalChecks(true), WithCircuitBreaker(2)},
+			expect: func(t *testing.T, a *Analyzer) {
+				if !slices.Contains(a.TrippedIntegrations(), "registry.npmjs.org") {
+					t.Errorf("tripped %v, want registry.npmjs.org", a.TrippedIntegrations())
+				}
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			unspaced(t)
+			f := newFakeGitHub(t, accounts...)
+			tt.setup(f)
+			a := newFakeAnalyzerToken(f, "ghp_test", tt.opts...)
+
+			var wg sync.WaitGroup
+			errs := make([]error, 20)
+			for i := range errs {
+				wg.Go(func() {
+					login := logins[i%len(logins)]
+					if i%4 == 3 {
+						// Every fourth lookup is of an account that doesn't exist
+						login = "ghost-" + strconv.Itoa(i%2)
+					}
+					analysis, err := a.AnalyzeContext(context.Background(), login)
+					switch {
+					case strings.HasPrefix(login, "ghost-"):
+						if !errors.Is(err, ErrAccountNotFound) {
+							errs[i] = fmt.Errorf("%s: err = %v, want ErrAccountNotFound", login, err)
+						}
+					case analysis == nil:
+						errs[i] = fmt.Errorf("%s: no analysis: %v", login, err)
+					case analysis.User.Login != login:
+						errs[i] = fmt.Errorf("%s: analysis is of %s", login, analysis.User.Login)
+					}
+				})
+			}
+			wg.Wait()
+			for _, err := range errs {
+				if err != nil {
+					t.Error(err)
+				}
+			}
+			tt.expect(t, a)
+		})
+	}
+}
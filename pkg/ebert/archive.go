@@ -0,0 +1,78 @@
+package ebert
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// recentArchiveWindow is how recently a repo must have been archived to
+	// count towards a wind-down; archiving bumps updated_at
+	recentArchiveWindow = 365 * 24 * time.Hour
+
+	// flagshipCount is how many of the most-starred repos count as flagships
+	flagshipCount = 3
+
+	// activeFlagshipWindow is how recently a flagship must have been updated
+	// to count as actively maintained
+	activeFlagshipWindow = 90 * 24 * time.Hour
+)
+
+// flagships returns the most-starred repos, at most flagshipCount of them
+func (r *analysisRun) flagships() []GitHubRepo {
+	top := r.checkable()
+	return top[:min(flagshipCount, len(top))]
+}
+
+// countActiveFlagships counts the flagships updated recently, which
+// separates a maintainer winding down from one tidying away old experiments
+func (c RuleContext) countActiveFlagships() {
+	if !c.coverage().repos {
+		return
+	}
+	c.run.acc.metrics.ActiveFlagships = 0
+	for _, repo := range c.run.flagships() {
+		if !repo.Archived && c.run.now.Sub(repo.UpdatedAt) <= activeFlagshipWindow {
+			c.run.acc.metrics.ActiveFlagships++
+		}
+	}
+}
+
+// flagshipArchivedRule flags recently archived flagship repos
+var flagshipArchivedRule = rule{
+	code:        "FLAGSHIP_ARCHIVED",
+	description: "One of the most-starred repos was archived within the last year",
+	evaluate: func(c RuleContext) []Finding {
+		if !c.coverage().repos {
+			return nil
+		}
+		var archivedFlagships []string
+		for _, repo := range c.run.flagships() {
+			if repo.Archived && c.run.now.Sub(repo.UpdatedAt) <= recentArchiveWindow {
+				archivedFlagships = append(archivedFlagships, fmt.Sprintf("%s (%d stars)", repo.Name, repo.StargazersCount))
+			}
+		}
+		if len(archivedFlagships) == 0 {
+			return nil
+		}
+		return []Finding{{
+			Code:     "FLAGSHIP_ARCHIVED",
+			Severity: SeverityRedFlag,
+			Message:  "Flagship repository archived within the last year",
+			Evidence: archivedFlagships,
+		}}
+	},
+}
+
+// windingDown reports whether a large share of repos were archived
+// recently, or old archives dominate with no flagship still maintained
+func windingDown(metrics Metrics) bool {
+	if metrics.Repos == 0 {
+		return false
+	}
+
+	recentRatio := float64(metrics.RecentlyArchived) / float64(metrics.Repos)
+	archivedRatio := float64(metrics.Archived) / float64(metrics.Repos)
+
+	return recentRatio > 0.3 || (archivedRatio > 0.3 && metrics.ActiveFlagships == 0)
+}
@@ -0,0 +1,103 @@
+package ebert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// archivePersona is an account of ten repos: three flagships updated
+// flagshipAge ago, archived or not, and seven experiments, archived
+// archivedAge ago when archived is set
+func archivePersona(login string, flagshipsArchived bool, flagshipAge time.Duration, archived bool, archivedAge time.Duration) *fakeAccount {
+	var repos []GitHubRepo
+	for i := range 3 {
+		repos = append(repos, GitHubRepo{
+			Name: fmt.Sprintf("flagship-%d", i), Language: "Go", Size: 5000,
+			StargazersCount: 900 - i*100, ForksCount: 50, HasIssues: true,
+			Archived: flagshipsArchived, UpdatedAt: fakeNow.Add(-flagshipAge),
+		})
+	}
+	for i := range 7 {
+		repo := GitHubRepo{Name: fmt.Sprintf("experiment-%d", i), Language: "Go", Size: 200, StargazersCount: 2, UpdatedAt: fakeNow.Add(-days(400))}
+		if archived {
+			repo.Archived, repo.UpdatedAt = true, fakeNow.Add(-archivedAge)
+		}
+		repos = append(repos, repo)
+	}
+	return newAccount(login, days(3000), repos...)
+}
+
+func TestArchiveTrendPersonas(t *testing.T) {
+	windingDown := archivePersona("winding", true, days(40), true, days(60))
+	tidy := archivePersona("tidy", false, days(5), true, days(1500))
+	untouched := archivePersona("untouched", false, days(5), false, 0)
+	f := newFakeGitHub(t, windingDown, tidy, untouched)
+	a := newFakeAnalyzer(f)
+
+	analyze := func(login string) *Analysis {
+		t.Helper()
+		analysis, _ := a.Analyze(login)
+		if analysis == nil {
+			t.Fatalf("no analysis of %s", login)
+		}
+		return analysis
+	}
+
+	t.Run("winding down", func(t *testing.T) {
+		analysis := analyze("winding")
+		if got := analysis.Metrics.RecentlyArchived; got != 10 {
+			t.Errorf("RecentlyArchived = %d, want 10", got)
+		}
+		flag := finding(analysis, "FLAGSHIP_ARCHIVED")
+		if flag == nil || flag.Severity != SeverityRedFlag {
+			t.Fatalf("want a FLAGSHIP_ARCHIVED red flag, got %+v", flag)
+		}
+		if !strings.Contains(strings.Join(flag.Evidence, " "), "flagship-0 (900 stars)") {
+			t.Errorf("evidence %q should name the flagship", flag.Evidence)
+		}
+		if got := *analysis.Scores.Maintenance; got < 70 {
+			t.Errorf("Maintenance = %.1f, want a high risk score", got)
+		}
+	})
+
+	t.Run("tidy", func(t *testing.T) {
+		analysis := analyze("tidy")
+		if got := analysis.Metrics.RecentlyArchived; got != 0 {
+			t.Errorf("RecentlyArchived = %d, want 0", got)
+		}
+		if got := analysis.Metrics.ActiveFlagships; got != 3 {
+			t.Errorf("ActiveFlagships = %d, want 3", got)
+		}
+		if flag := finding(analysis, "FLAGSHIP_ARCHIVED"); flag != nil {
+			t.Errorf("unexpected %+v", flag)
+		}
+		// Archiving old experiments costs nothing against the same
+		// account that never archived them
+		baseline := analyze("untouched")
+		if got, want := *analysis.Scores.Maintenance, *baseline.Scores.Maintenance; got > want {
+			t.Errorf("Maintenance = %.1f, want no more than the %.1f of the unarchived account", got, want)
+		}
+	})
+}
+
+func TestMaintenanceScoreArchives(t *testing.T) {
+	a := NewAnalyzer("", WithScoringVersion(ScoringV1))
+	for _, tt := range []struct {
+		name    string
+		metrics Metrics
+		want    float64
+	}{
+		{"recent mass archiving", Metrics{Archived: 6, RecentlyArchived: 6, RecentlyUpdated: 6}, 60},
+		{"some recent archiving", Metrics{Archived: 4, RecentlyArchived: 4, RecentlyUpdated: 4}, 55},
+		{"old archives, flagships maintained", Metrics{Archived: 6, ActiveFlagships: 2, RecentlyUpdated: 2}, 50},
+		{"old archives, flagships idle", Metrics{Archived: 6}, 100},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.calculateMaintenanceScore(tt.metrics, 10); got != tt.want {
+				t.Errorf("score = %.1f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package ebert
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxArtifactPackages bounds the published packages whose latest
+	// artifact is downloaded and compared with the repo
+	maxArtifactPackages = 5
+
+	// maxArtifactBytes caps a downloaded npm tarball or PyPI sdist
+	maxArtifactBytes = 20 << 20
+
+	// maxArtifactEvidence is how many extra files a finding lists per
+	// package
+	maxArtifactEvidence = 10
+)
+
+// DefaultArtifactIgnore are the build outputs and generated files a
+// published artifact holds without the repo tracking them. A pattern
+// ending in "/" is a directory at the package root, ignored only while the
+// repo doesn't track it; any other is matched with path.Match against the
+// base name, or against the whole path when it holds a slash.
+var DefaultArtifactIgnore = []string{
+	"dist/", "build/", "lib/", "out/", "esm/", "cjs/", "*.egg-info/",
+	"*.map", "*.d.ts", "*.tsbuildinfo", "PKG-INFO", "setup.cfg",
+}
+
+// compiledSources are the source extensions a published JavaScript file
+// may have been compiled from in place
+var compiledSources = []string{".ts", ".tsx", ".mts", ".cts"}
+
+// npmBin is the bin field of a package.json: one command's file, or a map
+// of commands to files
+type npmBin []string
+
+// UnmarshalJSON accepts both forms, ignoring any other
+func (b *npmBin) UnmarshalJSON(data []byte) error {
+	var file string
+	if err := json.Unmarshal(data, &file); err == nil {
+		*b = npmBin{file}
+		return nil
+	}
+	var commands map[string]string
+	if err := json.Unmarshal(data, &commands); err == nil {
+		files := make(npmBin, 0, len(commands))
+		for _, file := range commands {
+			files = append(files, file)
+		}
+		slices.Sort(files)
+		*b = files
+	}
+	return nil
+}
+
+// npmRepository is the repository field of a package.json: a URL or
+// shorthand such as "github:owner/repo", or an object holding the URL
+type npmRepository struct {
+	URL string
+}
+
+// UnmarshalJSON accepts both forms, ignoring any other
+func (r *npmRepository) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.URL); err == nil {
+		return nil
+	}
+	var object struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(data, &object); err == nil {
+		r.URL = object.URL
+	}
+	return nil
+}
+
+// pypiProject is the part of a PyPI project's JSON metadata the artifact
+// check reads: its latest version, links and files
+type pypiProject struct {
+	Info struct {
+		Version     string            `json:"version"`
+		HomePage    string            `json:"home_page"`
+		ProjectURLs map[string]string `json:"project_urls"`
+	} `json:"info"`
+	URLs []struct {
+		PackageType string `json:"packagetype"`
+		URL         string `json:"url"`
+	} `json:"urls"`
+}
+
+// getPyPIProject fetches a project's metadata, reporting false if it
+// isn't published
+func (c *GitHubClient) getPyPIProject(ctx context.Context, name string) (*pypiProject, bool, error) {
+	data, err := c.getExternal(ctx, packageURL(EcosystemPyPI, name), maxManifestBytes)
+	if errors.Is(err, errExternalNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var project pypiProject
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, false, fmt.Errorf("failed to decode PyPI metadata for %s: %w", name, err)
+	}
+	return &project, true, nil
+}
+
+// publishedArtifact is the latest published version of a package built
+// from dir in repo. Its entry points are the files it runs on import or
+// install, each with the kind of entry.
+type publishedArtifact struct {
+	name        string
+	version     string
+	url         string
+	repo        GitHubRepo
+	dir         string
+	entryPoints map[string]string
+}
+
+func (p publishedArtifact) String() string {
+	return p.name + "@" + p.version
+}
+
+// linksRepo reports whether a registry's source link points at repo, so a
+// package merely sharing a name with it isn't compared
+func linksRepo(link string, repo GitHubRepo) bool {
+	link = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(link)), ".git")
+	fullName := strings.ToLower(repo.FullName)
+	if link == fullName || link == "github:"+fullName {
+		return true
+	}
+	_, rest, ok := strings.Cut(link, "github.com/"+fullName)
+	return ok && (rest == "" || strings.ContainsAny(rest[:1], "/#?"))
+}
+
+// publishedArtifacts lists the latest artifacts of the user's confirmed
+// npm packages, then of the PyPI packages the package scan found, up to
+// maxArtifactPackages. Only packages whose registry metadata links back
+// to their repo are listed; PyPI projects without an sdist are skipped,
+// since wheels hold built output.
+func (a *Analyzer) publishedArtifacts(ctx context.Context, r *analysisRun) ([]publishedArtifact, error) {
+	var artifacts []publishedArtifact
+	for _, pkg := range a.npmPackages(ctx, r) {
+		if len(artifacts) == maxArtifactPackages {
+			return artifacts, nil
+		}
+		published := pkg.published
+		if published.Version == "" || published.Dist.Tarball == "" || !linksRepo(published.Repository.URL, pkg.repo) {
+			continue
+		}
+		artifacts = append(artifacts, publishedArtifact{
+			name:        published.Name,
+			version:     published.Version,
+			url:         published.Dist.Tarball,
+			repo:        pkg.repo,
+			dir:         pkg.dir,
+			entryPoints: npmEntryPoints(published),
+		})
+	}
+
+	var failed error
+	for _, repo := range r.checkable() {
+		for _, pkg := range r.repoPackages[strings.ToLower(repo.FullName)] {
+			if len(artifacts) == maxArtifactPackages || ctx.Err() != nil {
+				return artifacts, failed
+			}
+			if pkg.ecosystem != EcosystemPyPI {
+				continue
+			}
+			project, ok, err := a.client.getPyPIProject(ctx, pkg.name)
+			if err != nil {
+				failed = fmt.Errorf("failed to fetch PyPI metadata for %s: %w", pkg.name, err)
+				continue
+			}
+			if !ok || !pypiLinksRepo(project, repo) {
+				continue
+			}
+			sdist := false
+			for _, file := range project.URLs {
+				if file.PackageType == "sdist" && strings.HasSuffix(file.URL, ".tar.gz") {
+					artifacts = append(artifacts, publishedArtifact{
+						name:        pkg.name,
+						version:     project.Info.Version,
+						url:         file.URL,
+						repo:        repo,
+						dir:         pkg.dir,
+						entryPoints: map[string]string{"setup.py": "install script"},
+					})
+					sdist = true
+					break
+				}
+			}
+			if !sdist {
+				a.opts.Logger.Debug("no sdist for published package; wheels aren't compared", "package", pkg.name+"@"+project.Info.Version, "repo", repo.FullName)
+			}
+		}
+	}
+	return artifacts, failed
+}
+
+// pypiLinksRepo reports whether any of a project's links point at repo
+func pypiLinksRepo(project *pypiProject, repo GitHubRepo) bool {
+	if linksRepo(project.Info.HomePage, repo) {
+		return true
+	}
+	for _, link := range project.Info.ProjectURLs {
+		if linksRepo(link, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// npmEntryPoints are the files a published npm version runs: its main
+// module and commands, and any file its install hooks name
+func npmEntryPoints(manifest *npmManifest) map[string]string {
+	entries := map[string]string{path.Clean(cmp.Or(manifest.Main, "index.js")): "entry point"}
+	for _, file := range manifest.Bin {
+		entries[path.Clean(file)] = "entry point"
+	}
+	for _, hook := range installHooks {
+		for _, word := range strings.Fields(manifest.Scripts[hook]) {
+			if strings.Contains(word, ".") && !strings.Contains(word, "://") {
+				entries[path.Clean(strings.Trim(word, `"'`))] = "install script"
+			}
+		}
+	}
+	return entries
+}
+
+// releaseTags are the tag names a release of version is usually cut as
+func releaseTags(name, version string) []string {
+	return []string{"v" + version, version, name + "@" + version}
+}
+
+// taggedFiles lists the files below the artifact's package directory at
+// its release tag and names the tag, or returns nil when the repo has
+// none of the usual tags
+func (a *Analyzer) taggedFiles(ctx context.Context, r *analysisRun, artifact publishedArtifact) (map[string]bool, string, error) {
+	owner, name := repoOwnerAndName(artifact.repo, r.username)
+	for _, tag := range releaseTags(artifact.name, artifact.version) {
+		if !r.contentsBudget.take() {
+			return nil, "", nil
+		}
+		entries, truncated, err := a.client.GetTree(ctx, owner, name, tag, true)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list %s at %s: %w", artifact.repo.FullName, tag, err)
+		}
+		if truncated {
+			return nil, "", fmt.Errorf("%s at %s has too many files to compare", artifact.repo.FullName, tag)
+		}
+
+		prefix := ""
+		if artifact.dir != "." {
+			prefix = artifact.dir + "/"
+		}
+		files := map[string]bool{}
+		for _, entry := range entries {
+			if rel, ok := strings.CutPrefix(entry.Path, prefix); ok && entry.Type != "tree" {
+				files[rel] = true
+			}
+		}
+		return files, tag, nil
+	}
+	return nil, "", nil
+}
+
+// artifactIgnored reports whether an artifact file is build output the
+// repo can't be expected to track
+func artifactIgnored(file string, tracked map[string]bool, ignore []string) bool {
+	ext := path.Ext(file)
+	if ext == ".js" || ext == ".mjs" || ext == ".cjs" {
+		stem := strings.TrimSuffix(file, ext)
+		for _, source := range compiledSources {
+			if tracked[stem+source] {
+				return true
+			}
+		}
+	}
+
+	top, _, nested := strings.Cut(file, "/")
+	for _, pattern := range ignore {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if matched, _ := path.Match(dir, top); matched && nested && !tracksDir(tracked, top) {
+				return true
+			}
+			continue
+		}
+		subject := path.Base(file)
+		if strings.Contains(pattern, "/") {
+			subject = file
+		}
+		if matched, _ := path.Match(pattern, subject); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// tracksDir reports whether the repo tracks any file below dir
+func tracksDir(tracked map[string]bool, dir string) bool {
+	for file := range tracked {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// artifactMismatches lists the files of an artifact missing from the repo
+// at its tag, entry points and install scripts marked, and the entries
+// escaping the package
+func artifactMismatches(artifact publishedArtifact, listing *tarballListing, tracked map[string]bool, ignore []string) []string {
+	var extra []string
+	for _, file := range listing.files {
+		if tracked[file] || artifactIgnored(file, tracked, ignore) {
+			continue
+		}
+		if kind, ok := artifact.entryPoints[file]; ok {
+			file += " (" + kind + ")"
+		}
+		extra = append(extra, file)
+	}
+	for _, entry := range listing.unsafe {
+		extra = append(extra, entry+" (escapes the package)")
+	}
+
+	evidence := make([]string, 0, min(len(extra), maxArtifactEvidence+1))
+	for i, file := range extra {
+		if i == maxArtifactEvidence {
+			evidence = append(evidence, fmt.Sprintf("%s: %d more files", artifact, len(extra)-i))
+			break
+		}
+		evidence = append(evidence, fmt.Sprintf("%s: %s", artifact, file))
+	}
+	return evidence
+}
+
+// checkArtifacts downloads the latest published artifact of the user's top
+// npm and PyPI packages and compares its files with the repo at the
+// release tag, flagging files only the artifact holds: the tarball
+// everyone installs isn't the code everyone reviews. Only tarballs are
+// read, npm's and PyPI sdists; wheels, being zips of built output, aren't
+// compared. It is opt-in and runs in deep mode with external checks.
+func (a *Analyzer) checkArtifacts(ctx context.Context, r *analysisRun) {
+	if !a.opts.VerifyArtifacts || !a.opts.DeepChecks || !a.opts.ExternalChecks {
+		return
+	}
+
+	ignore := slices.Concat(DefaultArtifactIgnore, a.opts.ArtifactIgnore)
+	artifacts, failed := a.publishedArtifacts(ctx, r)
+	var evidence []string
+	compared := 0
+	for _, artifact := range artifacts {
+		if ctx.Err() != nil {
+			break
+		}
+		tracked, tag, err := a.taggedFiles(ctx, r, artifact)
+		if err != nil {
+			failed = err
+			continue
+		}
+		if tracked == nil {
+			a.opts.Logger.Debug("no release tag for published package", "package", artifact.String(), "repo", artifact.repo.FullName)
+			continue
+		}
+
+		data, err := a.client.getExternal(ctx, artifact.url, maxArtifactBytes)
+		if err != nil {
+			failed = fmt.Errorf("failed to download %s: %w", artifact, err)
+			continue
+		}
+		listing, err := listTarball(data)
+		if err != nil {
+			failed = fmt.Errorf("%s: %w", artifact, err)
+			continue
+		}
+		compared++
+		mismatches := artifactMismatches(artifact, listing, tracked, ignore)
+		a.opts.Logger.Debug("compared published artifact", "package", artifact.String(), "tag", tag, "files", len(listing.files), "extra", len(mismatches))
+		evidence = append(evidence, mismatches...)
+	}
+
+	if len(evidence) > 0 {
+		r.addFinding(Finding{
+			Code:     "ARTIFACT_REPO_MISMATCH",
+			Severity: SeverityRedFlag,
+			Message:  "Published package artifacts (npm tarballs and PyPI sdists; wheels aren't compared) contain files missing from the repo at the release tag",
+			Evidence: evidence,
+		})
+	}
+	switch {
+	case failed != nil:
+		r.log.fellBack("artifacts", failed)
+	case compared > 0:
+		r.log.ok("artifacts")
+	}
+}
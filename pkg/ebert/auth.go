@@ -0,0 +1,47 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrUnauthorized is returned on a 401 when StrictAuth forbids falling
+// back to anonymous requests, or when an endpoint needs the token
+var ErrUnauthorized = errors.New("GitHub rejected the token as bad credentials")
+
+// errTokenRejected is recorded on analyses that continued without the token
+var errTokenRejected = errors.New("GITHUB_TOKEN appears expired or revoked - continuing unauthenticated")
+
+// authState is the client's one-time decision about a token GitHub rejects
+type authState struct {
+	once     sync.Once
+	rejected atomic.Bool
+}
+
+// authenticated reports whether requests still carry the token
+func (c *GitHubClient) authenticated() bool {
+	return c.hasToken() && !c.shared().auth.rejected.Load()
+}
+
+// TokenRejected reports whether the client dropped its token after GitHub
+// refused it, and now makes anonymous requests
+func (c *GitHubClient) TokenRejected() bool {
+	return c.shared().auth.rejected.Load()
+}
+
+// rejectToken decides, once per client, whether a 401 means the token
+// itself is bad: /rate_limit answers any valid token, so a 401 there too
+// settles it. Requests racing the decision wait for it.
+func (c *GitHubClient) rejectToken(ctx context.Context) bool {
+	auth := &c.shared().auth
+	auth.once.Do(func() {
+		resp, _, err := c.do(ctx, apiRequest{method: "GET", url: c.BaseURL + "/rate_limit", accept: defaultAccept}, 1)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized {
+			auth.rejected.Store(true)
+		}
+	})
+	return auth.rejected.Load()
+}
@@ -0,0 +1,102 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// authFake serves octo, answering path with a 401 whenever the request
+// carries a token, and /rate_limit likewise when expired is set. It
+// counts the /rate_limit checks.
+func authFake(t *testing.T, path string, expired bool) (*fakeGitHub, *atomic.Int32) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}))
+	var checks atomic.Int32
+	f.route("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		if expired && r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"resources":{}}`))
+	})
+	f.route(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(f.account("octo").User)
+	})
+	return f, &checks
+}
+
+func TestTokenRejected(t *testing.T) {
+	f, checks := authFake(t, "/users/octo", true)
+	a := newFakeAnalyzerToken(f, "expired")
+	analysis, err := a.Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if !a.TokenRejected() || checks.Load() != 1 {
+		t.Errorf("token rejected %t after %d checks, want rejected after one", a.TokenRejected(), checks.Load())
+	}
+	var source *DataSource
+	for i := range analysis.DataSources {
+		if analysis.DataSources[i].Name == "authentication" {
+			source = &analysis.DataSources[i]
+		}
+	}
+	if source == nil || source.Status != SourceFallback || !strings.Contains(source.Detail, "expired or revoked") {
+		t.Errorf("authentication source = %+v, want the downgrade noted", source)
+	}
+
+	// The decision is the client's, made once
+	if _, err := a.Analyze("octo"); err != nil || checks.Load() != 1 {
+		t.Errorf("second Analyze = %v after %d checks, want no new check", err, checks.Load())
+	}
+	if _, err := a.AuthenticatedLogin(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("AuthenticatedLogin = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestTokenKeptWhenValid(t *testing.T) {
+	// One endpoint refusing a token /rate_limit accepts doesn't drop it
+	f, checks := authFake(t, "/users/octo/events/public", false)
+	a := newFakeAnalyzerToken(f, "valid")
+	analysis, _ := a.Analyze("octo")
+	if analysis == nil || a.TokenRejected() || checks.Load() != 1 {
+		t.Errorf("analysis %v, token rejected %t after %d checks; want it kept after one", analysis != nil, a.TokenRejected(), checks.Load())
+	}
+	_, _ = a.Analyze("octo")
+	if checks.Load() != 1 {
+		t.Errorf("checked the token %d times, want once", checks.Load())
+	}
+}
+
+func TestStrictAuth(t *testing.T) {
+	f, checks := authFake(t, "/users/octo", true)
+	a := newFakeAnalyzerToken(f, "expired", WithStrictAuth(true))
+	if _, err := a.Analyze("octo"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Analyze = %v, want ErrUnauthorized", err)
+	}
+	if a.TokenRejected() || checks.Load() != 0 {
+		t.Errorf("token rejected %t after %d checks, want it neither checked nor dropped", a.TokenRejected(), checks.Load())
+	}
+}
+
+func TestAnonymousUnauthorized(t *testing.T) {
+	// Without a token a 401 is just an error, with nothing to fall back from
+	f, checks := authFake(t, "/users/octo/events/public", true)
+	f.route("/users/octo/events/public", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	a := newFakeAnalyzer(f)
+	_, _ = a.Analyze("octo")
+	if a.TokenRejected() || checks.Load() != 0 {
+		t.Errorf("token rejected %t after %d checks without a token", a.TokenRejected(), checks.Load())
+	}
+}
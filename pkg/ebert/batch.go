@@ -0,0 +1,387 @@
+package ebert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BatchFormats are the output formats a BatchWriter streams
+var BatchFormats = []string{"text", "json", "jsonl", "csv", "openmetrics"}
+
+// BatchResult is one account of a batch. Analysis is nil when the account
+// couldn't be analyzed, with the reason in Error. Status is one of the
+// member statuses, e.g. MemberNotFound for an account since deleted.
+type BatchResult struct {
+	Login    string    `json:"login"`
+	Status   string    `json:"status"`
+	Analysis *Analysis `json:"analysis,omitempty"`
+	Error    string    `json:"error,omitempty"`
+
+	// Raw is the fetched data when the batch asked for it; writers leave
+	// it out, so it can go into a file of its own
+	Raw *RawData `json:"-"`
+}
+
+// BatchSummary totals a batch once every result has been emitted.
+// RequestStats covers every account, including those that failed.
+type BatchSummary struct {
+	Accounts     int           `json:"accounts"`
+	Analyzed     int           `json:"analyzed"`
+	Partial      int           `json:"partial"`
+	Failed       int           `json:"failed"`
+	NotFound     []string      `json:"not_found,omitempty"`
+	RequestStats *RequestStats `json:"request_stats,omitempty"`
+}
+
+func (s *BatchSummary) add(result BatchResult) {
+	switch result.Status {
+	case MemberAnalyzed:
+		s.Analyzed++
+	case MemberPartial:
+		s.Analyzed++
+		s.Partial++
+	case MemberNotFound:
+		s.NotFound = append(s.NotFound, result.Login)
+	default:
+		s.Failed++
+	}
+}
+
+// WriteBatchSummary ends a batch with a warning naming the accounts that
+// no longer exist on GitHub and a line of the requests it cost
+func WriteBatchSummary(w io.Writer, s *BatchSummary) error {
+	if len(s.NotFound) > 0 {
+		if _, err := fmt.Fprintf(w, "⚠️  %d maintainers no longer exist on GitHub: %s\n", len(s.NotFound), strings.Join(s.NotFound, ", ")); err != nil {
+			return err
+		}
+	}
+	if footer := requestFooter(s.RequestStats, englishCatalog); footer != "" {
+		if _, err := fmt.Fprintf(w, "Batch of %d accounts: %s\n", s.Accounts, footer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseLogins reads one login per line, skipping blank lines and
+// "#" comments
+func ParseLogins(r io.Reader) ([]string, error) {
+	var logins []string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		login := NormalizeUsername(strings.TrimSpace(line))
+		if login == "" {
+			continue
+		}
+		if err := ValidateUsername(login); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		logins = append(logins, login)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read logins: %w", err)
+	}
+	if len(logins) == 0 {
+		return nil, errors.New("no logins to analyze")
+	}
+	return logins, nil
+}
+
+// LoadLogins reads a logins file from path
+func LoadLogins(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logins: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return ParseLogins(f)
+}
+
+// AnalyzeBatch analyzes each login on the member worker pool and hands
+// every result to emit in input order as soon as it and those before it
+// are done. Only the results in flight are held, so memory stays flat
+// however many logins there are; the summary keeps counts, the logins not
+// found and the requests of the whole batch. With raw, each result carries
+// its fetched data. An emit error stops the batch and is returned.
+func (a *Analyzer) AnalyzeBatch(ctx context.Context, logins []string, raw bool, emit func(BatchResult) error) (*BatchSummary, error) {
+	stats := newStatsRecorder()
+	ctx = withStatsRecorder(ctx, stats)
+	summary := &BatchSummary{Accounts: len(logins)}
+	err := streamInOrder(len(logins), orgMemberWorkers, func(i int) BatchResult {
+		result := BatchResult{Login: logins[i], Status: MemberAnalyzed}
+		var err error
+		if raw {
+			var detailed *DetailedAnalysis
+			if detailed, err = a.AnalyzeDetailedContext(ctx, logins[i]); detailed != nil {
+				result.Analysis, result.Raw = detailed.Analysis, detailed.Raw
+			}
+		} else {
+			result.Analysis, err = a.AnalyzeContext(ctx, logins[i])
+		}
+		switch {
+		case result.Analysis == nil && errors.Is(err, ErrAccountNotFound):
+			result.Status, result.Error = MemberNotFound, err.Error()
+		case result.Analysis == nil:
+			result.Status, result.Error = MemberFailed, err.Error()
+		case result.Analysis.Partial:
+			result.Status = MemberPartial
+		}
+		return result
+	}, func(_ int, result BatchResult) error {
+		summary.add(result)
+		return emit(result)
+	})
+
+	snapshot := stats.snapshot()
+	if !a.opts.RequestStats {
+		snapshot.Endpoints = nil
+	}
+	summary.RequestStats = &snapshot
+	return summary, err
+}
+
+// streamInOrder calls fn with each index below n on up to workers
+// goroutines and passes each result to emit in index order. Work runs at
+// most 2*workers indices ahead of the last emitted, so a slow index holds
+// up a bounded number of finished ones. An emit error stops new work;
+// the calls already running finish and are dropped.
+func streamInOrder[T any](n, workers int, fn func(i int) T, emit func(i int, v T) error) error {
+	type done struct {
+		i int
+		v T
+	}
+	workers = max(workers, 1)
+	slots := make(chan struct{}, 2*workers)
+	jobs := make(chan int)
+	results := make(chan done)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(jobs)
+		for i := range n {
+			select {
+			case slots <- struct{}{}:
+			case <-stop:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Go(func() {
+			for i := range jobs {
+				results <- done{i, fn(i)}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := map[int]T{}
+	next := 0
+	var err error
+	for result := range results {
+		if err != nil {
+			continue
+		}
+		pending[result.i] = result.v
+		for v, ok := pending[next]; ok && err == nil; v, ok = pending[next] {
+			delete(pending, next)
+			if err = emit(next, v); err != nil {
+				close(stop)
+			}
+			next++
+			<-slots
+		}
+	}
+	return err
+}
+
+// BatchWriter streams batch results to an output as they arrive. Close
+// finishes the output, such as the closing bracket of a JSON array.
+type BatchWriter interface {
+	Write(result BatchResult) error
+	Close() error
+}
+
+// NewBatchWriter streams results to w in one of BatchFormats:
+//   - text, a WriteBrief line per analyzed account; failures are left to
+//     the caller
+//   - json, one array of results, written an element at a time
+//   - jsonl, a JSON result per line
+//   - csv, a header and a row of the headline scores per account
+//   - openmetrics, one WriteOpenMetrics document of every analyzed account,
+//     written on Close since each metric family lists them all
+func NewBatchWriter(w io.Writer, format string) (BatchWriter, error) {
+	switch format {
+	case "text":
+		return &briefBatchWriter{w: w}, nil
+	case "json":
+		return &arrayBatchWriter{w: w}, nil
+	case "jsonl":
+		return &lineBatchWriter{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		return &csvBatchWriter{w: csv.NewWriter(w)}, nil
+	case "openmetrics":
+		return &metricsBatchWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("batch format must be one of %s, got %q", strings.Join(BatchFormats, ", "), format)
+}
+
+type briefBatchWriter struct {
+	w io.Writer
+}
+
+func (b *briefBatchWriter) Write(result BatchResult) error {
+	if result.Analysis == nil {
+		return nil
+	}
+	return WriteBrief(b.w, result.Analysis)
+}
+
+func (b *briefBatchWriter) Close() error {
+	return nil
+}
+
+// arrayBatchWriter writes the array brackets and commas itself, encoding
+// one element at a time into a reused buffer
+type arrayBatchWriter struct {
+	w       io.Writer
+	buf     bytes.Buffer
+	enc     *json.Encoder
+	started bool
+}
+
+func (b *arrayBatchWriter) Write(result BatchResult) error {
+	if b.enc == nil {
+		b.enc = json.NewEncoder(&b.buf)
+		b.enc.SetIndent("  ", "  ")
+	}
+	b.buf.Reset()
+	if b.started {
+		b.buf.WriteString(",\n  ")
+	} else {
+		b.buf.WriteString("[\n  ")
+		b.started = true
+	}
+	if err := b.enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", result.Login, err)
+	}
+	b.buf.Truncate(b.buf.Len() - 1)
+	_, err := b.w.Write(b.buf.Bytes())
+	return err
+}
+
+func (b *arrayBatchWriter) Close() error {
+	closing := "\n]\n"
+	if !b.started {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(b.w, closing)
+	return err
+}
+
+type lineBatchWriter struct {
+	enc *json.Encoder
+}
+
+func (b *lineBatchWriter) Write(result BatchResult) error {
+	if err := b.enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", result.Login, err)
+	}
+	return nil
+}
+
+func (b *lineBatchWriter) Close() error {
+	return nil
+}
+
+// csvBatchHeader names the columns csvBatchWriter writes; new ones are
+// only ever appended
+var csvBatchHeader = []string{"login", "overall_score", "risk_level", "trust_index", "abandonment_index", "confidence", "red_flags", "warnings", "partial", "error", "status"}
+
+// csvBatchWriter flushes every row, so each lands as its account finishes
+type csvBatchWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (b *csvBatchWriter) Write(result BatchResult) error {
+	if !b.started {
+		b.started = true
+		if err := b.w.Write(csvBatchHeader); err != nil {
+			return err
+		}
+	}
+	row := []string{result.Login, "", "", "", "", "", "", "", "", result.Error, result.Status}
+	if a := result.Analysis; a != nil {
+		index := func(value *float64) string {
+			if value == nil {
+				return ""
+			}
+			return strconv.FormatFloat(*value, 'f', 1, 64)
+		}
+		row = []string{result.Login, strconv.FormatFloat(a.OverallScore, 'f', 1, 64), a.RiskLevel, index(a.TrustIndex), index(a.AbandonmentIndex),
+			strconv.FormatFloat(a.Confidence, 'f', 2, 64), strconv.Itoa(len(a.RedFlags)), strconv.Itoa(len(a.Warnings)), strconv.FormatBool(a.Partial), result.Error, result.Status}
+	}
+	if err := b.w.Write(row); err != nil {
+		return err
+	}
+	b.w.Flush()
+	return b.w.Error()
+}
+
+func (b *csvBatchWriter) Close() error {
+	if !b.started {
+		if err := b.w.Write(csvBatchHeader); err != nil {
+			return err
+		}
+	}
+	b.w.Flush()
+	return b.w.Error()
+}
+
+// metricsBatchWriter keeps only what WriteOpenMetrics reads of each
+// analysis, so a large batch doesn't hold every report until Close
+type metricsBatchWriter struct {
+	w        io.Writer
+	analyses []*Analysis
+}
+
+func (b *metricsBatchWriter) Write(result BatchResult) error {
+	if a := result.Analysis; a != nil {
+		b.analyses = append(b.analyses, &Analysis{
+			User:         GitHubUser{Login: a.User.Login},
+			Scores:       a.Scores,
+			OverallScore: a.OverallScore,
+			RedFlags:     slices.Clone(a.RedFlags),
+			Timestamp:    a.Timestamp,
+		})
+	}
+	return nil
+}
+
+func (b *metricsBatchWriter) Close() error {
+	return WriteOpenMetrics(b.w, b.analyses)
+}
@@ -0,0 +1,193 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchJSONArrayOutOfOrder(t *testing.T) {
+	const n = 60
+	rng := rand.New(rand.NewPCG(3, 5))
+	delays := make([]time.Duration, n)
+	for i := range delays {
+		delays[i] = time.Duration(rng.IntN(2000)) * time.Microsecond
+	}
+
+	var buf bytes.Buffer
+	writer, err := NewBatchWriter(&buf, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var finished []int
+	err = streamInOrder(n, orgMemberWorkers, func(i int) BatchResult {
+		time.Sleep(delays[i])
+		mu.Lock()
+		finished = append(finished, i)
+		mu.Unlock()
+		result := BatchResult{Login: fmt.Sprintf("user%02d", i), Status: MemberAnalyzed, Analysis: &Analysis{OverallScore: float64(i)}}
+		if i%7 == 3 {
+			result = BatchResult{Login: result.Login, Status: MemberNotFound, Error: "not found"}
+		}
+		return result
+	}, func(_ int, result BatchResult) error {
+		return writer.Write(result)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if slices.IsSorted(finished) {
+		t.Fatal("the accounts finished in input order; the test exercises nothing")
+	}
+
+	var results []BatchResult
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("the batch isn't a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(results) != n {
+		t.Fatalf("decoded %d results, want %d", len(results), n)
+	}
+	for i, result := range results {
+		if want := fmt.Sprintf("user%02d", i); result.Login != want {
+			t.Fatalf("result %d is %s, want %s: the array isn't in input order", i, result.Login, want)
+		}
+		if i%7 == 3 {
+			if result.Status != MemberNotFound || result.Analysis != nil {
+				t.Errorf("result %d = %+v, want not_found without an analysis", i, result)
+			}
+		} else if result.Analysis == nil || result.Analysis.OverallScore != float64(i) {
+			t.Errorf("result %d carries %+v, want its own analysis", i, result.Analysis)
+		}
+	}
+}
+
+func TestBatchJSONArrayShapes(t *testing.T) {
+	for _, n := range []int{0, 1, 2} {
+		var buf bytes.Buffer
+		writer, _ := NewBatchWriter(&buf, "json")
+		for i := range n {
+			if err := writer.Write(BatchResult{Login: fmt.Sprint("user", i), Status: MemberAnalyzed}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var results []BatchResult
+		if err := json.Unmarshal(buf.Bytes(), &results); err != nil || results == nil || len(results) != n {
+			t.Errorf("%d results wrote %q, want an array of %d", n, buf.String(), n)
+		}
+		if !json.Valid(buf.Bytes()) || !strings.HasSuffix(buf.String(), "]\n") {
+			t.Errorf("%d results wrote %q, want one array ending the output", n, buf.String())
+		}
+	}
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	unspaced(t)
+	f := newFakeGitHub(t,
+		newAccount("octo", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}),
+		newAccount("hubot", days(3000), GitHubRepo{Name: "bot", Language: "Go", Size: 300, UpdatedAt: fakeNow.Add(-days(9))}),
+	)
+	logins := []string{"octo", "deleted-one", "hubot", "deleted-two"}
+
+	var buf bytes.Buffer
+	writer, _ := NewBatchWriter(&buf, "csv")
+	sent := f.requests.Load()
+	summary, err := newFakeAnalyzer(f).AnalyzeBatch(context.Background(), logins, false, writer.Write)
+	if err != nil {
+		t.Fatalf("AnalyzeBatch: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Accounts != 4 || summary.Analyzed != 2 || summary.Failed != 0 || !slices.Equal(summary.NotFound, []string{"deleted-one", "deleted-two"}) {
+		t.Errorf("summary = %+v, want two analyzed and two not found", summary)
+	}
+	if summary.RequestStats == nil || summary.RequestStats.Requests != f.requests.Load()-sent {
+		t.Errorf("batch request stats = %+v, want all %d requests, the 404s included", summary.RequestStats, f.requests.Load()-sent)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := slices.Index(rows[0], "status")
+	var statuses []string
+	for _, row := range rows[1:] {
+		statuses = append(statuses, row[0]+" "+row[status])
+	}
+	if want := []string{"octo analyzed", "deleted-one not_found", "hubot analyzed", "deleted-two not_found"}; !slices.Equal(statuses, want) {
+		t.Errorf("csv statuses = %q, want %q", statuses, want)
+	}
+
+	var out strings.Builder
+	if err := WriteBatchSummary(&out, summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 maintainers no longer exist on GitHub: deleted-one, deleted-two") {
+		t.Errorf("summary output %q should warn of the deleted accounts", out.String())
+	}
+	if !strings.Contains(out.String(), fmt.Sprintf("Batch of 4 accounts: %d API calls", summary.RequestStats.Requests)) {
+		t.Errorf("summary output %q should total the batch's requests", out.String())
+	}
+}
+
+// BenchmarkAnalyzeBatchMemory streams batches with raw data as a JSON
+// array and reports the peak heap above where each started. Flat memory
+// shows as the same peak for 100 accounts and for 1,000.
+func BenchmarkAnalyzeBatchMemory(b *testing.B) {
+	unspaced(b)
+	for _, n := range []int{100, 1000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			accounts := make([]*fakeAccount, n)
+			logins := make([]string, n)
+			for i := range accounts {
+				logins[i] = fmt.Sprintf("user%04d", i)
+				accounts[i] = newAccount(logins[i], days(1000+i),
+					GitHubRepo{Name: "tool", Language: "Go", Size: 500, StargazersCount: i, UpdatedAt: fakeNow.Add(-days(i % 60))},
+					GitHubRepo{Name: "site", Language: "JavaScript", Size: 200, UpdatedAt: fakeNow.Add(-days(i % 90))},
+				)
+			}
+			analyzer := newFakeAnalyzer(newFakeGitHub(b, accounts...))
+
+			peak := uint64(0)
+			for b.Loop() {
+				runtime.GC()
+				var stats runtime.MemStats
+				runtime.ReadMemStats(&stats)
+				start := stats.HeapInuse
+
+				writer, _ := NewBatchWriter(io.Discard, "json")
+				emitted := 0
+				_, err := analyzer.AnalyzeBatch(context.Background(), logins, true, func(result BatchResult) error {
+					if emitted++; emitted%25 == 0 {
+						runtime.ReadMemStats(&stats)
+						peak = max(peak, stats.HeapInuse-min(start, stats.HeapInuse))
+					}
+					return writer.Write(result)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = writer.Close()
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
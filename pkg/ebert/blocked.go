@@ -0,0 +1,123 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// blockedRepos collects the repos that answered 451 Unavailable For Legal
+// Reasons during one analysis. It rides on the request context so the
+// client can report blocks wherever a check hits one.
+type blockedRepos struct {
+	mu    sync.Mutex
+	repos map[string]struct{}
+}
+
+type blockedKey struct{}
+
+func withBlockedRepos(ctx context.Context, b *blockedRepos) context.Context {
+	return context.WithValue(ctx, blockedKey{}, b)
+}
+
+func blockedReposFrom(ctx context.Context) *blockedRepos {
+	b, _ := ctx.Value(blockedKey{}).(*blockedRepos)
+	return b
+}
+
+// record notes the repo a blocked request URL belongs to
+func (b *blockedRepos) record(rawURL string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "repos" || i+2 >= len(segments) {
+			continue
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.repos == nil {
+			b.repos = map[string]struct{}{}
+		}
+		b.repos[strings.ToLower(segments[i+1]+"/"+segments[i+2])] = struct{}{}
+		return
+	}
+}
+
+// has reports whether fullName has answered 451
+func (b *blockedRepos) has(fullName string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.repos[strings.ToLower(fullName)]
+	return ok
+}
+
+// names lists the blocked repos
+func (b *blockedRepos) names() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	names := make([]string, 0, len(b.repos))
+	for name := range b.repos {
+		names = append(names, name)
+	}
+	return names
+}
+
+// isBlocked reports whether repo is disabled or has answered 451
+func (r *analysisRun) isBlocked(repo GitHubRepo) bool {
+	return repo.Disabled || (r.blocked != nil && r.blocked.has(repo.FullName))
+}
+
+// skipBlocked drops the disabled and 451-blocked repos, whose contents the
+// per-repo checks can't read
+func (r *analysisRun) skipBlocked(repos []GitHubRepo) []GitHubRepo {
+	kept := make([]GitHubRepo, 0, len(repos))
+	for _, repo := range repos {
+		if !r.isBlocked(repo) {
+			kept = append(kept, repo)
+		}
+	}
+	return kept
+}
+
+// checkable returns the most-starred repos the per-repo checks can inspect
+func (r *analysisRun) checkable() []GitHubRepo {
+	return r.skipBlocked(r.acc.top.list())
+}
+
+// checkBlockedRepos flags repos GitHub has disabled or withheld for legal
+// reasons; a DMCA takedown on a maintainer's repo is worth a reviewer's
+// attention
+func (a *Analyzer) checkBlockedRepos(r *analysisRun) {
+	evidence := make([]string, 0, len(r.acc.disabled))
+	seen := map[string]struct{}{}
+	for _, name := range r.acc.disabled {
+		seen[strings.ToLower(name)] = struct{}{}
+		evidence = append(evidence, name+" (disabled)")
+	}
+
+	var legal []string
+	for _, name := range r.blocked.names() {
+		if _, ok := seen[name]; !ok {
+			legal = append(legal, name+" (DMCA/451)")
+		}
+	}
+	sort.Strings(legal)
+	evidence = append(evidence, legal...)
+
+	r.acc.metrics.BlockedRepos = len(evidence)
+	if len(evidence) == 0 {
+		return
+	}
+	r.addFinding(Finding{
+		Code:     "BLOCKED_REPOS",
+		Severity: SeverityRedFlag,
+		Message:  fmt.Sprintf("%d repos disabled by GitHub or unavailable for legal reasons", len(evidence)),
+		Evidence: evidence,
+	})
+}
@@ -0,0 +1,88 @@
+package ebert
+
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBlockedReposRecord(t *testing.T) {
+	var blocked blockedRepos
+	for _, url := range []string{
+		"https://api.github.com/repos/Octo/Tool/contents/README.md",
+		"https://ghe.example.com/api/v3/repos/octo/mirror/commits?per_page=30",
+		// Not a repo request
+		"https://api.github.com/users/octo/repos",
+		"https://api.github.com/repos/octo",
+		"://not a URL",
+	} {
+		blocked.record(url)
+	}
+	names := blocked.names()
+	slices.Sort(names)
+	if want := []string{"octo/mirror", "octo/tool"}; !slices.Equal(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+	if !blocked.has("OCTO/tool") || blocked.has("octo/other") {
+		t.Errorf("has doesn't match octo/tool alone, whatever the case")
+	}
+}
+
+// blockedFake serves octo's tool, frozen, which GitHub has disabled, and
+// takedown, whose contents answer 451, counting the directory listings of
+// the two blocked repos
+func blockedFake(t *testing.T) (f *fakeGitHub, frozen, takedown *atomic.Int32) {
+	repo := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Go", Size: 900, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(3))}
+	}
+	disabled := repo("frozen", 200)
+	disabled.Disabled = true
+	f = newFakeGitHub(t, newAccount("octo", days(3000), repo("tool", 100), disabled, repo("takedown", 50)))
+	serveDirectory(f, "tool", "", "main.go")
+
+	frozen, takedown = &atomic.Int32{}, &atomic.Int32{}
+	f.route("/repos/octo/frozen/contents/", func(w http.ResponseWriter, r *http.Request) {
+		frozen.Add(1)
+		http.Error(w, `{"message":"Repository access blocked"}`, http.StatusForbidden)
+	})
+	f.route("/repos/octo/takedown/contents/", func(w http.ResponseWriter, r *http.Request) {
+		takedown.Add(1)
+		http.Error(w, `{"message":"Repository access blocked"}`, http.StatusUnavailableForLegalReasons)
+	})
+	return f, frozen, takedown
+}
+
+func TestCheckBlockedRepos(t *testing.T) {
+	f, frozen, takedown := blockedFake(t)
+	analysis, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	flag := finding(analysis, "BLOCKED_REPOS")
+	if want := []string{"octo/frozen (disabled)", "octo/takedown (DMCA/451)"}; flag == nil || flag.Severity != SeverityRedFlag || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("BLOCKED_REPOS = %+v, want %q", flag, want)
+	}
+	if analysis.Metrics.BlockedRepos != 2 {
+		t.Errorf("BlockedRepos = %d, want 2", analysis.Metrics.BlockedRepos)
+	}
+	// The disabled repo is never read and the 451 stops later checks
+	if frozen.Load() != 0 || takedown.Load() != 1 {
+		t.Errorf("listed frozen %d times and takedown %d times, want 0 and 1", frozen.Load(), takedown.Load())
+	}
+}
+
+func TestCheckBlockedReposNone(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 100, UpdatedAt: fakeNow.Add(-days(3))}))
+	// Other errors don't count as blocks
+	f.route("/repos/octo/tool/contents/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Forbidden"}`, http.StatusForbidden)
+	})
+	analysis, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if flag := finding(analysis, "BLOCKED_REPOS"); flag != nil || analysis.Metrics.BlockedRepos != 0 {
+		t.Errorf("BLOCKED_REPOS = %+v, BlockedRepos = %d; want neither", flag, analysis.Metrics.BlockedRepos)
+	}
+}
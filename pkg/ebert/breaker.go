@@ -0,0 +1,171 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBreakerThreshold is how many consecutive failures of an external
+// host open its circuit breaker
+const DefaultBreakerThreshold = 3
+
+// DefaultBreakerCooldown is how long an open breaker skips a host's
+// requests before letting one through to probe whether it has recovered
+const DefaultBreakerCooldown = 5 * time.Minute
+
+// skippedIntegrationConfidence scales the confidence once for each external
+// host skipped during an analysis
+const skippedIntegrationConfidence = 0.9
+
+// ErrCircuitOpen is returned for requests to an external host skipped
+// after repeated failures
+var ErrCircuitOpen = errors.New("skipped after repeated failures")
+
+// circuitBreaker counts consecutive failures per external host. Once a host
+// reaches the threshold its requests are skipped, so a batch of analyses
+// stops paying the timeout on a registry that is down. After each cooldown
+// one request is let through as a probe: a success closes the breaker, a
+// failure keeps it open for another cooldown. A zero threshold never opens.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]int
+	opened   map[string]time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		failures:  map[string]int{},
+		opened:    map[string]time.Time{},
+	}
+}
+
+// allow reports whether a request to host may be sent. While the breaker is
+// open only the first request after each cooldown is, as the probe; a probe
+// that never reports back is replaced after the next cooldown.
+func (b *circuitBreaker) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 || b.failures[host] < b.threshold {
+		return true
+	}
+	now := b.now()
+	if now.Before(b.opened[host].Add(b.cooldown)) {
+		return false
+	}
+	b.opened[host] = now
+	return true
+}
+
+// record counts a failed request to host, or closes its breaker on success
+func (b *circuitBreaker) record(host string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		delete(b.failures, host)
+		delete(b.opened, host)
+		return
+	}
+	b.failures[host]++
+	if b.threshold > 0 && b.failures[host] >= b.threshold {
+		// Opening, or a failed probe: skip requests for another cooldown
+		b.opened[host] = b.now()
+	}
+}
+
+// open lists the hosts whose breaker has opened
+func (b *circuitBreaker) open() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var hosts []string
+	for host, failures := range b.failures {
+		if b.threshold > 0 && failures >= b.threshold {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// externalFailure reports whether a response status counts toward a host's
+// breaker: the host is overloaded or broken, not answering for the resource
+func externalFailure(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+// externalHost is the breaker key of a URL
+func externalHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// skippedHosts collects the external hosts whose requests were skipped
+// during one analysis, and why. Like blockedRepos it rides on the request
+// context.
+type skippedHosts struct {
+	mu    sync.Mutex
+	hosts map[string]error
+}
+
+type skippedKey struct{}
+
+func withSkippedHosts(ctx context.Context, s *skippedHosts) context.Context {
+	return context.WithValue(ctx, skippedKey{}, s)
+}
+
+func skippedHostsFrom(ctx context.Context) *skippedHosts {
+	s, _ := ctx.Value(skippedKey{}).(*skippedHosts)
+	return s
+}
+
+// record notes host was skipped for reason, keeping the first reason
+func (s *skippedHosts) record(host string, reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hosts == nil {
+		s.hosts = map[string]error{}
+	}
+	if _, ok := s.hosts[host]; !ok {
+		s.hosts[host] = reason
+	}
+}
+
+// names lists the skipped hosts
+func (s *skippedHosts) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts := make([]string, 0, len(s.hosts))
+	for host := range s.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// reason is why host was skipped
+func (s *skippedHosts) reason(host string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hosts[host]
+}
+
+// TrippedIntegrations lists the external hosts whose circuit breaker is
+// open, whose requests later analyses skip until a probe succeeds
+func (a *Analyzer) TrippedIntegrations() []string {
+	return a.client.shared().breaker.open()
+}
@@ -0,0 +1,103 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerRecovers(t *testing.T) {
+	f := newFakeGitHub(t)
+	// The registry fails three times, then recovers; a probe during the
+	// outage fails too
+	var mu sync.Mutex
+	script := []int{503, 503, 503, 200, 200}
+	f.host("registry.example", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		status := http.StatusOK
+		if len(script) > 0 {
+			status, script = script[0], script[1:]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	})
+
+	c := &GitHubClient{BaseURL: f.URL, HTTPClient: f.client(), BreakerThreshold: 2, BreakerCooldown: time.Minute}
+	now := fakeNow
+	c.shared().breaker.now = func() time.Time { return now }
+	a := &Analyzer{client: c}
+
+	fetch := func() error {
+		_, err := c.getExternal(context.Background(), "https://registry.example/pkg", 1024)
+		return err
+	}
+	sent := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return 5 - len(script)
+	}
+
+	for _, step := range []struct {
+		name    string
+		advance time.Duration
+		open    bool
+		sent    int
+	}{
+		{"first failure", 0, false, 1},
+		{"second failure opens", 0, false, 2},
+		{"skipped while open", 0, true, 2},
+		{"skipped before the cooldown", 59 * time.Second, true, 2},
+		{"failed probe", time.Second, false, 3},
+		{"reopened after the failed probe", 0, true, 3},
+		{"successful probe", time.Minute, false, 4},
+		{"closed", 0, false, 5},
+	} {
+		now = now.Add(step.advance)
+		err := fetch()
+		if got := errors.Is(err, ErrCircuitOpen); got != step.open {
+			t.Errorf("%s: skipped = %v (%v), want %v", step.name, got, err, step.open)
+		}
+		if got := sent(); got != step.sent {
+			t.Errorf("%s: %d requests reached the host, want %d", step.name, got, step.sent)
+		}
+	}
+	if tripped := a.TrippedIntegrations(); len(tripped) != 0 {
+		t.Errorf("tripped %v after recovery, want none", tripped)
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	b := newCircuitBreaker(1, time.Minute)
+	now := fakeNow
+	b.now = func() time.Time { return now }
+
+	b.record("host", true)
+	if b.allow("host") || !slices.Equal(b.open(), []string{"host"}) {
+		t.Fatal("one failure should open a breaker with a threshold of one")
+	}
+
+	// Only one probe is let through per cooldown, even if it never reports
+	now = now.Add(time.Minute)
+	if !b.allow("host") {
+		t.Fatal("want a probe after the cooldown")
+	}
+	if b.allow("host") {
+		t.Error("a second request during the probe should be skipped")
+	}
+	now = now.Add(time.Minute)
+	if !b.allow("host") {
+		t.Error("a lost probe should be replaced after another cooldown")
+	}
+
+	b.record("host", false)
+	if !b.allow("host") || len(b.open()) != 0 {
+		t.Error("a successful probe should close the breaker")
+	}
+	if !newCircuitBreaker(0, time.Minute).allow("host") {
+		t.Error("a zero threshold should never open")
+	}
+}
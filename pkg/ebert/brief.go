@@ -0,0 +1,17 @@
+package ebert
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteBrief writes the analysis as one tab-separated line for scripts:
+//
+//	<login> <overall_score> <risk_level> <red_flag_count>
+//
+// The score has one decimal place. The fields and their order are a
+// stable interface; new fields will only ever be appended.
+func WriteBrief(w io.Writer, analysis *Analysis) error {
+	_, err := fmt.Fprintf(w, "%s\t%.1f\t%s\t%d\n", analysis.User.Login, analysis.OverallScore, analysis.RiskLevel, len(analysis.RedFlags))
+	return err
+}
@@ -0,0 +1,196 @@
+package ebert
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// bundleVersion is the bundle format written by WriteBundle
+const bundleVersion = 1
+
+// maxBundleBytes caps the bytes ReadBundle decompresses
+const maxBundleBytes = 1 << 30
+
+// Files of an audit bundle, in the order they are written
+const (
+	bundleManifestFile = "manifest.json"
+	bundleAnalysisFile = "analysis.json"
+	bundleRawFile      = "raw.json"
+	bundleRequestsFile = "requests.json"
+	bundleOptionsFile  = "options.json"
+	bundleVersionFile  = "version.txt"
+)
+
+// ErrBundleDigest is returned by ReadBundle for a file that doesn't match
+// the digest its manifest records
+var ErrBundleDigest = errors.New("bundle file does not match its digest")
+
+// BundleFile is a file of an audit bundle with its SHA-256 digest in hex
+type BundleFile struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BundleManifest lists an audit bundle's files and whom and when it
+// records
+type BundleManifest struct {
+	Version   int          `json:"version"`
+	Login     string       `json:"login"`
+	Timestamp time.Time    `json:"timestamp"`
+	Files     []BundleFile `json:"files"`
+}
+
+// Bundle is the audit record of one analysis, as ReadBundle found it
+type Bundle struct {
+	Manifest BundleManifest
+	Report   *DetailedAnalysis
+	Requests []LoggedRequest
+}
+
+// WriteBundle writes the audit record of an analysis to w as a gzipped
+// tarball: the analysis, the raw data behind it, the requests it sent,
+// the options and the ebert version it ran with, and a manifest of their
+// SHA-256 digests. The same report and requests always write the same
+// bytes; the only time recorded is the analysis timestamp.
+func WriteBundle(w io.Writer, report *DetailedAnalysis, requests []LoggedRequest) error {
+	if report == nil || report.Analysis == nil {
+		return errors.New("no analysis to bundle")
+	}
+	if requests == nil {
+		requests = []LoggedRequest{}
+	}
+	meta := report.Meta
+	if meta == nil {
+		meta = &AnalysisMeta{Version: "unknown"}
+	}
+
+	files := []struct {
+		name  string
+		value any
+	}{
+		{bundleAnalysisFile, report.Analysis},
+		{bundleRawFile, report.Raw},
+		{bundleRequestsFile, requests},
+		{bundleOptionsFile, meta.Options},
+	}
+	contents := map[string][]byte{bundleVersionFile: []byte(meta.Version + "\n")}
+	manifest := BundleManifest{Version: bundleVersion, Login: report.User.Login, Timestamp: report.Timestamp}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		contents[file.name] = append(data, '\n')
+	}
+	names := []string{bundleAnalysisFile, bundleRawFile, bundleRequestsFile, bundleOptionsFile, bundleVersionFile}
+	for _, name := range names {
+		digest := sha256.Sum256(contents[name])
+		manifest.Files = append(manifest.Files, BundleFile{Name: name, Size: len(contents[name]), SHA256: hex.EncodeToString(digest[:])})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", bundleManifestFile, err)
+	}
+	contents[bundleManifestFile] = append(data, '\n')
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, name := range append([]string{bundleManifestFile}, names...) {
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(contents[name])),
+			ModTime:  report.Timestamp.UTC().Truncate(time.Second),
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatUSTAR,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+		if _, err := tw.Write(contents[name]); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// ReadBundle reads a bundle WriteBundle wrote, checking every file
+// against the manifest's digests
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not an ebert bundle: %w", err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	unpacked := &io.LimitedReader{R: gz, N: maxBundleBytes + 1}
+	tr := tar.NewReader(unpacked)
+	contents := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if unpacked.N <= 0 {
+			return nil, fmt.Errorf("bundle exceeds %d bytes", maxBundleBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from bundle: %w", header.Name, err)
+		}
+		contents[header.Name] = data
+	}
+
+	bundle := &Bundle{}
+	if err := json.Unmarshal(contents[bundleManifestFile], &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("bundle has no readable %s", bundleManifestFile)
+	}
+	for _, file := range bundle.Manifest.Files {
+		data, ok := contents[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("bundle is missing %s", file.Name)
+		}
+		if digest := sha256.Sum256(data); hex.EncodeToString(digest[:]) != file.SHA256 {
+			return nil, fmt.Errorf("%s: %w", file.Name, ErrBundleDigest)
+		}
+	}
+
+	var analysis Analysis
+	if err := json.Unmarshal(contents[bundleAnalysisFile], &analysis); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", bundleAnalysisFile, err)
+	}
+	bundle.Report = &DetailedAnalysis{Analysis: &analysis}
+	if data := bytes.TrimSpace(contents[bundleRawFile]); len(data) > 0 && !bytes.Equal(data, []byte("null")) {
+		bundle.Report.Raw = &RawData{}
+		if err := json.Unmarshal(data, bundle.Report.Raw); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", bundleRawFile, err)
+		}
+	}
+	if err := json.Unmarshal(contents[bundleRequestsFile], &bundle.Requests); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", bundleRequestsFile, err)
+	}
+	return bundle, nil
+}
@@ -0,0 +1,129 @@
+package ebert
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundleReproducible(t *testing.T) {
+	unspaced(t)
+	f := newFakeGitHub(t, syntheticAccount("audit", 30))
+	tape := filepath.Join(t.TempDir(), "audit.json")
+	recorder := NewRecorder(f.client().Transport, "")
+	if _, err := newFakeAnalyzer(f, WithHTTPClient(&http.Client{Transport: recorder}), WithDeepChecks(true)).AnalyzeDetailed("audit"); err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	if err := recorder.Save(tape); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each bundle is built from a fresh replay of the tape, its requests
+	// timed by the recording as the command does
+	bundle := func() []byte {
+		t.Helper()
+		replayer, err := LoadTape(tape)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requestLog := NewRequestLog(replayer, replayer.RecordedAt)
+		report, err := newFakeAnalyzer(f, WithHTTPClient(&http.Client{Transport: requestLog}), WithDeepChecks(true)).AnalyzeDetailed("audit")
+		if err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := WriteBundle(&buf, report, requestLog.Requests()); err != nil {
+			t.Fatalf("WriteBundle: %v", err)
+		}
+		return buf.Bytes()
+	}
+	sent := f.requests.Load()
+	first, second := bundle(), bundle()
+	if f.requests.Load() != sent {
+		t.Errorf("the replays sent %d requests to the API, want none", f.requests.Load()-sent)
+	}
+
+	if sha256.Sum256(first) != sha256.Sum256(second) {
+		a, errA := ReadBundle(bytes.NewReader(first))
+		b, errB := ReadBundle(bytes.NewReader(second))
+		if errA != nil || errB != nil {
+			t.Fatalf("bundles differ and don't read back: %v, %v", errA, errB)
+		}
+		for i, file := range a.Manifest.Files {
+			if file != b.Manifest.Files[i] {
+				t.Errorf("%s differs between identical replays: %s and %s", file.Name, file.SHA256, b.Manifest.Files[i].SHA256)
+			}
+		}
+		t.Fatal("bundles of identical replays differ")
+	}
+
+	read, err := ReadBundle(bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("ReadBundle: %v", err)
+	}
+	if read.Manifest.Login != "audit" || !read.Manifest.Timestamp.Equal(fakeNow) || len(read.Manifest.Files) != 5 {
+		t.Errorf("manifest = %+v, want audit's five files at the analysis time", read.Manifest)
+	}
+	if want := len(f.account("audit").Repos); read.Report.Raw == nil || len(read.Report.Raw.Repos) != want {
+		t.Errorf("the bundle should carry all %d raw repos", want)
+	}
+	if len(read.Requests) == 0 {
+		t.Fatal("the bundle logs no requests")
+	}
+	replayer, err := LoadTape(tape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, request := range read.Requests {
+		if !request.Time.Equal(replayer.RecordedAt()) {
+			t.Errorf("request %s logged at %s, want the recording time", request.URL, request.Time)
+		}
+	}
+}
+
+func TestReadBundleDigestMismatch(t *testing.T) {
+	report := &DetailedAnalysis{Analysis: &Analysis{User: GitHubUser{Login: "audit"}, Timestamp: fakeNow, OverallScore: 12}}
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, report, nil); err != nil {
+		t.Fatalf("WriteBundle: %v", err)
+	}
+
+	// Edit the score beneath the manifest that records the original
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var edited bytes.Buffer
+	gzw := gzip.NewWriter(&edited)
+	tr, tw := tar.NewReader(gz), tar.NewWriter(gzw)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		if header.Name == bundleAnalysisFile {
+			data = bytes.Replace(data, []byte(`"overall_score": 12`), []byte(`"overall_score": 2`), 1)
+			header.Size = int64(len(data))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write(data)
+	}
+	_ = tw.Close()
+	_ = gzw.Close()
+
+	if _, err := ReadBundle(&edited); !errors.Is(err, ErrBundleDigest) {
+		t.Errorf("ReadBundle of an edited analysis = %v, want ErrBundleDigest", err)
+	}
+}
@@ -0,0 +1,246 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// RiskBands are the risk levels an analysis can land in, lowest first
+var RiskBands = []string{"low", "medium", "high"}
+
+// calibrationFindings is how many finding codes a calibration report ranks
+const calibrationFindings = 10
+
+// ParseLabels decodes a YAML map of logins to the risk band each is
+// expected to land in, e.g. "torvalds: low"
+func ParseLabels(data []byte) (map[string]string, error) {
+	var labels map[string]string
+	if err := yaml.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels: %w", err)
+	}
+	if len(labels) == 0 {
+		return nil, errors.New("labels name no accounts")
+	}
+
+	normalized := make(map[string]string, len(labels))
+	for login, band := range labels {
+		band = strings.ToLower(strings.TrimSpace(band))
+		if !slices.Contains(RiskBands, band) {
+			return nil, fmt.Errorf("label for %s must be one of %s, got %q", login, strings.Join(RiskBands, ", "), band)
+		}
+		normalized[NormalizeUsername(login)] = band
+	}
+	return normalized, nil
+}
+
+// LoadLabels reads a YAML labels file from path
+func LoadLabels(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+	return ParseLabels(data)
+}
+
+// LabeledResult is an analysis of an account alongside the band it was
+// expected to land in. Analysis is nil when the account couldn't be
+// analyzed, with the reason in Error.
+type LabeledResult struct {
+	Login    string    `json:"login"`
+	Expected string    `json:"expected"`
+	Analysis *Analysis `json:"analysis,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Misclassification is a labeled account that landed in another band
+type Misclassification struct {
+	Login     string   `json:"login"`
+	Expected  string   `json:"expected"`
+	Predicted string   `json:"predicted"`
+	Score     float64  `json:"score"`
+	Findings  []string `json:"findings"`
+}
+
+// FindingCorrelation compares how often a finding fired on misclassified
+// accounts against correctly classified ones. A large positive Lift marks
+// a finding worth reweighing.
+type FindingCorrelation struct {
+	Code          string  `json:"code"`
+	Misclassified int     `json:"misclassified"`
+	Correct       int     `json:"correct"`
+	Lift          float64 `json:"lift"`
+}
+
+// CalibrationReport measures how well the scoring separates labeled
+// accounts. Confusion counts accounts by expected then predicted band.
+type CalibrationReport struct {
+	Accounts      int                       `json:"accounts"`
+	Analyzed      int                       `json:"analyzed"`
+	Correct       int                       `json:"correct"`
+	Accuracy      float64                   `json:"accuracy"`
+	Confusion     map[string]map[string]int `json:"confusion"`
+	Misclassified []Misclassification       `json:"misclassified"`
+	Findings      []FindingCorrelation      `json:"findings"`
+	Failed        []string                  `json:"failed,omitempty"`
+	Overscored    int                       `json:"overscored"`
+	Underscored   int                       `json:"underscored"`
+}
+
+// Calibrate compares each result's risk level with its expected band and
+// ranks the findings by how much more often they fired on the
+// misclassified accounts than on the rest
+func Calibrate(results []LabeledResult) CalibrationReport {
+	report := CalibrationReport{Accounts: len(results), Confusion: map[string]map[string]int{}}
+	for _, band := range RiskBands {
+		report.Confusion[band] = map[string]int{}
+	}
+
+	missed, hit := map[string]int{}, map[string]int{}
+	for _, result := range results {
+		if result.Analysis == nil {
+			report.Failed = append(report.Failed, result.Login)
+			continue
+		}
+		report.Analyzed++
+
+		predicted := result.Analysis.RiskLevel
+		if report.Confusion[result.Expected] == nil {
+			report.Confusion[result.Expected] = map[string]int{}
+		}
+		report.Confusion[result.Expected][predicted]++
+
+		codes := findingCodes(result.Analysis.Findings)
+		if predicted == result.Expected {
+			report.Correct++
+			for _, code := range codes {
+				hit[code]++
+			}
+			continue
+		}
+
+		for _, code := range codes {
+			missed[code]++
+		}
+		if slices.Index(RiskBands, predicted) > slices.Index(RiskBands, result.Expected) {
+			report.Overscored++
+		} else {
+			report.Underscored++
+		}
+		report.Misclassified = append(report.Misclassified, Misclassification{
+			Login:     result.Login,
+			Expected:  result.Expected,
+			Predicted: predicted,
+			Score:     result.Analysis.OverallScore,
+			Findings:  codes,
+		})
+	}
+
+	if report.Analyzed == 0 {
+		return report
+	}
+	report.Accuracy = float64(report.Correct) / float64(report.Analyzed)
+
+	misclassified := len(report.Misclassified)
+	for code, n := range missed {
+		lift := float64(n) / float64(misclassified)
+		if report.Correct > 0 {
+			lift -= float64(hit[code]) / float64(report.Correct)
+		}
+		report.Findings = append(report.Findings, FindingCorrelation{Code: code, Misclassified: n, Correct: hit[code], Lift: lift})
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Lift != b.Lift {
+			return a.Lift > b.Lift
+		}
+		return a.Code < b.Code
+	})
+	report.Findings = report.Findings[:min(len(report.Findings), calibrationFindings)]
+
+	sort.Slice(report.Misclassified, func(i, j int) bool {
+		return report.Misclassified[i].Login < report.Misclassified[j].Login
+	})
+	return report
+}
+
+// findingCodes lists the distinct codes of findings, sorted
+func findingCodes(findings []Finding) []string {
+	codes := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		if !slices.Contains(codes, finding.Code) {
+			codes = append(codes, finding.Code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// AnalyzeLabeled analyzes each labeled account on the member worker pool,
+// sorted by login. Partial analyses are kept; they are what a real run
+// would have scored.
+func (a *Analyzer) AnalyzeLabeled(ctx context.Context, labels map[string]string) []LabeledResult {
+	logins := make([]string, 0, len(labels))
+	for login := range labels {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	results := make([]LabeledResult, len(logins))
+	forEachConcurrently(len(logins), orgMemberWorkers, func(i int) {
+		result := LabeledResult{Login: logins[i], Expected: labels[logins[i]]}
+		analysis, err := a.AnalyzeContext(ctx, logins[i])
+		result.Analysis = analysis
+		if analysis == nil {
+			result.Error = err.Error()
+		}
+		results[i] = result
+	})
+	return results
+}
+
+// FprintCalibration writes the human-readable calibration report to w
+func FprintCalibration(w io.Writer, report CalibrationReport) {
+	fmt.Fprintf(w, "\n🎯 CALIBRATION (%d of %d accounts analyzed)\n", report.Analyzed, report.Accounts)
+	fmt.Fprintf(w, "   Accuracy:           %.0f%% (%d correct, %d scored too high, %d too low)\n",
+		report.Accuracy*100, report.Correct, report.Overscored, report.Underscored)
+
+	fmt.Fprintf(w, "\n   %-18s", "expected \\ scored")
+	for _, band := range RiskBands {
+		fmt.Fprintf(w, " %7s", band)
+	}
+	fmt.Fprintln(w)
+	for _, expected := range RiskBands {
+		fmt.Fprintf(w, "   %-18s", expected)
+		for _, predicted := range RiskBands {
+			fmt.Fprintf(w, " %7d", report.Confusion[expected][predicted])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Misclassified) > 0 {
+		fmt.Fprintln(w, "\n   Misclassified:")
+		for _, miss := range report.Misclassified {
+			fmt.Fprintf(w, "   • %s: expected %s, scored %s (%.1f)\n", miss.Login, miss.Expected, miss.Predicted, miss.Score)
+		}
+	}
+	if len(report.Findings) > 0 {
+		fmt.Fprintln(w, "\n   Findings most over-represented in misclassified accounts:")
+		for _, finding := range report.Findings {
+			fmt.Fprintf(w, "   • %-28s %+.0f%% (%d misclassified, %d correct)\n",
+				finding.Code, finding.Lift*100, finding.Misclassified, finding.Correct)
+		}
+	}
+	if len(report.Failed) > 0 {
+		fmt.Fprintf(w, "\n   Not analyzed: %s\n", strings.Join(report.Failed, ", "))
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+}
@@ -0,0 +1,189 @@
+package ebert
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels([]byte("torvalds: low\n\" @octo \": ' Medium '\nburned: high\n"))
+	if err != nil {
+		t.Fatalf("ParseLabels: %v", err)
+	}
+	want := map[string]string{"torvalds": "low", "octo": "medium", "burned": "high"}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	for login, band := range want {
+		if labels[login] != band {
+			t.Errorf("labels[%s] = %q, want %q", login, labels[login], band)
+		}
+	}
+
+	for _, tt := range []struct {
+		name, data, want string
+	}{
+		{"empty", "", "labels name no accounts"},
+		{"unknown band", "octo: trusted\n", `label for octo must be one of low, medium, high, got "trusted"`},
+		{"not a map", "- octo\n- burned\n", "failed to parse labels"},
+	} {
+		if _, err := ParseLabels([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ParseLabels = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoadLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.yaml")
+	if err := os.WriteFile(path, []byte("octo: low\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if labels, err := LoadLabels(path); err != nil || labels["octo"] != "low" {
+		t.Errorf("LoadLabels = %v, %v", labels, err)
+	}
+	if _, err := LoadLabels(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "failed to read labels") {
+		t.Errorf("LoadLabels of a missing file = %v", err)
+	}
+}
+
+// labeled is a result for login, expected in one band and scored into
+// predicted with findings of the given codes
+func labeled(login, expected, predicted string, score float64, codes ...string) LabeledResult {
+	analysis := &Analysis{RiskLevel: predicted, OverallScore: score}
+	for _, code := range codes {
+		analysis.Findings = append(analysis.Findings, Finding{Code: code})
+	}
+	return LabeledResult{Login: login, Expected: expected, Analysis: analysis}
+}
+
+func TestCalibrate(t *testing.T) {
+	report := Calibrate([]LabeledResult{
+		labeled("zed", "low", "high", 3.1, "NEW_ACCOUNT", "NO_ACTIVITY", "NEW_ACCOUNT"),
+		labeled("ann", "low", "low", 8.2, "ESTABLISHED"),
+		labeled("bob", "low", "low", 7.9, "ESTABLISHED", "NO_ACTIVITY"),
+		labeled("carl", "high", "medium", 5.5, "ESTABLISHED", "FORKS_ONLY"),
+		labeled("dora", "high", "high", 2.0, "NEW_ACCOUNT", "FORKS_ONLY"),
+		{Login: "ghost", Expected: "medium", Error: "user not found"},
+	})
+
+	if report.Accounts != 6 || report.Analyzed != 5 || report.Correct != 3 || report.Accuracy != 0.6 {
+		t.Errorf("report %d of %d analyzed, %d correct (%v)", report.Analyzed, report.Accounts, report.Correct, report.Accuracy)
+	}
+	if report.Overscored != 1 || report.Underscored != 1 || !slices.Equal(report.Failed, []string{"ghost"}) {
+		t.Errorf("%d overscored, %d underscored, failed %q", report.Overscored, report.Underscored, report.Failed)
+	}
+	for _, cell := range []struct {
+		expected, predicted string
+		n                   int
+	}{
+		{"low", "low", 2}, {"low", "high", 1}, {"high", "medium", 1}, {"high", "high", 1}, {"medium", "medium", 0},
+	} {
+		if got := report.Confusion[cell.expected][cell.predicted]; got != cell.n {
+			t.Errorf("Confusion[%s][%s] = %d, want %d", cell.expected, cell.predicted, got, cell.n)
+		}
+	}
+
+	// Sorted by login, each finding code once
+	if len(report.Misclassified) != 2 || report.Misclassified[0].Login != "carl" || report.Misclassified[1].Login != "zed" {
+		t.Fatalf("Misclassified = %+v, want carl then zed", report.Misclassified)
+	}
+	if zed := report.Misclassified[1]; zed.Expected != "low" || zed.Predicted != "high" || zed.Score != 3.1 || !slices.Equal(zed.Findings, []string{"NEW_ACCOUNT", "NO_ACTIVITY"}) {
+		t.Errorf("zed = %+v", zed)
+	}
+
+	// ESTABLISHED fired on every correct account too, so it trails
+	var codes []string
+	for _, finding := range report.Findings {
+		codes = append(codes, finding.Code)
+	}
+	if !slices.Equal(codes, []string{"FORKS_ONLY", "NEW_ACCOUNT", "NO_ACTIVITY", "ESTABLISHED"}) {
+		t.Errorf("findings ranked %q", codes)
+	}
+	if first := report.Findings[0]; first.Misclassified != 1 || first.Correct != 1 || math.Abs(first.Lift-(0.5-1.0/3)) > 1e-9 {
+		t.Errorf("FORKS_ONLY = %+v", first)
+	}
+}
+
+func TestCalibrateEdges(t *testing.T) {
+	// Nothing analyzed leaves the ratios at zero
+	report := Calibrate([]LabeledResult{{Login: "ghost", Expected: "low", Error: "user not found"}})
+	if report.Analyzed != 0 || report.Accuracy != 0 || report.Findings != nil || len(report.Confusion) != len(RiskBands) {
+		t.Errorf("report = %+v", report)
+	}
+
+	// With no correct accounts, lift is the misclassified share alone
+	report = Calibrate([]LabeledResult{
+		labeled("a", "low", "high", 2, "NEW_ACCOUNT"),
+		labeled("b", "high", "low", 8, "ESTABLISHED", "NEW_ACCOUNT"),
+	})
+	if report.Accuracy != 0 || report.Overscored != 1 || report.Underscored != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if first := report.Findings[0]; first.Code != "NEW_ACCOUNT" || first.Lift != 1 {
+		t.Errorf("first finding %+v, want NEW_ACCOUNT with lift 1", first)
+	}
+
+	// Only the ten most over-represented findings are ranked
+	var codes []string
+	for _, c := range "ABCDEFGHIJKL" {
+		codes = append(codes, string(c))
+	}
+	report = Calibrate([]LabeledResult{labeled("a", "low", "high", 2, codes...)})
+	if len(report.Findings) != calibrationFindings || report.Findings[calibrationFindings-1].Code != "J" {
+		t.Errorf("ranked %d findings: %+v", len(report.Findings), report.Findings)
+	}
+}
+
+func TestFprintCalibration(t *testing.T) {
+	report := Calibrate([]LabeledResult{
+		labeled("ann", "low", "low", 8.2, "ESTABLISHED"),
+		labeled("zed", "low", "high", 3.1, "NEW_ACCOUNT"),
+		{Login: "ghost", Expected: "medium", Error: "user not found"},
+	})
+	var out strings.Builder
+	FprintCalibration(&out, report)
+	for _, want := range []string{
+		"🎯 CALIBRATION (2 of 3 accounts analyzed)",
+		"Accuracy:           50% (1 correct, 1 scored too high, 0 too low)",
+		"expected \\ scored      low  medium    high",
+		"low                      1       0       1",
+		"• zed: expected low, scored high (3.1)",
+		"• NEW_ACCOUNT                  +100% (1 misclassified, 0 correct)",
+		"Not analyzed: ghost",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestAnalyzeLabeled(t *testing.T) {
+	repo := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), repo), newAccount("fresh", days(3)))
+	labels := map[string]string{"octo": "low", "fresh": "high", "ghost": "medium"}
+
+	results := newFakeAnalyzer(f).AnalyzeLabeled(context.Background(), labels)
+	var logins []string
+	for _, result := range results {
+		logins = append(logins, result.Login)
+		if result.Expected != labels[result.Login] {
+			t.Errorf("%s expected %q, want %q", result.Login, result.Expected, labels[result.Login])
+		}
+		if analyzed := result.Analysis != nil; analyzed != (result.Login != "ghost") || analyzed != (result.Error == "") {
+			t.Errorf("%s: analysis %v, error %q", result.Login, analyzed, result.Error)
+		}
+	}
+	if !slices.Equal(logins, []string{"fresh", "ghost", "octo"}) {
+		t.Errorf("results for %q, want them sorted by login", logins)
+	}
+
+	report := Calibrate(results)
+	if report.Analyzed != 2 || !slices.Equal(report.Failed, []string{"ghost"}) {
+		t.Errorf("report %+v", report)
+	}
+}
@@ -0,0 +1,174 @@
+package ebert
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLang is the language of the built-in messages
+const DefaultLang = "en"
+
+//go:embed catalogs/*.json
+var builtinCatalogs embed.FS
+
+// catalogPlaceholder matches the indexed placeholders in catalog
+// messages, e.g. {0}, so translations can reorder the values
+var catalogPlaceholder = regexp.MustCompile(`\{(\d+)\}`)
+
+// Catalog holds the printed report's section labels and finding messages
+// in one language. Finding messages are keyed "finding.<CODE>"; keys it
+// lacks fall back to English. Only the printed report is localized; JSON
+// keeps the English messages and stable codes.
+type Catalog struct {
+	Lang     string
+	messages map[string]string
+	fallback *Catalog
+}
+
+var englishCatalog = mustBuiltinCatalog(DefaultLang)
+
+// mustBuiltinCatalog parses an embedded catalog, which can only fail on
+// a broken build
+func mustBuiltinCatalog(lang string) *Catalog {
+	data, err := builtinCatalogs.ReadFile("catalogs/" + lang + ".json")
+	if err != nil {
+		panic(fmt.Sprintf("ebert: embedded catalog %s: %v", lang, err))
+	}
+	messages, err := decodeCatalog(lang, data)
+	if err != nil {
+		panic(fmt.Sprintf("ebert: embedded catalog %s: %v", lang, err))
+	}
+	return &Catalog{Lang: lang, messages: messages}
+}
+
+// EnglishCatalog returns the built-in English catalog
+func EnglishCatalog() *Catalog {
+	return englishCatalog
+}
+
+// CatalogLanguages lists the languages with a built-in catalog
+func CatalogLanguages() []string {
+	entries, _ := fs.ReadDir(builtinCatalogs, "catalogs")
+	langs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		langs = append(langs, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// ParseCatalog decodes a JSON object of message keys to messages
+func ParseCatalog(lang string, data []byte) (*Catalog, error) {
+	messages, err := decodeCatalog(lang, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Catalog{Lang: lang, messages: messages, fallback: englishCatalog}, nil
+}
+
+func decodeCatalog(lang string, data []byte) (map[string]string, error) {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse %s catalog: %w", lang, err)
+	}
+	return messages, nil
+}
+
+// LoadCatalog returns the catalog for lang, e.g. "de": <dir>/<lang>.json
+// when dir is set and holds one, else the built-in catalog. An empty lang
+// or DefaultLang is English.
+func LoadCatalog(lang, dir string) (*Catalog, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" || lang == DefaultLang {
+		return englishCatalog, nil
+	}
+	if strings.ContainsAny(lang, `/\.`) {
+		return nil, fmt.Errorf("invalid language %q", lang)
+	}
+
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, lang+".json"))
+		if err == nil {
+			return ParseCatalog(lang, data)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s catalog: %w", lang, err)
+		}
+	}
+
+	data, err := builtinCatalogs.ReadFile("catalogs/" + lang + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("no catalog for language %q; built in: %s", lang, strings.Join(CatalogLanguages(), ", "))
+	}
+	return ParseCatalog(lang, data)
+}
+
+// Text renders the message for key with args in place of its indexed
+// placeholders, falling back to English and then to the key itself
+func (c *Catalog) Text(key string, args ...any) string {
+	message, ok := c.lookup(key, len(args))
+	if !ok {
+		return key
+	}
+	return interpolate(message, args)
+}
+
+// lookup finds key in the catalog or its fallback, skipping translations
+// that refer to more values than given
+func (c *Catalog) lookup(key string, n int) (string, bool) {
+	for catalog := c; catalog != nil; catalog = catalog.fallback {
+		if message, ok := catalog.messages[key]; ok && maxPlaceholder(message) < n {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Finding renders a finding's message. The English message stands unless
+// the catalog translates its code with no more placeholders than the
+// finding has Args.
+func (c *Catalog) Finding(finding Finding) string {
+	if c == nil || c == englishCatalog {
+		return finding.Message
+	}
+	message, ok := c.messages["finding."+finding.Code]
+	if !ok || maxPlaceholder(message) >= len(finding.Args) {
+		return finding.Message
+	}
+	args := make([]any, len(finding.Args))
+	for i, arg := range finding.Args {
+		args[i] = arg
+	}
+	return interpolate(message, args)
+}
+
+// maxPlaceholder is the highest placeholder index in message, or -1
+func maxPlaceholder(message string) int {
+	highest := -1
+	for _, match := range catalogPlaceholder.FindAllStringSubmatch(message, -1) {
+		if i, err := strconv.Atoi(match[1]); err == nil {
+			highest = max(highest, i)
+		}
+	}
+	return highest
+}
+
+// interpolate replaces each {i} in message with args[i]
+func interpolate(message string, args []any) string {
+	return catalogPlaceholder.ReplaceAllStringFunc(message, func(placeholder string) string {
+		i, _ := strconv.Atoi(placeholder[1 : len(placeholder)-1])
+		if i >= len(args) {
+			return placeholder
+		}
+		return fmt.Sprint(args[i])
+	})
+}
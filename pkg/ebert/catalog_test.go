@@ -0,0 +1,131 @@
+package ebert
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// catalogStats is the request cost of a run: cached requests and a known
+// rate limit, so every footer key is used
+var catalogStats = &RequestStats{Requests: 1234, CacheHits: 56, RateLimit: &RateLimit{Remaining: 4812, Reset: time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)}}
+
+func TestCatalogFooter(t *testing.T) {
+	german, err := LoadCatalog("de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		catalog *Catalog
+		want    string
+	}{
+		{englishCatalog, "1,234 API calls (56 cached), 4,812 remaining until 16:00 UTC"},
+		// The German message puts the reset time before the remaining budget
+		{german, "1,234 API-Aufrufe (56 aus dem Cache); bis 16:00 UTC noch 4,812 verfügbar"},
+	} {
+		if got := requestFooter(catalogStats, tc.catalog); got != tc.want {
+			t.Errorf("%s footer = %q, want %q", tc.catalog.Lang, got, tc.want)
+		}
+	}
+}
+
+func TestCatalogMetaSummary(t *testing.T) {
+	meta := &AnalysisMeta{Version: "v0.7.0", Options: AnalyzerOptions{ScoringVersion: ScoringV2, ActivityWindow: 90 * 24 * time.Hour, DeepChecks: true}}
+	german, err := LoadCatalog("de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := meta.Summary(), "ebert v0.7.0, scoring v2, window 90d, deep checks on"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if got, want := meta.summary(german), "ebert v0.7.0, Bewertung v2, Zeitraum 90 T., Tiefenprüfung an"; got != want {
+		t.Errorf("German summary = %q, want %q", got, want)
+	}
+}
+
+func TestCatalogFallsBackToEnglish(t *testing.T) {
+	// A partial translation: the request count only, and a rate limit
+	// message that wants more values than the footer has
+	partial, err := ParseCatalog("xx", []byte(`{
+		"footer.requests": "{0} Anfragen",
+		"footer.rate_limit": " [{2}] {1} {0}"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1,234 Anfragen (56 cached), 4,812 remaining until 16:00 UTC"
+	if got := requestFooter(catalogStats, partial); got != want {
+		t.Errorf("partial footer = %q, want %q", got, want)
+	}
+	if got := partial.Text("no.such.key"); got != "no.such.key" {
+		t.Errorf("a key no catalog has rendered %q, want the key", got)
+	}
+}
+
+func TestCatalogReorderedPlaceholders(t *testing.T) {
+	catalog, err := ParseCatalog("xx", []byte(`{"report.user": "@{1} ({0}), @{1}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := catalog.Text("report.user", "The Octocat", "octocat"), "@octocat (The Octocat), @octocat"; got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+}
+
+func TestCatalogKeysHaveEnglish(t *testing.T) {
+	for _, lang := range CatalogLanguages() {
+		catalog, err := LoadCatalog(lang, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, message := range catalog.messages {
+			english, ok := englishCatalog.messages[key]
+			if !ok {
+				if !strings.HasPrefix(key, "finding.") && !strings.HasPrefix(key, "remediation.") {
+					t.Errorf("%s translates %s, which has no English message", lang, key)
+				}
+				continue
+			}
+			if maxPlaceholder(message) > maxPlaceholder(english) {
+				t.Errorf("%s %s refers to more values than the English %q", lang, key, english)
+			}
+		}
+	}
+}
+
+func TestCatalogJSONStaysEnglish(t *testing.T) {
+	german, err := LoadCatalog("de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis := &Analysis{
+		User:         GitHubUser{Login: "octo", Name: "Octo"},
+		OverallScore: 40,
+		RiskLevel:    "medium",
+		Findings:     []Finding{{Code: "LOW_ACTIVITY", Severity: SeverityWarning, Message: "Low activity (last 90 days)", Args: []string{"90"}}},
+		Warnings:     []string{"Low activity (last 90 days)"},
+		RequestStats: catalogStats,
+	}
+
+	var report strings.Builder
+	FprintReport(&report, analysis, ReportOptions{Catalog: german})
+	for _, want := range []string{"Geringe Aktivität (letzte 90 Tage)", "MITTEL", "API-Aufrufe"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("the German report lacks %q:\n%s", want, report.String())
+		}
+	}
+
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"code":"LOW_ACTIVITY"`, `"message":"Low activity (last 90 days)"`, `"risk_level":"medium"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("the JSON lacks %s", want)
+		}
+	}
+	if strings.Contains(string(data), "Aktivität") {
+		t.Error("printing a German report translated the JSON")
+	}
+}
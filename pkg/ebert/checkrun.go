@@ -0,0 +1,208 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// checkRunName is the name the check run is listed under on the commit
+	checkRunName = "ebert"
+
+	// maxAnnotationsPerRequest is the most annotations the Checks API
+	// accepts in one create or update request
+	maxAnnotationsPerRequest = 50
+
+	// maxCheckRunSummary is the Checks API's limit on the summary length
+	maxCheckRunSummary = 65535
+)
+
+// ErrChecksRequireToken is returned for check runs without a token; the
+// Checks API takes a GitHub App installation token or an Actions
+// GITHUB_TOKEN with checks: write
+var ErrChecksRequireToken = errors.New("Checks API requires a token")
+
+// AnnotationTarget is the manifest line check-run annotations are attached
+// to, typically the one that added the dependency
+type AnnotationTarget struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+}
+
+// ParseAnnotationTarget parses "path:line", e.g. "go.mod:14"
+func ParseAnnotationTarget(s string) (AnnotationTarget, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return AnnotationTarget{}, fmt.Errorf("annotation target %q is not path:line", s)
+	}
+	path, line := s[:i], s[i+1:]
+	n, err := strconv.Atoi(line)
+	if path == "" || err != nil || n < 1 {
+		return AnnotationTarget{}, fmt.Errorf("annotation target %q is not path:line", s)
+	}
+	return AnnotationTarget{Path: path, Line: n}, nil
+}
+
+// CheckRunConclusion maps an analysis to a check conclusion: failure when
+// it fails policy, neutral when warnings or red flags remain, success
+// otherwise
+func CheckRunConclusion(a *Analysis, policy FindingPolicy) string {
+	if policy.Check(a) != nil {
+		return "failure"
+	}
+	if len(a.RedFlags) > 0 || len(a.Warnings) > 0 {
+		return "neutral"
+	}
+	return "success"
+}
+
+// checkRunAnnotation is one annotation in a check run's output
+type checkRunAnnotation struct {
+	Path       string `json:"path"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Level      string `json:"annotation_level"`
+	Title      string `json:"title"`
+	Message    string `json:"message"`
+	RawDetails string `json:"raw_details,omitempty"`
+}
+
+// checkRunOutput is the title, summary and annotations shown on the run
+type checkRunOutput struct {
+	Title       string               `json:"title"`
+	Summary     string               `json:"summary"`
+	Annotations []checkRunAnnotation `json:"annotations,omitempty"`
+}
+
+// checkRunRequest is the create body; updates send only the output
+type checkRunRequest struct {
+	Name        string         `json:"name"`
+	HeadSHA     string         `json:"head_sha"`
+	Status      string         `json:"status"`
+	Conclusion  string         `json:"conclusion"`
+	CompletedAt time.Time      `json:"completed_at"`
+	Output      checkRunOutput `json:"output"`
+}
+
+// checkRunAnnotations turns the analysis's red flags, warnings and info
+// findings into annotations on target, with warnings promoted as the
+// policy asks
+func checkRunAnnotations(a *Analysis, target AnnotationTarget, policy FindingPolicy) []checkRunAnnotation {
+	gated := FilterFindings(a, FindingPolicy{WarningsAsErrors: policy.WarningsAsErrors})
+	var annotations []checkRunAnnotation
+	for _, finding := range gated.Findings {
+		var level string
+		switch finding.Severity {
+		case SeverityRedFlag:
+			level = "failure"
+		case SeverityWarning:
+			level = "warning"
+		case SeverityInfo:
+			level = "notice"
+		default:
+			continue
+		}
+		annotations = append(annotations, checkRunAnnotation{
+			Path:       target.Path,
+			StartLine:  target.Line,
+			EndLine:    target.Line,
+			Level:      level,
+			Title:      finding.Code,
+			Message:    finding.Message,
+			RawDetails: strings.Join(finding.Evidence, "\n"),
+		})
+	}
+	return annotations
+}
+
+// checkRunRequests builds the create body and the output-only updates
+// carrying the annotations beyond the first request's
+func checkRunRequests(headSHA string, a *Analysis, target AnnotationTarget, policy FindingPolicy) (checkRunRequest, []checkRunOutput) {
+	var summary bytes.Buffer
+	FprintMarkdown(&summary, a)
+	output := checkRunOutput{
+		Title:   fmt.Sprintf("@%s: %s risk (%.1f)", a.User.Login, a.RiskLevel, a.OverallScore),
+		Summary: truncateRunes(summary.String(), maxCheckRunSummary),
+	}
+
+	annotations := checkRunAnnotations(a, target, policy)
+	create := checkRunRequest{
+		Name:        checkRunName,
+		HeadSHA:     headSHA,
+		Status:      "completed",
+		Conclusion:  CheckRunConclusion(a, policy),
+		CompletedAt: a.Timestamp.UTC(),
+		Output:      output,
+	}
+	create.Output.Annotations = annotations[:min(len(annotations), maxAnnotationsPerRequest)]
+
+	var updates []checkRunOutput
+	for start := maxAnnotationsPerRequest; start < len(annotations); start += maxAnnotationsPerRequest {
+		update := output
+		update.Annotations = annotations[start:min(start+maxAnnotationsPerRequest, len(annotations))]
+		updates = append(updates, update)
+	}
+	return create, updates
+}
+
+// truncateRunes cuts s to at most n bytes without splitting a character
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+// CreateCheckRun attaches the analysis to headSHA in owner/repo as a
+// completed check run: the verdict under policy is the conclusion, and
+// each red flag, warning and info finding an annotation on the manifest
+// line. Annotations past the Checks API's 50 per request are added by
+// updating the run.
+func (c *GitHubClient) CreateCheckRun(ctx context.Context, owner, repo, headSHA string, a *Analysis, manifest AnnotationTarget, policy FindingPolicy) (err error) {
+	ctx, span := c.startSpan(ctx, "github.check_run", "repos/:owner/:repo/check-runs")
+	defer func() { endSpan(span, err) }()
+
+	if !c.authenticated() {
+		return ErrChecksRequireToken
+	}
+
+	create, updates := checkRunRequests(headSHA, a, manifest, policy)
+	body, err := json.Marshal(create)
+	if err != nil {
+		return fmt.Errorf("failed to encode check run: %w", err)
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs", c.BaseURL, owner, repo)
+	data, err := c.send(ctx, apiRequest{method: "POST", url: url, accept: defaultAccept, body: body})
+	if err != nil {
+		return fmt.Errorf("failed to create check run: %w", err)
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("failed to decode check run: %w", err)
+	}
+	for _, output := range updates {
+		body, err := json.Marshal(map[string]checkRunOutput{"output": output})
+		if err != nil {
+			return fmt.Errorf("failed to encode check run annotations: %w", err)
+		}
+		if _, err := c.send(ctx, apiRequest{method: "PATCH", url: fmt.Sprintf("%s/%d", url, created.ID), accept: defaultAccept, body: body}); err != nil {
+			return fmt.Errorf("failed to add check run annotations: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateCheckRun attaches the analysis to a commit as a check run; see
+// GitHubClient.CreateCheckRun
+func (a *Analyzer) CreateCheckRun(ctx context.Context, owner, repo, headSHA string, analysis *Analysis, manifest AnnotationTarget, policy FindingPolicy) error {
+	return a.client.CreateCheckRun(ctx, owner, repo, headSHA, analysis, manifest, policy)
+}
@@ -0,0 +1,217 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseAnnotationTarget(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want AnnotationTarget
+		ok   bool
+	}{
+		{"go.mod:14", AnnotationTarget{Path: "go.mod", Line: 14}, true},
+		// Only the last colon separates the line
+		{"c:/src/go.mod:3", AnnotationTarget{Path: "c:/src/go.mod", Line: 3}, true},
+		{"go.mod", AnnotationTarget{}, false},
+		{"go.mod:", AnnotationTarget{}, false},
+		{":14", AnnotationTarget{}, false},
+		{"go.mod:0", AnnotationTarget{}, false},
+		{"go.mod:l4", AnnotationTarget{}, false},
+	} {
+		got, err := ParseAnnotationTarget(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseAnnotationTarget(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// checkRunFixture is an analysis with the given findings, split by
+// severity as an analysis would be
+func checkRunFixture(findings ...Finding) *Analysis {
+	a := &Analysis{User: GitHubUser{Login: "octo"}, Timestamp: fakeNow, OverallScore: 42, RiskLevel: "medium", Findings: findings}
+	a.RedFlags, a.Warnings, a.Positives = splitFindings(findings)
+	return a
+}
+
+func TestCheckRunConclusion(t *testing.T) {
+	positive := Finding{Code: "ESTABLISHED", Severity: SeverityPositive, Message: "Established account"}
+	info := Finding{Code: "BUS_FACTOR", Severity: SeverityInfo, Message: "A single maintainer"}
+	warning := Finding{Code: "NO_LICENSE", Severity: SeverityWarning, Message: "Repos without a license"}
+	redFlag := Finding{Code: "SECRET_IN_REPO", Severity: SeverityRedFlag, Message: "Possible secret committed"}
+	none := 0
+	for _, tt := range []struct {
+		name     string
+		findings []Finding
+		policy   FindingPolicy
+		want     string
+	}{
+		{"clean", []Finding{positive, info}, FindingPolicy{}, "success"},
+		{"warning", []Finding{positive, warning}, FindingPolicy{}, "neutral"},
+		{"red flag", []Finding{redFlag}, FindingPolicy{}, "neutral"},
+		{"warnings as errors", []Finding{warning}, FindingPolicy{WarningsAsErrors: true}, "failure"},
+		{"too many warnings", []Finding{warning}, FindingPolicy{MaxWarnings: &none}, "failure"},
+		{"within the policy", []Finding{info}, FindingPolicy{WarningsAsErrors: true, MaxWarnings: &none}, "success"},
+	} {
+		if got := CheckRunConclusion(checkRunFixture(tt.findings...), tt.policy); got != tt.want {
+			t.Errorf("%s: CheckRunConclusion = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// checkRunAPI records the create and update requests to octo/tool's check
+// runs, answering the create with run 7
+type checkRunAPI struct {
+	mu      sync.Mutex
+	create  checkRunRequest
+	updates []checkRunOutput
+	errors  []string
+}
+
+func serveCheckRuns(f *fakeGitHub) *checkRunAPI {
+	api := &checkRunAPI{}
+	record := func(r *http.Request, method string, into any) bool {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		if r.Method != method || r.Header.Get("Authorization") != "token token" {
+			api.errors = append(api.errors, fmt.Sprintf("%s %s with %q", r.Method, r.URL.Path, r.Header.Get("Authorization")))
+			return false
+		}
+		if err := json.NewDecoder(r.Body).Decode(into); err != nil {
+			api.errors = append(api.errors, fmt.Sprintf("%s %s: %v", r.Method, r.URL.Path, err))
+			return false
+		}
+		return true
+	}
+	f.route("/repos/octo/tool/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		if !record(r, http.MethodPost, &api.create) {
+			http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":7}`))
+	})
+	f.route("/repos/octo/tool/check-runs/7", func(w http.ResponseWriter, r *http.Request) {
+		var update map[string]checkRunOutput
+		if !record(r, http.MethodPatch, &update) {
+			http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		api.mu.Lock()
+		api.updates = append(api.updates, update["output"])
+		api.mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":7}`))
+	})
+	return api
+}
+
+func TestCreateCheckRun(t *testing.T) {
+	f := newFakeGitHub(t)
+	api := serveCheckRuns(f)
+	analysis := checkRunFixture(
+		Finding{Code: "SECRET_IN_REPO", Severity: SeverityRedFlag, Message: "Possible secret committed", Evidence: []string{"tool/.env", "lib/.npmrc"}},
+		Finding{Code: "NO_LICENSE", Severity: SeverityWarning, Message: "Repos without a license", Evidence: []string{"lib"}},
+		Finding{Code: "BUS_FACTOR", Severity: SeverityInfo, Message: "A single maintainer"},
+		Finding{Code: "ESTABLISHED", Severity: SeverityPositive, Message: "Established account"},
+	)
+	manifest := AnnotationTarget{Path: "go.mod", Line: 14}
+	if err := newFakeAnalyzerToken(f, "token").CreateCheckRun(context.Background(), "octo", "tool", "abc123", analysis, manifest, FindingPolicy{}); err != nil {
+		t.Fatalf("CreateCheckRun: %v", err)
+	}
+	if len(api.errors) > 0 {
+		t.Fatalf("unexpected requests: %q", api.errors)
+	}
+
+	create := api.create
+	if create.Name != "ebert" || create.HeadSHA != "abc123" || create.Status != "completed" || create.Conclusion != "neutral" || !create.CompletedAt.Equal(fakeNow) {
+		t.Errorf("check run %+v, want a completed neutral ebert run on abc123", create)
+	}
+	if create.Output.Title != "@octo: medium risk (42.0)" || !strings.Contains(create.Output.Summary, "octo") {
+		t.Errorf("output title %q, summary %q", create.Output.Title, create.Output.Summary)
+	}
+	// Positives aren't annotated
+	want := []checkRunAnnotation{
+		{Path: "go.mod", StartLine: 14, EndLine: 14, Level: "failure", Title: "SECRET_IN_REPO", Message: "Possible secret committed", RawDetails: "tool/.env\nlib/.npmrc"},
+		{Path: "go.mod", StartLine: 14, EndLine: 14, Level: "warning", Title: "NO_LICENSE", Message: "Repos without a license", RawDetails: "lib"},
+		{Path: "go.mod", StartLine: 14, EndLine: 14, Level: "notice", Title: "BUS_FACTOR", Message: "A single maintainer"},
+	}
+	if fmt.Sprint(create.Output.Annotations) != fmt.Sprint(want) {
+		t.Errorf("annotations %+v, want %+v", create.Output.Annotations, want)
+	}
+	if len(api.updates) != 0 {
+		t.Errorf("%d updates, want none", len(api.updates))
+	}
+
+	// Promoted warnings are annotated, and concluded, as failures
+	api = serveCheckRuns(f)
+	if err := newFakeAnalyzerToken(f, "token").CreateCheckRun(context.Background(), "octo", "tool", "abc123", analysis, manifest, FindingPolicy{WarningsAsErrors: true}); err != nil {
+		t.Fatalf("CreateCheckRun: %v", err)
+	}
+	if api.create.Conclusion != "failure" || len(api.create.Output.Annotations) != 3 || api.create.Output.Annotations[1].Level != "failure" {
+		t.Errorf("with warnings as errors: %s, annotations %+v", api.create.Conclusion, api.create.Output.Annotations)
+	}
+}
+
+func TestCreateCheckRunBatches(t *testing.T) {
+	f := newFakeGitHub(t)
+	api := serveCheckRuns(f)
+	var findings []Finding
+	for i := range 120 {
+		findings = append(findings, Finding{Code: fmt.Sprintf("INFO_%03d", i), Severity: SeverityInfo, Message: "Noted"})
+	}
+	if err := newFakeAnalyzerToken(f, "token").CreateCheckRun(context.Background(), "octo", "tool", "abc123", checkRunFixture(findings...), AnnotationTarget{Path: "go.mod", Line: 1}, FindingPolicy{}); err != nil {
+		t.Fatalf("CreateCheckRun: %v", err)
+	}
+	if len(api.errors) > 0 {
+		t.Fatalf("unexpected requests: %q", api.errors)
+	}
+
+	// 50 on creating the run, then 50 and 20 more
+	batches := [][]checkRunAnnotation{api.create.Output.Annotations}
+	for _, update := range api.updates {
+		if update.Title != api.create.Output.Title || update.Summary != api.create.Output.Summary {
+			t.Errorf("update output %q, %q doesn't repeat the run's", update.Title, update.Summary)
+		}
+		batches = append(batches, update.Annotations)
+	}
+	var titles []string
+	for i, want := range []int{50, 50, 20} {
+		if i >= len(batches) || len(batches[i]) != want {
+			t.Fatalf("%d batches, want 50, 50 and 20", len(batches))
+		}
+		for _, annotation := range batches[i] {
+			titles = append(titles, annotation.Title)
+		}
+	}
+	if len(batches) != 3 {
+		t.Errorf("%d batches, want 3", len(batches))
+	}
+	for i, title := range titles {
+		if want := fmt.Sprintf("INFO_%03d", i); title != want {
+			t.Fatalf("annotation %d is %s, want %s", i, title, want)
+		}
+	}
+}
+
+func TestCreateCheckRunErrors(t *testing.T) {
+	f := newFakeGitHub(t)
+	serveCheckRuns(f)
+	analysis := checkRunFixture(Finding{Code: "BUS_FACTOR", Severity: SeverityInfo, Message: "A single maintainer"})
+	err := newFakeAnalyzer(f).CreateCheckRun(context.Background(), "octo", "tool", "abc123", analysis, AnnotationTarget{Path: "go.mod", Line: 1}, FindingPolicy{})
+	if !errors.Is(err, ErrChecksRequireToken) || f.requests.Load() != 0 {
+		t.Errorf("without a token: %v after %d requests, want ErrChecksRequireToken and none", err, f.requests.Load())
+	}
+
+	// The repo has no check-runs route here
+	err = newFakeAnalyzerToken(f, "token").CreateCheckRun(context.Background(), "octo", "other", "abc123", analysis, AnnotationTarget{Path: "go.mod", Line: 1}, FindingPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "failed to create check run") {
+		t.Errorf("CreateCheckRun = %v, want the create failing", err)
+	}
+}
@@ -0,0 +1,144 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RefPayload is the payload of a CreateEvent or DeleteEvent
+type RefPayload struct {
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"` // repository, branch or tag
+}
+
+// RefPayload decodes the payload of a CreateEvent or DeleteEvent
+func (e *GitHubEvent) RefPayload() (RefPayload, error) {
+	var payload RefPayload
+	if e.Type != "CreateEvent" && e.Type != "DeleteEvent" {
+		return payload, fmt.Errorf("event type %s is not a CreateEvent or DeleteEvent", e.Type)
+	}
+	if len(e.Payload) == 0 {
+		return payload, nil
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return payload, fmt.Errorf("failed to decode ref payload: %w", err)
+	}
+	return payload, nil
+}
+
+// repoChurn tracks the repo lifecycle evidence in the repo list and feed
+type repoChurn struct {
+	// listed maps the lowercase full name of each listed repo the events
+	// feed names to its creation time
+	listed map[string]time.Time
+
+	// created and deleted hold repository create and delete events, and
+	// firstSeen the earliest event of any kind, by lowercase full name
+	created   map[string]time.Time
+	deleted   map[string]time.Time
+	firstSeen map[string]time.Time
+}
+
+func newRepoChurn() repoChurn {
+	return repoChurn{
+		listed:    map[string]time.Time{},
+		created:   map[string]time.Time{},
+		deleted:   map[string]time.Time{},
+		firstSeen: map[string]time.Time{},
+	}
+}
+
+func (c *repoChurn) addRepo(repo GitHubRepo, login string) {
+	owner, name := repoOwnerAndName(repo, login)
+	c.listed[strings.ToLower(owner+"/"+name)] = repo.CreatedAt
+}
+
+func (c *repoChurn) addEvent(event *GitHubEvent) {
+	name := strings.ToLower(event.Repo.Name)
+	if name == "" {
+		return
+	}
+	if seen, ok := c.firstSeen[name]; !ok || event.CreatedAt.Before(seen) {
+		c.firstSeen[name] = event.CreatedAt
+	}
+
+	if event.Type != "CreateEvent" && event.Type != "DeleteEvent" {
+		return
+	}
+	payload, err := event.RefPayload()
+	if err != nil || payload.RefType != "repository" {
+		return
+	}
+	if event.Type == "CreateEvent" {
+		c.created[name] = event.CreatedAt
+	} else {
+		c.deleted[name] = event.CreatedAt
+	}
+}
+
+// churned lists repos the feed shows deleted, created but no longer
+// listed, or referenced before their current creation time
+func (c *repoChurn) churned(login string) []string {
+	prefix := strings.ToLower(login) + "/"
+	var churned []string
+
+	for name, at := range c.deleted {
+		if strings.HasPrefix(name, prefix) {
+			churned = append(churned, fmt.Sprintf("%s (deleted %s)", name, at.Format("2006-01-02")))
+		}
+	}
+	for name, at := range c.created {
+		if _, listed := c.listed[name]; !listed && strings.HasPrefix(name, prefix) {
+			if _, deleted := c.deleted[name]; !deleted {
+				churned = append(churned, fmt.Sprintf("%s (created %s, no longer listed)", name, at.Format("2006-01-02")))
+			}
+		}
+	}
+	for name, createdAt := range c.listed {
+		// Allow for clock skew between the feed and the repo record
+		if seen, ok := c.firstSeen[name]; ok && seen.Before(createdAt.Add(-time.Hour)) {
+			churned = append(churned, fmt.Sprintf("%s (recreated %s, events from %s)", name, createdAt.Format("2006-01-02"), seen.Format("2006-01-02")))
+		}
+	}
+
+	sort.Strings(churned)
+	return churned
+}
+
+// countRepoChurn counts the repos deleted or recreated. A sampled repo
+// list can't tell a deleted repo from an unlisted one, so it is skipped.
+func (c RuleContext) countRepoChurn() {
+	if c.repoChurnVisible() {
+		c.run.acc.metrics.RepoChurn = len(c.run.acc.churn.churned(c.run.username))
+	}
+}
+
+func (c RuleContext) repoChurnVisible() bool {
+	cov := c.coverage()
+	return cov.repos && cov.events && !c.run.acc.metrics.ReposSampled
+}
+
+// repoChurnRule warns about repos deleted and recreated, a way to reset
+// issue history or star baselines
+var repoChurnRule = rule{
+	code:        "REPO_CHURN",
+	description: "Repos were deleted or recreated within the events feed's 90 days",
+	evaluate: func(c RuleContext) []Finding {
+		if !c.repoChurnVisible() {
+			return nil
+		}
+		churned := c.run.acc.churn.churned(c.run.username)
+		if len(churned) == 0 {
+			return nil
+		}
+		return []Finding{{
+			Code:     "REPO_CHURN",
+			Severity: SeverityWarning,
+			Message:  "Repos were deleted or recreated recently (the public events feed covers only about 90 days, so older churn isn't visible)",
+			Evidence: churned,
+		}}
+	},
+}
@@ -0,0 +1,96 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// refEvent is a CreateEvent or DeleteEvent of refType in repo
+func refEvent(kind, repo, refType string, at time.Time) GitHubEvent {
+	event := GitHubEvent{ID: fmt.Sprint(at.Unix()), Type: kind, CreatedAt: at,
+		Payload: json.RawMessage(fmt.Sprintf(`{"ref":null,"ref_type":%q}`, refType))}
+	event.Repo.Name = repo
+	return event
+}
+
+func TestRefPayload(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		event   GitHubEvent
+		want    string
+		wantErr bool
+	}{
+		{"repository", refEvent("CreateEvent", "octo/tool", "repository", fakeNow), "repository", false},
+		{"branch", refEvent("DeleteEvent", "octo/tool", "branch", fakeNow), "branch", false},
+		{"empty payload", GitHubEvent{Type: "CreateEvent"}, "", false},
+		{"bad payload", GitHubEvent{Type: "CreateEvent", Payload: json.RawMessage(`[1]`)}, "", true},
+		{"other event", GitHubEvent{Type: "PushEvent", Payload: json.RawMessage(`{}`)}, "", true},
+	} {
+		payload, err := tt.event.RefPayload()
+		if payload.RefType != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: RefPayload = %+v, %v", tt.name, payload, err)
+		}
+	}
+}
+
+// churnAccount lists tool and reborn, recreated five days ago, and has a
+// feed that shows gone deleted and vanished created but since removed
+func churnAccount() *fakeAccount {
+	account := newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, CreatedAt: fakeNow.Add(-days(60)), UpdatedAt: fakeNow.Add(-days(2))},
+		GitHubRepo{Name: "reborn", Language: "Go", Size: 900, CreatedAt: fakeNow.Add(-days(5)), UpdatedAt: fakeNow.Add(-days(2))},
+	)
+	push := pushFeed("octo", 1, 1)[0]
+	push.Repo.Name, push.CreatedAt = "octo/reborn", fakeNow.Add(-days(30))
+	account.Events = []GitHubEvent{
+		refEvent("CreateEvent", "octo/tool", "repository", fakeNow.Add(-days(60))),
+		refEvent("CreateEvent", "octo/tool", "branch", fakeNow.Add(-days(50))),
+		refEvent("DeleteEvent", "octo/tool", "branch", fakeNow.Add(-days(40))),
+		refEvent("CreateEvent", "octo/gone", "repository", fakeNow.Add(-days(20))),
+		refEvent("DeleteEvent", "octo/gone", "repository", fakeNow.Add(-days(10))),
+		refEvent("CreateEvent", "octo/vanished", "repository", fakeNow.Add(-days(15))),
+		// Repos of other accounts aren't the user's to churn
+		refEvent("DeleteEvent", "acme/thing", "repository", fakeNow.Add(-days(8))),
+		push,
+	}
+	return account
+}
+
+func TestRepoChurn(t *testing.T) {
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, churnAccount())).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	want := []string{
+		"octo/gone (deleted 2024-05-22)",
+		"octo/reborn (recreated 2024-05-27, events from 2024-05-02)",
+		"octo/vanished (created 2024-05-17, no longer listed)",
+	}
+	if analysis.Metrics.RepoChurn != len(want) {
+		t.Errorf("RepoChurn = %d, want %d", analysis.Metrics.RepoChurn, len(want))
+	}
+	flag := finding(analysis, "REPO_CHURN")
+	if flag == nil || flag.Severity != SeverityWarning || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("REPO_CHURN = %+v, want %q", flag, want)
+	}
+}
+
+func TestRepoChurnSampled(t *testing.T) {
+	account := churnAccount()
+	account.Repos = append(account.Repos, bigAccount(50).Repos...)
+	account.User.PublicRepos = len(account.Repos)
+	// A sample leaves repos out, so none of them count as deleted
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account), WithMaxRepos(10)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if !analysis.Metrics.ReposSampled {
+		t.Fatal("the repos weren't sampled")
+	}
+	if analysis.Metrics.RepoChurn != 0 || finding(analysis, "REPO_CHURN") != nil {
+		t.Errorf("counted %d churned repos from a sample", analysis.Metrics.RepoChurn)
+	}
+}
@@ -0,0 +1,81 @@
+package ebert
+
+import "strings"
+
+// RepoClass says what kind of repo a GitHubRepo is for scoring purposes
+type RepoClass string
+
+const (
+	RepoOriginal RepoClass = "original"
+	RepoFork     RepoClass = "fork"
+	RepoTemplate RepoClass = "template"
+	RepoMirror   RepoClass = "mirror"
+	// RepoMeta is the .github community-health repo or a user pages site
+	RepoMeta RepoClass = "meta"
+)
+
+// RepoClassCounts breaks the user's repos down by class
+type RepoClassCounts struct {
+	Original int `json:"original"`
+	Fork     int `json:"fork"`
+	Template int `json:"template"`
+	Mirror   int `json:"mirror"`
+	Meta     int `json:"meta"`
+}
+
+func (c *RepoClassCounts) add(class RepoClass) {
+	switch class {
+	case RepoOriginal:
+		c.Original++
+	case RepoFork:
+		c.Fork++
+	case RepoTemplate:
+		c.Template++
+	case RepoMirror:
+		c.Mirror++
+	case RepoMeta:
+		c.Meta++
+	}
+}
+
+// classifyRepo assigns repo to a single class; mirrors and forks take
+// precedence because their content isn't the owner's own work
+func classifyRepo(repo GitHubRepo, login string) RepoClass {
+	switch {
+	case repo.MirrorURL != "":
+		return RepoMirror
+	case repo.Fork:
+		return RepoFork
+	case repo.IsTemplate:
+		return RepoTemplate
+	case strings.EqualFold(repo.Name, ".github") || isUserPagesRepo(repo, login):
+		return RepoMeta
+	default:
+		return RepoOriginal
+	}
+}
+
+// repoTotals are the repo counts the quality and maintenance ratios use
+type repoTotals struct {
+	repos, stars, forks        int
+	archived, recentlyArchived int
+	recentlyUpdated            int
+	weightedStars              repoSum
+}
+
+// scoringMetrics returns metrics with the repo totals replaced by those of
+// the original repos, unless the analyzer scores all repos
+func (a *Analyzer) scoringMetrics(metrics Metrics, original repoTotals) Metrics {
+	if a.opts.ScoreAllRepos {
+		return metrics
+	}
+
+	metrics.Repos = original.repos
+	metrics.Stars = original.stars
+	metrics.WeightedStars = original.weightedStars.value()
+	metrics.Forks = original.forks
+	metrics.Archived = original.archived
+	metrics.RecentlyArchived = original.recentlyArchived
+	metrics.RecentlyUpdated = original.recentlyUpdated
+	return metrics
+}
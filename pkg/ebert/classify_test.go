@@ -0,0 +1,77 @@
+package ebert
+
+import (
+	"testing"
+)
+
+func TestClassifyRepo(t *testing.T) {
+	for _, tc := range []struct {
+		repo GitHubRepo
+		want RepoClass
+	}{
+		{GitHubRepo{Name: "tool"}, RepoOriginal},
+		{GitHubRepo{Name: "tool", Fork: true}, RepoFork},
+		{GitHubRepo{Name: "starter", IsTemplate: true}, RepoTemplate},
+		{GitHubRepo{Name: "linux", MirrorURL: "https://git.kernel.org/linux.git"}, RepoMirror},
+		// A mirror's content isn't the owner's, even forked or templated
+		{GitHubRepo{Name: "linux", MirrorURL: "https://git.kernel.org/linux.git", Fork: true, IsTemplate: true}, RepoMirror},
+		{GitHubRepo{Name: "starter", Fork: true, IsTemplate: true}, RepoFork},
+		{GitHubRepo{Name: ".github"}, RepoMeta},
+		{GitHubRepo{Name: ".GitHub"}, RepoMeta},
+		{GitHubRepo{Name: "Octo.github.io"}, RepoMeta},
+		// Another account's pages repo is just a repo
+		{GitHubRepo{Name: "hubot.github.io"}, RepoOriginal},
+	} {
+		if got := classifyRepo(tc.repo, "octo"); got != tc.want {
+			t.Errorf("classifyRepo(%+v) = %s, want %s", tc.repo, got, tc.want)
+		}
+	}
+}
+
+func TestOriginalReposScored(t *testing.T) {
+	unspaced(t)
+	f := newFakeGitHub(t, mixedClassAccount())
+	for _, tc := range []struct {
+		name                 string
+		all                  bool
+		quality, maintenance float64
+	}{
+		// The originals are fresh but modestly starred
+		{"original repos", false, 40, 30},
+		// The template's stars flatter quality; the stale forks and
+		// mirror drag maintenance down
+		{"all repos", true, 30, 40},
+	} {
+		analysis, err := newFakeAnalyzer(f, WithScoreAllRepos(tc.all)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		scores := analysis.Scores
+		if scores.Quality == nil || *scores.Quality != tc.quality || scores.Maintenance == nil || *scores.Maintenance != tc.maintenance {
+			t.Errorf("%s: quality %s, maintenance %s; want %g and %g", tc.name, formatScore(scores.Quality), formatScore(scores.Maintenance), tc.quality, tc.maintenance)
+		}
+		// The reported metrics count every repo either way
+		metrics := analysis.Metrics
+		if want := (RepoClassCounts{Original: 4, Fork: 2, Template: 1, Mirror: 1, Meta: 2}); metrics.RepoClasses != want || metrics.Repos != 10 {
+			t.Errorf("%s: %d repos classed %+v, want 10 classed %+v", tc.name, metrics.Repos, metrics.RepoClasses, want)
+		}
+	}
+}
+
+// mixedClassAccount has four original repos, modestly starred and pushed
+// to this month, beside a popular template, stale forks and mirror, and
+// the meta repos
+func mixedClassAccount() *fakeAccount {
+	original := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Go", Size: 800, StargazersCount: stars, HasIssues: true, UpdatedAt: fakeNow.Add(-days(5))}
+	}
+	return newAccount("octo", days(2500),
+		original("tool", 40), original("lib", 25), original("cli", 12), original("bot", 3),
+		GitHubRepo{Name: "starter", Language: "Go", Size: 50, StargazersCount: 3000, IsTemplate: true, UpdatedAt: fakeNow.Add(-days(700))},
+		GitHubRepo{Name: "linux", Language: "C", Size: 900000, MirrorURL: "https://git.kernel.org/linux.git", UpdatedAt: fakeNow.Add(-days(900))},
+		GitHubRepo{Name: "react", Language: "JavaScript", Size: 20000, Fork: true, UpdatedAt: fakeNow.Add(-days(1200))},
+		GitHubRepo{Name: "vue", Language: "JavaScript", Size: 20000, Fork: true, Archived: true, UpdatedAt: fakeNow.Add(-days(1100))},
+		GitHubRepo{Name: ".github", Size: 5, UpdatedAt: fakeNow.Add(-days(800))},
+		GitHubRepo{Name: "octo.github.io", Language: "HTML", Size: 30, UpdatedAt: fakeNow.Add(-days(400))},
+	)
+}
@@ -0,0 +1,654 @@
+package ebert
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxResponseBytes is the per-response body cap used when
+// GitHubClient.MaxResponseBytes is unset
+const DefaultMaxResponseBytes = 20 << 20
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's cap
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// GitHubClient handles API requests. It is safe for concurrent use provided
+// its fields are not modified once requests have started.
+type GitHubClient struct {
+	BaseURL          string
+	Token            string // Optional: GitHub token for higher rate limits
+	MaxResponseBytes int64  // Optional: per-response body cap, DefaultMaxResponseBytes if zero
+
+	// TokenSource, if set, supplies the token for each request in place
+	// of Token, e.g. an AppTokenSource refreshing installation tokens
+	TokenSource TokenSource
+
+	// HTTPClient sends the requests; a shared client with a ten second timeout is used if nil
+	HTTPClient *http.Client
+
+	// RequestTimeout, if set, bounds each attempt at a request
+	RequestTimeout time.Duration
+
+	// MinRequestsPerSecond and MaxRequestsPerSecond bound the adaptive
+	// pacing of requests; the defaults apply where zero
+	MinRequestsPerSecond float64
+	MaxRequestsPerSecond float64
+
+	// OnRequest, if set, is called before every attempt at a request,
+	// including retries; RequestAttempt reports the attempt number
+	OnRequest func(*http.Request)
+
+	// OnResponse, if set, is called after each response body has been read
+	// in full. The body it sees is a copy, so hooks may read it freely.
+	OnResponse func(*http.Response, time.Duration)
+
+	// Tracer, if set, records a span per endpoint fetch
+	Tracer Tracer
+
+	// StrictAuth fails requests with ErrUnauthorized when GitHub rejects
+	// the token, rather than continuing without it
+	StrictAuth bool
+
+	// NotFoundTTL is how long a user lookup's 404 is remembered, sparing
+	// the request on the next lookup; DefaultNotFoundTTL if zero, and a
+	// negative value disables it
+	NotFoundTTL time.Duration
+
+	// ExternalTimeout bounds each request to a host other than the GitHub
+	// API, DefaultExternalTimeout if zero; IntegrationTimeouts overrides it
+	// per host, e.g. "registry.npmjs.org"
+	ExternalTimeout     time.Duration
+	IntegrationTimeouts map[string]time.Duration
+
+	// BreakerThreshold is how many consecutive failures of an external host
+	// skip its remaining requests; DefaultBreakerThreshold if zero, and a
+	// negative value disables the breaker
+	BreakerThreshold int
+
+	// BreakerCooldown is how long an open breaker skips a host before
+	// probing it again; DefaultBreakerCooldown if zero
+	BreakerCooldown time.Duration
+
+	once  sync.Once
+	state *clientState
+}
+
+// clientState is the mutable, synchronized state shared by every request
+// made through one client
+type clientState struct {
+	gate    *requestGate
+	pacer   *pacer
+	search  *intervalLimiter
+	crates  *intervalLimiter
+	stats   *statsRecorder
+	absent  *notFoundCache
+	breaker *circuitBreaker
+	auth    authState
+}
+
+// APIError is a non-success response from the GitHub API. RateLimited
+// marks a response refused for the rate limit, which errors.Is matches
+// as ErrRateLimited.
+type APIError struct {
+	StatusCode  int
+	URL         string
+	RateLimited bool
+}
+
+func (e *APIError) Error() string {
+	if e.RateLimited {
+		return fmt.Sprintf("GitHub API error: %d (rate limited)", e.StatusCode)
+	}
+	return fmt.Sprintf("GitHub API error: %d", e.StatusCode)
+}
+
+// Is matches ErrRateLimited for a rate limited response
+func (e *APIError) Is(target error) bool {
+	return target == ErrRateLimited && e.RateLimited
+}
+
+func (c *GitHubClient) shared() *clientState {
+	c.once.Do(func() {
+		c.state = &clientState{
+			gate:    newRequestGate(defaultMaxConcurrency),
+			pacer:   newPacer(c.MinRequestsPerSecond, c.MaxRequestsPerSecond),
+			search:  newIntervalLimiter(searchInterval),
+			crates:  newIntervalLimiter(cratesInterval),
+			stats:   newStatsRecorder(),
+			absent:  newNotFoundCache(),
+			breaker: newCircuitBreaker(cmp.Or(c.BreakerThreshold, DefaultBreakerThreshold), cmp.Or(c.BreakerCooldown, DefaultBreakerCooldown)),
+		}
+	})
+	return c.state
+}
+
+// startSpan begins a span for one endpoint fetch
+func (c *GitHubClient) startSpan(ctx context.Context, name, endpoint string) (context.Context, Span) {
+	tracer := c.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	ctx, span := tracer.Start(ctx, name)
+	span.SetAttributes(Attribute{Key: "github.endpoint", Value: endpoint})
+	return withSpan(ctx, span), span
+}
+
+// RequestStats returns the totals accumulated over the client's lifetime
+func (c *GitHubClient) RequestStats() RequestStats {
+	return c.shared().stats.snapshot()
+}
+
+func NewGitHubClient(token string) *GitHubClient {
+	return &GitHubClient{
+		BaseURL:          "https://api.github.com",
+		Token:            token,
+		MaxResponseBytes: DefaultMaxResponseBytes,
+	}
+}
+
+// GetRepos fetches every public repo of the user. Elements that fail to
+// decode are skipped and reported in the returned count. Without opts the
+// listing is sorted by update time as it always has been.
+func (c *GitHubClient) GetRepos(ctx context.Context, username string, opts ...RepoListOptions) ([]GitHubRepo, int, error) {
+	var allRepos []GitHubRepo
+
+	skipped, err := c.EachRepoPage(ctx, username, func(_ int, repos []GitHubRepo) error {
+		allRepos = append(allRepos, repos...)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, skipped, err
+	}
+
+	return allRepos, skipped, nil
+}
+
+// EachRepoPage calls fn with each page of the user's repos as it arrives,
+// stopping early if fn returns an error
+func (c *GitHubClient) EachRepoPage(ctx context.Context, username string, fn func(page int, repos []GitHubRepo) error, opts ...RepoListOptions) (_ int, err error) {
+	ctx, span := c.startSpan(ctx, "github.repos", "users/:user/repos")
+	skipped := 0
+	page := 1
+	defer func() {
+		span.SetAttributes(Attribute{Key: "github.pages", Value: page}, Attribute{Key: "github.cache_hit", Value: false})
+		endSpan(span, err)
+	}()
+
+	list := repoListOptions(opts)
+	if err := list.validate(); err != nil {
+		return 0, err
+	}
+	perPage := list.perPage()
+
+	for {
+		data, err := c.get(ctx, fmt.Sprintf("%s/users/%s/repos?%s", c.BaseURL, url.PathEscape(username), list.query(page).Encode()))
+		if err != nil {
+			return skipped, err
+		}
+
+		repos, bad, err := decodeElements[GitHubRepo](data)
+		if err != nil {
+			return skipped, err
+		}
+		skipped += bad
+
+		// If no repos returned, we've reached the end
+		if len(repos)+bad == 0 {
+			break
+		}
+
+		if err := fn(page, repos); err != nil {
+			return skipped, err
+		}
+
+		// A short page was the last one
+		if len(repos)+bad < perPage {
+			break
+		}
+
+		page++
+	}
+
+	return skipped, nil
+}
+
+// GetEvents fetches the user's public events. Elements that fail to decode
+// are skipped and reported in the returned count.
+func (c *GitHubClient) GetEvents(ctx context.Context, username string) ([]GitHubEvent, int, error) {
+	return c.GetEventsSince(ctx, username, time.Time{})
+}
+
+// GetEventsSince is GetEvents that stops paginating after the first page
+// reaching events older than since. The feed is newest first, so later
+// pages hold only older events.
+func (c *GitHubClient) GetEventsSince(ctx context.Context, username string, since time.Time) ([]GitHubEvent, int, error) {
+	events, skipped, _, err := c.getEvents(ctx, username, "", since)
+	return events, skipped, err
+}
+
+// getEvents is GetEventsSince made conditional on the first page's etag,
+// also returning that page's ETag. Events arriving while the feed is paged
+// push ones already fetched onto the next page; those repeats are dropped
+// by ID and counted in the request stats.
+func (c *GitHubClient) getEvents(ctx context.Context, username, etag string, since time.Time) (_ []GitHubEvent, _ int, _ string, err error) {
+	ctx, span := c.startSpan(ctx, "github.events", "users/:user/events/public")
+	var allEvents []GitHubEvent
+	var firstETag string
+	skipped := 0
+	seen := map[string]bool{}
+	page := 1
+	defer func() {
+		span.SetAttributes(Attribute{Key: "github.pages", Value: page}, Attribute{Key: "github.cache_hit", Value: false})
+		endSpan(span, err)
+	}()
+
+	for {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(maxPerPage))
+		query.Set("page", strconv.Itoa(page))
+
+		endpoint := fmt.Sprintf("%s/users/%s/events/public?%s", c.BaseURL, url.PathEscape(username), query.Encode())
+		var data []byte
+		var err error
+		if page == 1 {
+			data, firstETag, err = c.getETag(ctx, endpoint, etag)
+		} else {
+			data, err = c.get(ctx, endpoint)
+		}
+		if err != nil {
+			return nil, skipped, "", err
+		}
+
+		events, bad, err := decodeElements[GitHubEvent](data)
+		if err != nil {
+			return nil, skipped, "", err
+		}
+		skipped += bad
+
+		// If no events returned, we've reached the end
+		if len(events)+bad == 0 {
+			break
+		}
+
+		duplicates, reachedSince := 0, false
+		for _, event := range events {
+			if event.ID != "" && seen[event.ID] {
+				duplicates++
+				continue
+			}
+			if event.ID != "" {
+				seen[event.ID] = true
+			}
+			allEvents = append(allEvents, event)
+			reachedSince = reachedSince || !event.CreatedAt.IsZero() && event.CreatedAt.Before(since)
+		}
+		c.recordDuplicateEvents(ctx, duplicates)
+
+		// If we got less than 100 events, this was the last page
+		if len(events)+bad < 100 || reachedSince {
+			break
+		}
+
+		page++
+	}
+
+	return allEvents, skipped, firstETag, nil
+}
+
+// recordDuplicateEvents counts repeated events in the client's stats and
+// those attached to ctx
+func (c *GitHubClient) recordDuplicateEvents(ctx context.Context, n int) {
+	if n == 0 {
+		return
+	}
+	c.shared().stats.recordDuplicateEvents(n)
+	for _, rec := range statsRecordersFrom(ctx) {
+		rec.recordDuplicateEvents(n)
+	}
+}
+
+func (c *GitHubClient) GetUser(ctx context.Context, username string) (*GitHubUser, error) {
+	user, _, err := c.getUser(ctx, username, "")
+	return user, err
+}
+
+// getUser is GetUser made conditional on etag, also returning the ETag
+func (c *GitHubClient) getUser(ctx context.Context, username, etag string) (_ *GitHubUser, _ string, err error) {
+	ctx, span := c.startSpan(ctx, "github.user", "users/:user")
+	cacheHit := false
+	defer func() {
+		span.SetAttributes(Attribute{Key: "github.pages", Value: 1}, Attribute{Key: "github.cache_hit", Value: cacheHit})
+		endSpan(span, err)
+	}()
+
+	u := fmt.Sprintf("%s/users/%s", c.BaseURL, url.PathEscape(username))
+	ttl := cmp.Or(c.NotFoundTTL, DefaultNotFoundTTL)
+	absent := c.shared().absent
+	if ttl > 0 && absent.has(username, time.Now()) {
+		cacheHit = true
+		c.shared().stats.recordCacheHit()
+		for _, rec := range statsRecordersFrom(ctx) {
+			rec.recordCacheHit()
+		}
+		return nil, "", &APIError{StatusCode: http.StatusNotFound, URL: u}
+	}
+
+	data, etag, err := c.getETag(ctx, u, etag)
+	if err != nil {
+		if ttl > 0 && isNotFound(err) {
+			absent.add(username, time.Now().Add(ttl))
+		}
+		return nil, "", err
+	}
+
+	var user GitHubUser
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, "", err
+	}
+
+	return &user, etag, nil
+}
+
+// GetAuthenticatedUser returns the account the client's token belongs to.
+// A 403 usually means the token lacks the read:user scope.
+func (c *GitHubClient) GetAuthenticatedUser(ctx context.Context) (_ *GitHubUser, err error) {
+	ctx, span := c.startSpan(ctx, "github.authenticated_user", "user")
+	defer func() { endSpan(span, err) }()
+
+	if c.TokenRejected() {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, errTokenRejected)
+	}
+	if !c.hasToken() {
+		return nil, errors.New("no token to look up the authenticated user with")
+	}
+	data, err := c.get(ctx, c.BaseURL+"/user")
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("token can't read its own account, it may lack the read:user scope: %w", err)
+	}
+	if err != nil && c.TokenRejected() {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, errTokenRejected)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user GitHubUser
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// decodeElements decodes a JSON array one element at a time so a single
+// malformed element doesn't fail the whole page. A null body yields no
+// items, and a null element counts as skipped.
+func decodeElements[T any](data []byte) ([]T, int, error) {
+	// A 204 from an empty repo has no body at all
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, 0, nil
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]T, 0, len(raw))
+	skipped := 0
+	for _, element := range raw {
+		var item T
+		if string(element) == "null" || json.Unmarshal(element, &item) != nil {
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return items, skipped, nil
+}
+
+// defaultAccept is the media type requested from the REST API
+const defaultAccept = "application/vnd.github.v3+json"
+
+// get fetches url, backing off and retrying on secondary rate limits. After
+// the first secondary limit the client is throttled to one request at a time.
+func (c *GitHubClient) get(ctx context.Context, url string) ([]byte, error) {
+	return c.getAccept(ctx, url, defaultAccept)
+}
+
+// getAccept is get with an explicit Accept media type
+func (c *GitHubClient) getAccept(ctx context.Context, url, accept string) ([]byte, error) {
+	return c.send(ctx, apiRequest{method: "GET", url: url, accept: accept})
+}
+
+// apiRequest describes one call to the GitHub API; etag makes it
+// conditional, answered by errNotModified when the resource is unchanged
+type apiRequest struct {
+	method string
+	url    string
+	accept string
+	body   []byte
+	etag   string
+}
+
+// send performs req with the client's retry and throttling behavior
+func (c *GitHubClient) send(ctx context.Context, req apiRequest) ([]byte, error) {
+	_, data, err := c.exchange(ctx, req)
+	return data, err
+}
+
+// getETag is get made conditional on etag, also returning the current
+// ETag; an unchanged resource answers errNotModified, which costs no quota
+func (c *GitHubClient) getETag(ctx context.Context, url, etag string) ([]byte, string, error) {
+	resp, data, err := c.exchange(ctx, apiRequest{method: "GET", url: url, accept: defaultAccept, etag: etag})
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// exchange is send that also returns the final response, for its headers
+func (c *GitHubClient) exchange(ctx context.Context, req apiRequest) (*http.Response, []byte, error) {
+	state := c.shared()
+	url := req.url
+
+	polls := 0
+	for attempt := 1; ; attempt++ {
+		resp, data, err := c.do(ctx, req, attempt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if span := spanFrom(ctx); span != nil {
+			span.SetAttributes(Attribute{Key: "http.status_code", Value: resp.StatusCode})
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusCreated:
+			return resp, data, nil
+		case http.StatusNoContent:
+			// Empty repos answer list and statistics endpoints with no body
+			return resp, nil, nil
+		case http.StatusNotModified:
+			if req.etag != "" {
+				return resp, nil, errNotModified
+			}
+		case http.StatusAccepted:
+			// Statistics still being computed; poll briefly, then give up
+			// with the 202 for the caller to treat as no data yet
+			if polls < maxAcceptedPolls {
+				polls++
+				if err := sleepContext(ctx, acceptedPollInterval); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			return nil, nil, &APIError{StatusCode: resp.StatusCode, URL: url}
+		}
+
+		if wait, ok := secondaryRateLimit(resp, data); ok && attempt < maxSecondaryRetries {
+			state.stats.recordSecondaryLimit()
+			for _, rec := range statsRecordersFrom(ctx) {
+				rec.recordSecondaryLimit()
+			}
+			state.gate.throttle()
+			if err := sleepContext(ctx, wait+jitter()); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		// Another request may have dropped the token while this one was
+		// in flight, so what counts is whether this one carried it
+		if resp.StatusCode == http.StatusUnauthorized && resp.Request.Header.Get("Authorization") != "" {
+			if c.StrictAuth {
+				return nil, nil, fmt.Errorf("%w: %w", ErrUnauthorized, &APIError{StatusCode: resp.StatusCode, URL: url})
+			}
+			if c.rejectToken(ctx) {
+				// Retried, and everything after sent, without the token
+				continue
+			}
+		}
+
+		if resp.StatusCode == http.StatusUnavailableForLegalReasons {
+			if blocked := blockedReposFrom(ctx); blocked != nil {
+				blocked.record(url)
+			}
+		}
+		return nil, nil, &APIError{StatusCode: resp.StatusCode, URL: url, RateLimited: rateLimited(resp, data)}
+	}
+}
+
+// do performs a single request at the pacer's rate and within the
+// concurrency gate, reads its body and records it in the request stats
+func (c *GitHubClient) do(ctx context.Context, apiReq apiRequest, attempt int) (*http.Response, []byte, error) {
+	state := c.shared()
+	if err := state.pacer.wait(ctx); err != nil {
+		return nil, nil, err
+	}
+	if err := state.gate.acquire(ctx); err != nil {
+		return nil, nil, err
+	}
+	defer state.gate.release()
+
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+
+	ctx = context.WithValue(ctx, attemptKey{}, attempt)
+	var body io.Reader
+	if apiReq.body != nil {
+		body = bytes.NewReader(apiReq.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, apiReq.method, apiReq.url, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept", apiReq.accept)
+	if apiReq.etag != "" {
+		req.Header.Set("If-None-Match", apiReq.etag)
+	}
+	if apiReq.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authenticated() {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	if c.OnRequest != nil {
+		c.OnRequest(req)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, connectionHint(err)
+	}
+	if resp.Request == nil {
+		// Transports such as a replayer may leave it unset
+		resp.Request = req
+	}
+
+	// A failed close only leaks the connection; it must not take down
+	// other analyses sharing the process
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	data, err := c.readBody(resp.Body, resp.ContentLength)
+	if err != nil {
+		return nil, nil, err
+	}
+	latency := time.Since(start)
+
+	endpoint := endpointName(apiReq.url)
+	limit := parseRateLimit(resp.Header)
+	state.stats.recordRequest(endpoint, attempt, len(data), latency)
+	state.stats.recordRateLimit(limit)
+	state.pacer.observe(limit)
+	for _, rec := range statsRecordersFrom(ctx) {
+		rec.recordRequest(endpoint, attempt, len(data), latency)
+		rec.recordRateLimit(limit)
+	}
+
+	if c.OnResponse != nil {
+		hooked := *resp
+		hooked.Body = io.NopCloser(bytes.NewReader(data))
+		c.OnResponse(&hooked, latency)
+	}
+
+	return resp, data, nil
+}
+
+// readBody reads at most MaxResponseBytes from body. A body of known
+// length is read into a buffer of that size in one allocation; gzipped
+// responses, decoded transparently by the default transport, have no
+// length and are read as they come.
+func (c *GitHubClient) readBody(body io.Reader, length int64) ([]byte, error) {
+	limit := c.MaxResponseBytes
+	if limit <= 0 {
+		limit = DefaultMaxResponseBytes
+	}
+
+	body = io.LimitReader(body, limit+1)
+	var data []byte
+	var err error
+	if length >= 0 {
+		// ReadFrom wants MinRead spare bytes to see the end without growing
+		buf := bytes.NewBuffer(make([]byte, 0, min(length, limit)+bytes.MinRead))
+		_, err = buf.ReadFrom(body)
+		data = buf.Bytes()
+	} else {
+		data, err = io.ReadAll(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, limit)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,330 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDecodeElementsEvents(t *testing.T) {
+	data, err := os.ReadFile("testdata/events_odd.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, skipped, err := decodeElements[GitHubEvent](data)
+	if err != nil {
+		t.Fatalf("decodeElements: %v", err)
+	}
+	// A numeric id, an unparsable timestamp, a bare string and a null
+	if skipped != 4 {
+		t.Errorf("skipped = %d, want 4", skipped)
+	}
+
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	want := []string{"1001", "1002", "1003", "1004", "1005", "1006"}
+	if len(ids) != len(want) {
+		t.Fatalf("decoded %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("decoded %v, want %v", ids, want)
+		}
+	}
+
+	if !events[1].CreatedAt.IsZero() || !events[2].CreatedAt.IsZero() {
+		t.Error("empty and null created_at should decode to the zero time")
+	}
+	for _, i := range []int{0, 4} {
+		payload, err := events[i].PushPayload()
+		if err != nil {
+			t.Errorf("event %s: PushPayload: %v", events[i].ID, err)
+		}
+		if len(payload.Commits) != 0 {
+			t.Errorf("event %s: commits = %v, want none", events[i].ID, payload.Commits)
+		}
+	}
+}
+
+func TestDecodeElementsRepos(t *testing.T) {
+	data, err := os.ReadFile("testdata/repos_odd.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos, skipped, err := decodeElements[GitHubRepo](data)
+	if err != nil {
+		t.Fatalf("decodeElements: %v", err)
+	}
+	if len(repos) != 3 || skipped != 3 {
+		t.Fatalf("decoded %d and skipped %d, want 3 and 3", len(repos), skipped)
+	}
+	if repos[0].StargazersCount != 120 || repos[0].Topics != nil {
+		t.Errorf("tool = %+v", repos[0])
+	}
+	if !repos[1].UpdatedAt.IsZero() || !repos[1].PushedAt.IsZero() || repos[1].CreatedAt.IsZero() {
+		t.Errorf("empty timestamps = %v %v %v", repos[1].UpdatedAt, repos[1].PushedAt, repos[1].CreatedAt)
+	}
+	if repos[2].Owner != nil {
+		t.Errorf("null owner decoded as %+v", repos[2].Owner)
+	}
+}
+
+func TestDecodeElementsWholeBody(t *testing.T) {
+	for _, tt := range []struct {
+		body    string
+		wantErr bool
+	}{
+		{"", false},
+		{"  \n", false},
+		{"null", false},
+		{"[]", false},
+		{`{"message": "Not Found"}`, true},
+		{"[", true},
+	} {
+		items, skipped, err := decodeElements[GitHubEvent]([]byte(tt.body))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, want error %v", tt.body, err, tt.wantErr)
+		}
+		if len(items) != 0 || skipped != 0 {
+			t.Errorf("%q: decoded %d and skipped %d, want none", tt.body, len(items), skipped)
+		}
+	}
+}
+
+func FuzzDecodeElements(f *testing.F) {
+	for _, fixture := range []string{"testdata/events_odd.json", "testdata/repos_odd.json"} {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`[{"type":"PushEvent","payload":{"size":null,"distinct_size":"3","commits":{}}}]`))
+	f.Add([]byte(`[{"created_at":"2024-13-45T99:00:00Z"},{"repo":[]},{}]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var raw []json.RawMessage
+		arrayErr := json.Unmarshal(data, &raw)
+
+		events, skipped, err := decodeElements[GitHubEvent](data)
+		if err != nil {
+			return
+		}
+		if arrayErr == nil && len(events)+skipped != len(raw) {
+			t.Fatalf("decoded %d and skipped %d of %d elements", len(events), skipped, len(raw))
+		}
+		for i := range events {
+			// Whatever decoded must be usable by the metrics
+			_, _ = events[i].PushPayload()
+		}
+
+		repos, skipped, err := decodeElements[GitHubRepo](data)
+		if err != nil {
+			t.Fatalf("repos failed where events decoded: %v", err)
+		}
+		if arrayErr == nil && len(repos)+skipped != len(raw) {
+			t.Fatalf("decoded %d and skipped %d of %d repos", len(repos), skipped, len(raw))
+		}
+	})
+}
+
+func TestReadBodyCap(t *testing.T) {
+	c := &GitHubClient{MaxResponseBytes: 1024}
+	for _, tt := range []struct {
+		name    string
+		size    int
+		length  int64
+		wantErr bool
+	}{
+		{"under", 1000, 1000, false},
+		{"at cap", 1024, 1024, false},
+		{"over", 1025, 1025, true},
+		{"over unsized", 5000, -1, true},
+		{"understated length", 5000, 10, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			body := bytes.Repeat([]byte("x"), tt.size)
+			data, err := c.readBody(bytes.NewReader(body), tt.length)
+			if tt.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(data, body) {
+				t.Fatalf("read %d bytes, err %v", len(data), err)
+			}
+		})
+	}
+}
+
+func TestResponseTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"login":"alice","bio":"`+strings.Repeat("a", 4096)+`"}`)
+	}))
+	defer srv.Close()
+
+	c := &GitHubClient{BaseURL: srv.URL, MaxResponseBytes: 1024}
+	if _, err := c.GetUser(context.Background(), "alice"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("GetUser err = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+// BenchmarkReadBody compares reading a 2MB page the way bodies were read
+// before the cap, with io.ReadAll, against readBody
+func BenchmarkReadBody(b *testing.B) {
+	page := bytes.Repeat([]byte(`{"name":"repo","stargazers_count":1},`), 2<<20/37)
+	c := &GitHubClient{}
+
+	b.Run("ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := io.ReadAll(bytes.NewReader(page)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("sized", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := c.readBody(bytes.NewReader(page), int64(len(page))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("unsized", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := c.readBody(bytes.NewReader(page), -1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetUserEscapesLogin(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	c := &GitHubClient{BaseURL: srv.URL}
+	for _, login := range []string{"../orgs/acme", "a b", "x?admin=1"} {
+		_, _ = c.GetUser(context.Background(), login)
+	}
+	want := []string{"/users/..%2Forgs%2Facme", "/users/a%20b", "/users/x%3Fadmin=1"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("requested %q, want %q", paths, want)
+	}
+}
+
+func TestRequestHooks(t *testing.T) {
+	const limited, found = `{"message":"You have exceeded a secondary rate limit."}`, `{"login":"octo","public_repos":3}`
+	f := newFakeGitHub(t, newAccount("octo", days(1000)))
+	var served atomic.Int32
+	f.route("/users/octo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Trace") == "" {
+			http.Error(w, "no trace header", http.StatusBadRequest)
+			return
+		}
+		if served.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, limited, http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(found))
+	})
+
+	var requests, responses []string
+	onRequest := func(r *http.Request) {
+		r.Header.Set("X-Trace", "abc")
+		requests = append(requests, fmt.Sprintf("%s #%d", r.URL.Path, RequestAttempt(r)))
+	}
+	onResponse := func(resp *http.Response, latency time.Duration) {
+		// Draining the body must leave the client its own copy
+		body, _ := io.ReadAll(resp.Body)
+		responses = append(responses, fmt.Sprintf("%d #%d %dB", resp.StatusCode, RequestAttempt(resp.Request), len(body)))
+		if latency <= 0 {
+			t.Errorf("latency = %s, want it measured", latency)
+		}
+	}
+
+	client := newFakeAnalyzer(f, WithRequestHooks(onRequest, onResponse)).client
+	user, err := client.GetUser(context.Background(), "octo")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.Login != "octo" || user.PublicRepos != 3 {
+		t.Errorf("user = %+v, want octo decoded after the hook read the body", user)
+	}
+	if want := []string{"/users/octo #1", "/users/octo #2"}; strings.Join(requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("OnRequest saw %q, want %q", requests, want)
+	}
+	// http.Error ends the body with a newline
+	if want := []string{fmt.Sprintf("403 #1 %dB", len(limited)+1), fmt.Sprintf("200 #2 %dB", len(found))}; strings.Join(responses, ", ") != strings.Join(want, ", ") {
+		t.Errorf("OnResponse saw %q, want %q", responses, want)
+	}
+
+	stats := client.RequestStats()
+	if stats.Requests != 2 || stats.Retries != 1 || stats.Bytes != int64(len(limited)+1+len(found)) {
+		t.Errorf("stats = %+v, want 2 requests, 1 retry and both bodies' bytes", stats)
+	}
+	if endpoint := stats.Endpoints["users/:user"]; endpoint.Requests != 2 || endpoint.MaxLatencyNS <= 0 || endpoint.TotalLatencyNS < endpoint.MaxLatencyNS {
+		t.Errorf("users/:user = %+v, want both requests timed", endpoint)
+	}
+}
+
+func TestEndpointName(t *testing.T) {
+	for _, tt := range []struct {
+		url, want string
+	}{
+		{"https://api.github.com/users/octo", "users/:user"},
+		{"https://api.github.com/users/octo/events/public?page=2", "users/:user/events/public"},
+		{"https://api.github.com/orgs/acme/members", "orgs/:org/members"},
+		{"https://api.github.com/repos/octo/tool/stats/contributors", "repos/:owner/:repo/stats/contributors"},
+		// GitHub Enterprise's API prefix is dropped
+		{"https://ghe.example.com/api/v3/users/octo/repos", "users/:user/repos"},
+		{"https://api.github.com/search/commits?q=author:octo", "search/commits"},
+		{"https://api.github.com/graphql", "graphql"},
+		{"://bad", "unknown"},
+	} {
+		if got := endpointName(tt.url); got != tt.want {
+			t.Errorf("endpointName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestRequestStatsAttached(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(1000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(2))}))
+	for _, detailed := range []bool{false, true} {
+		sent := f.requests.Load()
+		analysis, err := newFakeAnalyzer(f, WithRequestStats(detailed)).Analyze("octo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := analysis.RequestStats
+		if stats == nil || stats.Requests != int64(f.requests.Load()-sent) || stats.Bytes == 0 {
+			t.Fatalf("RequestStats = %+v, want the %d requests of the run", stats, f.requests.Load()-sent)
+		}
+		// The per-endpoint breakdown is only kept for debugging
+		if got := len(stats.Endpoints); (got > 0) != detailed {
+			t.Errorf("with WithRequestStats(%t) the breakdown has %d endpoints", detailed, got)
+		}
+	}
+}
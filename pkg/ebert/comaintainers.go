@@ -0,0 +1,276 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// coMaintainerShare is the share of a flagship's listed commits that
+	// makes a contributor a co-maintainer
+	coMaintainerShare = 0.1
+
+	// maxCoMaintainers bounds the co-maintainers kept per account
+	maxCoMaintainers = 10
+
+	// maxReleasesListed is how many releases are read per flagship for
+	// their authors
+	maxReleasesListed = 30
+
+	// MaxCoMaintainerDepth caps WithCoMaintainerDepth, and
+	// maxRecursedAccounts the shallow analyses one run may make
+	MaxCoMaintainerDepth = 2
+	maxRecursedAccounts  = 20
+)
+
+// CoMaintainer is another account with a hand in the flagship repos: a
+// large share of their commits or any of their releases. RiskLevel and
+// OverallScore are filled by a co-maintainer recursion; Depth is how many
+// hops from the analyzed account it was found and Via who it was found
+// through.
+type CoMaintainer struct {
+	Login    string   `json:"login"`
+	Repos    []string `json:"repos"`
+	Share    float64  `json:"share"`
+	Releases int      `json:"releases,omitempty"`
+
+	Depth        int      `json:"depth,omitempty"`
+	Via          string   `json:"via,omitempty"`
+	RiskLevel    string   `json:"risk_level,omitempty"`
+	OverallScore *float64 `json:"overall_score,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// GetReleases lists up to n of a repo's releases, newest first
+func (c *GitHubClient) GetReleases(ctx context.Context, owner, repo string, n int) (_ []GitHubRelease, err error) {
+	ctx, span := c.startSpan(ctx, "github.releases", "repos/:owner/:repo/releases")
+	defer func() { endSpan(span, err) }()
+
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(min(n, maxPerPage)))
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/releases?%s", c.BaseURL, owner, repo, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	releases, _, err := decodeElements[GitHubRelease](data)
+	return releases, err
+}
+
+// contributors lists a flagship's top contributors once per analysis
+func (a *Analyzer) contributors(ctx context.Context, r *analysisRun, repo GitHubRepo) ([]GitHubUser, error) {
+	if cached, ok := r.contributors[repo.FullName]; ok {
+		return cached, nil
+	}
+	owner, name := repoOwnerAndName(repo, r.username)
+	contributors, err := a.client.GetContributors(ctx, owner, name, maxContributorsChecked)
+	if err != nil {
+		return nil, err
+	}
+	r.contributors[repo.FullName] = contributors
+	return contributors, nil
+}
+
+// isBotLogin reports whether an account is an app or bot rather than a person
+func isBotLogin(user GitHubUser) bool {
+	return user.Type == "Bot" || strings.HasSuffix(user.Login, "[bot]")
+}
+
+// discoverCoMaintainers collects the accounts with at least
+// coMaintainerShare of a flagship's listed commits or any of its recent
+// releases, excluding the analyzed account, bots and the ghost placeholder
+func (a *Analyzer) discoverCoMaintainers(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	self := strings.ToLower(r.user.Login)
+	found := map[string]*CoMaintainer{}
+	note := func(login, repo string) *CoMaintainer {
+		key := strings.ToLower(login)
+		cm, ok := found[key]
+		if !ok {
+			cm = &CoMaintainer{Login: login}
+			found[key] = cm
+		}
+		if !strings.EqualFold(lastOf(cm.Repos), repo) {
+			cm.Repos = append(cm.Repos, repo)
+		}
+		return cm
+	}
+
+	for _, repo := range r.flagships() {
+		if repo.Fork {
+			continue
+		}
+		if contributors, err := a.contributors(ctx, r, repo); err == nil {
+			total := 0
+			for _, contributor := range contributors {
+				total += contributor.Contributions
+			}
+			for _, contributor := range contributors {
+				if total == 0 || isBotLogin(contributor) || isGhost(contributor) || strings.EqualFold(contributor.Login, self) {
+					continue
+				}
+				if share := float64(contributor.Contributions) / float64(total); share >= coMaintainerShare {
+					cm := note(contributor.Login, repo.FullName)
+					cm.Share = max(cm.Share, share)
+				}
+			}
+		}
+
+		owner, name := repoOwnerAndName(repo, r.username)
+		if releases, err := a.client.GetReleases(ctx, owner, name, maxReleasesListed); err == nil {
+			for _, release := range releases {
+				author := release.Author
+				if author.Login == "" || isBotLogin(author) || isGhost(author) || strings.EqualFold(author.Login, self) {
+					continue
+				}
+				note(author.Login, repo.FullName).Releases++
+			}
+		}
+	}
+
+	r.coMaintainers = make([]CoMaintainer, 0, len(found))
+	for _, cm := range found {
+		r.coMaintainers = append(r.coMaintainers, *cm)
+	}
+	sort.Slice(r.coMaintainers, func(i, j int) bool {
+		a, b := r.coMaintainers[i], r.coMaintainers[j]
+		if a.Releases != b.Releases {
+			return a.Releases > b.Releases
+		}
+		if a.Share != b.Share {
+			return a.Share > b.Share
+		}
+		return a.Login < b.Login
+	})
+	r.coMaintainers = r.coMaintainers[:min(len(r.coMaintainers), maxCoMaintainers)]
+}
+
+// lastOf returns the last element of values, or ""
+func lastOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// shallow returns an analyzer for co-maintainer recursion: the same
+// client, no deep, gist or external checks, and no further recursion
+func (a *Analyzer) shallow() *Analyzer {
+	opts := a.opts
+	opts.DeepChecks = false
+	opts.Gists = false
+	opts.ExternalChecks = false
+	opts.CoMaintainerDepth = 0
+	opts.AnalysisTimeout = 0
+	return &Analyzer{client: a.client, opts: opts, denylist: a.denylist}
+}
+
+// recurseCoMaintainers runs a shallow analysis of each co-maintainer,
+// breadth first to CoMaintainerDepth hops. Accounts already seen, the
+// analyzed one included, are never analyzed twice, so cycles end, and no
+// more than maxRecursedAccounts analyses are made.
+func (a *Analyzer) recurseCoMaintainers(ctx context.Context, r *analysisRun) {
+	if a.opts.CoMaintainerDepth <= 0 || len(r.coMaintainers) == 0 {
+		return
+	}
+
+	shallow := a.shallow()
+	seen := map[string]struct{}{strings.ToLower(r.user.Login): {}}
+	for _, cm := range r.coMaintainers {
+		seen[strings.ToLower(cm.Login)] = struct{}{}
+	}
+	for i := range r.coMaintainers {
+		r.coMaintainers[i].Depth = 1
+	}
+
+	analyzed := 0
+	for next := 0; next < len(r.coMaintainers) && ctx.Err() == nil; next++ {
+		cm := &r.coMaintainers[next]
+		if analyzed == maxRecursedAccounts {
+			break
+		}
+		analyzed++
+
+		// A partial analysis still carries a risk level worth reporting
+		analysis, err := shallow.analyze(ctx, cm.Login, nil, nil, nil)
+		if analysis == nil {
+			cm.Error = err.Error()
+			continue
+		}
+		score := analysis.OverallScore
+		cm.RiskLevel, cm.OverallScore = analysis.RiskLevel, &score
+
+		if cm.Depth >= a.opts.CoMaintainerDepth {
+			continue
+		}
+		depth, via := cm.Depth+1, cm.Login
+		for _, found := range analysis.CoMaintainers {
+			key := strings.ToLower(found.Login)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			found.Depth, found.Via = depth, via
+			// Appending may move the slice, so cm isn't used past here
+			r.coMaintainers = append(r.coMaintainers, found)
+		}
+	}
+}
+
+// checkCoMaintainers reports the co-maintainers, raising a red flag when a
+// recursion found one at high risk
+func (a *Analyzer) checkCoMaintainers(r *analysisRun) {
+	if len(r.coMaintainers) == 0 {
+		return
+	}
+
+	evidence := make([]string, 0, len(r.coMaintainers))
+	var risky []string
+	for _, cm := range r.coMaintainers {
+		line := cm.describe()
+		evidence = append(evidence, line)
+		if cm.RiskLevel == "high" {
+			risky = append(risky, line)
+		}
+	}
+	r.addFinding(Finding{
+		Code:     "CO_MAINTAINERS",
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("%d other accounts commit to or release the flagship repos - worth a follow-up", len(r.coMaintainers)),
+		Evidence: evidence,
+	})
+	if len(risky) > 0 {
+		r.addFinding(Finding{
+			Code:     "RISKY_CO_MAINTAINER",
+			Severity: SeverityRedFlag,
+			Message:  "A co-maintainer of the flagship repos is itself high risk",
+			Evidence: risky,
+		})
+	}
+}
+
+// describe summarizes a co-maintainer for evidence
+func (cm CoMaintainer) describe() string {
+	var parts []string
+	if cm.Share > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% of commits", cm.Share*100))
+	}
+	if cm.Releases > 0 {
+		parts = append(parts, fmt.Sprintf("%d releases", cm.Releases))
+	}
+	line := fmt.Sprintf("%s (%s in %s)", cm.Login, strings.Join(parts, ", "), strings.Join(cm.Repos, ", "))
+	if cm.Via != "" {
+		line += " via " + cm.Via
+	}
+	if cm.RiskLevel != "" {
+		line += fmt.Sprintf(": %s risk, %.1f", cm.RiskLevel, *cm.OverallScore)
+	}
+	return line
+}
@@ -0,0 +1,231 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithCoMaintainerDepth(t *testing.T) {
+	for _, depth := range []int{-1, MaxCoMaintainerDepth + 1} {
+		if _, err := New("", WithCoMaintainerDepth(depth)); err == nil {
+			t.Errorf("depth %d was accepted", depth)
+		}
+	}
+	a, err := New("", WithCoMaintainerDepth(MaxCoMaintainerDepth))
+	if err != nil || a.opts.CoMaintainerDepth != MaxCoMaintainerDepth {
+		t.Errorf("New = %v, depth %d", err, a.opts.CoMaintainerDepth)
+	}
+}
+
+func TestCoMaintainerDescribe(t *testing.T) {
+	score := 6.25
+	for _, tt := range []struct {
+		cm   CoMaintainer
+		want string
+	}{
+		{CoMaintainer{Login: "alice", Repos: []string{"octo/tool"}, Share: 0.25}, "alice (25% of commits in octo/tool)"},
+		{CoMaintainer{Login: "bob", Repos: []string{"octo/tool", "octo/lib"}, Releases: 3}, "bob (3 releases in octo/tool, octo/lib)"},
+		{CoMaintainer{Login: "dave", Repos: []string{"alice/app"}, Share: 0.2, Releases: 1, Via: "alice", RiskLevel: "high", OverallScore: &score},
+			"dave (20% of commits, 1 releases in alice/app) via alice: high risk, 6.2"},
+	} {
+		if got := tt.cm.describe(); got != tt.want {
+			t.Errorf("describe = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// serveContributors lists contributors of owner/repo with their commit counts
+func serveContributors(f *fakeGitHub, owner, repo string, contributions map[string]int) {
+	f.route("/repos/"+owner+"/"+repo+"/contributors", func(w http.ResponseWriter, r *http.Request) {
+		var users []GitHubUser
+		for login, n := range contributions {
+			user := GitHubUser{Login: login, Type: "User", Contributions: n}
+			if strings.HasSuffix(login, "[bot]") {
+				user.Type = "Bot"
+			}
+			users = append(users, user)
+		}
+		slices.SortFunc(users, func(x, y GitHubUser) int { return y.Contributions - x.Contributions })
+		_ = json.NewEncoder(w).Encode(users)
+	})
+}
+
+func TestDiscoverCoMaintainers(t *testing.T) {
+	repo := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Go", Size: 900, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(2))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), repo("tool", 300), repo("lib", 200)))
+	serveContributors(f, "octo", "tool", map[string]int{"octo": 55, "alice": 25, "bob": 5, "dependabot[bot]": 10, "ghost": 5})
+	serveContributors(f, "octo", "lib", map[string]int{"Octo": 50, "alice": 50})
+	f.route("/repos/octo/tool/releases", func(w http.ResponseWriter, r *http.Request) {
+		releases := make([]GitHubRelease, 5)
+		for i, author := range []string{"octo", "bob", "bob", "carol", "github-actions[bot]"} {
+			releases[i].Author = GitHubUser{Login: author}
+		}
+		_ = json.NewEncoder(w).Encode(releases)
+	})
+
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// Release authors first, then by share; bots, the ghost and octo itself
+	// are left out, and bob's share of commits is too small to count
+	want := []CoMaintainer{
+		{Login: "bob", Repos: []string{"octo/tool"}, Releases: 2},
+		{Login: "carol", Repos: []string{"octo/tool"}, Releases: 1},
+		{Login: "alice", Repos: []string{"octo/tool", "octo/lib"}, Share: 0.5},
+	}
+	if !slices.EqualFunc(analysis.CoMaintainers, want, func(x, y CoMaintainer) bool {
+		return x.Login == y.Login && slices.Equal(x.Repos, y.Repos) && x.Share == y.Share && x.Releases == y.Releases && x.RiskLevel == ""
+	}) {
+		t.Errorf("CoMaintainers = %+v, want %+v", analysis.CoMaintainers, want)
+	}
+
+	flag := finding(analysis, "CO_MAINTAINERS")
+	evidence := []string{"bob (2 releases in octo/tool)", "carol (1 releases in octo/tool)", "alice (50% of commits in octo/tool, octo/lib)"}
+	if flag == nil || flag.Severity != SeverityInfo || !slices.Equal(flag.Evidence, evidence) {
+		t.Errorf("CO_MAINTAINERS = %+v, want %q", flag, evidence)
+	}
+	if finding(analysis, "RISKY_CO_MAINTAINER") != nil {
+		t.Errorf("RISKY_CO_MAINTAINER without a recursion")
+	}
+}
+
+// recursionFake serves octo, co-maintained by alice, who shares alice/app
+// with octo and with dave, who is denylisted
+func recursionFake(t *testing.T) *fakeGitHub {
+	repo := func(name string) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Go", Size: 900, StargazersCount: 80, UpdatedAt: fakeNow.Add(-days(2))}
+	}
+	f := newFakeGitHub(t,
+		newAccount("octo", days(3000), repo("tool")),
+		newAccount("alice", days(2500), repo("app")),
+		newAccount("dave", days(2000), repo("kit")),
+	)
+	serveContributors(f, "octo", "tool", map[string]int{"octo": 70, "alice": 30})
+	// octo is already seen, so the cycle back ends here
+	serveContributors(f, "alice", "app", map[string]int{"alice": 50, "octo": 30, "dave": 20})
+	serveContributors(f, "dave", "kit", map[string]int{"dave": 50, "erin": 50})
+	return f
+}
+
+func TestRecurseCoMaintainers(t *testing.T) {
+	denied := WithDenylist(DenylistEntry{Login: "dave", Reference: "https://example.com/advisory"})
+	for _, tt := range []struct {
+		depth  int
+		logins []string
+		risky  []string
+	}{
+		{0, []string{"alice"}, nil},
+		{1, []string{"alice"}, nil},
+		{2, []string{"alice", "dave"}, []string{"dave"}},
+	} {
+		t.Run(fmt.Sprint(tt.depth), func(t *testing.T) {
+			analysis, err := newFakeAnalyzer(recursionFake(t), WithCoMaintainerDepth(tt.depth), denied).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			var logins, risky []string
+			for _, cm := range analysis.CoMaintainers {
+				logins = append(logins, cm.Login)
+				if analyzed := cm.RiskLevel != "" && cm.OverallScore != nil; analyzed != (tt.depth > 0) {
+					t.Errorf("%s: risk %q, want it analyzed only when recursing", cm.Login, cm.RiskLevel)
+				}
+				if cm.RiskLevel == "high" {
+					risky = append(risky, cm.describe())
+				}
+			}
+			if !slices.Equal(logins, tt.logins) {
+				t.Fatalf("co-maintainers %q, want %q", logins, tt.logins)
+			}
+			if tt.depth == 2 {
+				if dave := analysis.CoMaintainers[1]; dave.Depth != 2 || dave.Via != "alice" || dave.RiskLevel != "high" || !slices.Equal(dave.Repos, []string{"alice/app"}) {
+					t.Errorf("dave = %+v, want found at depth 2 via alice, high risk", dave)
+				}
+			}
+
+			flag := finding(analysis, "RISKY_CO_MAINTAINER")
+			if tt.risky == nil {
+				if flag != nil {
+					t.Errorf("unexpected %+v", flag)
+				}
+				return
+			}
+			if flag == nil || flag.Severity != SeverityRedFlag || !slices.Equal(flag.Evidence, risky) || !strings.HasPrefix(flag.Evidence[0], "dave (20% of commits in alice/app) via alice: high risk") {
+				t.Errorf("RISKY_CO_MAINTAINER = %+v, want dave's line", flag)
+			}
+		})
+	}
+}
+
+func TestRecurseCoMaintainersCap(t *testing.T) {
+	repo := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 80, UpdatedAt: fakeNow.Add(-days(2))}
+	accounts := []*fakeAccount{newAccount("octo", days(3000), repo)}
+	first := map[string]int{}
+	for i := range maxCoMaintainers {
+		login := fmt.Sprintf("c%d", i)
+		first[login] = 10
+		accounts = append(accounts, newAccount(login, days(2000), repo))
+	}
+	f := newFakeGitHub(t, accounts...)
+	serveContributors(f, "octo", "tool", first)
+	// Each first-hop co-maintainer brings ten more, none of them known
+	for login := range first {
+		second := map[string]int{}
+		for j := range maxCoMaintainers {
+			second[fmt.Sprintf("%s-%d", login, j)] = 10
+		}
+		serveContributors(f, login, "tool", second)
+	}
+
+	analysis, err := newFakeAnalyzer(f, WithCoMaintainerDepth(2)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	analyzed := 0
+	for i, cm := range analysis.CoMaintainers {
+		if cm.RiskLevel != "" || cm.Error != "" {
+			analyzed++
+		}
+		if wantDepth := 1 + min(i/maxCoMaintainers, 1); cm.Depth != wantDepth {
+			t.Errorf("%s at depth %d, want %d", cm.Login, cm.Depth, wantDepth)
+		}
+	}
+	// Breadth first, so the whole first hop is analyzed before the cap
+	if analyzed != maxRecursedAccounts || len(analysis.CoMaintainers) != maxCoMaintainers*(maxCoMaintainers+1) {
+		t.Errorf("analyzed %d of %d co-maintainers, want %d", analyzed, len(analysis.CoMaintainers), maxRecursedAccounts)
+	}
+	for _, cm := range analysis.CoMaintainers[:maxCoMaintainers] {
+		if cm.RiskLevel == "" {
+			t.Errorf("first-hop %s wasn't analyzed: %+v", cm.Login, cm)
+		}
+	}
+}
+
+func TestEstimateCoMaintainerCost(t *testing.T) {
+	user := &GitHubUser{Login: "octo", PublicRepos: 12}
+	counts := func(depth int) map[string]int {
+		opts := defaultOptions()
+		opts.CoMaintainerDepth = depth
+		counts := map[string]int{}
+		for _, request := range EstimateCost(user, opts).Requests {
+			counts[request.Step] += request.Count
+		}
+		return counts
+	}
+	shallow := counts(0)
+	if shallow["co_maintainers"] != 2*flagshipCount || shallow["co_maintainer_user"] != 0 {
+		t.Errorf("depth 0 costs %d discovery requests and %d recursed users, want %d and none", shallow["co_maintainers"], shallow["co_maintainer_user"], 2*flagshipCount)
+	}
+	// Each recursed account costs at least its user lookup
+	for depth, accounts := range map[int]int{1: maxCoMaintainers, 2: maxRecursedAccounts} {
+		if got := counts(depth)["co_maintainer_user"]; got != accounts {
+			t.Errorf("depth %d costs %d recursed users, want %d", depth, got, accounts)
+		}
+	}
+}
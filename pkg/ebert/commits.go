@@ -0,0 +1,74 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Commit count methods recorded in Metrics.CommitCountMethod
+const (
+	CommitCountSearch = "search"
+	CommitCountEvents = "events"
+)
+
+// eventsFeedCeiling is the most events the public events API will return
+const eventsFeedCeiling = 300
+
+// commitSearchAccept is the media type for commit search; GitHub once
+// required the cloak preview and still accepts it
+const commitSearchAccept = "application/vnd.github.cloak-preview+json"
+
+// SearchCommitCount returns how many commits username authored since the
+// given time, using the commit search API. Search requests share their own
+// rate limiter, separate from the core API budget.
+func (c *GitHubClient) SearchCommitCount(ctx context.Context, username string, since time.Time) (_ int, err error) {
+	ctx, span := c.startSpan(ctx, "github.search_commits", "search/commits")
+	defer func() { endSpan(span, err) }()
+
+	if err := c.shared().search.wait(ctx); err != nil {
+		return 0, err
+	}
+
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf("author:%s author-date:>%s", username, since.UTC().Format("2006-01-02")))
+	query.Set("per_page", "1")
+
+	data, err := c.getAccept(ctx, c.BaseURL+"/search/commits?"+query.Encode(), commitSearchAccept)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		TotalCount int `json:"total_count"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return 0, err
+	}
+
+	return result.TotalCount, nil
+}
+
+// countCommits replaces the events heuristic with a true commit count when
+// the client is authenticated, falling back silently to the events figure
+func (a *Analyzer) countCommits(ctx context.Context, username string, now time.Time, metrics *Metrics, log *sourceLog) {
+	metrics.CommitCountMethod = CommitCountEvents
+	metrics.CommitCountLowerBound = metrics.EventsReceived >= eventsFeedCeiling
+
+	if !a.client.authenticated() {
+		return
+	}
+
+	count, err := a.client.SearchCommitCount(ctx, username, now.Add(-a.opts.ActivityWindow))
+	if err != nil {
+		log.fellBack("commit_search", fmt.Errorf("failed to search commits: %w", err))
+		return
+	}
+
+	log.ok("commit_search")
+	metrics.RecentCommits = count
+	metrics.CommitCountMethod = CommitCountSearch
+	metrics.CommitCountLowerBound = false
+}
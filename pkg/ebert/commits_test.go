@@ -0,0 +1,131 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// pushFeed is n pushes to login/tool an hour apart, each of commits commits
+func pushFeed(login string, n, commits int) []GitHubEvent {
+	events := make([]GitHubEvent, n)
+	for i := range events {
+		events[i] = GitHubEvent{
+			ID: fmt.Sprint(6000 + i), Type: "PushEvent", CreatedAt: fakeNow.Add(-time.Duration(i+1) * time.Hour),
+			Payload: json.RawMessage(fmt.Sprintf(`{"push_id":%d,"size":%d,"distinct_size":%d,"ref":"refs/heads/main"}`, 600+i, commits, commits)),
+		}
+		events[i].Repo.Name = login + "/tool"
+	}
+	return events
+}
+
+func TestCommitCountMethods(t *testing.T) {
+	searchFound := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"total_count":1234,"items":[]}`))
+	}
+	searchFails := func(w http.ResponseWriter, r *http.Request) { http.Error(w, "{}", http.StatusUnprocessableEntity) }
+	for _, tt := range []struct {
+		name       string
+		token      string
+		search     http.HandlerFunc
+		events     int
+		commits    int
+		method     string
+		lowerBound bool
+		// source is the commit_search status, empty when it isn't tried
+		source string
+	}{
+		{"searched", "t0ken", searchFound, 20, 1, CommitCountSearch, false, SourceOK},
+		{"search fails", "t0ken", searchFails, 20, 1, CommitCountEvents, false, SourceFallback},
+		{"anonymous", "", searchFound, 20, 1, CommitCountEvents, false, ""},
+		{"anonymous at the ceiling", "", searchFound, eventsFeedCeiling, 2, CommitCountEvents, true, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newAccount("octo", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(1))})
+			account.Events = pushFeed("octo", tt.events, tt.commits)
+			f := newFakeGitHub(t, account)
+			var queries []string
+			f.route("/search/commits", func(w http.ResponseWriter, r *http.Request) {
+				if accept := r.Header.Get("Accept"); accept != commitSearchAccept {
+					t.Errorf("commit search sent Accept %q", accept)
+				}
+				queries = append(queries, r.URL.Query().Get("q"))
+				tt.search(w, r)
+			})
+
+			analysis, err := newFakeAnalyzerToken(f, tt.token).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if metrics.CommitCountMethod != tt.method || metrics.CommitCountLowerBound != tt.lowerBound {
+				t.Errorf("counted by %s, lower bound %t; want %s, %t", metrics.CommitCountMethod, metrics.CommitCountLowerBound, tt.method, tt.lowerBound)
+			}
+			want := tt.events * tt.commits
+			if tt.method == CommitCountSearch {
+				want = 1234
+			}
+			if metrics.RecentCommits != want {
+				t.Errorf("RecentCommits = %d, want %d", metrics.RecentCommits, want)
+			}
+
+			if tt.token == "" && len(queries) > 0 {
+				t.Errorf("searched %q without a token", queries)
+			}
+			if tt.token != "" && !slices.Equal(queries, []string{"author:octo author-date:>2024-03-03"}) {
+				t.Errorf("searched %q, want commits since the start of the 90-day window", queries)
+			}
+			status := ""
+			for _, source := range analysis.DataSources {
+				if source.Name == "commit_search" {
+					status = source.Status
+				}
+			}
+			if status != tt.source || analysis.Partial {
+				t.Errorf("commit_search is %q, partial %t; want %q and a complete analysis", status, analysis.Partial, tt.source)
+			}
+		})
+	}
+}
+
+func TestFeedCeilingNotPenalized(t *testing.T) {
+	// 300 pushes of nothing new: the ceiling hides any real commits
+	account := newAccount("octo", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(1))})
+	account.Events = pushFeed("octo", eventsFeedCeiling, 0)
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if !analysis.Metrics.CommitCountLowerBound {
+		t.Fatal("a full feed should make the commit count a lower bound")
+	}
+	if flag := finding(analysis, "LOW_ACTIVITY"); flag != nil {
+		t.Errorf("got %+v for a feed cut off at its ceiling", flag)
+	}
+
+	// The same pushes in a short feed are low activity
+	account.Events = account.Events[:40]
+	analysis, err = newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if finding(analysis, "LOW_ACTIVITY") == nil {
+		t.Error("want LOW_ACTIVITY for 40 empty pushes")
+	}
+}
+
+func TestSearchLimiterSpacing(t *testing.T) {
+	l := newIntervalLimiter(20 * time.Millisecond)
+	start := time.Now()
+	for range 3 {
+		if err := l.wait(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("three searches took %s, want them 20ms apart", elapsed)
+	}
+}
@@ -0,0 +1,118 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CommunityProfile is a repo's community health summary: which of the
+// recommended community files it has and the share present
+type CommunityProfile struct {
+	HealthPercentage int `json:"health_percentage"`
+	Files            struct {
+		Readme              *CommunityFile `json:"readme"`
+		License             *CommunityFile `json:"license"`
+		CodeOfConduct       *CommunityFile `json:"code_of_conduct"`
+		Contributing        *CommunityFile `json:"contributing"`
+		IssueTemplate       *CommunityFile `json:"issue_template"`
+		PullRequestTemplate *CommunityFile `json:"pull_request_template"`
+
+		// Security is only reported by some GitHub versions; when absent
+		// the security policy is looked for file by file
+		Security *CommunityFile `json:"security"`
+	} `json:"files"`
+}
+
+// CommunityFile is one community file a profile found; Key and Name are
+// set for licenses and codes of conduct
+type CommunityFile struct {
+	Key     string `json:"key,omitempty"`
+	Name    string `json:"name,omitempty"`
+	HTMLURL string `json:"html_url"`
+}
+
+// path is the file's path in the repo, from its blob URL
+func (f *CommunityFile) path() (string, bool) {
+	_, rest, ok := strings.Cut(f.HTMLURL, "/blob/")
+	if !ok {
+		return "", false
+	}
+	_, path, ok := strings.Cut(rest, "/")
+	return path, ok && path != ""
+}
+
+// GetCommunityProfile fetches a repo's community profile: README, license,
+// code of conduct, contributing guide and templates in one request. Empty
+// repos have none and answer 404.
+func (c *GitHubClient) GetCommunityProfile(ctx context.Context, owner, repo string) (_ *CommunityProfile, err error) {
+	ctx, span := c.startSpan(ctx, "github.community_profile", "repos/:owner/:repo/community/profile")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/community/profile", c.BaseURL, url.PathEscape(owner), url.PathEscape(repo)))
+	if err != nil {
+		return nil, err
+	}
+
+	var profile CommunityProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to decode community profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// checkCommunityProfiles fetches the community profile of each top repo,
+// averaging their health into Metrics.AvgCommunityHealth and warning about
+// original repos without a license. A repo without a profile counts as 0.
+func (a *Analyzer) checkCommunityProfiles(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	var failed error
+	var empty, unlicensed []string
+	total, counted := 0, 0
+	for _, repo := range r.checkable() {
+		owner, name := repoOwnerAndName(repo, r.username)
+		profile, err := a.client.GetCommunityProfile(ctx, owner, name)
+		switch {
+		case isNotFound(err):
+			empty = append(empty, repo.FullName)
+			counted++
+			continue
+		case err != nil:
+			failed = err
+			continue
+		}
+		r.communityProfiles[repo.FullName] = profile
+		total += profile.HealthPercentage
+		counted++
+
+		if profile.Files.License == nil && classifyRepo(repo, r.user.Login) == RepoOriginal {
+			unlicensed = append(unlicensed, repo.FullName)
+		}
+	}
+
+	switch {
+	case failed != nil:
+		r.log.fellBack("community_profile", fmt.Errorf("failed to fetch some community profiles: %w", failed))
+	case len(empty) > 0:
+		r.log.okWith("community_profile", fmt.Sprintf("no profile for %s, counted as 0", strings.Join(empty, ", ")))
+	case counted > 0:
+		r.log.ok("community_profile")
+	}
+	if counted > 0 {
+		r.acc.metrics.AvgCommunityHealth = float64(total) / float64(counted)
+	}
+
+	if len(unlicensed) > 0 {
+		r.addFinding(Finding{
+			Code:     "NO_LICENSE",
+			Severity: SeverityWarning,
+			Message:  "Top repos have no license, leaving their terms of use unclear",
+			Evidence: unlicensed,
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package ebert
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestCommunityFilePath(t *testing.T) {
+	for _, tt := range []struct {
+		url, want string
+		ok        bool
+	}{
+		{"https://github.com/octo/tool/blob/main/SECURITY.md", "SECURITY.md", true},
+		{"https://github.com/octo/tool/blob/main/.github/SECURITY.md", ".github/SECURITY.md", true},
+		{"https://github.com/octo/tool/blob/main/", "", false},
+		{"https://github.com/octo/tool", "", false},
+		{"", "", false},
+	} {
+		file := CommunityFile{HTMLURL: tt.url}
+		if got, ok := file.path(); got != tt.want || ok != tt.ok {
+			t.Errorf("path(%q) = %q, %t; want %q, %t", tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// serveCommunityProfile answers octo/repo's community profile with body
+func serveCommunityProfile(f *fakeGitHub, repo, body string) {
+	f.route("/repos/octo/"+repo+"/community/profile", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+// communitySource returns the analysis's community_profile data source
+func communitySource(t *testing.T, analysis *Analysis) DataSource {
+	t.Helper()
+	for _, source := range analysis.DataSources {
+		if source.Name == "community_profile" {
+			return source
+		}
+	}
+	t.Fatalf("no community_profile source in %+v", analysis.DataSources)
+	return DataSource{}
+}
+
+func TestCheckCommunityProfiles(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 50, UpdatedAt: fakeNow.Add(-days(2))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(3))},
+		GitHubRepo{Name: "forked", Language: "Go", Fork: true, Size: 300, StargazersCount: 30, UpdatedAt: fakeNow.Add(-days(4))},
+		GitHubRepo{Name: "empty", StargazersCount: 20, UpdatedAt: fakeNow.Add(-days(5))},
+	))
+	serveCommunityProfile(f, "tool", `{"health_percentage":80,"files":{
+		"readme":{"html_url":"https://github.com/octo/tool/blob/main/README.md"},
+		"license":{"key":"mit","name":"MIT License","html_url":"https://github.com/octo/tool/blob/main/LICENSE"},
+		"security":{"html_url":"https://github.com/octo/tool/blob/main/docs/SECURITY.md"}}}`)
+	serveCommunityProfile(f, "lib", `{"health_percentage":40,"files":{"readme":{"html_url":"https://github.com/octo/lib/blob/main/README.md"}}}`)
+	serveCommunityProfile(f, "forked", `{"health_percentage":20,"files":{}}`)
+	serveFile(f, "tool", "docs/SECURITY.md", "Mail security@example.com")
+	// The root listing misses the policy the profile names
+	serveDirectory(f, "tool", "", "README.md")
+
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// The empty repo counts as 0
+	if got := analysis.Metrics.AvgCommunityHealth; got != 35 {
+		t.Errorf("AvgCommunityHealth = %v, want 35", got)
+	}
+	if source := communitySource(t, analysis); source.Status != SourceOK || source.Detail != "no profile for octo/empty, counted as 0" {
+		t.Errorf("community_profile source %+v", source)
+	}
+
+	// Forks inherit their upstream's terms
+	flag := finding(analysis, "NO_LICENSE")
+	if want := []string{"octo/lib"}; flag == nil || flag.Severity != SeverityWarning || flag.Index != IndexAbandonment || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("NO_LICENSE = %+v, want %q", flag, want)
+	}
+
+	if analysis.Metrics.ReposWithSecurityPolicy != 1 || !analysis.Metrics.HasDisclosureContact {
+		t.Errorf("%d security policies, contact %t; want tool's found from its profile",
+			analysis.Metrics.ReposWithSecurityPolicy, analysis.Metrics.HasDisclosureContact)
+	}
+}
+
+func TestCheckCommunityProfilesFallback(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 50, UpdatedAt: fakeNow.Add(-days(2))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(3))},
+	))
+	serveCommunityProfile(f, "tool", `{"health_percentage":70,"files":{"license":{"key":"mit","html_url":"https://github.com/octo/tool/blob/main/LICENSE"}}}`)
+	f.route("/repos/octo/lib/community/profile", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"unavailable"}`, http.StatusUnprocessableEntity)
+	})
+	// Without its profile, lib's policy is found by listing its root
+	serveDirectory(f, "lib", "", "README.md", "SECURITY.md")
+	serveFile(f, "lib", "SECURITY.md", "Mail security@example.com")
+
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// A profile that couldn't be fetched isn't counted as 0
+	if got := analysis.Metrics.AvgCommunityHealth; got != 70 {
+		t.Errorf("AvgCommunityHealth = %v, want 70", got)
+	}
+	if source := communitySource(t, analysis); source.Status != SourceFallback {
+		t.Errorf("community_profile source %+v, want a fallback", source)
+	}
+	if flag := finding(analysis, "NO_LICENSE"); flag != nil {
+		t.Errorf("unexpected %+v", flag)
+	}
+	if analysis.Metrics.ReposWithSecurityPolicy != 1 {
+		t.Errorf("ReposWithSecurityPolicy = %d, want lib's", analysis.Metrics.ReposWithSecurityPolicy)
+	}
+}
+
+func TestEstimateCommunityProfileCost(t *testing.T) {
+	opts := defaultOptions()
+	for repos, want := range map[int]int{3: 3, 500: opts.TopRepos} {
+		var count int
+		for _, request := range EstimateCost(&GitHubUser{Login: "octo", PublicRepos: repos}, opts).Requests {
+			if request.Step == "community_profile" {
+				count += request.Count
+			}
+		}
+		if count != want {
+			t.Errorf("%d repos: %d community profiles planned, want %d", repos, count, want)
+		}
+	}
+
+	// Security policies no longer cost a directory listing per top repo
+	for _, request := range EstimateCost(&GitHubUser{Login: "octo", PublicRepos: 3}, opts).Requests {
+		if request.Step == "contents" && request.Count != 2*3+2*3 {
+			t.Errorf("%d contents requests planned for 3 repos, want 12", request.Count)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package ebert
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxConfusableEvidence bounds how many names each confusable finding lists
+const maxConfusableEvidence = 20
+
+// confusables maps characters that render like Latin letters to the Latin
+// letter, a trimmed subset of the Unicode TR39 skeleton data covering the
+// common Cyrillic, Greek and digit cases. Fullwidth forms are folded
+// separately in skeleton.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j',
+	'к': 'k', 'ӏ': 'l', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's',
+	'т': 't', 'ц': 'u', 'ѵ': 'v', 'ԝ': 'w', 'х': 'x', 'у': 'y',
+	'А': 'a', 'В': 'b', 'С': 'c', 'Е': 'e', 'Н': 'h', 'І': 'i', 'Ј': 'j', 'К': 'k',
+	'М': 'm', 'О': 'o', 'Р': 'p', 'Ѕ': 's', 'Т': 't', 'Х': 'x', 'У': 'y',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	'Α': 'a', 'Β': 'b', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h', 'Ι': 'i', 'Κ': 'k', 'Μ': 'm',
+	'Ν': 'n', 'Ο': 'o', 'Ρ': 'p', 'Τ': 't', 'Υ': 'y', 'Χ': 'x',
+	// ASCII lookalikes
+	'0': 'o', '1': 'l', 'I': 'l', '|': 'l',
+}
+
+// popularNames are widely depended-on packages and maintainers that
+// lookalike names impersonate
+var popularNames = []string{
+	"react", "lodash", "express", "axios", "chalk", "commander", "debug", "moment",
+	"request", "typescript", "webpack", "eslint", "jquery", "vue", "angular",
+	"requests", "numpy", "pandas", "django", "flask", "boto3", "urllib3", "setuptools",
+	"colorama", "cryptography", "pyyaml", "openai", "anthropic",
+	"torvalds", "gaearon", "sindresorhus", "tj", "yyx990803", "kennethreitz",
+	"modelcontextprotocol", "microsoft", "google", "facebook", "github",
+}
+
+// popularSkeletons indexes popularNames by skeleton
+var popularSkeletons = func() map[string]string {
+	index := make(map[string]string, len(popularNames))
+	for _, name := range popularNames {
+		index[skeleton(name)] = name
+	}
+	return index
+}()
+
+// skeleton folds name to lowercase ASCII lookalikes so names that render
+// alike compare equal
+func skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		// Fullwidth ASCII variants sit at a fixed offset from ASCII
+		if r >= '！' && r <= '～' {
+			r -= 0xFEE0
+		}
+		if mapped, ok := confusables[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// mixedScripts reports whether name uses letters from more than one of
+// the Latin, Cyrillic and Greek scripts
+func mixedScripts(name string) bool {
+	var latin, cyrillic, greek bool
+	for _, r := range name {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		case unicode.Is(unicode.Greek, r):
+			greek = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{latin, cyrillic, greek} {
+		if present {
+			count++
+		}
+	}
+	return count > 1
+}
+
+// nonASCII reports whether name has any character outside ASCII
+func nonASCII(name string) bool {
+	for _, r := range name {
+		if r > unicode.MaxASCII {
+			return true
+		}
+	}
+	return false
+}
+
+// confusableScan collects lookalike names across the login and repos
+type confusableScan struct {
+	confusable    []string
+	impersonating []string
+}
+
+// add checks one name, recording it if it mixes scripts or uses
+// non-ASCII lookalikes, or if it folds to a popular name it isn't
+func (s *confusableScan) add(kind, name string) {
+	folded := skeleton(name)
+
+	if (mixedScripts(name) || nonASCII(name) && folded != strings.ToLower(name)) && len(s.confusable) < maxConfusableEvidence {
+		s.confusable = append(s.confusable, fmt.Sprintf("%s %q (skeleton %q)", kind, name, folded))
+	}
+
+	if popular, ok := popularSkeletons[folded]; ok && !strings.EqualFold(name, popular) && len(s.impersonating) < maxConfusableEvidence {
+		s.impersonating = append(s.impersonating, fmt.Sprintf("%s %q looks like %q (skeleton %q)", kind, name, popular, folded))
+	}
+}
+
+// confusables scans the login on top of the repo names already scanned
+func (c RuleContext) confusables() confusableScan {
+	scan := c.run.acc.confusables
+	scan.confusable = slices.Clone(scan.confusable)
+	scan.impersonating = slices.Clone(scan.impersonating)
+	scan.add("login", c.run.user.Login)
+	return scan
+}
+
+// confusableNameRule flags lookalike characters in the login and repo names
+var confusableNameRule = rule{
+	code:        "CONFUSABLE_NAME",
+	description: "The login or a repo name mixes scripts or uses characters that render like Latin letters",
+	evaluate: func(c RuleContext) []Finding {
+		scan := c.confusables()
+		if len(scan.confusable) == 0 {
+			return nil
+		}
+		return []Finding{{
+			Code:     "CONFUSABLE_NAME",
+			Severity: SeverityRedFlag,
+			Message:  "Names mix scripts or use lookalike characters that render like Latin letters",
+			Evidence: scan.confusable,
+		}}
+	},
+}
+
+// lookalikeNameRule flags names that render like popular packages or
+// maintainers
+var lookalikeNameRule = rule{
+	code:        "LOOKALIKE_NAME",
+	description: "The login or a repo name renders like a popular package or maintainer",
+	evaluate: func(c RuleContext) []Finding {
+		scan := c.confusables()
+		if len(scan.impersonating) == 0 {
+			return nil
+		}
+		return []Finding{{
+			Code:     "LOOKALIKE_NAME",
+			Severity: SeverityRedFlag,
+			Message:  "Names render like popular packages or maintainers (possible impersonation)",
+			Evidence: scan.impersonating,
+		}}
+	},
+}
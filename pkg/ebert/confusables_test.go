@@ -0,0 +1,86 @@
+package ebert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSkeleton(t *testing.T) {
+	for _, tt := range []struct {
+		name, raw, want string
+	}{
+		{"Cyrillic a", "reаct", "react"},
+		{"Cyrillic o and e", "lоdаsh-еxpress", "lodash-express"},
+		{"Greek omicron", "mοment", "moment"},
+		{"Greek capitals", "ΚΑΤΟ", "kato"},
+		{"fullwidth", "ｒｅａｃｔ", "react"},
+		{"fullwidth capitals and digits", "Ｗｅｂｐａｃｋ５", "webpack5"},
+		{"ASCII lookalikes", "I0dash", "lodash"},
+		{"plain", "Express", "express"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skeleton(tt.raw); got != tt.want {
+				t.Errorf("skeleton(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfusableScan(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		raw           string
+		confusable    bool
+		impersonating string
+	}{
+		{"Cyrillic a in a popular name", "reаct", true, "react"},
+		{"Greek omicron in a popular name", "mοment", true, "moment"},
+		{"fullwidth popular name", "ａｘｉｏｓ", true, "axios"},
+		{"mixed scripts", "my-тool", true, ""},
+		{"ASCII lookalike of a maintainer", "t0rvalds", false, "torvalds"},
+		{"the popular name itself", "React", false, ""},
+		{"unrelated ASCII", "dotfiles", false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var scan confusableScan
+			scan.add("repo", tt.raw)
+			if got := len(scan.confusable) == 1; got != tt.confusable {
+				t.Errorf("confusable = %v, want %v: %q", got, tt.confusable, scan.confusable)
+			}
+			if tt.impersonating == "" {
+				if len(scan.impersonating) != 0 {
+					t.Errorf("unexpected %q", scan.impersonating)
+				}
+				return
+			}
+			if len(scan.impersonating) != 1 || !strings.Contains(scan.impersonating[0], "looks like \""+tt.impersonating+"\"") {
+				t.Errorf("impersonating = %q, want %s", scan.impersonating, tt.impersonating)
+			}
+		})
+	}
+}
+
+func TestConfusableFindings(t *testing.T) {
+	account := newAccount("squatter", days(2000),
+		GitHubRepo{Name: "lоdash", Language: "JavaScript", Size: 300, UpdatedAt: fakeNow.Add(-days(2))},
+		GitHubRepo{Name: "notes", Language: "Go", Size: 300, UpdatedAt: fakeNow.Add(-days(9))},
+	)
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("squatter")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	for code, want := range map[string]string{
+		"CONFUSABLE_NAME": `repo "lоdash" (skeleton "lodash")`,
+		"LOOKALIKE_NAME":  `repo "lоdash" looks like "lodash" (skeleton "lodash")`,
+	} {
+		flag := finding(analysis, code)
+		if flag == nil || flag.Severity != SeverityRedFlag {
+			t.Errorf("want a %s red flag, got %+v", code, flag)
+			continue
+		}
+		if len(flag.Evidence) != 1 || flag.Evidence[0] != want {
+			t.Errorf("%s evidence = %q, want [%q]", code, flag.Evidence, want)
+		}
+	}
+}
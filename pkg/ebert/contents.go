@@ -0,0 +1,161 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxContentsRequests caps the contents and commits probes per analysis
+const maxContentsRequests = 30
+
+// ContentEntry is one entry of a repository directory listing
+type ContentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int    `json:"size"`
+}
+
+// GetDirectory lists a directory in the repo's default branch; a missing
+// directory or empty repo yields no entries and no error
+func (c *GitHubClient) GetDirectory(ctx context.Context, owner, repo, path string) (_ []ContentEntry, err error) {
+	ctx, span := c.startSpan(ctx, "github.contents", "repos/:owner/:repo/contents")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/contents/%s", c.BaseURL, owner, repo, escapePath(path)))
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ContentEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		// A path naming a file returns an object rather than a list
+		return nil, nil
+	}
+	return entries, nil
+}
+
+// LastCommitTime returns when path was last changed on the default branch,
+// or the zero time if no commit touches it
+func (c *GitHubClient) LastCommitTime(ctx context.Context, owner, repo, path string) (_ time.Time, err error) {
+	ctx, span := c.startSpan(ctx, "github.commits", "repos/:owner/:repo/commits")
+	defer func() { endSpan(span, err) }()
+
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("per_page", "1")
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/commits?%s", c.BaseURL, owner, repo, query.Encode()))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var commits []struct {
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	if err := json.Unmarshal(data, &commits); err != nil {
+		return time.Time{}, err
+	}
+	if len(commits) == 0 {
+		return time.Time{}, nil
+	}
+	return commits[0].Commit.Committer.Date, nil
+}
+
+// escapePath escapes each segment of a slash-separated repo path
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// isNotFound reports whether err is a 404 from the API
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// requestBudget bounds optional per-repo probes within one analysis
+type requestBudget struct {
+	remaining int
+}
+
+// take spends one request, reporting false once the budget is exhausted
+func (b *requestBudget) take() bool {
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// hasEntry reports whether a directory listing contains one of names
+func hasEntry(entries []ContentEntry, names ...string) (string, bool) {
+	for _, entry := range entries {
+		for _, name := range names {
+			if entry.Name == name {
+				return entry.Path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// directory lists path in a flagship repo, caching listings per run so
+// several checks can share one request and honoring the contents budget
+func (a *Analyzer) directory(ctx context.Context, r *analysisRun, repo GitHubRepo, path string) []ContentEntry {
+	key := repo.FullName + ":" + path
+	if entries, ok := r.directories[key]; ok {
+		return entries
+	}
+
+	var entries []ContentEntry
+	if r.contentsBudget.take() {
+		owner, name := repoOwnerAndName(repo, r.username)
+		entries, _ = a.client.GetDirectory(ctx, owner, name, path)
+	}
+	r.directories[key] = entries
+	return entries
+}
+
+// rawAccept asks the contents API for a file's raw bytes
+const rawAccept = "application/vnd.github.raw+json"
+
+// GetFile fetches a file from the repo's default branch; a missing file
+// yields nil and no error
+func (c *GitHubClient) GetFile(ctx context.Context, owner, repo, path string) ([]byte, error) {
+	return c.GetFileAt(ctx, owner, repo, path, "")
+}
+
+// GetFileAt fetches a file from the repo at ref, a branch, tag or commit,
+// or the default branch when ref is empty; a missing file or ref yields
+// nil and no error
+func (c *GitHubClient) GetFileAt(ctx context.Context, owner, repo, path, ref string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "github.contents", "repos/:owner/:repo/contents")
+	defer func() { endSpan(span, err) }()
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/contents/%s", c.BaseURL, owner, repo, escapePath(path))
+	if ref != "" {
+		endpoint += "?ref=" + url.QueryEscape(ref)
+	}
+	data, err := c.getAccept(ctx, endpoint, rawAccept)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return data, err
+}
@@ -0,0 +1,317 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Request budgets a planned request draws on
+const (
+	BudgetCore     = "core"
+	BudgetSearch   = "search"
+	BudgetGraphQL  = "graphql"
+	BudgetExternal = "external"
+)
+
+// PlannedRequest is one line of a cost estimate: how many requests a step
+// of the analysis is expected to make
+type PlannedRequest struct {
+	Step     string `json:"step"`
+	Endpoint string `json:"endpoint"`
+	Count    int    `json:"count"`
+	Budget   string `json:"budget"`
+	Note     string `json:"note,omitempty"`
+
+	// NeedsToken marks steps that only run when authenticated
+	NeedsToken bool `json:"needs_token,omitempty"`
+
+	// anonymous replaces a step that needs a token when there is none
+	anonymous []PlannedRequest
+}
+
+// CostEstimate is the expected request cost of analyzing an account. The
+// counts are upper bounds for the pages and caps ebert applies, so a real
+// run usually costs less.
+type CostEstimate struct {
+	Requests []PlannedRequest `json:"requests"`
+	Core     int              `json:"core"`
+	Search   int              `json:"search"`
+	GraphQL  int              `json:"graphql"`
+	External int              `json:"external"`
+
+	// RateLimit is the core quota when the estimate was made, if known
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+func (e *CostEstimate) add(request PlannedRequest) {
+	if request.Count <= 0 {
+		return
+	}
+	e.Requests = append(e.Requests, request)
+	switch request.Budget {
+	case BudgetCore:
+		e.Core += request.Count
+	case BudgetSearch:
+		e.Search += request.Count
+	case BudgetGraphQL:
+		e.GraphQL += request.Count
+	case BudgetExternal:
+		e.External += request.Count
+	}
+}
+
+// withoutToken drops the steps that need authentication
+func (e CostEstimate) withoutToken() CostEstimate {
+	kept := CostEstimate{RateLimit: e.RateLimit}
+	for _, request := range e.Requests {
+		if !request.NeedsToken {
+			kept.add(request)
+		}
+		for _, fallback := range request.anonymous {
+			kept.add(fallback)
+		}
+	}
+	return kept
+}
+
+// EstimateCost plans the requests an analysis of user would make with
+// opts, assuming an authenticated client. Repo pages come from
+// public_repos after the repo cap; the deep checks that read repo
+// contents share one per-analysis budget, so they are totalled together.
+func EstimateCost(user *GitHubUser, opts AnalyzerOptions) CostEstimate {
+	var e CostEstimate
+	pages := func(n int) int { return (n + maxPerPage - 1) / maxPerPage }
+
+	repos := user.PublicRepos
+	e.add(PlannedRequest{Step: "user", Endpoint: "users/:user", Count: 1, Budget: BudgetCore})
+	if limit := repoCap(user, opts); limit > 0 && repos > limit {
+		e.add(PlannedRequest{Step: "repo_search", Endpoint: "search/repositories", Count: 1, Budget: BudgetSearch,
+			Note: fmt.Sprintf("top-starred half of a %d-repo sample", limit)})
+		repos = limit
+	}
+	top := min(repos, opts.TopRepos)
+	restRepos := []PlannedRequest{
+		{Step: "repos", Endpoint: "users/:user/repos", Count: max(pages(repos), 1), Budget: BudgetCore,
+			Note: fmt.Sprintf("%d repos, %d per page", repos, maxPerPage)},
+		{Step: "repo_details", Endpoint: "repos/:owner/:repo", Count: top, Budget: BudgetCore,
+			Note: fmt.Sprintf("full objects for %d top repos", top)},
+	}
+	if opts.RepoList.graphQLListable() {
+		e.add(PlannedRequest{Step: "repos", Endpoint: "graphql", Count: max(pages(repos), 1), Budget: BudgetGraphQL, NeedsToken: true,
+			Note: fmt.Sprintf("%d repos with fork parents and watchers, %d per page", repos, maxPerPage), anonymous: restRepos})
+	} else {
+		for _, request := range restRepos {
+			e.add(request)
+		}
+	}
+	e.add(PlannedRequest{Step: "events", Endpoint: "users/:user/events/public", Count: pages(eventsFeedCeiling), Budget: BudgetCore,
+		Note: fmt.Sprintf("the feed holds at most %d events", eventsFeedCeiling)})
+	e.add(PlannedRequest{Step: "commit_search", Endpoint: "search/commits", Count: 1, Budget: BudgetSearch, NeedsToken: true})
+	e.add(PlannedRequest{Step: "works_in", Endpoint: "repos/:owner/:repo", Count: maxWorksIn, Budget: BudgetCore,
+		Note: fmt.Sprintf("up to %d repos of other accounts contributed to most", maxWorksIn)})
+	if opts.Gists {
+		e.add(PlannedRequest{Step: "gists", Endpoint: "users/:user/gists", Count: 1, Budget: BudgetCore})
+	}
+
+	flagships := min(repos, flagshipCount)
+	deep := opts.DeepChecks
+	external := deep && opts.ExternalChecks
+
+	if external {
+		e.add(PlannedRequest{Step: "docs_sites", Endpoint: "HEAD <pages site>", Count: flagships, Budget: BudgetExternal,
+			Note: "flagships with GitHub Pages"})
+	}
+	if deep {
+		e.add(PlannedRequest{Step: "pull_requests", Endpoint: "repos/:owner/:repo/pulls", Count: 2 * top, Budget: BudgetCore,
+			Note: fmt.Sprintf("open and closed for %d top repos", top)})
+		e.add(PlannedRequest{Step: "discussions", Endpoint: "graphql", Count: flagships, Budget: BudgetGraphQL, NeedsToken: true})
+		if user.Followers >= followerStepSize {
+			e.add(PlannedRequest{Step: "followers", Endpoint: "graphql", Count: pages(min(user.Followers, maxFollowersSampled)), Budget: BudgetGraphQL,
+				NeedsToken: true, Note: fmt.Sprintf("up to %d most recent follows", maxFollowersSampled)})
+		}
+		if opts.EngagementRings && flagships > 0 {
+			e.add(PlannedRequest{Step: "engagement_rings", Endpoint: "stargazers, users/:user/followers, following, starred",
+				Count: flagships + 2 + ringMembersChecked, Budget: BudgetCore,
+				Note: fmt.Sprintf("at most; stars of up to %d shared accounts only read on a high overlap", ringMembersChecked)})
+		}
+	}
+
+	e.add(PlannedRequest{Step: "community_profile", Endpoint: "repos/:owner/:repo/community/profile", Count: top, Budget: BudgetCore,
+		Note: fmt.Sprintf("community files of %d top repos", top)})
+
+	// The contents checks list directories and read files per repo; all of
+	// them together stop at maxContentsRequests. Security policies found in
+	// a community profile need no directory listing of their own.
+	contents := 2*top + flagships + flagships
+	note := "dependency automation, lockfiles, review sampling, flagship files and security policies the community profile misses"
+	if deep {
+		contents += 3*flagships + min(repos, maxInstallScriptPackages) + (1+historyTagChecks)*flagships + min(repos, maxContentOnlyChecked) + (1+maxVendoredSubtrees)*min(top, maxVendoredChecked) + (2+maxMonorepoSubtrees)*min(top, maxMonorepoChecked)
+		note += ", actions, package manifests, release reachability, content-only and vendored-code trees, monorepo packages"
+	}
+	if external {
+		contents += flagships + min(repos, maxCratesChecked)
+		note += ", releases, Cargo.toml"
+	}
+	e.add(PlannedRequest{Step: "contents", Endpoint: "repos/:owner/:repo/contents, commits, tags, releases, compare, git/trees",
+		Count: min(contents, maxContentsRequests), Budget: BudgetCore,
+		Note: fmt.Sprintf("%s; capped at %d", note, maxContentsRequests)})
+
+	e.add(PlannedRequest{Step: "co_maintainers", Endpoint: "repos/:owner/:repo/contributors, releases", Count: 2 * flagships,
+		Budget: BudgetCore, Note: "contributors and release authors of the flagships"})
+	if opts.CoMaintainerDepth > 0 && flagships > 0 {
+		// Each co-maintainer costs a shallow analysis; their repo counts
+		// aren't known yet, so this is a lower bound
+		accounts := min(maxCoMaintainers, maxRecursedAccounts)
+		if opts.CoMaintainerDepth > 1 {
+			accounts = maxRecursedAccounts
+		}
+		shallow := opts
+		shallow.DeepChecks, shallow.Gists, shallow.ExternalChecks, shallow.CoMaintainerDepth = false, false, false, 0
+		scale := func(request PlannedRequest) PlannedRequest {
+			request.Step = "co_maintainer_" + request.Step
+			request.Count *= accounts
+			request.Note = fmt.Sprintf("at least, for up to %d co-maintainers", accounts)
+			return request
+		}
+		for _, request := range EstimateCost(&GitHubUser{}, shallow).Requests {
+			request = scale(request)
+			anonymous := make([]PlannedRequest, 0, len(request.anonymous))
+			for _, fallback := range request.anonymous {
+				anonymous = append(anonymous, scale(fallback))
+			}
+			request.anonymous = anonymous
+			e.add(request)
+		}
+	}
+
+	if deep {
+		e.add(PlannedRequest{Step: "generated_content", Endpoint: "repos/:owner/:repo/commits, readme", Count: top + min(repos, maxReadmeSamples),
+			Budget: BudgetCore, Note: fmt.Sprintf("commit messages of %d top repos and up to %d READMEs", top, maxReadmeSamples)})
+		e.add(PlannedRequest{Step: "packages", Endpoint: "users/:user/packages", Count: 2, Budget: BudgetCore, NeedsToken: true,
+			Note: "npm and container"})
+	}
+	if external {
+		e.add(PlannedRequest{Step: "registry", Endpoint: "npm, PyPI and Docker Hub", Count: min(repos, maxInstallScriptPackages) + 2,
+			Budget: BudgetExternal, Note: "package manifests, maintainer search and images"})
+		e.add(PlannedRequest{Step: "package_co_maintainers", Endpoint: "npm search, users/:user", Count: 2 * maxNPMMaintainerResolutions,
+			Budget: BudgetExternal, NeedsToken: true, Note: fmt.Sprintf("at most; resolves up to %d npm co-maintainers to GitHub accounts", maxNPMMaintainerResolutions)})
+		e.add(PlannedRequest{Step: "image_signatures", Endpoint: "packages/container/:name/versions", Count: maxImageSignatureChecks,
+			Budget: BudgetCore, NeedsToken: true, Note: "only when Dockerfiles or images are found"})
+		e.add(PlannedRequest{Step: "release_signatures", Endpoint: "release assets", Count: flagships * maxSignaturesPerRelease * 2,
+			Budget: BudgetExternal, Note: "signatures and certificates on flagship releases"})
+		e.add(PlannedRequest{Step: "crates", Endpoint: "crates.io crates/:name, owners", Count: 2 * min(repos, maxCratesChecked),
+			Budget: BudgetExternal, Note: fmt.Sprintf("at most, one a second; Rust repos whose Cargo.toml declares a package, up to %d", maxCratesChecked)})
+		if opts.VerifyArtifacts {
+			e.add(PlannedRequest{Step: "artifact_tags", Endpoint: "repos/:owner/:repo/git/trees/:tag", Count: maxArtifactPackages * len(releaseTags("", "")),
+				Budget: BudgetCore, Note: fmt.Sprintf("at most; the release tag of up to %d published packages", maxArtifactPackages)})
+			e.add(PlannedRequest{Step: "artifacts", Endpoint: "npm tarballs, PyPI metadata and sdists", Count: 2 * maxArtifactPackages,
+				Budget: BudgetExternal, Note: fmt.Sprintf("at most; up to %d MiB each", maxArtifactBytes>>20)})
+		}
+	}
+	return e
+}
+
+// EstimateOrgCost plans an organization analysis over up to members of
+// its public members. Members' repo counts aren't known before they are
+// listed, so each is costed as an account without repos: a lower bound.
+func EstimateOrgCost(org *GitHubUser, members int, opts AnalyzerOptions) CostEstimate {
+	e := EstimateCost(org, opts)
+	if members <= 0 {
+		members = DefaultOrgMembers
+	}
+	e.add(PlannedRequest{Step: "org_members", Endpoint: "orgs/:org/members", Count: 1, Budget: BudgetCore,
+		Note: "more pages for orgs with over 100 public members"})
+
+	member := EstimateCost(&GitHubUser{}, opts)
+	for _, request := range member.Requests {
+		request.Step = "member_" + request.Step
+		request.Count *= members
+		request.Note = fmt.Sprintf("at least, for up to %d members", members)
+		e.add(request)
+	}
+	return e
+}
+
+// Plan looks up username and estimates what analyzing it would cost with
+// this analyzer's options and token, alongside the remaining core quota.
+// The two requests it makes aren't counted in the estimate.
+func (a *Analyzer) Plan(ctx context.Context, username string) (*GitHubUser, CostEstimate, error) {
+	return a.plan(ctx, username, func(user *GitHubUser) CostEstimate { return EstimateCost(user, a.opts) })
+}
+
+// PlanOrg is Plan for an organization analysis over up to members members
+func (a *Analyzer) PlanOrg(ctx context.Context, org string, members int) (*GitHubUser, CostEstimate, error) {
+	return a.plan(ctx, org, func(user *GitHubUser) CostEstimate { return EstimateOrgCost(user, members, a.opts) })
+}
+
+func (a *Analyzer) plan(ctx context.Context, username string, estimate func(*GitHubUser) CostEstimate) (*GitHubUser, CostEstimate, error) {
+	if err := ValidateUsername(username); err != nil {
+		return nil, CostEstimate{}, err
+	}
+	user, err := a.client.GetUser(ctx, NormalizeUsername(username))
+	if err != nil {
+		return nil, CostEstimate{}, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	cost := estimate(user)
+	if !a.client.authenticated() {
+		cost = cost.withoutToken()
+	}
+	if limit, err := a.client.GetRateLimit(ctx); err == nil {
+		cost.RateLimit = limit
+	}
+	return user, cost, nil
+}
+
+// FprintCostEstimate writes a cost estimate as a table
+func FprintCostEstimate(w io.Writer, user *GitHubUser, e CostEstimate) {
+	fmt.Fprintf(w, "Planned requests for %s (%d public repos):\n\n", user.Login, user.PublicRepos)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "   STEP\tENDPOINT\tREQUESTS\tBUDGET\tNOTE")
+	for _, request := range e.Requests {
+		fmt.Fprintf(tw, "   %s\t%s\t%d\t%s\t%s\n", request.Step, request.Endpoint, request.Count, request.Budget, request.Note)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(w, "\nEstimated total: %s core, %d search, %d GraphQL, %d external\n",
+		formatThousands(int64(e.Core)), e.Search, e.GraphQL, e.External)
+	if limit := e.RateLimit; limit != nil {
+		fmt.Fprintf(w, "Remaining quota: %s of %s core, resets %s\n",
+			formatThousands(int64(limit.Remaining)), formatThousands(int64(limit.Limit)), limit.Reset.Format("2006-01-02 15:04 MST"))
+		if e.Core > limit.Remaining {
+			fmt.Fprintln(w, "⚠️  The estimate exceeds the remaining quota; the run would exhaust it before the reset")
+		}
+	}
+}
+
+// GetRateLimit returns the core quota. The rate_limit endpoint doesn't
+// count against it.
+func (c *GitHubClient) GetRateLimit(ctx context.Context) (_ *RateLimit, err error) {
+	ctx, span := c.startSpan(ctx, "github.rate_limit", "rate_limit")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, c.BaseURL+"/rate_limit")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	core := result.Resources.Core
+	return &RateLimit{Limit: core.Limit, Remaining: core.Remaining, Reset: time.Unix(core.Reset, 0).UTC()}, nil
+}
@@ -0,0 +1,95 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateCostProfiles(t *testing.T) {
+	type totals struct{ core, search, graphQL, external int }
+	for _, tc := range []struct {
+		name  string
+		user  GitHubUser
+		deep  bool
+		token totals
+		// anonymous is the estimate without a token: REST repo pages and
+		// details in place of GraphQL, and no commit search
+		anonymous totals
+	}{
+		{"empty account", GitHubUser{PublicRepos: 0}, false, totals{8, 1, 1, 0}, totals{9, 0, 0, 0}},
+		{"30 repos", GitHubUser{PublicRepos: 30}, false, totals{35, 1, 1, 0}, totals{41, 0, 0, 0}},
+		{"30 repos, deep", GitHubUser{PublicRepos: 30, Followers: 250}, true, totals{91, 1, 7, 63}, totals{90, 0, 0, 43}},
+		{"950 repos", GitHubUser{PublicRepos: 950}, false, totals{35, 1, 10, 0}, totals{50, 0, 0, 0}},
+		// Organizations are sampled from a search past DefaultOrgMaxRepos
+		{"5,000-repo org", GitHubUser{PublicRepos: 5000, Type: "Organization"}, false, totals{35, 2, 10, 0}, totals{50, 1, 0, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := defaultOptions()
+			opts.DeepChecks = tc.deep
+			e := EstimateCost(&tc.user, opts)
+			if got := (totals{e.Core, e.Search, e.GraphQL, e.External}); got != tc.token {
+				t.Errorf("with a token the estimate is %+v, want %+v", got, tc.token)
+			}
+			anonymous := e.withoutToken()
+			if got := (totals{anonymous.Core, anonymous.Search, anonymous.GraphQL, anonymous.External}); got != tc.anonymous {
+				t.Errorf("without a token the estimate is %+v, want %+v", got, tc.anonymous)
+			}
+			for _, estimate := range []CostEstimate{e, anonymous} {
+				sum := totals{}
+				for _, request := range estimate.Requests {
+					switch request.Budget {
+					case BudgetCore:
+						sum.core += request.Count
+					case BudgetSearch:
+						sum.search += request.Count
+					case BudgetGraphQL:
+						sum.graphQL += request.Count
+					case BudgetExternal:
+						sum.external += request.Count
+					}
+				}
+				if sum != (totals{estimate.Core, estimate.Search, estimate.GraphQL, estimate.External}) {
+					t.Errorf("the requests add up to %+v, not the totals", sum)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanDryRun(t *testing.T) {
+	repos := make([]GitHubRepo, 30)
+	for i := range repos {
+		repos[i] = GitHubRepo{Name: fmt.Sprintf("repo-%02d", i), Language: "Go", Size: 100, UpdatedAt: fakeNow.Add(-days(i))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(2000), repos...))
+	f.route("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"resources":{"core":{"limit":60,"remaining":12,"reset":%d}}}`, fakeNow.Add(time.Hour).Unix())
+	})
+
+	sent := f.requests.Load()
+	user, e, err := newFakeAnalyzer(f, WithDeepChecks(false)).Plan(context.Background(), "octo")
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if n := f.requests.Load() - sent; n != 2 {
+		t.Errorf("planning sent %d requests, want the user and the rate limit only", n)
+	}
+	if user.PublicRepos != 30 || e.Core != 41 || e.Search != 0 || e.GraphQL != 0 {
+		t.Errorf("planned %d core, %d search, %d GraphQL requests for %d repos; want the 41 core of an anonymous run over 30", e.Core, e.Search, e.GraphQL, user.PublicRepos)
+	}
+	if e.RateLimit == nil || e.RateLimit.Remaining != 12 {
+		t.Fatalf("rate limit = %+v, want 12 remaining", e.RateLimit)
+	}
+
+	var out strings.Builder
+	FprintCostEstimate(&out, user, e)
+	for _, want := range []string{"Planned requests for octo (30 public repos)", "Estimated total: 41 core, 0 search, 0 GraphQL, 0 external", "Remaining quota: 12 of 60 core", "exceeds the remaining quota"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the plan lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package ebert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCratesChecked bounds the Rust repos whose Cargo.toml is read and
+	// whose crate is looked up
+	maxCratesChecked = 5
+
+	// activeCrateDownloads is how many downloads in the last 90 days make
+	// a crate actively used
+	activeCrateDownloads = 1000
+
+	// maxCrateBytes caps a crates.io API response
+	maxCrateBytes = 1 << 20
+)
+
+// cratesInterval spaces requests to crates.io, whose crawler policy allows
+// one a second across every analysis the client runs
+var cratesInterval = time.Second
+
+// cratesRegistryURL is the crates.io API
+var cratesRegistryURL = "https://crates.io/api/v1"
+
+// cargoPackageName matches the name key of a Cargo.toml table
+var cargoPackageName = regexp.MustCompile(`^name\s*=\s*["']([^"']+)["']`)
+
+// cargoPackage returns the crate name a Cargo.toml declares in its
+// [package] table; workspace roots without one declare no crate
+func cargoPackage(manifest []byte) (string, bool) {
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			table = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		if table != "package" {
+			continue
+		}
+		if match := cargoPackageName.FindStringSubmatch(line); match != nil {
+			return match[1], true
+		}
+	}
+	return "", false
+}
+
+// crate is the part of a crates.io crate record ebert reads
+type crate struct {
+	Name            string `json:"name"`
+	Repository      string `json:"repository"`
+	Downloads       int64  `json:"downloads"`
+	RecentDownloads int64  `json:"recent_downloads"`
+}
+
+// crateOwner is a user or team that can publish a crate. A team's login
+// is "github:<org>:<team>".
+type crateOwner struct {
+	Login string `json:"login"`
+	Kind  string `json:"kind"`
+}
+
+// getCrates fetches a crates.io API path, waiting its turn under the
+// registry's rate limit
+func (c *GitHubClient) getCrates(ctx context.Context, path string) ([]byte, error) {
+	if err := c.shared().crates.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.getExternal(ctx, cratesRegistryURL+path, maxCrateBytes)
+}
+
+// getCrate looks name up on crates.io, reporting false if it isn't published
+func (c *GitHubClient) getCrate(ctx context.Context, name string) (*crate, bool, error) {
+	data, err := c.getCrates(ctx, "/crates/"+url.PathEscape(name))
+	if errors.Is(err, errExternalNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response struct {
+		Crate crate `json:"crate"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to decode crate %s: %w", name, err)
+	}
+	return &response.Crate, true, nil
+}
+
+// getCrateOwners lists the users and teams that can publish name
+func (c *GitHubClient) getCrateOwners(ctx context.Context, name string) ([]crateOwner, error) {
+	data, err := c.getCrates(ctx, "/crates/"+url.PathEscape(name)+"/owners")
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Users []crateOwner `json:"users"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode owners of crate %s: %w", name, err)
+	}
+	return response.Users, nil
+}
+
+// sameGitHubRepo reports whether a crate's repository link points at the
+// repo with fullName, in any of the forms githubOwner accepts
+func sameGitHubRepo(link, fullName string) bool {
+	i := strings.Index(strings.ToLower(link), "github.com")
+	if i < 0 {
+		return false
+	}
+	parts := strings.SplitN(strings.TrimLeft(link[i+len("github.com"):], "/:"), "/", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	return strings.EqualFold(parts[0]+"/"+strings.TrimSuffix(parts[1], ".git"), fullName)
+}
+
+// ownsCrate reports whether the account is among a crate's owners, as a
+// user or through one of its organization's teams
+func ownsCrate(login string, owners []crateOwner) bool {
+	for _, owner := range owners {
+		if strings.EqualFold(owner.Login, login) {
+			return true
+		}
+		if org, _, ok := strings.Cut(strings.TrimPrefix(owner.Login, "github:"), ":"); ok && owner.Kind == "team" && strings.EqualFold(org, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCrates finds the crates the user's Rust repos publish: a Cargo.toml
+// with a [package] table whose crate on crates.io links back to the repo.
+// Actively downloaded crates are a positive; a crate the account can't
+// publish is a red flag, since whoever can ships under the repo's name. It
+// runs in deep mode with external checks.
+func (a *Analyzer) checkCrates(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !a.opts.ExternalChecks {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	active := 0
+	var activeEvidence, mismatched []string
+	for _, repo := range r.skipBlocked(r.acc.crateRepos.list()) {
+		if ctx.Err() != nil || !r.contentsBudget.take() {
+			break
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+		manifest, err := a.client.GetFile(ctx, owner, name, "Cargo.toml")
+		if err != nil || manifest == nil {
+			continue
+		}
+		crateName, ok := cargoPackage(manifest)
+		if !ok {
+			continue
+		}
+
+		published, ok, err := a.client.getCrate(ctx, crateName)
+		if err != nil {
+			r.log.fellBack("crates", fmt.Errorf("failed to look up crate %s: %w", crateName, err))
+			continue
+		}
+		// A crate linking elsewhere is another project's under the same name
+		if !ok || !sameGitHubRepo(published.Repository, owner+"/"+name) {
+			continue
+		}
+		metrics.CratesPackages++
+		if published.RecentDownloads >= activeCrateDownloads {
+			active++
+			if len(activeEvidence) < maxPackageEvidence {
+				activeEvidence = append(activeEvidence, fmt.Sprintf("%s (%s recent downloads)", published.Name, formatThousands(published.RecentDownloads)))
+			}
+		}
+
+		owners, err := a.client.getCrateOwners(ctx, crateName)
+		if err != nil {
+			r.log.fellBack("crates", fmt.Errorf("failed to list owners of crate %s: %w", crateName, err))
+			continue
+		}
+		if len(owners) > 0 && !ownsCrate(owner, owners) && !ownsCrate(r.user.Login, owners) {
+			logins := make([]string, 0, len(owners))
+			for _, crateOwner := range owners {
+				logins = append(logins, crateOwner.Login)
+			}
+			mismatched = append(mismatched, fmt.Sprintf("%s links to %s/%s but is owned on crates.io by %s",
+				published.Name, owner, name, strings.Join(logins, ", ")))
+		}
+	}
+
+	if active > 0 {
+		r.addFinding(Finding{
+			Code:     "ACTIVE_CRATES",
+			Severity: SeverityPositive,
+			Message:  fmt.Sprintf("Publishes %d actively downloaded crates on crates.io", active),
+			Evidence: activeEvidence,
+		})
+	}
+	if len(mismatched) > 0 {
+		r.addFinding(Finding{
+			Code:     "CRATE_OWNERSHIP_MISMATCH",
+			Severity: SeverityRedFlag,
+			Message:  "Crates linking to the user's repos are published by other crates.io accounts",
+			Evidence: mismatched,
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCargoPackage(t *testing.T) {
+	for _, tt := range []struct {
+		name, manifest, want string
+		ok                   bool
+	}{
+		{"package", "[package]\nname = \"tool\"\nversion = \"0.1.0\"\n", "tool", true},
+		{"single quotes", "[package]\nversion = '0.1.0'\nname='tool-rs'\n", "tool-rs", true},
+		{"after dependencies", "[dependencies]\nname = \"not-it\"\n\n[ package ]\nname = \"tool\"\n", "tool", true},
+		// A workspace root declares no crate of its own
+		{"workspace", "[workspace]\nmembers = [\"a\", \"b\"]\n", "", false},
+		{"table name", "[package]\nversion = \"0.1.0\"\n[lib]\nname = \"tool\"\n", "", false},
+		{"empty", "", "", false},
+	} {
+		if got, ok := cargoPackage([]byte(tt.manifest)); got != tt.want || ok != tt.ok {
+			t.Errorf("%s: cargoPackage = %q, %t; want %q, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSameGitHubRepo(t *testing.T) {
+	for _, tt := range []struct {
+		link string
+		want bool
+	}{
+		{"https://github.com/octo/tool", true},
+		{"https://github.com/Octo/Tool/", true},
+		{"git+https://github.com/octo/tool.git", true},
+		{"git@github.com:octo/tool.git", true},
+		{"https://github.com/octo/tool/tree/main/crates/tool", true},
+		{"https://github.com/octo/tool-rs", false},
+		{"https://github.com/other/tool", false},
+		{"https://gitlab.com/octo/tool", false},
+		{"https://github.com/octo", false},
+		{"", false},
+	} {
+		if got := sameGitHubRepo(tt.link, "octo/tool"); got != tt.want {
+			t.Errorf("sameGitHubRepo(%q) = %t, want %t", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestOwnsCrate(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		owners []crateOwner
+		want   bool
+	}{
+		{"user", []crateOwner{{Login: "alice", Kind: "user"}, {Login: "Octo", Kind: "user"}}, true},
+		{"team", []crateOwner{{Login: "github:octo:maintainers", Kind: "team"}}, true},
+		{"someone else's team", []crateOwner{{Login: "github:other:octo", Kind: "team"}}, false},
+		// Only teams name an organization
+		{"user named like a team", []crateOwner{{Login: "github:octo:x", Kind: "user"}}, false},
+		{"other users", []crateOwner{{Login: "mallory", Kind: "user"}}, false},
+		{"none", nil, false},
+	} {
+		if got := ownsCrate("octo", tt.owners); got != tt.want {
+			t.Errorf("%s: ownsCrate = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+// cratesFake serves octo's Rust repos and their crates: alpha is actively
+// downloaded and published by an octo team, beta is published by someone
+// else, gamma is a workspace, delta isn't published and serde's crate is
+// another project's. It records when each crates.io request arrived and
+// with which User-Agent.
+func cratesFake(t *testing.T) (*fakeGitHub, func() ([]time.Time, []string)) {
+	rust := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Rust", Size: 900, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(3))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), rust("alpha", 50), rust("beta", 40), rust("gamma", 30), rust("delta", 20), rust("serde", 10)))
+	for repo, manifest := range map[string]string{
+		"alpha": "[package]\nname = \"alpha\"\n",
+		"beta":  "[package]\nname = \"beta-rs\"\n",
+		"gamma": "[workspace]\nmembers = [\"core\"]\n",
+		"delta": "[package]\nname = \"delta\"\n",
+		"serde": "[package]\nname = \"serde\"\n",
+	} {
+		f.route("/repos/octo/"+repo+"/contents/Cargo.toml", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(manifest))
+		})
+	}
+
+	crates := map[string]any{
+		"alpha":   map[string]any{"crate": crate{Name: "alpha", Repository: "https://github.com/octo/alpha", RecentDownloads: 5200}},
+		"beta-rs": map[string]any{"crate": crate{Name: "beta-rs", Repository: "git+https://github.com/Octo/beta.git", RecentDownloads: 10}},
+		"serde":   map[string]any{"crate": crate{Name: "serde", Repository: "https://github.com/serde-rs/serde", RecentDownloads: 9000000}},
+		"alpha/owners": map[string]any{"users": []crateOwner{
+			{Login: "github:octo:maintainers", Kind: "team"}, {Login: "alice", Kind: "user"},
+		}},
+		"beta-rs/owners": map[string]any{"users": []crateOwner{{Login: "mallory", Kind: "user"}, {Login: "trudy", Kind: "user"}}},
+	}
+	var mu sync.Mutex
+	var arrived []time.Time
+	var agents []string
+	f.host("crates.io", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrived, agents = append(arrived, time.Now()), append(agents, r.Header.Get("User-Agent"))
+		mu.Unlock()
+		response, ok := crates[strings.TrimPrefix(r.URL.Path, "/api/v1/crates/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+	return f, func() ([]time.Time, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(arrived), slices.Clone(agents)
+	}
+}
+
+func TestCheckCrates(t *testing.T) {
+	unspaced(t)
+	f, requests := cratesFake(t)
+	analysis, err := newFakeAnalyzerToken(f, "token", WithDeepChecks(true), WithExternalChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// Only the crates linking back to their repo count
+	if analysis.Metrics.CratesPackages != 2 {
+		t.Errorf("CratesPackages = %d, want 2", analysis.Metrics.CratesPackages)
+	}
+
+	active := finding(analysis, "ACTIVE_CRATES")
+	if want := []string{"alpha (5,200 recent downloads)"}; active == nil || active.Severity != SeverityPositive || !slices.Equal(active.Evidence, want) {
+		t.Errorf("ACTIVE_CRATES = %+v, want %q", active, want)
+	}
+	mismatch := finding(analysis, "CRATE_OWNERSHIP_MISMATCH")
+	if want := []string{"beta-rs links to octo/beta but is owned on crates.io by mallory, trudy"}; mismatch == nil || mismatch.Severity != SeverityRedFlag || !slices.Equal(mismatch.Evidence, want) {
+		t.Errorf("CRATE_OWNERSHIP_MISMATCH = %+v, want %q", mismatch, want)
+	}
+
+	// alpha and its owners, beta-rs and its owners, delta and serde
+	_, agents := requests()
+	if len(agents) != 6 {
+		t.Errorf("%d crates.io requests, want 6", len(agents))
+	}
+	for _, agent := range agents {
+		if !strings.HasPrefix(agent, "ebert/") {
+			t.Errorf("User-Agent %q doesn't name ebert", agent)
+		}
+	}
+}
+
+func TestCheckCratesSerialized(t *testing.T) {
+	interval := cratesInterval
+	cratesInterval = 20 * time.Millisecond
+	t.Cleanup(func() { cratesInterval = interval })
+
+	f, requests := cratesFake(t)
+	if _, err := newFakeAnalyzerToken(f, "token", WithDeepChecks(true), WithExternalChecks(true)).Analyze("octo"); err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	arrived, _ := requests()
+	if len(arrived) != 6 {
+		t.Fatalf("%d crates.io requests, want 6", len(arrived))
+	}
+	// Allowing for some jitter in delivering them
+	for i := 1; i < len(arrived); i++ {
+		if gap := arrived[i].Sub(arrived[i-1]); gap < 15*time.Millisecond {
+			t.Errorf("crates.io request %d came %s after the one before", i, gap)
+		}
+	}
+}
+
+func TestCheckCratesGated(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"not deep", []Option{WithExternalChecks(true)}},
+		{"no external checks", []Option{WithDeepChecks(true), WithExternalChecks(false)}},
+	} {
+		f, requests := cratesFake(t)
+		analysis, err := newFakeAnalyzerToken(f, "token", tt.opts...).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		if _, agents := requests(); len(agents) != 0 || analysis.Metrics.CratesPackages != 0 {
+			t.Errorf("%s: %d crates.io requests, %d crates", tt.name, len(agents), analysis.Metrics.CratesPackages)
+		}
+	}
+}
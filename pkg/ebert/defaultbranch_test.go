@@ -0,0 +1,79 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDefaultBranchDevelop(t *testing.T) {
+	account := newAccount("gitflow", days(2000),
+		GitHubRepo{Name: "tool", Language: "JavaScript", Size: 900, StargazersCount: 400, DefaultBranch: "develop", UpdatedAt: fakeNow.Add(-days(2))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 30, DefaultBranch: "trunk", UpdatedAt: fakeNow.Add(-days(9))},
+		GitHubRepo{Name: "old", Language: "Go", Size: 300, StargazersCount: 10, DefaultBranch: "master", UpdatedAt: fakeNow.Add(-days(90))},
+		GitHubRepo{Name: "fork", Language: "Go", Size: 300, Fork: true, DefaultBranch: "dev", UpdatedAt: fakeNow.Add(-days(90))},
+	)
+	f := newFakeGitHub(t, account)
+
+	// The tree is only at develop; asking for main finds nothing
+	serveTree(f, "gitflow", "tool", "develop", monorepoFixture(t, "workspaces"))
+
+	// Every commit on develop came through a pull request, while main
+	// and master don't exist
+	f.route("/repos/gitflow/tool/commits", func(w http.ResponseWriter, r *http.Request) {
+		if branch := r.URL.Query().Get("sha"); branch != "develop" {
+			t.Errorf("commits listed on %q, want develop", branch)
+			http.NotFound(w, r)
+			return
+		}
+		var commits []GitHubCommit
+		for i := range 10 {
+			commit := GitHubCommit{SHA: strings.Repeat("a", i+1)}
+			commit.Commit.Message = "Merge pull request #12 from gitflow/feature\n\nAdd a feature"
+			commits = append(commits, commit)
+		}
+		_ = json.NewEncoder(w).Encode(commits)
+	})
+
+	// Contents reads leave the ref to GitHub, which resolves the default
+	f.route("/repos/gitflow/tool/contents/", func(w http.ResponseWriter, r *http.Request) {
+		if ref := r.URL.Query().Get("ref"); ref != "" {
+			t.Errorf("root contents read at %q, want the default branch", ref)
+		}
+		_ = json.NewEncoder(w).Encode([]ContentEntry{
+			{Name: "CODEOWNERS", Path: "CODEOWNERS", Type: "file", Size: 20},
+			{Name: "package.json", Path: "package.json", Type: "file", Size: 80},
+		})
+	})
+
+	analysis, err := newFakeAnalyzer(f, WithDeepChecks(true)).Analyze("gitflow")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	metrics := analysis.Metrics
+	if metrics.NPMPackages != 3 {
+		t.Errorf("NPMPackages = %d, want the 3 workspaces in the develop tree", metrics.NPMPackages)
+	}
+	if metrics.ReposWithCodeowners == 0 {
+		t.Error("CODEOWNERS on the default branch wasn't found")
+	}
+	var tool *RepoReviewWorkflow
+	for i, workflow := range metrics.ReviewWorkflows {
+		if workflow.Repo == "tool" {
+			tool = &metrics.ReviewWorkflows[i]
+		}
+	}
+	if tool == nil || tool.Estimate != ReviewLikely || tool.PRCommitRatio != 1 {
+		t.Errorf("tool review workflow = %+v, want the develop commits via pull requests", tool)
+	}
+	if finding(analysis, "DIRECT_PUSHES") != nil {
+		t.Error("an empty commit listing was taken for direct pushes")
+	}
+
+	// develop and trunk count; master, and a fork's branch, don't
+	if metrics.NonDefaultBranchRepos != 2 {
+		t.Errorf("NonDefaultBranchRepos = %d, want 2", metrics.NonDefaultBranchRepos)
+	}
+}
@@ -0,0 +1,161 @@
+package ebert
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// maxContributorsChecked bounds the contributors listed per flagship repo
+// in the deep co-maintainer check
+const maxContributorsChecked = 30
+
+//go:embed denylist.yaml
+var builtinDenylist []byte
+
+// DenylistEntry is an account named in an advisory or known incident
+type DenylistEntry struct {
+	Login     string `yaml:"login" json:"login"`
+	Date      string `yaml:"date" json:"date"`
+	Reference string `yaml:"reference" json:"reference"`
+	Summary   string `yaml:"summary" json:"summary"`
+}
+
+// ParseDenylist decodes a YAML denylist with a top-level accounts list
+func ParseDenylist(data []byte) ([]DenylistEntry, error) {
+	var list struct {
+		Accounts []DenylistEntry `yaml:"accounts"`
+	}
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse denylist: %w", err)
+	}
+
+	for i, entry := range list.Accounts {
+		if strings.TrimSpace(entry.Login) == "" {
+			return nil, fmt.Errorf("denylist entry %d has no login", i+1)
+		}
+		if entry.Reference == "" {
+			return nil, fmt.Errorf("denylist entry %s has no reference", entry.Login)
+		}
+	}
+	return list.Accounts, nil
+}
+
+// LoadDenylist reads a YAML denylist from path, held to the size and
+// entry count of DefaultPatternLimits
+func LoadDenylist(path string) ([]DenylistEntry, error) {
+	data, err := readLimited(path, DefaultPatternLimits.MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read denylist: %w", err)
+	}
+	entries, err := ParseDenylist(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > DefaultPatternLimits.MaxEntries {
+		return nil, fmt.Errorf("denylist %s: %w: %d accounts, at most %d allowed", path, ErrPatternLimit, len(entries), DefaultPatternLimits.MaxEntries)
+	}
+	return entries, nil
+}
+
+// mergeDenylists indexes the built-in list and the extra entries by
+// lowercase login, later entries replacing earlier ones
+func mergeDenylists(extra []DenylistEntry) map[string]DenylistEntry {
+	builtin, err := ParseDenylist(builtinDenylist)
+	if err != nil {
+		panic(fmt.Sprintf("ebert: embedded denylist: %v", err))
+	}
+
+	merged := map[string]DenylistEntry{}
+	for _, entry := range append(builtin, extra...) {
+		merged[strings.ToLower(strings.TrimSpace(entry.Login))] = entry
+	}
+	return merged
+}
+
+// describe renders an entry as finding evidence
+func (e DenylistEntry) describe() string {
+	var parts []string
+	for _, part := range []string{e.Summary, e.Date, e.Reference} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return fmt.Sprintf("%s: %s", e.Login, strings.Join(parts, ", "))
+}
+
+// GetContributors lists up to n of a repo's contributors by commit count
+func (c *GitHubClient) GetContributors(ctx context.Context, owner, repo string, n int) (_ []GitHubUser, err error) {
+	ctx, span := c.startSpan(ctx, "github.contributors", "repos/:owner/:repo/contributors")
+	defer func() { endSpan(span, err) }()
+
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(min(n, maxPerPage)))
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/contributors?%s", c.BaseURL, owner, repo, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	contributors, _, err := decodeElements[GitHubUser](data)
+	return contributors, err
+}
+
+// checkDenylist matches the login, and in deep mode the flagship
+// contributors and npm maintainers, against the denylist. A hit is
+// list-based rather than heuristic and forces the highest risk level.
+func (a *Analyzer) checkDenylist(ctx context.Context, r *analysisRun) {
+	if entry, ok := a.denylist[strings.ToLower(r.user.Login)]; ok {
+		r.denylisted = true
+		r.addFinding(Finding{
+			Code:     "DENYLISTED_ACCOUNT",
+			Severity: SeverityRedFlag,
+			Message:  "Account is named in a published advisory or incident (list-based match, not a heuristic)",
+			Evidence: []string{entry.describe()},
+		})
+	}
+
+	if !a.opts.DeepChecks {
+		return
+	}
+
+	others := map[string]struct{}{}
+	for _, name := range r.npmMaintainers {
+		others[strings.ToLower(name)] = struct{}{}
+	}
+	for _, cm := range r.coMaintainers {
+		others[strings.ToLower(cm.Login)] = struct{}{}
+	}
+	for _, repo := range r.flagships() {
+		contributors, err := a.contributors(ctx, r, repo)
+		if err != nil {
+			continue
+		}
+		for _, contributor := range contributors {
+			others[strings.ToLower(contributor.Login)] = struct{}{}
+		}
+	}
+	delete(others, strings.ToLower(r.user.Login))
+
+	var hits []string
+	for login := range others {
+		if entry, ok := a.denylist[login]; ok {
+			hits = append(hits, entry.describe())
+		}
+	}
+	sortStrings(hits)
+
+	if len(hits) > 0 {
+		r.denylisted = true
+		r.addFinding(Finding{
+			Code:     "DENYLISTED_COLLABORATOR",
+			Severity: SeverityRedFlag,
+			Message:  "A co-maintainer is named in a published advisory or incident (list-based match, not a heuristic)",
+			Evidence: hits,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package ebert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDenylist(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"valid", "accounts:\n  - login: octo\n    reference: https://example.com/advisory\n", ""},
+		{"empty", "", ""},
+		{"no login", "accounts:\n  - login: ' '\n    reference: https://example.com/advisory\n", "denylist entry 1 has no login"},
+		{"no reference", "accounts:\n  - login: octo\n", "denylist entry octo has no reference"},
+		{"not YAML", "accounts: [", "failed to parse denylist"},
+	} {
+		_, err := ParseDenylist([]byte(tt.data))
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMergeDenylists(t *testing.T) {
+	if _, err := ParseDenylist(builtinDenylist); err != nil {
+		t.Fatalf("the starter list doesn't parse: %v", err)
+	}
+	merged := mergeDenylists([]DenylistEntry{
+		{Login: " Octo ", Reference: "https://example.com/first"},
+		{Login: "OCTO", Reference: "https://example.com/second"},
+		{Login: "jiat75", Reference: "https://example.com/local"},
+	})
+	if merged["octo"].Reference != "https://example.com/second" {
+		t.Errorf("octo = %+v, want the later entry", merged["octo"])
+	}
+	// Extra entries replace built-in ones, whatever the case
+	if merged["jiat75"].Reference != "https://example.com/local" || merged["right9ctrl"].Login != "right9ctrl" {
+		t.Errorf("merged list = %+v, want the starter list with jiat75 replaced", merged)
+	}
+}
+
+func TestLoadDenylistLimit(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("accounts:\n")
+	for i := range DefaultPatternLimits.MaxEntries + 1 {
+		fmt.Fprintf(&data, "  - {login: user%d, reference: r}\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "denylist.yaml")
+	if err := os.WriteFile(path, []byte(data.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDenylist(path); !errors.Is(err, ErrPatternLimit) {
+		t.Errorf("LoadDenylist = %v, want ErrPatternLimit", err)
+	}
+}
+
+func TestDenylistedAccount(t *testing.T) {
+	account := newAccount("jiat75", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 300, UpdatedAt: fakeNow.Add(-days(2))})
+	plain, err := newFakeAnalyzer(newFakeGitHub(t, newAccount("octo", days(3000), account.Repos[0]))).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("JIAT75")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	flag := finding(analysis, "DENYLISTED_ACCOUNT")
+	want := "JiaT75: xz-utils backdoor (CVE-2024-3094), 2024-03-29, https://nvd.nist.gov/vuln/detail/CVE-2024-3094"
+	if flag == nil || flag.Severity != SeverityRedFlag || !slices.Equal(flag.Evidence, []string{want}) {
+		t.Errorf("DENYLISTED_ACCOUNT = %+v, want %q", flag, want)
+	}
+	// The match, not the score, decides the level
+	if plain.RiskLevel == "high" || analysis.RiskLevel != "high" {
+		t.Errorf("risk level is %s for an identical account and %s denylisted", plain.RiskLevel, analysis.RiskLevel)
+	}
+}
+
+func TestDenylistedCollaborator(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 300, UpdatedAt: fakeNow.Add(-days(2))}))
+	f.route("/repos/octo/tool/contributors", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]GitHubUser{{Login: "octo"}, {Login: "Right9Ctrl"}, {Login: "alice"}})
+	})
+	own := WithDenylist(DenylistEntry{Login: "octo", Reference: "https://example.com/advisory"})
+
+	for _, tt := range []struct {
+		name string
+		deep bool
+		want []string
+	}{
+		{"deep", true, []string{"right9ctrl: event-stream flatmap-stream malicious dependency, 2018-11-26, https://github.com/dominictarr/event-stream/issues/116"}},
+		// Contributors are only listed in deep mode
+		{"not deep", false, nil},
+	} {
+		analysis, err := newFakeAnalyzer(f, WithDeepChecks(tt.deep), own).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		// The account's own entry is reported once, as the account's
+		if self := finding(analysis, "DENYLISTED_ACCOUNT"); self == nil || !slices.Equal(self.Evidence, []string{"octo: https://example.com/advisory"}) {
+			t.Errorf("%s: DENYLISTED_ACCOUNT = %+v", tt.name, self)
+		}
+		flag := finding(analysis, "DENYLISTED_COLLABORATOR")
+		if tt.want == nil {
+			if flag != nil {
+				t.Errorf("%s: unexpected %+v", tt.name, flag)
+			}
+			continue
+		}
+		if flag == nil || flag.Severity != SeverityRedFlag || !slices.Equal(flag.Evidence, tt.want) {
+			t.Errorf("%s: DENYLISTED_COLLABORATOR = %+v, want %q", tt.name, flag, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// staleLockfileAge is how long a flagship lockfile can go untouched before
+// dependencies are presumed unpatched
+const staleLockfileAge = 365 * 24 * time.Hour
+
+var (
+	depAutomationRootFiles   = []string{"renovate.json", "renovate.json5", ".renovaterc", ".renovaterc.json"}
+	depAutomationGitHubFiles = []string{"dependabot.yml", "dependabot.yaml", "renovate.json", "renovate.json5"}
+	lockfileNames            = []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "go.sum", "poetry.lock", "Pipfile.lock", "Cargo.lock", "Gemfile.lock", "composer.lock"}
+)
+
+// checkDependencyAutomation looks for Dependabot or Renovate on the
+// non-fork flagship repos and for lockfiles nobody has refreshed in a year.
+// It needs a token for the contents requests and skips silently without one.
+func (a *Analyzer) checkDependencyAutomation(ctx context.Context, r *analysisRun) {
+	if !a.client.authenticated() {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	var automated, staleLocks []string
+
+	for _, repo := range r.checkable() {
+		if repo.Fork || repo.Archived {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+
+		hasAutomation := mergedBotPRs(r.closedPulls[repo.FullName], r.now) > 0
+
+		root := a.directory(ctx, r, repo, "")
+		if _, ok := hasEntry(root, depAutomationRootFiles...); ok {
+			hasAutomation = true
+		}
+		if _, ok := hasEntry(root, ".github"); ok && !hasAutomation {
+			if _, ok := hasEntry(a.directory(ctx, r, repo, ".github"), depAutomationGitHubFiles...); ok {
+				hasAutomation = true
+			}
+		}
+
+		if hasAutomation {
+			metrics.ReposWithDepAutomation++
+			automated = append(automated, repo.Name)
+			continue
+		}
+
+		if lockfile, ok := hasEntry(root, lockfileNames...); ok && r.contentsBudget.take() {
+			changed, err := a.client.LastCommitTime(ctx, owner, name, lockfile)
+			if err == nil && !changed.IsZero() && r.now.Sub(changed) > staleLockfileAge {
+				staleLocks = append(staleLocks, fmt.Sprintf("%s/%s (last changed %s)", repo.Name, lockfile, changed.Format("2006-01-02")))
+			}
+		}
+	}
+
+	if len(automated) > 0 {
+		r.addFinding(Finding{
+			Code:     "DEPENDENCY_AUTOMATION",
+			Severity: SeverityPositive,
+			Message:  fmt.Sprintf("Automated dependency updates on %d flagship repo(s)", len(automated)),
+			Evidence: automated,
+		})
+	}
+	if len(staleLocks) > 0 {
+		r.addFinding(Finding{
+			Code:     "STALE_LOCKFILES",
+			Severity: SeverityWarning,
+			Message:  "Flagship repo lockfiles untouched for over a year - dependencies may be unpatched",
+			Evidence: staleLocks,
+		})
+	}
+}
+
+// mergedBotPRs counts bot-authored PRs merged within the last 90 days
+func mergedBotPRs(pulls []GitHubPull, now time.Time) int {
+	count := 0
+	for _, pull := range pulls {
+		if pull.IsBot() && pull.MergedAt != nil && now.Sub(*pull.MergedAt) <= stalePRAge {
+			count++
+		}
+	}
+	return count
+}
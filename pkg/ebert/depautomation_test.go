@@ -0,0 +1,96 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"testing"
+	"time"
+)
+
+// serveDirectory answers the contents listing of dir in octo/repo
+func serveDirectory(f *fakeGitHub, repo, dir string, names ...string) {
+	f.route("/repos/octo/"+repo+"/contents/"+dir, func(w http.ResponseWriter, r *http.Request) {
+		entries := make([]ContentEntry, len(names))
+		for i, name := range names {
+			kind := "file"
+			if name == ".github" {
+				kind = "dir"
+			}
+			entries[i] = ContentEntry{Name: name, Path: path.Join(dir, name), Type: kind, Size: 100}
+		}
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
+
+// serveLockfile dates the last commit to path in octo/repo
+func serveLockfile(f *fakeGitHub, repo, path string, changed time.Time) {
+	f.route("/repos/octo/"+repo+"/commits", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("path"); got != path {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `[{"commit":{"committer":{"date":%q}}}]`, changed.Format(time.RFC3339))
+	})
+}
+
+func TestDependencyAutomation(t *testing.T) {
+	repo := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Go", Size: 900, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(2))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000),
+		repo("dependabot", 500), repo("renovated", 400), repo("bots", 300), repo("locked", 200), repo("fresh-lock", 100)))
+
+	serveDirectory(f, "dependabot", "", "go.mod", "go.sum", ".github")
+	serveDirectory(f, "dependabot", ".github", "workflows", "dependabot.yml")
+	serveDirectory(f, "renovated", "", "package.json", "package-lock.json", "renovate.json")
+	// No config, but dependabot's PRs keep being merged
+	serveDirectory(f, "bots", "", "go.mod", "go.sum")
+	merged := fakeNow.Add(-days(10))
+	f.route("/repos/octo/bots/pulls", func(w http.ResponseWriter, r *http.Request) {
+		var pulls []GitHubPull
+		if r.URL.Query().Get("state") == "closed" {
+			pull := GitHubPull{Number: 9, State: "closed", MergedAt: &merged, UpdatedAt: merged}
+			pull.User.Login, pull.User.Type = "dependabot[bot]", "Bot"
+			pulls = append(pulls, pull)
+		}
+		_ = json.NewEncoder(w).Encode(pulls)
+	})
+	serveDirectory(f, "locked", "", "go.mod", "go.sum")
+	serveLockfile(f, "locked", "go.sum", fakeNow.AddDate(-2, 0, 0))
+	serveDirectory(f, "fresh-lock", "", "package.json", "package-lock.json")
+	serveLockfile(f, "fresh-lock", "package-lock.json", fakeNow.Add(-days(30)))
+
+	analysis, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if got := analysis.Metrics.ReposWithDepAutomation; got != 3 {
+		t.Errorf("ReposWithDepAutomation = %d, want 3", got)
+	}
+	automation := finding(analysis, "DEPENDENCY_AUTOMATION")
+	if automation == nil || automation.Severity != SeverityPositive || !slices.Equal(automation.Evidence, []string{"dependabot", "renovated", "bots"}) {
+		t.Errorf("DEPENDENCY_AUTOMATION = %+v, want the three automated repos", automation)
+	}
+	stale := finding(analysis, "STALE_LOCKFILES")
+	if stale == nil || stale.Severity != SeverityWarning || !slices.Equal(stale.Evidence, []string{"locked/go.sum (last changed 2022-06-01)"}) {
+		t.Errorf("STALE_LOCKFILES = %+v, want locked's go.sum alone", stale)
+	}
+}
+
+func TestDependencyAutomationNeedsToken(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, UpdatedAt: fakeNow.Add(-days(2))}))
+	// Other checks may list the root anonymously; the config is there to find
+	f.route("/repos/octo/tool/contents/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"renovate.json","path":"renovate.json","type":"file"}]`))
+	})
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if analysis.Metrics.ReposWithDepAutomation != 0 || finding(analysis, "DEPENDENCY_AUTOMATION") != nil {
+		t.Errorf("found %d automated repos without a token", analysis.Metrics.ReposWithDepAutomation)
+	}
+}
@@ -0,0 +1,96 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// DefaultInternalNamePatterns are name fragments typical of internal
+// packages. corp only counts as a whole word, so scorpion and corpus pass.
+var DefaultInternalNamePatterns = []string{"-internal", "-private", `/(^|[-_.])corp([-_.]|$)/`}
+
+const (
+	// nearEmptyRepoKB is the repo size under which a published package
+	// has next to no source behind it
+	nearEmptyRepoKB = 10
+
+	// maxPackageCandidates bounds how many repos are kept for the check
+	maxPackageCandidates = 50
+
+	// maxRegistryLookups caps registry requests per analysis
+	maxRegistryLookups = 10
+)
+
+// packageCandidate is a repo that may publish a package
+type packageCandidate struct {
+	name      string
+	ecosystem Ecosystem
+	internal  bool
+}
+
+// internalNamePatterns is the set of DefaultInternalNamePatterns and the
+// configured ones, which WithInternalNamePatterns has already validated
+func internalNamePatterns(configured []string) *PatternSet {
+	patterns, err := NewPatternSet(append(slices.Clone(DefaultInternalNamePatterns), configured...), DefaultPatternLimits)
+	if err != nil {
+		patterns, _ = NewPatternSet(DefaultInternalNamePatterns, DefaultPatternLimits)
+	}
+	return patterns
+}
+
+// addPackageCandidate keeps repo if its name looks internal or it is
+// nearly empty, the two shapes of dependency-confusion exposure
+func (m *metricsAccumulator) addPackageCandidate(repo GitHubRepo, class RepoClass) {
+	ecosystem, ok := repoEcosystem(repo)
+	if !ok || class != RepoOriginal || len(m.packages) >= maxPackageCandidates {
+		return
+	}
+
+	internal := m.internalPatterns.MatchString(repo.Name)
+	if internal || repo.Size < nearEmptyRepoKB {
+		m.packages = append(m.packages, packageCandidate{name: repo.Name, ecosystem: ecosystem, internal: internal})
+	}
+}
+
+// checkDependencyConfusion warns about package names that collide with
+// internal naming schemes, and in deep mode about nearly empty repos whose
+// names are claimed on the public registry
+func (a *Analyzer) checkDependencyConfusion(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	lookups := a.opts.DeepChecks && a.opts.ExternalChecks
+	remaining := maxRegistryLookups
+
+	var candidates []string
+	for _, pkg := range r.acc.packages {
+		if pkg.internal {
+			candidates = append(candidates, fmt.Sprintf("%s (%s, internal-looking name)", pkg.name, pkg.ecosystem))
+			continue
+		}
+		if !lookups || remaining == 0 {
+			continue
+		}
+		remaining--
+
+		exists, err := a.client.packageExists(ctx, pkg.ecosystem, pkg.name)
+		if err != nil {
+			r.log.fellBack("registry", fmt.Errorf("failed to look up %s package %s: %w", pkg.ecosystem, pkg.name, err))
+			continue
+		}
+		if exists {
+			candidates = append(candidates, fmt.Sprintf("%s (%s, published from a near-empty repo)", pkg.name, pkg.ecosystem))
+		}
+	}
+
+	if len(candidates) > 0 {
+		r.addFinding(Finding{
+			Code:     "DEP_CONFUSION_CANDIDATE",
+			Severity: SeverityWarning,
+			Message:  "Package names that could collide with internal packages (dependency confusion)",
+			Evidence: candidates,
+		})
+	}
+}
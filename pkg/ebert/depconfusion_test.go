@@ -0,0 +1,26 @@
+package ebert
+
+import "testing"
+
+func TestDefaultInternalNamePatterns(t *testing.T) {
+	patterns := internalNamePatterns(nil)
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{"billing-internal", true},
+		{"auth-private-sdk", true},
+		{"acme-corp-sdk", true},
+		{"corp-utils", true},
+		{"Acme_Corp", true},
+		{"corp", true},
+		{"scorpion", false},
+		{"corpus-tools", false},
+		{"corporate-site", false},
+		{"megacorp", false},
+	} {
+		if got := patterns.MatchString(tt.name); got != tt.want {
+			t.Errorf("%s: internal = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
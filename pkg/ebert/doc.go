@@ -0,0 +1,38 @@
+// Package ebert scores the supply-chain risk of a GitHub account from its
+// profile, repositories, events and gists.
+//
+// Install it with
+//
+//	go get github.com/JamesWoolfenden/ebert
+//
+// and analyze an account:
+//
+//	analyzer, err := ebert.New(os.Getenv("GITHUB_TOKEN"),
+//		ebert.WithDeepChecks(true),
+//	)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	analysis, err := analyzer.AnalyzeContext(ctx, "modelcontextprotocol")
+//	if analysis == nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(analysis.RiskLevel, analysis.OverallScore)
+//
+// A nil error with a non-nil Analysis is a full report. A non-nil error
+// alongside an Analysis means some data sources failed; the report is partial
+// and Analysis.DataSources says which. Point the analyzer at a fake or GitHub
+// Enterprise API with WithBaseURL.
+//
+// The ebert command's exit code says why it failed, as the Exit constants
+// list: ExitOK, ExitError, ExitPolicyViolation, ExitUserNotFound,
+// ExitRateLimited and ExitPartialData, numbered 0 to 5. The codes are
+// stable across releases and stderr names the category beside the
+// message, e.g. "Error (user_not_found): ...". ExitCode maps an error
+// onto them, and AnalysisExitCode an analysis with its error and policy,
+// for programs running their own analyses.
+//
+// An Analyzer is safe for concurrent use. The CLI lives in cmd/ebert and
+// OpenTelemetry tracing is available through the otelebert subpackage.
+package ebert
@@ -0,0 +1,195 @@
+package ebert
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Email consistency verdicts recorded in Metrics.EmailConsistency
+const (
+	EmailConsistent   = "consistent"
+	EmailInconsistent = "inconsistent"
+)
+
+// Where an email identity was seen
+const (
+	emailProfile = "profile"
+	emailCommits = "commits"
+	emailNPM     = "npm"
+)
+
+// maxCommitEmails bounds the distinct commit author emails kept
+const maxCommitEmails = 10
+
+// noreplyDomain is the domain of GitHub's private commit addresses,
+// [<id>+]<login>@users.noreply.github.com
+const noreplyDomain = "@users.noreply.github.com"
+
+// normalizeEmail lowercases an address, returning "" for anything that
+// isn't one
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if local, domain, ok := strings.Cut(email, "@"); !ok || local == "" || !strings.Contains(domain, ".") {
+		return ""
+	}
+	return email
+}
+
+// isNoreply reports whether email is a GitHub private commit address
+func isNoreply(email string) bool {
+	return strings.HasSuffix(email, noreplyDomain)
+}
+
+// noreplyOf reports whether email is login's private commit address
+func noreplyOf(email, login string) bool {
+	local, ok := strings.CutSuffix(email, noreplyDomain)
+	if _, name, numbered := strings.Cut(local, "+"); numbered {
+		local = name
+	}
+	return ok && strings.EqualFold(local, login)
+}
+
+// addCommitEmail counts the author email of a pushed commit. Pushes carry
+// commits others authored too, so only those under the user's login or
+// profile name, or the user's private address, count.
+func (m *metricsAccumulator) addCommitEmail(name, email string) {
+	email = normalizeEmail(email)
+	own := strings.EqualFold(name, m.login) || m.name != "" && strings.EqualFold(name, m.name)
+	switch {
+	case email == "":
+		return
+	case isNoreply(email) && !noreplyOf(email, m.login):
+		return
+	case !isNoreply(email) && !own:
+		return
+	}
+	if _, seen := m.commitEmails[email]; seen || len(m.commitEmails) < maxCommitEmails {
+		m.commitEmails[email]++
+	}
+}
+
+// emailIdentities collects the addresses an account is seen under, in
+// the order first seen, with where each was seen
+type emailIdentities struct {
+	order   []string
+	sources map[string][]string
+}
+
+func (ids *emailIdentities) add(email, source string) {
+	if email = normalizeEmail(email); email == "" {
+		return
+	}
+	if _, seen := ids.sources[email]; !seen {
+		ids.order = append(ids.order, email)
+	}
+	if !slices.Contains(ids.sources[email], source) {
+		ids.sources[email] = append(ids.sources[email], source)
+	}
+}
+
+// seenOnly lists the addresses seen under source alone
+func (ids *emailIdentities) seenOnly(source string) []string {
+	var emails []string
+	for _, email := range ids.order {
+		if sources := ids.sources[email]; len(sources) == 1 && sources[0] == source {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// summary lists each address with where it was seen
+func (ids *emailIdentities) summary() []string {
+	summary := make([]string, 0, len(ids.order))
+	for _, email := range ids.order {
+		summary = append(summary, fmt.Sprintf("%s (%s)", email, strings.Join(ids.sources[email], ", ")))
+	}
+	return summary
+}
+
+// collectEmailIdentities gathers the profile email, the user's commit
+// emails, most used first, and the npm registry emails of the resolved
+// npm account
+func (r *analysisRun) collectEmailIdentities() *emailIdentities {
+	ids := &emailIdentities{sources: map[string][]string{}}
+	ids.add(r.user.Email, emailProfile)
+
+	commits := slices.Collect(maps.Keys(r.acc.commitEmails))
+	slices.SortFunc(commits, func(x, y string) int {
+		return cmp.Or(cmp.Compare(r.acc.commitEmails[y], r.acc.commitEmails[x]), cmp.Compare(x, y))
+	})
+	for _, email := range commits {
+		ids.add(email, emailCommits)
+	}
+
+	if username := r.acc.metrics.NPMUsername; username != "" {
+		for _, pkg := range r.npmPublished {
+			if strings.EqualFold(pkg.published.NPMUser.Name, username) {
+				ids.add(pkg.published.NPMUser.Email, emailNPM)
+			}
+			for _, maintainer := range pkg.published.Maintainers {
+				if strings.EqualFold(maintainer.Name, username) {
+					ids.add(maintainer.Email, emailNPM)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// checkEmailIdentities correlates the addresses an account is seen under
+// and scores whether they agree, not any one of them. Commits leaking a
+// personal address while the profile keeps its email private, and a
+// registry address the profile and commits never use, are inconsistent;
+// one address seen in two places is consistent.
+func (a *Analyzer) checkEmailIdentities(r *analysisRun) {
+	ids := r.collectEmailIdentities()
+	metrics := &r.acc.metrics
+	metrics.EmailIdentities = ids.summary()
+
+	var private, personal []string
+	for _, email := range ids.order {
+		if !slices.Contains(ids.sources[email], emailCommits) {
+			continue
+		}
+		if isNoreply(email) {
+			private = append(private, email)
+		} else {
+			personal = append(personal, email)
+		}
+	}
+	leaked := r.user.Email == "" && len(private) > 0 && len(personal) > 0
+
+	var unmatched []string
+	if len(ids.order) > len(ids.seenOnly(emailNPM)) {
+		unmatched = ids.seenOnly(emailNPM)
+	}
+
+	switch {
+	case leaked || len(unmatched) > 0:
+		metrics.EmailConsistency = EmailInconsistent
+	case slices.ContainsFunc(ids.order, func(email string) bool { return len(ids.sources[email]) > 1 }):
+		metrics.EmailConsistency = EmailConsistent
+	}
+
+	if leaked {
+		r.addFinding(Finding{
+			Code:     "EMAIL_PRIVACY_LEAK",
+			Severity: SeverityWarning,
+			Message:  "Commits expose a personal email though the account keeps its email private",
+			Evidence: personal,
+		})
+	}
+	if len(unmatched) > 0 {
+		r.addFinding(Finding{
+			Code:     "REGISTRY_EMAIL_UNMATCHED",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("npm account %s publishes under an email the profile and commits never use", metrics.NPMUsername),
+			Evidence: unmatched,
+			Args:     []string{metrics.NPMUsername},
+		})
+	}
+}
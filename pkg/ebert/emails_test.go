@@ -0,0 +1,179 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	for in, want := range map[string]string{
+		" Octo@Example.COM ": "octo@example.com",
+		"octo@localhost":     "",
+		"@example.com":       "",
+		"octo":               "",
+		"":                   "",
+	} {
+		if got := normalizeEmail(in); got != want {
+			t.Errorf("normalizeEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNoreplyOf(t *testing.T) {
+	for _, tt := range []struct {
+		email string
+		want  bool
+	}{
+		{"octo@users.noreply.github.com", true},
+		{"12345+octo@users.noreply.github.com", true},
+		{"12345+Octo@users.noreply.github.com", true},
+		{"12345+bob@users.noreply.github.com", false},
+		{"octo@example.com", false},
+	} {
+		if got := noreplyOf(tt.email, "octo"); got != tt.want {
+			t.Errorf("noreplyOf(%q) = %t, want %t", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestAddCommitEmail(t *testing.T) {
+	acc := newMetricsAccumulator(&GitHubUser{Login: "octo", Name: "Octo Cat"}, fakeNow, &AnalyzerOptions{})
+	for _, author := range [][2]string{
+		{"octo", "Octo@Example.com"},
+		{"Octo Cat", "octo@example.com"},
+		// Anyone's commit under the user's private address is the user's
+		{"laptop", "1+octo@users.noreply.github.com"},
+		// Co-authors' commits ride along in the same pushes
+		{"bob", "bob@example.com"},
+		{"octo", "2+bob@users.noreply.github.com"},
+		{"octo", "not an email"},
+	} {
+		acc.addCommitEmail(author[0], author[1])
+	}
+	want := map[string]int{"octo@example.com": 2, "1+octo@users.noreply.github.com": 1}
+	if len(acc.commitEmails) != len(want) || acc.commitEmails["octo@example.com"] != 2 || acc.commitEmails["1+octo@users.noreply.github.com"] != 1 {
+		t.Errorf("commitEmails = %v, want %v", acc.commitEmails, want)
+	}
+
+	// Past the bound only the addresses already seen are counted
+	for i := range maxCommitEmails {
+		acc.addCommitEmail("octo", fmt.Sprintf("octo%d@example.com", i))
+	}
+	acc.addCommitEmail("octo", "octo@example.com")
+	if len(acc.commitEmails) != maxCommitEmails || acc.commitEmails["octo@example.com"] != 3 {
+		t.Errorf("%d commit emails, octo@example.com %d times; want %d and 3", len(acc.commitEmails), acc.commitEmails["octo@example.com"], maxCommitEmails)
+	}
+}
+
+// emailsFake serves octo, whose profile shows profileEmail, pushing
+// commits by the given authors to widget, an npm package published from
+// npmEmail
+func emailsFake(t *testing.T, profileEmail, npmEmail string, authors ...[2]string) *fakeGitHub {
+	unspaced(t)
+	account := newAccount("octo", days(3000), GitHubRepo{Name: "widget", Language: "JavaScript", Size: 400, StargazersCount: 90, UpdatedAt: fakeNow.Add(-days(3))})
+	account.User.Email = profileEmail
+	for i, author := range authors {
+		commits, _ := json.Marshal([]map[string]any{{
+			"sha": fmt.Sprintf("c%d", i), "message": "Fix", "distinct": true,
+			"author": map[string]string{"name": author[0], "email": author[1]},
+		}})
+		event := GitHubEvent{
+			ID: fmt.Sprint(7000 + i), Type: "PushEvent", CreatedAt: fakeNow.Add(-days(i + 1)),
+			Payload: json.RawMessage(fmt.Sprintf(`{"push_id":%d,"size":1,"distinct_size":1,"ref":"refs/heads/main","commits":%s}`, 700+i, commits)),
+		}
+		event.Repo.Name = "octo/widget"
+		account.Events = append(account.Events, event)
+	}
+	f := newFakeGitHub(t, account)
+	f.route("/repos/octo/widget/contents/package.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"widget","version":"1.0.0"}`))
+	})
+	f.host("registry.npmjs.org", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/widget", "/widget/latest":
+			_, _ = fmt.Fprintf(w, `{"name":"widget","version":"1.0.0","_npmUser":{"name":"octo","email":%q},"maintainers":[{"name":"octo","email":%q}]}`, npmEmail, npmEmail)
+		case "/-/v1/search":
+			_, _ = w.Write([]byte(`{"objects":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return f
+}
+
+func TestCheckEmailIdentities(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		profile, npm string
+		authors      [][2]string
+		identities   []string
+		consistency  string
+		code         string
+		evidence     []string
+	}{
+		{
+			name:        "private profile, leaking commits",
+			authors:     [][2]string{{"octo", "12+octo@users.noreply.github.com"}, {"octo", "12+octo@users.noreply.github.com"}, {"octo", "octo@personal.dev"}, {"bob", "bob@example.com"}},
+			identities:  []string{"12+octo@users.noreply.github.com (commits)", "octo@personal.dev (commits)"},
+			consistency: EmailInconsistent, code: "EMAIL_PRIVACY_LEAK", evidence: []string{"octo@personal.dev"},
+		},
+		{
+			name:    "third address on the registry",
+			profile: "octo@example.com", npm: "octo@other.net",
+			authors:     [][2]string{{"octo", "Octo@example.com"}},
+			identities:  []string{"octo@example.com (profile, commits)", "octo@other.net (npm)"},
+			consistency: EmailInconsistent, code: "REGISTRY_EMAIL_UNMATCHED", evidence: []string{"octo@other.net"},
+		},
+		{
+			name:    "one address everywhere",
+			profile: "octo@example.com", npm: "octo@example.com",
+			authors:     [][2]string{{"octo", "octo@example.com"}},
+			identities:  []string{"octo@example.com (profile, commits, npm)"},
+			consistency: EmailConsistent,
+		},
+		// The registry alone is nothing to compare
+		{
+			name: "registry only", npm: "octo@example.com",
+			identities: []string{"octo@example.com (npm)"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := emailsFake(t, tt.profile, tt.npm, tt.authors...)
+			analysis, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true), WithExternalChecks(true)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if !slices.Equal(metrics.EmailIdentities, tt.identities) || metrics.EmailConsistency != tt.consistency {
+				t.Errorf("identities %q, %q; want %q, %q", metrics.EmailIdentities, metrics.EmailConsistency, tt.identities, tt.consistency)
+			}
+			for _, code := range []string{"EMAIL_PRIVACY_LEAK", "REGISTRY_EMAIL_UNMATCHED"} {
+				flag := finding(analysis, code)
+				switch {
+				case code != tt.code && flag != nil:
+					t.Errorf("unexpected %+v", flag)
+				case code == tt.code && (flag == nil || flag.Severity != SeverityWarning || flag.Index != IndexTrust || !slices.Equal(flag.Evidence, tt.evidence)):
+					t.Errorf("%s = %+v, want %q", code, flag, tt.evidence)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailConsistencyScore(t *testing.T) {
+	identity := func(npmEmail string) float64 {
+		f := emailsFake(t, "octo@example.com", npmEmail, [2]string{"octo", "octo@example.com"})
+		analysis, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true), WithExternalChecks(true)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		return *analysis.Scores.Identity
+	}
+	// 5 off for the consistent identity, 10 on for the inconsistent one
+	if consistent, inconsistent := identity("octo@example.com"), identity("octo@other.net"); inconsistent-consistent != 15 {
+		t.Errorf("identity %v when consistent, %v when not; want 15 apart", consistent, inconsistent)
+	}
+}
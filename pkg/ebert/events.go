@@ -0,0 +1,160 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// forcePushWarnThreshold is how many force pushes in the activity window
+// earn a warning
+const forcePushWarnThreshold = 3
+
+// eventsFeedRetention is how far back the public events API reaches at most
+const eventsFeedRetention = 90 * 24 * time.Hour
+
+// PushPayload is the payload of a PushEvent. Commits is truncated to 20
+// entries by the API, so Size and DistinctSize are the real counts; they
+// are nil when the feed omits them.
+type PushPayload struct {
+	PushID       int64  `json:"push_id"`
+	Size         *int   `json:"size"`
+	DistinctSize *int   `json:"distinct_size"`
+	Ref          string `json:"ref"`
+	Before       string `json:"before"`
+	Head         string `json:"head"`
+	Forced       bool   `json:"forced"`
+	Commits      []struct {
+		SHA      string `json:"sha"`
+		Message  string `json:"message"`
+		Distinct bool   `json:"distinct"`
+		Author   struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+	} `json:"commits"`
+}
+
+// PushPayload decodes the payload of a PushEvent
+func (e *GitHubEvent) PushPayload() (PushPayload, error) {
+	var payload PushPayload
+	if e.Type != "PushEvent" {
+		return payload, fmt.Errorf("event type %s is not a PushEvent", e.Type)
+	}
+	if len(e.Payload) == 0 {
+		return payload, nil
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return payload, fmt.Errorf("failed to decode push payload: %w", err)
+	}
+	return payload, nil
+}
+
+// distinctCommits is the number of new commits a push introduced. Without
+// size fields it falls back to counting the listed commits, and a push
+// whose payload says nothing still counts as one commit.
+func (p PushPayload) distinctCommits() int {
+	if p.DistinctSize != nil {
+		return *p.DistinctSize
+	}
+	distinct := 0
+	for _, commit := range p.Commits {
+		if commit.Distinct {
+			distinct++
+		}
+	}
+	if distinct == 0 && p.Size == nil && len(p.Commits) == 0 {
+		return 1
+	}
+	return distinct
+}
+
+// forcePush reports whether a push rewrote history rather than adding to it
+func (p PushPayload) forcePush() bool {
+	if p.Forced {
+		return true
+	}
+	return p.DistinctSize != nil && *p.DistinctSize == 0 && p.Size != nil && *p.Size > 0
+}
+
+// addPush counts one PushEvent within the activity window, ignoring a
+// push_id already counted
+func (m *metricsAccumulator) addPush(event *GitHubEvent) {
+	payload, err := event.PushPayload()
+	if err != nil {
+		m.metrics.RecentCommits++
+		return
+	}
+
+	if payload.PushID != 0 {
+		if _, seen := m.pushes[payload.PushID]; seen {
+			return
+		}
+		m.pushes[payload.PushID] = struct{}{}
+	}
+
+	commits := payload.distinctCommits()
+	m.metrics.RecentCommits += commits
+	if payload.forcePush() {
+		m.metrics.ForcePushes++
+	}
+
+	if event.Repo.Name != "" && payload.Ref != "" {
+		key := event.Repo.Name + " " + payload.Ref
+		m.pushLinks[key] = append(m.pushLinks[key], pushLink{at: event.CreatedAt, before: payload.Before, head: payload.Head})
+	}
+
+	if event.Repo.Name != "" {
+		if _, seen := m.pushRepos[event.Repo.Name]; !seen {
+			m.metrics.ActiveRepos++
+		}
+		m.pushRepos[event.Repo.Name] += commits
+		if !m.owns(event.Repo.Name) {
+			m.metrics.ContributedCommits += commits
+			m.contributed[event.Repo.Name] += commits
+		}
+		for _, commit := range payload.Commits {
+			if commit.Distinct && len(m.pushMessages[event.Repo.Name]) < maxFarmingMessages {
+				m.pushMessages[event.Repo.Name] = append(m.pushMessages[event.Repo.Name], commit.Message)
+			}
+			if commit.Distinct {
+				m.addCommitEmail(commit.Author.Name, commit.Author.Email)
+			}
+		}
+	}
+}
+
+// eventsCoverage records the oldest event received and, when the feed hit
+// its ceiling or retention limit before reaching the start of the activity
+// window, how many days it actually covers
+func (m *metricsAccumulator) eventsCoverage() {
+	if m.oldestEvent.IsZero() {
+		return
+	}
+	oldest := m.oldestEvent
+	m.metrics.OldestEvent = &oldest
+
+	truncated := m.metrics.EventsReceived >= eventsFeedCeiling || m.window > eventsFeedRetention
+	covered := m.now.Sub(oldest)
+	if !truncated || covered >= m.window {
+		return
+	}
+	m.metrics.EventsCoverageDays = max(int(covered.Hours()/24), 1)
+}
+
+// commitDays is how many days an events-based commit count spans
+func commitDays(metrics Metrics) int {
+	if metrics.EventsCoverageDays > 0 && metrics.CommitCountMethod == CommitCountEvents {
+		return metrics.EventsCoverageDays
+	}
+	return metrics.ActivityWindowDays
+}
+
+// activityCoverage is the fraction of the activity window an events-based
+// commit count spans, 1 when the count is complete
+func activityCoverage(metrics Metrics) float64 {
+	if metrics.ActivityWindowDays == 0 {
+		return 1
+	}
+	return min(float64(commitDays(metrics))/float64(metrics.ActivityWindowDays), 1)
+}
@@ -0,0 +1,204 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddPushCounts(t *testing.T) {
+	data, err := os.ReadFile("testdata/events_pushes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []GitHubEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		t.Fatal(err)
+	}
+
+	user := GitHubUser{Login: "alice", CreatedAt: fakeNow.AddDate(-5, 0, 0)}
+	opts := NewAnalyzer("").Options()
+	acc := newMetricsAccumulator(&user, fakeNow, &opts)
+	acc.addEvents(events)
+
+	for _, tt := range []struct {
+		name      string
+		got, want int
+	}{
+		// 45 of the big push, none of the force push, one of the merge,
+		// the forced push's one, two of the sizeless push and the empty one
+		{"RecentCommits", acc.metrics.RecentCommits, 50},
+		{"ForcePushes", acc.metrics.ForcePushes, 2},
+		{"ActiveRepos", acc.metrics.ActiveRepos, 3},
+		{"ContributedCommits", acc.metrics.ContributedCommits, 2},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDistinctCommits(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		payload string
+		want    int
+		forced  bool
+	}{
+		{"sizes", `{"size":30,"distinct_size":25}`, 25, false},
+		{"replayed", `{"size":3,"distinct_size":0}`, 0, true},
+		{"forced flag", `{"size":1,"distinct_size":1,"forced":true}`, 1, true},
+		{"listed only", `{"commits":[{"distinct":true},{"distinct":false},{"distinct":true}]}`, 2, false},
+		{"nothing", `{}`, 1, false},
+		{"empty branch", `{"size":0,"distinct_size":0}`, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload PushPayload
+			if err := json.Unmarshal([]byte(tt.payload), &payload); err != nil {
+				t.Fatal(err)
+			}
+			if got := payload.distinctCommits(); got != tt.want {
+				t.Errorf("distinctCommits = %d, want %d", got, tt.want)
+			}
+			if got := payload.forcePush(); got != tt.forced {
+				t.Errorf("forcePush = %v, want %v", got, tt.forced)
+			}
+		})
+	}
+}
+
+func TestFrequentForcePushes(t *testing.T) {
+	account := newAccount("rewriter", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(1))})
+	for i := range forcePushWarnThreshold {
+		event := GitHubEvent{
+			ID: fmt.Sprint(7000 + i), Type: "PushEvent", CreatedAt: fakeNow.Add(-days(i + 1)),
+			Payload: json.RawMessage(fmt.Sprintf(`{"push_id":%d,"size":2,"distinct_size":0,"ref":"refs/heads/main"}`, 800+i)),
+		}
+		event.Repo.Name = "rewriter/tool"
+		account.Events = append(account.Events, event)
+	}
+
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("rewriter")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	flag := finding(analysis, "FREQUENT_FORCE_PUSHES")
+	if flag == nil || flag.Severity != SeverityWarning {
+		t.Fatalf("want a FREQUENT_FORCE_PUSHES warning, got %+v", flag)
+	}
+	if analysis.Metrics.RecentCommits != 0 {
+		t.Errorf("RecentCommits = %d, want force pushes to add none", analysis.Metrics.RecentCommits)
+	}
+}
+
+func TestOverlappingEventPages(t *testing.T) {
+	// 160 pushes of one commit each within the activity window, then
+	// older ones. None carries a push_id, so only the event IDs tell the
+	// repeats apart.
+	var feed []GitHubEvent
+	for i := range 300 {
+		at := fakeNow.Add(-time.Duration(i)*12*time.Hour - time.Hour)
+		if i >= 160 {
+			at = fakeNow.Add(-days(100) - time.Duration(i)*time.Hour)
+		}
+		event := GitHubEvent{ID: fmt.Sprint(5000 + i), Type: "PushEvent", CreatedAt: at, Payload: json.RawMessage(`{"size":1,"distinct_size":1,"ref":"refs/heads/main"}`)}
+		event.Repo.Name = "shifty/tool"
+		feed = append(feed, event)
+	}
+
+	f := newFakeGitHub(t, newAccount("shifty", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(1))}))
+	// Five events arrive after the first page is read, pushing its last
+	// five onto the second
+	var pages atomic.Int32
+	f.route("/users/shifty/events/public", func(w http.ResponseWriter, r *http.Request) {
+		pages.Add(1)
+		start := 0
+		if page, _ := strconv.Atoi(r.URL.Query().Get("page")); page > 1 {
+			start = (page-1)*100 - 5
+		}
+		_ = json.NewEncoder(w).Encode(feed[min(start, len(feed)):min(start+100, len(feed))])
+	})
+
+	analysis, err := newFakeAnalyzer(f, WithRequestStats(true)).Analyze("shifty")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if analysis.Metrics.RecentCommits != 160 {
+		t.Errorf("RecentCommits = %d, want the 160 pushes in the window counted once each", analysis.Metrics.RecentCommits)
+	}
+	if pages.Load() != 2 {
+		t.Errorf("fetched %d event pages, want to stop at the second, which reaches past the window", pages.Load())
+	}
+	if analysis.RequestStats == nil || analysis.RequestStats.DuplicateEvents != 5 {
+		t.Errorf("request stats = %+v, want the 5 repeated events counted", analysis.RequestStats)
+	}
+}
+
+func TestEventsTruncated(t *testing.T) {
+	// pushes spread evenly back over the last 11 days
+	feed := func(n int) []GitHubEvent {
+		events := make([]GitHubEvent, n)
+		for i := range events {
+			events[i] = GitHubEvent{
+				ID: fmt.Sprint(9000 + i), Type: "PushEvent", CreatedAt: fakeNow.Add(-days(11) * time.Duration(i+1) / time.Duration(n)),
+				Payload: json.RawMessage(fmt.Sprintf(`{"push_id":%d,"size":1,"distinct_size":1,"ref":"refs/heads/main"}`, 900+i)),
+			}
+			events[i].Repo.Name = "busy/tool"
+		}
+		return events
+	}
+	for _, tt := range []struct {
+		name     string
+		events   int
+		opts     []Option
+		coverage int
+		// oldest is the age of the oldest event fetched
+		oldest time.Duration
+	}{
+		// The feed's 300-event ceiling reached 11 days into the 90
+		{"ceiling", eventsFeedCeiling, nil, 11, days(11)},
+		{"short feed", 40, nil, 0, days(11)},
+		// A week-long window is covered by the second page, where the
+		// fetch stops
+		{"window covered", eventsFeedCeiling, []Option{WithActivityWindow(days(7))}, 0, days(11) * 200 / 300},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newAccount("busy", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(1))})
+			account.Events = feed(tt.events)
+
+			analysis, err := newFakeAnalyzer(newFakeGitHub(t, account), tt.opts...).Analyze("busy")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if metrics.OldestEvent == nil || !metrics.OldestEvent.Equal(fakeNow.Add(-tt.oldest)) {
+				t.Errorf("OldestEvent = %v, want %s back", metrics.OldestEvent, tt.oldest)
+			}
+			if metrics.EventsCoverageDays != tt.coverage {
+				t.Errorf("EventsCoverageDays = %d, want %d", metrics.EventsCoverageDays, tt.coverage)
+			}
+
+			flag := finding(analysis, "EVENTS_TRUNCATED")
+			if tt.coverage == 0 {
+				if flag != nil {
+					t.Errorf("got %+v for a feed covering the window", flag)
+				}
+				if got := activityCoverage(metrics); got != 1 {
+					t.Errorf("activityCoverage = %v, want the activity score at full weight", got)
+				}
+				return
+			}
+			if flag == nil || flag.Severity != SeverityInfo || flag.Message != "Events cover 11 days of the requested 90 - event-based activity figures are partial" {
+				t.Fatalf("EVENTS_TRUNCATED = %+v, want an informational finding with the coverage", flag)
+			}
+			if got, want := activityCoverage(metrics), 11.0/90; got != want {
+				t.Errorf("activityCoverage = %v, want the activity weight scaled by %v", got, want)
+			}
+		})
+	}
+}
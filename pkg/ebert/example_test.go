@@ -0,0 +1,610 @@
+package ebert_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// exampleAPI answers the three endpoints an unauthenticated analysis reads
+func exampleAPI() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/octo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"octo","name":"Octo Cat","company":"Acme","blog":"https://octo.example",
+			"public_repos":2,"followers":420,"following":10,"type":"User",
+			"created_at":"2016-03-01T00:00:00Z","updated_at":"2024-05-30T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"name":"tool","full_name":"octo/tool","language":"Go","size":2400,"stargazers_count":310,"forks_count":25,
+			 "has_issues":true,"default_branch":"main","owner":{"login":"octo","type":"User"},
+			 "created_at":"2018-01-01T00:00:00Z","updated_at":"2024-05-28T00:00:00Z","pushed_at":"2024-05-28T00:00:00Z"},
+			{"name":"notes","full_name":"octo/notes","language":"Shell","size":80,"stargazers_count":3,
+			 "default_branch":"main","owner":{"login":"octo","type":"User"},
+			 "created_at":"2019-06-01T00:00:00Z","updated_at":"2023-02-01T00:00:00Z","pushed_at":"2023-02-01T00:00:00Z"}
+		]`)
+	})
+	mux.HandleFunc("/users/octo/events/public", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id":"1","type":"PushEvent","repo":{"name":"octo/tool"},"created_at":"2024-05-28T09:00:00Z",
+			 "payload":{"push_id":10,"size":3,"distinct_size":3,"ref":"refs/heads/main"}}
+		]`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func ExampleAnalyzer_Analyze() {
+	api := exampleAPI()
+	defer api.Close()
+
+	analyzer, err := ebert.New("",
+		ebert.WithBaseURL(api.URL),
+		ebert.WithClock(func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	analysis, err := analyzer.Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.User.Login, analysis.RiskLevel)
+	fmt.Printf("repos %d, recent commits %d\n", analysis.Metrics.Repos, analysis.Metrics.RecentCommits)
+	// Output:
+	// octo medium
+	// repos 2, recent commits 3
+}
+
+// exampleAnalyzer analyzes against api on 1 June 2024, with opts
+func exampleAnalyzer(api *httptest.Server, opts ...ebert.Option) *ebert.Analyzer {
+	defaults := []ebert.Option{
+		ebert.WithBaseURL(api.URL),
+		ebert.WithClock(func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }),
+	}
+	analyzer, err := ebert.New("", append(defaults, opts...)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return analyzer
+}
+
+// exampleFile writes content to a temporary file and returns its path
+func exampleFile(content string) string {
+	f, err := os.CreateTemp("", "ebert-example-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString(content); err != nil {
+		log.Fatal(err)
+	}
+	return f.Name()
+}
+
+// countingTransport counts the requests it sends
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func ExampleWithHTTPClient() {
+	api := exampleAPI()
+	defer api.Close()
+
+	transport := &countingTransport{}
+	analyzer := exampleAnalyzer(api, ebert.WithHTTPClient(&http.Client{Timeout: time.Minute, Transport: transport}))
+	if _, err := analyzer.Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(transport.requests > 0)
+	// Output: true
+}
+
+func ExampleWithClock() {
+	api := exampleAPI()
+	defer api.Close()
+
+	analyzer := exampleAnalyzer(api, ebert.WithClock(func() time.Time { return time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC) }))
+	analysis, err := analyzer.Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.Timestamp.Format(time.DateOnly), analysis.Metrics.AccountAgeDays)
+	// Output: 2025-03-01 3287
+}
+
+func ExampleWithBaseURL() {
+	analyzer, err := ebert.New("", ebert.WithBaseURL("https://github.example.com/api/v3"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().BaseURL)
+
+	_, err = ebert.New("", ebert.WithBaseURL("github.example.com"))
+	fmt.Println(err)
+	// Output:
+	// https://github.example.com/api/v3
+	// invalid base url "github.example.com": scheme and host are required
+}
+
+func ExampleWithWeights() {
+	api := exampleAPI()
+	defer api.Close()
+
+	identity := ebert.Weights{Identity: 1}
+	analysis, err := exampleAnalyzer(api, ebert.WithWeights(identity)).Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.OverallScore == *analysis.Scores.Identity)
+
+	_, err = ebert.New("", ebert.WithWeights(ebert.Weights{Identity: -1}))
+	fmt.Println(err)
+	// Output:
+	// true
+	// invalid weights: weights must not be negative
+}
+
+func ExampleWithActivityWindow() {
+	api := exampleAPI()
+	defer api.Close()
+
+	// The one push, four days before the analysis, is outside a day's window
+	analysis, err := exampleAnalyzer(api, ebert.WithActivityWindow(24*time.Hour)).Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.Metrics.ActivityWindowDays, analysis.Metrics.RecentCommits)
+	// Output: 1 0
+}
+
+func ExampleWithLogger() {
+	api := exampleAPI()
+	defer api.Close()
+
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := exampleAnalyzer(api, ebert.WithLogger(logger)).Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(strings.Contains(logs.String(), "classified repo"))
+	// Output: true
+}
+
+func ExampleWithDeepChecks() {
+	analyzer, err := ebert.New("", ebert.WithDeepChecks(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().DeepChecks)
+	// Output: true
+}
+
+func ExampleWithRequestStats() {
+	api := exampleAPI()
+	defer api.Close()
+
+	analysis, err := exampleAnalyzer(api, ebert.WithRequestStats(true)).Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.RequestStats.Endpoints["users/:user"].Requests)
+	// Output: 1
+}
+
+func ExampleWithRequestHooks() {
+	api := exampleAPI()
+	defer api.Close()
+
+	var paths []string
+	onRequest := func(req *http.Request) { paths = append(paths, req.URL.Path) }
+	if _, err := exampleAnalyzer(api, ebert.WithRequestHooks(onRequest, nil)).Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(paths[0])
+	// Output: /users/octo
+}
+
+// printTracer prints the name of every span started
+type printTracer struct{}
+
+func (printTracer) Start(ctx context.Context, name string) (context.Context, ebert.Span) {
+	if name == "ebert.Analyze" {
+		fmt.Println("span", name)
+	}
+	return ctx, printSpan{}
+}
+
+type printSpan struct{}
+
+func (printSpan) SetAttributes(...ebert.Attribute) {}
+func (printSpan) RecordError(error)                {}
+func (printSpan) End()                             {}
+
+func ExampleWithTracer() {
+	api := exampleAPI()
+	defer api.Close()
+
+	if _, err := exampleAnalyzer(api, ebert.WithTracer(printTracer{})).Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	// Output: span ebert.Analyze
+}
+
+// keylessVerifier accepts every signature as issued to one identity
+type keylessVerifier struct{}
+
+func (keylessVerifier) Verify(context.Context, ebert.SignedArtifact) (*ebert.SignerIdentity, error) {
+	return &ebert.SignerIdentity{Issuer: "https://token.actions.githubusercontent.com", Subject: "octo"}, nil
+}
+
+func ExampleWithProvenanceVerifier() {
+	analyzer, err := ebert.New("", ebert.WithDeepChecks(true), ebert.WithProvenanceVerifier(keylessVerifier{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().ProvenanceVerifier != nil)
+	// Output: true
+}
+
+func ExampleWithResultCache() {
+	api := exampleAPI()
+	defer api.Close()
+
+	transport := &countingTransport{}
+	analyzer := exampleAnalyzer(api, ebert.WithHTTPClient(&http.Client{Transport: transport}), ebert.WithResultCache(ebert.NewMemoryCache(), time.Hour))
+	if _, err := analyzer.Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	sent := transport.requests
+	if _, err := analyzer.Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("requests for the cached rerun:", transport.requests-sent)
+	// Output: requests for the cached rerun: 0
+}
+
+func ExampleWithGists() {
+	analyzer, err := ebert.New("", ebert.WithGists(false))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().Gists)
+	// Output: false
+}
+
+func ExampleWithTopRepos() {
+	analyzer, err := ebert.New("", ebert.WithTopRepos(10))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().TopRepos)
+	// Output: 10
+}
+
+func ExampleWithExternalChecks() {
+	analyzer, err := ebert.New("", ebert.WithExternalChecks(false))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().ExternalChecks)
+	// Output: false
+}
+
+func ExampleWithNewAccountThreshold() {
+	analyzer, err := ebert.New("", ebert.WithNewAccountThreshold(30*24*time.Hour))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().NewAccountThreshold)
+	// Output: 720h0m0s
+}
+
+func ExampleWithGeneratedContentThresholds() {
+	thresholds := ebert.DefaultGeneratedContentThresholds()
+	thresholds.MinCommits = 500
+	analyzer, err := ebert.New("", ebert.WithGeneratedContentThresholds(thresholds))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().GeneratedContent.MinCommits)
+	// Output: 500
+}
+
+func ExampleWithScoreAllRepos() {
+	analyzer, err := ebert.New("", ebert.WithScoreAllRepos(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().ScoreAllRepos)
+	// Output: true
+}
+
+func ExampleWithScoringVersion() {
+	analyzer, err := ebert.New("", ebert.WithScoringVersion(ebert.ScoringV2))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().ScoringVersion)
+	// Output: 2
+}
+
+func ExampleWithFreshnessHalfLife() {
+	analyzer, err := ebert.New("", ebert.WithScoringVersion(ebert.ScoringV2), ebert.WithFreshnessHalfLife(90*24*time.Hour))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().FreshnessHalfLife)
+	// Output: 2160h0m0s
+}
+
+func ExampleWithStarAgeWeighting() {
+	analyzer, err := ebert.New("", ebert.WithStarAgeWeighting(2*365*24*time.Hour, ebert.StarFalloffExponential))
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := analyzer.Options()
+	fmt.Println(opts.StarHorizon, opts.StarFalloff)
+	// Output: 17520h0m0s exponential
+}
+
+func ExampleWithInternalNamePatterns() {
+	analyzer, err := ebert.New("", ebert.WithInternalNamePatterns("acme-", "/^acme_[a-z]+$/"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().InternalNamePatterns)
+	// Output: [acme- /^acme_[a-z]+$/]
+}
+
+func ExampleWithInternalNamePatternsFile() {
+	path := exampleFile("# internal prefixes\nacme-\nacme_\n")
+	defer func() { _ = os.Remove(path) }()
+
+	analyzer, err := ebert.New("", ebert.WithInternalNamePatternsFile(path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().InternalNamePatterns)
+	// Output: [acme- acme_]
+}
+
+func ExampleWithTutorialKeywords() {
+	analyzer, err := ebert.New("", ebert.WithTutorialKeywords("bootcamp", "/^homework-/"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().TutorialKeywords)
+	// Output: [bootcamp /^homework-/]
+}
+
+func ExampleWithTutorialKeywordsFile() {
+	path := exampleFile("bootcamp\ncourse\n")
+	defer func() { _ = os.Remove(path) }()
+
+	analyzer, err := ebert.New("", ebert.WithTutorialKeywordsFile(path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().TutorialKeywords)
+	// Output: [bootcamp course]
+}
+
+func ExampleWithInstallScripts() {
+	analyzer, err := ebert.New("", ebert.WithDeepChecks(true), ebert.WithInstallScripts(false))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().InstallScripts)
+	// Output: false
+}
+
+func ExampleWithVerifyArtifacts() {
+	analyzer, err := ebert.New("", ebert.WithDeepChecks(true), ebert.WithVerifyArtifacts(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().VerifyArtifacts)
+	// Output: true
+}
+
+func ExampleWithArtifactIgnore() {
+	analyzer, err := ebert.New("", ebert.WithVerifyArtifacts(true), ebert.WithArtifactIgnore("generated/", "*.wasm"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().ArtifactIgnore)
+	// Output: [generated/ *.wasm]
+}
+
+func ExampleWithCoMaintainerDepth() {
+	analyzer, err := ebert.New("", ebert.WithCoMaintainerDepth(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().CoMaintainerDepth)
+
+	_, err = ebert.New("", ebert.WithCoMaintainerDepth(ebert.MaxCoMaintainerDepth+1))
+	fmt.Println(err)
+	// Output:
+	// 1
+	// co-maintainer depth must be between 0 and 2, got 3
+}
+
+func ExampleWithEngagementRings() {
+	analyzer, err := ebert.New("", ebert.WithDeepChecks(true), ebert.WithEngagementRings(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().EngagementRings)
+	// Output: true
+}
+
+func ExampleWithStrictAuth() {
+	analyzer, err := ebert.New(os.Getenv("GITHUB_TOKEN"), ebert.WithStrictAuth(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().StrictAuth)
+	// Output: true
+}
+
+func ExampleWithSeed() {
+	api := exampleAPI()
+	defer api.Close()
+
+	analysis, err := exampleAnalyzer(api, ebert.WithSeed(42)).Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.Meta.Seed)
+	// Output: 42
+}
+
+func ExampleWithTokenSource() {
+	api := exampleAPI()
+	defer api.Close()
+
+	var authorization string
+	onRequest := func(req *http.Request) { authorization = req.Header.Get("Authorization") }
+	analyzer := exampleAnalyzer(api, ebert.WithTokenSource(ebert.StaticToken("ghp_example")), ebert.WithRequestHooks(onRequest, nil))
+	if _, err := analyzer.Analyze("octo"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(authorization)
+	// Output: token ghp_example
+}
+
+func ExampleWithAnalysisTimeout() {
+	analyzer, err := ebert.New("", ebert.WithAnalysisTimeout(5*time.Minute))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().AnalysisTimeout)
+	// Output: 5m0s
+}
+
+func ExampleWithRequestTimeout() {
+	analyzer, err := ebert.New("", ebert.WithRequestTimeout(15*time.Second))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().RequestTimeout)
+	// Output: 15s
+}
+
+func ExampleWithExternalTimeout() {
+	analyzer, err := ebert.New("", ebert.WithExternalTimeout(3*time.Second))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().ExternalTimeout)
+	// Output: 3s
+}
+
+func ExampleWithIntegrationTimeout() {
+	analyzer, err := ebert.New("",
+		ebert.WithIntegrationTimeout("registry.npmjs.org", 20*time.Second),
+		ebert.WithIntegrationTimeout("PyPI.org", 5*time.Second),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().IntegrationTimeouts)
+	// Output: map[pypi.org:5s registry.npmjs.org:20s]
+}
+
+func ExampleWithCircuitBreaker() {
+	analyzer, err := ebert.New("", ebert.WithCircuitBreaker(3))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().BreakerThreshold)
+	// Output: 3
+}
+
+func ExampleWithAllowedHosts() {
+	analyzer, err := ebert.New("", ebert.WithAllowedHosts("API.github.com", "registry.npmjs.org"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().AllowedHosts)
+	// Output: [api.github.com registry.npmjs.org]
+}
+
+func ExampleWithOffline() {
+	analyzer, err := ebert.New("", ebert.WithOffline())
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = analyzer.Analyze("octo")
+	fmt.Println(errors.Is(err, ebert.ErrHostNotAllowed))
+	// Output: true
+}
+
+func ExampleWithRequestRate() {
+	analyzer, err := ebert.New("", ebert.WithRequestRate(0.5, 2))
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := analyzer.Options()
+	fmt.Println(opts.MinRequestsPerSecond, opts.MaxRequestsPerSecond)
+	// Output: 0.5 2
+}
+
+func ExampleWithMaxRepos() {
+	analyzer, err := ebert.New("", ebert.WithMaxRepos(200))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().MaxRepos)
+	// Output: 200
+}
+
+func ExampleWithRepoListOptions() {
+	analyzer, err := ebert.New("", ebert.WithRepoListOptions(ebert.RepoListOptions{Type: "all", Sort: "pushed"}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().RepoList)
+	// Output: per_page=100&sort=pushed&type=all
+}
+
+func ExampleWithDenylist() {
+	api := exampleAPI()
+	defer api.Close()
+
+	entry := ebert.DenylistEntry{Login: "octo", Date: "2024-05-01", Reference: "https://example.com/advisory", Summary: "published a malicious release"}
+	analysis, err := exampleAnalyzer(api, ebert.WithDenylist(entry)).Analyze("octo")
+	if analysis == nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analysis.RiskLevel)
+	// Output: high
+}
+
+func ExampleWithDenylistFile() {
+	path := exampleFile("accounts:\n  - login: octo\n    date: 2024-05-01\n    reference: https://example.com/advisory\n")
+	defer func() { _ = os.Remove(path) }()
+
+	analyzer, err := ebert.New("", ebert.WithDenylistFile(path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(analyzer.Options().Denylist[0].Login)
+	// Output: octo
+}
@@ -0,0 +1,93 @@
+package ebert
+
+import (
+	"cmp"
+	"errors"
+)
+
+// Exit codes of the ebert command. They are a stable contract for scripts
+// and CI wrappers: a code keeps its meaning across releases and new
+// categories take new numbers.
+const (
+	// ExitOK is a full analysis that passed any policy
+	ExitOK = 0
+
+	// ExitError is any other failure: bad arguments, network or API
+	// errors, an export that failed
+	ExitError = 1
+
+	// ExitPolicyViolation is an analysis that failed its FindingPolicy,
+	// such as a --fail-on-trust threshold
+	ExitPolicyViolation = 2
+
+	// ExitUserNotFound is an account GitHub doesn't know
+	ExitUserNotFound = 3
+
+	// ExitRateLimited is a run stopped by the GitHub rate limit
+	ExitRateLimited = 4
+
+	// ExitPartialData is an analysis missing data sources, without
+	// --allow-partial
+	ExitPartialData = 5
+)
+
+// exitCategories name the exit codes, by code
+var exitCategories = []string{
+	ExitOK:              "ok",
+	ExitError:           "error",
+	ExitPolicyViolation: "policy_violation",
+	ExitUserNotFound:    "user_not_found",
+	ExitRateLimited:     "rate_limited",
+	ExitPartialData:     "partial_data",
+}
+
+// ExitCategory names an exit code, e.g. "rate_limited", or "error" for a
+// code outside the contract
+func ExitCategory(code int) string {
+	if code < 0 || code >= len(exitCategories) {
+		return exitCategories[ExitError]
+	}
+	return exitCategories[code]
+}
+
+// ExitCode is the exit code for err: ExitOK for nil, the code of the
+// first category err matches, ExitError otherwise. Partial data isn't an
+// error kind, so ExitPartialData is left to AnalysisExitCode, which holds
+// the Analysis.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrPolicyViolation):
+		return ExitPolicyViolation
+	case errors.Is(err, ErrAccountNotFound):
+		return ExitUserNotFound
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrWaitExceedsDeadline):
+		return ExitRateLimited
+	}
+	return ExitError
+}
+
+// PartialExitCode is the exit code of an analysis that came back
+// incomplete with err: ExitRateLimited when the rate limit cut it short,
+// ExitPartialData otherwise
+func PartialExitCode(err error) int {
+	if code := ExitCode(err); code == ExitRateLimited {
+		return code
+	}
+	return ExitPartialData
+}
+
+// AnalysisExitCode is the exit code the ebert command gives an analysis
+// that came back with err: err's code when there is no analysis, its
+// PartialExitCode when err is set and allowPartial isn't, and otherwise
+// ExitPolicyViolation when it fails policy
+func AnalysisExitCode(analysis *Analysis, err error, policy FindingPolicy, allowPartial bool) int {
+	switch {
+	case analysis == nil:
+		return cmp.Or(ExitCode(err), ExitError)
+	case err != nil && !allowPartial:
+		return PartialExitCode(err)
+	}
+	return ExitCode(policy.Check(analysis))
+}
@@ -0,0 +1,80 @@
+package ebert
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAnalysisExitCodes(t *testing.T) {
+	unspaced(t)
+	zero := 0.0
+	for _, tt := range []struct {
+		name         string
+		login        string
+		route        string
+		handler      http.HandlerFunc
+		policy       FindingPolicy
+		allowPartial bool
+		want         int
+	}{
+		{name: "ok", login: "octo", want: ExitOK},
+		{name: "policy violation", login: "octo", policy: FindingPolicy{FailOnTrust: &zero}, want: ExitPolicyViolation},
+		{name: "user not found", login: "ghost", want: ExitUserNotFound},
+		{
+			name: "rate limited", login: "octo", route: "/users/octo",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "60")
+				http.Error(w, `{"message":"You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+			},
+			want: ExitRateLimited,
+		},
+		{
+			name: "rate limited partway", login: "octo", route: "/users/octo/gists",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "60")
+				http.Error(w, `{"message":"You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+			},
+			want: ExitRateLimited,
+		},
+		{
+			name: "partial data", login: "octo", route: "/users/octo/events/public",
+			handler: func(w http.ResponseWriter, r *http.Request) { http.Error(w, "{}", http.StatusUnprocessableEntity) },
+			want:    ExitPartialData,
+		},
+		{
+			name: "partial data allowed", login: "octo", route: "/users/octo/events/public",
+			handler:      func(w http.ResponseWriter, r *http.Request) { http.Error(w, "{}", http.StatusUnprocessableEntity) },
+			allowPartial: true, want: ExitOK,
+		},
+		{
+			// A profile that can't be read leaves nothing to analyze
+			name: "error", login: "octo", route: "/users/octo",
+			handler: func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(`{"login":`)) },
+			want:    ExitError,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGitHub(t, newAccount("octo", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}))
+			if tt.route != "" {
+				f.route(tt.route, tt.handler)
+			}
+			analysis, err := newFakeAnalyzer(f, WithAnalysisTimeout(20*time.Second)).Analyze(tt.login)
+			if got := AnalysisExitCode(analysis, err, tt.policy, tt.allowPartial); got != tt.want {
+				t.Errorf("exit code = %d (%s), want %d (%s); err: %v", got, ExitCategory(got), tt.want, ExitCategory(tt.want), err)
+			}
+		})
+	}
+}
+
+func TestExitCategories(t *testing.T) {
+	// The numbers and names are a published contract
+	for code, name := range []string{"ok", "error", "policy_violation", "user_not_found", "rate_limited", "partial_data"} {
+		if got := ExitCategory(code); got != name {
+			t.Errorf("ExitCategory(%d) = %q, want %q", code, got, name)
+		}
+	}
+	if got := ExitCategory(99); got != "error" {
+		t.Errorf("ExitCategory(99) = %q, want error", got)
+	}
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir stores reports as files under a root directory
+type Dir struct {
+	root string
+}
+
+// NewDir returns a sink writing under root, created as needed
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// Put writes data to root/name, replacing any existing file atomically
+func (d *Dir) Put(_ context.Context, name string, data []byte) error {
+	dst := filepath.Join(d.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".ebert-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
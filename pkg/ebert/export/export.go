@@ -0,0 +1,81 @@
+// Package export archives analyses to a directory, S3 or GCS, keeping the
+// cloud SDKs out of builds that don't export.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Sink stores one named report
+type Sink interface {
+	Put(ctx context.Context, name string, data []byte) error
+}
+
+// Name is where an analysis of username run at t is stored:
+// <username>/<timestamp>.json
+func Name(username string, t time.Time) string {
+	return path.Join(strings.ToLower(username), t.UTC().Format("20060102T150405Z")+".json")
+}
+
+// Open returns the sink a URL names: s3://bucket/prefix, gs://bucket/prefix,
+// or a local directory given as a path or file:// URL. Cloud credentials
+// come from each SDK's default chain. Close the sink when done if it
+// implements io.Closer.
+func Open(ctx context.Context, rawURL string) (Sink, error) {
+	if !strings.Contains(rawURL, "://") {
+		return NewDir(rawURL), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export URL %q: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewDir(u.Path), nil
+	case "s3", "gs", "gcs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("export URL %q names no bucket", rawURL)
+		}
+		prefix := strings.Trim(u.Path, "/")
+		if u.Scheme == "s3" {
+			return NewS3(ctx, u.Host, prefix)
+		}
+		return NewGCS(ctx, u.Host, prefix)
+	default:
+		return nil, fmt.Errorf("unsupported export scheme %q", u.Scheme)
+	}
+}
+
+// Writer is a sink that streams each report to a writer opened per name,
+// for destinations with an io.Writer API
+type Writer struct {
+	open func(ctx context.Context, name string) (io.WriteCloser, error)
+}
+
+// NewWriter returns a sink that writes each report to open(ctx, name)
+func NewWriter(open func(ctx context.Context, name string) (io.WriteCloser, error)) *Writer {
+	return &Writer{open: open}
+}
+
+// Put writes data to a writer for name and closes it
+func (w *Writer) Put(ctx context.Context, name string, data []byte) error {
+	dst, err := w.open(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	if _, err := dst.Write(data); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestName(t *testing.T) {
+	at := time.Date(2024, 6, 1, 14, 30, 5, 0, time.FixedZone("CEST", 2*60*60))
+	if got, want := Name("Octo", at), "octo/20240601T123005Z.json"; got != want {
+		t.Errorf("Name = %q, want %q", got, want)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		url  string
+		root string
+		err  string
+	}{
+		{url: dir, root: dir},
+		{url: "file://" + dir, root: dir},
+		{url: "s3:///prefix", err: "names no bucket"},
+		{url: "gs://", err: "names no bucket"},
+		{url: "ftp://host/reports", err: `unsupported export scheme "ftp"`},
+		{url: "s3://bucket\x7f/prefix", err: "invalid export URL"},
+	} {
+		sink, err := Open(context.Background(), tt.url)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Open(%q) = %v, want %q", tt.url, err, tt.err)
+			}
+			continue
+		}
+		if d, ok := sink.(*Dir); err != nil || !ok || d.root != tt.root {
+			t.Errorf("Open(%q) = %#v, %v; want a directory at %s", tt.url, sink, err, tt.root)
+		}
+	}
+}
+
+func TestDirPut(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "reports")
+	sink := NewDir(root)
+	for _, data := range []string{`{"first":true}`, `{"second":true}`} {
+		if err := sink.Put(context.Background(), "octo/20240601T120000Z.json", []byte(data)); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	// The second report replaces the first and no temporary file is left
+	got, err := os.ReadFile(filepath.Join(root, "octo", "20240601T120000Z.json"))
+	if err != nil || string(got) != `{"second":true}` {
+		t.Errorf("report = %q, %v; want the second", got, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "octo")); len(entries) != 1 {
+		t.Errorf("export directory holds %d files, want 1", len(entries))
+	}
+
+	// A file where the directory should be
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewDir(blocker).Put(context.Background(), "octo/report.json", nil); err == nil || !strings.Contains(err.Error(), "failed to create export directory") {
+		t.Errorf("Put under a file = %v", err)
+	}
+}
+
+// writeCloser records what is written and fails as told
+type writeCloser struct {
+	strings.Builder
+	writeErr, closeErr error
+	closed             bool
+}
+
+func (w *writeCloser) Write(p []byte) (int, error) {
+	if w.writeErr != nil {
+		return 0, w.writeErr
+	}
+	return w.Builder.Write(p)
+}
+
+func (w *writeCloser) Close() error {
+	w.closed = true
+	return w.closeErr
+}
+
+func TestWriterPut(t *testing.T) {
+	failure := errors.New("disk full")
+	for _, tt := range []struct {
+		name    string
+		dst     *writeCloser
+		openErr error
+		err     string
+	}{
+		{name: "ok", dst: &writeCloser{}},
+		{name: "open", openErr: failure, err: "failed to open octo.json: disk full"},
+		{name: "write", dst: &writeCloser{writeErr: failure}, err: "failed to write octo.json: disk full"},
+		// Object stores commit the upload on Close
+		{name: "close", dst: &writeCloser{closeErr: failure}, err: "failed to write octo.json: disk full"},
+	} {
+		var opened string
+		sink := NewWriter(func(_ context.Context, name string) (io.WriteCloser, error) {
+			opened = name
+			if tt.openErr != nil {
+				return nil, tt.openErr
+			}
+			return tt.dst, nil
+		})
+		err := sink.Put(context.Background(), "octo.json", []byte("{}"))
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err || !errors.Is(err, failure)) {
+			t.Errorf("%s: Put = %v, want %q", tt.name, err, tt.err)
+		}
+		if opened != "octo.json" || tt.dst != nil && !tt.dst.closed {
+			t.Errorf("%s: opened %q, closed %t", tt.name, opened, tt.dst != nil && tt.dst.closed)
+		}
+		if tt.name == "ok" && tt.dst.String() != "{}" {
+			t.Errorf("wrote %q, want {}", tt.dst.String())
+		}
+	}
+}
+
+func TestS3Put(t *testing.T) {
+	var key, contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		key, contentType, body = r.URL.Path, r.Header.Get("Content-Type"), string(data)
+	}))
+	t.Cleanup(server.Close)
+
+	// Keep the SDK's default chain to static credentials and the fake
+	home := t.TempDir()
+	for name, value := range map[string]string{
+		"AWS_ACCESS_KEY_ID":           "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY":       "secret",
+		"AWS_REGION":                  "us-east-1",
+		"AWS_ENDPOINT_URL_S3":         server.URL,
+		"AWS_CONFIG_FILE":             filepath.Join(home, "config"),
+		"AWS_SHARED_CREDENTIALS_FILE": filepath.Join(home, "credentials"),
+		"AWS_PROFILE":                 "",
+	} {
+		t.Setenv(name, value)
+	}
+
+	sink, err := Open(context.Background(), "s3://reports/ci/nightly/")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if s, ok := sink.(*S3); !ok || s.bucket != "reports" || s.prefix != "ci/nightly" {
+		t.Fatalf("Open = %#v, want the reports bucket under ci/nightly", sink)
+	}
+	if err := sink.Put(context.Background(), "octo/20240601T120000Z.json", []byte(`{"login":"octo"}`)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if key != "/reports/ci/nightly/octo/20240601T120000Z.json" || contentType != "application/json" || body != `{"login":"octo"}` {
+		t.Errorf("uploaded %s as %q: %q", key, contentType, body)
+	}
+}
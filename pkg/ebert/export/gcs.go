@@ -0,0 +1,39 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"cloud.google.com/go/storage"
+)
+
+// GCS stores reports as objects in a Google Cloud Storage bucket
+type GCS struct {
+	*Writer
+	client *storage.Client
+}
+
+// NewGCS returns a sink writing under prefix in bucket, with credentials
+// from Application Default Credentials
+func NewGCS(ctx context.Context, bucket, prefix string) (*GCS, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	handle := client.Bucket(bucket)
+	return &GCS{
+		client: client,
+		Writer: NewWriter(func(ctx context.Context, name string) (io.WriteCloser, error) {
+			w := handle.Object(path.Join(prefix, name)).NewWriter(ctx)
+			w.ContentType = "application/json"
+			return w, nil
+		}),
+	}, nil
+}
+
+// Close releases the GCS client
+func (g *GCS) Close() error {
+	return g.client.Close()
+}
@@ -0,0 +1,115 @@
+package ebert
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultExternalTimeout bounds requests to hosts other than the GitHub API
+const DefaultExternalTimeout = 5 * time.Second
+
+// errExternalNotFound is returned by getExternal for a 404
+var errExternalNotFound = errors.New("HTTP 404")
+
+// getExternal fetches a URL outside the GitHub API. The GitHub token is
+// never sent, the body is capped at maxBytes and the request is bounded by
+// a short timeout.
+func (c *GitHubClient) getExternal(ctx context.Context, url string, maxBytes int64) ([]byte, error) {
+	var data []byte
+	err := c.sendExternal(ctx, "GET", url, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s: %w", url, errExternalNotFound)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: HTTP %d", url, resp.StatusCode)
+		}
+
+		var err error
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > maxBytes {
+			return fmt.Errorf("%w: %s exceeds %d bytes", ErrResponseTooLarge, url, maxBytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// headExternal reports the status of a HEAD request to a URL outside the
+// GitHub API, without credentials and within the short external timeout
+func (c *GitHubClient) headExternal(ctx context.Context, url string) (int, error) {
+	status := 0
+	err := c.sendExternal(ctx, "HEAD", url, func(resp *http.Response) error {
+		status = resp.StatusCode
+		return nil
+	})
+	return status, err
+}
+
+// sendExternal sends one request outside the GitHub API and hands the
+// response to handle. Requests to a host whose circuit breaker is open fail
+// with ErrCircuitOpen without being sent, and so do those to a host outside
+// the allowlist, with ErrHostNotAllowed.
+func (c *GitHubClient) sendExternal(ctx context.Context, method, url string, handle func(*http.Response) error) error {
+	host := externalHost(url)
+	breaker := c.shared().breaker
+	if !breaker.allow(host) {
+		if skipped := skippedHostsFrom(ctx); skipped != nil {
+			skipped.record(host, ErrCircuitOpen)
+		}
+		return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.externalTimeout(host))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	// Registries such as crates.io refuse anonymous user agents
+	req.Header.Set("User-Agent", externalUserAgent())
+
+	client := c.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Neither the analysis ending nor the allowlist is the host's fault
+		if ctx.Err() == nil && !errors.Is(err, ErrHostNotAllowed) {
+			breaker.record(host, true)
+		}
+		return connectionHint(err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	breaker.record(host, externalFailure(resp.StatusCode))
+	return handle(resp)
+}
+
+// externalUserAgent names ebert and where to reach its maintainers, as
+// registry crawler policies ask
+func externalUserAgent() string {
+	return "ebert/" + BuildVersion() + " (+https://" + modulePath + ")"
+}
+
+// externalTimeout is the timeout of requests to host
+func (c *GitHubClient) externalTimeout(host string) time.Duration {
+	if timeout, ok := c.IntegrationTimeouts[host]; ok {
+		return timeout
+	}
+	return cmp.Or(c.ExternalTimeout, DefaultExternalTimeout)
+}
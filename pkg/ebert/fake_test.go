@@ -0,0 +1,387 @@
+package ebert
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeNow is the clock every analysis against the fake API runs at
+var fakeNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeAccount is one account the fake API serves
+type fakeAccount struct {
+	User   GitHubUser
+	Repos  []GitHubRepo
+	Events []GitHubEvent
+}
+
+// fakeGitHub serves the user, repo listing and events endpoints of the
+// REST API from in-memory accounts, paginated and with ETags the way
+// GitHub answers them. Anything else is a 404 unless routed. Requests sent
+// through client to other hosts, such as package registries, are answered
+// by the handler for their host.
+type fakeGitHub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	accounts map[string]*fakeAccount
+	routes   map[string]http.HandlerFunc
+	external map[string]http.HandlerFunc
+
+	// rejectTokens answers every request carrying a token with a 401
+	rejectTokens atomic.Bool
+
+	requests atomic.Int64
+}
+
+func newFakeGitHub(t testing.TB, accounts ...*fakeAccount) *fakeGitHub {
+	t.Helper()
+	f := &fakeGitHub{accounts: map[string]*fakeAccount{}, routes: map[string]http.HandlerFunc{}, external: map[string]http.HandlerFunc{}}
+	for _, account := range accounts {
+		f.accounts[strings.ToLower(account.User.Login)] = account
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// route answers path with handler in place of the built-in endpoints
+func (f *fakeGitHub) route(path string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[path] = handler
+}
+
+// host answers requests to another host with handler
+func (f *fakeGitHub) host(host string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.external[host] = handler
+}
+
+// client sends requests for any host to the fake, keeping their Host
+func (f *fakeGitHub) client() *http.Client {
+	transport := f.Server.Client().Transport
+	return &http.Client{Transport: fakeRoundTripper(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host != f.Listener.Addr().String() {
+			req = req.Clone(req.Context())
+			req.Host = req.URL.Host
+			req.URL.Scheme, req.URL.Host = "http", f.Listener.Addr().String()
+		}
+		return transport.RoundTrip(req)
+	})}
+}
+
+type fakeRoundTripper func(*http.Request) (*http.Response, error)
+
+func (f fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// account returns the account served for login, for tests to change
+func (f *fakeGitHub) account(login string) *fakeAccount {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.accounts[strings.ToLower(login)]
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	f.mu.Lock()
+	handler, routed := f.routes[r.URL.Path]
+	if r.Host != f.Listener.Addr().String() {
+		handler, routed = f.external[r.Host], true
+		if handler == nil {
+			handler = http.NotFound
+		}
+	}
+	f.mu.Unlock()
+	if f.rejectTokens.Load() && r.Header.Get("Authorization") != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if routed {
+		handler(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "users" {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	account, ok := f.accounts[strings.ToLower(parts[1])]
+	var body any
+	switch {
+	case !ok:
+	case len(parts) == 2:
+		body = account.User
+	case len(parts) == 3 && parts[2] == "repos":
+		repos := slices.Clone(account.Repos)
+		if r.URL.Query().Get("sort") == "pushed" {
+			slices.SortStableFunc(repos, func(x, y GitHubRepo) int { return y.PushedAt.Compare(x.PushedAt) })
+		}
+		body = fakePage(repos, r)
+	case len(parts) == 4 && parts[2] == "events" && parts[3] == "public":
+		body = fakePage(account.Events, r)
+	default:
+		ok = false
+	}
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// serveGraphQLRepos answers the repositories queries from the accounts,
+// ordered as asked and paged by cursor; route it at /graphql
+func (f *fakeGitHub) serveGraphQLRepos(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Variables struct {
+			Login   string            `json:"login"`
+			First   int               `json:"first"`
+			After   *string           `json:"after"`
+			OrderBy map[string]string `json:"orderBy"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	vars := request.Variables
+	account := f.account(vars.Login)
+	if account == nil {
+		_, _ = w.Write([]byte(`{"data":{"repositoryOwner":null}}`))
+		return
+	}
+
+	f.mu.Lock()
+	repos := slices.Clone(account.Repos)
+	f.mu.Unlock()
+	slices.SortStableFunc(repos, func(x, y GitHubRepo) int {
+		var order int
+		switch vars.OrderBy["field"] {
+		case "PUSHED_AT":
+			order = x.PushedAt.Compare(y.PushedAt)
+		case "UPDATED_AT":
+			order = x.UpdatedAt.Compare(y.UpdatedAt)
+		case "CREATED_AT":
+			order = x.CreatedAt.Compare(y.CreatedAt)
+		default:
+			order = strings.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name))
+		}
+		if vars.OrderBy["direction"] == "DESC" {
+			order = -order
+		}
+		return order
+	})
+
+	start := 0
+	if vars.After != nil {
+		start, _ = strconv.Atoi(*vars.After)
+	}
+	end := min(start+cmp.Or(vars.First, maxPerPage), len(repos))
+	start = min(start, end)
+
+	nodes := make([]map[string]any, 0, end-start)
+	for _, repo := range repos[start:end] {
+		topics := []map[string]any{}
+		for _, topic := range repo.Topics {
+			topics = append(topics, map[string]any{"topic": map[string]string{"name": topic}})
+		}
+		nodes = append(nodes, map[string]any{
+			"name": repo.Name, "nameWithOwner": repo.FullName, "description": repo.Description, "url": repo.HTMLURL,
+			"primaryLanguage": map[string]string{"name": repo.Language},
+			"stargazerCount":  repo.StargazersCount, "forkCount": repo.ForksCount, "diskUsage": repo.Size,
+			"isArchived": repo.Archived, "isFork": repo.Fork, "isDisabled": repo.Disabled, "isTemplate": repo.IsTemplate,
+			"createdAt": repo.CreatedAt, "updatedAt": repo.UpdatedAt, "pushedAt": repo.PushedAt,
+			"hasIssuesEnabled": repo.HasIssues, "hasWikiEnabled": repo.HasWiki, "hasDiscussionsEnabled": repo.HasDiscussions,
+			"defaultBranchRef": map[string]string{"name": repo.DefaultBranch},
+			"repositoryTopics": map[string]any{"nodes": topics},
+			"watchers":         map[string]int{"totalCount": repo.SubscribersCount},
+		})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repositoryOwner": map[string]any{"repositories": map[string]any{
+		"pageInfo": map[string]any{"hasNextPage": end < len(repos), "endCursor": strconv.Itoa(end)},
+		"nodes":    nodes,
+	}}}})
+}
+
+// fakePage is the page of items the request's page and per_page ask for
+func fakePage[T any](items []T, r *http.Request) []T {
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 30
+	}
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	start := min((page-1)*perPage, len(items))
+	return append([]T{}, items[start:min(start+perPage, len(items))]...)
+}
+
+// unspaced drops the search and crates.io spacing for the test, so
+// concurrent analyses don't queue for seconds behind each other
+func unspaced(t testing.TB) {
+	search, crates := searchInterval, cratesInterval
+	searchInterval, cratesInterval = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { searchInterval, cratesInterval = search, crates })
+}
+
+// newFakeAnalyzer analyzes against f at fakeNow, unpaced
+func newFakeAnalyzer(f *fakeGitHub, opts ...Option) *Analyzer {
+	return newFakeAnalyzerToken(f, "", opts...)
+}
+
+// newFakeAnalyzerToken is newFakeAnalyzer with a token
+func newFakeAnalyzerToken(f *fakeGitHub, token string, opts ...Option) *Analyzer {
+	defaults := []Option{WithBaseURL(f.URL), WithHTTPClient(f.client()), WithClock(func() time.Time { return fakeNow }), WithRequestRate(1000, 10000)}
+	return NewAnalyzer(token, append(defaults, opts...)...)
+}
+
+// syntheticAccount builds an account of n repos, most of them idle, with
+// a feed of pushes to the first few, a push-farming repo and a repo
+// created and deleted again
+func syntheticAccount(login string, n int) *fakeAccount {
+	account := &fakeAccount{User: GitHubUser{
+		Login:       login,
+		Name:        "Synthetic " + login,
+		Company:     "Acme",
+		Blog:        "https://example.com",
+		PublicRepos: n,
+		Followers:   180,
+		Following:   12,
+		CreatedAt:   fakeNow.AddDate(-6, 0, 0),
+		UpdatedAt:   fakeNow.AddDate(0, 0, -3),
+		Type:        "User",
+	}}
+
+	languages := []string{"Go", "Python", "JavaScript", "Rust", "Shell", ""}
+	for i := range n {
+		updated := fakeNow.AddDate(0, 0, -(i*7)%900)
+		size := 100 + i
+		if i%12 == 2 {
+			// A near-empty JavaScript repo, whose name deep mode looks up
+			size = 4
+		}
+		account.Repos = append(account.Repos, GitHubRepo{
+			Name:            fmt.Sprintf("repo-%04d", i),
+			FullName:        fmt.Sprintf("%s/repo-%04d", login, i),
+			Description:     "A repo",
+			Language:        languages[i%len(languages)],
+			StargazersCount: (n - i) * 3 % 500,
+			ForksCount:      (n - i) % 40,
+			Size:            size,
+			Archived:        i%17 == 5,
+			Fork:            i%9 == 4,
+			HasIssues:       i%3 != 0,
+			HasPages:        i == 2,
+			CreatedAt:       fakeNow.AddDate(-5, 0, -i),
+			UpdatedAt:       updated,
+			PushedAt:        updated,
+			DefaultBranch:   "main",
+			HTMLURL:         fmt.Sprintf("https://github.com/%s/repo-%04d", login, i),
+			Owner:           &RepoOwner{Login: login, Type: "User"},
+		})
+	}
+	account.Repos = append(account.Repos, GitHubRepo{
+		Name: "auto-commit", FullName: login + "/auto-commit", Size: 1,
+		CreatedAt: fakeNow.AddDate(0, -2, 0), UpdatedAt: fakeNow, PushedAt: fakeNow,
+		DefaultBranch: "main", Owner: &RepoOwner{Login: login, Type: "User"},
+	})
+	account.User.PublicRepos = len(account.Repos)
+
+	id := 9000
+	event := func(kind, repo string, at time.Time, payload string) {
+		id++
+		event := GitHubEvent{ID: strconv.Itoa(id), Type: kind, CreatedAt: at, Payload: json.RawMessage(payload)}
+		event.Repo.Name = repo
+		event.Actor.Login = login
+		account.Events = append(account.Events, event)
+	}
+	for day := range 40 {
+		at := fakeNow.Add(-time.Duration(day)*26*time.Hour - time.Hour)
+		repo := fmt.Sprintf("%s/repo-%04d", login, day%4)
+		event("PushEvent", repo, at, fmt.Sprintf(`{"push_id":%d,"size":2,"distinct_size":2,"ref":"refs/heads/main","commits":[{"sha":"a%d","message":"Fix %d","distinct":true,"author":{"name":"Synthetic","email":"%s@example.com"}}]}`, 100+day, day, day, login))
+		event("PushEvent", login+"/auto-commit", at.Add(-time.Minute), fmt.Sprintf(`{"push_id":%d,"size":25,"distinct_size":25,"ref":"refs/heads/main","commits":[{"sha":"b%d","message":"update","distinct":true}]}`, 500+day, day))
+	}
+	event("CreateEvent", login+"/short-lived", fakeNow.AddDate(0, 0, -20), `{"ref_type":"repository"}`)
+	event("DeleteEvent", login+"/short-lived", fakeNow.AddDate(0, 0, -19), `{"ref_type":"repository"}`)
+	event("WatchEvent", "someone/else", fakeNow.AddDate(0, 0, -2), `{"action":"started"}`)
+	slices.SortStableFunc(account.Events, func(x, y GitHubEvent) int { return y.CreatedAt.Compare(x.CreatedAt) })
+	return account
+}
+
+// newAccount is an account created createdAgo before fakeNow owning repos,
+// whose full names and owners are filled in
+func newAccount(login string, createdAgo time.Duration, repos ...GitHubRepo) *fakeAccount {
+	for i := range repos {
+		repo := &repos[i]
+		repo.FullName = login + "/" + repo.Name
+		repo.Owner = &RepoOwner{Login: login, Type: "User"}
+		repo.HTMLURL = "https://github.com/" + repo.FullName
+		repo.DefaultBranch = cmp.Or(repo.DefaultBranch, "main")
+		if repo.CreatedAt.IsZero() {
+			repo.CreatedAt = fakeNow.Add(-createdAgo / 2)
+		}
+		if repo.PushedAt.IsZero() {
+			repo.PushedAt = repo.UpdatedAt
+		}
+	}
+	return &fakeAccount{
+		User: GitHubUser{
+			Login:       login,
+			Name:        login,
+			PublicRepos: len(repos),
+			Followers:   30,
+			CreatedAt:   fakeNow.Add(-createdAgo),
+			Type:        "User",
+		},
+		Repos: repos,
+	}
+}
+
+// finding returns the analysis's finding with code, or nil
+func finding(analysis *Analysis, code string) *Finding {
+	for i := range analysis.Findings {
+		if analysis.Findings[i].Code == code {
+			return &analysis.Findings[i]
+		}
+	}
+	return nil
+}
+
+// days is n days as a duration
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
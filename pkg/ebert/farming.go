@@ -0,0 +1,115 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// farmingShare is the share of pushed commits one repo must take for
+	// the activity to look crowded into it
+	farmingShare = 0.9
+
+	// minFarmingCommits is how many commits the crowded repo needs before
+	// the pattern is worth flagging
+	minFarmingCommits = 50
+
+	// maxFarmingMessages is how many commit messages are sampled per repo
+	maxFarmingMessages = 30
+
+	// minFarmingMessages and sameMessageShare set when a sample counts as
+	// identical: enough messages, nearly all the same
+	minFarmingMessages = 5
+	sameMessageShare   = 0.8
+)
+
+// farmingNamePattern matches names typical of contribution-graph bots
+var farmingNamePattern = regexp.MustCompile(`(?i)auto[-_.]?commit|green[-_.]?(squares|graph|wall)|contribution|streak|commit[-_.]?(bot|daily|everyday)|daily[-_.]?commit|fake[-_.]?commit`)
+
+// checkActivityFarming looks for recent commits crowded into one
+// low-value repo - zero stars and a bot-like name or identical commit
+// messages - the signature of an auto-commit "green squares" bot. When it
+// finds one, that repo's share of the commits is left out of the Activity
+// score. Deep mode samples the repo's commit messages when the events
+// feed didn't carry them.
+func (a *Analyzer) checkActivityFarming(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().events {
+		return
+	}
+
+	total, top, topCommits := 0, "", 0
+	for repo, commits := range r.acc.pushRepos {
+		total += commits
+		if commits > topCommits || (commits == topCommits && repo < top) {
+			top, topCommits = repo, commits
+		}
+	}
+	if total == 0 {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	share := float64(topCommits) / float64(total)
+	metrics.TopRepoCommitShare = share
+	if share <= farmingShare || topCommits < minFarmingCommits {
+		return
+	}
+
+	stars, listed := r.acc.repoStars[strings.ToLower(top)]
+	if !listed || stars > 0 {
+		return
+	}
+
+	var evidence []string
+	if farmingNamePattern.MatchString(top) {
+		evidence = append(evidence, "name looks like a commit bot")
+	}
+
+	messages := r.acc.pushMessages[top]
+	if len(messages) < minFarmingMessages && a.opts.DeepChecks && r.contentsBudget.take() {
+		if owner, name, ok := strings.Cut(top, "/"); ok {
+			if commits, err := a.client.GetCommits(ctx, owner, name, "", maxFarmingMessages); err == nil {
+				messages = nil
+				for _, commit := range commits {
+					messages = append(messages, commit.Commit.Message)
+				}
+			}
+		}
+	}
+	if message, same := sameMessages(messages); same {
+		evidence = append(evidence, fmt.Sprintf("recent commits share the message %q", message))
+	}
+	if len(evidence) == 0 {
+		return
+	}
+
+	// A search count covers more than the feed, so the feed's share of it
+	// is set aside rather than the feed's raw figure
+	metrics.FarmedCommits = int(float64(metrics.RecentCommits) * share)
+	r.addFinding(Finding{
+		Code:     "ACTIVITY_FARMING",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%.0f%% of recent commits went to %s, a zero-star repo - activity looks farmed and is discounted", share*100, top),
+		Evidence: append([]string{fmt.Sprintf("%s: %d of %d pushed commits", top, topCommits, total)}, evidence...),
+	})
+}
+
+// sameMessages reports the most common commit subject when nearly all of
+// a big enough sample share it
+func sameMessages(messages []string) (string, bool) {
+	if len(messages) < minFarmingMessages {
+		return "", false
+	}
+	counts := map[string]int{}
+	best := ""
+	for _, message := range messages {
+		subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		counts[subject]++
+		if counts[subject] > counts[best] {
+			best = subject
+		}
+	}
+	return best, float64(counts[best]) >= float64(len(messages))*sameMessageShare
+}
@@ -0,0 +1,167 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSameMessages(t *testing.T) {
+	repeat := func(message string, n int) []string { return slices.Repeat([]string{message}, n) }
+	for _, tt := range []struct {
+		name     string
+		messages []string
+		want     string
+		same     bool
+	}{
+		{"identical", repeat("update", 10), "update", true},
+		// Only the subject line counts
+		{"bodies differ", []string{"update\n\n1", "update\n\n2", " update", "update\n\n4", "update"}, "update", true},
+		{"mostly identical", append(repeat("update", 8), "fix", "docs"), "update", true},
+		{"varied", append(repeat("update", 7), "fix", "docs", "tests"), "update", false},
+		{"too few", repeat("update", minFarmingMessages-1), "", false},
+	} {
+		got, same := sameMessages(tt.messages)
+		if same != tt.same || tt.same && got != tt.want {
+			t.Errorf("%s: sameMessages = %q, %t; want %q, %t", tt.name, got, same, tt.want, tt.same)
+		}
+	}
+}
+
+// farmedPushes is n single-commit pushes to login/repo, starting at id,
+// titled by message(i), or carrying no messages when message is nil
+func farmedPushes(login, repo string, id, n int, message func(i int) string) []GitHubEvent {
+	events := make([]GitHubEvent, n)
+	for i := range events {
+		commits := "[]"
+		if message != nil {
+			commits = fmt.Sprintf(`[{"sha":"%040d","message":%q,"distinct":true}]`, id+i, message(i))
+		}
+		events[i] = GitHubEvent{
+			ID: fmt.Sprint(id + i), Type: "PushEvent", CreatedAt: fakeNow.Add(-time.Duration(id+i) * time.Minute),
+			Payload: json.RawMessage(fmt.Sprintf(`{"push_id":%d,"size":1,"distinct_size":1,"ref":"refs/heads/main","commits":%s}`, id+i, commits)),
+		}
+		events[i].Repo.Name = login + "/" + repo
+	}
+	return events
+}
+
+func TestActivityFarming(t *testing.T) {
+	varied := func(i int) string { return fmt.Sprintf("Add step %d", i) }
+	same := func(int) string { return "update" }
+	for _, tt := range []struct {
+		name string
+		// farm commits titled by message go to repo, which has stars, and
+		// tool commits to tool
+		repo       string
+		farm, tool int
+		stars      int
+		unlisted   bool
+		message    func(int) string
+		evidence   []string
+		share      float64
+	}{
+		{name: "bot name", repo: "auto-commit", farm: 95, tool: 5, message: varied,
+			evidence: []string{"octo/auto-commit: 95 of 100 pushed commits", "name looks like a commit bot"}, share: 0.95},
+		{name: "identical messages", repo: "notes", farm: 190, tool: 10, message: same,
+			evidence: []string{"octo/notes: 190 of 200 pushed commits", `recent commits share the message "update"`}, share: 0.95},
+		{name: "both", repo: "green-squares", farm: 99, tool: 1, message: same,
+			evidence: []string{"octo/green-squares: 99 of 100 pushed commits", "name looks like a commit bot", `recent commits share the message "update"`}, share: 0.99},
+		// None of these is farming
+		{name: "starred", repo: "auto-commit", farm: 95, tool: 5, stars: 3, message: same, share: 0.95},
+		{name: "spread", repo: "auto-commit", farm: 80, tool: 20, message: same, share: 0.8},
+		{name: "too few", repo: "auto-commit", farm: 40, tool: 1, message: same, share: 40.0 / 41},
+		{name: "ordinary repo", repo: "notes", farm: 95, tool: 5, message: varied, share: 0.95},
+		{name: "not the user's", repo: "auto-commit", farm: 95, tool: 5, unlisted: true, message: same, share: 0.95},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			unspaced(t)
+			tool := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}
+			account := newAccount("octo", days(3000), tool)
+			if !tt.unlisted {
+				account = newAccount("octo", days(3000), tool, GitHubRepo{Name: tt.repo, Size: 10, StargazersCount: tt.stars, UpdatedAt: fakeNow.Add(-days(1))})
+			}
+			account.Events = append(farmedPushes("octo", tt.repo, 1, tt.farm, tt.message), farmedPushes("octo", "tool", 1000, tt.tool, varied)...)
+			slices.SortFunc(account.Events, func(x, y GitHubEvent) int { return y.CreatedAt.Compare(x.CreatedAt) })
+
+			analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if metrics.TopRepoCommitShare != tt.share {
+				t.Errorf("TopRepoCommitShare = %v, want %v", metrics.TopRepoCommitShare, tt.share)
+			}
+
+			flag := finding(analysis, "ACTIVITY_FARMING")
+			if tt.evidence == nil {
+				if flag != nil || metrics.FarmedCommits != 0 {
+					t.Errorf("unexpected %+v, %d farmed commits", flag, metrics.FarmedCommits)
+				}
+				return
+			}
+			if flag == nil || flag.Severity != SeverityWarning || !slices.Equal(flag.Evidence, tt.evidence) {
+				t.Fatalf("ACTIVITY_FARMING = %+v, want %q", flag, tt.evidence)
+			}
+			if want := fmt.Sprintf("%.0f%% of recent commits went to octo/%s", tt.share*100, tt.repo); !strings.HasPrefix(flag.Message, want) {
+				t.Errorf("message %q, want it to start %q", flag.Message, want)
+			}
+
+			// The farmed share is left out of the Activity score
+			if want := int(float64(metrics.RecentCommits) * tt.share); metrics.FarmedCommits != want {
+				t.Errorf("FarmedCommits = %d, want %d", metrics.FarmedCommits, want)
+			}
+			a := NewAnalyzer("")
+			undiscounted := metrics
+			undiscounted.FarmedCommits = 0
+			if got := *analysis.Scores.Activity; got != a.calculateActivityScore(metrics, metrics.Repos) || got == a.calculateActivityScore(undiscounted, metrics.Repos) {
+				t.Errorf("Activity score %v doesn't discount the farmed commits", got)
+			}
+		})
+	}
+}
+
+func TestActivityFarmingSamplesMessages(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		deep bool
+	}{
+		{"deep", true},
+		{"not deep", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			unspaced(t)
+			account := newAccount("octo", days(3000), GitHubRepo{Name: "notes", Size: 10, UpdatedAt: fakeNow.Add(-days(1))})
+			// The feed carries no messages for the crowded repo
+			account.Events = farmedPushes("octo", "notes", 1, 60, nil)
+			f := newFakeGitHub(t, account)
+			var sampled bool
+			f.route("/repos/octo/notes/commits", func(w http.ResponseWriter, r *http.Request) {
+				// Other checks list a branch; the sample takes the default one
+				query := r.URL.Query()
+				if !query.Has("sha") && !query.Has("path") {
+					sampled = true
+					if got := query.Get("per_page"); got != fmt.Sprint(maxFarmingMessages) {
+						t.Errorf("sampled %s commits, want %d", got, maxFarmingMessages)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(commitHistory(0, maxFarmingMessages))
+			})
+
+			analysis, err := newFakeAnalyzer(f, WithDeepChecks(tt.deep)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			flag := finding(analysis, "ACTIVITY_FARMING")
+			want := []string{"octo/notes: 60 of 60 pushed commits", `recent commits share the message "fix the build"`}
+			// Without deep checks there are no messages to go on
+			if sampled != tt.deep || tt.deep != (flag != nil) || flag != nil && !slices.Equal(flag.Evidence, want) {
+				t.Errorf("sampled %t, ACTIVITY_FARMING = %+v; want sampled %t and %q", sampled, flag, tt.deep, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// activeDiscussionWindow is how recently a discussion must have been
+// updated for the repo's discussions to count as active
+const activeDiscussionWindow = 90 * 24 * time.Hour
+
+// checkRepoFeatures warns when flagship repos have issues disabled and the
+// maintainer offers no other way to be reached, and in deep mode credits
+// active GitHub Discussions
+func (a *Analyzer) checkRepoFeatures(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	var unreachable []string
+	for _, repo := range r.flagships() {
+		if repo.HasIssues || repo.Archived || repo.Fork {
+			continue
+		}
+		if _, ok := a.securityPolicyPath(ctx, r, repo); !ok && r.user.Email == "" {
+			unreachable = append(unreachable, repo.Name)
+		}
+	}
+
+	if len(unreachable) > 0 {
+		r.addFinding(Finding{
+			Code:     "NO_ISSUE_TRACKER",
+			Severity: SeverityWarning,
+			Message:  "Flagship repos have issues disabled and no security policy or public email - no way to report problems",
+			Evidence: unreachable,
+		})
+	}
+
+	if a.opts.DeepChecks && a.client.authenticated() {
+		a.checkDiscussions(ctx, r)
+	}
+}
+
+const discussionsQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    discussions(first: 1, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      totalCount
+      nodes { updatedAt }
+    }
+  }
+}`
+
+// checkDiscussions credits flagship repos with recently active discussions
+func (a *Analyzer) checkDiscussions(ctx context.Context, r *analysisRun) {
+	var active []string
+
+	for _, repo := range r.flagships() {
+		if !repo.HasDiscussions {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+
+		var result struct {
+			Repository struct {
+				Discussions struct {
+					TotalCount int `json:"totalCount"`
+					Nodes      []struct {
+						UpdatedAt time.Time `json:"updatedAt"`
+					} `json:"nodes"`
+				} `json:"discussions"`
+			} `json:"repository"`
+		}
+		err := a.client.graphQL(ctx, discussionsQuery, map[string]any{"owner": owner, "name": name}, &result)
+		if err != nil {
+			r.log.fellBack("discussions", fmt.Errorf("failed to query discussions: %w", err))
+			return
+		}
+
+		nodes := result.Repository.Discussions.Nodes
+		if len(nodes) > 0 && r.now.Sub(nodes[0].UpdatedAt) <= activeDiscussionWindow {
+			active = append(active, fmt.Sprintf("%s (%d discussions)", repo.Name, result.Repository.Discussions.TotalCount))
+		}
+	}
+
+	r.acc.metrics.ActiveDiscussions = len(active)
+	if len(active) > 0 {
+		r.addFinding(Finding{
+			Code:     "ACTIVE_DISCUSSIONS",
+			Severity: SeverityPositive,
+			Message:  "Flagship repos have active GitHub Discussions",
+			Evidence: active,
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package ebert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNoIssueTracker(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		email string
+		// policy lists the repos with a SECURITY.md
+		policy []string
+		want   []string
+	}{
+		{"unreachable", "", nil, []string{"tool", "lib"}},
+		{"security policy", "", []string{"lib"}, []string{"tool"}},
+		{"public email", "octo@example.com", nil, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newAccount("octo", days(3000),
+				GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 500, UpdatedAt: fakeNow.Add(-days(5))},
+				GitHubRepo{Name: "lib", Language: "Go", Size: 900, StargazersCount: 400, UpdatedAt: fakeNow.Add(-days(9))},
+				GitHubRepo{Name: "app", Language: "Go", Size: 900, StargazersCount: 300, HasIssues: true, UpdatedAt: fakeNow.Add(-days(9))},
+				GitHubRepo{Name: "minor", Language: "Go", Size: 100, StargazersCount: 1, HasIssues: true, UpdatedAt: fakeNow.Add(-days(9))},
+				// Forks don't count toward the ratio
+				GitHubRepo{Name: "fork", Language: "Go", Size: 100, Fork: true, UpdatedAt: fakeNow.Add(-days(9))},
+			)
+			account.User.Email = tt.email
+			f := newFakeGitHub(t, account)
+			for _, repo := range tt.policy {
+				serveDirectory(f, repo, "", "README.md", "SECURITY.md")
+			}
+
+			analysis, err := newFakeAnalyzer(f).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if got := analysis.Metrics.IssuesEnabledRatio; got != 0.5 {
+				t.Errorf("IssuesEnabledRatio = %v, want 2 of the 4 non-forks", got)
+			}
+			flag := finding(analysis, "NO_ISSUE_TRACKER")
+			if tt.want == nil {
+				if flag != nil {
+					t.Errorf("got %+v for a maintainer who can be reached", flag)
+				}
+				return
+			}
+			if flag == nil || flag.Severity != SeverityWarning || !slices.Equal(flag.Evidence, tt.want) {
+				t.Errorf("NO_ISSUE_TRACKER = %+v, want %q", flag, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveDiscussions(t *testing.T) {
+	account := newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 500, HasIssues: true, HasDiscussions: true, UpdatedAt: fakeNow.Add(-days(5))},
+		GitHubRepo{Name: "quiet", Language: "Go", Size: 900, StargazersCount: 400, HasIssues: true, HasDiscussions: true, UpdatedAt: fakeNow.Add(-days(5))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 900, StargazersCount: 300, HasIssues: true, UpdatedAt: fakeNow.Add(-days(5))},
+	)
+	f := newFakeGitHub(t, account)
+	lastDiscussed := map[string]int{"tool": 12, "quiet": 200}
+	var queried []string
+	f.route("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.Unmarshal(body, &req)
+		if !strings.Contains(req.Query, "discussions(") {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			f.serveGraphQLRepos(w, r)
+			return
+		}
+		name, _ := req.Variables["name"].(string)
+		queried = append(queried, name)
+		_, _ = fmt.Fprintf(w, `{"data":{"repository":{"discussions":{"totalCount":%d,"nodes":[{"updatedAt":%q}]}}}}`,
+			len(name), fakeNow.Add(-days(lastDiscussed[name])).Format("2006-01-02T15:04:05Z"))
+	})
+
+	for _, tt := range []struct {
+		name   string
+		token  string
+		deep   bool
+		active int
+	}{
+		{"deep", "t0ken", true, 1},
+		{"not deep", "t0ken", false, 0},
+		// GraphQL needs a token
+		{"anonymous", "", true, 0},
+	} {
+		queried = nil
+		analysis, err := newFakeAnalyzerToken(f, tt.token, WithDeepChecks(tt.deep)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		if analysis.Metrics.ActiveDiscussions != tt.active {
+			t.Errorf("%s: ActiveDiscussions = %d, want %d", tt.name, analysis.Metrics.ActiveDiscussions, tt.active)
+		}
+		flag := finding(analysis, "ACTIVE_DISCUSSIONS")
+		if tt.active == 0 {
+			if flag != nil || len(queried) > 0 {
+				t.Errorf("%s: queried discussions of %q and found %+v", tt.name, queried, flag)
+			}
+			continue
+		}
+		// Only repos with discussions enabled are asked about
+		if !slices.Equal(queried, []string{"tool", "quiet"}) {
+			t.Errorf("%s: queried discussions of %q, want tool and quiet", tt.name, queried)
+		}
+		if flag == nil || flag.Severity != SeverityPositive || !slices.Equal(flag.Evidence, []string{"tool (4 discussions)"}) {
+			t.Errorf("%s: ACTIVE_DISCUSSIONS = %+v, want tool's", tt.name, flag)
+		}
+	}
+}
+
+func TestFeatureFieldsOptional(t *testing.T) {
+	// An analysis saved before the repo feature fields existed
+	data := []byte(`{"user":{"login":"octo"},"metrics":{"repos":2,"stars":10},"repos":[{"name":"tool","has_pages":true}]}`)
+	var analysis struct {
+		Analysis
+		Repos []GitHubRepo `json:"repos"`
+	}
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		t.Fatalf("an older analysis doesn't parse: %v", err)
+	}
+	metrics := analysis.Metrics
+	if metrics.Repos != 2 || metrics.IssuesEnabledRatio != 0 || metrics.ActiveDiscussions != 0 {
+		t.Errorf("metrics = %+v, want the new fields zero", metrics)
+	}
+	repo := analysis.Repos[0]
+	if !repo.HasPages || repo.HasIssues || repo.HasWiki || repo.HasDiscussions {
+		t.Errorf("repo = %+v, want only has_pages set", repo)
+	}
+}
@@ -0,0 +1,149 @@
+package ebert
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how strongly a finding bears on the risk verdict
+type Severity int
+
+const (
+	SeverityPositive Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityRedFlag
+)
+
+var severityNames = map[Severity]string{
+	SeverityPositive: "positive",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityRedFlag:  "red_flag",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if strings.EqualFold(name, string(text)) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// Finding is a single coded observation about the account
+type Finding struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Evidence []string `json:"evidence,omitempty"`
+
+	// Remediation says what would resolve the finding; ScoreImpact is how
+	// much the overall score would drop if it were resolved
+	Remediation string  `json:"remediation,omitempty"`
+	ScoreImpact float64 `json:"score_impact,omitempty"`
+
+	// Index is the composite index the finding counts toward, if any
+	Index RiskIndex `json:"index,omitempty"`
+
+	// Args are the values interpolated into Message, in order, for
+	// findings whose message a catalog can translate
+	Args []string `json:"args,omitempty"`
+}
+
+// sortFindings puts findings in canonical order: highest severity first,
+// then by code, then by message, so repeated runs serialize identically
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		if findings[i].Code != findings[j].Code {
+			return findings[i].Code < findings[j].Code
+		}
+		return findings[i].Message < findings[j].Message
+	})
+}
+
+// maxCollapsedExamples is how much evidence a collapsed report line shows
+const maxCollapsedExamples = 3
+
+// fprintFindings writes one severity's findings ordered by code. Collapsed,
+// the findings of a code share one line counting their evidence with a few
+// examples; expanded, each finding is listed with all its evidence.
+func fprintFindings(w io.Writer, findings []Finding, catalog *Catalog, expand bool) {
+	line := func(key string, args ...any) {
+		fmt.Fprintln(w, catalog.Text(key, args...))
+	}
+	findings = slices.Clone(findings)
+	sortFindings(findings)
+
+	if expand {
+		for _, finding := range findings {
+			line("report.finding", catalog.Finding(finding))
+			for _, item := range finding.Evidence {
+				line("report.evidence", item)
+			}
+		}
+		return
+	}
+
+	for start := 0; start < len(findings); {
+		end := start + 1
+		for end < len(findings) && findings[end].Code == findings[start].Code {
+			end++
+		}
+		message := catalog.Finding(findings[start])
+		var evidence []string
+		for _, finding := range findings[start:end] {
+			evidence = append(evidence, finding.Evidence...)
+		}
+
+		switch {
+		case len(evidence) > maxCollapsedExamples:
+			line("report.finding_examples", message, len(evidence), strings.Join(evidence[:maxCollapsedExamples], ", "))
+		case len(evidence) > 0:
+			line("report.finding_evidence", message, strings.Join(evidence, ", "))
+		case end-start > 1:
+			line("report.finding_repeated", message, end-start)
+		default:
+			line("report.finding", message)
+		}
+		start = end
+	}
+}
+
+// splitFindings returns the messages of the red flag, warning and positive findings
+func splitFindings(findings []Finding) ([]string, []string, []string) {
+	var redFlags, warnings, positives []string
+
+	for _, finding := range findings {
+		switch finding.Severity {
+		case SeverityRedFlag:
+			redFlags = append(redFlags, finding.Message)
+		case SeverityWarning:
+			warnings = append(warnings, finding.Message)
+		case SeverityPositive:
+			positives = append(positives, finding.Message)
+		}
+	}
+
+	return redFlags, warnings, positives
+}
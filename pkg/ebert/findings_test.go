@@ -0,0 +1,59 @@
+package ebert
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reportFixture has repeated codes with and without evidence, findings of
+// every severity out of order, and more evidence than a line shows
+func reportFixture() *Analysis {
+	return &Analysis{
+		User:         GitHubUser{Login: "octo", Name: "Octo", HTMLURL: "https://github.com/octo"},
+		Scores:       RiskScores{Identity: computed(15), Activity: computed(70), Security: computed(65)},
+		OverallScore: 50,
+		RiskLevel:    "medium",
+		Confidence:   0.8,
+		Metrics:      Metrics{AccountAgeDays: 2000, Repos: 24, ActivityWindowDays: 90},
+		Findings: []Finding{
+			{Code: "NO_LICENSE", Severity: SeverityWarning, Message: "Repos without a license", Evidence: []string{"foo", "bar"}},
+			{Code: "ESTABLISHED", Severity: SeverityPositive, Message: "Established account"},
+			{Code: "NO_LICENSE", Severity: SeverityWarning, Message: "Repos without a license", Evidence: []string{"baz", "qux", "quux"}},
+			{Code: "SECRET_IN_REPO", Severity: SeverityRedFlag, Message: "Possible secret committed", Evidence: []string{"tool/.env"}},
+			{Code: "FORCE_PUSH", Severity: SeverityWarning, Message: "Force push to the default branch"},
+			{Code: "FORCE_PUSH", Severity: SeverityWarning, Message: "Force push to the default branch"},
+			{Code: "BUS_FACTOR", Severity: SeverityInfo, Message: "A single maintainer"},
+			{Code: "CI_CONFIGURED", Severity: SeverityPositive, Message: "CI configured"},
+			{Code: "BRAND_NEW_REPOS", Severity: SeverityRedFlag, Message: "Burst of new repos"},
+		},
+		RequestStats: &RequestStats{Requests: 1234, CacheHits: 56, RateLimit: &RateLimit{Remaining: 4812, Reset: time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)}},
+	}
+}
+
+func TestFprintReportGolden(t *testing.T) {
+	for _, tc := range []struct {
+		golden string
+		expand bool
+	}{
+		{"testdata/report.golden", false},
+		{"testdata/report_expanded.golden", true},
+	} {
+		var report strings.Builder
+		FprintReport(&report, reportFixture(), ReportOptions{Expand: tc.expand})
+		if *update {
+			if err := os.WriteFile(tc.golden, []byte(report.String()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(tc.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.String() != string(want) {
+			t.Errorf("the report differs from %s; rerun with -update if intended:\n%s", tc.golden, report.String())
+		}
+	}
+}
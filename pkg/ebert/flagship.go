@@ -0,0 +1,58 @@
+package ebert
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultTopRepos is how many of the most-starred repos get per-repo checks
+const DefaultTopRepos = 5
+
+// topRepos keeps the most-starred repos seen so far, bounded to limit, so
+// per-repo checks work without holding the whole repo list
+type topRepos struct {
+	limit int
+	repos []GitHubRepo
+}
+
+func (t *topRepos) add(repo GitHubRepo) {
+	if t.limit <= 0 {
+		return
+	}
+
+	t.repos = append(t.repos, repo)
+	sort.SliceStable(t.repos, func(i, j int) bool {
+		if t.repos[i].StargazersCount != t.repos[j].StargazersCount {
+			return t.repos[i].StargazersCount > t.repos[j].StargazersCount
+		}
+		return t.repos[i].Name < t.repos[j].Name
+	})
+
+	if len(t.repos) > t.limit {
+		t.repos = t.repos[:t.limit]
+	}
+}
+
+// replace swaps a repo for a fuller copy of it, keeping its place
+func (t *topRepos) replace(repo GitHubRepo) {
+	for i := range t.repos {
+		if strings.EqualFold(t.repos[i].FullName, repo.FullName) {
+			t.repos[i] = repo
+			return
+		}
+	}
+}
+
+// list returns the flagship repos, most-starred first
+func (t *topRepos) list() []GitHubRepo {
+	return t.repos
+}
+
+// repoOwnerAndName splits a repo's full name, falling back to the analyzed
+// login when the full name is missing
+func repoOwnerAndName(repo GitHubRepo, login string) (string, string) {
+	if owner, name, ok := strings.Cut(repo.FullName, "/"); ok {
+		return owner, name
+	}
+	return login, repo.Name
+}
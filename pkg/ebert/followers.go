@@ -0,0 +1,185 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxFollowersSampled bounds the most recent follows read, in pages of
+	// maxPerPage
+	maxFollowersSampled = 500
+
+	// followerStepSize is how many consecutive follows make a step, and
+	// followerStepShare the share of them that must come from accounts
+	// created within followerCreationSpan of each other
+	followerStepSize     = 100
+	followerStepShare    = 0.8
+	followerCreationSpan = 14 * 24 * time.Hour
+
+	// followerEvidenceLogins is how many step logins the evidence names
+	followerEvidenceLogins = 5
+)
+
+// Follower is a follower account in follow order, as the growth check
+// samples them
+type Follower struct {
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const followersQuery = `query($login: String!, $before: String) {
+  user(login: $login) {
+    followers(last: 100, before: $before) {
+      pageInfo { hasPreviousPage startCursor }
+      nodes { login createdAt }
+    }
+  }
+}`
+
+// GetRecentFollowers returns up to n of the user's most recent followers,
+// oldest follow first. The connection lists followers in follow order,
+// which is the only follow-date signal the API gives.
+func (c *GitHubClient) GetRecentFollowers(ctx context.Context, login string, n int) (_ []Follower, err error) {
+	ctx, span := c.startSpan(ctx, "github.followers", "graphql:followers")
+	defer func() { endSpan(span, err) }()
+
+	var followers []Follower
+	var before *string
+	for len(followers) < n {
+		var result struct {
+			User struct {
+				Followers struct {
+					PageInfo struct {
+						HasPreviousPage bool   `json:"hasPreviousPage"`
+						StartCursor     string `json:"startCursor"`
+					} `json:"pageInfo"`
+					Nodes []Follower `json:"nodes"`
+				} `json:"followers"`
+			} `json:"user"`
+		}
+		if err := c.graphQL(ctx, followersQuery, map[string]any{"login": login, "before": before}, &result); err != nil {
+			return nil, err
+		}
+
+		page := result.User.Followers
+		followers = append(page.Nodes, followers...)
+		if !page.PageInfo.HasPreviousPage || len(page.Nodes) == 0 {
+			break
+		}
+		before = &page.PageInfo.StartCursor
+	}
+	if len(followers) > n {
+		followers = followers[len(followers)-n:]
+	}
+	return followers, nil
+}
+
+// followerStep is a run of follows from accounts created together
+type followerStep struct {
+	start, end       int // positions in the sample, end exclusive
+	clustered        int
+	earliest, latest time.Time
+	logins           []string
+}
+
+// findFollowerStep looks for followerStepSize consecutive follows of which
+// at least followerStepShare came from accounts created within
+// followerCreationSpan, the shape bought followers leave. Follow dates
+// aren't exposed, but each follow came after its account was created, and
+// later follows after earlier ones. Adjoining windows that also qualify
+// widen the step.
+func findFollowerStep(followers []Follower) (followerStep, bool) {
+	var best followerStep
+	found := false
+	for start := 0; start+followerStepSize <= len(followers); start++ {
+		window := followers[start : start+followerStepSize]
+		if clustered, _, _ := densestCreationCluster(window); float64(clustered) < followerStepShare*float64(followerStepSize) {
+			continue
+		}
+		if found && start < best.end {
+			best.end = start + followerStepSize
+			continue
+		}
+		if found {
+			// Report the first step; later ones usually repeat it
+			break
+		}
+		best = followerStep{start: start, end: start + followerStepSize}
+		found = true
+	}
+	if !found {
+		return best, false
+	}
+	best.clustered, best.earliest, best.latest = densestCreationCluster(followers[best.start:best.end])
+
+	for _, follower := range followers[best.start:best.end] {
+		if len(best.logins) == followerEvidenceLogins {
+			break
+		}
+		if !follower.CreatedAt.Before(best.earliest) && !follower.CreatedAt.After(best.latest) {
+			best.logins = append(best.logins, follower.Login)
+		}
+	}
+	return best, true
+}
+
+// densestCreationCluster finds the most accounts in window created within
+// followerCreationSpan, and that span's bounds
+func densestCreationCluster(window []Follower) (int, time.Time, time.Time) {
+	created := make([]time.Time, 0, len(window))
+	for _, follower := range window {
+		if !follower.CreatedAt.IsZero() {
+			created = append(created, follower.CreatedAt)
+		}
+	}
+	sort.Slice(created, func(i, j int) bool { return created[i].Before(created[j]) })
+
+	best, earliest, latest := 0, time.Time{}, time.Time{}
+	low := 0
+	for high := range created {
+		for created[high].Sub(created[low]) > followerCreationSpan {
+			low++
+		}
+		if n := high - low + 1; n > best {
+			best, earliest, latest = n, created[low], created[high]
+		}
+	}
+	return best, earliest, latest
+}
+
+// checkFollowerGrowth samples the most recent follows in deep mode and
+// warns on a step: a run of follows from accounts created days apart
+func (a *Analyzer) checkFollowerGrowth(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !a.client.authenticated() || r.user.Followers < followerStepSize {
+		return
+	}
+
+	followers, err := a.client.GetRecentFollowers(ctx, r.user.Login, maxFollowersSampled)
+	if err != nil {
+		r.log.fellBack("followers", fmt.Errorf("failed to sample followers: %w", err))
+		return
+	}
+	r.acc.metrics.SampledFollowers = len(followers)
+
+	step, ok := findFollowerStep(followers)
+	if !ok {
+		return
+	}
+	// Positions count back from the newest follow, which is what a
+	// reader can check on the profile
+	newest := len(followers)
+	r.addFinding(Finding{
+		Code:     "FOLLOWER_GROWTH_ANOMALY",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("Follower growth has a step: %d of %d consecutive follows came from accounts created within days of each other", step.clustered, step.end-step.start),
+		Evidence: []string{
+			fmt.Sprintf("follows %d to %d back from the newest, after %s", newest-step.end+1, newest-step.start, step.latest.Format("2006-01-02")),
+			fmt.Sprintf("follower accounts created %s to %s", step.earliest.Format("2006-01-02"), step.latest.Format("2006-01-02")),
+			"e.g. " + strings.Join(step.logins, ", "),
+		},
+	})
+}
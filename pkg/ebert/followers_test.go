@@ -0,0 +1,200 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// followerSeries is n follows, oldest first, of accounts f000, f001, ...
+// created at created(i)
+func followerSeries(n int, created func(i int) time.Time) []Follower {
+	followers := make([]Follower, n)
+	for i := range followers {
+		followers[i] = Follower{Login: fmt.Sprintf("f%03d", i), CreatedAt: created(i)}
+	}
+	return followers
+}
+
+// organicCreation spreads account creation three days apart over years
+func organicCreation(i int) time.Time { return fakeNow.Add(-days(2000 - 3*i)) }
+
+// steppedCreation is organicCreation but for the follows in [from, to),
+// whose accounts were created an hour apart 40 days ago
+func steppedCreation(from, to int) func(int) time.Time {
+	return func(i int) time.Time {
+		if i >= from && i < to {
+			return fakeNow.Add(-days(40) + time.Duration(i-from)*time.Hour)
+		}
+		return organicCreation(i)
+	}
+}
+
+func TestFindFollowerStep(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		followers  []Follower
+		found      bool
+		start, end int
+		clustered  int
+	}{
+		{"organic", followerSeries(500, organicCreation), false, 0, 0, 0},
+		{"empty", nil, false, 0, 0, 0},
+		{"too few follows", followerSeries(followerStepSize-1, steppedCreation(0, followerStepSize-1)), false, 0, 0, 0},
+		{"cluster too small", followerSeries(500, steppedCreation(200, 279)), false, 0, 0, 0},
+		{"just enough", followerSeries(500, steppedCreation(200, 280)), true, 180, 300, 80},
+		// Every window holding 80 of the clustered follows widens the step
+		{"step", followerSeries(500, steppedCreation(200, 320)), true, 180, 340, 120},
+		{"whole sample", followerSeries(followerStepSize, steppedCreation(0, followerStepSize)), true, 0, followerStepSize, followerStepSize},
+	} {
+		step, found := findFollowerStep(tt.followers)
+		if found != tt.found {
+			t.Errorf("%s: found %t, want %t", tt.name, found, tt.found)
+			continue
+		}
+		if found && (step.start != tt.start || step.end != tt.end || step.clustered != tt.clustered) {
+			t.Errorf("%s: step [%d, %d) with %d clustered, want [%d, %d) with %d", tt.name, step.start, step.end, step.clustered, tt.start, tt.end, tt.clustered)
+		}
+	}
+
+	step, _ := findFollowerStep(followerSeries(500, steppedCreation(200, 320)))
+	if want := "f200, f201, f202, f203, f204"; strings.Join(step.logins, ", ") != want {
+		t.Errorf("logins %q, want %q", step.logins, want)
+	}
+	if !step.earliest.Equal(fakeNow.Add(-days(40))) || !step.latest.Equal(fakeNow.Add(-days(40)+119*time.Hour)) {
+		t.Errorf("created %s to %s", step.earliest, step.latest)
+	}
+}
+
+// serveFollowers answers the followers query from followers, oldest
+// first, paging back from the newest as GitHub's last/before does, and
+// 404s other queries. It counts the followers pages served.
+func serveFollowers(f *fakeGitHub, followers []Follower) *atomic.Int32 {
+	var pages atomic.Int32
+	f.route("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query     string `json:"query"`
+			Variables struct {
+				Before *string `json:"before"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || !strings.Contains(request.Query, "followers(") {
+			http.NotFound(w, r)
+			return
+		}
+		pages.Add(1)
+		end := len(followers)
+		if request.Variables.Before != nil {
+			end, _ = strconv.Atoi(*request.Variables.Before)
+		}
+		start := max(end-100, 0)
+		var page struct {
+			PageInfo struct {
+				HasPreviousPage bool   `json:"hasPreviousPage"`
+				StartCursor     string `json:"startCursor"`
+			} `json:"pageInfo"`
+			Nodes []Follower `json:"nodes"`
+		}
+		page.PageInfo.HasPreviousPage, page.PageInfo.StartCursor = start > 0, strconv.Itoa(start)
+		page.Nodes = followers[start:end]
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"user": map[string]any{"followers": page}}})
+	})
+	return &pages
+}
+
+func TestGetRecentFollowers(t *testing.T) {
+	for _, tt := range []struct {
+		total, pages int
+		first        string
+	}{
+		{250, 3, "f000"},
+		// Only the newest are read, and no more pages than they take
+		{700, 5, "f200"},
+	} {
+		f := newFakeGitHub(t)
+		pages := serveFollowers(f, followerSeries(tt.total, organicCreation))
+		followers, err := newFakeAnalyzerToken(f, "token").client.GetRecentFollowers(t.Context(), "octo", maxFollowersSampled)
+		if err != nil {
+			t.Fatalf("GetRecentFollowers: %v", err)
+		}
+		want := min(tt.total, maxFollowersSampled)
+		if len(followers) != want || followers[0].Login != tt.first || followers[want-1].Login != fmt.Sprintf("f%03d", tt.total-1) || pages.Load() != int32(tt.pages) {
+			t.Errorf("%d followers: read %d from %s in %d pages, want %d from %s in %d", tt.total, len(followers), followers[0].Login, pages.Load(), want, tt.first, tt.pages)
+		}
+	}
+}
+
+func TestCheckFollowerGrowth(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		token     string
+		deep      bool
+		followers int
+		series    func(int) time.Time
+		sampled   bool
+		flagged   bool
+	}{
+		{name: "step", token: "token", deep: true, followers: 500, series: steppedCreation(200, 320), sampled: true, flagged: true},
+		{name: "organic", token: "token", deep: true, followers: 500, series: organicCreation, sampled: true},
+		// GraphQL needs a token, and the sample is a deep check
+		{name: "anonymous", deep: true, followers: 500, series: steppedCreation(200, 320)},
+		{name: "not deep", token: "token", followers: 500, series: steppedCreation(200, 320)},
+		{name: "few followers", token: "token", deep: true, followers: followerStepSize - 1, series: steppedCreation(0, followerStepSize-1)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))})
+			account.User.Followers = tt.followers
+			f := newFakeGitHub(t, account)
+			pages := serveFollowers(f, followerSeries(tt.followers, tt.series))
+
+			analysis, err := newFakeAnalyzerToken(f, tt.token, WithDeepChecks(tt.deep)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if sampled := pages.Load() > 0; sampled != tt.sampled || tt.sampled && analysis.Metrics.SampledFollowers != tt.followers {
+				t.Errorf("sampled %d followers in %d pages, want sampled %t", analysis.Metrics.SampledFollowers, pages.Load(), tt.sampled)
+			}
+
+			flag := finding(analysis, "FOLLOWER_GROWTH_ANOMALY")
+			if !tt.flagged {
+				if flag != nil {
+					t.Errorf("unexpected %+v", flag)
+				}
+				return
+			}
+			want := []string{
+				"follows 161 to 320 back from the newest, after 2024-04-27",
+				"follower accounts created 2024-04-22 to 2024-04-27",
+				"e.g. f200, f201, f202, f203, f204",
+			}
+			if flag == nil || flag.Severity != SeverityWarning || !slices.Equal(flag.Evidence, want) {
+				t.Fatalf("FOLLOWER_GROWTH_ANOMALY = %+v, want %q", flag, want)
+			}
+			if !strings.Contains(flag.Message, "120 of 160 consecutive follows") {
+				t.Errorf("message %q", flag.Message)
+			}
+		})
+	}
+}
+
+func TestEstimateFollowerCost(t *testing.T) {
+	opts := defaultOptions()
+	opts.DeepChecks = true
+	for followers, want := range map[int]int{followerStepSize - 1: 0, 250: 3, 5000: 5} {
+		var count int
+		for _, request := range EstimateCost(&GitHubUser{Login: "octo", PublicRepos: 3, Followers: followers}, opts).Requests {
+			if request.Step == "followers" {
+				count += request.Count
+			}
+		}
+		if count != want {
+			t.Errorf("%d followers cost %d pages, want %d", followers, count, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ebert
+
+import (
+	"math"
+	"time"
+)
+
+// Scoring versions selectable with WithScoringVersion
+const (
+	// ScoringV1 counts repos updated in the last 30 days
+	ScoringV1 = 1
+
+	// ScoringV2 scores maintenance on MaintenanceFreshness, so a repo
+	// pushed 91 days ago still counts for more than one untouched for years,
+	// and quality on WeightedStars, so stars earned long ago on a repo
+	// left dormant since count for less
+	ScoringV2 = 2
+
+	latestScoringVersion = ScoringV2
+)
+
+// DefaultFreshnessHalfLife is how long after its last push a repo counts
+// half as fresh
+const DefaultFreshnessHalfLife = 180 * 24 * time.Hour
+
+// freshness weighs a repo by its last push, decaying by half every
+// halfLife; repos without a push time fall back to their update time
+func freshness(repo GitHubRepo, now time.Time, halfLife time.Duration) float64 {
+	last := repo.PushedAt
+	if last.IsZero() {
+		last = repo.UpdatedAt
+	}
+	if last.IsZero() {
+		return 0
+	}
+	age := max(now.Sub(last), 0)
+	return math.Exp2(-float64(age) / float64(halfLife))
+}
+
+// freshnessAdjustment moves the maintenance score by up to 20 either way:
+// +20 when nothing has been pushed in years, -20 once the repos are on
+// average about as fresh as a push four months ago
+func freshnessAdjustment(freshness float64) float64 {
+	return max(-20, 20-60*freshness)
+}
@@ -0,0 +1,105 @@
+package ebert
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFreshness(t *testing.T) {
+	halfLife := DefaultFreshnessHalfLife
+	for _, tt := range []struct {
+		name string
+		repo GitHubRepo
+		want float64
+	}{
+		{"just pushed", GitHubRepo{PushedAt: fakeNow}, 1},
+		{"one half-life", GitHubRepo{PushedAt: fakeNow.Add(-halfLife)}, 0.5},
+		{"two half-lives", GitHubRepo{PushedAt: fakeNow.Add(-2 * halfLife)}, 0.25},
+		{"pushed wins", GitHubRepo{PushedAt: fakeNow.Add(-halfLife), UpdatedAt: fakeNow}, 0.5},
+		{"updated only", GitHubRepo{UpdatedAt: fakeNow.Add(-halfLife)}, 0.5},
+		{"never", GitHubRepo{}, 0},
+		{"clock skew", GitHubRepo{PushedAt: fakeNow.Add(time.Hour)}, 1},
+	} {
+		if got := freshness(tt.repo, fakeNow, halfLife); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: freshness = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFreshnessAdjustment(t *testing.T) {
+	for _, tt := range []struct{ freshness, want float64 }{
+		{0, 20}, {0.5, -10}, {1, -20},
+	} {
+		if got := freshnessAdjustment(tt.freshness); got != tt.want {
+			t.Errorf("freshnessAdjustment(%v) = %v, want %v", tt.freshness, got, tt.want)
+		}
+	}
+}
+
+func TestWithScoringVersion(t *testing.T) {
+	for _, version := range []int{0, latestScoringVersion + 1} {
+		if _, err := New("", WithScoringVersion(version)); err == nil {
+			t.Errorf("scoring version %d was accepted", version)
+		}
+	}
+	if _, err := New("", WithFreshnessHalfLife(0)); err == nil {
+		t.Error("a zero half-life was accepted")
+	}
+	a, err := New("", WithScoringVersion(ScoringV2), WithFreshnessHalfLife(90*24*time.Hour))
+	if err != nil || a.opts.ScoringVersion != ScoringV2 || a.opts.FreshnessHalfLife != 90*24*time.Hour {
+		t.Errorf("New = %v, options %+v", err, a.opts)
+	}
+	if got := NewAnalyzer("").opts.ScoringVersion; got != ScoringV1 {
+		t.Errorf("default scoring version %d, want v1", got)
+	}
+}
+
+func TestMaintenanceScoreVersions(t *testing.T) {
+	// Ten repos pushed just either side of the 30-day cutoff: v1 jumps
+	// when they cross it, v2 barely moves
+	before := Metrics{RecentlyUpdated: 10, MaintenanceFreshness: freshness(GitHubRepo{PushedAt: fakeNow.Add(-days(29))}, fakeNow, DefaultFreshnessHalfLife)}
+	after := Metrics{RecentlyUpdated: 0, MaintenanceFreshness: freshness(GitHubRepo{PushedAt: fakeNow.Add(-days(31))}, fakeNow, DefaultFreshnessHalfLife)}
+
+	v1 := NewAnalyzer("")
+	if got, want := v1.calculateMaintenanceScore(after, 10)-v1.calculateMaintenanceScore(before, 10), 40.0; got != want {
+		t.Errorf("v1 moved %v across the cutoff, want %v", got, want)
+	}
+	v2 := NewAnalyzer("", WithScoringVersion(ScoringV2))
+	if got := v2.calculateMaintenanceScore(after, 10) - v2.calculateMaintenanceScore(before, 10); got < 0 || got > 1 {
+		t.Errorf("v2 moved %v across the cutoff, want at most a point", got)
+	}
+	// and keeps rising, slowly, as the repos age
+	older := Metrics{MaintenanceFreshness: freshness(GitHubRepo{PushedAt: fakeNow.Add(-days(150))}, fakeNow, DefaultFreshnessHalfLife)}
+	oldest := Metrics{MaintenanceFreshness: freshness(GitHubRepo{PushedAt: fakeNow.Add(-days(152))}, fakeNow, DefaultFreshnessHalfLife)}
+	if got := v2.calculateMaintenanceScore(oldest, 10) - v2.calculateMaintenanceScore(older, 10); got <= 0 || got > 1 {
+		t.Errorf("v2 moved %v over two days at 150, want a small increase", got)
+	}
+	// Long untouched repos score as badly either way
+	stale := Metrics{MaintenanceFreshness: 0}
+	if v1.calculateMaintenanceScore(stale, 10) != v2.calculateMaintenanceScore(stale, 10) {
+		t.Errorf("stale repos score %v in v1 but %v in v2", v1.calculateMaintenanceScore(stale, 10), v2.calculateMaintenanceScore(stale, 10))
+	}
+}
+
+func TestMaintenanceFreshness(t *testing.T) {
+	account := newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 10, UpdatedAt: fakeNow.Add(-DefaultFreshnessHalfLife)},
+		// Forks don't count
+		GitHubRepo{Name: "fork", Fork: true, Size: 100, UpdatedAt: fakeNow.Add(-days(3000))},
+	)
+	for _, version := range []int{ScoringV1, ScoringV2} {
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, account), WithScoringVersion(version)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		// Both versions report it, so v1 runs can be compared with v2's
+		if got := analysis.Metrics.MaintenanceFreshness; math.Abs(got-0.75) > 1e-9 {
+			t.Errorf("v%d: MaintenanceFreshness = %v, want 0.75", version, got)
+		}
+		if analysis.Meta.Options.ScoringVersion != version {
+			t.Errorf("v%d: meta records scoring version %d", version, analysis.Meta.Options.ScoringVersion)
+		}
+	}
+}
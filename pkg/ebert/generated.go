@@ -0,0 +1,262 @@
+package ebert
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxReadmeSamples is how many non-fork repos have their README compared
+	maxReadmeSamples = 20
+
+	// readmeOpeningLines is how much of each README is compared; templates
+	// show in the opening, while later sections drift as repos are edited
+	readmeOpeningLines = 10
+
+	// readmeShingleWords is the word length of the shingles READMEs are
+	// hashed into
+	readmeShingleWords = 3
+
+	// maxGeneratedExamples is how many examples a finding lists
+	maxGeneratedExamples = 5
+)
+
+// GeneratedContentThresholds set when sampled content looks generated
+type GeneratedContentThresholds struct {
+	// MessageShare is the share of sampled commit messages repeating an
+	// earlier one, and MinCommits the sample size needed to judge it
+	MessageShare float64 `json:"message_share"`
+	MinCommits   int     `json:"min_commits"`
+
+	// ReadmeSimilarity is the shingle Jaccard similarity at which two
+	// READMEs count as near-identical, and ReadmeRepos how many repos must
+	// share one
+	ReadmeSimilarity float64 `json:"readme_similarity"`
+	ReadmeRepos      int     `json:"readme_repos"`
+}
+
+// DefaultGeneratedContentThresholds flags over 80% repeated messages
+// across at least 100 commits, or one README across at least 10 repos
+func DefaultGeneratedContentThresholds() GeneratedContentThresholds {
+	return GeneratedContentThresholds{MessageShare: 0.8, MinCommits: 100, ReadmeSimilarity: 0.9, ReadmeRepos: 10}
+}
+
+func (t GeneratedContentThresholds) validate() error {
+	if t.MessageShare <= 0 || t.MessageShare > 1 || t.ReadmeSimilarity <= 0 || t.ReadmeSimilarity > 1 {
+		return errors.New("shares must be in (0, 1]")
+	}
+	if t.MinCommits < 1 || t.ReadmeRepos < 2 {
+		return errors.New("need at least one commit and two repos")
+	}
+	return nil
+}
+
+// GetReadme fetches a repo's README from its default branch; a repo
+// without one yields nil and no error
+func (c *GitHubClient) GetReadme(ctx context.Context, owner, repo string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "github.readme", "repos/:owner/:repo/readme")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.getAccept(ctx, fmt.Sprintf("%s/repos/%s/%s/readme", c.BaseURL, owner, repo), rawAccept)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// checkGeneratedContent samples commit messages from the top repos and
+// READMEs across the account's repos, warning when the messages are
+// mostly repeats or one README template is cloned across many repos -
+// the padding of scripted accounts. It runs in deep mode.
+func (a *Analyzer) checkGeneratedContent(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !r.log.coverage().repos {
+		return
+	}
+
+	thresholds := a.opts.GeneratedContent
+	metrics := &r.acc.metrics
+
+	var messages []string
+	for _, repo := range r.checkable() {
+		if repo.Fork {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+		if commits, err := a.client.GetCommits(ctx, owner, name, repo.DefaultBranch, maxPerPage); err == nil {
+			for _, commit := range commits {
+				messages = append(messages, commit.Commit.Message)
+			}
+		}
+	}
+	repeated, common := repeatedMessages(messages)
+	metrics.SampledCommitMessages = len(messages)
+	if len(messages) > 0 {
+		metrics.CommitMessageUniqueness = 1 - repeated
+	}
+
+	var readmes []sampledReadme
+	for _, repo := range r.skipBlocked(r.acc.readmeRepos.list()) {
+		owner, name := repoOwnerAndName(repo, r.username)
+		data, err := a.client.GetReadme(ctx, owner, name)
+		if err != nil || data == nil {
+			continue
+		}
+		if shingles := readmeShingles(string(data), name); len(shingles) > 0 {
+			readmes = append(readmes, sampledReadme{repo: repo.FullName, opening: readmeOpening(string(data)), shingles: shingles})
+		}
+	}
+	cloned := largestReadmeCluster(readmes, thresholds.ReadmeSimilarity)
+	metrics.TemplatedReadmes = len(cloned)
+
+	var evidence []string
+	if len(messages) >= thresholds.MinCommits && repeated > thresholds.MessageShare {
+		evidence = append(evidence, fmt.Sprintf("%.0f%% of %d sampled commit messages repeat an earlier one", repeated*100, len(messages)))
+		for _, message := range common {
+			evidence = append(evidence, fmt.Sprintf("commit message %q", message))
+		}
+	}
+	if len(cloned) >= thresholds.ReadmeRepos {
+		evidence = append(evidence, fmt.Sprintf("%d repos share a near-identical README opening %q", len(cloned), cloned[0].opening))
+		for _, readme := range cloned[:min(len(cloned), maxGeneratedExamples)] {
+			evidence = append(evidence, "README of "+readme.repo)
+		}
+	}
+	if len(evidence) == 0 {
+		return
+	}
+	r.addFinding(Finding{
+		Code:     "GENERATED_CONTENT",
+		Severity: SeverityWarning,
+		Message:  "Commit messages or READMEs look generated from a template - the account may be padded by scripts",
+		Evidence: evidence,
+	})
+}
+
+// repeatedMessages returns the share of messages whose subject repeats an
+// earlier one, and the most repeated subjects
+func repeatedMessages(messages []string) (float64, []string) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	counts := map[string]int{}
+	for _, message := range messages {
+		subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		counts[strings.ToLower(strings.TrimSpace(subject))]++
+	}
+
+	subjects := make([]string, 0, len(counts))
+	for subject, count := range counts {
+		if count > 1 {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Slice(subjects, func(i, j int) bool {
+		if counts[subjects[i]] != counts[subjects[j]] {
+			return counts[subjects[i]] > counts[subjects[j]]
+		}
+		return subjects[i] < subjects[j]
+	})
+	return 1 - float64(len(counts))/float64(len(messages)), subjects[:min(len(subjects), maxGeneratedExamples)]
+}
+
+// sampledReadme is the opening of one repo's README, hashed into shingles
+type sampledReadme struct {
+	repo     string
+	opening  string
+	shingles map[uint64]struct{}
+}
+
+// readmeWordPattern splits README text into words, dropping Markdown
+// punctuation
+var readmeWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// readmeOpening is the first line of README prose, past a title heading
+// that usually just names the repo
+func readmeOpening(readme string) string {
+	heading := ""
+	for line := range strings.Lines(readme) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			heading = cmp.Or(heading, strings.TrimSpace(strings.TrimLeft(line, "#")))
+			continue
+		}
+		if line != "" {
+			return line
+		}
+	}
+	return heading
+}
+
+// readmeShingles hashes the opening lines of a README into word shingles.
+// The repo name is masked so a template stamped with each repo's name
+// still matches itself.
+func readmeShingles(readme, repoName string) map[uint64]struct{} {
+	var lines []string
+	for line := range strings.Lines(readme) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == readmeOpeningLines {
+			break
+		}
+	}
+
+	nameWords := map[string]struct{}{}
+	for _, word := range readmeWordPattern.FindAllString(strings.ToLower(repoName), -1) {
+		nameWords[word] = struct{}{}
+	}
+	words := readmeWordPattern.FindAllString(strings.ToLower(strings.Join(lines, " ")), -1)
+	for i, word := range words {
+		if _, ok := nameWords[word]; ok {
+			words[i] = "_"
+		}
+	}
+
+	shingles := map[uint64]struct{}{}
+	for i := 0; i < max(len(words)-readmeShingleWords+1, min(len(words), 1)); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(words[i:min(i+readmeShingleWords, len(words))], " ")))
+		shingles[h.Sum64()] = struct{}{}
+	}
+	return shingles
+}
+
+// jaccard is the overlap of two sets, e.g. of shingles or logins
+func jaccard[K comparable](a, b map[K]struct{}) float64 {
+	shared := 0
+	for key := range a {
+		if _, ok := b[key]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// largestReadmeCluster returns the biggest group of READMEs near-identical
+// to one of them
+func largestReadmeCluster(readmes []sampledReadme, similarity float64) []sampledReadme {
+	var best []sampledReadme
+	for i := range readmes {
+		cluster := []sampledReadme{readmes[i]}
+		for j := range readmes {
+			if i != j && jaccard(readmes[i].shingles, readmes[j].shingles) >= similarity {
+				cluster = append(cluster, readmes[j])
+			}
+		}
+		if len(cluster) > len(best) && len(cluster) > 1 {
+			best = cluster
+		}
+	}
+	return best
+}
@@ -0,0 +1,207 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGeneratedContentThresholdsValidate(t *testing.T) {
+	defaults := DefaultGeneratedContentThresholds()
+	if err := defaults.validate(); err != nil {
+		t.Errorf("defaults are invalid: %v", err)
+	}
+	for _, tt := range []struct {
+		name   string
+		change func(*GeneratedContentThresholds)
+	}{
+		{"no message share", func(g *GeneratedContentThresholds) { g.MessageShare = 0 }},
+		{"message share over 1", func(g *GeneratedContentThresholds) { g.MessageShare = 1.5 }},
+		{"no similarity", func(g *GeneratedContentThresholds) { g.ReadmeSimilarity = 0 }},
+		{"no commits", func(g *GeneratedContentThresholds) { g.MinCommits = 0 }},
+		{"one repo", func(g *GeneratedContentThresholds) { g.ReadmeRepos = 1 }},
+	} {
+		thresholds := defaults
+		tt.change(&thresholds)
+		if _, err := New("", WithGeneratedContentThresholds(thresholds)); err == nil || !strings.Contains(err.Error(), "invalid generated content thresholds") {
+			t.Errorf("%s: New = %v, want the thresholds rejected", tt.name, err)
+		}
+	}
+}
+
+func TestRepeatedMessages(t *testing.T) {
+	if share, common := repeatedMessages(nil); share != 0 || common != nil {
+		t.Errorf("no messages: %v, %q", share, common)
+	}
+	messages := []string{"Update", "update\n\nmore", " update ", "fix", "Fix", "docs", "tests", "a", "b", "c"}
+	share, common := repeatedMessages(messages)
+	// 7 distinct subjects among 10 messages
+	if math.Abs(share-0.3) > 1e-9 || !slices.Equal(common, []string{"update", "fix"}) {
+		t.Errorf("repeatedMessages = %v, %q; want 0.3 and update then fix", share, common)
+	}
+}
+
+func TestReadmeOpening(t *testing.T) {
+	for _, tt := range []struct{ readme, want string }{
+		{"# tool\n\nA tool for things.\n", "A tool for things."},
+		{"## Heading only\n", "Heading only"},
+		{"\n\nPlain first line\nsecond\n", "Plain first line"},
+		{"", ""},
+	} {
+		if got := readmeOpening(tt.readme); got != tt.want {
+			t.Errorf("readmeOpening(%q) = %q, want %q", tt.readme, got, tt.want)
+		}
+	}
+}
+
+func TestReadmeShingles(t *testing.T) {
+	template := func(name string) string {
+		return fmt.Sprintf("# %s\n\n%s is a simple project generated by repo-factory.\n\n## Install\n\nRun make install for %s.\n", name, name, name)
+	}
+	// The repo name is masked, so stamped templates match
+	alpha, beta := readmeShingles(template("alpha"), "alpha"), readmeShingles(template("beta"), "beta")
+	if got := jaccard(alpha, beta); got != 1 {
+		t.Errorf("stamped templates are %v similar, want 1", got)
+	}
+	other := readmeShingles("# gamma\n\nAn HTTP router with middleware chaining and zero allocations.\n", "gamma")
+	if got := jaccard(alpha, other); got > 0.1 {
+		t.Errorf("unrelated READMEs are %v similar", got)
+	}
+	// Only the opening lines count
+	opening := template("alpha") + "One.\nTwo.\nThree.\nFour.\nFive.\nSix.\n"
+	long := opening + strings.Repeat("Extra detail that differs line by line.\n", 20)
+	if got := jaccard(readmeShingles(opening, "alpha"), readmeShingles(long, "alpha")); got != 1 {
+		t.Errorf("appended sections changed the shingles: %v", got)
+	}
+	if got := readmeShingles("Hi", "x"); len(got) != 1 {
+		t.Errorf("a one-word README has %d shingles, want 1", len(got))
+	}
+	if got := jaccard(map[int]struct{}{}, map[int]struct{}{}); got != 0 {
+		t.Errorf("empty sets are %v similar, want 0", got)
+	}
+}
+
+// generatedFake serves n repos, repo00 the most starred, whose commits are
+// titled by message and whose READMEs are readme(name), counting README
+// requests
+func generatedFake(t *testing.T, n int, message func(repo string, i int) string, readme func(name string) string) (*fakeGitHub, *atomic.Int32) {
+	var repos []GitHubRepo
+	for i := range n {
+		repos = append(repos, GitHubRepo{Name: fmt.Sprintf("repo%02d", i), Language: "Go", Size: 500, StargazersCount: 100 - i, UpdatedAt: fakeNow.Add(-days(i + 1))})
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), repos...))
+	var readmes atomic.Int32
+	for _, repo := range repos {
+		f.route("/repos/octo/"+repo.Name+"/commits", func(w http.ResponseWriter, r *http.Request) {
+			commits := make([]GitHubCommit, 30)
+			for i := range commits {
+				commits[i].SHA = fmt.Sprintf("%040d", i)
+				commits[i].Commit.Message = message(repo.Name, i)
+			}
+			_ = json.NewEncoder(w).Encode(commits)
+		})
+		f.route("/repos/octo/"+repo.Name+"/readme", func(w http.ResponseWriter, r *http.Request) {
+			readmes.Add(1)
+			_, _ = w.Write([]byte(readme(repo.Name)))
+		})
+	}
+	return f, &readmes
+}
+
+func TestCheckGeneratedContent(t *testing.T) {
+	uniform := func(string, int) string { return "update" }
+	organic := func(repo string, i int) string { return fmt.Sprintf("Fix %s issue #%d", repo, i) }
+	template := func(name string) string {
+		return fmt.Sprintf("# %s\n\n%s is a simple project generated by repo-factory.\n\n## Install\n\nRun make install for %s.\n", name, name, name)
+	}
+	words := []string{"router", "parser", "scheduler", "cache", "logger", "queue", "proxy", "shell", "editor", "linter", "mailer", "tracer"}
+	distinct := func(name string) string {
+		var n int
+		_, _ = fmt.Sscanf(name, "repo%d", &n)
+		return fmt.Sprintf("# %s\n\nA %s written for %s workloads, tuned over %d releases.\n", name, words[n], words[(n+5)%len(words)], n*7)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		message    func(string, int) string
+		readme     func(string) string
+		thresholds *GeneratedContentThresholds
+		// same is set when every message is "update"; messages and readmes
+		// say which evidence is expected
+		same              bool
+		messages, readmes bool
+		templated         int
+	}{
+		{name: "uniform", message: uniform, readme: template, same: true, messages: true, readmes: true, templated: 12},
+		{name: "uniform messages", message: uniform, readme: distinct, same: true, messages: true},
+		{name: "templated READMEs", message: organic, readme: template, readmes: true, templated: 12},
+		{name: "organic", message: organic, readme: distinct},
+		// A stricter sample size needs more commits than the top repos give
+		{name: "too few commits", message: uniform, readme: distinct, same: true,
+			thresholds: &GeneratedContentThresholds{MessageShare: 0.8, MinCommits: 10000, ReadmeSimilarity: 0.9, ReadmeRepos: 10}},
+		{name: "repo count met exactly", message: organic, readme: template, readmes: true, templated: 12,
+			thresholds: &GeneratedContentThresholds{MessageShare: 0.8, MinCommits: 100, ReadmeSimilarity: 0.9, ReadmeRepos: 12}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := generatedFake(t, 12, tt.message, tt.readme)
+			opts := []Option{WithDeepChecks(true)}
+			if tt.thresholds != nil {
+				opts = append(opts, WithGeneratedContentThresholds(*tt.thresholds))
+			}
+			analysis, err := newFakeAnalyzer(f, opts...).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if metrics.SampledCommitMessages < 100 || metrics.SampledCommitMessages%30 != 0 || metrics.TemplatedReadmes != tt.templated {
+				t.Errorf("sampled %d messages, %d templated READMEs; want whole repos of 30 and %d", metrics.SampledCommitMessages, metrics.TemplatedReadmes, tt.templated)
+			}
+			uniqueness := 1.0
+			if tt.same {
+				uniqueness = 1 / float64(metrics.SampledCommitMessages)
+			}
+			if math.Abs(metrics.CommitMessageUniqueness-uniqueness) > 1e-9 {
+				t.Errorf("CommitMessageUniqueness = %v, want %v", metrics.CommitMessageUniqueness, uniqueness)
+			}
+
+			flag := finding(analysis, "GENERATED_CONTENT")
+			if !tt.messages && !tt.readmes {
+				if flag != nil {
+					t.Errorf("unexpected %+v", flag)
+				}
+				return
+			}
+			if flag == nil || flag.Severity != SeverityWarning {
+				t.Fatalf("GENERATED_CONTENT = %+v, want a warning", flag)
+			}
+			var want []string
+			if tt.messages {
+				share := 1 - 1/float64(metrics.SampledCommitMessages)
+				want = append(want, fmt.Sprintf("%.0f%% of %d sampled commit messages repeat an earlier one", share*100, metrics.SampledCommitMessages), `commit message "update"`)
+			}
+			if tt.readmes {
+				want = append(want, `12 repos share a near-identical README opening "repo00 is a simple project generated by repo-factory."`,
+					"README of octo/repo00", "README of octo/repo01", "README of octo/repo02", "README of octo/repo03", "README of octo/repo04")
+			}
+			if !slices.Equal(flag.Evidence, want) {
+				t.Errorf("evidence %q, want %q", flag.Evidence, want)
+			}
+		})
+	}
+}
+
+func TestCheckGeneratedContentNotDeep(t *testing.T) {
+	f, readmes := generatedFake(t, 12, func(string, int) string { return "update" }, func(name string) string { return "# " + name + "\n\nTemplate.\n" })
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if readmes.Load() != 0 || analysis.Metrics.SampledCommitMessages != 0 || finding(analysis, "GENERATED_CONTENT") != nil {
+		t.Errorf("fetched %d READMEs and sampled %d messages outside deep mode", readmes.Load(), analysis.Metrics.SampledCommitMessages)
+	}
+}
@@ -0,0 +1,87 @@
+package ebert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ghostLogin is the placeholder account GitHub attributes the commits,
+// issues and releases of deleted accounts to
+const ghostLogin = "ghost"
+
+// isGhost reports whether user is GitHub's stand-in for a deleted account
+func isGhost(user GitHubUser) bool {
+	return strings.EqualFold(user.Login, ghostLogin)
+}
+
+// unassessableAccount returns the decisive red flag for an account whose
+// activity says nothing about it: suspended, or the ghost placeholder
+func unassessableAccount(user *GitHubUser) (Finding, bool) {
+	switch {
+	case isGhost(*user):
+		return Finding{
+			Code:     "GHOST_ACCOUNT",
+			Severity: SeverityRedFlag,
+			Message:  "Account is ghost, GitHub's placeholder for deleted accounts - whoever owned this history no longer exists",
+		}, true
+	case user.SuspendedAt != nil:
+		return Finding{
+			Code:     "SUSPENDED_ACCOUNT",
+			Severity: SeverityRedFlag,
+			Message:  "Account is suspended",
+			Evidence: []string{"suspended " + user.SuspendedAt.Format("2006-01-02")},
+		}, true
+	}
+	return Finding{}, false
+}
+
+// unassessableAnalysis is the analysis of a suspended or ghost account:
+// maximum risk on the one decisive red flag, rather than a low-data score
+// that would read as reassuring. No other stage runs.
+func (a *Analyzer) unassessableAnalysis(r *analysisRun, finding Finding) *Analysis {
+	r.addFinding(finding)
+	findings := r.findings
+	indexFindings(findings)
+	redFlags, warnings, positives := splitFindings(findings)
+	maxRisk := 100.0
+
+	return &Analysis{
+		User:         *r.user,
+		Scores:       RiskScores{Identity: computed(maxRisk)},
+		OverallScore: maxRisk,
+		RiskLevel:    "high",
+		TrustIndex:   computed(maxRisk),
+		Confidence:   1,
+		Metrics:      r.acc.metrics,
+		Findings:     findings,
+		RedFlags:     redFlags,
+		Warnings:     warnings,
+		Positives:    positives,
+		Timestamp:    r.now,
+		Meta:         a.runMeta(r),
+	}
+}
+
+// checkGhostContributors notes the flagships whose contributors include
+// the ghost placeholder, meaning some past contributors deleted their
+// accounts and can't be vetted
+func (a *Analyzer) checkGhostContributors(r *analysisRun) {
+	var repos []string
+	for _, repo := range r.flagships() {
+		for _, contributor := range r.contributors[repo.FullName] {
+			if isGhost(contributor) {
+				repos = append(repos, fmt.Sprintf("%s (%d commits)", repo.FullName, contributor.Contributions))
+				break
+			}
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+	r.addFinding(Finding{
+		Code:     "DELETED_CONTRIBUTORS",
+		Severity: SeverityInfo,
+		Message:  "Some historical contributors' accounts were deleted, so their commits can't be tied to anyone",
+		Evidence: repos,
+	})
+}
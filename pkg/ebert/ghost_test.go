@@ -0,0 +1,97 @@
+package ebert
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUnassessableAccount(t *testing.T) {
+	suspended := time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name     string
+		user     GitHubUser
+		code     string
+		evidence []string
+	}{
+		{"ghost", GitHubUser{Login: "ghost"}, "GHOST_ACCOUNT", nil},
+		{"ghost in capitals", GitHubUser{Login: "Ghost"}, "GHOST_ACCOUNT", nil},
+		{"suspended", GitHubUser{Login: "octo", SuspendedAt: &suspended}, "SUSPENDED_ACCOUNT", []string{"suspended 2024-03-09"}},
+		// No repos and no plan fit a brand-new account as well
+		{"empty", GitHubUser{Login: "octo"}, "", nil},
+		{"named like the ghost", GitHubUser{Login: "ghost-writer"}, "", nil},
+	} {
+		flag, ok := unassessableAccount(&tt.user)
+		if ok != (tt.code != "") || flag.Code != tt.code || ok && (flag.Severity != SeverityRedFlag || !slices.Equal(flag.Evidence, tt.evidence)) {
+			t.Errorf("%s: unassessableAccount = %+v, %t; want %s with %q", tt.name, flag, ok, tt.code, tt.evidence)
+		}
+	}
+}
+
+func TestAnalyzeUnassessable(t *testing.T) {
+	suspended := fakeNow.Add(-days(20))
+	repo := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 400, UpdatedAt: fakeNow.Add(-days(2))}
+	for _, tt := range []struct {
+		name, login, code string
+	}{
+		{"ghost", "ghost", "GHOST_ACCOUNT"},
+		{"suspended", "octo", "SUSPENDED_ACCOUNT"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// An established-looking account, which would otherwise score well
+			account := newAccount(tt.login, days(3000), repo)
+			account.Events = pushFeed(tt.login, 30, 2)
+			if tt.code == "SUSPENDED_ACCOUNT" {
+				account.User.SuspendedAt = &suspended
+			}
+			f := newFakeGitHub(t, account)
+
+			analysis, err := newFakeAnalyzer(f).Analyze(tt.login)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if len(analysis.Findings) != 1 || analysis.Findings[0].Code != tt.code || len(analysis.RedFlags) != 1 || analysis.Findings[0].Index != IndexTrust {
+				t.Errorf("findings %+v, want %s alone", analysis.Findings, tt.code)
+			}
+			if analysis.OverallScore != 100 || analysis.RiskLevel != "high" || analysis.TrustIndex == nil || *analysis.TrustIndex != 100 {
+				t.Errorf("score %v, %s risk, trust %s; want the maximum", analysis.OverallScore, analysis.RiskLevel, formatScore(analysis.TrustIndex))
+			}
+			// Nothing past the user lookup runs
+			if analysis.Metrics.Repos != 0 || analysis.Metrics.EventsReceived != 0 || f.requests.Load() != 1 {
+				t.Errorf("%d repos and %d events read in %d requests, want only the user", analysis.Metrics.Repos, analysis.Metrics.EventsReceived, f.requests.Load())
+			}
+		})
+	}
+}
+
+func TestGhostContributors(t *testing.T) {
+	repo := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "Go", Size: 900, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(2))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), repo("tool", 300), repo("lib", 200), repo("app", 100)))
+	// A large share, but the ghost is never a co-maintainer
+	serveContributors(f, "octo", "tool", map[string]int{"octo": 60, "ghost": 40})
+	serveContributors(f, "octo", "lib", map[string]int{"octo": 50, "alice": 50})
+	serveContributors(f, "octo", "app", map[string]int{"octo": 90, "ghost": 3})
+
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(analysis.CoMaintainers) != 1 || analysis.CoMaintainers[0].Login != "alice" {
+		t.Errorf("CoMaintainers = %+v, want alice alone", analysis.CoMaintainers)
+	}
+	flag := finding(analysis, "DELETED_CONTRIBUTORS")
+	if want := []string{"octo/tool (40 commits)", "octo/app (3 commits)"}; flag == nil || flag.Severity != SeverityInfo || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("DELETED_CONTRIBUTORS = %+v, want %q", flag, want)
+	}
+
+	f = newFakeGitHub(t, newAccount("octo", days(3000), repo("tool", 300)))
+	serveContributors(f, "octo", "tool", map[string]int{"octo": 60, "alice": 40})
+	if analysis, err = newFakeAnalyzer(f).Analyze("octo"); err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if flag := finding(analysis, "DELETED_CONTRIBUTORS"); flag != nil {
+		t.Errorf("unexpected %+v", flag)
+	}
+}
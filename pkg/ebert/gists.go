@@ -0,0 +1,170 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// maxGists bounds the gist scan to the first page of gists
+	maxGists = 100
+
+	// maxGistFileBytes caps each gist file fetched in deep mode
+	maxGistFileBytes = 256 << 10
+
+	// maxGistFilesScanned bounds the content fetches in deep mode
+	maxGistFilesScanned = 20
+)
+
+// GitHubGist is a public gist
+type GitHubGist struct {
+	ID          string              `json:"id"`
+	HTMLURL     string              `json:"html_url"`
+	Description string              `json:"description"`
+	Public      bool                `json:"public"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	Files       map[string]GistFile `json:"files"`
+}
+
+// GistFile is one file in a gist
+type GistFile struct {
+	Filename string `json:"filename"`
+	Language string `json:"language"`
+	RawURL   string `json:"raw_url"`
+	Size     int    `json:"size"`
+}
+
+// secretNamePatterns match gist filenames or descriptions suggesting
+// credentials were pasted
+var secretNamePatterns = []string{
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", ".env", "credentials",
+	".pem", ".p12", ".pfx", ".npmrc", ".pypirc", ".netrc", "secret", "password",
+}
+
+// secretContentPatterns match common token formats in gist contents
+var secretContentPatterns = map[string]*regexp.Regexp{
+	"AWS access key":    regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	"GitHub token":      regexp.MustCompile(`\b(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b|\bgithub_pat_[A-Za-z0-9_]{22,}`),
+	"private key":       regexp.MustCompile(`-----BEGIN (RSA |EC |DSA |OPENSSH )?PRIVATE KEY-----`),
+	"Slack token":       regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	"npm token":         regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`),
+	"Google API key":    regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	"Stripe secret key": regexp.MustCompile(`\bsk_live_[0-9A-Za-z]{24,}\b`),
+}
+
+// GetGists fetches up to the first 100 public gists of the user
+func (c *GitHubClient) GetGists(ctx context.Context, username string) (_ []GitHubGist, _ int, err error) {
+	ctx, span := c.startSpan(ctx, "github.gists", "users/:user/gists")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/users/%s/gists?per_page=%d", c.BaseURL, url.PathEscape(username), maxGists))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return decodeElements[GitHubGist](data)
+}
+
+// fetchGists records gist activity and scans gists for leaked secrets
+func (a *Analyzer) fetchGists(ctx context.Context, r *analysisRun) {
+	if !a.opts.Gists {
+		return
+	}
+
+	gists, bad, err := a.client.GetGists(ctx, r.username)
+	r.decodeErrors += bad
+	if err != nil {
+		r.log.fellBack("gists", fmt.Errorf("failed to fetch gists: %w", err))
+		return
+	}
+	r.log.ok("gists")
+	if r.raw != nil {
+		r.raw.Gists = gists
+	}
+
+	metrics := &r.acc.metrics
+	metrics.PublicGists = len(gists)
+	var suspicious []string
+
+	for _, gist := range gists {
+		if gist.UpdatedAt.After(metrics.LastGistAt) {
+			metrics.LastGistAt = gist.UpdatedAt
+		}
+		if gistLooksSecret(gist) {
+			suspicious = append(suspicious, gist.HTMLURL)
+		}
+	}
+
+	if len(suspicious) > 0 {
+		r.addFinding(Finding{
+			Code:     "POSSIBLE_SECRET_GIST",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%d gist(s) named like credentials or keys", len(suspicious)),
+			Evidence: suspicious,
+		})
+	}
+
+	if a.opts.DeepChecks && a.opts.ExternalChecks {
+		a.scanGistContents(ctx, r, gists)
+	}
+}
+
+func gistLooksSecret(gist GitHubGist) bool {
+	texts := []string{strings.ToLower(gist.Description)}
+	for name := range gist.Files {
+		texts = append(texts, strings.ToLower(name))
+	}
+
+	for _, text := range texts {
+		for _, pattern := range secretNamePatterns {
+			if strings.Contains(text, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scanGistContents fetches a bounded number of gist files and looks for
+// common token formats
+func (a *Analyzer) scanGistContents(ctx context.Context, r *analysisRun, gists []GitHubGist) {
+	var evidence []string
+	scanned := 0
+
+	for _, gist := range gists {
+		for _, file := range gist.Files {
+			if scanned >= maxGistFilesScanned {
+				break
+			}
+			if file.RawURL == "" || file.Size > maxGistFileBytes {
+				continue
+			}
+			scanned++
+
+			content, err := a.client.getExternal(ctx, file.RawURL, maxGistFileBytes)
+			if err != nil {
+				continue
+			}
+			for kind, pattern := range secretContentPatterns {
+				if pattern.Match(content) {
+					evidence = append(evidence, fmt.Sprintf("%s in %s (%s)", kind, file.Filename, gist.HTMLURL))
+				}
+			}
+		}
+	}
+
+	if len(evidence) > 0 {
+		sortStrings(evidence)
+		r.addFinding(Finding{
+			Code:     "SECRET_IN_GIST",
+			Severity: SeverityRedFlag,
+			Message:  fmt.Sprintf("Public gists appear to contain %d credential(s)", len(evidence)),
+			Evidence: evidence,
+		})
+	}
+}
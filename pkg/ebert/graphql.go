@@ -0,0 +1,58 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrGraphQLRequiresToken is returned for GraphQL calls without a token,
+// which the GraphQL API always requires
+var ErrGraphQLRequiresToken = errors.New("GraphQL API requires a token")
+
+// graphQL runs query with variables and decodes the data member into out
+func (c *GitHubClient) graphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	if !c.authenticated() {
+		return ErrGraphQLRequiresToken
+	}
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	data, err := c.send(ctx, apiRequest{method: "POST", url: c.graphQLURL(), accept: defaultAccept, body: body})
+	if err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	if len(envelope.Errors) > 0 {
+		messages := make([]string, 0, len(envelope.Errors))
+		for _, e := range envelope.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("GraphQL error: %s", strings.Join(messages, "; "))
+	}
+
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// graphQLURL derives the GraphQL endpoint from the REST base URL; GitHub
+// Enterprise serves REST at /api/v3 and GraphQL at /api/graphql
+func (c *GitHubClient) graphQLURL() string {
+	if base, ok := strings.CutSuffix(c.BaseURL, "/v3"); ok {
+		return base + "/graphql"
+	}
+	return c.BaseURL + "/graphql"
+}
@@ -0,0 +1,49 @@
+package ebert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+func clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// computed marks a sub-score as computed
+func computed(score float64) *float64 {
+	return &score
+}
+
+// formatScore renders a sub-score, or "not computed" if it is missing
+func formatScore(score *float64) string {
+	if score == nil {
+		return "not computed"
+	}
+	return fmt.Sprintf("%.1f/100", *score)
+}
+
+// sortStrings sorts in place so map-derived evidence is deterministic
+func sortStrings(values []string) {
+	sort.Strings(values)
+}
+
+// formatThousands renders n with comma thousands separators
+func formatThousands(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits
+}
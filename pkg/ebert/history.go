@@ -0,0 +1,179 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// minHistoryRewriteStars is how many stars a repo needs before its
+	// rewritten history is worth flagging; below it are personal scratch
+	// repos
+	minHistoryRewriteStars = 5
+
+	// historyTagChecks is how many of a flagship's oldest listed releases
+	// deep mode checks are still reachable from the default branch
+	historyTagChecks = 2
+)
+
+// zeroSHA is the before of a push that created the ref
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// pushLink is where one push moved a ref from and to
+type pushLink struct {
+	at           time.Time
+	before, head string
+}
+
+// GitHubComparison is how two commits relate according to the compare
+// API: Status is "ahead", "behind", "identical" or "diverged"
+type GitHubComparison struct {
+	Status   string `json:"status"`
+	AheadBy  int    `json:"ahead_by"`
+	BehindBy int    `json:"behind_by"`
+}
+
+// CompareCommits compares base to head, either of which may be a branch,
+// tag or SHA
+func (c *GitHubClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (_ *GitHubComparison, err error) {
+	ctx, span := c.startSpan(ctx, "github.compare", "repos/:owner/:repo/compare/:basehead")
+	defer func() { endSpan(span, err) }()
+
+	basehead := url.PathEscape(base) + "..." + url.PathEscape(head)
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/compare/%s?per_page=1", c.BaseURL, owner, repo, basehead))
+	if err != nil {
+		return nil, err
+	}
+	var comparison GitHubComparison
+	if err := json.Unmarshal(data, &comparison); err != nil {
+		return nil, err
+	}
+	return &comparison, nil
+}
+
+// brokenPushChains finds the pushes whose before isn't the head the
+// previous push to the same ref left, meaning the ref was moved by a push
+// missing from the feed, typically a rewrite of its history. It returns
+// the time of each break by repo.
+func brokenPushChains(links map[string][]pushLink) map[string][]time.Time {
+	breaks := map[string][]time.Time{}
+	for key, pushes := range links {
+		repo, _, _ := strings.Cut(key, " ")
+		pushes = append([]pushLink(nil), pushes...)
+		sort.SliceStable(pushes, func(i, j int) bool { return pushes[i].at.Before(pushes[j].at) })
+		for i := 1; i < len(pushes); i++ {
+			previous, push := pushes[i-1], pushes[i]
+			if previous.head == "" || push.before == "" || push.before == zeroSHA || push.before == previous.head {
+				continue
+			}
+			breaks[repo] = append(breaks[repo], push.at)
+		}
+	}
+	return breaks
+}
+
+// checkHistoryRewrites warns when published history was rewritten on a
+// repo with enough stars for others to depend on it: pushes in the events
+// feed that don't continue from the previous push's head and, in deep
+// mode, old release tags no longer reachable from the default branch.
+func (a *Analyzer) checkHistoryRewrites(ctx context.Context, r *analysisRun) {
+	var evidence []string
+	rewrites := 0
+
+	if r.log.coverage().events {
+		breaks := brokenPushChains(r.acc.pushLinks)
+		repos := make([]string, 0, len(breaks))
+		for repo := range breaks {
+			if r.acc.repoStars[strings.ToLower(repo)] >= minHistoryRewriteStars {
+				repos = append(repos, repo)
+			}
+		}
+		sort.Strings(repos)
+		for _, repo := range repos {
+			times := breaks[repo]
+			sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+			rewrites += len(times)
+			first, last := times[0].Format("2006-01-02"), times[len(times)-1].Format("2006-01-02")
+			span := first
+			if last != first {
+				span = first + " to " + last
+			}
+			pushes := "pushes"
+			if len(times) == 1 {
+				pushes = "push"
+			}
+			evidence = append(evidence, fmt.Sprintf("%s: %d %s not continuing from the previous head, %s", repo, len(times), pushes, span))
+		}
+	}
+
+	if a.opts.DeepChecks {
+		for _, repo := range r.flagships() {
+			if ctx.Err() != nil {
+				break
+			}
+			if repo.Fork || repo.StargazersCount < minHistoryRewriteStars || repo.DefaultBranch == "" {
+				continue
+			}
+			for _, tag := range a.unreachableReleases(ctx, r, repo) {
+				rewrites++
+				evidence = append(evidence, fmt.Sprintf("%s: release %s is no longer reachable from %s", repo.FullName, tag, repo.DefaultBranch))
+			}
+		}
+	}
+
+	r.acc.metrics.HistoryRewrites = rewrites
+	if len(evidence) > 0 {
+		r.addFinding(Finding{
+			Code:     "HISTORY_REWRITE",
+			Severity: SeverityWarning,
+			Message:  "Published history was rewritten on starred repos - a hygiene problem and a way to cover tracks",
+			Evidence: evidence,
+		})
+	}
+}
+
+// unreachableReleases returns the tags of the repo's oldest listed
+// releases that the default branch no longer contains. The latest release
+// is never checked, as it may sit on a branch not merged back yet.
+func (a *Analyzer) unreachableReleases(ctx context.Context, r *analysisRun, repo GitHubRepo) []string {
+	if !r.contentsBudget.take() {
+		return nil
+	}
+	owner, name := repoOwnerAndName(repo, r.username)
+	releases, err := a.client.GetReleases(ctx, owner, name, maxReleasesListed)
+	if err != nil {
+		return nil
+	}
+
+	var tags []string
+	for _, release := range releases {
+		if !release.Draft && !release.Prerelease && release.TagName != "" {
+			tags = append(tags, release.TagName)
+		}
+	}
+	if len(tags) < 2 {
+		return nil
+	}
+	// Newest first; keep the oldest, leaving out the latest release
+	tags = tags[max(len(tags)-historyTagChecks, 1):]
+
+	var unreachable []string
+	for _, tag := range tags {
+		if !r.contentsBudget.take() {
+			break
+		}
+		comparison, err := a.client.CompareCommits(ctx, owner, name, tag, repo.DefaultBranch)
+		if err != nil {
+			continue
+		}
+		if comparison.Status == "diverged" || comparison.Status == "behind" {
+			unreachable = append(unreachable, tag)
+		}
+	}
+	return unreachable
+}
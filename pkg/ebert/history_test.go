@@ -0,0 +1,138 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBrokenPushChains(t *testing.T) {
+	at := func(hours int) time.Time { return fakeNow.Add(-time.Duration(hours) * time.Hour) }
+	breaks := brokenPushChains(map[string][]pushLink{
+		// Out of order, as the feed is newest first
+		"octo/tool refs/heads/main": {
+			{at: at(1), before: "c3", head: "c4"},
+			{at: at(3), before: "c1", head: "c2"},
+			{at: at(4), before: zeroSHA, head: "c1"},
+			{at: at(2), before: "c2", head: "c3"},
+		},
+		"octo/lib refs/heads/main": {
+			{at: at(5), before: "d1", head: "d2"},
+			{at: at(4), before: "x9", head: "d3"},
+			// Deleted and pushed again
+			{at: at(3), before: zeroSHA, head: "d4"},
+			// Payloads without the SHAs can't be followed
+			{at: at(2), before: "", head: "d5"},
+			{at: at(1), before: "y9", head: "d6"},
+		},
+		// Each ref is its own chain
+		"octo/lib refs/heads/dev": {
+			{at: at(6), before: "e1", head: "e2"},
+		},
+	})
+	if len(breaks) != 1 || !slices.Equal(breaks["octo/lib"], []time.Time{at(4), at(1)}) {
+		t.Errorf("breaks %v, want octo/lib at %s and %s", breaks, at(4), at(1))
+	}
+}
+
+// pushEvent is a push to repo's ref an hour ago times hours, moving it
+// from before to head
+func pushEvent(repo, ref string, hours int, before, head string) GitHubEvent {
+	event := GitHubEvent{
+		ID: fmt.Sprint(4000 + hours), Type: "PushEvent", CreatedAt: fakeNow.Add(-time.Duration(hours) * time.Hour),
+		Payload: json.RawMessage(fmt.Sprintf(`{"push_id":%d,"size":1,"distinct_size":1,"ref":%q,"before":%q,"head":%q}`, 400+hours, ref, before, head)),
+	}
+	event.Repo.Name = repo
+	return event
+}
+
+// historyFake serves octo's repos, a feed rewriting tool's main twice and
+// lib's and scratch's once, and tool's releases, v1 of which tool's main
+// no longer contains. It counts the comparisons.
+func historyFake(t *testing.T) (*fakeGitHub, *atomic.Int32) {
+	account := newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(1))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 10, UpdatedAt: fakeNow.Add(-days(2))},
+		// Too few stars to be worth flagging
+		GitHubRepo{Name: "scratch", Language: "Go", Size: 50, StargazersCount: 4, UpdatedAt: fakeNow.Add(-days(2))},
+	)
+	account.Events = []GitHubEvent{
+		pushEvent("octo/tool", "refs/heads/main", 24, "f2", "a5"),
+		pushEvent("octo/tool", "refs/heads/main", 48, "a3", "a4"),
+		pushEvent("octo/tool", "refs/heads/main", 72, "f1", "a3"),
+		pushEvent("octo/lib", "refs/heads/main", 80, "x1", "b2"),
+		pushEvent("octo/scratch", "refs/heads/main", 90, "x2", "s2"),
+		pushEvent("octo/lib", "refs/heads/main", 96, "b0", "b1"),
+		pushEvent("octo/scratch", "refs/heads/main", 100, "s0", "s1"),
+		pushEvent("octo/tool", "refs/heads/main", 110, "a1", "a2"),
+		pushEvent("octo/tool", "refs/heads/main", 120, zeroSHA, "a1"),
+	}
+	f := newFakeGitHub(t, account)
+
+	f.route("/repos/octo/tool/releases", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]GitHubRelease{{TagName: "v3"}, {TagName: "v3-rc1", Prerelease: true}, {TagName: "v2"}, {TagName: "v1"}})
+	})
+	var comparisons atomic.Int32
+	for tag, status := range map[string]string{"v2": "ahead", "v1": "diverged"} {
+		f.route("/repos/octo/tool/compare/"+tag+"...main", func(w http.ResponseWriter, r *http.Request) {
+			comparisons.Add(1)
+			_ = json.NewEncoder(w).Encode(GitHubComparison{Status: status})
+		})
+	}
+	return f, &comparisons
+}
+
+func TestCheckHistoryRewrites(t *testing.T) {
+	pushes := []string{
+		"octo/lib: 1 push not continuing from the previous head, 2024-05-29",
+		"octo/tool: 2 pushes not continuing from the previous head, 2024-05-29 to 2024-05-31",
+	}
+	for _, tt := range []struct {
+		name        string
+		deep        bool
+		rewrites    int
+		comparisons int32
+		evidence    []string
+	}{
+		{name: "events", rewrites: 3, evidence: pushes},
+		// The latest release is never compared
+		{name: "deep", deep: true, rewrites: 4, comparisons: 2, evidence: append(slices.Clone(pushes), "octo/tool: release v1 is no longer reachable from main")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, comparisons := historyFake(t)
+			analysis, err := newFakeAnalyzerToken(f, "token", WithDeepChecks(tt.deep)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if analysis.Metrics.HistoryRewrites != tt.rewrites || comparisons.Load() != tt.comparisons {
+				t.Errorf("%d rewrites in %d comparisons, want %d in %d", analysis.Metrics.HistoryRewrites, comparisons.Load(), tt.rewrites, tt.comparisons)
+			}
+			flag := finding(analysis, "HISTORY_REWRITE")
+			if flag == nil || flag.Severity != SeverityWarning || flag.Index != IndexTrust || !slices.Equal(flag.Evidence, tt.evidence) {
+				t.Errorf("HISTORY_REWRITE = %+v, want %q", flag, tt.evidence)
+			}
+		})
+	}
+}
+
+func TestCheckHistoryRewritesClean(t *testing.T) {
+	account := newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(1))})
+	account.Events = []GitHubEvent{
+		pushEvent("octo/tool", "refs/heads/main", 24, "a2", "a3"),
+		pushEvent("octo/tool", "refs/heads/main", 48, "a1", "a2"),
+		// A new branch starts a chain of its own
+		pushEvent("octo/tool", "refs/heads/dev", 60, zeroSHA, "d1"),
+		pushEvent("octo/tool", "refs/heads/main", 72, zeroSHA, "a1"),
+	}
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if flag := finding(analysis, "HISTORY_REWRITE"); flag != nil || analysis.Metrics.HistoryRewrites != 0 {
+		t.Errorf("unexpected %+v, %d rewrites", flag, analysis.Metrics.HistoryRewrites)
+	}
+}
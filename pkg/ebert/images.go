@@ -0,0 +1,227 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dockerHubURL is the Docker Hub API, checked without credentials
+var dockerHubURL = "https://hub.docker.com/v2"
+
+const (
+	// Registries recorded in PublishedImage.Registry
+	RegistryDockerHub = "docker.io"
+	RegistryGHCR      = "ghcr.io"
+
+	// maxImageSignatureChecks bounds the signature lookups per analysis
+	maxImageSignatureChecks = 5
+
+	// popularImagePulls is how many pulls make a Docker Hub image popular,
+	// and staleImagePulls how many make a stale one worth a warning. Docker
+	// Hub only reports all-time pulls.
+	popularImagePulls = 10000
+	staleImagePulls   = 1000
+
+	// activeImageWindow is how recently an image must have been pushed to
+	// count as maintained; staleImageAge is when its base image goes stale
+	activeImageWindow = 90 * 24 * time.Hour
+	staleImageAge     = 365 * 24 * time.Hour
+)
+
+// PublishedImage is a container image the user publishes. Pulls is only
+// known for Docker Hub; Signed is nil when the signature wasn't checked.
+type PublishedImage struct {
+	Registry   string    `json:"registry"`
+	Name       string    `json:"name"`
+	Pulls      int64     `json:"pulls,omitempty"`
+	LastPushed time.Time `json:"last_pushed,omitzero"`
+	Signed     *bool     `json:"signed,omitempty"`
+}
+
+// getDockerHubImages lists the repositories in a Docker Hub namespace
+func (c *GitHubClient) getDockerHubImages(ctx context.Context, namespace string) ([]PublishedImage, error) {
+	data, err := c.getExternal(ctx, fmt.Sprintf("%s/repositories/%s/?page_size=%d", dockerHubURL, url.PathEscape(namespace), maxPackageResults), maxSearchBytes)
+	if err != nil {
+		if errors.Is(err, errExternalNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var page struct {
+		Results []struct {
+			Name        string    `json:"name"`
+			PullCount   int64     `json:"pull_count"`
+			LastUpdated time.Time `json:"last_updated"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode Docker Hub repositories: %w", err)
+	}
+
+	images := make([]PublishedImage, 0, len(page.Results))
+	for _, result := range page.Results {
+		images = append(images, PublishedImage{
+			Registry:   RegistryDockerHub,
+			Name:       namespace + "/" + result.Name,
+			Pulls:      result.PullCount,
+			LastPushed: result.LastUpdated,
+		})
+	}
+	return images, nil
+}
+
+// dockerHubSigned reports whether a Docker Hub image has a cosign
+// signature tag
+func (c *GitHubClient) dockerHubSigned(ctx context.Context, image string) (bool, error) {
+	data, err := c.getExternal(ctx, fmt.Sprintf("%s/repositories/%s/tags?page_size=1&name=.sig", dockerHubURL, image), maxSearchBytes)
+	if err != nil {
+		return false, err
+	}
+
+	var page struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return false, fmt.Errorf("failed to decode Docker Hub tags: %w", err)
+	}
+	return page.Count > 0, nil
+}
+
+// ghcrSigned reports whether any recent version of a GHCR image carries a
+// cosign signature tag
+func (c *GitHubClient) ghcrSigned(ctx context.Context, owner, name string, org bool) (_ bool, err error) {
+	ctx, span := c.startSpan(ctx, "github.package_versions", "users/:username/packages/container/:name/versions")
+	defer func() { endSpan(span, err) }()
+
+	scope := "users"
+	if org {
+		scope = "orgs"
+	}
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(maxPackageResults))
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/%s/%s/packages/container/%s/versions?%s", c.BaseURL, scope, owner, url.PathEscape(name), query.Encode()))
+	if err != nil {
+		return false, err
+	}
+
+	var versions []struct {
+		Metadata struct {
+			Container struct {
+				Tags []string `json:"tags"`
+			} `json:"container"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return false, err
+	}
+	for _, version := range versions {
+		for _, tag := range version.Metadata.Container.Tags {
+			if strings.HasSuffix(tag, ".sig") {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkImages looks for Dockerfiles in the flagship repos and, when there
+// are any, for images published on Docker Hub under the login and on GHCR,
+// checking the most-pulled for cosign signatures. It runs in deep mode
+// with external checks and a token.
+func (a *Analyzer) checkImages(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !a.opts.ExternalChecks || !a.client.authenticated() {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	for _, repo := range r.checkable() {
+		if repo.Fork {
+			continue
+		}
+		if _, ok := hasEntry(a.directory(ctx, r, repo, ""), "Dockerfile", "Containerfile"); ok {
+			metrics.DockerfileRepos++
+		}
+	}
+	if metrics.DockerfileRepos == 0 && len(r.containerPackages) == 0 {
+		return
+	}
+
+	namespace := strings.ToLower(r.user.Login)
+	images, err := a.client.getDockerHubImages(ctx, namespace)
+	if err != nil {
+		r.log.fellBack("docker_hub", fmt.Errorf("failed to list Docker Hub images: %w", err))
+	} else {
+		r.log.ok("docker_hub")
+	}
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Pulls > images[j].Pulls })
+
+	for _, pkg := range r.containerPackages {
+		images = append(images, PublishedImage{Registry: RegistryGHCR, Name: namespace + "/" + pkg.Name, LastPushed: pkg.UpdatedAt})
+	}
+
+	org := r.user.Type == "Organization"
+	for i := range images[:min(len(images), maxImageSignatureChecks)] {
+		image := &images[i]
+		var signed bool
+		var err error
+		if image.Registry == RegistryGHCR {
+			signed, err = a.client.ghcrSigned(ctx, r.user.Login, strings.TrimPrefix(image.Name, namespace+"/"), org)
+		} else {
+			signed, err = a.client.dockerHubSigned(ctx, image.Name)
+		}
+		if err == nil {
+			image.Signed = &signed
+		}
+	}
+
+	metrics.PublishedImages = len(images)
+	metrics.Images = images
+
+	var popular, stale, signed []string
+	for _, image := range images {
+		age := r.now.Sub(image.LastPushed)
+		switch {
+		case image.Pulls >= popularImagePulls && age <= activeImageWindow:
+			popular = append(popular, fmt.Sprintf("%s (%s pulls, pushed %s)", image.Name, formatThousands(image.Pulls), image.LastPushed.Format("2006-01-02")))
+		case image.Pulls >= staleImagePulls && !image.LastPushed.IsZero() && age > staleImageAge:
+			stale = append(stale, fmt.Sprintf("%s (%s pulls, last pushed %s)", image.Name, formatThousands(image.Pulls), image.LastPushed.Format("2006-01-02")))
+		}
+		if image.Signed != nil && *image.Signed {
+			signed = append(signed, image.Registry+"/"+image.Name)
+		}
+	}
+
+	if len(popular) > 0 {
+		r.addFinding(Finding{
+			Code:     "POPULAR_IMAGES",
+			Severity: SeverityPositive,
+			Message:  "Publishes popular, actively pushed container images",
+			Evidence: popular,
+		})
+	}
+	if len(stale) > 0 {
+		r.addFinding(Finding{
+			Code:     "STALE_IMAGES",
+			Severity: SeverityWarning,
+			Message:  "Container images still pulled but not rebuilt in over a year - base images are likely unpatched",
+			Evidence: stale,
+		})
+	}
+	if len(signed) > 0 {
+		r.addFinding(Finding{
+			Code:     "SIGNED_IMAGES",
+			Severity: SeverityPositive,
+			Message:  "Container images are signed with cosign",
+			Evidence: signed,
+		})
+	}
+}
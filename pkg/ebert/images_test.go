@@ -0,0 +1,170 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// imagesFake serves octo's app repo with a Dockerfile, three Docker Hub
+// images, one of them signed, and a signed image on GHCR
+func imagesFake(t *testing.T) (*fakeGitHub, *atomic.Int32) {
+	unspaced(t)
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "app", Language: "Go", Size: 900, StargazersCount: 80, UpdatedAt: fakeNow.Add(-days(3))}))
+	serveDirectory(f, "app", "", "Dockerfile", "main.go")
+	f.route("/users/octo/packages", func(w http.ResponseWriter, r *http.Request) {
+		var packages []GitHubPackage
+		if r.URL.Query().Get("package_type") == PackageTypeContainer {
+			packages = []GitHubPackage{{Name: "tool", VersionCount: 6, UpdatedAt: fakeNow.Add(-days(20))}}
+		}
+		_ = json.NewEncoder(w).Encode(packages)
+	})
+	f.route("/users/octo/packages/container/tool/versions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"metadata":{"container":{"tags":["latest"]}}},{"metadata":{"container":{"tags":["sha256-abc.sig"]}}}]`))
+	})
+
+	var listings atomic.Int32
+	f.host("hub.docker.com", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/repositories/octo/":
+			listings.Add(1)
+			_, _ = fmt.Fprintf(w, `{"results":[
+				{"name":"tiny","pull_count":10,"last_updated":%q},
+				{"name":"old","pull_count":5000,"last_updated":%q},
+				{"name":"app","pull_count":50000,"last_updated":%q}]}`,
+				fakeNow.Add(-days(5)).Format(time.RFC3339), fakeNow.AddDate(-2, 0, 0).Format(time.RFC3339), fakeNow.Add(-days(10)).Format(time.RFC3339))
+		case "/v2/repositories/octo/app/tags":
+			_, _ = w.Write([]byte(`{"count":1}`))
+		case "/v2/repositories/octo/old/tags", "/v2/repositories/octo/tiny/tags":
+			_, _ = w.Write([]byte(`{"count":0}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return f, &listings
+}
+
+func TestCheckImages(t *testing.T) {
+	f, _ := imagesFake(t)
+	detailed, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).AnalyzeDetailed("octo")
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed: %v", err)
+	}
+	metrics := detailed.Metrics
+	if metrics.DockerfileRepos != 1 || metrics.PublishedImages != 4 {
+		t.Errorf("%d Dockerfile repos, %d images; want 1 and 4", metrics.DockerfileRepos, metrics.PublishedImages)
+	}
+
+	// Docker Hub images come most-pulled first, then GHCR's
+	var names []string
+	for _, image := range metrics.Images {
+		names = append(names, image.Registry+"/"+image.Name)
+		if image.Signed == nil {
+			t.Errorf("%s wasn't checked for a signature", image.Name)
+		}
+	}
+	if want := []string{"docker.io/octo/app", "docker.io/octo/old", "docker.io/octo/tiny", "ghcr.io/octo/tool"}; !slices.Equal(names, want) {
+		t.Errorf("images = %q, want %q", names, want)
+	}
+
+	for _, tt := range []struct {
+		code     string
+		severity Severity
+		evidence []string
+	}{
+		{"POPULAR_IMAGES", SeverityPositive, []string{"octo/app (50,000 pulls, pushed 2024-05-22)"}},
+		{"STALE_IMAGES", SeverityWarning, []string{"octo/old (5,000 pulls, last pushed 2022-06-01)"}},
+		{"SIGNED_IMAGES", SeverityPositive, []string{"docker.io/octo/app", "ghcr.io/octo/tool"}},
+	} {
+		flag := finding(detailed.Analysis, tt.code)
+		if flag == nil || flag.Severity != tt.severity || !slices.Equal(flag.Evidence, tt.evidence) {
+			t.Errorf("%s = %+v, want %q", tt.code, flag, tt.evidence)
+		}
+	}
+	if flag := finding(detailed.Analysis, "STALE_IMAGES"); flag != nil && flag.Remediation == "" {
+		t.Errorf("STALE_IMAGES has no remediation")
+	}
+	if i := slices.IndexFunc(detailed.DataSources, func(s DataSource) bool { return s.Name == "docker_hub" }); i < 0 || detailed.DataSources[i].Status != SourceOK {
+		t.Errorf("sources = %+v, want docker_hub ok", detailed.DataSources)
+	}
+}
+
+func TestCheckImagesGated(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		token string
+		opts  []Option
+	}{
+		{"not deep", "t0ken", nil},
+		{"no external checks", "t0ken", []Option{WithDeepChecks(true), WithExternalChecks(false)}},
+		{"anonymous", "", []Option{WithDeepChecks(true)}},
+	} {
+		f, listings := imagesFake(t)
+		analysis, err := newFakeAnalyzerToken(f, tt.token, tt.opts...).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		if listings.Load() != 0 || analysis.Metrics.PublishedImages != 0 || finding(analysis, "POPULAR_IMAGES") != nil {
+			t.Errorf("%s: listed Docker Hub %d times, found %d images", tt.name, listings.Load(), analysis.Metrics.PublishedImages)
+		}
+	}
+}
+
+func TestCheckImagesNothingToBuild(t *testing.T) {
+	f, listings := imagesFake(t)
+	// No Dockerfile and nothing on GHCR
+	serveDirectory(f, "app", "", "main.go")
+	f.route("/users/octo/packages", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	analysis, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if listings.Load() != 0 || analysis.Metrics.DockerfileRepos != 0 || analysis.Metrics.PublishedImages != 0 {
+		t.Errorf("listed Docker Hub %d times for an account with nothing to build", listings.Load())
+	}
+}
+
+func TestCheckImagesNoNamespace(t *testing.T) {
+	f, _ := imagesFake(t)
+	// The login isn't a Docker Hub namespace
+	f.host("hub.docker.com", http.NotFound)
+	f.route("/users/octo/packages", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	detailed, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).AnalyzeDetailed("octo")
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed: %v", err)
+	}
+	if detailed.Metrics.DockerfileRepos != 1 || detailed.Metrics.PublishedImages != 0 {
+		t.Errorf("%d Dockerfile repos, %d images; want 1 and none", detailed.Metrics.DockerfileRepos, detailed.Metrics.PublishedImages)
+	}
+	// A missing namespace is an answer, not a failure
+	if i := slices.IndexFunc(detailed.DataSources, func(s DataSource) bool { return s.Name == "docker_hub" }); i < 0 || detailed.DataSources[i].Status != SourceOK {
+		t.Errorf("sources = %+v, want docker_hub ok", detailed.DataSources)
+	}
+}
+
+func TestCheckImagesDockerHubDown(t *testing.T) {
+	f, _ := imagesFake(t)
+	f.host("hub.docker.com", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	detailed, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).AnalyzeDetailed("octo")
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed: %v", err)
+	}
+	if i := slices.IndexFunc(detailed.DataSources, func(s DataSource) bool { return s.Name == "docker_hub" }); i < 0 || detailed.DataSources[i].Status != SourceFallback {
+		t.Errorf("sources = %+v, want docker_hub fallen back", detailed.DataSources)
+	}
+	// GHCR is still checked
+	signed := finding(detailed.Analysis, "SIGNED_IMAGES")
+	if want := []string{"ghcr.io/octo/tool"}; detailed.Metrics.PublishedImages != 1 || signed == nil || !slices.Equal(signed.Evidence, want) {
+		t.Errorf("images = %+v, SIGNED_IMAGES = %+v; want only GHCR's, signed", detailed.Metrics.Images, signed)
+	}
+}
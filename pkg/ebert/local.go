@@ -0,0 +1,445 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// OfflineNote marks a local analysis, which has no registry or
+	// profile data behind it
+	OfflineNote = "offline — no registry or profile data"
+
+	// maxLocalAuthors bounds the authors listed in a RepoAnalysis
+	maxLocalAuthors = 100
+
+	// maxLocalTags bounds the tags listed in a RepoAnalysis
+	maxLocalTags = 20
+
+	// rewrittenCommitLag is how long after authoring a commit must have been
+	// committed to count as rebased or otherwise rewritten
+	rewrittenCommitLag = 30 * 24 * time.Hour
+
+	// inactiveRepoAge is how old the newest commit can be before the
+	// checkout looks unmaintained
+	inactiveRepoAge = 365 * 24 * time.Hour
+
+	// maxAuthorLookups bounds the commit searches ResolveLocalAuthors makes
+	maxAuthorLookups = 10
+)
+
+var (
+	// noreplyEmailPattern matches GitHub's private commit emails, which
+	// embed the account login
+	noreplyEmailPattern = regexp.MustCompile(`^(?:\d+\+)?([A-Za-z0-9-]+)@users\.noreply\.github\.com$`)
+
+	ciConfigFiles = []string{".gitlab-ci.yml", ".travis.yml", "Jenkinsfile", "azure-pipelines.yml", "bitbucket-pipelines.yml", ".circleci/config.yml", ".buildkite/pipeline.yml"}
+)
+
+// LocalAuthor is a commit author identity found in a local checkout. Login
+// is known when the email is a GitHub noreply address or was resolved
+// online.
+type LocalAuthor struct {
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Login       string    `json:"login,omitempty"`
+	Commits     int       `json:"commits"`
+	FirstCommit time.Time `json:"first_commit"`
+	LastCommit  time.Time `json:"last_commit"`
+}
+
+// LocalLockfile is a lockfile in a local checkout and when it last changed
+type LocalLockfile struct {
+	Path        string    `json:"path"`
+	LastChanged time.Time `json:"last_changed,omitzero"`
+}
+
+// RepoAnalysis is the report for a local git checkout, built without API
+// access from the commit log and the working tree
+type RepoAnalysis struct {
+	Path    string `json:"path"`
+	Offline bool   `json:"offline"`
+	Note    string `json:"note"`
+
+	// Branch is empty on a detached HEAD; Shallow means the history and
+	// every count derived from it are truncated
+	Head         string `json:"head"`
+	Branch       string `json:"branch,omitempty"`
+	DetachedHead bool   `json:"detached_head,omitempty"`
+	Shallow      bool   `json:"shallow,omitempty"`
+
+	Commits         int       `json:"commits"`
+	SignedCommits   int       `json:"signed_commits"`
+	CommitsPerMonth float64   `json:"commits_per_month"`
+	FirstCommit     time.Time `json:"first_commit,omitzero"`
+	LastCommit      time.Time `json:"last_commit,omitzero"`
+
+	// RewrittenCommits were committed long after they were authored, as a
+	// rebase or filter leaves them; ForcedUpdates counts reflog entries
+	// where a fetched ref had been force-pushed
+	RewrittenCommits int `json:"rewritten_commits"`
+	ForcedUpdates    int `json:"forced_updates"`
+
+	AuthorCount int           `json:"author_count"`
+	Authors     []LocalAuthor `json:"authors"`
+	TagCount    int           `json:"tag_count"`
+	Tags        []LocalTag    `json:"tags,omitempty"`
+
+	License        string          `json:"license,omitempty"`
+	SecurityPolicy string          `json:"security_policy,omitempty"`
+	CIConfigs      []string        `json:"ci_configs,omitempty"`
+	Lockfiles      []LocalLockfile `json:"lockfiles,omitempty"`
+
+	Findings  []Finding `json:"findings"`
+	RedFlags  []string  `json:"red_flags"`
+	Warnings  []string  `json:"warnings"`
+	Positives []string  `json:"positives"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+}
+
+// AnalyzeLocal analyzes a local git checkout without contacting GitHub
+func (a *Analyzer) AnalyzeLocal(path string) (*RepoAnalysis, error) {
+	return a.AnalyzeLocalContext(context.Background(), path)
+}
+
+// AnalyzeLocalContext is AnalyzeLocal with a caller-supplied context
+func (a *Analyzer) AnalyzeLocalContext(ctx context.Context, path string) (*RepoAnalysis, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	repo := gitRepo{dir: dir}
+
+	head, err := repo.run(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s as a git repository: %w", path, err)
+	}
+	commits, err := repo.commits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the commit log: %w", err)
+	}
+	tags, err := repo.tags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	now := a.opts.now()
+	report := &RepoAnalysis{
+		Path:          dir,
+		Offline:       true,
+		Note:          OfflineNote,
+		Head:          strings.TrimSpace(head),
+		Branch:        repo.branch(ctx),
+		Shallow:       repo.shallow(ctx),
+		ForcedUpdates: repo.forcedUpdates(ctx),
+		TagCount:      len(tags),
+		Tags:          tags[:min(len(tags), maxLocalTags)],
+		Timestamp:     now,
+	}
+	report.DetachedHead = report.Branch == ""
+	report.addCommits(commits)
+	report.checkTree(ctx, repo)
+	report.generateFindings(now)
+
+	sortFindings(report.Findings)
+	report.RedFlags, report.Warnings, report.Positives = splitFindings(report.Findings)
+	a.opts.Logger.Debug("analyzed local checkout", "path", dir, "commits", report.Commits, "authors", report.AuthorCount)
+	return report, nil
+}
+
+// addCommits folds the log into commit, signing and author totals
+func (r *RepoAnalysis) addCommits(commits []localCommit) {
+	authors := map[string]*LocalAuthor{}
+	for _, commit := range commits {
+		r.Commits++
+		if commit.signed() {
+			r.SignedCommits++
+		}
+		if commit.committed.Sub(commit.authored) > rewrittenCommitLag {
+			r.RewrittenCommits++
+		}
+		if r.LastCommit.IsZero() || commit.committed.After(r.LastCommit) {
+			r.LastCommit = commit.committed
+		}
+		if r.FirstCommit.IsZero() || commit.authored.Before(r.FirstCommit) {
+			r.FirstCommit = commit.authored
+		}
+
+		author, ok := authors[commit.email]
+		if !ok {
+			author = &LocalAuthor{Name: commit.name, Email: commit.email, Login: noreplyLogin(commit.email)}
+			authors[commit.email] = author
+		}
+		author.Commits++
+		if author.LastCommit.IsZero() || commit.authored.After(author.LastCommit) {
+			author.LastCommit = commit.authored
+		}
+		if author.FirstCommit.IsZero() || commit.authored.Before(author.FirstCommit) {
+			author.FirstCommit = commit.authored
+		}
+	}
+
+	for _, author := range authors {
+		r.Authors = append(r.Authors, *author)
+	}
+	sort.Slice(r.Authors, func(i, j int) bool {
+		if r.Authors[i].Commits != r.Authors[j].Commits {
+			return r.Authors[i].Commits > r.Authors[j].Commits
+		}
+		return r.Authors[i].Email < r.Authors[j].Email
+	})
+	r.AuthorCount = len(r.Authors)
+	r.Authors = r.Authors[:min(len(r.Authors), maxLocalAuthors)]
+
+	if !r.FirstCommit.IsZero() {
+		days := int(r.LastCommit.Sub(r.FirstCommit).Hours() / 24)
+		r.CommitsPerMonth = perMonth(r.Commits, days)
+	}
+}
+
+// checkTree looks in the working tree for a license, a security policy,
+// CI configuration and lockfiles
+func (r *RepoAnalysis) checkTree(ctx context.Context, repo gitRepo) {
+	root, _ := os.ReadDir(repo.dir)
+	for _, entry := range root {
+		name := strings.ToLower(entry.Name())
+		if !entry.IsDir() && (strings.HasPrefix(name, "license") || strings.HasPrefix(name, "licence") || strings.HasPrefix(name, "copying")) {
+			r.License = entry.Name()
+			break
+		}
+	}
+
+	for _, dir := range append([]string{""}, securityPolicyDirs...) {
+		for _, name := range securityPolicyNames {
+			if fileExists(filepath.Join(repo.dir, dir, name)) {
+				r.SecurityPolicy = filepath.ToSlash(filepath.Join(dir, name))
+				break
+			}
+		}
+		if r.SecurityPolicy != "" {
+			break
+		}
+	}
+
+	workflows, _ := filepath.Glob(filepath.Join(repo.dir, ".github", "workflows", "*.y*ml"))
+	for _, workflow := range workflows {
+		rel, _ := filepath.Rel(repo.dir, workflow)
+		r.CIConfigs = append(r.CIConfigs, filepath.ToSlash(rel))
+	}
+	for _, name := range ciConfigFiles {
+		if fileExists(filepath.Join(repo.dir, name)) {
+			r.CIConfigs = append(r.CIConfigs, name)
+		}
+	}
+
+	for _, name := range lockfileNames {
+		if fileExists(filepath.Join(repo.dir, name)) {
+			r.Lockfiles = append(r.Lockfiles, LocalLockfile{Path: name, LastChanged: repo.lastChanged(ctx, name)})
+		}
+	}
+}
+
+func (r *RepoAnalysis) generateFindings(now time.Time) {
+	add := func(code string, severity Severity, message string, evidence ...string) {
+		r.Findings = append(r.Findings, Finding{Code: code, Severity: severity, Message: message, Evidence: evidence})
+	}
+
+	add("OFFLINE_ANALYSIS", SeverityInfo, "Offline analysis of a local checkout - no registry or profile data")
+
+	if r.Shallow {
+		add("SHALLOW_CLONE", SeverityWarning, "Shallow clone - commit history, authors and cadence are truncated; run git fetch --unshallow for a full report")
+	}
+	if r.DetachedHead {
+		add("DETACHED_HEAD", SeverityInfo, fmt.Sprintf("Detached HEAD at %s - only history reachable from it was analyzed", shortHash(r.Head)))
+	}
+
+	if r.ForcedUpdates > 0 || (r.Commits > 0 && r.RewrittenCommits*4 > r.Commits) {
+		add("REWRITTEN_HISTORY", SeverityInfo, "History appears to have been rewritten",
+			fmt.Sprintf("%d of %d commits committed over 30 days after they were authored", r.RewrittenCommits, r.Commits),
+			fmt.Sprintf("%d forced updates in the reflog", r.ForcedUpdates))
+	}
+
+	if !r.LastCommit.IsZero() && now.Sub(r.LastCommit) > inactiveRepoAge {
+		add("INACTIVE_REPO", SeverityWarning, fmt.Sprintf("No commits since %s", r.LastCommit.Format("2006-01-02")))
+	}
+	if r.AuthorCount == 1 && !r.Shallow {
+		add("SINGLE_AUTHOR", SeverityWarning, "Every commit has the same author - no co-maintainer to review changes")
+	}
+
+	if r.Commits > 0 && r.SignedCommits*2 > r.Commits {
+		add("SIGNED_COMMITS", SeverityPositive, fmt.Sprintf("Most commits are signed (%d/%d)", r.SignedCommits, r.Commits))
+	}
+	if r.TagCount == 0 {
+		add("NO_TAGS", SeverityInfo, "No tags - releases can't be tied to commits")
+	}
+
+	if r.License == "" {
+		add("NO_LICENSE", SeverityWarning, "No license file in the repository root")
+	}
+	if r.SecurityPolicy != "" {
+		add("SECURITY_POLICY", SeverityPositive, fmt.Sprintf("Has a security policy (%s)", r.SecurityPolicy))
+	} else {
+		add("NO_SECURITY_POLICY", SeverityWarning, "No security policy")
+	}
+	if len(r.CIConfigs) == 0 {
+		add("NO_CI", SeverityWarning, "No CI configuration found")
+	}
+
+	var stale []string
+	for _, lockfile := range r.Lockfiles {
+		if !lockfile.LastChanged.IsZero() && now.Sub(lockfile.LastChanged) > staleLockfileAge {
+			stale = append(stale, fmt.Sprintf("%s (last changed %s)", lockfile.Path, lockfile.LastChanged.Format("2006-01-02")))
+		}
+	}
+	if len(stale) > 0 {
+		add("STALE_LOCKFILES", SeverityWarning, "Lockfiles untouched for over a year - dependencies may be unpatched", stale...)
+	}
+}
+
+// ResolveLocalAuthors maps the emails of the most active authors without a
+// known login to GitHub accounts via commit search, once the machine is
+// online. It needs a token and stops at the first failed search.
+func (a *Analyzer) ResolveLocalAuthors(ctx context.Context, report *RepoAnalysis) error {
+	if !a.client.authenticated() {
+		return fmt.Errorf("resolving authors needs a GitHub token")
+	}
+
+	lookups := 0
+	for i := range report.Authors {
+		author := &report.Authors[i]
+		if author.Login != "" || lookups == maxAuthorLookups {
+			continue
+		}
+		lookups++
+
+		login, err := a.client.SearchCommitAuthor(ctx, author.Email)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", author.Email, err)
+		}
+		author.Login = login
+	}
+	return nil
+}
+
+// SearchCommitAuthor returns the login GitHub links to commits by email,
+// or "" when none are linked
+func (c *GitHubClient) SearchCommitAuthor(ctx context.Context, email string) (_ string, err error) {
+	ctx, span := c.startSpan(ctx, "github.search_commits", "search/commits")
+	defer func() { endSpan(span, err) }()
+
+	if err := c.shared().search.wait(ctx); err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("q", "author-email:"+email)
+	query.Set("per_page", "1")
+
+	data, err := c.getAccept(ctx, c.BaseURL+"/search/commits?"+query.Encode(), commitSearchAccept)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Items []struct {
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", err
+	}
+	if len(result.Items) == 0 || result.Items[0].Author == nil {
+		return "", nil
+	}
+	return result.Items[0].Author.Login, nil
+}
+
+// noreplyLogin extracts the login from a GitHub noreply email
+func noreplyLogin(email string) string {
+	if match := noreplyEmailPattern.FindStringSubmatch(email); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 12)]
+}
+
+// PrintRepoAnalysis prints a local checkout report to stdout
+func PrintRepoAnalysis(report *RepoAnalysis) {
+	FprintRepoAnalysis(os.Stdout, report)
+}
+
+// FprintRepoAnalysis writes a local checkout report to w
+func FprintRepoAnalysis(w io.Writer, report *RepoAnalysis) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+	fmt.Fprintln(w, "  LOCAL REPOSITORY ANALYSIS")
+	fmt.Fprintf(w, "  %s\n", strings.ToUpper(report.Note))
+	fmt.Fprintln(w, strings.Repeat("=", 80))
+
+	fmt.Fprintf(w, "\n📁 Repository: %s\n", report.Path)
+	if report.DetachedHead {
+		fmt.Fprintf(w, "   HEAD: %s (detached)\n", shortHash(report.Head))
+	} else {
+		fmt.Fprintf(w, "   HEAD: %s (%s)\n", shortHash(report.Head), report.Branch)
+	}
+
+	fmt.Fprintln(w, "\n📊 HISTORY")
+	if report.Shallow {
+		fmt.Fprintf(w, "   Commits:            %d (shallow clone)\n", report.Commits)
+	} else {
+		fmt.Fprintf(w, "   Commits:            %d\n", report.Commits)
+	}
+	fmt.Fprintf(w, "   Signed Commits:     %d\n", report.SignedCommits)
+	fmt.Fprintf(w, "   Cadence:            %.1f commits/month\n", report.CommitsPerMonth)
+	if !report.LastCommit.IsZero() {
+		fmt.Fprintf(w, "   Span:               %s to %s\n", report.FirstCommit.Format("2006-01-02"), report.LastCommit.Format("2006-01-02"))
+	}
+	fmt.Fprintf(w, "   Tags:               %d\n", report.TagCount)
+	fmt.Fprintf(w, "   Authors:            %d\n", report.AuthorCount)
+	for _, author := range report.Authors[:min(len(report.Authors), 5)] {
+		identity := author.Email
+		if author.Login != "" {
+			identity += " @" + author.Login
+		}
+		fmt.Fprintf(w, "   • %s <%s>: %d commits\n", author.Name, identity, author.Commits)
+	}
+
+	if len(report.RedFlags) > 0 {
+		fmt.Fprintln(w, "\n🚨 RED FLAGS")
+		for _, flag := range report.RedFlags {
+			fmt.Fprintf(w, "   • %s\n", flag)
+		}
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Fprintln(w, "\n⚠️  WARNINGS")
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(w, "   • %s\n", warning)
+		}
+	}
+	if len(report.Positives) > 0 {
+		fmt.Fprintln(w, "\n✅ POSITIVE SIGNALS")
+		for _, positive := range report.Positives {
+			fmt.Fprintf(w, "   • %s\n", positive)
+		}
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+}
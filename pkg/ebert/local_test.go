@@ -0,0 +1,253 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gitFixture is a scratch git repository built commit by commit
+type gitFixture struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitFixture(t *testing.T) *gitFixture {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	g := &gitFixture{t: t, dir: t.TempDir()}
+	g.git(nil, "init", "-q", "-b", "main")
+	return g
+}
+
+// git runs a git command in the fixture, isolated from the user's config
+func (g *gitFixture) git(env []string, args ...string) string {
+	g.t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", g.dir}, args...)...)
+	cmd.Env = append(os.Environ(), append([]string{"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1"}, env...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		g.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// commit writes files and commits them as the author, authored and
+// committed at the given times
+func (g *gitFixture) commit(name, email string, authored, committed time.Time, files ...string) {
+	g.t.Helper()
+	for _, file := range files {
+		path := filepath.Join(g.dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			g.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file+" "+committed.String()), 0o644); err != nil {
+			g.t.Fatal(err)
+		}
+	}
+	g.git(nil, "add", "-A")
+	g.git([]string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email, "GIT_AUTHOR_DATE=" + authored.Format(time.RFC3339),
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email, "GIT_COMMITTER_DATE=" + committed.Format(time.RFC3339),
+	}, "commit", "-q", "--allow-empty", "-m", "change")
+}
+
+// in2023 is a day of 2023 at noon UTC
+func in2023(month time.Month, day int) time.Time {
+	return time.Date(2023, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+// localAnalyzer analyzes checkouts as of fakeNow
+func localAnalyzer() *Analyzer {
+	return NewAnalyzer("", WithClock(func() time.Time { return fakeNow }))
+}
+
+func TestAnalyzeLocal(t *testing.T) {
+	g := newGitFixture(t)
+	g.commit("Alice", "Alice@Example.com", in2023(1, 5), in2023(1, 5), "LICENSE", "go.mod", "go.sum")
+	g.commit("Alice", "alice@example.com", in2023(3, 1), in2023(3, 1), ".github/SECURITY.md", ".github/workflows/ci.yml")
+	// Rebased months after it was written
+	g.commit("Bob", "1234+bob@users.noreply.github.com", in2023(2, 1), in2023(6, 9), "main.go")
+	g.commit("Alice", "alice@example.com", in2023(6, 10), in2023(6, 10), "README.md")
+	g.git(nil, "tag", "v1.0.0")
+
+	report, err := localAnalyzer().AnalyzeLocal(g.dir)
+	if err != nil {
+		t.Fatalf("AnalyzeLocal: %v", err)
+	}
+	if !report.Offline || report.Note != OfflineNote || report.Branch != "main" || report.DetachedHead || report.Shallow {
+		t.Errorf("report = offline %t (%q), branch %q, detached %t, shallow %t", report.Offline, report.Note, report.Branch, report.DetachedHead, report.Shallow)
+	}
+	if report.Commits != 4 || report.SignedCommits != 0 || report.RewrittenCommits != 1 {
+		t.Errorf("%d commits, %d signed, %d rewritten; want 4, 0 and 1", report.Commits, report.SignedCommits, report.RewrittenCommits)
+	}
+	if !report.FirstCommit.Equal(in2023(1, 5)) || !report.LastCommit.Equal(in2023(6, 10)) {
+		t.Errorf("history spans %s to %s", report.FirstCommit, report.LastCommit)
+	}
+
+	// Emails are matched case-insensitively, and noreply ones name the login
+	want := []LocalAuthor{
+		{Name: "Alice", Email: "alice@example.com", Commits: 3, FirstCommit: in2023(1, 5), LastCommit: in2023(6, 10)},
+		{Name: "Bob", Email: "1234+bob@users.noreply.github.com", Login: "bob", Commits: 1, FirstCommit: in2023(2, 1), LastCommit: in2023(2, 1)},
+	}
+	if report.AuthorCount != 2 || !slices.EqualFunc(report.Authors, want, func(x, y LocalAuthor) bool {
+		return x.Name == y.Name && x.Email == y.Email && x.Login == y.Login && x.Commits == y.Commits &&
+			x.FirstCommit.Equal(y.FirstCommit) && x.LastCommit.Equal(y.LastCommit)
+	}) {
+		t.Errorf("authors = %+v, want %+v", report.Authors, want)
+	}
+	if report.TagCount != 1 || report.Tags[0].Name != "v1.0.0" {
+		t.Errorf("tags = %+v, want v1.0.0", report.Tags)
+	}
+
+	if report.License != "LICENSE" || report.SecurityPolicy != ".github/SECURITY.md" || !slices.Equal(report.CIConfigs, []string{".github/workflows/ci.yml"}) {
+		t.Errorf("tree: license %q, security policy %q, CI %q", report.License, report.SecurityPolicy, report.CIConfigs)
+	}
+	if len(report.Lockfiles) != 1 || report.Lockfiles[0].Path != "go.sum" || !report.Lockfiles[0].LastChanged.Equal(in2023(1, 5)) {
+		t.Errorf("lockfiles = %+v, want go.sum from the first commit", report.Lockfiles)
+	}
+
+	codes := map[string]Finding{}
+	for _, f := range report.Findings {
+		codes[f.Code] = f
+	}
+	for _, code := range []string{"OFFLINE_ANALYSIS", "SECURITY_POLICY", "STALE_LOCKFILES"} {
+		if _, ok := codes[code]; !ok {
+			t.Errorf("no %s finding in %+v", code, report.Findings)
+		}
+	}
+	for _, code := range []string{"NO_LICENSE", "NO_SECURITY_POLICY", "NO_CI", "NO_TAGS", "SINGLE_AUTHOR", "INACTIVE_REPO", "REWRITTEN_HISTORY", "SHALLOW_CLONE"} {
+		if f, ok := codes[code]; ok {
+			t.Errorf("unexpected %+v", f)
+		}
+	}
+	if stale := codes["STALE_LOCKFILES"]; !slices.Equal(stale.Evidence, []string{"go.sum (last changed 2023-01-05)"}) {
+		t.Errorf("STALE_LOCKFILES = %+v", stale)
+	}
+	if !slices.Contains(report.Positives, codes["SECURITY_POLICY"].Message) {
+		t.Errorf("positives = %q, want the security policy", report.Positives)
+	}
+
+	var out strings.Builder
+	FprintRepoAnalysis(&out, report)
+	for _, want := range []string{strings.ToUpper(OfflineNote), "(main)", "Commits:            4", "• Bob <1234+bob@users.noreply.github.com @bob>: 1 commits"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestAnalyzeLocalEdgeCases(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		build func(g *gitFixture) string
+		want  []string
+	}{
+		{"bare minimum", func(g *gitFixture) string {
+			g.commit("Alice", "alice@example.com", in2023(1, 5), in2023(1, 5), "main.go")
+			return g.dir
+		}, []string{"INACTIVE_REPO", "SINGLE_AUTHOR", "NO_TAGS", "NO_LICENSE", "NO_SECURITY_POLICY", "NO_CI"}},
+		{"detached HEAD", func(g *gitFixture) string {
+			g.commit("Alice", "alice@example.com", in2023(6, 1), in2023(6, 1), "main.go")
+			g.commit("Bob", "bob@example.com", in2023(6, 2), in2023(6, 2), "lib.go")
+			g.git(nil, "checkout", "-q", "--detach", "HEAD~1")
+			return g.dir
+		}, []string{"DETACHED_HEAD", "SINGLE_AUTHOR"}},
+		// One author in a shallow clone may not be the only one
+		{"shallow clone", func(g *gitFixture) string {
+			g.commit("Alice", "alice@example.com", in2023(6, 1), in2023(6, 1), "main.go")
+			g.commit("Bob", "bob@example.com", in2023(6, 2), in2023(6, 2), "lib.go")
+			clone := filepath.Join(t.TempDir(), "clone")
+			g.git(nil, "clone", "-q", "--depth", "1", "file://"+g.dir, clone)
+			return clone
+		}, []string{"SHALLOW_CLONE"}},
+		{"rewritten history", func(g *gitFixture) string {
+			g.commit("Alice", "alice@example.com", in2023(1, 1), in2023(6, 1), "main.go")
+			g.commit("Bob", "bob@example.com", in2023(1, 2), in2023(6, 1), "lib.go")
+			return g.dir
+		}, []string{"REWRITTEN_HISTORY"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := localAnalyzer().AnalyzeLocal(tt.build(newGitFixture(t)))
+			if err != nil {
+				t.Fatalf("AnalyzeLocal: %v", err)
+			}
+			codes := map[string]bool{}
+			for _, f := range report.Findings {
+				codes[f.Code] = true
+			}
+			for _, code := range tt.want {
+				if !codes[code] {
+					t.Errorf("no %s finding in %+v", code, report.Findings)
+				}
+			}
+			for _, code := range []string{"DETACHED_HEAD", "SHALLOW_CLONE", "REWRITTEN_HISTORY", "SINGLE_AUTHOR"} {
+				if codes[code] && !slices.Contains(tt.want, code) {
+					t.Errorf("unexpected %s finding", code)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeLocalNotARepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	if _, err := localAnalyzer().AnalyzeLocal(t.TempDir()); err == nil || !strings.Contains(err.Error(), "as a git repository") {
+		t.Errorf("AnalyzeLocal of a plain directory: err = %v", err)
+	}
+}
+
+func TestNoreplyLogin(t *testing.T) {
+	for _, tt := range []struct{ email, want string }{
+		{"1234+octo-cat@users.noreply.github.com", "octo-cat"},
+		{"octo@users.noreply.github.com", "octo"},
+		{"octo@example.com", ""},
+		{"1234+octo@users.noreply.github.com.evil.com", ""},
+	} {
+		if got := noreplyLogin(tt.email); got != tt.want {
+			t.Errorf("noreplyLogin(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestResolveLocalAuthors(t *testing.T) {
+	f := newFakeGitHub(t)
+	var searches atomic.Int32
+	f.route("/search/commits", func(w http.ResponseWriter, r *http.Request) {
+		searches.Add(1)
+		items := []map[string]any{}
+		if q := r.URL.Query().Get("q"); q == "author-email:alice@example.com" {
+			items = append(items, map[string]any{"author": map[string]string{"login": "alice"}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	})
+	report := &RepoAnalysis{Authors: []LocalAuthor{
+		{Email: "bob@users.noreply.github.com", Login: "bob"},
+		{Email: "alice@example.com"},
+		{Email: "nobody@example.com"},
+	}}
+
+	unspaced(t)
+	if err := newFakeAnalyzer(f).ResolveLocalAuthors(t.Context(), report); err == nil {
+		t.Error("resolved authors without a token")
+	}
+	if err := newFakeAnalyzerToken(f, "t0ken").ResolveLocalAuthors(t.Context(), report); err != nil {
+		t.Fatalf("ResolveLocalAuthors: %v", err)
+	}
+	// Known logins aren't looked up again
+	if searches.Load() != 2 || report.Authors[1].Login != "alice" || report.Authors[2].Login != "" {
+		t.Errorf("%d searches resolved %+v", searches.Load(), report.Authors)
+	}
+}
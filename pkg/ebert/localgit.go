@@ -0,0 +1,146 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitRepo runs git against a local checkout
+type gitRepo struct {
+	dir string
+}
+
+// run executes a git subcommand in the checkout and returns its stdout
+func (g gitRepo) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s: %w", args[0], msg, err)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// localCommit is one commit from git log
+type localCommit struct {
+	hash      string
+	name      string
+	email     string
+	authored  time.Time
+	committed time.Time
+	signature string
+}
+
+// signed reports whether git found any signature on the commit, valid or not
+func (c localCommit) signed() bool {
+	return c.signature != "" && c.signature != "N"
+}
+
+// commitLogFormat separates the fields with the ASCII unit separator,
+// which can't appear in names or emails
+const commitLogFormat = "--format=%H%x1f%an%x1f%ae%x1f%at%x1f%ct%x1f%G?"
+
+// commits lists every commit reachable from HEAD, newest first
+func (g gitRepo) commits(ctx context.Context) ([]localCommit, error) {
+	out, err := g.run(ctx, "log", commitLogFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []localCommit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+		commits = append(commits, localCommit{
+			hash:      fields[0],
+			name:      fields[1],
+			email:     strings.ToLower(fields[2]),
+			authored:  unixTime(fields[3]),
+			committed: unixTime(fields[4]),
+			signature: fields[5],
+		})
+	}
+	return commits, nil
+}
+
+// LocalTag is a tag in a local checkout
+type LocalTag struct {
+	Name string    `json:"name"`
+	Date time.Time `json:"date,omitzero"`
+}
+
+// tags lists the checkout's tags, newest first
+func (g gitRepo) tags(ctx context.Context) ([]LocalTag, error) {
+	out, err := g.run(ctx, "for-each-ref", "--sort=-creatordate", "--format=%(refname:short)%1f%(creatordate:unix)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []LocalTag
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		name, date, ok := strings.Cut(line, "\x1f")
+		if !ok || name == "" {
+			continue
+		}
+		tags = append(tags, LocalTag{Name: name, Date: unixTime(date)})
+	}
+	return tags, nil
+}
+
+// shallow reports whether the checkout is a shallow clone
+func (g gitRepo) shallow(ctx context.Context) bool {
+	out, err := g.run(ctx, "rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// branch returns the checked-out branch, or "" on a detached HEAD
+func (g gitRepo) branch(ctx context.Context) string {
+	out, err := g.run(ctx, "symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// lastChanged returns when path was last committed, zero if never
+func (g gitRepo) lastChanged(ctx context.Context, path string) time.Time {
+	out, err := g.run(ctx, "log", "-1", "--format=%ct", "--", path)
+	if err != nil {
+		return time.Time{}
+	}
+	return unixTime(strings.TrimSpace(out))
+}
+
+// forcedUpdates counts reflog entries where a fetch found a remote ref
+// rewritten rather than fast-forwarded
+func (g gitRepo) forcedUpdates(ctx context.Context) int {
+	out, err := g.run(ctx, "reflog", "--all", "--format=%gs")
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "forced-update") {
+			count++
+		}
+	}
+	return count
+}
+
+func unixTime(s string) time.Time {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
@@ -0,0 +1,75 @@
+package ebert
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FprintMarkdown writes the analysis as a Markdown report, e.g. for a
+// GitHub Actions job summary or a pull request comment
+func FprintMarkdown(w io.Writer, analysis *Analysis) {
+	fmt.Fprintf(w, "## ebert: @%s\n\n", analysis.User.Login)
+	if analysis.SelfAnalysis {
+		fmt.Fprint(w, "_Self-analysis of the token's own account_\n\n")
+	}
+
+	if missing := analysis.MissingSources(); len(missing) > 0 {
+		fmt.Fprintf(w, "> [!WARNING]\n> Partial report - missing data: %s\n\n", strings.Join(missing, ", "))
+	}
+
+	fmt.Fprintf(w, "**Risk: %s** - score %.1f/100 (lower is better), confidence %.0f%%\n\n",
+		strings.ToUpper(analysis.RiskLevel), analysis.OverallScore, analysis.Confidence*100)
+	fmt.Fprintf(w, "Trust index %s, abandonment index %s\n\n", formatScore(analysis.TrustIndex), formatScore(analysis.AbandonmentIndex))
+	if skipped := analysis.SkippedIntegrations(); len(skipped) > 0 {
+		fmt.Fprintf(w, "Skipped integrations, lowering confidence: %s\n\n", strings.Join(skipped, ", "))
+	}
+
+	fmt.Fprintln(w, "| Dimension | Score |")
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, row := range []struct {
+		name  string
+		score *float64
+	}{
+		{"Identity", analysis.Scores.Identity},
+		{"Activity", analysis.Scores.Activity},
+		{"Quality", analysis.Scores.Quality},
+		{"Maintenance", analysis.Scores.Maintenance},
+		{"Community", analysis.Scores.Community},
+		{"Security", analysis.Scores.Security},
+	} {
+		fmt.Fprintf(w, "| %s | %s |\n", row.name, formatScore(row.score))
+	}
+
+	m := analysis.Metrics
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Account age %dy %dm · %d repos · %d stars · %d followers · %d recent commits (%d days)\n",
+		m.AccountAgeDays/365, (m.AccountAgeDays%365)/30, m.Repos, m.Stars, m.Followers, m.RecentCommits, m.ActivityWindowDays)
+
+	markdownList(w, "🚨 Red flags", analysis.RedFlags)
+	markdownList(w, "⚠️ Warnings", analysis.Warnings)
+	markdownList(w, "✅ Positive signals", analysis.Positives)
+
+	if len(analysis.TopRemediations) > 0 {
+		fmt.Fprintln(w, "\n### 🔧 Top remediations")
+		fmt.Fprintln(w)
+		for _, item := range analysis.TopRemediations {
+			fmt.Fprintf(w, "- %s (-%.1f, `%s`)\n", item.Action, item.ScoreImpact, item.Code)
+		}
+	}
+
+	if analysis.Meta != nil {
+		fmt.Fprintf(w, "\n<sub>%s</sub>\n", analysis.Meta.Summary())
+	}
+}
+
+// markdownList writes a titled bullet list, skipping it when empty
+func markdownList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n### %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "- %s\n", item)
+	}
+}
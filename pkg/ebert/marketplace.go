@@ -0,0 +1,236 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// heavilyUsedActionStars is how many stars mark an action as widely used
+	heavilyUsedActionStars = 100
+
+	// staleActionReleaseAge is how old an action's latest release can be
+	// before its release discipline looks lapsed
+	staleActionReleaseAge = 365 * 24 * time.Hour
+
+	// maxActionTags is how many tags are listed when looking for a moving
+	// major tag
+	maxActionTags = 100
+)
+
+// actionManifestNames are the metadata files that make a repo an action
+var actionManifestNames = []string{"action.yml", "action.yaml"}
+
+// majorTagPattern matches a moving major version tag such as v1
+var majorTagPattern = regexp.MustCompile(`^v\d+$`)
+
+// ActionRepo is a flagship repo published as a GitHub Action and its
+// release hygiene
+type ActionRepo struct {
+	Repo        string    `json:"repo"`
+	Stars       int       `json:"stars"`
+	MajorTag    string    `json:"major_tag,omitempty"`
+	LastRelease time.Time `json:"last_release,omitzero"`
+
+	// Docker is set for Docker container actions; UnpinnedImage is the
+	// external image such an action pulls by tag rather than digest
+	Docker        bool   `json:"docker,omitempty"`
+	UnpinnedImage string `json:"unpinned_image,omitempty"`
+}
+
+// hygienic reports whether the action has a moving major tag and a
+// release within the last year
+func (a ActionRepo) hygienic(now time.Time) bool {
+	return a.MajorTag != "" && !a.LastRelease.IsZero() && now.Sub(a.LastRelease) <= staleActionReleaseAge
+}
+
+// actionManifest is the part of action.yml this check reads
+type actionManifest struct {
+	Runs struct {
+		Using string `yaml:"using"`
+		Image string `yaml:"image"`
+	} `yaml:"runs"`
+}
+
+// unpinnedImage returns the external image a Docker action pulls by
+// mutable tag, or "" when it builds a local Dockerfile or pins a digest
+func (m actionManifest) unpinnedImage() string {
+	image, external := strings.CutPrefix(m.Runs.Image, "docker://")
+	if m.Runs.Using != "docker" || !external || strings.Contains(image, "@sha256:") {
+		return ""
+	}
+	return image
+}
+
+// GitHubTag is a git tag from the tags API
+type GitHubTag struct {
+	Name string `json:"name"`
+}
+
+// GetTags lists up to n of a repo's tags
+func (c *GitHubClient) GetTags(ctx context.Context, owner, repo string, n int) (_ []GitHubTag, err error) {
+	ctx, span := c.startSpan(ctx, "github.tags", "repos/:owner/:repo/tags")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/tags?per_page=%d", c.BaseURL, owner, repo, min(n, maxPerPage)))
+	if err != nil {
+		return nil, err
+	}
+	tags, _, err := decodeElements[GitHubTag](data)
+	return tags, err
+}
+
+// GitHubRelease is a published release
+type GitHubRelease struct {
+	TagName     string         `json:"tag_name"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	PublishedAt time.Time      `json:"published_at"`
+	Assets      []ReleaseAsset `json:"assets"`
+	Author      GitHubUser     `json:"author"`
+}
+
+// ReleaseAsset is a file attached to a release. Digest is "sha256:<hex>"
+// for assets uploaded since GitHub began recording it, else empty.
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	Digest             string `json:"digest"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// GetLatestRelease returns the repo's latest release, or nil if it has none
+func (c *GitHubClient) GetLatestRelease(ctx context.Context, owner, repo string) (_ *GitHubRelease, err error) {
+	ctx, span := c.startSpan(ctx, "github.releases", "repos/:owner/:repo/releases/latest")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/releases/latest", c.BaseURL, owner, repo))
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var release GitHubRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// checkMarketplaceActions finds flagship repos published as GitHub Actions,
+// which run with access to their users' repos and secrets, and checks
+// their release tagging and Docker image pinning. It needs a token and
+// skips silently without one.
+func (a *Analyzer) checkMarketplaceActions(ctx context.Context, r *analysisRun) {
+	if !a.client.authenticated() {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	var published, wellMaintained, lapsed, unpinned []string
+
+	for _, repo := range r.checkable() {
+		if repo.Fork || repo.Archived {
+			continue
+		}
+		path, ok := hasEntry(a.directory(ctx, r, repo, ""), actionManifestNames...)
+		if !ok {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+		action := ActionRepo{Repo: repo.FullName, Stars: repo.StargazersCount}
+
+		if r.contentsBudget.take() {
+			if data, err := a.client.GetFile(ctx, owner, name, path); err == nil && data != nil {
+				var manifest actionManifest
+				if yaml.Unmarshal(data, &manifest) == nil {
+					action.Docker = manifest.Runs.Using == "docker"
+					action.UnpinnedImage = manifest.unpinnedImage()
+				}
+			}
+		}
+		if r.contentsBudget.take() {
+			if tags, err := a.client.GetTags(ctx, owner, name, maxActionTags); err == nil {
+				for _, tag := range tags {
+					if majorTagPattern.MatchString(tag.Name) {
+						action.MajorTag = tag.Name
+						break
+					}
+				}
+			}
+		}
+		if r.contentsBudget.take() {
+			if release, err := a.client.GetLatestRelease(ctx, owner, name); err == nil && release != nil {
+				action.LastRelease = release.PublishedAt
+			}
+		}
+
+		metrics.ActionRepos = append(metrics.ActionRepos, action)
+		published = append(published, fmt.Sprintf("%s (%d stars)", repo.FullName, repo.StargazersCount))
+
+		switch {
+		case action.hygienic(r.now) && action.Stars >= heavilyUsedActionStars:
+			wellMaintained = append(wellMaintained, fmt.Sprintf("%s: %s tag, released %s", repo.FullName, action.MajorTag, action.LastRelease.Format("2006-01-02")))
+		case !action.hygienic(r.now):
+			lapsed = append(lapsed, actionHygieneEvidence(action))
+		}
+		if action.UnpinnedImage != "" {
+			unpinned = append(unpinned, fmt.Sprintf("%s pulls %s", repo.FullName, action.UnpinnedImage))
+		}
+	}
+	metrics.PublishedActions = len(metrics.ActionRepos)
+
+	if len(published) > 0 {
+		r.addFinding(Finding{
+			Code:     "PUBLISHES_ACTIONS",
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("Publishes %d GitHub Actions - they run with access to their users' repos and secrets", len(published)),
+			Evidence: published,
+		})
+	}
+	if len(wellMaintained) > 0 {
+		r.addFinding(Finding{
+			Code:     "WELL_MAINTAINED_ACTIONS",
+			Severity: SeverityPositive,
+			Message:  "Widely used GitHub Actions with moving major tags and recent releases",
+			Evidence: wellMaintained,
+		})
+	}
+	if len(lapsed) > 0 {
+		r.addFinding(Finding{
+			Code:     "ACTION_RELEASE_HYGIENE",
+			Severity: SeverityWarning,
+			Message:  "GitHub Actions without a moving major tag or a release in the last year",
+			Evidence: lapsed,
+		})
+	}
+	if len(unpinned) > 0 {
+		r.addFinding(Finding{
+			Code:     "UNPINNED_ACTION_IMAGE",
+			Severity: SeverityWarning,
+			Message:  "Docker actions pull external images by mutable tag rather than digest",
+			Evidence: unpinned,
+		})
+	}
+}
+
+// actionHygieneEvidence says what an action's release discipline lacks
+func actionHygieneEvidence(action ActionRepo) string {
+	var gaps []string
+	if action.MajorTag == "" {
+		gaps = append(gaps, "no major version tag")
+	}
+	if action.LastRelease.IsZero() {
+		gaps = append(gaps, "no releases")
+	} else {
+		gaps = append(gaps, "last release "+action.LastRelease.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s: %s", action.Repo, strings.Join(gaps, ", "))
+}
@@ -0,0 +1,145 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUnpinnedImage(t *testing.T) {
+	for _, tt := range []struct {
+		using, image string
+		want         string
+	}{
+		{"docker", "docker://alpine:3.19", "alpine:3.19"},
+		{"docker", "docker://ghcr.io/octo/runner:latest", "ghcr.io/octo/runner:latest"},
+		{"docker", "docker://alpine@sha256:0123abcd", ""},
+		// A local Dockerfile is built from the action's own source
+		{"docker", "Dockerfile", ""},
+		{"node20", "", ""},
+	} {
+		var manifest actionManifest
+		manifest.Runs.Using, manifest.Runs.Image = tt.using, tt.image
+		if got := manifest.unpinnedImage(); got != tt.want {
+			t.Errorf("unpinnedImage(%s, %q) = %q, want %q", tt.using, tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestActionHygiene(t *testing.T) {
+	for _, tt := range []struct {
+		action   ActionRepo
+		hygienic bool
+		evidence string
+	}{
+		{ActionRepo{Repo: "octo/a", MajorTag: "v2", LastRelease: fakeNow.Add(-days(30))}, true, "octo/a: last release 2024-05-02"},
+		{ActionRepo{Repo: "octo/b", MajorTag: "v1", LastRelease: fakeNow.AddDate(-2, 0, 0)}, false, "octo/b: last release 2022-06-01"},
+		{ActionRepo{Repo: "octo/c", LastRelease: fakeNow.Add(-days(30))}, false, "octo/c: no major version tag, last release 2024-05-02"},
+		{ActionRepo{Repo: "octo/d", MajorTag: "v1"}, false, "octo/d: no releases"},
+		{ActionRepo{Repo: "octo/e"}, false, "octo/e: no major version tag, no releases"},
+	} {
+		if got := tt.action.hygienic(fakeNow); got != tt.hygienic {
+			t.Errorf("%s: hygienic = %t, want %t", tt.action.Repo, got, tt.hygienic)
+		}
+		if got := actionHygieneEvidence(tt.action); got != tt.evidence {
+			t.Errorf("%s: evidence %q, want %q", tt.action.Repo, got, tt.evidence)
+		}
+	}
+}
+
+// actionsFake serves octo's actions: setup, widely used and well
+// released; runner, a Docker action on an unpinned image with neither a
+// major tag nor a recent release; and pinned, a Docker action on a digest
+// that was never released
+func actionsFake(t *testing.T) *fakeGitHub {
+	repo := func(name string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: "TypeScript", Size: 300, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(4))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), repo("setup", 500), repo("lib", 80), repo("runner", 20), repo("pinned", 5)))
+
+	serveDirectory(f, "setup", "", "action.yml", "dist")
+	serveFile(f, "setup", "action.yml", "runs:\n  using: node20\n  main: dist/index.js\n")
+	serveDirectory(f, "lib", "", "go.mod")
+	serveDirectory(f, "runner", "", "action.yaml")
+	serveFile(f, "runner", "action.yaml", "runs:\n  using: docker\n  image: docker://alpine:3.19\n")
+	serveDirectory(f, "pinned", "", "action.yml")
+	serveFile(f, "pinned", "action.yml", "runs:\n  using: docker\n  image: docker://alpine@sha256:0123abcd\n")
+
+	serveTags := func(repo string, names ...string) {
+		f.route("/repos/octo/"+repo+"/tags", func(w http.ResponseWriter, r *http.Request) {
+			tags := make([]GitHubTag, len(names))
+			for i, name := range names {
+				tags[i].Name = name
+			}
+			_ = json.NewEncoder(w).Encode(tags)
+		})
+	}
+	serveRelease := func(repo string, published time.Time) {
+		f.route("/repos/octo/"+repo+"/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"tag_name":"v1.2.0","published_at":%q}`, published.Format(time.RFC3339))
+		})
+	}
+	serveTags("setup", "v1.2.0", "v1", "v1.1.0")
+	serveRelease("setup", fakeNow.Add(-days(30)))
+	serveTags("runner", "v0.1.0")
+	serveRelease("runner", fakeNow.AddDate(-2, 0, 0))
+	return f
+}
+
+func TestCheckMarketplaceActions(t *testing.T) {
+	analysis, err := newFakeAnalyzerToken(actionsFake(t), "t0ken").Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	metrics := analysis.Metrics
+	if metrics.PublishedActions != 3 || len(metrics.ActionRepos) != 3 {
+		t.Fatalf("%d published actions: %+v, want setup, runner and pinned", metrics.PublishedActions, metrics.ActionRepos)
+	}
+	byRepo := map[string]ActionRepo{}
+	for _, action := range metrics.ActionRepos {
+		byRepo[action.Repo] = action
+	}
+	if setup := byRepo["octo/setup"]; setup.MajorTag != "v1" || setup.Docker || !setup.LastRelease.Equal(fakeNow.Add(-days(30))) {
+		t.Errorf("setup = %+v, want the v1 tag and last month's release", setup)
+	}
+	if runner := byRepo["octo/runner"]; !runner.Docker || runner.UnpinnedImage != "alpine:3.19" || runner.MajorTag != "" {
+		t.Errorf("runner = %+v, want a Docker action on alpine:3.19", runner)
+	}
+	if pinned := byRepo["octo/pinned"]; !pinned.Docker || pinned.UnpinnedImage != "" || !pinned.LastRelease.IsZero() {
+		t.Errorf("pinned = %+v, want a pinned Docker action with no release", pinned)
+	}
+
+	for _, tt := range []struct {
+		code     string
+		severity Severity
+		evidence []string
+	}{
+		{"PUBLISHES_ACTIONS", SeverityInfo, []string{"octo/setup (500 stars)", "octo/runner (20 stars)", "octo/pinned (5 stars)"}},
+		{"WELL_MAINTAINED_ACTIONS", SeverityPositive, []string{"octo/setup: v1 tag, released 2024-05-02"}},
+		{"ACTION_RELEASE_HYGIENE", SeverityWarning, []string{"octo/runner: no major version tag, last release 2022-06-01", "octo/pinned: no major version tag, no releases"}},
+		{"UNPINNED_ACTION_IMAGE", SeverityWarning, []string{"octo/runner pulls alpine:3.19"}},
+	} {
+		flag := finding(analysis, tt.code)
+		if flag == nil || flag.Severity != tt.severity || !slices.Equal(flag.Evidence, tt.evidence) {
+			t.Errorf("%s = %+v, want %q", tt.code, flag, tt.evidence)
+			continue
+		}
+		if tt.severity == SeverityWarning && flag.Remediation == "" {
+			t.Errorf("%s has no remediation", tt.code)
+		}
+	}
+}
+
+func TestCheckMarketplaceActionsAnonymous(t *testing.T) {
+	f := actionsFake(t)
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if analysis.Metrics.PublishedActions != 0 || finding(analysis, "PUBLISHES_ACTIONS") != nil {
+		t.Errorf("found %d actions without a token", analysis.Metrics.PublishedActions)
+	}
+}
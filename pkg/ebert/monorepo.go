@@ -0,0 +1,310 @@
+package ebert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxMonorepoChecked is how many top repos deep mode scans for the
+	// packages they hold
+	maxMonorepoChecked = 5
+
+	// maxMonorepoSubtrees bounds the top-level directories of a Go or
+	// Python repo listed for nested go.mod and pyproject.toml files
+	maxMonorepoSubtrees = 8
+
+	// maxRepoPackages caps the packages counted in one repo
+	maxRepoPackages = 20
+)
+
+// packageLanguages are the primary languages of the repos scanned for
+// packages
+var packageLanguages = map[string]bool{
+	"JavaScript": true,
+	"TypeScript": true,
+	"Python":     true,
+	"Go":         true,
+}
+
+// manifestFiles are the file names that declare a package
+var manifestFiles = map[string]bool{
+	"package.json":   true,
+	"pyproject.toml": true,
+	"go.mod":         true,
+}
+
+// pyprojectName matches the name key of a pyproject.toml table
+var pyprojectName = regexp.MustCompile(`^name\s*=\s*["']([^"']+)["']`)
+
+// npmWorkspaces is the workspaces field of a package.json: a list of
+// patterns, or an object listing them under packages as Yarn writes it
+type npmWorkspaces []string
+
+// UnmarshalJSON accepts both forms, ignoring any other
+func (w *npmWorkspaces) UnmarshalJSON(data []byte) error {
+	var patterns []string
+	if err := json.Unmarshal(data, &patterns); err == nil {
+		*w = patterns
+		return nil
+	}
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &object); err == nil {
+		*w = object.Packages
+	}
+	return nil
+}
+
+// repoPackage is a package declared in a repo; npm holds the package.json
+// of npm packages
+type repoPackage struct {
+	ecosystem Ecosystem
+	name, dir string
+	npm       *npmManifest
+}
+
+func (p repoPackage) String() string {
+	return fmt.Sprintf("%s (%s, %s)", p.name, p.ecosystem, p.dir)
+}
+
+// packageScan collects the packages of one repo, every request taken from
+// the contents budget
+type packageScan struct {
+	ctx         context.Context
+	a           *Analyzer
+	r           *analysisRun
+	owner, name string
+	packages    []repoPackage
+}
+
+func (s *packageScan) take() bool {
+	return len(s.packages) < maxRepoPackages && s.ctx.Err() == nil && s.r.contentsBudget.take()
+}
+
+// read fetches the manifest at file and adds the package it declares,
+// returning a package.json for its workspaces
+func (s *packageScan) read(file string) *npmManifest {
+	if !s.take() {
+		return nil
+	}
+	data, err := s.a.client.GetFile(s.ctx, s.owner, s.name, file)
+	if err != nil || data == nil {
+		return nil
+	}
+
+	dir := path.Dir(file)
+	switch path.Base(file) {
+	case "package.json":
+		var manifest npmManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil
+		}
+		if manifest.Name != "" && !manifest.Private {
+			s.packages = append(s.packages, repoPackage{ecosystem: EcosystemNPM, name: manifest.Name, dir: dir, npm: &manifest})
+		}
+		return &manifest
+	case "pyproject.toml":
+		if name := pyprojectPackage(data); name != "" {
+			s.packages = append(s.packages, repoPackage{ecosystem: EcosystemPyPI, name: name, dir: dir})
+		}
+	case "go.mod":
+		if module := goModule(data); module != "" {
+			s.packages = append(s.packages, repoPackage{ecosystem: EcosystemGo, name: module, dir: dir})
+		}
+	}
+	return nil
+}
+
+// workspaces adds the npm packages the root package.json's workspace
+// patterns name. Plain directories are read wherever they are, but only
+// "dir/*" one level below the root is expanded, which covers the usual
+// layouts without walking the tree.
+func (s *packageScan) workspaces(patterns []string, dirs map[string]GitHubTreeEntry) {
+	for _, pattern := range patterns {
+		pattern = path.Clean(strings.TrimPrefix(pattern, "./"))
+		parent, wildcard := strings.CutSuffix(pattern, "/*")
+		if strings.ContainsAny(parent, "*?[!{") || parent == "." || strings.HasPrefix(parent, "..") || path.IsAbs(parent) {
+			continue
+		}
+		if !wildcard {
+			s.read(parent + "/package.json")
+			continue
+		}
+		if strings.Contains(parent, "/") {
+			continue
+		}
+
+		dir, ok := dirs[parent]
+		if !ok || !s.take() {
+			continue
+		}
+		entries, _, err := s.a.client.GetTree(s.ctx, s.owner, s.name, dir.SHA, false)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type == "tree" {
+				s.read(path.Join(parent, entry.Path, "package.json"))
+			}
+		}
+	}
+}
+
+// scanRepoPackages lists the packages a repo declares: its root manifests,
+// the npm workspaces of its root package.json and, in Go and Python repos,
+// the go.mod and pyproject.toml files one directory down
+func (a *Analyzer) scanRepoPackages(ctx context.Context, r *analysisRun, repo GitHubRepo) ([]repoPackage, error) {
+	owner, name := repoOwnerAndName(repo, r.username)
+	root, _, err := a.client.GetTree(ctx, owner, name, repo.DefaultBranch, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &packageScan{ctx: ctx, a: a, r: r, owner: owner, name: name}
+	dirs := map[string]GitHubTreeEntry{}
+	var workspaces npmWorkspaces
+	for _, entry := range root {
+		switch {
+		case entry.Type == "tree" && !strings.HasPrefix(entry.Path, "."):
+			dirs[entry.Path] = entry
+		case entry.Type == "blob" && manifestFiles[entry.Path]:
+			if manifest := s.read(entry.Path); manifest != nil {
+				workspaces = manifest.Workspaces
+			}
+		}
+	}
+	s.workspaces(workspaces, dirs)
+
+	if repo.Language != "Go" && repo.Language != "Python" {
+		return s.packages, nil
+	}
+	listed := 0
+	for _, entry := range root {
+		dir, ok := dirs[entry.Path]
+		if !ok || vendoredDirs[strings.ToLower(dir.Path)] {
+			continue
+		}
+		if listed == maxMonorepoSubtrees || !s.take() {
+			break
+		}
+		listed++
+		entries, _, err := a.client.GetTree(ctx, owner, name, dir.SHA, false)
+		if err != nil {
+			continue
+		}
+		for _, nested := range entries {
+			if nested.Type == "blob" && (nested.Path == "go.mod" || nested.Path == "pyproject.toml") {
+				s.read(path.Join(dir.Path, nested.Path))
+			}
+		}
+	}
+	return s.packages, nil
+}
+
+// pyprojectPackage is the name in a pyproject.toml's [project] or
+// [tool.poetry] table
+func pyprojectPackage(data []byte) string {
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[] ")
+			continue
+		}
+		if table != "project" && table != "tool.poetry" {
+			continue
+		}
+		if match := pyprojectName.FindStringSubmatch(line); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// goModule is the module path a go.mod declares
+func goModule(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
+
+// countRepoPackages replaces the one package the repo's primary language
+// counted it as with the packages found in it
+func (r *analysisRun) countRepoPackages(repo GitHubRepo, packages []repoPackage) {
+	counts := map[Ecosystem]int{}
+	for _, pkg := range packages {
+		counts[pkg.ecosystem]++
+	}
+	if ecosystem, ok := repoEcosystem(repo); ok {
+		counts[ecosystem]--
+	} else if repo.Language == "Go" {
+		counts[EcosystemGo]--
+	}
+
+	metrics := &r.acc.metrics
+	metrics.NPMPackages = max(0, metrics.NPMPackages+counts[EcosystemNPM])
+	metrics.PythonPackages = max(0, metrics.PythonPackages+counts[EcosystemPyPI])
+	metrics.GoModules = max(0, metrics.GoModules+counts[EcosystemGo])
+}
+
+// checkRepoPackages counts the packages of the top repos in deep mode, so
+// monorepos such as npm workspaces and multi-module Go repos count each
+// package. The npm packages go on to the install-script and registry
+// checks, and internal-looking names to the dependency confusion check.
+func (a *Analyzer) checkRepoPackages(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !r.log.coverage().repos {
+		return
+	}
+
+	var failed error
+	checked := 0
+	r.repoPackages = map[string][]repoPackage{}
+	for _, repo := range r.checkable() {
+		if checked == maxMonorepoChecked || ctx.Err() != nil {
+			break
+		}
+		if repo.DefaultBranch == "" || repo.Fork || !packageLanguages[repo.Language] {
+			continue
+		}
+		if !r.contentsBudget.take() {
+			break
+		}
+		checked++
+		packages, err := a.scanRepoPackages(ctx, r, repo)
+		if err != nil {
+			if !isNotFound(err) {
+				failed = err
+			}
+			continue
+		}
+
+		r.repoPackages[strings.ToLower(repo.FullName)] = packages
+		r.countRepoPackages(repo, packages)
+		a.opts.Logger.Debug("found repo packages", "repo", repo.FullName, "packages", packages)
+		for _, pkg := range packages {
+			if len(r.acc.packages) < maxPackageCandidates && pkg.ecosystem != EcosystemGo && pkg.name != repo.Name && r.acc.internalPatterns.MatchString(pkg.name) {
+				r.acc.packages = append(r.acc.packages, packageCandidate{name: pkg.name, ecosystem: pkg.ecosystem, internal: true})
+			}
+		}
+	}
+
+	switch {
+	case failed != nil:
+		r.log.fellBack("repo_packages", fmt.Errorf("failed to list some repo trees: %w", failed))
+	case checked > 0:
+		r.log.ok("repo_packages")
+	}
+}
@@ -0,0 +1,137 @@
+package ebert
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// serveTree answers the trees and contents endpoints of owner/repo from
+// files, keyed by slash-separated path. The root tree is at branch and
+// each directory's at the SHA-1 of its path.
+func serveTree(f *fakeGitHub, owner, repo, branch string, files map[string]string) {
+	trees := map[string][]GitHubTreeEntry{}
+	seen := map[string]bool{}
+	treeSHA := func(dir string) string {
+		if dir == "." {
+			return branch
+		}
+		sum := sha1.Sum([]byte(dir))
+		return hex.EncodeToString(sum[:])
+	}
+	for file, content := range files {
+		trees[path.Dir(file)] = append(trees[path.Dir(file)], GitHubTreeEntry{Path: path.Base(file), Type: "blob", SHA: treeSHA(file), Size: int64(len(content))})
+		f.route("/repos/"+owner+"/"+repo+"/contents/"+file, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(content))
+		})
+		for dir := path.Dir(file); dir != "." && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			trees[path.Dir(dir)] = append(trees[path.Dir(dir)], GitHubTreeEntry{Path: path.Base(dir), Type: "tree", SHA: treeSHA(dir)})
+		}
+	}
+	for dir, entries := range trees {
+		slices.SortFunc(entries, func(x, y GitHubTreeEntry) int { return strings.Compare(x.Path, y.Path) })
+		f.route("/repos/"+owner+"/"+repo+"/git/trees/"+treeSHA(dir), func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"tree": entries, "truncated": false})
+		})
+	}
+}
+
+// monorepoFixture reads the files of testdata/monorepo/name
+func monorepoFixture(t *testing.T, name string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	root := os.DirFS(path.Join("testdata", "monorepo", name))
+	err := fs.WalkDir(root, ".", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(root, file)
+		files[file] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestMonorepoPackages(t *testing.T) {
+	for _, tt := range []struct {
+		fixture  string
+		language string
+		want     []string
+		metric   func(Metrics) int
+	}{
+		// The private root and workspace are left out, and so is a
+		// pattern deeper than one level
+		{"workspaces", "JavaScript", []string{"@ws/core", "@ws/utils", "ws-cli"}, func(m Metrics) int { return m.NPMPackages }},
+		// Yarn lists workspaces under packages; the public root counts
+		{"yarn", "TypeScript", []string{"yarn-root", "yarn-a", "yarn-b"}, func(m Metrics) int { return m.NPMPackages }},
+		// vendor is skipped, and cmd/tool is too deep to be looked for
+		{"gomulti", "Go", []string{"example.com/gomulti", "example.com/gomulti/api", "example.com/gomulti/sdk"}, func(m Metrics) int { return m.GoModules }},
+		// Only [project] and [tool.poetry] name the package
+		{"pyns", "Python", []string{"ns-core", "ns-plugin-a", "ns-plugin-b"}, func(m Metrics) int { return m.PythonPackages }},
+	} {
+		t.Run(tt.fixture, func(t *testing.T) {
+			account := newAccount("mono", days(2000), GitHubRepo{Name: tt.fixture, Language: tt.language, Size: 900, StargazersCount: 120, UpdatedAt: fakeNow.Add(-days(2))})
+			f := newFakeGitHub(t, account)
+			serveTree(f, "mono", tt.fixture, "main", monorepoFixture(t, tt.fixture))
+
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			analysis, err := newFakeAnalyzer(f, WithDeepChecks(true), WithLogger(logger)).Analyze("mono")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+
+			if got := tt.metric(analysis.Metrics); got != len(tt.want) {
+				t.Errorf("packages counted = %d, want %d", got, len(tt.want))
+			}
+			var found string
+			for _, line := range strings.Split(logs.String(), "\n") {
+				if strings.Contains(line, "found repo packages") {
+					found = line
+				}
+			}
+			for _, name := range tt.want {
+				if !strings.Contains(found, name+" (") {
+					t.Errorf("verbose output %q should list %s", found, name)
+				}
+			}
+			if strings.Contains(found, "private") || strings.Contains(found, "vendored") || strings.Contains(found, "cmd/tool") || strings.Contains(found, "not-the-project") {
+				t.Errorf("verbose output lists a package it shouldn't: %s", found)
+			}
+		})
+	}
+}
+
+func TestMonorepoPackageCap(t *testing.T) {
+	files := map[string]string{"package.json": `{"name":"big","private":true,"workspaces":["packages/*"]}`}
+	for i := range maxRepoPackages + 10 {
+		files[fmt.Sprintf("packages/p%02d/package.json", i)] = fmt.Sprintf(`{"name":"big-p%02d"}`, i)
+	}
+	account := newAccount("big", days(2000), GitHubRepo{Name: "big", Language: "JavaScript", Size: 900, StargazersCount: 120, UpdatedAt: fakeNow.Add(-days(2))})
+	f := newFakeGitHub(t, account)
+	serveTree(f, "big", "big", "main", files)
+
+	analysis, err := newFakeAnalyzer(f, WithDeepChecks(true)).Analyze("big")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// The contents budget runs out before the per-repo cap, and neither
+	// lets the scan read every workspace
+	if got := analysis.Metrics.NPMPackages; got == 0 || got > maxRepoPackages {
+		t.Errorf("NPMPackages = %d, want between 1 and %d", got, maxRepoPackages)
+	}
+}
@@ -0,0 +1,86 @@
+package ebert
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultNewAccountThreshold is the account age below which an account is
+// treated as new and scored with reduced confidence
+const DefaultNewAccountThreshold = 90 * 24 * time.Hour
+
+// minRateDays is the smallest denominator used when normalizing counts
+// over time, so a days-old account can't produce absurd rates
+const minRateDays = 7
+
+// newAccountScoreFloor is the lowest history-based sub-score a new account
+// can get: too little history is no evidence of low risk
+const newAccountScoreFloor = 30.0
+
+// minNewAccountConfidence keeps a brand-new account's confidence above zero
+const minNewAccountConfidence = 0.25
+
+// accountAgeDays counts whole UTC days between created and now, never
+// returning a negative age for clock skew
+func accountAgeDays(created, now time.Time) int {
+	days := int(now.UTC().Sub(created.UTC()) / (24 * time.Hour))
+	return max(days, 0)
+}
+
+// perMonth normalizes count over days to a 30 day rate, clamping the
+// denominator to minRateDays
+func perMonth(count, days int) float64 {
+	return float64(count) / (float64(max(days, minRateDays)) / 30)
+}
+
+// isNewAccount reports whether metrics describe an account younger than threshold
+func isNewAccount(metrics Metrics, threshold time.Duration) bool {
+	return metrics.AccountAgeDays < int(threshold/(24*time.Hour))
+}
+
+// capNewAccountScores raises the history-based sub-scores of a new account
+// to the floor, pulling only reassuring scores toward neutral; scores that
+// already lean toward risk are left alone
+func capNewAccountScores(scores *RiskScores) {
+	for _, score := range []*float64{scores.Activity, scores.Quality, scores.Maintenance, scores.Community, scores.Security} {
+		if score != nil {
+			*score = max(*score, newAccountScoreFloor)
+		}
+	}
+}
+
+// confidence is the share of the configured weight that was computed,
+// scaled down for sampled repos and for accounts younger than threshold
+func (a *Analyzer) confidence(scores RiskScores, metrics Metrics) float64 {
+	pairs := weightedScores(scores, a.opts.Weights)
+
+	got, total := 0.0, 0.0
+	for _, pair := range pairs {
+		total += pair.weight
+		if pair.score != nil {
+			got += pair.weight
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	confidence := got / total * sampleConfidence(metrics)
+
+	threshold := a.opts.NewAccountThreshold
+	if isNewAccount(metrics, threshold) {
+		age := float64(metrics.AccountAgeDays) / (threshold.Hours() / 24)
+		confidence *= clamp(age, minNewAccountConfidence, 1)
+	}
+
+	return confidence
+}
+
+// newAccountFinding flags an account younger than threshold
+func newAccountFinding(metrics Metrics, threshold time.Duration) Finding {
+	return Finding{
+		Code:     "NEW_ACCOUNT",
+		Severity: SeverityRedFlag,
+		Message: fmt.Sprintf("Account created %d days ago (under %d days) - scores have low confidence",
+			metrics.AccountAgeDays, int(threshold.Hours()/24)),
+	}
+}
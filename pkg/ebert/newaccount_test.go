@@ -0,0 +1,111 @@
+package ebert
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewAccountAges(t *testing.T) {
+	repos := func() []GitHubRepo {
+		return []GitHubRepo{
+			{Name: "tool", Language: "Go", Size: 800, StargazersCount: 12, HasIssues: true, UpdatedAt: fakeNow.Add(-time.Hour)},
+			{Name: "dotfiles", Language: "Shell", Size: 40, UpdatedAt: fakeNow.Add(-2 * time.Hour)},
+		}
+	}
+	f := newFakeGitHub(t,
+		newAccount("dayold", days(1), repos()...),
+		newAccount("monthold", days(30), repos()...),
+		newAccount("veteran", days(3650), repos()...),
+	)
+	a := newFakeAnalyzer(f)
+
+	var veteranConfidence float64
+	for _, tt := range []struct {
+		login   string
+		ageDays int
+		isNew   bool
+	}{
+		{"veteran", 3650, false},
+		{"monthold", 30, true},
+		{"dayold", 1, true},
+	} {
+		t.Run(tt.login, func(t *testing.T) {
+			analysis, err := a.Analyze(tt.login)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if got := analysis.Metrics.AccountAgeDays; got != tt.ageDays {
+				t.Errorf("AccountAgeDays = %d, want %d", got, tt.ageDays)
+			}
+			if got := finding(analysis, "NEW_ACCOUNT") != nil; got != tt.isNew {
+				t.Errorf("NEW_ACCOUNT flagged = %v, want %v", got, tt.isNew)
+			}
+
+			scores := analysis.Scores
+			for name, score := range map[string]*float64{
+				"activity": scores.Activity, "quality": scores.Quality, "maintenance": scores.Maintenance,
+				"community": scores.Community, "security": scores.Security, "identity": scores.Identity,
+			} {
+				if score == nil {
+					continue
+				}
+				if math.IsNaN(*score) || *score < 0 || *score > 100 {
+					t.Errorf("%s score = %v, want within 0-100", name, *score)
+				}
+				if tt.isNew && name != "identity" && *score < newAccountScoreFloor {
+					t.Errorf("%s score = %.1f, want at least %.0f for a new account", name, *score, newAccountScoreFloor)
+				}
+			}
+
+			if !tt.isNew {
+				veteranConfidence = analysis.Confidence
+				return
+			}
+			if analysis.Confidence >= veteranConfidence || analysis.Confidence <= 0 {
+				t.Errorf("Confidence = %.2f, want below the %.2f of an old account and above zero", analysis.Confidence, veteranConfidence)
+			}
+		})
+	}
+}
+
+func TestCapNewAccountScores(t *testing.T) {
+	scores := RiskScores{Activity: computed(5), Quality: computed(50), Maintenance: computed(95), Identity: computed(0)}
+	capNewAccountScores(&scores)
+
+	for _, tt := range []struct {
+		name  string
+		score *float64
+		want  float64
+	}{
+		{"reassuring activity", scores.Activity, newAccountScoreFloor},
+		{"neutral quality", scores.Quality, 50},
+		{"risky maintenance", scores.Maintenance, 95},
+		{"identity", scores.Identity, 0},
+	} {
+		if *tt.score != tt.want {
+			t.Errorf("%s = %.1f, want %.1f", tt.name, *tt.score, tt.want)
+		}
+	}
+	if scores.Community != nil {
+		t.Error("an uncomputed score should stay uncomputed")
+	}
+}
+
+func TestAccountAgeDaysUTC(t *testing.T) {
+	created := time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	now := time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	honolulu := time.FixedZone("HST", -10*60*60)
+
+	want := accountAgeDays(created, now)
+	if got := accountAgeDays(created.In(tokyo), now.In(honolulu)); got != want {
+		t.Errorf("age across zones = %d, want %d", got, want)
+	}
+	if got := accountAgeDays(now, created); got != 0 {
+		t.Errorf("age with a skewed clock = %d, want 0", got)
+	}
+	if got := perMonth(3, 1); got != perMonth(3, minRateDays) {
+		t.Errorf("perMonth over a day = %.1f, want the %d-day rate", got, minRateDays)
+	}
+}
@@ -0,0 +1,36 @@
+package ebert
+
+// maxNoCodeConfidence caps the confidence of an account with no public
+// repos, whose scores rest on the profile alone
+const maxNoCodeConfidence = 0.3
+
+// noPublicCode reports whether the repo listing succeeded and found
+// nothing, so the code-derived scores and findings would say more about
+// the lack of data than about the account
+func noPublicCode(user GitHubUser, metrics Metrics, cov coverage) bool {
+	return cov.repos && user.PublicRepos == 0 && metrics.Repos == 0
+}
+
+// noPublicCode is noPublicCode for the run so far
+func (r *analysisRun) noPublicCode() bool {
+	return noPublicCode(*r.user, r.acc.metrics, r.log.coverage())
+}
+
+// NoPublicCode reports whether the account was scored without public
+// repos, on identity and community alone
+func (a *Analysis) NoPublicCode() bool {
+	for _, finding := range a.Findings {
+		if finding.Code == "NO_PUBLIC_CODE" {
+			return true
+		}
+	}
+	return false
+}
+
+// noPublicCodeRule stands in for the findings an account without public
+// repos would otherwise draw from code it doesn't show
+var noPublicCodeRule = flagRule("NO_PUBLIC_CODE", SeverityInfo, "Account has no public repositories, so only its profile is scored",
+	func(c RuleContext) (string, []any, bool) {
+		return "No public repositories - too little data to judge code, activity or maintenance; the account may work mainly in private repos",
+			nil, c.run.noPublicCode()
+	})
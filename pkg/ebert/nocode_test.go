@@ -0,0 +1,95 @@
+package ebert
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNoPublicCode(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		user    GitHubUser
+		metrics Metrics
+		cov     coverage
+		want    bool
+	}{
+		{"no repos", GitHubUser{}, Metrics{}, coverage{repos: true}, true},
+		// A failed listing is missing data, not an empty account
+		{"listing failed", GitHubUser{}, Metrics{}, coverage{}, false},
+		{"repos listed", GitHubUser{PublicRepos: 2}, Metrics{Repos: 2}, coverage{repos: true}, false},
+		{"profile disagrees", GitHubUser{PublicRepos: 2}, Metrics{}, coverage{repos: true}, false},
+	} {
+		if got := noPublicCode(tt.user, tt.metrics, tt.cov); got != tt.want {
+			t.Errorf("%s: noPublicCode = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeNoPublicCode(t *testing.T) {
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, newAccount("octo", days(3000)))).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if !analysis.NoPublicCode() {
+		t.Fatalf("findings %+v, want NO_PUBLIC_CODE", analysis.Findings)
+	}
+	if flag := finding(analysis, "NO_PUBLIC_CODE"); flag.Severity != SeverityInfo || len(analysis.RedFlags) != 0 {
+		t.Errorf("NO_PUBLIC_CODE = %+v, red flags %q; want info and none", flag, analysis.RedFlags)
+	}
+	// The findings code would have drawn are left out
+	for _, code := range []string{"LOW_ACTIVITY", "NO_SECURITY_POLICY"} {
+		if flag := finding(analysis, code); flag != nil {
+			t.Errorf("unexpected %+v", flag)
+		}
+	}
+
+	scores := analysis.Scores
+	if scores.Identity == nil || scores.Community == nil {
+		t.Errorf("identity %s, community %s; want both scored", formatScore(scores.Identity), formatScore(scores.Community))
+	}
+	if scores.Activity != nil || scores.Quality != nil || scores.Maintenance != nil || scores.Security != nil {
+		t.Errorf("activity %s, quality %s, maintenance %s, security %s; want all uncomputed",
+			formatScore(scores.Activity), formatScore(scores.Quality), formatScore(scores.Maintenance), formatScore(scores.Security))
+	}
+	if analysis.Confidence > maxNoCodeConfidence {
+		t.Errorf("Confidence = %v, want at most %v", analysis.Confidence, maxNoCodeConfidence)
+	}
+
+	var report strings.Builder
+	FprintAnalysis(&report, analysis)
+	if !strings.Contains(report.String(), "may work mainly in private repos") {
+		t.Errorf("report doesn't explain the missing code:\n%s", report.String())
+	}
+	german, err := LoadCatalog("de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.Reset()
+	FprintLocalizedAnalysis(&report, analysis, german)
+	if !strings.Contains(report.String(), "Keine öffentlichen Repositories") {
+		t.Errorf("German report doesn't explain the missing code:\n%s", report.String())
+	}
+}
+
+func TestAnalyzeWithPublicCode(t *testing.T) {
+	// A single idle repo is judged on its code, low activity and all
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, UpdatedAt: fakeNow.Add(-days(200))}))
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if analysis.NoPublicCode() || finding(analysis, "LOW_ACTIVITY") == nil || analysis.Scores.Quality == nil {
+		t.Errorf("findings %+v, quality %s; want the code scored", analysis.Findings, formatScore(analysis.Scores.Quality))
+	}
+
+	// Nor is a listing that failed taken for an empty account
+	f = newFakeGitHub(t, newAccount("octo", days(3000)))
+	f.route("/users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+	})
+	analysis, _ = newFakeAnalyzer(f).Analyze("octo")
+	if analysis == nil || analysis.NoPublicCode() {
+		t.Errorf("a failed repo listing was scored as no public code: %+v", analysis)
+	}
+}
@@ -0,0 +1,49 @@
+package ebert
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultNotFoundTTL is how long a client remembers that an account
+// doesn't exist
+const DefaultNotFoundTTL = 24 * time.Hour
+
+// ErrAccountNotFound is wrapped by analyses of logins GitHub doesn't know,
+// usually deleted or renamed accounts
+var ErrAccountNotFound = errors.New("account does not exist on GitHub")
+
+// notFoundCache remembers logins whose lookup returned 404, so analyses
+// sharing a client don't pay for the same missing account again. Deletions
+// are rarely undone, but renames free the login, hence the expiry.
+type notFoundCache struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+func newNotFoundCache() *notFoundCache {
+	return &notFoundCache{expires: map[string]time.Time{}}
+}
+
+// has reports whether login is remembered as missing at now
+func (c *notFoundCache) has(login string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.ToLower(login)
+	expires, ok := c.expires[key]
+	if ok && !now.Before(expires) {
+		delete(c.expires, key)
+		return false
+	}
+	return ok
+}
+
+// add remembers login as missing until expires
+func (c *notFoundCache) add(login string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires[strings.ToLower(login)] = expires
+}
@@ -0,0 +1,100 @@
+package ebert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotFoundCache(t *testing.T) {
+	c := newNotFoundCache()
+	c.add("Ghost", fakeNow.Add(time.Hour))
+
+	if !c.has("ghost", fakeNow) {
+		t.Error("a remembered login should match regardless of case")
+	}
+	if c.has("other", fakeNow) {
+		t.Error("an unknown login should not be remembered")
+	}
+	if c.has("ghost", fakeNow.Add(time.Hour)) {
+		t.Error("the login should be forgotten once it expires")
+	}
+	if c.has("ghost", fakeNow) {
+		t.Error("an expired login should stay forgotten")
+	}
+}
+
+func TestNotFoundCacheHit(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("real", days(1000)))
+	a := newFakeAnalyzer(f)
+
+	if _, err := a.Analyze("ghost"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("err = %v, want ErrAccountNotFound", err)
+	}
+	sent := f.requests.Load()
+
+	if _, err := a.Analyze("GHOST"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("cached lookup: err = %v, want ErrAccountNotFound", err)
+	}
+	if got := f.requests.Load(); got != sent {
+		t.Errorf("the cached lookup sent %d requests, want none", got-sent)
+	}
+	if got := a.client.RequestStats().CacheHits; got != 1 {
+		t.Errorf("CacheHits = %d, want 1", got)
+	}
+
+	// Without a TTL every lookup asks GitHub again
+	a.client.NotFoundTTL = -1
+	if _, err := a.Analyze("ghost"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("uncached lookup: err = %v, want ErrAccountNotFound", err)
+	}
+	if f.requests.Load() == sent {
+		t.Error("a negative TTL should bypass the cache")
+	}
+}
+
+func TestOrgMembersNotFound(t *testing.T) {
+	f := newFakeGitHub(t,
+		newAccount("acme", days(2000)),
+		newAccount("alice", days(1500)),
+		newAccount("bob", days(900)),
+	)
+	// The listing still names members deleted since
+	f.route("/orgs/acme/members", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]GitHubUser{{Login: "alice"}, {Login: "deleted-1"}, {Login: "bob"}, {Login: "deleted-2"}})
+	})
+
+	org, err := newFakeAnalyzer(f).AnalyzeOrgMembers("acme", 10)
+	if err != nil {
+		t.Fatalf("AnalyzeOrgMembers: %v", err)
+	}
+
+	statuses := map[string]string{}
+	for _, member := range org.Members {
+		statuses[member.Login] = member.Status
+	}
+	for login, want := range map[string]string{"alice": MemberAnalyzed, "bob": MemberAnalyzed, "deleted-1": MemberNotFound, "deleted-2": MemberNotFound} {
+		if statuses[login] != want {
+			t.Errorf("%s: status = %q, want %q", login, statuses[login], want)
+		}
+	}
+	if org.Analyzed != 2 {
+		t.Errorf("Analyzed = %d, want 2", org.Analyzed)
+	}
+	if got := strings.Join(org.NotFound, ","); got != "deleted-1,deleted-2" {
+		t.Errorf("NotFound = %q, want deleted-1,deleted-2", got)
+	}
+
+	var out bytes.Buffer
+	FprintOrgAnalysis(&out, org)
+	if !strings.Contains(out.String(), "2 members no longer exist on GitHub: deleted-1, deleted-2") {
+		t.Errorf("report should warn of the deleted members:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "deleted-1 not analyzed") {
+		t.Errorf("a deleted member should not be reported as a failure:\n%s", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxNPMMaintainerResolutions bounds how many npm co-maintainers are
+// resolved to GitHub accounts, each a registry search and a user lookup
+const maxNPMMaintainerResolutions = 10
+
+// githubOwner returns the account in a GitHub repository link, in any of
+// the forms package metadata uses, e.g. git+ssh://git@github.com/o/r.git
+func githubOwner(link string) (string, bool) {
+	lower := strings.ToLower(link)
+	i := strings.Index(lower, "github.com")
+	if i < 0 {
+		return "", false
+	}
+	rest := strings.TrimLeft(link[i+len("github.com"):], "/:")
+	owner, _, ok := strings.Cut(rest, "/")
+	if !ok || owner == "" {
+		return "", false
+	}
+	return owner, true
+}
+
+// resolveNPMMaintainer guesses an npm user's GitHub account from the
+// repositories of the packages they maintain: the owner most of them link
+// to, leaving out the analyzed user's
+func (a *Analyzer) resolveNPMMaintainer(ctx context.Context, r *analysisRun, name string) (string, error) {
+	results, err := a.client.searchNPMMaintainer(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	counts := map[string]int{}
+	best := ""
+	for _, result := range results {
+		owner, ok := githubOwner(result.Repository)
+		if !ok || strings.EqualFold(owner, r.user.Login) {
+			continue
+		}
+		owner = strings.ToLower(owner)
+		counts[owner]++
+		if counts[owner] > counts[best] || (counts[owner] == counts[best] && owner < best) {
+			best = owner
+		}
+	}
+	return best, nil
+}
+
+// checkPackageCoMaintainers lists the other npm accounts that can publish
+// the user's packages and warns on those with no linked GitHub account, or
+// one too new or empty to vouch for them. It runs with the published
+// manifests: in deep mode with external checks and a token.
+func (a *Analyzer) checkPackageCoMaintainers(ctx context.Context, r *analysisRun) {
+	packages := a.npmPackages(ctx, r)
+	if len(packages) == 0 {
+		return
+	}
+	self := npmUsername(r.user.Login, packages)
+
+	// Each co-maintainer with the packages they can publish, in first-seen
+	// order so resolution favors the top repos
+	var names []string
+	publishes := map[string][]string{}
+	for _, pkg := range packages {
+		for _, maintainer := range pkg.published.Maintainers {
+			name := maintainer.Name
+			if name == "" || strings.EqualFold(name, self) || strings.EqualFold(name, r.user.Login) {
+				continue
+			}
+			if _, ok := publishes[name]; !ok {
+				names = append(names, name)
+			}
+			publishes[name] = append(publishes[name], pkg.published.Name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	r.packageCoMaintainers = append([]string(nil), names...)
+	sort.Strings(r.packageCoMaintainers)
+
+	var evidence []string
+	for i, name := range names {
+		if i == maxNPMMaintainerResolutions || ctx.Err() != nil {
+			break
+		}
+		packageName := publishes[name][0]
+		if len(publishes[name]) > 1 {
+			packageName = fmt.Sprintf("%s (and %d more)", packageName, len(publishes[name])-1)
+		}
+
+		login, err := a.resolveNPMMaintainer(ctx, r, name)
+		if err != nil {
+			r.log.fellBack("registry", fmt.Errorf("failed to search npm packages for %s: %w", name, err))
+			continue
+		}
+		if login == "" {
+			evidence = append(evidence, fmt.Sprintf("package %s can also be published by npm user '%s' with no linked GitHub account", packageName, name))
+			continue
+		}
+
+		user, err := a.client.GetUser(ctx, login)
+		switch {
+		case isNotFound(err):
+			evidence = append(evidence, fmt.Sprintf("package %s can also be published by npm user '%s', whose linked GitHub account %s no longer exists", packageName, name, login))
+		case err != nil:
+			continue
+		case r.now.Sub(user.CreatedAt) < a.opts.NewAccountThreshold:
+			evidence = append(evidence, fmt.Sprintf("package %s can also be published by npm user '%s', linked to GitHub account %s created %s", packageName, name, user.Login, user.CreatedAt.Format("2006-01-02")))
+		case user.PublicRepos == 0 && user.Followers == 0:
+			evidence = append(evidence, fmt.Sprintf("package %s can also be published by npm user '%s', linked to GitHub account %s with no public repos or followers", packageName, name, user.Login))
+		}
+	}
+
+	if len(evidence) > 0 {
+		r.addFinding(Finding{
+			Code:     "UNVETTED_NPM_CO_MAINTAINER",
+			Severity: SeverityWarning,
+			Message:  "Published npm packages can also be published by accounts with no established GitHub presence",
+			Evidence: evidence,
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGitHubOwner(t *testing.T) {
+	for _, tt := range []struct {
+		link, want string
+		ok         bool
+	}{
+		{"https://github.com/octo/tool", "octo", true},
+		{"git+ssh://git@github.com/Octo/tool.git", "Octo", true},
+		{"git@github.com:octo/tool.git", "octo", true},
+		{"https://gitlab.com/octo/tool", "", false},
+		{"https://github.com/octo", "", false},
+		{"", "", false},
+	} {
+		if got, ok := githubOwner(tt.link); got != tt.want || ok != tt.ok {
+			t.Errorf("githubOwner(%q) = %q, %t; want %q, %t", tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// npmMaintainersFake serves octo's JavaScript repos, each published to npm
+// with the given maintainers, and answers the registry's maintainer search
+// with packages linking to links[name]. It counts the searches for
+// co-maintainers.
+func npmMaintainersFake(t *testing.T, maintainers map[string][]string, links map[string][]string, accounts ...*fakeAccount) (*fakeGitHub, *atomic.Int32) {
+	var repos []GitHubRepo
+	for name := range maintainers {
+		repos = append(repos, GitHubRepo{Name: name, Language: "JavaScript", Size: 400, UpdatedAt: fakeNow.Add(-days(3))})
+	}
+	// Starred in name order, so the first name is the top repo
+	slices.SortFunc(repos, func(x, y GitHubRepo) int { return strings.Compare(x.Name, y.Name) })
+	for i := range repos {
+		repos[i].StargazersCount = 100 - i
+	}
+	f := newFakeGitHub(t, append([]*fakeAccount{newAccount("octo", days(3000), repos...)}, accounts...)...)
+	for name := range maintainers {
+		f.route("/repos/octo/"+name+"/contents/package.json", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"name":%q,"version":"1.0.0"}`, name)
+		})
+	}
+
+	var searches atomic.Int32
+	f.host("registry.npmjs.org", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/-/v1/search" {
+			name := strings.TrimPrefix(r.URL.Query().Get("text"), "maintainer:")
+			var objects []map[string]any
+			if name != "octo" {
+				searches.Add(1)
+				for i, link := range links[name] {
+					objects = append(objects, map[string]any{"package": map[string]any{"name": fmt.Sprintf("%s-pkg%d", name, i), "links": map[string]string{"repository": link}}})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"objects": objects})
+			return
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		names, ok := maintainers[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var listed []map[string]string
+		for _, maintainer := range names {
+			listed = append(listed, map[string]string{"name": maintainer, "email": maintainer + "@example.com"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": name, "version": "1.0.0", "maintainers": listed})
+	})
+	return f, &searches
+}
+
+func TestCheckPackageCoMaintainers(t *testing.T) {
+	hollow := newAccount("hollow", days(2000))
+	hollow.User.Followers = 0
+	f, searches := npmMaintainersFake(t,
+		map[string][]string{
+			"gadget": {"octo", "zzz", "hollow-npm"},
+			"widget": {"octo", "alice", "zzz", "newbie", "gone-npm"},
+		},
+		map[string][]string{
+			// The analyzed user's own repos don't count toward the guess
+			"alice":      {"https://github.com/alice/a", "git+https://github.com/alice/b.git", "https://github.com/octo/widget"},
+			"zzz":        {"", "https://gitlab.com/zzz/x"},
+			"newbie":     {"git+ssh://git@github.com/Fresh-Dev/tool.git"},
+			"gone-npm":   {"https://github.com/gone/x"},
+			"hollow-npm": {"https://github.com/hollow/x"},
+		},
+		newAccount("alice", days(2500)), newAccount("fresh-dev", days(10)), hollow,
+	)
+
+	analysis, err := newFakeAnalyzerToken(f, "ghp_test", WithDeepChecks(true), WithExternalChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if want := []string{"alice", "gone-npm", "hollow-npm", "newbie", "zzz"}; !slices.Equal(analysis.PackageCoMaintainers, want) {
+		t.Errorf("PackageCoMaintainers = %q, want %q", analysis.PackageCoMaintainers, want)
+	}
+	if searches.Load() != 5 {
+		t.Errorf("searched for %d co-maintainers, want 5", searches.Load())
+	}
+
+	// In first-seen order, the top repo's first; alice is established
+	flag := finding(analysis, "UNVETTED_NPM_CO_MAINTAINER")
+	want := []string{
+		"package gadget (and 1 more) can also be published by npm user 'zzz' with no linked GitHub account",
+		"package gadget can also be published by npm user 'hollow-npm', linked to GitHub account hollow with no public repos or followers",
+		"package widget can also be published by npm user 'newbie', linked to GitHub account fresh-dev created 2024-05-22",
+		"package widget can also be published by npm user 'gone-npm', whose linked GitHub account gone no longer exists",
+	}
+	if flag == nil || flag.Severity != SeverityWarning || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("UNVETTED_NPM_CO_MAINTAINER = %+v, want %q", flag, want)
+	}
+}
+
+func TestCheckPackageCoMaintainersCap(t *testing.T) {
+	names := []string{"octo"}
+	for i := range maxNPMMaintainerResolutions + 2 {
+		names = append(names, fmt.Sprintf("npm%02d", i))
+	}
+	f, searches := npmMaintainersFake(t, map[string][]string{"widget": names}, nil)
+
+	analysis, err := newFakeAnalyzerToken(f, "ghp_test", WithDeepChecks(true), WithExternalChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// All are listed, but only the first few are looked up
+	flag := finding(analysis, "UNVETTED_NPM_CO_MAINTAINER")
+	if len(analysis.PackageCoMaintainers) != len(names)-1 || searches.Load() != maxNPMMaintainerResolutions || flag == nil || len(flag.Evidence) != maxNPMMaintainerResolutions {
+		t.Errorf("listed %d, searched %d, flagged %+v; want %d listed and %d searched and flagged",
+			len(analysis.PackageCoMaintainers), searches.Load(), flag, len(names)-1, maxNPMMaintainerResolutions)
+	}
+}
+
+func TestCheckPackageCoMaintainersGated(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		token string
+		opts  []Option
+	}{
+		{"anonymous", "", []Option{WithDeepChecks(true), WithExternalChecks(true)}},
+		{"not deep", "ghp_test", []Option{WithExternalChecks(true)}},
+		{"no external checks", "ghp_test", []Option{WithDeepChecks(true), WithExternalChecks(false)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, searches := npmMaintainersFake(t, map[string][]string{"widget": {"octo", "zzz"}}, nil)
+			analysis, err := newFakeAnalyzerToken(f, tt.token, tt.opts...).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if searches.Load() != 0 || analysis.PackageCoMaintainers != nil || finding(analysis, "UNVETTED_NPM_CO_MAINTAINER") != nil {
+				t.Errorf("searched %d times, co-maintainers %q", searches.Load(), analysis.PackageCoMaintainers)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package ebert
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// openMetricsLabel escapes a label value for the OpenMetrics text format
+var openMetricsLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteOpenMetrics writes the scores of analyses as one OpenMetrics text
+// document, e.g. for the node-exporter textfile collector. Each metric
+// family lists every analysis, labeled by login; sub-scores that weren't
+// computed are left out.
+func WriteOpenMetrics(w io.Writer, analyses []*Analysis) error {
+	out := bufio.NewWriter(w)
+	family := func(name, kind, unit, help string) {
+		out.WriteString("# TYPE " + name + " " + kind + "\n")
+		if unit != "" {
+			out.WriteString("# UNIT " + name + " " + unit + "\n")
+		}
+		out.WriteString("# HELP " + name + " " + help + "\n")
+	}
+	sample := func(name string, value float64, labels ...string) {
+		out.WriteString(name + "{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString(labels[i] + `="` + openMetricsLabel.Replace(labels[i+1]) + `"`)
+		}
+		out.WriteString("} " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+	}
+
+	family("ebert_overall_score", "gauge", "", "Overall risk score from 0 to 100, lower is better.")
+	for _, analysis := range analyses {
+		sample("ebert_overall_score", analysis.OverallScore, "login", analysis.User.Login)
+	}
+
+	family("ebert_subscore", "gauge", "", "Risk score of one dimension from 0 to 100, lower is better.")
+	for _, analysis := range analyses {
+		for _, dimension := range []struct {
+			name  string
+			score *float64
+		}{
+			{"identity", analysis.Scores.Identity},
+			{"activity", analysis.Scores.Activity},
+			{"quality", analysis.Scores.Quality},
+			{"maintenance", analysis.Scores.Maintenance},
+			{"community", analysis.Scores.Community},
+			{"security", analysis.Scores.Security},
+		} {
+			if dimension.score != nil {
+				sample("ebert_subscore", *dimension.score, "login", analysis.User.Login, "dimension", dimension.name)
+			}
+		}
+	}
+
+	family("ebert_red_flags", "counter", "", "Red flags raised by the latest analysis.")
+	for _, analysis := range analyses {
+		sample("ebert_red_flags_total", float64(len(analysis.RedFlags)), "login", analysis.User.Login)
+	}
+
+	family("ebert_analysis_timestamp_seconds", "gauge", "seconds", "When the analysis ran, as a Unix time.")
+	for _, analysis := range analyses {
+		// Stable analyses carry no timestamp
+		if analysis.Timestamp.IsZero() {
+			continue
+		}
+		seconds := float64(analysis.Timestamp.UnixNano()) / 1e9
+		sample("ebert_analysis_timestamp_seconds", seconds, "login", analysis.User.Login)
+	}
+
+	out.WriteString("# EOF\n")
+	return out.Flush()
+}
@@ -0,0 +1,289 @@
+package ebert
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// metricFamily is one family of a parsed OpenMetrics document
+type metricFamily struct {
+	kind, unit, help string
+	samples          []metricSample
+}
+
+type metricSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseOpenMetrics reads an OpenMetrics text document strictly: metadata
+// before a family's samples, sample names the family's type allows,
+// families never reopened, escaped label values and a closing # EOF
+func parseOpenMetrics(doc string) (map[string]*metricFamily, error) {
+	if !strings.HasSuffix(doc, "# EOF\n") {
+		return nil, fmt.Errorf("the document doesn't end with # EOF")
+	}
+	lines := strings.Split(strings.TrimSuffix(doc, "# EOF\n"), "\n")
+	lines = lines[:len(lines)-1]
+
+	families := map[string]*metricFamily{}
+	var name string
+	var current *metricFamily
+	for i, line := range lines {
+		if meta, ok := strings.CutPrefix(line, "# "); ok {
+			keyword, rest, _ := strings.Cut(meta, " ")
+			family, value, _ := strings.Cut(rest, " ")
+			if family != name {
+				if families[family] != nil {
+					return nil, fmt.Errorf("line %d reopens family %s", i+1, family)
+				}
+				name, current = family, &metricFamily{}
+				families[name] = current
+			}
+			if len(current.samples) > 0 {
+				return nil, fmt.Errorf("line %d: %s metadata after its samples", i+1, family)
+			}
+			switch keyword {
+			case "TYPE":
+				current.kind = value
+			case "UNIT":
+				if !strings.HasSuffix(family, "_"+value) {
+					return nil, fmt.Errorf("line %d: family %s doesn't end in its unit %s", i+1, family, value)
+				}
+				current.unit = value
+			case "HELP":
+				current.help = value
+			default:
+				return nil, fmt.Errorf("line %d: unknown metadata %q", i+1, line)
+			}
+			continue
+		}
+
+		sample, err := parseMetricSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		want := name
+		if current != nil && current.kind == "counter" {
+			want = name + "_total"
+		}
+		if current == nil || sample.name != want {
+			return nil, fmt.Errorf("line %d: sample %s outside a family that allows it, want %s", i+1, sample.name, want)
+		}
+		current.samples = append(current.samples, sample)
+	}
+	return families, nil
+}
+
+// parseMetricSample reads name{label="value",...} value
+func parseMetricSample(line string) (metricSample, error) {
+	sample := metricSample{labels: map[string]string{}}
+	name, rest, ok := strings.Cut(line, "{")
+	if !ok {
+		return sample, fmt.Errorf("sample %q has no labels", line)
+	}
+	sample.name = name
+	for !strings.HasPrefix(rest, "}") {
+		label, after, ok := strings.Cut(rest, `="`)
+		if !ok {
+			return sample, fmt.Errorf("sample %q has a malformed label", line)
+		}
+		var value strings.Builder
+		for i := 0; ; i++ {
+			if i >= len(after) {
+				return sample, fmt.Errorf("sample %q has an unterminated label value", line)
+			}
+			c := after[i]
+			if c == '"' {
+				rest = strings.TrimPrefix(after[i+1:], ",")
+				break
+			}
+			if c == '\n' {
+				return sample, fmt.Errorf("sample %q has a raw newline in a label value", line)
+			}
+			if c == '\\' {
+				if i++; i >= len(after) {
+					return sample, fmt.Errorf("sample %q ends in an escape", line)
+				}
+				switch after[i] {
+				case '\\', '"':
+					c = after[i]
+				case 'n':
+					c = '\n'
+				default:
+					return sample, fmt.Errorf("sample %q has an unknown escape \\%c", line, after[i])
+				}
+			}
+			value.WriteByte(c)
+		}
+		if _, dup := sample.labels[label]; dup {
+			return sample, fmt.Errorf("sample %q repeats label %s", line, label)
+		}
+		sample.labels[label] = value.String()
+	}
+	value, ok := strings.CutPrefix(rest, "} ")
+	if !ok {
+		return sample, fmt.Errorf("sample %q has no value", line)
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return sample, fmt.Errorf("sample %q: %w", line, err)
+	}
+	sample.value = n
+	return sample, nil
+}
+
+func TestWriteOpenMetricsParses(t *testing.T) {
+	awkward := "quo\"te\\back\nslash"
+	analyses := []*Analysis{
+		{
+			User:         GitHubUser{Login: "octo"},
+			Scores:       RiskScores{Identity: computed(15), Security: computed(65)},
+			OverallScore: 38.5,
+			RedFlags:     []string{"one", "two"},
+			Timestamp:    time.Unix(1717243200, 500_000_000),
+		},
+		// Label values are escaped, and a stable analysis has no timestamp
+		{User: GitHubUser{Login: awkward}, OverallScore: 70},
+	}
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, analyses); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := parseOpenMetrics(buf.String())
+	if err != nil {
+		t.Fatalf("the output doesn't parse: %v\n%s", err, buf.String())
+	}
+	for name, kind := range map[string]string{
+		"ebert_overall_score":              "gauge",
+		"ebert_subscore":                   "gauge",
+		"ebert_red_flags":                  "counter",
+		"ebert_analysis_timestamp_seconds": "gauge",
+	} {
+		if family := families[name]; family == nil || family.kind != kind || family.help == "" {
+			t.Errorf("family %s = %+v, want a %s with help", name, family, kind)
+		}
+	}
+	if len(families) != 4 {
+		t.Errorf("parsed %d families, want 4", len(families))
+	}
+
+	value := func(family string, labels ...string) (float64, bool) {
+		t.Helper()
+	samples:
+		for _, sample := range families[family].samples {
+			if len(sample.labels) != len(labels)/2 {
+				continue
+			}
+			for i := 0; i < len(labels); i += 2 {
+				if sample.labels[labels[i]] != labels[i+1] {
+					continue samples
+				}
+			}
+			return sample.value, true
+		}
+		return 0, false
+	}
+	for _, tc := range []struct {
+		family string
+		labels []string
+		want   float64
+	}{
+		{"ebert_overall_score", []string{"login", "octo"}, 38.5},
+		{"ebert_overall_score", []string{"login", awkward}, 70},
+		{"ebert_subscore", []string{"login", "octo", "dimension", "identity"}, 15},
+		{"ebert_subscore", []string{"login", "octo", "dimension", "security"}, 65},
+		{"ebert_red_flags", []string{"login", "octo"}, 2},
+		{"ebert_red_flags", []string{"login", awkward}, 0},
+		{"ebert_analysis_timestamp_seconds", []string{"login", "octo"}, 1717243200.5},
+	} {
+		if got, ok := value(tc.family, tc.labels...); !ok || got != tc.want {
+			t.Errorf("%s%q = %g (found %t), want %g", tc.family, tc.labels, got, ok, tc.want)
+		}
+	}
+	if n := len(families["ebert_subscore"].samples); n != 2 {
+		t.Errorf("wrote %d sub-scores, want only the 2 computed", n)
+	}
+	if _, ok := value("ebert_analysis_timestamp_seconds", "login", awkward); ok {
+		t.Error("wrote a timestamp for the stable analysis")
+	}
+	if samples := families["ebert_red_flags"].samples; len(samples) == 0 || samples[0].name != "ebert_red_flags_total" {
+		t.Errorf("red flag counter samples %+v, want ebert_red_flags_total", samples)
+	}
+}
+
+func TestWriteOpenMetricsEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	families, err := parseOpenMetrics(buf.String())
+	if err != nil {
+		t.Fatalf("the output doesn't parse: %v\n%s", err, buf.String())
+	}
+	for name, family := range families {
+		if len(family.samples) != 0 {
+			t.Errorf("family %s has samples without analyses", name)
+		}
+	}
+}
+
+func TestParseOpenMetricsStrict(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no EOF":             "# TYPE a gauge\na{l=\"x\"} 1\n",
+		"counter sample":     "# TYPE a counter\na{l=\"x\"} 1\n# EOF\n",
+		"raw quote":          "# TYPE a gauge\na{l=\"x\"y\"} 1\n# EOF\n",
+		"unknown escape":     "# TYPE a gauge\na{l=\"x\\t\"} 1\n# EOF\n",
+		"reopened family":    "# TYPE a gauge\na{l=\"x\"} 1\n# TYPE b gauge\n# TYPE a gauge\n# EOF\n",
+		"unit isn't suffix":  "# TYPE a gauge\n# UNIT a seconds\n# EOF\n",
+		"sample outside one": "a{l=\"x\"} 1\n# EOF\n",
+	} {
+		if _, err := parseOpenMetrics(doc); err == nil {
+			t.Errorf("%s: parsed %q", name, doc)
+		}
+	}
+}
+
+func TestBatchOpenMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewBatchWriter(&buf, "openmetrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range []BatchResult{
+		{Login: "octo", Status: MemberAnalyzed, Analysis: &Analysis{User: GitHubUser{Login: "octo"}, OverallScore: 20, RedFlags: []string{"x"}}},
+		{Login: "gone", Status: MemberNotFound, Error: "not found"},
+		{Login: "hubot", Status: MemberAnalyzed, Analysis: &Analysis{User: GitHubUser{Login: "hubot"}, OverallScore: 40}},
+	} {
+		if err := writer.Write(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("wrote %q before Close; families must list every account", buf.String())
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := parseOpenMetrics(buf.String())
+	if err != nil {
+		t.Fatalf("the batch output doesn't parse: %v\n%s", err, buf.String())
+	}
+	var logins []string
+	for _, sample := range families["ebert_overall_score"].samples {
+		logins = append(logins, sample.labels["login"])
+	}
+	if strings.Join(logins, " ") != "octo hubot" {
+		t.Errorf("scored %q, want the analyzed accounts in order", logins)
+	}
+	if strings.Count(buf.String(), "# EOF") != 1 {
+		t.Errorf("the batch wrote %d documents, want one", strings.Count(buf.String(), "# EOF"))
+	}
+}
@@ -0,0 +1,755 @@
+package ebert
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultActivityWindow is how far back events count as recent activity
+const DefaultActivityWindow = 90 * 24 * time.Hour
+
+// Weights sets the relative contribution of each sub-score to the overall score
+type Weights struct {
+	Identity    float64 `json:"identity"`
+	Activity    float64 `json:"activity"`
+	Quality     float64 `json:"quality"`
+	Maintenance float64 `json:"maintenance"`
+	Community   float64 `json:"community"`
+	Security    float64 `json:"security"`
+}
+
+// DefaultWeights weighs every dimension equally
+func DefaultWeights() Weights {
+	return Weights{Identity: 1, Activity: 1, Quality: 1, Maintenance: 1, Community: 1, Security: 1}
+}
+
+func (w Weights) validate() error {
+	values := []float64{w.Identity, w.Activity, w.Quality, w.Maintenance, w.Community, w.Security}
+	total := 0.0
+	for _, value := range values {
+		if value < 0 {
+			return errors.New("weights must not be negative")
+		}
+		total += value
+	}
+	if total == 0 {
+		return errors.New("at least one weight must be positive")
+	}
+	return nil
+}
+
+// AnalyzerOptions is the effective configuration of an Analyzer
+type AnalyzerOptions struct {
+	HTTPClient     *http.Client  `json:"-"`
+	BaseURL        string        `json:"base_url"`
+	Weights        Weights       `json:"weights"`
+	ActivityWindow time.Duration `json:"activity_window"`
+	Logger         *slog.Logger  `json:"-"`
+	DeepChecks     bool          `json:"deep_checks"`
+	Gists          bool          `json:"gists"`
+	TopRepos       int           `json:"top_repos"`
+	ExternalChecks bool          `json:"external_checks"`
+
+	// NewAccountThreshold is the age below which an account is new
+	NewAccountThreshold time.Duration `json:"new_account_threshold"`
+
+	// GeneratedContent sets when the deep generated-content check warns
+	GeneratedContent GeneratedContentThresholds `json:"generated_content"`
+
+	// CoMaintainerDepth is how many hops of co-maintainers get a shallow
+	// analysis of their own; zero only lists them
+	CoMaintainerDepth int `json:"co_maintainer_depth"`
+
+	// ScoreAllRepos bases quality and maintenance on every repo rather
+	// than only original ones
+	ScoreAllRepos bool `json:"score_all_repos"`
+
+	// ScoringVersion selects the scoring formulas, ScoringV1 by default;
+	// FreshnessHalfLife sets the decay behind MaintenanceFreshness
+	ScoringVersion    int           `json:"scoring_version"`
+	FreshnessHalfLife time.Duration `json:"freshness_half_life"`
+
+	// StarHorizon and StarFalloff set how WeightedStars discounts the
+	// stars of repos not pushed to within the horizon
+	StarHorizon time.Duration `json:"star_horizon"`
+	StarFalloff string        `json:"star_falloff"`
+
+	// InternalNamePatterns are the organization's own internal package
+	// prefixes, checked alongside DefaultInternalNamePatterns
+	InternalNamePatterns []string `json:"internal_name_patterns,omitempty"`
+
+	// TutorialKeywords replace DefaultTutorialKeywords when set
+	TutorialKeywords []string `json:"tutorial_keywords,omitempty"`
+
+	// InstallScripts inspects published npm install scripts in deep mode
+	InstallScripts bool `json:"install_scripts"`
+
+	// VerifyArtifacts compares the latest published npm and PyPI artifacts
+	// with their repos in deep mode; ArtifactIgnore adds to
+	// DefaultArtifactIgnore
+	VerifyArtifacts bool     `json:"verify_artifacts"`
+	ArtifactIgnore  []string `json:"artifact_ignore,omitempty"`
+
+	// StrictAuth fails the analysis with ErrUnauthorized when GitHub
+	// rejects the token instead of continuing unauthenticated
+	StrictAuth bool `json:"strict_auth"`
+
+	// Seed pins the seed of sampled checks; zero derives one per analysis
+	// from the login and the UTC date
+	Seed uint64 `json:"seed,omitempty"`
+
+	// TokenSource supplies the token in place of the one given to New
+	TokenSource TokenSource `json:"-"`
+
+	// EngagementRings looks for star-for-star and follow-back rings in
+	// deep mode
+	EngagementRings bool `json:"engagement_rings"`
+
+	// AnalysisTimeout bounds a whole analysis and RequestTimeout each
+	// request; zero disables either
+	AnalysisTimeout time.Duration `json:"analysis_timeout"`
+	RequestTimeout  time.Duration `json:"request_timeout"`
+
+	// ExternalTimeout bounds each request to a host other than the GitHub
+	// API, such as a package registry; IntegrationTimeouts overrides it per
+	// host
+	ExternalTimeout     time.Duration            `json:"external_timeout"`
+	IntegrationTimeouts map[string]time.Duration `json:"integration_timeouts,omitempty"`
+
+	// BreakerThreshold is how many consecutive failures of an external host
+	// skip its remaining requests, across every analysis the Analyzer runs;
+	// zero disables the breaker
+	BreakerThreshold int `json:"breaker_threshold"`
+
+	// AllowedHosts, when set, are the only hosts requests are sent to;
+	// Offline allows none, so only a replayed tape answers
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+	Offline      bool     `json:"offline"`
+
+	// MinRequestsPerSecond and MaxRequestsPerSecond bound the pacing of API
+	// requests, which adapts to the remaining rate limit budget
+	MinRequestsPerSecond float64 `json:"min_requests_per_second"`
+	MaxRequestsPerSecond float64 `json:"max_requests_per_second"`
+
+	// MaxRepos caps the repos analyzed; zero means unlimited for users and
+	// DefaultOrgMaxRepos for organizations, negative means unlimited
+	MaxRepos int `json:"max_repos"`
+
+	// RepoList sets the query options of the repo listing
+	RepoList RepoListOptions `json:"repo_list"`
+
+	// Denylist extends the built-in list of accounts named in advisories
+	Denylist []DenylistEntry `json:"denylist,omitempty"`
+
+	RequestStats bool   `json:"request_stats"`
+	Tracer       Tracer `json:"-"`
+
+	// ProvenanceVerifier checks release signatures in deep mode; without
+	// one, signatures are only detected
+	ProvenanceVerifier ProvenanceVerifier `json:"-"`
+
+	OnRequest  func(*http.Request)                 `json:"-"`
+	OnResponse func(*http.Response, time.Duration) `json:"-"`
+
+	// Clock supplies the analysis time; nil means time.Now
+	Clock func() time.Time `json:"-"`
+
+	// ResultCache serves repeat analyses for ResultCacheTTL; nil disables it
+	ResultCache    ResultCache   `json:"-"`
+	ResultCacheTTL time.Duration `json:"-"`
+}
+
+// now is the current analysis time in UTC
+func (o *AnalyzerOptions) now() time.Time {
+	if o.Clock != nil {
+		return o.Clock().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func defaultOptions() AnalyzerOptions {
+	return AnalyzerOptions{
+		BaseURL:        "https://api.github.com",
+		Weights:        DefaultWeights(),
+		ActivityWindow: DefaultActivityWindow,
+		Logger:         slog.New(slog.DiscardHandler),
+		Tracer:         noopTracer{},
+		Gists:          true,
+		TopRepos:       DefaultTopRepos,
+		ExternalChecks: true,
+
+		NewAccountThreshold:  DefaultNewAccountThreshold,
+		GeneratedContent:     DefaultGeneratedContentThresholds(),
+		InstallScripts:       true,
+		ScoringVersion:       ScoringV1,
+		FreshnessHalfLife:    DefaultFreshnessHalfLife,
+		StarHorizon:          DefaultStarHorizon,
+		StarFalloff:          StarFalloffLinear,
+		AnalysisTimeout:      DefaultAnalysisTimeout,
+		RequestTimeout:       DefaultRequestTimeout,
+		ExternalTimeout:      DefaultExternalTimeout,
+		BreakerThreshold:     DefaultBreakerThreshold,
+		MinRequestsPerSecond: DefaultMinRequestsPerSecond,
+		MaxRequestsPerSecond: DefaultMaxRequestsPerSecond,
+		RepoList:             DefaultRepoListOptions(),
+	}
+}
+
+// Option configures an Analyzer. Options are validated when applied by New.
+type Option func(*AnalyzerOptions) error
+
+// WithHTTPClient sends API requests through hc instead of a default client
+// with a ten second timeout, e.g. WithHTTPClient(&http.Client{Timeout: time.Minute})
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *AnalyzerOptions) error {
+		if hc == nil {
+			return errors.New("http client must not be nil")
+		}
+		o.HTTPClient = hc
+		return nil
+	}
+}
+
+// WithClock fixes what "now" is for account ages and activity windows,
+// e.g. to the time a replayed tape was recorded
+func WithClock(now func() time.Time) Option {
+	return func(o *AnalyzerOptions) error {
+		if now == nil {
+			return errors.New("clock must not be nil")
+		}
+		o.Clock = now
+		return nil
+	}
+}
+
+// WithBaseURL points the analyzer at another API host, such as a GitHub
+// Enterprise server: WithBaseURL("https://github.example.com/api/v3")
+func WithBaseURL(u string) Option {
+	return func(o *AnalyzerOptions) error {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("invalid base url: %w", err)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid base url %q: scheme and host are required", u)
+		}
+		o.BaseURL = u
+		return nil
+	}
+}
+
+// WithWeights replaces the sub-score weights, for example doubling the
+// weight of identity: WithWeights(Weights{Identity: 2, Activity: 1, ...})
+func WithWeights(w Weights) Option {
+	return func(o *AnalyzerOptions) error {
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("invalid weights: %w", err)
+		}
+		o.Weights = w
+		return nil
+	}
+}
+
+// WithActivityWindow sets how far back events count as recent activity,
+// e.g. WithActivityWindow(30 * 24 * time.Hour) for the last month
+func WithActivityWindow(window time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if window < 24*time.Hour {
+			return fmt.Errorf("activity window %s is shorter than a day", window)
+		}
+		o.ActivityWindow = window
+		return nil
+	}
+}
+
+// WithLogger sends diagnostic output to l, e.g. WithLogger(slog.Default())
+func WithLogger(l *slog.Logger) Option {
+	return func(o *AnalyzerOptions) error {
+		if l == nil {
+			return errors.New("logger must not be nil")
+		}
+		o.Logger = l
+		return nil
+	}
+}
+
+// WithDeepChecks enables the checks that cost extra API or registry requests
+func WithDeepChecks(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.DeepChecks = enabled
+		return nil
+	}
+}
+
+// WithRequestStats adds the per-endpoint breakdown to each Analysis's
+// request statistics, which is mostly useful when debugging API usage
+func WithRequestStats(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.RequestStats = enabled
+		return nil
+	}
+}
+
+// WithRequestHooks installs hooks called around every API request, for
+// example to add tracing headers or feed an audit log. Either may be nil.
+func WithRequestHooks(onRequest func(*http.Request), onResponse func(*http.Response, time.Duration)) Option {
+	return func(o *AnalyzerOptions) error {
+		o.OnRequest = onRequest
+		o.OnResponse = onResponse
+		return nil
+	}
+}
+
+// WithTracer traces each analysis and its API calls through t. Spans are
+// children of whatever span the caller's context carries.
+func WithTracer(t Tracer) Option {
+	return func(o *AnalyzerOptions) error {
+		if t == nil {
+			return errors.New("tracer must not be nil")
+		}
+		o.Tracer = t
+		return nil
+	}
+}
+
+// WithProvenanceVerifier verifies release signatures and provenance found
+// in deep mode through v; see the sigstoreebert package for Sigstore
+func WithProvenanceVerifier(v ProvenanceVerifier) Option {
+	return func(o *AnalyzerOptions) error {
+		if v == nil {
+			return errors.New("provenance verifier must not be nil")
+		}
+		o.ProvenanceVerifier = v
+		return nil
+	}
+}
+
+// WithResultCache serves Analyze and AnalyzeContext from cache when it
+// holds a complete analysis of the account made with the same options
+// within ttl, DefaultResultCacheTTL if zero, and stores new ones in it
+func WithResultCache(cache ResultCache, ttl time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if cache == nil {
+			return errors.New("result cache must not be nil")
+		}
+		if ttl < 0 {
+			return fmt.Errorf("result cache TTL must not be negative, got %s", ttl)
+		}
+		o.ResultCache, o.ResultCacheTTL = cache, cmp.Or(ttl, DefaultResultCacheTTL)
+		return nil
+	}
+}
+
+// WithGists enables or skips the gist activity and secret-leak checks
+func WithGists(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.Gists = enabled
+		return nil
+	}
+}
+
+// WithTopRepos sets how many of the most-starred repos get per-repo checks
+func WithTopRepos(n int) Option {
+	return func(o *AnalyzerOptions) error {
+		if n < 0 {
+			return fmt.Errorf("top repos must not be negative, got %d", n)
+		}
+		o.TopRepos = n
+		return nil
+	}
+}
+
+// WithExternalChecks allows or forbids requests to hosts other than the
+// GitHub API, such as probing documentation sites
+func WithExternalChecks(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.ExternalChecks = enabled
+		return nil
+	}
+}
+
+// WithNewAccountThreshold sets the age below which accounts are flagged as
+// new and scored with reduced confidence, e.g. 30 * 24 * time.Hour
+func WithNewAccountThreshold(threshold time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if threshold < 0 {
+			return fmt.Errorf("new account threshold must not be negative, got %s", threshold)
+		}
+		o.NewAccountThreshold = threshold
+		return nil
+	}
+}
+
+// WithGeneratedContentThresholds sets when sampled commit messages and
+// READMEs count as generated, e.g. raising MinCommits for prolific accounts
+func WithGeneratedContentThresholds(t GeneratedContentThresholds) Option {
+	return func(o *AnalyzerOptions) error {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("invalid generated content thresholds: %w", err)
+		}
+		o.GeneratedContent = t
+		return nil
+	}
+}
+
+// WithScoreAllRepos restores scoring quality and maintenance over every
+// repo, including forks, templates, mirrors and meta repos
+func WithScoreAllRepos(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.ScoreAllRepos = enabled
+		return nil
+	}
+}
+
+// WithScoringVersion selects the scoring formulas: ScoringV1, the
+// default, or ScoringV2, which replaces the 30-day recently-updated cutoff
+// in the Maintenance score with decaying MaintenanceFreshness and bases
+// the Quality score on WeightedStars
+func WithScoringVersion(version int) Option {
+	return func(o *AnalyzerOptions) error {
+		if version < ScoringV1 || version > latestScoringVersion {
+			return fmt.Errorf("scoring version must be between %d and %d, got %d", ScoringV1, latestScoringVersion, version)
+		}
+		o.ScoringVersion = version
+		return nil
+	}
+}
+
+// WithFreshnessHalfLife sets how long after its last push a repo counts
+// half as fresh in MaintenanceFreshness, e.g. 90 * 24 * time.Hour
+func WithFreshnessHalfLife(halfLife time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if halfLife <= 0 {
+			return fmt.Errorf("freshness half-life must be positive, got %s", halfLife)
+		}
+		o.FreshnessHalfLife = halfLife
+		return nil
+	}
+}
+
+// WithStarAgeWeighting sets how WeightedStars discounts stars on repos
+// whose last push is older than horizon: StarFalloffLinear to nothing over
+// a further horizon, or StarFalloffExponential halving every horizon
+func WithStarAgeWeighting(horizon time.Duration, falloff string) Option {
+	return func(o *AnalyzerOptions) error {
+		if horizon <= 0 {
+			return fmt.Errorf("star horizon must be positive, got %s", horizon)
+		}
+		if falloff != StarFalloffLinear && falloff != StarFalloffExponential {
+			return fmt.Errorf("star falloff must be %s or %s, got %q", StarFalloffLinear, StarFalloffExponential, falloff)
+		}
+		o.StarHorizon, o.StarFalloff = horizon, falloff
+		return nil
+	}
+}
+
+// WithInternalNamePatterns adds internal package name fragments to flag as
+// dependency-confusion candidates, e.g. WithInternalNamePatterns("acme-").
+// A pattern between slashes, such as "/^acme-/", is an RE2 regex; see
+// PatternSet.
+func WithInternalNamePatterns(patterns ...string) Option {
+	return func(o *AnalyzerOptions) error {
+		set, err := NewPatternSet(append(slices.Clone(o.InternalNamePatterns), patterns...), DefaultPatternLimits)
+		if err != nil {
+			return fmt.Errorf("invalid internal name patterns: %w", err)
+		}
+		o.InternalNamePatterns = set.Entries()
+		return nil
+	}
+}
+
+// WithInternalNamePatternsFile adds the internal name patterns in a file
+// of one per line, read with LoadPatternSet under DefaultPatternLimits
+func WithInternalNamePatternsFile(path string) Option {
+	return func(o *AnalyzerOptions) error {
+		set, err := LoadPatternSet(path, DefaultPatternLimits)
+		if err != nil {
+			return err
+		}
+		return WithInternalNamePatterns(set.Entries()...)(o)
+	}
+}
+
+// WithTutorialKeywords replaces DefaultTutorialKeywords, the name and
+// description fragments marking a repo as a tutorial clone; /regex/
+// entries are RE2 as in PatternSet
+func WithTutorialKeywords(keywords ...string) Option {
+	return func(o *AnalyzerOptions) error {
+		set, err := NewPatternSet(keywords, DefaultPatternLimits)
+		if err != nil {
+			return fmt.Errorf("invalid tutorial keywords: %w", err)
+		}
+		o.TutorialKeywords = set.Entries()
+		return nil
+	}
+}
+
+// WithTutorialKeywordsFile replaces DefaultTutorialKeywords with the ones
+// in a file of one per line, read with LoadPatternSet
+func WithTutorialKeywordsFile(path string) Option {
+	return func(o *AnalyzerOptions) error {
+		set, err := LoadPatternSet(path, DefaultPatternLimits)
+		if err != nil {
+			return err
+		}
+		o.TutorialKeywords = set.Entries()
+		return nil
+	}
+}
+
+// WithInstallScripts enables or skips inspecting the install scripts of
+// the user's published npm packages, which only runs in deep mode
+func WithInstallScripts(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.InstallScripts = enabled
+		return nil
+	}
+}
+
+// WithVerifyArtifacts downloads the latest published artifact of the top
+// npm and PyPI packages in deep mode and flags files the repo doesn't hold
+// at the release tag. It costs a download of up to 20 MiB per package, so
+// it is off by default.
+func WithVerifyArtifacts(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.VerifyArtifacts = enabled
+		return nil
+	}
+}
+
+// WithArtifactIgnore adds globs of build output the artifact check skips,
+// in the syntax of DefaultArtifactIgnore, e.g. "generated/" or "*.wasm"
+func WithArtifactIgnore(globs ...string) Option {
+	return func(o *AnalyzerOptions) error {
+		for _, glob := range globs {
+			if _, err := path.Match(strings.TrimSuffix(glob, "/"), ""); err != nil || glob == "" {
+				return fmt.Errorf("invalid artifact ignore glob %q", glob)
+			}
+		}
+		o.ArtifactIgnore = append(o.ArtifactIgnore, globs...)
+		return nil
+	}
+}
+
+// WithCoMaintainerDepth runs a shallow analysis, without deep checks, of
+// each co-maintainer of the flagship repos and, past depth 1, of theirs,
+// up to MaxCoMaintainerDepth hops
+func WithCoMaintainerDepth(depth int) Option {
+	return func(o *AnalyzerOptions) error {
+		if depth < 0 || depth > MaxCoMaintainerDepth {
+			return fmt.Errorf("co-maintainer depth must be between 0 and %d, got %d", MaxCoMaintainerDepth, depth)
+		}
+		o.CoMaintainerDepth = depth
+		return nil
+	}
+}
+
+// WithEngagementRings enables the deep check for star-for-star and
+// follow-back rings, which samples the stargazers of the flagships and the
+// user's followers and following
+func WithEngagementRings(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.EngagementRings = enabled
+		return nil
+	}
+}
+
+// WithStrictAuth makes a rejected token fail the analysis with
+// ErrUnauthorized. By default an expired or revoked token is verified
+// once and dropped, and the analysis continues anonymously.
+func WithStrictAuth(enabled bool) Option {
+	return func(o *AnalyzerOptions) error {
+		o.StrictAuth = enabled
+		return nil
+	}
+}
+
+// WithSeed pins the sampling seed recorded in Analysis.Meta, so a
+// disputed analysis can be rerun exactly; see AnalysisMeta.Seed
+func WithSeed(seed uint64) Option {
+	return func(o *AnalyzerOptions) error {
+		o.Seed = seed
+		return nil
+	}
+}
+
+// WithTokenSource authenticates requests with tokens from source instead
+// of the static token given to New, e.g. an AppTokenSource
+func WithTokenSource(source TokenSource) Option {
+	return func(o *AnalyzerOptions) error {
+		if source == nil {
+			return errors.New("token source must not be nil")
+		}
+		o.TokenSource = source
+		return nil
+	}
+}
+
+// WithAnalysisTimeout bounds each whole analysis, e.g. 5 * time.Minute.
+// When it expires the partial analysis is returned with a TIMEOUT warning.
+// Zero disables the bound.
+func WithAnalysisTimeout(timeout time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if timeout < 0 {
+			return fmt.Errorf("analysis timeout must not be negative, got %s", timeout)
+		}
+		o.AnalysisTimeout = timeout
+		return nil
+	}
+}
+
+// WithRequestTimeout bounds each API request separately from the analysis
+// timeout. Zero leaves requests bounded only by the HTTP client.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if timeout < 0 {
+			return fmt.Errorf("request timeout must not be negative, got %s", timeout)
+		}
+		o.RequestTimeout = timeout
+		return nil
+	}
+}
+
+// WithExternalTimeout bounds each request to a host other than the GitHub
+// API
+func WithExternalTimeout(timeout time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		if timeout <= 0 {
+			return fmt.Errorf("external timeout must be positive, got %s", timeout)
+		}
+		o.ExternalTimeout = timeout
+		return nil
+	}
+}
+
+// WithIntegrationTimeout bounds requests to one external host, e.g.
+// "registry.npmjs.org", overriding the external timeout
+func WithIntegrationTimeout(host string, timeout time.Duration) Option {
+	return func(o *AnalyzerOptions) error {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			return errors.New("integration timeout needs a host")
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("integration timeout for %s must be positive, got %s", host, timeout)
+		}
+		o.IntegrationTimeouts = maps.Clone(o.IntegrationTimeouts)
+		if o.IntegrationTimeouts == nil {
+			o.IntegrationTimeouts = map[string]time.Duration{}
+		}
+		o.IntegrationTimeouts[host] = timeout
+		return nil
+	}
+}
+
+// WithCircuitBreaker sets how many consecutive failures of an external host
+// skip its remaining requests. Zero disables the breaker.
+func WithCircuitBreaker(threshold int) Option {
+	return func(o *AnalyzerOptions) error {
+		if threshold < 0 {
+			return fmt.Errorf("circuit breaker threshold must not be negative, got %d", threshold)
+		}
+		o.BreakerThreshold = threshold
+		return nil
+	}
+}
+
+// WithAllowedHosts sends requests only to hosts, e.g. "api.github.com"
+// and "registry.npmjs.org"; the rest fail with ErrHostNotAllowed and the
+// checks needing them are skipped, lowering the confidence
+func WithAllowedHosts(hosts ...string) Option {
+	return func(o *AnalyzerOptions) error {
+		var allowed []string
+		for _, host := range hosts {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host == "" || strings.Contains(host, "/") {
+				return fmt.Errorf("allowed host %q must be a host name, optionally with a port", host)
+			}
+			allowed = append(allowed, host)
+		}
+		if len(allowed) == 0 {
+			return errors.New("allowed hosts must not be empty; use WithOffline to allow none")
+		}
+		o.AllowedHosts = allowed
+		return nil
+	}
+}
+
+// WithOffline refuses every request that would reach the network, so an
+// analysis is answered only by a Replayer given through WithHTTPClient
+func WithOffline() Option {
+	return func(o *AnalyzerOptions) error {
+		o.Offline = true
+		return nil
+	}
+}
+
+// WithRequestRate bounds how fast API requests are sent. Within the bounds
+// the rate follows the remaining budget spread over the time to its reset.
+func WithRequestRate(floor, ceiling float64) Option {
+	return func(o *AnalyzerOptions) error {
+		if floor <= 0 || ceiling < floor {
+			return fmt.Errorf("request rate needs 0 < floor <= ceiling, got %g and %g", floor, ceiling)
+		}
+		o.MinRequestsPerSecond, o.MaxRequestsPerSecond = floor, ceiling
+		return nil
+	}
+}
+
+// WithMaxRepos caps how many repos are analyzed, sampling the top-starred
+// and most recently updated when an account has more. A negative n lifts
+// the default cap on organizations.
+func WithMaxRepos(n int) Option {
+	return func(o *AnalyzerOptions) error {
+		o.MaxRepos = n
+		return nil
+	}
+}
+
+// WithRepoListOptions sets how repos are listed, e.g.
+// WithRepoListOptions(RepoListOptions{Type: "all", Sort: "pushed"})
+func WithRepoListOptions(list RepoListOptions) Option {
+	return func(o *AnalyzerOptions) error {
+		if err := list.validate(); err != nil {
+			return err
+		}
+		o.RepoList = list
+		return nil
+	}
+}
+
+// WithDenylist adds accounts to the built-in denylist; an entry for a login
+// already listed replaces it
+func WithDenylist(entries ...DenylistEntry) Option {
+	return func(o *AnalyzerOptions) error {
+		for _, entry := range entries {
+			if strings.TrimSpace(entry.Login) == "" {
+				return errors.New("denylist entries need a login")
+			}
+		}
+		o.Denylist = append(o.Denylist, entries...)
+		return nil
+	}
+}
+
+// WithDenylistFile adds the accounts in a YAML denylist file, in the same
+// format as the built-in list
+func WithDenylistFile(path string) Option {
+	return func(o *AnalyzerOptions) error {
+		entries, err := LoadDenylist(path)
+		if err != nil {
+			return err
+		}
+		o.Denylist = append(o.Denylist, entries...)
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package ebert
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOptionsCopiesCollections(t *testing.T) {
+	analyzer, err := New("",
+		WithInternalNamePatterns("acme-"),
+		WithTutorialKeywords("bootcamp"),
+		WithVerifyArtifacts(true),
+		WithArtifactIgnore("generated/"),
+		WithAllowedHosts("api.github.com"),
+		WithDenylist(DenylistEntry{Login: "octo", Reference: "https://example.com/advisory"}),
+		WithIntegrationTimeout("pypi.org", time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := analyzer.Options()
+	opts.InternalNamePatterns[0] = "changed"
+	opts.TutorialKeywords[0] = "changed"
+	opts.ArtifactIgnore[0] = "changed"
+	opts.AllowedHosts[0] = "changed"
+	opts.Denylist[0].Login = "changed"
+	opts.IntegrationTimeouts["pypi.org"] = time.Hour
+	opts.IntegrationTimeouts["registry.npmjs.org"] = time.Hour
+
+	after := analyzer.Options()
+	for name, list := range map[string][]string{
+		"internal name patterns": after.InternalNamePatterns,
+		"tutorial keywords":      after.TutorialKeywords,
+		"artifact ignore":        after.ArtifactIgnore,
+		"allowed hosts":          after.AllowedHosts,
+	} {
+		if slices.Contains(list, "changed") {
+			t.Errorf("editing the returned %s changed the analyzer's: %q", name, list)
+		}
+	}
+	if after.Denylist[0].Login != "octo" {
+		t.Errorf("editing the returned denylist changed the analyzer's to %q", after.Denylist[0].Login)
+	}
+	if len(after.IntegrationTimeouts) != 1 || after.IntegrationTimeouts["pypi.org"] != time.Second {
+		t.Errorf("editing the returned integration timeouts changed the analyzer's to %v", after.IntegrationTimeouts)
+	}
+}
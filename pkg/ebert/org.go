@@ -0,0 +1,313 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOrgMembers is how many members AnalyzeOrgMembers analyzes
+	// when limit is zero
+	DefaultOrgMembers = 25
+
+	// orgMemberWorkers bounds concurrent member analyses
+	orgMemberWorkers = 4
+
+	// riskiestMembers is how many members OrgAnalysis.Riskiest lists
+	riskiestMembers = 5
+
+	// newMemberAge is the account age below which a member is listed as new
+	newMemberAge = 180 * 24 * time.Hour
+)
+
+// Member statuses: analyzed, analyzed with some sources missing, failed,
+// or gone from GitHub
+const (
+	MemberAnalyzed = "analyzed"
+	MemberPartial  = "partial"
+	MemberFailed   = "error"
+	MemberNotFound = "not_found"
+)
+
+// MemberSummary is the outcome of analyzing one organization member
+type MemberSummary struct {
+	Login          string  `json:"login"`
+	Status         string  `json:"status"`
+	OverallScore   float64 `json:"overall_score"`
+	RiskLevel      string  `json:"risk_level"`
+	Confidence     float64 `json:"confidence"`
+	AccountAgeDays int     `json:"account_age_days"`
+	Partial        bool    `json:"partial,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// ScoreDistribution counts members per risk level
+type ScoreDistribution struct {
+	Low    int `json:"low"`
+	Medium int `json:"medium"`
+	High   int `json:"high"`
+}
+
+// OrgAnalysis combines an organization's own analysis with the analyses of
+// its public members. Private membership isn't visible, so the member view
+// is always partial: PublicMembers is a lower bound on the real total.
+type OrgAnalysis struct {
+	Org           *Analysis         `json:"org"`
+	PublicMembers int               `json:"public_members"`
+	Analyzed      int               `json:"analyzed"`
+	Members       []MemberSummary   `json:"members"`
+	Distribution  ScoreDistribution `json:"distribution"`
+	MeanScore     float64           `json:"mean_score"`
+
+	// Riskiest are the members with the highest risk scores, and
+	// NewAccounts those whose accounts are under six months old
+	Riskiest    []MemberSummary `json:"riskiest"`
+	NewAccounts []string        `json:"new_accounts"`
+
+	// NotFound are members whose accounts no longer exist, e.g. deleted
+	// since the listing was cached
+	NotFound []string `json:"not_found,omitempty"`
+
+	// Confidence is the members' mean confidence scaled by the share of
+	// public members analyzed; it can't account for private members
+	Confidence float64 `json:"confidence"`
+	Coverage   string  `json:"coverage"`
+}
+
+// GetOrgMembers lists the organization's public members
+func (c *GitHubClient) GetOrgMembers(ctx context.Context, org string) (_ []GitHubUser, err error) {
+	ctx, span := c.startSpan(ctx, "github.org_members", "orgs/:org/members")
+	defer func() { endSpan(span, err) }()
+
+	var members []GitHubUser
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(maxPerPage))
+		query.Set("page", strconv.Itoa(page))
+
+		data, err := c.get(ctx, fmt.Sprintf("%s/orgs/%s/members?%s", c.BaseURL, url.PathEscape(org), query.Encode()))
+		if err != nil {
+			return nil, err
+		}
+
+		batch, _, err := decodeElements[GitHubUser](data)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, batch...)
+
+		if len(batch) < maxPerPage {
+			return members, nil
+		}
+	}
+}
+
+// getOrgEvents fetches the organization's recent public events
+func (c *GitHubClient) getOrgEvents(ctx context.Context, org string) ([]GitHubEvent, error) {
+	data, err := c.get(ctx, fmt.Sprintf("%s/orgs/%s/events?per_page=%d", c.BaseURL, url.PathEscape(org), maxPerPage))
+	if err != nil {
+		return nil, err
+	}
+	events, _, err := decodeElements[GitHubEvent](data)
+	return events, err
+}
+
+// AnalyzeOrgMembers analyzes the organization and up to limit of its
+// public members, the most active in the org's recent events first
+func (a *Analyzer) AnalyzeOrgMembers(org string, limit int) (*OrgAnalysis, error) {
+	return a.AnalyzeOrgMembersContext(context.Background(), org, limit)
+}
+
+// AnalyzeOrgMembersContext is AnalyzeOrgMembers with a caller-supplied context
+func (a *Analyzer) AnalyzeOrgMembersContext(ctx context.Context, org string, limit int) (*OrgAnalysis, error) {
+	if limit <= 0 {
+		limit = DefaultOrgMembers
+	}
+
+	orgAnalysis, orgErr := a.AnalyzeContext(ctx, org)
+	if orgAnalysis == nil {
+		return nil, orgErr
+	}
+
+	members, err := a.client.GetOrgMembers(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of %s: %w", org, err)
+	}
+
+	result := &OrgAnalysis{Org: orgAnalysis, PublicMembers: len(members)}
+
+	logins := make([]string, len(members))
+	for i, member := range members {
+		logins[i] = member.Login
+	}
+	if len(logins) > limit {
+		// Without events to rank by, the listing order decides
+		events, _ := a.client.getOrgEvents(ctx, org)
+		rankByActivity(logins, events)
+		logins = logins[:limit]
+	}
+
+	result.Members = a.analyzeMembers(ctx, logins)
+	result.summarize()
+
+	var errs []error
+	if orgErr != nil {
+		errs = append(errs, orgErr)
+	}
+	for _, member := range result.Members {
+		if member.Status == MemberFailed {
+			errs = append(errs, fmt.Errorf("%s: %s", member.Login, member.Error))
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// rankByActivity orders logins by how many of events they authored
+func rankByActivity(logins []string, events []GitHubEvent) {
+	counts := map[string]int{}
+	for _, event := range events {
+		counts[strings.ToLower(event.Actor.Login)]++
+	}
+	sort.SliceStable(logins, func(i, j int) bool {
+		return counts[strings.ToLower(logins[i])] > counts[strings.ToLower(logins[j])]
+	})
+}
+
+// analyzeMembers analyzes each login on a small worker pool, keeping the
+// input order
+func (a *Analyzer) analyzeMembers(ctx context.Context, logins []string) []MemberSummary {
+	summaries := make([]MemberSummary, len(logins))
+	forEachConcurrently(len(logins), orgMemberWorkers, func(i int) {
+		summaries[i] = a.summarizeMember(ctx, logins[i])
+	})
+	return summaries
+}
+
+// forEachConcurrently calls fn with each index below n on up to workers
+// goroutines, returning once all calls have
+func forEachConcurrently(n, workers int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for range min(workers, n) {
+		wg.Go(func() {
+			for i := range jobs {
+				fn(i)
+			}
+		})
+	}
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (a *Analyzer) summarizeMember(ctx context.Context, login string) MemberSummary {
+	summary := MemberSummary{Login: login}
+
+	analysis, err := a.AnalyzeContext(ctx, login)
+	if analysis == nil {
+		summary.Status = MemberFailed
+		if errors.Is(err, ErrAccountNotFound) {
+			summary.Status = MemberNotFound
+		}
+		summary.Error = err.Error()
+		return summary
+	}
+
+	summary.Status = MemberAnalyzed
+	if analysis.Partial {
+		summary.Status = MemberPartial
+	}
+	summary.OverallScore = analysis.OverallScore
+	summary.RiskLevel = analysis.RiskLevel
+	summary.Confidence = analysis.Confidence
+	summary.AccountAgeDays = analysis.Metrics.AccountAgeDays
+	summary.Partial = analysis.Partial
+	return summary
+}
+
+// summarize fills in the distribution, riskiest and new-account views
+func (o *OrgAnalysis) summarize() {
+	var analyzed []MemberSummary
+	total, confidence := 0.0, 0.0
+
+	for _, member := range o.Members {
+		if member.Status == MemberNotFound {
+			o.NotFound = append(o.NotFound, member.Login)
+		}
+		if member.Status == MemberFailed || member.Status == MemberNotFound {
+			continue
+		}
+		analyzed = append(analyzed, member)
+		total += member.OverallScore
+		confidence += member.Confidence
+
+		switch member.RiskLevel {
+		case "high":
+			o.Distribution.High++
+		case "medium":
+			o.Distribution.Medium++
+		default:
+			o.Distribution.Low++
+		}
+
+		if member.AccountAgeDays < int(newMemberAge/(24*time.Hour)) {
+			o.NewAccounts = append(o.NewAccounts, member.Login)
+		}
+	}
+
+	o.Analyzed = len(analyzed)
+	o.Coverage = fmt.Sprintf("%d of %d public members analyzed; private members and the true total are unknown", o.Analyzed, o.PublicMembers)
+	if o.Analyzed == 0 {
+		return
+	}
+
+	o.MeanScore = total / float64(o.Analyzed)
+	// Mean member confidence times the analyzed share of public members
+	o.Confidence = confidence / float64(o.PublicMembers)
+
+	sort.SliceStable(analyzed, func(i, j int) bool {
+		return analyzed[i].OverallScore > analyzed[j].OverallScore
+	})
+	o.Riskiest = analyzed[:min(riskiestMembers, len(analyzed))]
+}
+
+// FprintOrgAnalysis writes the human-readable organization report to w
+func FprintOrgAnalysis(w io.Writer, org *OrgAnalysis) {
+	FprintAnalysis(w, org.Org)
+
+	fmt.Fprintf(w, "\n👥 MEMBERS (%s)\n", org.Coverage)
+	fmt.Fprintf(w, "   Mean Risk Score:    %.1f/100\n", org.MeanScore)
+	fmt.Fprintf(w, "   Distribution:       %d low, %d medium, %d high\n", org.Distribution.Low, org.Distribution.Medium, org.Distribution.High)
+	fmt.Fprintf(w, "   Confidence:         %.0f%%\n", org.Confidence*100)
+
+	if len(org.Riskiest) > 0 {
+		fmt.Fprintln(w, "\n   Highest risk:")
+		for _, member := range org.Riskiest {
+			fmt.Fprintf(w, "   • %s: %.1f (%s)\n", member.Login, member.OverallScore, member.RiskLevel)
+		}
+	}
+	if len(org.NewAccounts) > 0 {
+		fmt.Fprintf(w, "\n   Accounts under six months old: %s\n", strings.Join(org.NewAccounts, ", "))
+	}
+	if len(org.NotFound) > 0 {
+		fmt.Fprintf(w, "\n   ⚠️  %d members no longer exist on GitHub: %s\n", len(org.NotFound), strings.Join(org.NotFound, ", "))
+	}
+	for _, member := range org.Members {
+		if member.Status == MemberFailed {
+			fmt.Fprintf(w, "   ! %s not analyzed: %s\n", member.Login, member.Error)
+		}
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+}
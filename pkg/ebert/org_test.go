@@ -0,0 +1,152 @@
+package ebert
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRankByActivity(t *testing.T) {
+	events := func(actors ...string) []GitHubEvent {
+		var out []GitHubEvent
+		for _, actor := range actors {
+			var event GitHubEvent
+			event.Actor.Login = actor
+			out = append(out, event)
+		}
+		return out
+	}
+	logins := []string{"alice", "bob", "carol", "dave"}
+	rankByActivity(logins, events("Carol", "bob", "carol", "erin", "bob", "carol"))
+	// Ties, including members with no events, keep the listing order
+	if want := []string{"carol", "bob", "alice", "dave"}; !slices.Equal(logins, want) {
+		t.Errorf("ranked %q, want %q", logins, want)
+	}
+}
+
+// orgFake serves acme and its members, the named ones active in acme's
+// events as often as given
+func orgFake(t *testing.T, active map[string]int) *fakeGitHub {
+	repo := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 50, UpdatedAt: fakeNow.Add(-days(3))}
+	acme := newAccount("acme", days(2000), repo)
+	acme.User.Type = "Organization"
+	f := newFakeGitHub(t, acme,
+		newAccount("alice", days(3000), repo),
+		newAccount("bob", days(60), repo),
+		newAccount("carol", days(2500), repo),
+		newAccount("dave", days(1200), repo),
+		newAccount("erin", days(90)),
+	)
+	f.route("/orgs/acme/members", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]GitHubUser{{Login: "alice"}, {Login: "bob"}, {Login: "carol"}, {Login: "dave"}, {Login: "erin"}})
+	})
+	f.route("/orgs/acme/events", func(w http.ResponseWriter, r *http.Request) {
+		var events []GitHubEvent
+		for login, n := range active {
+			for range n {
+				event := GitHubEvent{ID: fmt.Sprint(len(events)), Type: "PushEvent", CreatedAt: fakeNow.Add(-days(1))}
+				event.Actor.Login = login
+				events = append(events, event)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(events)
+	})
+	return f
+}
+
+func TestAnalyzeOrgMembers(t *testing.T) {
+	f := orgFake(t, map[string]int{"erin": 3, "carol": 2, "bob": 1})
+	org, err := newFakeAnalyzer(f).AnalyzeOrgMembers("acme", 3)
+	if err != nil {
+		t.Fatalf("AnalyzeOrgMembers: %v", err)
+	}
+	if org.Org.User.Login != "acme" || org.PublicMembers != 5 || org.Analyzed != 3 {
+		t.Errorf("analyzed %s with %d of %d members, want acme with 3 of 5", org.Org.User.Login, org.Analyzed, org.PublicMembers)
+	}
+
+	// The limit keeps the members most active in the org's events
+	var logins []string
+	confidence := 0.0
+	for _, member := range org.Members {
+		logins = append(logins, member.Login)
+		confidence += member.Confidence
+		if member.Status != MemberAnalyzed || member.RiskLevel == "" {
+			t.Errorf("member %+v, want it analyzed", member)
+		}
+	}
+	if want := []string{"erin", "carol", "bob"}; !slices.Equal(logins, want) {
+		t.Errorf("analyzed %q, want %q", logins, want)
+	}
+	if want := []string{"erin", "bob"}; !slices.Equal(org.NewAccounts, want) {
+		t.Errorf("NewAccounts = %q, want %q", org.NewAccounts, want)
+	}
+
+	dist := org.Distribution
+	if dist.Low+dist.Medium+dist.High != 3 {
+		t.Errorf("distribution %+v doesn't count the 3 members", dist)
+	}
+	if !slices.IsSortedFunc(org.Riskiest, func(x, y MemberSummary) int { return cmp.Compare(y.OverallScore, x.OverallScore) }) || len(org.Riskiest) != 3 {
+		t.Errorf("Riskiest = %+v, want all 3 riskiest first", org.Riskiest)
+	}
+	// Members left out, and private ones, cost confidence
+	if want := confidence / 5; math.Abs(org.Confidence-want) > 1e-9 {
+		t.Errorf("Confidence = %v, want %v", org.Confidence, want)
+	}
+	if !strings.HasPrefix(org.Coverage, "3 of 5 public members analyzed") {
+		t.Errorf("Coverage = %q", org.Coverage)
+	}
+
+	var report strings.Builder
+	FprintOrgAnalysis(&report, org)
+	for _, want := range []string{"👥 MEMBERS (3 of 5 public members analyzed", "Accounts under six months old: erin, bob"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, report.String())
+		}
+	}
+}
+
+func TestAnalyzeOrgMembersUnderLimit(t *testing.T) {
+	f := orgFake(t, nil)
+	var ranked bool
+	f.route("/orgs/acme/events", func(w http.ResponseWriter, r *http.Request) {
+		ranked = true
+		_, _ = w.Write([]byte(`[]`))
+	})
+	// Zero means the default limit, which all five fit under
+	org, err := newFakeAnalyzer(f).AnalyzeOrgMembers("acme", 0)
+	if err != nil {
+		t.Fatalf("AnalyzeOrgMembers: %v", err)
+	}
+	if org.Analyzed != 5 || ranked {
+		t.Errorf("analyzed %d members, ranked by events %t; want all 5 in listing order", org.Analyzed, ranked)
+	}
+	if org.Members[0].Login != "alice" || org.Members[4].Login != "erin" {
+		t.Errorf("members = %+v, want the listing order", org.Members)
+	}
+}
+
+func TestGetOrgMembersPages(t *testing.T) {
+	f := newFakeGitHub(t)
+	members := make([]GitHubUser, 150)
+	for i := range members {
+		members[i].Login = fmt.Sprintf("member-%d", i)
+	}
+	var pages int
+	f.route("/orgs/acme/members", func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		_ = json.NewEncoder(w).Encode(fakePage(members, r))
+	})
+	got, err := newFakeAnalyzer(f).client.GetOrgMembers(t.Context(), "acme")
+	if err != nil {
+		t.Fatalf("GetOrgMembers: %v", err)
+	}
+	// A short page ends the listing without asking for an empty one
+	if len(got) != 150 || pages != 2 {
+		t.Errorf("listed %d members in %d pages, want 150 in 2", len(got), pages)
+	}
+}
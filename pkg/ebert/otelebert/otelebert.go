@@ -0,0 +1,76 @@
+// Package otelebert adapts an OpenTelemetry tracer to ebert's Tracer
+// interface, keeping the OpenTelemetry SDK out of builds that don't trace.
+package otelebert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer wraps an OpenTelemetry tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New adapts t for use with ebert.WithTracer
+func New(t trace.Tracer) *Tracer {
+	return &Tracer{tracer: t}
+}
+
+// Start begins a child span of whatever span ctx carries
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, ebert.Span) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, &Span{span: span}
+}
+
+// Span wraps an OpenTelemetry span
+type Span struct {
+	span trace.Span
+}
+
+func (s *Span) SetAttributes(attrs ...ebert.Attribute) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, keyValue(attr))
+	}
+	s.span.SetAttributes(kvs...)
+}
+
+func (s *Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *Span) End() {
+	s.span.End()
+}
+
+func keyValue(attr ebert.Attribute) attribute.KeyValue {
+	switch v := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, v)
+	case bool:
+		return attribute.Bool(attr.Key, v)
+	case int:
+		return attribute.Int(attr.Key, v)
+	case int64:
+		return attribute.Int64(attr.Key, v)
+	case float64:
+		return attribute.Float64(attr.Key, v)
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(v))
+	}
+}
+
+// ContextFromRequest extracts an incoming W3C traceparent header so an
+// analysis served over HTTP joins the caller's trace
+func ContextFromRequest(r *http.Request) context.Context {
+	return propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+}
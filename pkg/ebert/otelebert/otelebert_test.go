@@ -0,0 +1,160 @@
+package otelebert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newAPI serves octo's profile, one repo and an empty event feed, and 404s
+// anyone else
+func newAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/octo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"octo","public_repos":1,"type":"User","created_at":"2016-03-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"tool","full_name":"octo/tool","language":"Go","size":2400,"default_branch":"main",
+			"owner":{"login":"octo","type":"User"},"updated_at":"2024-05-28T00:00:00Z","pushed_at":"2024-05-28T00:00:00Z"}]`)
+	})
+	mux.HandleFunc("/users/octo/events/public", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// traced analyzes login against api, recording spans in memory
+func traced(t *testing.T, ctx context.Context, api *httptest.Server, login string) (tracetest.SpanStubs, error) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	analyzer, err := ebert.New("",
+		ebert.WithBaseURL(api.URL),
+		ebert.WithClock(func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }),
+		ebert.WithTracer(New(provider.Tracer("ebert-test"))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = analyzer.AnalyzeContext(ctx, login)
+	return exporter.GetSpans(), err
+}
+
+// attr is span's value for key, or an empty value
+func attr(span tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestAnalyzeSpanTree(t *testing.T) {
+	spans, err := traced(t, context.Background(), newAPI(t), "octo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var root *tracetest.SpanStub
+	for i := range spans {
+		if spans[i].Name == "ebert.Analyze" {
+			if root != nil {
+				t.Fatal("two ebert.Analyze spans")
+			}
+			root = &spans[i]
+		}
+	}
+	if root == nil {
+		t.Fatalf("no ebert.Analyze span among %d", len(spans))
+	}
+	if root.Parent.IsValid() {
+		t.Errorf("the analysis span has parent %s, want a root", root.Parent.SpanID())
+	}
+	if got := attr(*root, "github.username").AsString(); got != "octo" {
+		t.Errorf("github.username = %q, want octo", got)
+	}
+
+	calls := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		if span.Name == "ebert.Analyze" {
+			continue
+		}
+		calls[span.Name] = span
+		if span.SpanContext.TraceID() != root.SpanContext.TraceID() || span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("%s isn't a child of the analysis span", span.Name)
+		}
+		if span.EndTime.Before(span.StartTime) || span.EndTime.After(root.EndTime) {
+			t.Errorf("%s ran %s to %s, outside the analysis", span.Name, span.StartTime, span.EndTime)
+		}
+	}
+	for _, name := range []string{"github.user", "github.repos", "github.events"} {
+		span, ok := calls[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if status := attr(span, "http.status_code").AsInt64(); status != http.StatusOK {
+			t.Errorf("%s http.status_code = %d, want 200", name, status)
+		}
+		if span.Status.Code == codes.Error {
+			t.Errorf("%s failed: %s", name, span.Status.Description)
+		}
+	}
+}
+
+func TestAnalyzeSpanRecordsError(t *testing.T) {
+	spans, err := traced(t, context.Background(), newAPI(t), "ghost")
+	if !errors.Is(err, ebert.ErrAccountNotFound) {
+		t.Fatalf("analysis of a missing user = %v, want ErrAccountNotFound", err)
+	}
+	for _, name := range []string{"ebert.Analyze", "github.user"} {
+		var found bool
+		for _, span := range spans {
+			if span.Name != name {
+				continue
+			}
+			found = true
+			if span.Status.Code != codes.Error || len(span.Events) == 0 || span.Events[0].Name != "exception" {
+				t.Errorf("%s has status %v and events %v, want the error recorded", name, span.Status, span.Events)
+			}
+		}
+		if !found {
+			t.Errorf("no %s span", name)
+		}
+	}
+}
+
+func TestContextFromRequestJoinsTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/analyze/octo", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	spans, err := traced(t, ContextFromRequest(req), newAPI(t), "octo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("%s is in trace %s, want the caller's %s", span.Name, span.SpanContext.TraceID(), traceID)
+		}
+		if span.Name == "ebert.Analyze" && span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("the analysis span's parent is %s, want the caller's span", span.Parent.SpanID())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ebert
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMinRequestsPerSecond and DefaultMaxRequestsPerSecond bound the
+	// pacer's adaptive rate
+	DefaultMinRequestsPerSecond = 1.0
+	DefaultMaxRequestsPerSecond = 10.0
+
+	// pacerBurst is how many requests may go out back to back after a lull,
+	// so short analyses aren't slowed by pacing meant for sustained load
+	pacerBurst = 10
+)
+
+// pacer is a token bucket shared by every request through one client. Its
+// refill rate follows the remaining core budget spread evenly over the
+// time left until the reset, clamped between floor and ceiling, so
+// concurrent analyses spend the budget steadily instead of bursting into
+// secondary rate limits.
+type pacer struct {
+	mu             sync.Mutex
+	floor, ceiling float64
+	rate           float64
+	tokens         float64
+	last           time.Time
+
+	// now and sleep are swapped for a fake clock in tests
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+func newPacer(floor, ceiling float64) *pacer {
+	if ceiling <= 0 {
+		ceiling = DefaultMaxRequestsPerSecond
+	}
+	if floor <= 0 {
+		floor = min(DefaultMinRequestsPerSecond, ceiling)
+	}
+	return &pacer{
+		floor:   floor,
+		ceiling: ceiling,
+		rate:    ceiling,
+		tokens:  pacerBurst,
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+}
+
+// refill adds the tokens earned since the last call; callers hold mu
+func (p *pacer) refill(now time.Time) {
+	if !p.last.IsZero() {
+		p.tokens = min(p.tokens+now.Sub(p.last).Seconds()*p.rate, pacerBurst)
+	}
+	p.last = now
+}
+
+// wait takes a token, sleeping until one is due. Every attempt at a
+// request waits, so retries are paced like first attempts. A wait
+// abandoned because ctx ended hands its token back.
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	p.refill(p.now())
+	p.tokens--
+	deficit := -p.tokens
+	rate := p.rate
+	p.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	if err := p.sleep(ctx, time.Duration(deficit/rate*float64(time.Second))); err != nil {
+		p.mu.Lock()
+		p.tokens++
+		p.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// observe retunes the rate from a response's core rate limit headers
+func (p *pacer) observe(limit *RateLimit) {
+	if limit == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.refill(now)
+
+	untilReset := limit.Reset.Sub(now).Seconds()
+	if untilReset <= 0 {
+		// The window has rolled over; the next response will say how much
+		// of the fresh budget is left
+		p.rate = p.ceiling
+		return
+	}
+	p.rate = clamp(float64(limit.Remaining)/untilReset, p.floor, p.ceiling)
+}
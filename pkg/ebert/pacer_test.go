@@ -0,0 +1,214 @@
+package ebert
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pacerClock is a fake clock for a pacer: sleeping advances it
+type pacerClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *pacerClock) install(p *pacer) {
+	p.now = func() time.Time { return c.now }
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.slept = append(c.slept, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+}
+
+func newClockedPacer(floor, ceiling float64) (*pacer, *pacerClock) {
+	p := newPacer(floor, ceiling)
+	clock := &pacerClock{now: fakeNow}
+	clock.install(p)
+	return p, clock
+}
+
+func TestPacerBurstThenSteadyRate(t *testing.T) {
+	p, clock := newClockedPacer(1, 4)
+	ctx := context.Background()
+
+	for range pacerBurst {
+		if err := p.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.slept) != 0 {
+		t.Fatalf("the burst slept %v, want no waits", clock.slept)
+	}
+
+	// Past the burst every request waits out its share of a second
+	for range 3 {
+		if err := p.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, d := range clock.slept {
+		if d != 250*time.Millisecond {
+			t.Errorf("wait %d slept %v, want 250ms at 4 requests a second", i, d)
+		}
+	}
+
+	// A lull refills the bucket, but never past the burst
+	clock.now = clock.now.Add(time.Hour)
+	clock.slept = nil
+	for range pacerBurst {
+		_ = p.wait(ctx)
+	}
+	if len(clock.slept) != 0 {
+		t.Errorf("the burst after a lull slept %v, want no waits", clock.slept)
+	}
+	_ = p.wait(ctx)
+	if len(clock.slept) != 1 {
+		t.Errorf("the request past a refilled burst slept %v, want one wait", clock.slept)
+	}
+}
+
+func TestPacerDeficitSleep(t *testing.T) {
+	p, clock := newClockedPacer(1, 2)
+	p.tokens = -2.5
+
+	if err := p.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 3.5 tokens short at 2 a second
+	if len(clock.slept) != 1 || clock.slept[0] != 1750*time.Millisecond {
+		t.Errorf("slept %v, want 1.75s for a deficit of 3.5 tokens", clock.slept)
+	}
+}
+
+func TestPacerCanceledWaitReturnsToken(t *testing.T) {
+	p, _ := newClockedPacer(1, 2)
+	p.tokens = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.wait(ctx); err == nil {
+		t.Fatal("a wait on a canceled context succeeded")
+	}
+	if p.tokens != 0 {
+		t.Errorf("tokens = %g after an abandoned wait, want the token handed back", p.tokens)
+	}
+}
+
+func TestPacerObserve(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		remaining  int
+		untilReset time.Duration
+		want       float64
+	}{
+		{"budget spread over the window", 600, 5 * time.Minute, 2},
+		{"clamped to the ceiling", 5000, time.Minute, 4},
+		{"clamped to the floor", 10, time.Hour, 0.5},
+		{"spent budget", 0, 30 * time.Minute, 0.5},
+		{"window rolled over", 0, -time.Second, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, clock := newClockedPacer(0.5, 4)
+			p.rate = 1
+			p.observe(&RateLimit{Limit: 5000, Remaining: tc.remaining, Reset: clock.now.Add(tc.untilReset)})
+			if math.Abs(p.rate-tc.want) > 1e-9 {
+				t.Errorf("rate = %g, want %g", p.rate, tc.want)
+			}
+		})
+	}
+
+	p, _ := newClockedPacer(0.5, 4)
+	p.observe(nil)
+	if p.rate != 4 {
+		t.Errorf("a response without rate limit headers retuned the rate to %g", p.rate)
+	}
+}
+
+func TestPacerObserveSetsSleep(t *testing.T) {
+	p, clock := newClockedPacer(0.5, 10)
+	p.tokens = 0
+	// 120 requests left for the next minute is 2 a second
+	p.observe(&RateLimit{Remaining: 120, Reset: clock.now.Add(time.Minute)})
+
+	_ = p.wait(context.Background())
+	if len(clock.slept) != 1 || clock.slept[0] != 500*time.Millisecond {
+		t.Errorf("slept %v, want 500ms at the retuned 2 requests a second", clock.slept)
+	}
+}
+
+func TestPacerBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		floor, ceiling         float64
+		wantFloor, wantCeiling float64
+	}{
+		{"defaults", 0, 0, DefaultMinRequestsPerSecond, DefaultMaxRequestsPerSecond},
+		{"max-rps above the default floor", 0, 3, DefaultMinRequestsPerSecond, 3},
+		{"max-rps below the default floor", 0, 0.25, 0.25, 0.25},
+		{"both set", 2, 5, 2, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPacer(tc.floor, tc.ceiling)
+			if p.floor != tc.wantFloor || p.ceiling != tc.wantCeiling || p.rate != tc.wantCeiling {
+				t.Errorf("floor, ceiling, rate = %g, %g, %g, want %g, %g starting at the ceiling", p.floor, p.ceiling, p.rate, tc.wantFloor, tc.wantCeiling)
+			}
+		})
+	}
+}
+
+func TestPacerRequestRateOption(t *testing.T) {
+	// --max-rps 0.5 sets both bounds, as the CLI passes min(1, max-rps)
+	analyzer, err := New("", WithRequestRate(min(DefaultMinRequestsPerSecond, 0.5), 0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := analyzer.client.shared().pacer
+	if p.floor != 0.5 || p.ceiling != 0.5 {
+		t.Errorf("pacer bounds = %g, %g, want 0.5 and 0.5", p.floor, p.ceiling)
+	}
+
+	clock := &pacerClock{now: fakeNow}
+	clock.install(p)
+	p.observe(&RateLimit{Remaining: 5000, Reset: clock.now.Add(time.Minute)})
+	if p.rate != 0.5 {
+		t.Errorf("a full budget lifted the rate to %g, past --max-rps", p.rate)
+	}
+}
+
+func TestPacerRetryTakesAnotherToken(t *testing.T) {
+	interval := acceptedPollInterval
+	acceptedPollInterval = time.Millisecond
+	t.Cleanup(func() { acceptedPollInterval = interval })
+
+	f := newFakeGitHub(t)
+	var served atomic.Int32
+	f.route("/repos/octo/tool/stats/contributors", func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1) == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	})
+
+	client := newFakeAnalyzer(f).client
+	p := client.shared().pacer
+	clock := &pacerClock{now: fakeNow}
+	clock.install(p)
+
+	if _, err := client.get(context.Background(), f.URL+"/repos/octo/tool/stats/contributors"); err != nil {
+		t.Fatal(err)
+	}
+	if served.Load() != 2 {
+		t.Fatalf("served %d requests, want the 202 and its retry", served.Load())
+	}
+	if want := float64(pacerBurst - 2); p.tokens != want {
+		t.Errorf("tokens = %g, want %g: each attempt takes its own", p.tokens, want)
+	}
+}
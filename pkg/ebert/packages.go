@@ -0,0 +1,230 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxPackageResults bounds each package listing, registry search or
+	// GitHub Packages page alike
+	maxPackageResults = 50
+
+	// maxPackageEvidence is how many packages a finding lists
+	maxPackageEvidence = 5
+
+	// maxSearchBytes caps an npm registry search response
+	maxSearchBytes = 1 << 20
+)
+
+// GitHub Packages types ebert lists
+const (
+	PackageTypeNPM       = "npm"
+	PackageTypeContainer = "container"
+)
+
+// GitHubPackage is a package published to GitHub Packages. The API exposes
+// no download counts, so VersionCount stands in for popularity.
+type GitHubPackage struct {
+	Name         string `json:"name"`
+	PackageType  string `json:"package_type"`
+	Visibility   string `json:"visibility"`
+	HTMLURL      string `json:"html_url"`
+	VersionCount int    `json:"version_count"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetPackages lists up to maxPackageResults of the account's GitHub
+// Packages of one type. It needs a token with read:packages.
+func (c *GitHubClient) GetPackages(ctx context.Context, owner, packageType string, org bool) (_ []GitHubPackage, err error) {
+	ctx, span := c.startSpan(ctx, "github.packages", "users/:username/packages")
+	defer func() { endSpan(span, err) }()
+
+	scope := "users"
+	if org {
+		scope = "orgs"
+	}
+	query := url.Values{}
+	query.Set("package_type", packageType)
+	query.Set("per_page", strconv.Itoa(maxPackageResults))
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/%s/%s/packages?%s", c.BaseURL, scope, owner, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	packages, _, err := decodeElements[GitHubPackage](data)
+	return packages, err
+}
+
+// npmSearchResult is a package from the npm registry search endpoint;
+// Repository is its source repository link, when it declares one
+type npmSearchResult struct {
+	Name       string
+	Weekly     int
+	Repository string
+}
+
+// searchNPMMaintainer lists the packages an npm user maintains, scoped or
+// not, most-downloaded first
+func (c *GitHubClient) searchNPMMaintainer(ctx context.Context, username string) ([]npmSearchResult, error) {
+	query := url.Values{}
+	query.Set("text", "maintainer:"+username)
+	query.Set("size", strconv.Itoa(maxPackageResults))
+
+	data, err := c.getExternal(ctx, npmRegistryURL+"/-/v1/search?"+query.Encode(), maxSearchBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Objects []struct {
+			Package struct {
+				Name  string `json:"name"`
+				Links struct {
+					Repository string `json:"repository"`
+				} `json:"links"`
+			} `json:"package"`
+			Downloads struct {
+				Weekly int `json:"weekly"`
+			} `json:"downloads"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode npm search results: %w", err)
+	}
+
+	results := make([]npmSearchResult, 0, len(response.Objects))
+	for _, object := range response.Objects {
+		results = append(results, npmSearchResult{
+			Name:       object.Package.Name,
+			Weekly:     object.Downloads.Weekly,
+			Repository: object.Package.Links.Repository,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Weekly > results[j].Weekly })
+	return results, nil
+}
+
+// npmUsername picks the user's npm account from the published manifests:
+// a publisher or maintainer named like the GitHub login, else whoever
+// published most of them
+func npmUsername(login string, packages []publishedNPM) string {
+	counts := map[string]int{}
+	for _, pkg := range packages {
+		if name := pkg.published.NPMUser.Name; name != "" {
+			counts[name]++
+		}
+		for _, maintainer := range pkg.published.Maintainers {
+			if strings.EqualFold(maintainer.Name, login) {
+				return maintainer.Name
+			}
+		}
+	}
+
+	best := ""
+	for name, count := range counts {
+		if strings.EqualFold(name, login) {
+			return name
+		}
+		if count > counts[best] || (count == counts[best] && name < best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// checkPackages enumerates the user's npm packages, including scoped ones,
+// through the registry search and the user's GitHub Packages. It runs in
+// deep mode with a token; the registry search also needs external checks.
+func (a *Analyzer) checkPackages(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !a.client.authenticated() {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	npmNames := map[string]struct{}{}
+	var npmEvidence []string
+
+	if username := npmUsername(r.user.Login, a.npmPackages(ctx, r)); username != "" {
+		metrics.NPMUsername = username
+		results, err := a.client.searchNPMMaintainer(ctx, username)
+		if err != nil {
+			r.log.fellBack("registry", fmt.Errorf("failed to search npm packages for %s: %w", username, err))
+		}
+		for _, result := range results {
+			npmNames[result.Name] = struct{}{}
+			if len(npmEvidence) < maxPackageEvidence {
+				npmEvidence = append(npmEvidence, fmt.Sprintf("%s (%s weekly downloads)", result.Name, formatThousands(int64(result.Weekly))))
+			}
+		}
+	}
+
+	org := r.user.Type == "Organization"
+	owner := strings.ToLower(r.user.Login)
+	ghNPM, npmErr := a.client.GetPackages(ctx, r.user.Login, PackageTypeNPM, org)
+	containers, containerErr := a.client.GetPackages(ctx, r.user.Login, PackageTypeContainer, org)
+	if err := errors.Join(npmErr, containerErr); err != nil {
+		// Tokens without read:packages get a 403 here
+		r.log.fellBack("github_packages", fmt.Errorf("failed to list GitHub Packages: %w", err))
+	} else {
+		r.log.ok("github_packages")
+	}
+
+	sortPackages(ghNPM)
+	for _, pkg := range ghNPM {
+		// GitHub Packages npm packages live under the owner's scope
+		name := fmt.Sprintf("@%s/%s", owner, pkg.Name)
+		if _, seen := npmNames[name]; seen {
+			continue
+		}
+		npmNames[name] = struct{}{}
+		if len(npmEvidence) < maxPackageEvidence {
+			npmEvidence = append(npmEvidence, fmt.Sprintf("%s on GitHub Packages (%d versions)", name, pkg.VersionCount))
+		}
+	}
+
+	// The registry figure replaces the language heuristic when it is larger
+	metrics.NPMPackages = max(metrics.NPMPackages, len(npmNames))
+	metrics.ContainerImages = len(containers)
+	r.containerPackages = containers
+
+	if len(npmNames) > 0 {
+		r.addFinding(Finding{
+			Code:     "NPM_PACKAGES",
+			Severity: SeverityPositive,
+			Message:  fmt.Sprintf("Publishes %d npm packages", len(npmNames)),
+			Evidence: npmEvidence,
+		})
+	}
+	if len(containers) > 0 {
+		sortPackages(containers)
+		var evidence []string
+		for _, pkg := range containers[:min(len(containers), maxPackageEvidence)] {
+			evidence = append(evidence, fmt.Sprintf("%s (%d versions)", pkg.Name, pkg.VersionCount))
+		}
+		r.addFinding(Finding{
+			Code:     "CONTAINER_IMAGES",
+			Severity: SeverityPositive,
+			Message:  fmt.Sprintf("Publishes %d container images on GitHub Packages", len(containers)),
+			Evidence: evidence,
+		})
+	}
+}
+
+// sortPackages puts the most-versioned packages first
+func sortPackages(packages []GitHubPackage) {
+	sort.SliceStable(packages, func(i, j int) bool {
+		if packages[i].VersionCount != packages[j].VersionCount {
+			return packages[i].VersionCount > packages[j].VersionCount
+		}
+		return packages[i].Name < packages[j].Name
+	})
+}
@@ -0,0 +1,156 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNPMUsername(t *testing.T) {
+	published := func(publisher string, maintainers ...string) publishedNPM {
+		manifest := &npmManifest{}
+		manifest.NPMUser.Name = publisher
+		manifest.Maintainers = make([]struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		}, len(maintainers))
+		for i, name := range maintainers {
+			manifest.Maintainers[i].Name = name
+		}
+		return publishedNPM{published: manifest}
+	}
+	for _, tt := range []struct {
+		name     string
+		packages []publishedNPM
+		want     string
+	}{
+		{"none", nil, ""},
+		{"maintainer named like the login", []publishedNPM{published("ci-bot", "someone", "OCTO")}, "OCTO"},
+		{"publisher named like the login", []publishedNPM{published("ci-bot"), published("Octo"), published("ci-bot")}, "Octo"},
+		{"most published", []publishedNPM{published("zed"), published("ann"), published("zed")}, "zed"},
+		// Ties go to the first name alphabetically
+		{"tie", []publishedNPM{published("zed"), published("ann")}, "ann"},
+	} {
+		if got := npmUsername("octo", tt.packages); got != tt.want {
+			t.Errorf("%s: npmUsername = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// packagesFake serves octo's widget repo, published to npm by octo-npm,
+// who maintains a scoped package too, and octo's GitHub Packages
+func packagesFake(t *testing.T, userType string) (*fakeGitHub, *atomic.Int32) {
+	unspaced(t)
+	account := newAccount("octo", days(2000), GitHubRepo{Name: "widget", Language: "JavaScript", Size: 400, StargazersCount: 90, UpdatedAt: fakeNow.Add(-days(3))})
+	account.User.Type = userType
+	f := newFakeGitHub(t, account)
+	f.route("/repos/octo/widget/contents/package.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"widget","version":"1.0.0"}`))
+	})
+	f.host("registry.npmjs.org", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/widget", "/widget/latest":
+			_, _ = w.Write([]byte(`{"name":"widget","version":"1.0.0","_npmUser":{"name":"octo-npm"},"maintainers":[{"name":"octo-npm"}]}`))
+		case "/-/v1/search":
+			if q := r.URL.Query(); q.Get("text") != "maintainer:octo-npm" || q.Get("size") != "50" {
+				t.Errorf("searched npm for %q", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"objects":[
+				{"package":{"name":"@octo-npm/core"},"downloads":{"weekly":5000}},
+				{"package":{"name":"widget"},"downloads":{"weekly":12000}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	var listings atomic.Int32
+	scope := "/users/"
+	if userType == "Organization" {
+		scope = "/orgs/"
+	}
+	f.route(scope+"octo/packages", func(w http.ResponseWriter, r *http.Request) {
+		listings.Add(1)
+		var packages []GitHubPackage
+		switch r.URL.Query().Get("package_type") {
+		case PackageTypeNPM:
+			packages = []GitHubPackage{{Name: "internal", VersionCount: 4}}
+		case PackageTypeContainer:
+			packages = []GitHubPackage{{Name: "worker", VersionCount: 3}, {Name: "api", VersionCount: 10}}
+		}
+		_ = json.NewEncoder(w).Encode(packages)
+	})
+	return f, &listings
+}
+
+func TestCheckPackages(t *testing.T) {
+	for _, userType := range []string{"User", "Organization"} {
+		t.Run(userType, func(t *testing.T) {
+			f, listings := packagesFake(t, userType)
+			detailed, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).AnalyzeDetailed("octo")
+			if err != nil {
+				t.Fatalf("AnalyzeDetailed: %v", err)
+			}
+			metrics := detailed.Metrics
+			if metrics.NPMUsername != "octo-npm" || metrics.NPMPackages != 3 || metrics.ContainerImages != 2 || listings.Load() != 2 {
+				t.Errorf("npm user %q, %d npm packages, %d images from %d listings; want octo-npm, 3, 2 and 2",
+					metrics.NPMUsername, metrics.NPMPackages, metrics.ContainerImages, listings.Load())
+			}
+
+			npm := finding(detailed.Analysis, "NPM_PACKAGES")
+			want := []string{"widget (12,000 weekly downloads)", "@octo-npm/core (5,000 weekly downloads)", "@octo/internal on GitHub Packages (4 versions)"}
+			if npm == nil || npm.Severity != SeverityPositive || !slices.Equal(npm.Evidence, want) {
+				t.Errorf("NPM_PACKAGES = %+v, want %q", npm, want)
+			}
+			images := finding(detailed.Analysis, "CONTAINER_IMAGES")
+			if want := []string{"api (10 versions)", "worker (3 versions)"}; images == nil || !slices.Equal(images.Evidence, want) {
+				t.Errorf("CONTAINER_IMAGES = %+v, want the most-versioned first", images)
+			}
+			if i := slices.IndexFunc(detailed.DataSources, func(s DataSource) bool { return s.Name == "github_packages" }); i < 0 || detailed.DataSources[i].Status != SourceOK {
+				t.Errorf("sources = %+v, want github_packages ok", detailed.DataSources)
+			}
+		})
+	}
+}
+
+func TestCheckPackagesGated(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		token string
+		deep  bool
+	}{
+		{"not deep", "t0ken", false},
+		{"anonymous", "", true},
+	} {
+		f, listings := packagesFake(t, "User")
+		analysis, err := newFakeAnalyzerToken(f, tt.token, WithDeepChecks(tt.deep)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		if listings.Load() != 0 || analysis.Metrics.ContainerImages != 0 || analysis.Metrics.NPMUsername != "" || finding(analysis, "CONTAINER_IMAGES") != nil {
+			t.Errorf("%s: listed GitHub Packages %d times, found %d images", tt.name, listings.Load(), analysis.Metrics.ContainerImages)
+		}
+	}
+}
+
+func TestCheckPackagesForbidden(t *testing.T) {
+	f, _ := packagesFake(t, "User")
+	// A token without read:packages
+	f.route("/users/octo/packages", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Must have admin rights to Repository."}`, http.StatusForbidden)
+	})
+	detailed, err := newFakeAnalyzerToken(f, "t0ken", WithDeepChecks(true)).AnalyzeDetailed("octo")
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed: %v", err)
+	}
+	i := slices.IndexFunc(detailed.DataSources, func(s DataSource) bool { return s.Name == "github_packages" })
+	if i < 0 || detailed.DataSources[i].Status != SourceFallback || !strings.Contains(detailed.DataSources[i].Detail, "failed to list GitHub Packages") {
+		t.Errorf("sources = %+v, want github_packages fallen back", detailed.DataSources)
+	}
+	// The registry search still counts
+	if detailed.Metrics.NPMPackages != 2 || detailed.Metrics.ContainerImages != 0 {
+		t.Errorf("%d npm packages, %d images; want the 2 from npm and none", detailed.Metrics.NPMPackages, detailed.Metrics.ContainerImages)
+	}
+}
@@ -0,0 +1,212 @@
+package ebert
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	// contentOnlyKB is the size below which a repo without a code language
+	// is taken for README-only
+	contentOnlyKB = 100
+
+	// maxContentOnlyChecked bounds the content-only repos whose file tree
+	// deep mode reads to confirm there is no code
+	maxContentOnlyChecked = 5
+
+	// paddingShare and minPaddingRepos set when tutorial clones and
+	// content-only repos are enough of the original repos to warn about
+	paddingShare    = 0.3
+	minPaddingRepos = 5
+)
+
+//go:embed tutorialkeywords.txt
+var builtinTutorialKeywords []byte
+
+// DefaultTutorialKeywords are the fragments and /regexes/ that mark a repo
+// named or described like a course project
+func DefaultTutorialKeywords() []string {
+	set, err := ParsePatternSet(builtinTutorialKeywords, DefaultPatternLimits)
+	if err != nil {
+		panic(fmt.Sprintf("ebert: embedded tutorial keywords: %v", err))
+	}
+	return set.Entries()
+}
+
+// tutorialKeywords is the set WithTutorialKeywords configured, or the
+// built-in one
+func tutorialKeywords(configured []string) *PatternSet {
+	if configured == nil {
+		configured = DefaultTutorialKeywords()
+	}
+	set, err := NewPatternSet(configured, DefaultPatternLimits)
+	if err != nil {
+		set, _ = NewPatternSet(DefaultTutorialKeywords(), DefaultPatternLimits)
+	}
+	return set
+}
+
+// isTutorialClone reports whether the repo's name or description matches
+// a tutorial keyword; names are matched with dashes and underscores read
+// as spaces
+func isTutorialClone(repo GitHubRepo, keywords *PatternSet) bool {
+	name := strings.NewReplacer("-", " ", "_", " ", ".", " ").Replace(repo.Name)
+	return keywords.MatchString(name) || keywords.MatchString(repo.Description)
+}
+
+// isContentOnly reports whether the repo looks like it holds no code: no
+// language or Markdown, and either tiny or an awesome-list clone
+func isContentOnly(repo GitHubRepo) bool {
+	if repo.Language != "" && repo.Language != "Markdown" {
+		return false
+	}
+	return repo.Size < contentOnlyKB || strings.HasPrefix(strings.ToLower(repo.Name), "awesome")
+}
+
+// paddingRepo is an original repo counted as padding, kept so deep mode
+// can take back the content-only ones whose tree turns out to hold code
+type paddingRepo struct {
+	repo     GitHubRepo
+	tutorial bool
+}
+
+// addPadding counts an original repo that is a tutorial clone or
+// content-only toward Metrics and the totals Quality leaves out
+func (m *metricsAccumulator) addPadding(repo GitHubRepo, weightedStars float64) {
+	tutorial := isTutorialClone(repo, m.tutorialKeywords)
+	if !tutorial && !isContentOnly(repo) {
+		return
+	}
+	if tutorial {
+		m.metrics.TutorialRepos++
+	} else {
+		m.metrics.ContentOnlyRepos++
+		if len(m.paddingRepos) < maxContentOnlyChecked {
+			m.paddingRepos = append(m.paddingRepos, paddingRepo{repo: repo})
+		}
+	}
+	m.padding.repos++
+	m.padding.stars += repo.StargazersCount
+	m.padding.forks += repo.ForksCount
+	m.padding.weightedStars.add(weightedStars)
+}
+
+// removePadding takes a repo back out of the content-only count
+func (m *metricsAccumulator) removePadding(repo GitHubRepo) {
+	m.metrics.ContentOnlyRepos--
+	m.padding.repos--
+	m.padding.stars -= repo.StargazersCount
+	m.padding.forks -= repo.ForksCount
+	m.padding.weightedStars.add(-float64(repo.StargazersCount) * starWeight(repo, m.now, m.starHorizon, m.starFalloff))
+}
+
+// withoutPadding returns totals with the padding repos taken out, for
+// the Quality ratios
+func withoutPadding(metrics Metrics, padding repoTotals) Metrics {
+	metrics.Repos = max(metrics.Repos-padding.repos, 0)
+	metrics.Stars = max(metrics.Stars-padding.stars, 0)
+	metrics.Forks = max(metrics.Forks-padding.forks, 0)
+	metrics.WeightedStars = max(metrics.WeightedStars-padding.weightedStars.value(), 0)
+	return metrics
+}
+
+// contentFile reports whether a tree path is documentation, licensing or
+// an asset rather than code
+func contentFile(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, ".github/") {
+		return true
+	}
+	base := path.Base(lower)
+	for _, prefix := range []string{"readme", "license", "licence", "copying", "code_of_conduct", "contributing", ".gitignore", ".gitattributes"} {
+		if strings.HasPrefix(base, prefix) {
+			return true
+		}
+	}
+	switch path.Ext(base) {
+	case ".md", ".markdown", ".txt", ".rst", ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".ico", ".pdf":
+		return true
+	}
+	return false
+}
+
+// GitHubTreeEntry is one path in a git tree; Type is "blob", "tree" or
+// "commit" for submodules, and Size is set for blobs
+type GitHubTreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// GetTree lists the tree at ref, a branch, tag or tree SHA: its direct
+// entries, or with recursive every path below it. It reports whether
+// GitHub truncated the listing.
+func (c *GitHubClient) GetTree(ctx context.Context, owner, repo, ref string, recursive bool) (_ []GitHubTreeEntry, truncated bool, err error) {
+	ctx, span := c.startSpan(ctx, "github.tree", "repos/:owner/:repo/git/trees/:ref")
+	defer func() { endSpan(span, err) }()
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s", c.BaseURL, owner, repo, url.PathEscape(ref))
+	if recursive {
+		endpoint += "?recursive=1"
+	}
+	data, err := c.get(ctx, endpoint)
+	if err != nil {
+		return nil, false, err
+	}
+	var tree struct {
+		Tree      []GitHubTreeEntry `json:"tree"`
+		Truncated bool              `json:"truncated"`
+	}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, false, fmt.Errorf("failed to decode tree: %w", err)
+	}
+	return tree.Tree, tree.Truncated, nil
+}
+
+// confirmContentOnly reads the file trees of the first content-only repos
+// in deep mode and stops counting those that hold any code; a truncated
+// tree is too large to be README-only
+func (a *Analyzer) confirmContentOnly(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !r.log.coverage().repos {
+		return
+	}
+	for _, padding := range r.acc.paddingRepos {
+		repo := padding.repo
+		if ctx.Err() != nil || repo.DefaultBranch == "" || !r.contentsBudget.take() {
+			break
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+		tree, truncated, err := a.client.GetTree(ctx, owner, name, repo.DefaultBranch, true)
+		if err != nil {
+			continue
+		}
+		code := truncated
+		for _, entry := range tree {
+			if entry.Type == "blob" && !contentFile(entry.Path) {
+				code = true
+				break
+			}
+		}
+		if code {
+			r.acc.removePadding(repo)
+		}
+	}
+}
+
+// paddedRule warns when tutorial clones and README-only repos make up a
+// large share of the original repos, inflating how prolific the account
+// looks
+var paddedRule = flagRule("PADDED_WITH_TUTORIALS", SeverityWarning, "Tutorial clones and README-only repos are a large share of the original repos",
+	func(c RuleContext) (string, []any, bool) {
+		metrics := c.run.acc.metrics
+		padding := metrics.TutorialRepos + metrics.ContentOnlyRepos
+		original := metrics.RepoClasses.Original
+		return "Repo count padded with tutorial clones and README-only repos (%d of %d original)", []any{padding, original},
+			c.coverage().repos && padding >= minPaddingRepos && float64(padding) >= float64(original)*paddingShare
+	})
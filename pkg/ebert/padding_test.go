@@ -0,0 +1,189 @@
+package ebert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsTutorialClone(t *testing.T) {
+	keywords := tutorialKeywords(nil)
+	for _, tt := range []struct {
+		repo GitHubRepo
+		want bool
+	}{
+		{GitHubRepo{Name: "react-todo-app"}, true},
+		{GitHubRepo{Name: "My_First_Website"}, true},
+		{GitHubRepo{Name: "site", Description: "Built following a Udemy course"}, true},
+		{GitHubRepo{Name: "homework-3"}, true},
+		{GitHubRepo{Name: "assignment2"}, true},
+		{GitHubRepo{Name: "python.exercises"}, true},
+		{GitHubRepo{Name: "100-days-of-code"}, true},
+		// Whole words only for the regexes
+		{GitHubRepo{Name: "homeworks-tracker"}, false},
+		{GitHubRepo{Name: "reassignment"}, false},
+		{GitHubRepo{Name: "tool", Description: "A fast JSON parser"}, false},
+	} {
+		if got := isTutorialClone(tt.repo, keywords); got != tt.want {
+			t.Errorf("isTutorialClone(%q, %q) = %t, want %t", tt.repo.Name, tt.repo.Description, got, tt.want)
+		}
+	}
+}
+
+func TestIsContentOnly(t *testing.T) {
+	for _, tt := range []struct {
+		repo GitHubRepo
+		want bool
+	}{
+		{GitHubRepo{Name: "notes", Size: 12}, true},
+		{GitHubRepo{Name: "notes", Language: "Markdown", Size: 40}, true},
+		{GitHubRepo{Name: "Awesome-Go", Size: 9000}, true},
+		{GitHubRepo{Name: "notes", Size: contentOnlyKB}, false},
+		// Any code language is code, however small
+		{GitHubRepo{Name: "dotfiles", Language: "Shell", Size: 3}, false},
+		{GitHubRepo{Name: "awesome-tool", Language: "Go", Size: 9000}, false},
+	} {
+		if got := isContentOnly(tt.repo); got != tt.want {
+			t.Errorf("isContentOnly(%+v) = %t, want %t", tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestContentFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"README.md":                true,
+		"docs/readme.rst":          true,
+		"LICENSE":                  true,
+		"COPYING":                  true,
+		".github/workflows/ci.yml": true,
+		".gitignore":               true,
+		"assets/logo.svg":          true,
+		"notes/week1.txt":          true,
+		"main.go":                  false,
+		"scripts/run.sh":           false,
+		"Makefile":                 false,
+		".github.yml":              false,
+	} {
+		if got := contentFile(name); got != want {
+			t.Errorf("contentFile(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestTutorialKeywordOptions(t *testing.T) {
+	a, err := New("", WithTutorialKeywords("kata", "/^lesson \\d+$/"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// The configured keywords replace the built-in ones, and see names
+	// with dashes as spaces
+	keywords := tutorialKeywords(a.opts.TutorialKeywords)
+	for name, want := range map[string]bool{"string-kata": true, "lesson-4": true, "todo-app": false} {
+		if got := isTutorialClone(GitHubRepo{Name: name}, keywords); got != want {
+			t.Errorf("%s: tutorial clone %t, want %t", name, got, want)
+		}
+	}
+
+	if _, err := New("", WithTutorialKeywords("/[/")); err == nil {
+		t.Error("an invalid regex was accepted")
+	}
+
+	path := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(path, []byte("# Ours\nworkshop\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err = New("", WithTutorialKeywordsFile(path))
+	if err != nil || len(a.opts.TutorialKeywords) != 1 || a.opts.TutorialKeywords[0] != "workshop" {
+		t.Errorf("New = %v, keywords %q", err, a.opts.TutorialKeywords)
+	}
+	if _, err := New("", WithTutorialKeywordsFile(filepath.Join(t.TempDir(), "missing.txt"))); err == nil {
+		t.Error("a missing keywords file was accepted")
+	}
+}
+
+// paddedRepos are four real projects, three tutorial clones and three
+// content-only repos, one of which holds scripts
+func paddedRepos() []GitHubRepo {
+	repo := func(name, language string, size, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: language, Size: size, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(4))}
+	}
+	return []GitHubRepo{
+		repo("tool", "Go", 900, 300), repo("lib", "Go", 500, 80), repo("cli", "Rust", 700, 40), repo("site", "TypeScript", 400, 12),
+		repo("todo-app", "JavaScript", 300, 1), repo("weather-app-tutorial", "JavaScript", 250, 0), repo("bootcamp-week-2", "HTML", 200, 0),
+		repo("notes", "", 8, 2), repo("awesome-go-links", "", 2000, 50), repo("scripts", "", 20, 0),
+	}
+}
+
+func TestPaddedWithTutorials(t *testing.T) {
+	real, err := newFakeAnalyzer(newFakeGitHub(t, newAccount("octo", days(3000), paddedRepos()[:4]...))).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		deep    bool
+		content int
+		message string
+	}{
+		{name: "listing", content: 3, message: "(6 of 10 original)"},
+		// The tree of scripts holds code, so it counts after all
+		{name: "deep", deep: true, content: 2, message: "(5 of 10 original)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGitHub(t, newAccount("octo", days(3000), paddedRepos()...))
+			serveTree(f, "octo", "notes", "main", map[string]string{"README.md": "# Notes", "diagram.png": "", "LICENSE": "MIT"})
+			serveTree(f, "octo", "awesome-go-links", "main", map[string]string{"README.md": "# Awesome Go", "CONTRIBUTING.md": "PRs welcome"})
+			serveTree(f, "octo", "scripts", "main", map[string]string{"README.md": "# Scripts", "backup.sh": "#!/bin/sh"})
+
+			analysis, err := newFakeAnalyzerToken(f, "token", WithDeepChecks(tt.deep)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if analysis.Metrics.TutorialRepos != 3 || analysis.Metrics.ContentOnlyRepos != tt.content {
+				t.Errorf("%d tutorial clones and %d content-only repos, want 3 and %d", analysis.Metrics.TutorialRepos, analysis.Metrics.ContentOnlyRepos, tt.content)
+			}
+			flag := finding(analysis, "PADDED_WITH_TUTORIALS")
+			if flag == nil || flag.Severity != SeverityWarning || !strings.Contains(flag.Message, tt.message) {
+				t.Errorf("PADDED_WITH_TUTORIALS = %+v, want %s", flag, tt.message)
+			}
+		})
+	}
+
+	// Quality is scored as if the padding weren't there
+	f := newFakeGitHub(t, newAccount("octo", days(3000), paddedRepos()...))
+	padded, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if *padded.Scores.Quality != *real.Scores.Quality {
+		t.Errorf("quality %v with the padding, %v without", *padded.Scores.Quality, *real.Scores.Quality)
+	}
+}
+
+func TestPaddedWithTutorialsThreshold(t *testing.T) {
+	repos := paddedRepos()
+	var projects []GitHubRepo
+	for i := range 15 {
+		projects = append(projects, GitHubRepo{Name: fmt.Sprintf("project-%02d", i), Language: "Go", Size: 900, StargazersCount: 10, UpdatedAt: fakeNow.Add(-days(4))})
+	}
+	for _, tt := range []struct {
+		name  string
+		repos []GitHubRepo
+	}{
+		// Four is below the minimum, whatever the share
+		{"too few", append(repos[:4:4], repos[4:8]...)},
+		// Six of twenty-one is under 30%
+		{"too small a share", append(projects, repos[4:]...)},
+	} {
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, newAccount("octo", days(3000), tt.repos...))).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		if flag := finding(analysis, "PADDED_WITH_TUTORIALS"); flag != nil {
+			t.Errorf("%s: unexpected %+v", tt.name, flag)
+		}
+	}
+}
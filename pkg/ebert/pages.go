@@ -0,0 +1,62 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maintainedDocsWindow is how recently a docs site's repo must have been
+// updated to count as maintained
+const maintainedDocsWindow = 365 * 24 * time.Hour
+
+// isUserPagesRepo reports whether repo is the account's <login>.github.io site
+func isUserPagesRepo(repo GitHubRepo, login string) bool {
+	return strings.EqualFold(repo.Name, login+".github.io")
+}
+
+// pagesURL is the default GitHub Pages address for a repo
+func pagesURL(repo GitHubRepo, login string) string {
+	host := strings.ToLower(login) + ".github.io"
+	if isUserPagesRepo(repo, login) {
+		return "https://" + host + "/"
+	}
+	return "https://" + host + "/" + repo.Name + "/"
+}
+
+// checkDocsSites credits maintained documentation sites on flagship repos.
+// Having no docs site is never held against the account. In deep mode the
+// sites are probed to confirm they still serve.
+func (a *Analyzer) checkDocsSites(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	var maintained []string
+	for _, repo := range r.flagships() {
+		if !repo.HasPages || repo.Archived || r.now.Sub(repo.UpdatedAt) > maintainedDocsWindow {
+			continue
+		}
+
+		site := pagesURL(repo, r.username)
+		if a.opts.DeepChecks && a.opts.ExternalChecks {
+			status, err := a.client.headExternal(ctx, site)
+			if err != nil || status >= http.StatusBadRequest {
+				continue
+			}
+		}
+		maintained = append(maintained, fmt.Sprintf("%s (%s)", repo.Name, site))
+	}
+
+	r.acc.metrics.MaintainedDocsSites = len(maintained)
+	if len(maintained) > 0 {
+		r.addFinding(Finding{
+			Code:     "DOCS_SITE",
+			Severity: SeverityPositive,
+			Message:  "Flagship projects publish maintained documentation sites",
+			Evidence: maintained,
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package ebert
+
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPagesURL(t *testing.T) {
+	for _, tt := range []struct {
+		repo, want string
+	}{
+		{"tool", "https://octo.github.io/tool/"},
+		{"octo.github.io", "https://octo.github.io/"},
+		{"Octo.GitHub.io", "https://octo.github.io/"},
+	} {
+		if got := pagesURL(GitHubRepo{Name: tt.repo}, "Octo"); got != tt.want {
+			t.Errorf("pagesURL(%s) = %s, want %s", tt.repo, got, tt.want)
+		}
+	}
+}
+
+// docsAccount has three flagship repos, one untouched for two years, and a
+// minor one, all with or all without Pages
+func docsAccount(pages bool) *fakeAccount {
+	return newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 500, HasPages: pages, UpdatedAt: fakeNow.Add(-days(5))},
+		GitHubRepo{Name: "site", Language: "Go", Size: 900, StargazersCount: 400, HasPages: pages, UpdatedAt: fakeNow.Add(-days(30))},
+		GitHubRepo{Name: "old-docs", Language: "Go", Size: 900, StargazersCount: 300, HasPages: pages, UpdatedAt: fakeNow.Add(-days(730))},
+		GitHubRepo{Name: "minor", Language: "Go", Size: 100, StargazersCount: 2, HasPages: pages, UpdatedAt: fakeNow.Add(-days(10))},
+	)
+}
+
+func TestDocsSites(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		// live is the sites answering the probe
+		live       []string
+		maintained []string
+		probes     int32
+	}{
+		{"no deep checks", []Option{WithDeepChecks(false)}, nil, []string{"tool (https://octo.github.io/tool/)", "site (https://octo.github.io/site/)"}, 0},
+		{"probed", []Option{WithDeepChecks(true)}, []string{"/tool/"}, []string{"tool (https://octo.github.io/tool/)"}, 2},
+		{"external checks off", []Option{WithDeepChecks(true), WithExternalChecks(false)}, nil, []string{"tool (https://octo.github.io/tool/)", "site (https://octo.github.io/site/)"}, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGitHub(t, docsAccount(true))
+			var probes atomic.Int32
+			f.host("octo.github.io", func(w http.ResponseWriter, r *http.Request) {
+				probes.Add(1)
+				if r.Method != http.MethodHead {
+					t.Errorf("probed with %s, want HEAD", r.Method)
+				}
+				if !slices.Contains(tt.live, r.URL.Path) {
+					http.NotFound(w, r)
+				}
+			})
+
+			analysis, err := newFakeAnalyzer(f, tt.opts...).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if metrics.DocsSites != 4 || metrics.MaintainedDocsSites != len(tt.maintained) {
+				t.Errorf("DocsSites = %d, MaintainedDocsSites = %d; want 4 and %d", metrics.DocsSites, metrics.MaintainedDocsSites, len(tt.maintained))
+			}
+			flag := finding(analysis, "DOCS_SITE")
+			if flag == nil || flag.Severity != SeverityPositive || !slices.Equal(flag.Evidence, tt.maintained) {
+				t.Errorf("DOCS_SITE = %+v, want %q", flag, tt.maintained)
+			}
+			if probes.Load() != tt.probes {
+				t.Errorf("probed %d sites, want %d", probes.Load(), tt.probes)
+			}
+		})
+	}
+}
+
+func TestDocsSitesOnlyCredited(t *testing.T) {
+	unspaced(t)
+	quality := func(pages bool) float64 {
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, docsAccount(pages)), WithDeepChecks(false)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		if !pages && (finding(analysis, "DOCS_SITE") != nil || analysis.Metrics.DocsSites != 0) {
+			t.Errorf("credited docs sites to an account without Pages")
+		}
+		return *analysis.Scores.Quality
+	}
+	with, without := quality(true), quality(false)
+	if with != without-5 {
+		t.Errorf("quality risk is %v with docs sites and %v without, want 5 less with them", with, without)
+	}
+}
+
+func TestUserPagesSite(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		updated int
+		want    bool
+	}{
+		{"maintained", 100, true},
+		{"abandoned", 500, false},
+	} {
+		account := newAccount("octo", days(3000),
+			GitHubRepo{Name: "tool", Language: "Go", Size: 900, UpdatedAt: fakeNow.Add(-days(5))},
+			GitHubRepo{Name: "Octo.github.io", Language: "HTML", Size: 300, HasPages: true, UpdatedAt: fakeNow.Add(-days(tt.updated))},
+		)
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, account), WithDeepChecks(false)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		if analysis.Metrics.UserPagesSite != tt.want {
+			t.Errorf("%s: UserPagesSite = %t, want %t", tt.name, analysis.Metrics.UserPagesSite, tt.want)
+		}
+	}
+}
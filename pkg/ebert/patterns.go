@@ -0,0 +1,212 @@
+package ebert
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrPatternLimit is returned when a user-supplied pattern list is larger
+// than its PatternLimits allow
+var ErrPatternLimit = errors.New("pattern list over its limit")
+
+// PatternLimits bound a user-supplied pattern list so an oversized file or
+// entry fails with a clear error rather than exhausting memory. Zero
+// fields take DefaultPatternLimits' values.
+type PatternLimits struct {
+	// MaxBytes caps the size of a pattern file
+	MaxBytes int64
+
+	// MaxEntries caps the number of distinct entries
+	MaxEntries int
+
+	// MaxEntryLength caps the length of one entry in bytes
+	MaxEntryLength int
+}
+
+// DefaultPatternLimits are generous for hand-written lists and stop a
+// generated one long before it matters
+var DefaultPatternLimits = PatternLimits{MaxBytes: 1 << 20, MaxEntries: 10_000, MaxEntryLength: 512}
+
+func (l PatternLimits) withDefaults() PatternLimits {
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = DefaultPatternLimits.MaxBytes
+	}
+	if l.MaxEntries <= 0 {
+		l.MaxEntries = DefaultPatternLimits.MaxEntries
+	}
+	if l.MaxEntryLength <= 0 {
+		l.MaxEntryLength = DefaultPatternLimits.MaxEntryLength
+	}
+	return l
+}
+
+// PatternSet is a normalized, deduplicated list of case-insensitive
+// patterns. An entry is a name fragment matched as a substring, or, written
+// between slashes as in /^acme-/, an RE2 regular expression. RE2 runs in
+// time linear in the input, so no pattern can hang a match; in exchange
+// backreferences and lookarounds aren't supported.
+type PatternSet struct {
+	entries   []string
+	fragments []string
+	regexps   []*regexp.Regexp
+}
+
+// NewPatternSet builds a set from entries, trimming them, lowercasing
+// fragments and dropping duplicates. Empty entries, invalid regexes and
+// lists over limits are errors.
+func NewPatternSet(entries []string, limits PatternLimits) (*PatternSet, error) {
+	limits = limits.withDefaults()
+	set := &PatternSet{}
+	seen := map[string]struct{}{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return nil, errors.New("patterns must not be empty")
+		}
+		if err := set.add(entry, seen, limits); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// ParsePatternSet reads a set from a pattern file: one entry per line,
+// blank lines and lines starting with # skipped. A UTF-8 byte order mark
+// and CRLF line endings are accepted.
+func ParsePatternSet(data []byte, limits PatternLimits) (*PatternSet, error) {
+	limits = limits.withDefaults()
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrPatternLimit, len(data), limits.MaxBytes)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	set := &PatternSet{}
+	seen := map[string]struct{}{}
+	// data is already within MaxBytes, so any line fits; MaxEntryLength
+	// applies to the trimmed entry, not to comments or indentation
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if err := set.add(entry, seen, limits); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read patterns: %w", err)
+	}
+	return set, nil
+}
+
+// LoadPatternSet reads a pattern file in ParsePatternSet's format,
+// refusing files larger than limits.MaxBytes before reading them
+func LoadPatternSet(path string, limits PatternLimits) (*PatternSet, error) {
+	data, err := readLimited(path, limits.withDefaults().MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern file: %w", err)
+	}
+	set, err := ParsePatternSet(data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("pattern file %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// readLimited reads a user-supplied file of at most maxBytes, checking
+// the size up front and again while reading in case the file grows
+func readLimited(path string, maxBytes int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	if info, err := file.Stat(); err == nil && info.Mode().IsRegular() && info.Size() > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrPatternLimit, info.Size(), maxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrPatternLimit, maxBytes)
+	}
+	return data, nil
+}
+
+// add normalizes and appends one trimmed, non-empty entry unless it is a
+// duplicate
+func (s *PatternSet) add(entry string, seen map[string]struct{}, limits PatternLimits) error {
+	if len(entry) > limits.MaxEntryLength {
+		return fmt.Errorf("%w: pattern of %d bytes, at most %d allowed", ErrPatternLimit, len(entry), limits.MaxEntryLength)
+	}
+
+	isRegexp := len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/")
+	if !isRegexp {
+		entry = strings.ToLower(entry)
+	}
+	if _, ok := seen[entry]; ok {
+		return nil
+	}
+	if len(s.entries) >= limits.MaxEntries {
+		return fmt.Errorf("%w: more than %d patterns", ErrPatternLimit, limits.MaxEntries)
+	}
+
+	if isRegexp {
+		re, err := regexp.Compile("(?i)" + entry[1:len(entry)-1])
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s (RE2 syntax, no backreferences or lookarounds): %w", entry, err)
+		}
+		s.regexps = append(s.regexps, re)
+	} else {
+		s.fragments = append(s.fragments, entry)
+	}
+	seen[entry] = struct{}{}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Entries lists the normalized entries in the order first seen, regexes
+// still between slashes
+func (s *PatternSet) Entries() []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s.entries...)
+}
+
+// Len is the number of distinct entries
+func (s *PatternSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.entries)
+}
+
+// MatchString reports whether any entry matches value, ignoring case
+func (s *PatternSet) MatchString(value string) bool {
+	if s == nil {
+		return false
+	}
+	lower := strings.ToLower(value)
+	for _, fragment := range s.fragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	for _, re := range s.regexps {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
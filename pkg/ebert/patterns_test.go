@@ -0,0 +1,96 @@
+package ebert
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePatternSet(t *testing.T) {
+	data := []byte("\ufeff# internal names\r\nAcme-\r\n\r\n  acme-  \r\n/^corp[0-9]+$/\r\n# trailing comment\r\nINTERNAL\r\n/^corp[0-9]+$/")
+	set, err := ParsePatternSet(data, PatternLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The BOM and CRs are gone, fragments lowercased and repeats dropped
+	if want := []string{"acme-", "/^corp[0-9]+$/", "internal"}; !slices.Equal(set.Entries(), want) {
+		t.Errorf("entries = %q, want %q", set.Entries(), want)
+	}
+	for value, want := range map[string]bool{
+		"ACME-tools":  true,
+		"my-internal": true,
+		"Corp42":      true,
+		"corp42-x":    false,
+		"acme":        false,
+	} {
+		if got := set.MatchString(value); got != want {
+			t.Errorf("MatchString(%q) = %t, want %t", value, got, want)
+		}
+	}
+}
+
+func TestPatternSetLimits(t *testing.T) {
+	limits := PatternLimits{MaxBytes: 64, MaxEntries: 3, MaxEntryLength: 8}
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{"file size", strings.Repeat("a\n", 33)},
+		{"entry count", "a\nb\nc\nd\n"},
+		{"entry length", "abcdefghi\n"},
+	} {
+		if _, err := ParsePatternSet([]byte(tc.data), limits); !errors.Is(err, ErrPatternLimit) {
+			t.Errorf("%s: ParsePatternSet = %v, want ErrPatternLimit", tc.name, err)
+		}
+	}
+
+	// Duplicates, comments, indentation and line endings don't count
+	// against the limits
+	within := "# a long comment\r\na\r\nA\r\n          b\r\nabcdefgh\r\na\r\n"
+	if set, err := ParsePatternSet([]byte(within), limits); err != nil || set.Len() != 3 {
+		t.Errorf("ParsePatternSet(%q) = %v, %v; want 3 entries", within, set.Entries(), err)
+	}
+	if _, err := NewPatternSet([]string{"a", "b", "c", "d"}, limits); !errors.Is(err, ErrPatternLimit) {
+		t.Errorf("NewPatternSet over MaxEntries = %v, want ErrPatternLimit", err)
+	}
+}
+
+func TestPatternSetInvalid(t *testing.T) {
+	for _, entries := range [][]string{
+		{`/(a)\1/`},
+		{`/foo(?=bar)/`},
+		{`/[unclosed/`},
+		{"ok", "  "},
+	} {
+		if _, err := NewPatternSet(entries, PatternLimits{}); err == nil {
+			t.Errorf("NewPatternSet(%q) accepted it", entries)
+		}
+	}
+	_, err := ParsePatternSet([]byte("fine\n/(a)\\1/\n"), PatternLimits{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "RE2") {
+		t.Errorf("ParsePatternSet with a backreference = %v, want the line and RE2 named", err)
+	}
+}
+
+func TestLoadPatternSet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "patterns.txt")
+	if err := os.WriteFile(path, []byte("\ufeffacme-\r\n"+strings.Repeat("x", 200)+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := LoadPatternSet(path, PatternLimits{})
+	if err != nil || set.Len() != 2 || !set.MatchString("acme-web") {
+		t.Errorf("LoadPatternSet = %v, %v; want both entries", set.Entries(), err)
+	}
+	// Refused on its size, before reading
+	if _, err := LoadPatternSet(path, PatternLimits{MaxBytes: 100}); !errors.Is(err, ErrPatternLimit) || !strings.Contains(err.Error(), "at most 100") {
+		t.Errorf("LoadPatternSet of an oversized file = %v, want ErrPatternLimit", err)
+	}
+	if _, err := LoadPatternSet(filepath.Join(dir, "missing.txt"), PatternLimits{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadPatternSet of a missing file = %v, want ErrNotExist", err)
+	}
+}
@@ -0,0 +1,75 @@
+package ebert
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrPolicyViolation is returned by FindingPolicy.Check when an analysis
+// fails the policy
+var ErrPolicyViolation = errors.New("finding policy violated")
+
+// FindingPolicy is how a CI gate treats an analysis's findings and
+// composite indices. The zero policy changes nothing and passes every
+// analysis.
+type FindingPolicy struct {
+	// WarningsAsErrors promotes every warning to a red flag, and fails
+	// Check on any red flag
+	WarningsAsErrors bool `json:"warnings_as_errors,omitempty"`
+
+	// MaxWarnings fails Check when more warnings remain; nil is unlimited
+	MaxWarnings *int `json:"max_warnings,omitempty"`
+
+	// SeverityThreshold hides findings below it from FilterFindings'
+	// result. Check ignores it, so hiding a finding never passes a gate.
+	SeverityThreshold Severity `json:"severity_threshold,omitempty"`
+
+	// FailOnTrust and FailOnAbandonment fail Check when the TrustIndex or
+	// AbandonmentIndex reaches them; nil doesn't gate on the index
+	FailOnTrust       *float64 `json:"fail_on_trust,omitempty"`
+	FailOnAbandonment *float64 `json:"fail_on_abandonment,omitempty"`
+}
+
+// FilterFindings returns a copy of a with the policy applied to its
+// findings: warnings promoted, findings below the threshold dropped, and
+// the red flag, warning and positive lists rebuilt to match. Scores are
+// unchanged; a is not modified.
+func FilterFindings(a *Analysis, policy FindingPolicy) *Analysis {
+	filtered := *a
+	filtered.Findings = make([]Finding, 0, len(a.Findings))
+	for _, finding := range a.Findings {
+		if policy.WarningsAsErrors && finding.Severity == SeverityWarning {
+			finding.Severity = SeverityRedFlag
+		}
+		if finding.Severity < policy.SeverityThreshold {
+			continue
+		}
+		filtered.Findings = append(filtered.Findings, finding)
+	}
+	sortFindings(filtered.Findings)
+	filtered.RedFlags, filtered.Warnings, filtered.Positives = splitFindings(filtered.Findings)
+	filtered.TopRemediations = slices.DeleteFunc(slices.Clone(a.TopRemediations), func(item Remediation) bool {
+		return !slices.ContainsFunc(filtered.Findings, func(finding Finding) bool { return finding.Code == item.Code })
+	})
+	return &filtered
+}
+
+// Check reports whether a passes the policy, wrapping ErrPolicyViolation
+// with the reason when it doesn't
+func (p FindingPolicy) Check(a *Analysis) error {
+	gated := FilterFindings(a, FindingPolicy{WarningsAsErrors: p.WarningsAsErrors})
+	if p.WarningsAsErrors && len(gated.RedFlags) > 0 {
+		return fmt.Errorf("%w: %d red flags with warnings as errors", ErrPolicyViolation, len(gated.RedFlags))
+	}
+	if p.MaxWarnings != nil && len(gated.Warnings) > *p.MaxWarnings {
+		return fmt.Errorf("%w: %d warnings, at most %d allowed", ErrPolicyViolation, len(gated.Warnings), *p.MaxWarnings)
+	}
+	if p.FailOnTrust != nil && a.TrustIndex != nil && *a.TrustIndex >= *p.FailOnTrust {
+		return fmt.Errorf("%w: trust index %.1f reaches %.1f", ErrPolicyViolation, *a.TrustIndex, *p.FailOnTrust)
+	}
+	if p.FailOnAbandonment != nil && a.AbandonmentIndex != nil && *a.AbandonmentIndex >= *p.FailOnAbandonment {
+		return fmt.Errorf("%w: abandonment index %.1f reaches %.1f", ErrPolicyViolation, *a.AbandonmentIndex, *p.FailOnAbandonment)
+	}
+	return nil
+}
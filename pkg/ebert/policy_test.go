@@ -0,0 +1,99 @@
+package ebert
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// policyAnalysis has a finding or two of every severity and remediations
+// for a warning and the info
+func policyAnalysis(trust float64) *Analysis {
+	findings := []Finding{
+		{Code: "NEW_ACCOUNT", Severity: SeverityRedFlag, Message: "new"},
+		{Code: "UNSIGNED", Severity: SeverityWarning, Message: "unsigned"},
+		{Code: "NO_2FA", Severity: SeverityWarning, Message: "no 2fa"},
+		{Code: "CO_MAINTAINERS", Severity: SeverityInfo, Message: "co-maintained"},
+		{Code: "ESTABLISHED", Severity: SeverityPositive, Message: "established"},
+	}
+	analysis := &Analysis{Findings: findings, TrustIndex: &trust}
+	analysis.RedFlags, analysis.Warnings, analysis.Positives = splitFindings(findings)
+	analysis.TopRemediations = []Remediation{{Code: "UNSIGNED", Action: "sign"}, {Code: "CO_MAINTAINERS", Action: "review"}}
+	return analysis
+}
+
+func TestFilterFindings(t *testing.T) {
+	for _, tt := range []struct {
+		name                          string
+		policy                        FindingPolicy
+		codes                         []string
+		redFlags, warnings, positives int
+		remediations                  []string
+	}{
+		{name: "zero policy", codes: []string{"NEW_ACCOUNT", "NO_2FA", "UNSIGNED", "CO_MAINTAINERS", "ESTABLISHED"},
+			redFlags: 1, warnings: 2, positives: 1, remediations: []string{"UNSIGNED", "CO_MAINTAINERS"}},
+		{name: "warnings as errors", policy: FindingPolicy{WarningsAsErrors: true}, codes: []string{"NEW_ACCOUNT", "NO_2FA", "UNSIGNED", "CO_MAINTAINERS", "ESTABLISHED"},
+			redFlags: 3, positives: 1, remediations: []string{"UNSIGNED", "CO_MAINTAINERS"}},
+		{name: "warning threshold", policy: FindingPolicy{SeverityThreshold: SeverityWarning}, codes: []string{"NEW_ACCOUNT", "NO_2FA", "UNSIGNED"},
+			redFlags: 1, warnings: 2, remediations: []string{"UNSIGNED"}},
+		{name: "red flag threshold", policy: FindingPolicy{SeverityThreshold: SeverityRedFlag}, codes: []string{"NEW_ACCOUNT"}, redFlags: 1},
+		// Promoted warnings clear a red flag threshold
+		{name: "both", policy: FindingPolicy{WarningsAsErrors: true, SeverityThreshold: SeverityRedFlag}, codes: []string{"NEW_ACCOUNT", "NO_2FA", "UNSIGNED"},
+			redFlags: 3, remediations: []string{"UNSIGNED"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := policyAnalysis(0)
+			filtered := FilterFindings(analysis, tt.policy)
+
+			var codes, remediations []string
+			for _, finding := range filtered.Findings {
+				codes = append(codes, finding.Code)
+			}
+			for _, item := range filtered.TopRemediations {
+				remediations = append(remediations, item.Code)
+			}
+			if !slices.Equal(codes, tt.codes) || !slices.Equal(remediations, tt.remediations) {
+				t.Errorf("findings %q, remediations %q; want %q and %q", codes, remediations, tt.codes, tt.remediations)
+			}
+			if len(filtered.RedFlags) != tt.redFlags || len(filtered.Warnings) != tt.warnings || len(filtered.Positives) != tt.positives {
+				t.Errorf("%d red flags, %d warnings, %d positives; want %d, %d, %d",
+					len(filtered.RedFlags), len(filtered.Warnings), len(filtered.Positives), tt.redFlags, tt.warnings, tt.positives)
+			}
+			if len(analysis.Findings) != 5 || analysis.Findings[1].Severity != SeverityWarning || len(analysis.TopRemediations) != 2 {
+				t.Errorf("FilterFindings modified the analysis: %+v", analysis)
+			}
+		})
+	}
+}
+
+func TestFindingPolicyCheck(t *testing.T) {
+	limit := func(n int) *int { return &n }
+	threshold := func(v float64) *float64 { return &v }
+	clean := &Analysis{Findings: []Finding{{Code: "ESTABLISHED", Severity: SeverityPositive}}}
+
+	for _, tt := range []struct {
+		name     string
+		policy   FindingPolicy
+		analysis *Analysis
+		fails    bool
+	}{
+		{name: "zero policy", analysis: policyAnalysis(90)},
+		{name: "warnings as errors", policy: FindingPolicy{WarningsAsErrors: true}, analysis: policyAnalysis(0), fails: true},
+		{name: "warnings as errors, clean", policy: FindingPolicy{WarningsAsErrors: true}, analysis: clean},
+		{name: "max warnings met", policy: FindingPolicy{MaxWarnings: limit(2)}, analysis: policyAnalysis(0)},
+		{name: "max warnings exceeded", policy: FindingPolicy{MaxWarnings: limit(1)}, analysis: policyAnalysis(0), fails: true},
+		{name: "no warnings allowed", policy: FindingPolicy{MaxWarnings: limit(0)}, analysis: clean},
+		// Promoted warnings no longer count as warnings
+		{name: "promoted past max warnings", policy: FindingPolicy{WarningsAsErrors: true, MaxWarnings: limit(0)}, analysis: clean},
+		// Hiding findings from the report never passes a gate
+		{name: "threshold hides warnings", policy: FindingPolicy{SeverityThreshold: SeverityRedFlag, MaxWarnings: limit(1)}, analysis: policyAnalysis(0), fails: true},
+		{name: "fail on trust below", policy: FindingPolicy{FailOnTrust: threshold(50), MaxWarnings: limit(2)}, analysis: policyAnalysis(40)},
+		{name: "fail on trust reached", policy: FindingPolicy{FailOnTrust: threshold(40), MaxWarnings: limit(2)}, analysis: policyAnalysis(40), fails: true},
+		{name: "fail on trust without an index", policy: FindingPolicy{FailOnTrust: threshold(0)}, analysis: clean},
+	} {
+		err := tt.policy.Check(tt.analysis)
+		if fails := err != nil; fails != tt.fails || fails && !errors.Is(err, ErrPolicyViolation) {
+			t.Errorf("%s: Check = %v, want failure %t", tt.name, err, tt.fails)
+		}
+	}
+}
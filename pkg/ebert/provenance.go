@@ -0,0 +1,300 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxProvenanceBytes caps a downloaded signature, certificate or bundle
+const maxProvenanceBytes = 1 << 20
+
+// maxSignaturesPerRelease bounds the signature assets fetched per release
+const maxSignaturesPerRelease = 3
+
+// Kinds of release signature
+const (
+	SignatureBundle = "sigstore_bundle"
+	SignatureInToto = "intoto"
+	SignatureCosign = "cosign_blob"
+)
+
+// SignedArtifact is a signature or attestation found on a release, ready
+// for a ProvenanceVerifier
+type SignedArtifact struct {
+	Repo  string
+	Tag   string
+	Asset string
+	Kind  string
+
+	// Signature is the bundle, DSSE envelope or detached signature, and
+	// Certificate the PEM published beside a detached signature
+	Signature   []byte
+	Certificate []byte
+
+	// ArtifactDigest is the "sha256:<hex>" GitHub records for the signed
+	// asset, when the signature names one
+	ArtifactDigest string
+
+	// ReleaseDigests are the digests GitHub records for every asset of the
+	// release by name, so an attestation can be bound to the assets its
+	// statement names
+	ReleaseDigests map[string]string
+}
+
+// ErrUnboundSignature is returned, with the signer's identity, when a
+// signature verifies but is bound to no asset of the release: neither the
+// asset it sits beside nor a subject of its statement matches a digest
+// GitHub records, so it could have been made for anything
+var ErrUnboundSignature = errors.New("signature is bound to no release asset")
+
+// SignerIdentity is who a verified signature's certificate was issued to
+type SignerIdentity struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+
+	// SourceRepository is the repo URL a CI-issued certificate was built from
+	SourceRepository string `json:"source_repository,omitempty"`
+}
+
+// ProvenanceVerifier verifies a release signature or attestation and
+// returns the identity it was issued to. It is deliberately small so
+// ebert doesn't depend on a signing stack; see the sigstoreebert package.
+type ProvenanceVerifier interface {
+	Verify(ctx context.Context, artifact SignedArtifact) (*SignerIdentity, error)
+}
+
+// ReleaseProvenance is the outcome of checking one release signature.
+// WorkflowMatch is set when the certificate was issued to a GitHub Actions
+// workflow running in the release's own repo.
+type ReleaseProvenance struct {
+	Repo          string          `json:"repo"`
+	Tag           string          `json:"tag"`
+	Asset         string          `json:"asset"`
+	Kind          string          `json:"kind"`
+	Verified      bool            `json:"verified"`
+	Unbound       bool            `json:"unbound,omitempty"`
+	Identity      *SignerIdentity `json:"identity,omitempty"`
+	WorkflowMatch bool            `json:"workflow_match"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// verifierIntegration names a ProvenanceVerifier refused by the host
+// allowlist among the skipped integrations
+const verifierIntegration = "provenance_verifier"
+
+// githubActionsIssuer is the OIDC issuer of GitHub Actions workflow tokens
+const githubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// matchesRepo reports whether the identity is a GitHub Actions workflow of
+// fullName. Reusable workflows such as the SLSA generator sign under their
+// own path but carry the caller's repo as the source repository.
+func (id *SignerIdentity) matchesRepo(fullName string) bool {
+	if id == nil || id.Issuer != githubActionsIssuer {
+		return false
+	}
+	repoURL := "https://github.com/" + strings.ToLower(fullName)
+	return strings.EqualFold(id.SourceRepository, repoURL) ||
+		strings.HasPrefix(strings.ToLower(id.Subject), repoURL+"/")
+}
+
+// describe names the identity for evidence
+func (id *SignerIdentity) describe() string {
+	if id.SourceRepository != "" && !strings.HasPrefix(id.Subject, id.SourceRepository) {
+		return fmt.Sprintf("%s from %s", id.Subject, id.SourceRepository)
+	}
+	return id.Subject
+}
+
+// releaseSignatures picks the signature and provenance assets of a release,
+// pairing each with the certificate and signed asset it refers to
+func releaseSignatures(release *GitHubRelease) []releaseSignature {
+	assets := map[string]ReleaseAsset{}
+	for _, asset := range release.Assets {
+		assets[asset.Name] = asset
+	}
+
+	var signatures []releaseSignature
+	for _, asset := range release.Assets {
+		sig := releaseSignature{asset: asset}
+		var signed string
+		switch name := asset.Name; {
+		case strings.HasSuffix(name, ".sigstore.json"):
+			sig.kind, signed = SignatureBundle, strings.TrimSuffix(name, ".sigstore.json")
+		case strings.HasSuffix(name, ".sigstore"):
+			sig.kind, signed = SignatureBundle, strings.TrimSuffix(name, ".sigstore")
+		case strings.HasSuffix(name, ".intoto.jsonl"):
+			sig.kind = SignatureInToto
+		case strings.HasSuffix(name, ".sig"):
+			sig.kind, signed = SignatureCosign, strings.TrimSuffix(name, ".sig")
+			for _, cert := range []string{signed + ".pem", signed + ".crt", signed + ".cert"} {
+				if asset, ok := assets[cert]; ok {
+					sig.certificate = &asset
+					break
+				}
+			}
+		default:
+			continue
+		}
+		if asset, ok := assets[signed]; ok {
+			sig.digest = asset.Digest
+		}
+		signatures = append(signatures, sig)
+	}
+	return signatures
+}
+
+// releaseSignature is a signature asset with what it refers to
+type releaseSignature struct {
+	asset       ReleaseAsset
+	kind        string
+	certificate *ReleaseAsset
+	digest      string
+}
+
+// checkReleaseProvenance looks for Sigstore bundles, SLSA provenance and
+// cosign signatures on the flagship repos' latest releases and verifies
+// them through the configured ProvenanceVerifier. It runs in deep mode
+// with external checks.
+func (a *Analyzer) checkReleaseProvenance(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !a.opts.ExternalChecks {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	attested := map[string]struct{}{}
+	var verified, unverifiable, unbound, mismatched, unchecked []string
+
+	for _, repo := range r.flagships() {
+		if repo.Fork || repo.Archived || !r.contentsBudget.take() {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+		release, err := a.client.GetLatestRelease(ctx, owner, name)
+		if err != nil || release == nil {
+			continue
+		}
+
+		signatures := releaseSignatures(release)
+		for _, sig := range signatures[:min(len(signatures), maxSignaturesPerRelease)] {
+			result := ReleaseProvenance{Repo: repo.FullName, Tag: release.TagName, Asset: sig.asset.Name, Kind: sig.kind}
+			label := fmt.Sprintf("%s@%s %s", repo.FullName, release.TagName, sig.asset.Name)
+
+			if a.opts.ProvenanceVerifier == nil {
+				metrics.ReleaseSignatures = append(metrics.ReleaseSignatures, result)
+				unchecked = append(unchecked, label)
+				continue
+			}
+
+			identity, err := a.verifyReleaseSignature(ctx, repo, release, sig)
+			if errors.Is(err, ErrHostNotAllowed) {
+				// The verifier couldn't reach its trust root, which says
+				// nothing about the signature; the check is skipped
+				if skipped := skippedHostsFrom(ctx); skipped != nil {
+					skipped.record(verifierIntegration, err)
+				}
+				result.Error = err.Error()
+				metrics.ReleaseSignatures = append(metrics.ReleaseSignatures, result)
+				unchecked = append(unchecked, label)
+				continue
+			}
+			switch {
+			case errors.Is(err, ErrUnboundSignature):
+				result.Identity, result.Unbound, result.Error = identity, true, err.Error()
+				unbound = append(unbound, fmt.Sprintf("%s signed by %s", label, identity.describe()))
+			case err != nil:
+				result.Error = err.Error()
+				unverifiable = append(unverifiable, fmt.Sprintf("%s: %v", label, err))
+			case identity.matchesRepo(repo.FullName):
+				result.Verified, result.Identity, result.WorkflowMatch = true, identity, true
+				attested[repo.FullName] = struct{}{}
+				verified = append(verified, fmt.Sprintf("%s signed by %s", label, identity.describe()))
+			default:
+				result.Verified, result.Identity = true, identity
+				mismatched = append(mismatched, fmt.Sprintf("%s signed by %s", label, identity.describe()))
+			}
+			metrics.ReleaseSignatures = append(metrics.ReleaseSignatures, result)
+		}
+	}
+	metrics.AttestedReleases = len(attested)
+
+	if len(verified) > 0 {
+		r.addFinding(Finding{
+			Code:     "VERIFIED_PROVENANCE",
+			Severity: SeverityPositive,
+			Message:  "Releases carry signatures or provenance that verify and were issued to the repo's own workflow",
+			Evidence: verified,
+		})
+	}
+	if len(unchecked) > 0 {
+		message := "Releases carry signatures or provenance; no verifier was configured to check them"
+		if a.opts.ProvenanceVerifier != nil {
+			message = "Releases carry signatures or provenance; the verifier's trust root was out of reach of the allowed hosts"
+		}
+		r.addFinding(Finding{
+			Code:     "RELEASE_SIGNATURES",
+			Severity: SeverityInfo,
+			Message:  message,
+			Evidence: unchecked,
+		})
+	}
+	if len(unverifiable) > 0 {
+		r.addFinding(Finding{
+			Code:     "UNVERIFIABLE_SIGNATURES",
+			Severity: SeverityWarning,
+			Message:  "Release signatures or provenance that don't verify",
+			Evidence: unverifiable,
+		})
+	}
+	if len(unbound) > 0 {
+		r.addFinding(Finding{
+			Code:     "UNBOUND_SIGNATURES",
+			Severity: SeverityWarning,
+			Message:  "Release signatures verify but are bound to no asset of the release",
+			Evidence: unbound,
+		})
+	}
+	if len(mismatched) > 0 {
+		r.addFinding(Finding{
+			Code:     "PROVENANCE_IDENTITY_MISMATCH",
+			Severity: SeverityRedFlag,
+			Message:  "Release signatures verify but weren't issued to the repo's own workflow",
+			Evidence: mismatched,
+		})
+	}
+}
+
+// verifyReleaseSignature downloads a signature and its certificate and
+// passes them to the verifier
+func (a *Analyzer) verifyReleaseSignature(ctx context.Context, repo GitHubRepo, release *GitHubRelease, sig releaseSignature) (*SignerIdentity, error) {
+	signature, err := a.client.getExternal(ctx, sig.asset.BrowserDownloadURL, maxProvenanceBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", sig.asset.Name, err)
+	}
+	artifact := SignedArtifact{
+		Repo:           repo.FullName,
+		Tag:            release.TagName,
+		Asset:          sig.asset.Name,
+		Kind:           sig.kind,
+		Signature:      signature,
+		ArtifactDigest: sig.digest,
+		ReleaseDigests: map[string]string{},
+	}
+	for _, asset := range release.Assets {
+		if asset.Digest != "" {
+			artifact.ReleaseDigests[asset.Name] = asset.Digest
+		}
+	}
+	if sig.certificate != nil {
+		if artifact.Certificate, err = a.client.getExternal(ctx, sig.certificate.BrowserDownloadURL, maxProvenanceBytes); err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", sig.certificate.Name, err)
+		}
+	}
+
+	identity, err := a.opts.ProvenanceVerifier.Verify(ctx, artifact)
+	if (err == nil || errors.Is(err, ErrUnboundSignature)) && identity == nil {
+		err = errors.New("verifier returned no identity")
+	}
+	return identity, err
+}
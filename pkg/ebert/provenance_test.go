@@ -0,0 +1,130 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedVerifier answers each asset with a set identity and error, and
+// records what it was asked to verify
+type scriptedVerifier struct {
+	mu      sync.Mutex
+	answers map[string]error
+	seen    []SignedArtifact
+}
+
+func (v *scriptedVerifier) Verify(_ context.Context, artifact SignedArtifact) (*SignerIdentity, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.seen = append(v.seen, artifact)
+	identity := &SignerIdentity{
+		Issuer:           githubActionsIssuer,
+		Subject:          "https://github.com/" + artifact.Repo + "/.github/workflows/release.yml@refs/tags/" + artifact.Tag,
+		SourceRepository: "https://github.com/" + artifact.Repo,
+	}
+	return identity, v.answers[artifact.Asset]
+}
+
+// signedRelease serves signer/tool, whose latest release carries a signed
+// tarball, an unsigned one, a provenance file and a bundle
+func signedRelease(t *testing.T) (*fakeGitHub, GitHubRelease) {
+	t.Helper()
+	account := newAccount("signer", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 400, UpdatedAt: fakeNow.Add(-days(3))})
+	f := newFakeGitHub(t, account)
+	release := GitHubRelease{TagName: "v1.0.0", Assets: []ReleaseAsset{
+		{Name: "tool_linux_amd64.tar.gz", Digest: "sha256:" + strings.Repeat("ab", 32)},
+		{Name: "tool_darwin_arm64.tar.gz"},
+		{Name: "tool.intoto.jsonl"},
+		{Name: "tool_darwin_arm64.tar.gz.sigstore.json"},
+	}}
+	for i := range release.Assets {
+		release.Assets[i].BrowserDownloadURL = "https://github.com/signer/tool/releases/download/v1.0.0/" + release.Assets[i].Name
+	}
+	f.route("/repos/signer/tool/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(release)
+	})
+	f.host("github.com", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{}")
+	})
+	return f, release
+}
+
+func TestReleaseProvenanceBinding(t *testing.T) {
+	f, release := signedRelease(t)
+	verifier := &scriptedVerifier{answers: map[string]error{
+		"tool_darwin_arm64.tar.gz.sigstore.json": fmt.Errorf("%w: GitHub records no digest for the signed asset", ErrUnboundSignature),
+	}}
+	analysis, err := newFakeAnalyzerToken(f, "ghp_test", WithDeepChecks(true), WithExternalChecks(true), WithProvenanceVerifier(verifier)).Analyze("signer")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if len(verifier.seen) != 2 {
+		t.Fatalf("verified %d signatures, want 2", len(verifier.seen))
+	}
+	for _, artifact := range verifier.seen {
+		if artifact.ReleaseDigests["tool_linux_amd64.tar.gz"] != release.Assets[0].Digest || len(artifact.ReleaseDigests) != 1 {
+			t.Errorf("%s: release digests = %v, want the one recorded", artifact.Asset, artifact.ReleaseDigests)
+		}
+	}
+
+	if flag := finding(analysis, "VERIFIED_PROVENANCE"); flag == nil || len(flag.Evidence) != 1 || !strings.Contains(flag.Evidence[0], "tool.intoto.jsonl") {
+		t.Errorf("VERIFIED_PROVENANCE = %+v, want the attestation alone", flag)
+	}
+	unbound := finding(analysis, "UNBOUND_SIGNATURES")
+	if unbound == nil || unbound.Severity != SeverityWarning || len(unbound.Evidence) != 1 || !strings.Contains(unbound.Evidence[0], "sigstore.json") {
+		t.Errorf("UNBOUND_SIGNATURES = %+v, want a warning for the bundle", unbound)
+	}
+	for _, result := range analysis.Metrics.ReleaseSignatures {
+		if result.Unbound == result.Verified {
+			t.Errorf("%s: verified %v and unbound %v", result.Asset, result.Verified, result.Unbound)
+		}
+	}
+	if analysis.Metrics.AttestedReleases != 1 {
+		t.Errorf("AttestedReleases = %d, want 1", analysis.Metrics.AttestedReleases)
+	}
+}
+
+func TestReleaseProvenanceVerifierRefused(t *testing.T) {
+	f, release := signedRelease(t)
+	refused := fmt.Errorf("failed to fetch Sigstore trusted root: tuf-repo-cdn.sigstore.dev: %w", ErrHostNotAllowed)
+	verifier := &scriptedVerifier{answers: map[string]error{}}
+	for _, asset := range release.Assets {
+		verifier.answers[asset.Name] = refused
+	}
+
+	analyze := func(opts ...Option) *Analysis {
+		t.Helper()
+		opts = append([]Option{WithDeepChecks(true), WithExternalChecks(true)}, opts...)
+		analysis, err := newFakeAnalyzerToken(f, "ghp_test", opts...).Analyze("signer")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		return analysis
+	}
+	unverified := analyze()
+	analysis := analyze(WithProvenanceVerifier(verifier))
+
+	// A verifier that can't reach its trust root says nothing about the
+	// signatures, so none is reported as failing to verify
+	if flag := finding(analysis, "UNVERIFIABLE_SIGNATURES"); flag != nil {
+		t.Errorf("unexpected %+v", flag)
+	}
+	if flag := finding(analysis, "RELEASE_SIGNATURES"); flag == nil || len(flag.Evidence) != 2 || !strings.Contains(flag.Message, "allowed hosts") {
+		t.Errorf("RELEASE_SIGNATURES = %+v, want both signatures unchecked", flag)
+	}
+	if skipped := analysis.SkippedIntegrations(); !slices.Contains(skipped, verifierIntegration) {
+		t.Errorf("skipped %v, want %s", skipped, verifierIntegration)
+	}
+	want := unverified.Confidence * skippedIntegrationConfidence
+	if math.Abs(analysis.Confidence-want) > 1e-9 {
+		t.Errorf("Confidence = %.4f, want %.4f, one skipped integration below the unverified %.4f", analysis.Confidence, want, unverified.Confidence)
+	}
+}
@@ -0,0 +1,138 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// stalePRAge is how long an open PR can go without updates before it
+	// counts as stale
+	stalePRAge = 90 * 24 * time.Hour
+
+	// minStalePRThreshold is the fewest stale PRs that produce a warning on
+	// any repo; popular repos get proportionally more slack
+	minStalePRThreshold = 5
+
+	// starsPerStalePR scales the stale PR threshold with repo popularity
+	starsPerStalePR = 200
+)
+
+// GitHubPull is a pull request as returned by the pulls list endpoint
+type GitHubPull struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	State     string     `json:"state"`
+	HTMLURL   string     `json:"html_url"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	MergedAt  *time.Time `json:"merged_at"`
+	User      struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"user"`
+}
+
+// IsBot reports whether the PR was opened by a bot such as dependabot or renovate
+func (p GitHubPull) IsBot() bool {
+	return p.User.Type == "Bot" || strings.HasSuffix(p.User.Login, "[bot]")
+}
+
+// GetPullRequests fetches the first page of a repo's pull requests in the
+// given state ("open", "closed" or "all"), sorted by update time
+func (c *GitHubClient) GetPullRequests(ctx context.Context, owner, repo, state, direction string) (_ []GitHubPull, _ int, err error) {
+	ctx, span := c.startSpan(ctx, "github.pulls", "repos/:owner/:repo/pulls")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/pulls?state=%s&sort=updated&direction=%s&per_page=100", c.BaseURL, owner, repo, state, direction))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return decodeElements[GitHubPull](data)
+}
+
+// checkPullRequests measures open-PR staleness on the flagship repos
+func (a *Analyzer) checkPullRequests(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	var staleRepos, botRepos []string
+	failed := 0
+
+	for _, repo := range r.checkable() {
+		if repo.Archived {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+
+		// Oldest-updated first, so the stale ones are on the first page
+		pulls, bad, err := a.client.GetPullRequests(ctx, owner, name, "open", "asc")
+		r.decodeErrors += bad
+		if err != nil {
+			failed++
+			continue
+		}
+		r.openPulls[repo.FullName] = pulls
+
+		// Recently closed PRs show who actually gets changes merged
+		if closed, bad, err := a.client.GetPullRequests(ctx, owner, name, "closed", "desc"); err == nil {
+			r.decodeErrors += bad
+			r.closedPulls[repo.FullName] = closed
+		}
+
+		stale, staleBots := 0, 0
+		for _, pull := range pulls {
+			age := r.now.Sub(pull.UpdatedAt)
+			if days := int(r.now.Sub(pull.CreatedAt).Hours() / 24); days > metrics.OldestOpenPRDays {
+				metrics.OldestOpenPRDays = days
+			}
+			if age < stalePRAge {
+				continue
+			}
+			if pull.IsBot() {
+				staleBots++
+			} else {
+				stale++
+			}
+		}
+
+		metrics.StalePRs += stale
+		metrics.StaleBotPRs += staleBots
+
+		threshold := max(minStalePRThreshold, repo.StargazersCount/starsPerStalePR)
+		if stale > threshold {
+			staleRepos = append(staleRepos, fmt.Sprintf("%s (%d stale open PRs)", repo.Name, stale))
+		}
+		if staleBots >= minStalePRThreshold {
+			botRepos = append(botRepos, fmt.Sprintf("%s (%d unmerged bot PRs)", repo.Name, staleBots))
+		}
+	}
+
+	if failed > 0 {
+		r.log.fellBack("pulls", fmt.Errorf("failed to fetch pull requests for %d repo(s)", failed))
+	} else {
+		r.log.ok("pulls")
+	}
+
+	if len(staleRepos) > 0 {
+		r.addFinding(Finding{
+			Code:     "STALE_PULL_REQUESTS",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Pull requests going unreviewed for over %d days on flagship repos", int(stalePRAge.Hours()/24)),
+			Evidence: staleRepos,
+		})
+	}
+	if len(botRepos) > 0 {
+		r.addFinding(Finding{
+			Code:     "UNMERGED_BOT_PRS",
+			Severity: SeverityWarning,
+			Message:  "Dependency update PRs from bots are piling up unmerged",
+			Evidence: botRepos,
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPullIsBot(t *testing.T) {
+	for _, tt := range []struct {
+		login, kind string
+		want        bool
+	}{
+		{"dependabot[bot]", "Bot", true},
+		{"renovate[bot]", "User", true},
+		{"github-actions", "Bot", true},
+		{"octo", "User", false},
+		{"botany", "User", false},
+	} {
+		var pull GitHubPull
+		pull.User.Login, pull.User.Type = tt.login, tt.kind
+		if got := pull.IsBot(); got != tt.want {
+			t.Errorf("IsBot(%s, %s) = %t, want %t", tt.login, tt.kind, got, tt.want)
+		}
+	}
+}
+
+// openPulls is n open PRs by login numbered from first, all last updated
+// age ago and each opened a day before the last
+func openPulls(first, n int, login string, age time.Duration) []GitHubPull {
+	pulls := make([]GitHubPull, n)
+	for i := range pulls {
+		pulls[i] = GitHubPull{Number: first + i, State: "open", CreatedAt: fakeNow.Add(-age - days(i)), UpdatedAt: fakeNow.Add(-age)}
+		pulls[i].User.Login = login
+	}
+	return pulls
+}
+
+func TestStalePullRequests(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 100, UpdatedAt: fakeNow.Add(-days(2))},
+		// Popular enough for ten stale PRs to be tolerated
+		GitHubRepo{Name: "popular", Language: "Go", Size: 900, StargazersCount: 2000, UpdatedAt: fakeNow.Add(-days(2))},
+	))
+	pulls := map[string][]GitHubPull{
+		"tool": slices.Concat(
+			openPulls(1, 7, "alice", days(120)),
+			openPulls(20, 6, "dependabot[bot]", days(100)),
+			openPulls(40, 2, "bob", days(10)),
+		),
+		"popular": openPulls(1, 7, "carol", days(400)),
+	}
+	var closed atomic.Int32
+	for name, open := range pulls {
+		f.route("/repos/octo/"+name+"/pulls", func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("state") == "closed" {
+				closed.Add(1)
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			if query.Get("state") != "open" || query.Get("sort") != "updated" || query.Get("direction") != "asc" {
+				t.Errorf("pulls requested with %q, want open ones, least recently updated first", r.URL.RawQuery)
+			}
+			_ = json.NewEncoder(w).Encode(open)
+		})
+	}
+
+	analysis, err := newFakeAnalyzer(f, WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	metrics := analysis.Metrics
+	if metrics.StalePRs != 14 || metrics.StaleBotPRs != 6 {
+		t.Errorf("StalePRs = %d, StaleBotPRs = %d; want 14 and 6", metrics.StalePRs, metrics.StaleBotPRs)
+	}
+	// carol's seventh PR was opened 406 days ago
+	if metrics.OldestOpenPRDays != 406 {
+		t.Errorf("OldestOpenPRDays = %d, want 406", metrics.OldestOpenPRDays)
+	}
+	if closed.Load() != 2 {
+		t.Errorf("fetched closed PRs for %d repos, want both", closed.Load())
+	}
+
+	stale := finding(analysis, "STALE_PULL_REQUESTS")
+	if stale == nil || stale.Severity != SeverityWarning || !slices.Equal(stale.Evidence, []string{"tool (7 stale open PRs)"}) {
+		t.Errorf("STALE_PULL_REQUESTS = %+v, want tool alone", stale)
+	}
+	bots := finding(analysis, "UNMERGED_BOT_PRS")
+	if bots == nil || bots.Severity != SeverityWarning || !slices.Equal(bots.Evidence, []string{"tool (6 unmerged bot PRs)"}) {
+		t.Errorf("UNMERGED_BOT_PRS = %+v, want tool's dependabot PRs", bots)
+	}
+}
+
+func TestPullRequestsNeedDeepChecks(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, UpdatedAt: fakeNow.Add(-days(2))}))
+	var asked atomic.Int32
+	f.route("/repos/octo/tool/pulls", func(w http.ResponseWriter, r *http.Request) {
+		asked.Add(1)
+		_, _ = w.Write([]byte(`[]`))
+	})
+	if _, err := newFakeAnalyzer(f, WithDeepChecks(false)).Analyze("octo"); err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if asked.Load() != 0 {
+		t.Errorf("fetched pull requests %d times without deep checks", asked.Load())
+	}
+}
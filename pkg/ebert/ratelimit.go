@@ -0,0 +1,165 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxConcurrency bounds in-flight requests per client
+	defaultMaxConcurrency = 8
+
+	// maxSecondaryRetries is how many times a request is retried after a
+	// secondary rate limit before giving up
+	maxSecondaryRetries = 3
+
+	// defaultSecondaryWait is GitHub's advice when a secondary limit response
+	// carries no Retry-After header
+	defaultSecondaryWait = time.Minute
+)
+
+// searchInterval spaces search API calls to stay within its separate
+// budget of 30 requests per minute
+var searchInterval = 2 * time.Second
+
+// requestGate bounds concurrent requests. Its limit can be lowered at
+// runtime, after which it stays lowered for the life of the client.
+type requestGate struct {
+	slots     chan struct{}
+	throttled chan struct{}
+}
+
+func newRequestGate(limit int) *requestGate {
+	return &requestGate{
+		slots:     make(chan struct{}, limit),
+		throttled: make(chan struct{}),
+	}
+}
+
+func (g *requestGate) acquire(ctx context.Context) error {
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *requestGate) release() {
+	<-g.slots
+}
+
+// throttle drops the gate to a single in-flight request by permanently
+// occupying every other slot. Only the first call has any effect.
+func (g *requestGate) throttle() {
+	select {
+	case <-g.throttled:
+		return
+	default:
+		close(g.throttled)
+	}
+
+	go func() {
+		for i := 1; i < cap(g.slots); i++ {
+			g.slots <- struct{}{}
+		}
+	}()
+}
+
+// intervalLimiter lets one caller through per interval
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newIntervalLimiter(interval time.Duration) *intervalLimiter {
+	return &intervalLimiter{interval: interval}
+}
+
+// wait blocks until the caller's turn or until ctx is done
+func (l *intervalLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleepContext(ctx, time.Until(slot))
+}
+
+// secondaryRateLimit reports whether resp is a secondary ("abuse") rate
+// limit rather than primary quota exhaustion, and how long to back off
+func secondaryRateLimit(resp *http.Response, body []byte) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	if retryAfter == "" && !bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit")) {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	return defaultSecondaryWait, true
+}
+
+// ErrRateLimited matches an APIError GitHub answered with a rate limit,
+// primary or secondary, once the retries gave up
+var ErrRateLimited = errors.New("rate limited")
+
+// rateLimited reports whether resp refused the request for its rate
+// limit: a 429, or a 403 with the quota spent or a secondary limit
+func rateLimited(resp *http.Response, body []byte) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if _, ok := secondaryRateLimit(resp, body); ok {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// jitter spreads retries so throttled goroutines don't wake in lockstep
+func jitter() time.Duration {
+	return rand.N(time.Second)
+}
+
+// ErrWaitExceedsDeadline is returned instead of sleeping when a rate limit
+// wait would outlast the context's deadline
+var ErrWaitExceedsDeadline = errors.New("rate limit wait exceeds the deadline")
+
+// sleepContext waits for d or until ctx is done. A wait that would end
+// after ctx's deadline fails immediately rather than sleeping in vain, and
+// gives up the analysis ctx belongs to: it can't finish in time either.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(d).After(deadline) {
+		err := fmt.Errorf("%w: %s wait with %s left: %w", ErrWaitExceedsDeadline,
+			d.Round(time.Second), time.Until(deadline).Round(time.Second), context.DeadlineExceeded)
+		giveUp(ctx, err)
+		return err
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,135 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSecondaryRateLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		wait       time.Duration
+		ok         bool
+	}{
+		{"body only", http.StatusForbidden, "", `{"message":"You have exceeded a secondary rate limit."}`, defaultSecondaryWait, true},
+		{"Retry-After", http.StatusForbidden, "5", `{"message":"forbidden"}`, 5 * time.Second, true},
+		{"429 with Retry-After", http.StatusTooManyRequests, "0", "", 0, true},
+		{"date Retry-After", http.StatusForbidden, "Sat, 01 Jun 2024 12:01:00 GMT", "", defaultSecondaryWait, true},
+		{"primary limit", http.StatusForbidden, "", `{"message":"API rate limit exceeded"}`, 0, false},
+		{"not a refusal", http.StatusServiceUnavailable, "30", "secondary rate limit", 0, false},
+	} {
+		resp := &http.Response{StatusCode: tc.status, Header: http.Header{}}
+		if tc.retryAfter != "" {
+			resp.Header.Set("Retry-After", tc.retryAfter)
+		}
+		wait, ok := secondaryRateLimit(resp, []byte(tc.body))
+		if wait != tc.wait || ok != tc.ok {
+			t.Errorf("%s: secondaryRateLimit = %s, %t; want %s, %t", tc.name, wait, ok, tc.wait, tc.ok)
+		}
+	}
+}
+
+// throttled waits for g's throttle to take every slot but one
+func throttled(t *testing.T, g *requestGate) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); len(g.slots) < cap(g.slots)-1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the throttle holds %d of %d slots, want all but one", len(g.slots), cap(g.slots))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestGateThrottle(t *testing.T) {
+	g := newRequestGate(4)
+	g.throttle()
+	throttled(t, g)
+	// A second throttle mustn't take the last slot
+	g.throttle()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := g.acquire(context.Background()); err != nil {
+		t.Fatalf("the throttled gate refused its one request: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := g.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("a second request got %v, want it held until the deadline", err)
+	}
+	g.release()
+	if err := g.acquire(context.Background()); err != nil {
+		t.Errorf("the released slot wasn't reused: %v", err)
+	}
+}
+
+func TestSecondaryRateLimitRetries(t *testing.T) {
+	f := newFakeGitHub(t)
+	var served atomic.Int32
+	f.route("/users/octo", func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"message":"You have exceeded a secondary rate limit."}`, http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"login":"octo"}`))
+	})
+
+	client := newFakeAnalyzer(f).client
+	if _, err := client.get(context.Background(), f.URL+"/users/octo"); err != nil {
+		t.Fatalf("the retry after the secondary limit failed: %v", err)
+	}
+	if served.Load() != 2 {
+		t.Errorf("served %d requests, want the limited one and its retry", served.Load())
+	}
+	if hits := client.RequestStats().SecondaryRateLimitHits; hits != 1 {
+		t.Errorf("SecondaryRateLimitHits = %d, want 1", hits)
+	}
+	// The rest of the run goes one request at a time
+	throttled(t, client.shared().gate)
+}
+
+func TestSecondaryRateLimitPastDeadline(t *testing.T) {
+	f := newFakeGitHub(t)
+	f.route("/users/octo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, `{"message":"You have exceeded a secondary rate limit."}`, http.StatusForbidden)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := newFakeAnalyzer(f).client.get(ctx, f.URL+"/users/octo")
+	if !errors.Is(err, ErrWaitExceedsDeadline) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("get = %v, want ErrWaitExceedsDeadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s, want at once rather than sleeping", elapsed)
+	}
+	if ExitCode(err) != ExitRateLimited {
+		t.Errorf("exit code %d, want %d", ExitCode(err), ExitRateLimited)
+	}
+}
+
+func TestSleepContextGivesUp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	analysis, stop := withGiveUp(ctx)
+	defer stop()
+
+	if err := sleepContext(analysis, time.Hour); !errors.Is(err, ErrWaitExceedsDeadline) {
+		t.Fatalf("sleepContext = %v, want ErrWaitExceedsDeadline", err)
+	}
+	if cause := context.Cause(analysis); !errors.Is(cause, errAnalysisTimeout) || !errors.Is(cause, ErrWaitExceedsDeadline) {
+		t.Errorf("the analysis ended with %v, want it given up for the wait", cause)
+	}
+	if err := sleepContext(ctx, time.Millisecond); err != nil {
+		t.Errorf("a wait within the deadline failed: %v", err)
+	}
+}
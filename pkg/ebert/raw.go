@@ -0,0 +1,57 @@
+package ebert
+
+import "context"
+
+// RawData is the data an analysis fetched, exposed so downstream tools can
+// run their own checks without fetching it again
+type RawData struct {
+	User   GitHubUser    `json:"user"`
+	Repos  []GitHubRepo  `json:"repos"`
+	Events []GitHubEvent `json:"events"`
+	Gists  []GitHubGist  `json:"gists,omitempty"`
+
+	// OpenPulls and ClosedPulls are the deep-check PRs by repo full name
+	OpenPulls   map[string][]GitHubPull `json:"open_pulls,omitempty"`
+	ClosedPulls map[string][]GitHubPull `json:"closed_pulls,omitempty"`
+
+	// ETags are the user's and events' validators, so Reanalyze fetches
+	// them again only once they change
+	ETags map[string]string `json:"etags,omitempty"`
+}
+
+// DetailedAnalysis is an Analysis together with the raw data behind it
+type DetailedAnalysis struct {
+	*Analysis
+	Raw *RawData `json:"raw,omitempty"`
+}
+
+// AnalyzeDetailed is Analyze that also returns the fetched data. The raw
+// data holds every repo and event in memory, so prefer Analyze or
+// AnalyzeStream for very large accounts.
+func (a *Analyzer) AnalyzeDetailed(username string) (*DetailedAnalysis, error) {
+	return a.AnalyzeDetailedContext(context.Background(), username)
+}
+
+// AnalyzeDetailedContext is AnalyzeDetailed with a caller-supplied context
+func (a *Analyzer) AnalyzeDetailedContext(ctx context.Context, username string) (*DetailedAnalysis, error) {
+	raw := &RawData{}
+	analysis, err := a.analyze(ctx, username, nil, raw, nil)
+	if analysis == nil {
+		return nil, err
+	}
+	return &DetailedAnalysis{Analysis: analysis, Raw: raw}, err
+}
+
+// finishRaw copies the data gathered outside the fetch stages into raw
+func (r *analysisRun) finishRaw() {
+	if r.raw == nil {
+		return
+	}
+	r.raw.User = *r.user
+	if len(r.openPulls) > 0 {
+		r.raw.OpenPulls = r.openPulls
+	}
+	if len(r.closedPulls) > 0 {
+		r.raw.ClosedPulls = r.closedPulls
+	}
+}
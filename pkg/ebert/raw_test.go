@@ -0,0 +1,87 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeDetailedRaw(t *testing.T) {
+	account := newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 50, UpdatedAt: fakeNow.Add(-days(2))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 400, StargazersCount: 5, UpdatedAt: fakeNow.Add(-days(20))},
+		GitHubRepo{Name: "old", Language: "Go", Size: 100, UpdatedAt: fakeNow.Add(-days(900))},
+	)
+	account.Events = pushFeed("octo", 25, 1)
+	f := newFakeGitHub(t, account)
+	f.route("/users/octo/gists", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"id":"g1","html_url":"https://gist.github.com/octo/g1","files":{}}]`))
+	})
+	f.route("/repos/octo/tool/pulls", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(openPulls(1, 2, "alice", days(3)))
+	})
+
+	sent := f.requests.Load()
+	plain, err := newFakeAnalyzer(f, WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	plainRequests := f.requests.Load() - sent
+
+	sent = f.requests.Load()
+	detailed, err := newFakeAnalyzer(f, WithDeepChecks(true)).AnalyzeDetailed("octo")
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed: %v", err)
+	}
+	// The raw data is what the analysis fetched anyway
+	if n := f.requests.Load() - sent; n != plainRequests {
+		t.Errorf("AnalyzeDetailed sent %d requests, Analyze %d", n, plainRequests)
+	}
+
+	raw := detailed.Raw
+	if raw.User.Login != "octo" || raw.User.CreatedAt != account.User.CreatedAt {
+		t.Errorf("raw user = %+v, want octo as served", raw.User)
+	}
+	var repos []string
+	for _, repo := range raw.Repos {
+		repos = append(repos, repo.FullName)
+	}
+	if want := []string{"octo/tool", "octo/lib", "octo/old"}; !slices.Equal(repos, want) {
+		t.Errorf("raw repos = %q, want %q", repos, want)
+	}
+	if len(raw.Events) != len(account.Events) {
+		t.Fatalf("raw events = %d, want the %d served", len(raw.Events), len(account.Events))
+	}
+	for i, event := range raw.Events {
+		if event.ID != account.Events[i].ID || string(event.Payload) != string(account.Events[i].Payload) {
+			t.Errorf("raw event %d = %s %s, want %s as served", i, event.ID, event.Payload, account.Events[i].ID)
+		}
+	}
+	if len(raw.Gists) != 1 || raw.Gists[0].ID != "g1" || len(raw.OpenPulls["octo/tool"]) != 2 {
+		t.Errorf("raw gists = %+v and open pulls = %+v, want the deep-check payloads", raw.Gists, raw.OpenPulls)
+	}
+
+	// The analysis itself is unchanged, and only the detailed JSON has raw data
+	if detailed.OverallScore != plain.OverallScore || detailed.Metrics.Repos != plain.Metrics.Repos {
+		t.Errorf("detailed scored %v over %d repos, plain %v over %d", detailed.OverallScore, detailed.Metrics.Repos, plain.OverallScore, plain.Metrics.Repos)
+	}
+	for _, tt := range []struct {
+		name string
+		v    any
+		want bool
+	}{
+		{"Analysis", plain, false},
+		{"DetailedAnalysis", detailed, true},
+		{"DetailedAnalysis without raw data", &DetailedAnalysis{Analysis: plain}, false},
+	} {
+		data, err := json.Marshal(tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(data), `"raw":`); got != tt.want {
+			t.Errorf("%s JSON has a raw key: %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
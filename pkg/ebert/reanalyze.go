@@ -0,0 +1,217 @@
+package ebert
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// baselineOverlap widens the pushed-since cutoff, so pushes GitHub
+// reflected late or made while the baseline ran are fetched again
+const baselineOverlap = time.Hour
+
+// ETag keys recorded in RawData.ETags
+const (
+	etagUser   = "user"
+	etagEvents = "events"
+)
+
+// errNotModified answers a conditional request whose resource is unchanged
+var errNotModified = errors.New("not modified")
+
+// errCaughtUp stops the pushed-order listing at the first repo the
+// baseline already holds
+var errCaughtUp = errors.New("caught up with baseline")
+
+// baseline is the earlier detailed analysis a reanalysis starts from
+type baseline struct {
+	at  time.Time
+	raw *RawData
+}
+
+// newBaseline checks prev can seed a reanalysis with the analyzer's
+// options, saying why not when it can't
+func (a *Analyzer) newBaseline(prev *DetailedAnalysis) (*baseline, error) {
+	switch {
+	case prev.Raw == nil:
+		return nil, errors.New("baseline has no raw data")
+	case prev.Timestamp.IsZero():
+		return nil, errors.New("baseline has no timestamp, e.g. written with --stable")
+	case prev.Metrics.ReposSampled:
+		return nil, errors.New("baseline sampled its repos")
+	case prev.Meta != nil && prev.Meta.Options.RepoList != a.opts.RepoList:
+		return nil, errors.New("baseline listed repos with other options")
+	}
+	return &baseline{at: prev.Timestamp, raw: prev.Raw}, nil
+}
+
+// Reanalyze analyzes prev's account again, starting from the raw data
+// prev holds: the user and events are fetched only if their ETags
+// changed, which costs no quota otherwise, and only repos pushed since
+// prev are listed and merged over its repos. Repos not pushed since are
+// carried over with their archived flags, counts and topics refreshed from
+// a GraphQL listing of just those fields. The account is relisted in full
+// when its repo count doesn't add up, such as after a deletion, and
+// without a token, as the refresh needs GraphQL. Every
+// later stage runs as in AnalyzeDetailed, and so does the whole analysis
+// when prev can't be used; DataSources says which happened.
+func (a *Analyzer) Reanalyze(prev *DetailedAnalysis) (*DetailedAnalysis, error) {
+	return a.ReanalyzeContext(context.Background(), prev)
+}
+
+// ReanalyzeContext is Reanalyze with a caller-supplied context
+func (a *Analyzer) ReanalyzeContext(ctx context.Context, prev *DetailedAnalysis) (*DetailedAnalysis, error) {
+	if prev == nil || prev.Analysis == nil {
+		return nil, errors.New("no baseline analysis to start from")
+	}
+	base, reason := a.newBaseline(prev)
+
+	raw := &RawData{}
+	analysis, err := a.analyze(ctx, prev.User.Login, nil, raw, base)
+	if analysis == nil {
+		return nil, err
+	}
+	if reason != nil {
+		analysis.DataSources = append(analysis.DataSources, DataSource{Name: "baseline", Status: SourceFallback,
+			Detail: reason.Error() + "; analyzed in full"})
+	}
+	return &DetailedAnalysis{Analysis: analysis, Raw: raw}, err
+}
+
+// setETag records an ETag for the next reanalysis
+func (r *analysisRun) setETag(key, etag string) {
+	if r.raw == nil || etag == "" {
+		return
+	}
+	if r.raw.ETags == nil {
+		r.raw.ETags = map[string]string{}
+	}
+	r.raw.ETags[key] = etag
+}
+
+// baselineETag is the baseline's ETag for key, or "" without a baseline
+func (r *analysisRun) baselineETag(key string) string {
+	if r.base == nil {
+		return ""
+	}
+	return r.base.raw.ETags[key]
+}
+
+// fetchUser fetches the account, or takes the baseline's when its ETag
+// is unchanged
+func (a *Analyzer) fetchUser(ctx context.Context, username string, base *baseline) (_ *GitHubUser, etag string, unchanged bool, err error) {
+	if base != nil {
+		etag = base.raw.ETags[etagUser]
+	}
+	user, fresh, err := a.client.getUser(ctx, username, etag)
+	if errors.Is(err, errNotModified) {
+		user := base.raw.User
+		return &user, etag, true, nil
+	}
+	return user, fresh, false, err
+}
+
+// reposSince lists the repos pushed since the baseline over GraphQL, as a
+// full listing with a token does, and merges them over the baseline's in
+// its order, refreshing the state of the rest; new repos go first. It
+// reports false, to list in full, when the repo count doesn't add up or
+// the state can't be refreshed.
+func (a *Analyzer) reposSince(ctx context.Context, r *analysisRun) ([]GitHubRepo, int, bool) {
+	if !a.client.authenticated() || !a.opts.RepoList.graphQLListable() {
+		r.log.fellBack("repos_since_baseline", errors.New("refreshing repos not pushed since needs the GraphQL API; listing in full"))
+		return nil, 0, false
+	}
+
+	cutoff := r.base.at.Add(-baselineOverlap)
+	list := a.opts.RepoList
+	list.Sort, list.Direction = "pushed", "desc"
+
+	var fresh []GitHubRepo
+	err := a.client.EachRepoPageGraphQL(ctx, r.username, func(_ int, repos []GitHubRepo) error {
+		for _, repo := range repos {
+			if repo.PushedAt.Before(cutoff) {
+				return errCaughtUp
+			}
+			fresh = append(fresh, repo)
+		}
+		return nil
+	}, list)
+	if err != nil && !errors.Is(err, errCaughtUp) {
+		r.log.fellBack("repos_since_baseline", fmt.Errorf("failed to list repos pushed since the baseline: %w", err))
+		return nil, 0, false
+	}
+
+	index := make(map[string]int, len(r.base.raw.Repos))
+	merged := slices.Clone(r.base.raw.Repos)
+	for i, repo := range merged {
+		index[strings.ToLower(repo.FullName)] = i
+	}
+	var added []GitHubRepo
+	pushed := map[int]bool{}
+	for _, repo := range fresh {
+		if i, ok := index[strings.ToLower(repo.FullName)]; ok {
+			merged[i], pushed[i] = repo, true
+			continue
+		}
+		added = append(added, repo)
+	}
+	if r.user.PublicRepos != r.base.raw.User.PublicRepos+len(added) {
+		r.log.fellBack("repos_since_baseline", fmt.Errorf("%d public repos, baseline had %d and %d are new; listing in full",
+			r.user.PublicRepos, r.base.raw.User.PublicRepos, len(added)))
+		return nil, 0, false
+	}
+
+	// Archiving, stars and topics don't move pushed_at
+	if len(pushed) < len(merged) {
+		states, err := a.client.repoStates(ctx, r.username, a.opts.RepoList)
+		if err != nil {
+			r.log.fellBack("repos_since_baseline", fmt.Errorf("failed to refresh repos not pushed since the baseline: %w; listing in full", err))
+			return nil, 0, false
+		}
+		for i := range merged {
+			if pushed[i] {
+				continue
+			}
+			state, ok := states[strings.ToLower(merged[i].FullName)]
+			if !ok {
+				r.log.fellBack("repos_since_baseline", fmt.Errorf("%s is no longer listed; listing in full", merged[i].FullName))
+				return nil, 0, false
+			}
+			state.refresh(&merged[i])
+		}
+	}
+	merged = append(added, merged...)
+	sortRepoListing(merged, a.opts.RepoList)
+	return merged, len(fresh), true
+}
+
+// sortRepoListing puts repos in the order the listing options return
+// them, keeping the order of ties
+func sortRepoListing(repos []GitHubRepo, list RepoListOptions) {
+	var key func(GitHubRepo) time.Time
+	switch list.Sort {
+	case "updated":
+		key = func(repo GitHubRepo) time.Time { return repo.UpdatedAt }
+	case "pushed":
+		key = func(repo GitHubRepo) time.Time { return repo.PushedAt }
+	case "created":
+		key = func(repo GitHubRepo) time.Time { return repo.CreatedAt }
+	}
+	slices.SortStableFunc(repos, func(x, y GitHubRepo) int {
+		order := 0
+		if key != nil {
+			// Dates list newest first by default
+			order = key(y).Compare(key(x))
+		} else {
+			order = cmp.Compare(strings.ToLower(x.FullName), strings.ToLower(y.FullName))
+		}
+		if list.Direction == "asc" && key != nil || list.Direction == "desc" && key == nil {
+			order = -order
+		}
+		return order
+	})
+}
@@ -0,0 +1,104 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReanalyzeMatchesFullAnalysis(t *testing.T) {
+	unspaced(t)
+	account := archivePersona("keeper", false, days(40), false, 0)
+	f := newFakeGitHub(t, account)
+	f.route("/graphql", f.serveGraphQLRepos)
+	a := newFakeAnalyzerToken(f, "ghp_test")
+
+	base, err := a.AnalyzeDetailed("keeper")
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+
+	// Since the baseline the flagship was archived and another gained
+	// stars and topics, none of which moves pushed_at, and one repo was
+	// pushed to
+	f.mu.Lock()
+	account.Repos[0].Archived, account.Repos[0].UpdatedAt = true, fakeNow.Add(-days(1))
+	account.Repos[1].StargazersCount, account.Repos[1].Topics = 2400, []string{"cli"}
+	account.Repos[3].PushedAt, account.Repos[3].UpdatedAt = fakeNow.Add(-baselineOverlap/2), fakeNow.Add(-baselineOverlap/2)
+	f.mu.Unlock()
+
+	incremental, err := a.Reanalyze(base)
+	if err != nil {
+		t.Fatalf("Reanalyze: %v", err)
+	}
+	full, err := a.AnalyzeDetailed("keeper")
+	if err != nil {
+		t.Fatalf("full analysis: %v", err)
+	}
+
+	var repos DataSource
+	for _, source := range incremental.DataSources {
+		if source.Name == "repos" {
+			repos = source
+		}
+	}
+	if !strings.Contains(repos.Detail, "via baseline, 1 pushed since") {
+		t.Fatalf("repos source = %+v, want the incremental listing", repos)
+	}
+
+	if incremental.OverallScore != full.OverallScore || incremental.RiskLevel != full.RiskLevel {
+		t.Errorf("incremental score %.2f (%s), full %.2f (%s)", incremental.OverallScore, incremental.RiskLevel, full.OverallScore, full.RiskLevel)
+	}
+	for name, pair := range map[string][2]any{
+		"scores":  {incremental.Scores, full.Scores},
+		"metrics": {incremental.Metrics, full.Metrics},
+		"repos":   {incremental.Raw.Repos, full.Raw.Repos},
+	} {
+		got, _ := json.Marshal(pair[0])
+		want, _ := json.Marshal(pair[1])
+		if string(got) != string(want) {
+			t.Errorf("incremental %s differ from the full analysis:\n got %s\nwant %s", name, got, want)
+		}
+	}
+	codes := func(analysis *Analysis) []string {
+		var codes []string
+		for _, finding := range analysis.Findings {
+			codes = append(codes, fmt.Sprintf("%s %v", finding.Code, finding.Evidence))
+		}
+		slices.Sort(codes)
+		return codes
+	}
+	if got, want := codes(incremental.Analysis), codes(full.Analysis); !slices.Equal(got, want) {
+		t.Errorf("incremental findings differ:\n got %v\nwant %v", got, want)
+	}
+
+	rule, err := ParseAlertRule(string(AlertFlagshipArchived))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts := EvaluateAlerts(base.Analysis, incremental.Analysis, []AlertRule{rule}); len(alerts) != 1 {
+		t.Errorf("alerts = %v, want the flagship archived since the baseline", alerts)
+	}
+}
+
+func TestReanalyzeWithoutToken(t *testing.T) {
+	f := newFakeGitHub(t, archivePersona("anon", false, days(40), false, 0))
+	a := newFakeAnalyzer(f)
+
+	base, err := a.AnalyzeDetailed("anon")
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	incremental, err := a.Reanalyze(base)
+	if err != nil {
+		t.Fatalf("Reanalyze: %v", err)
+	}
+	for _, source := range incremental.DataSources {
+		if source.Name == "repos_since_baseline" && source.Status == SourceFallback {
+			return
+		}
+	}
+	t.Errorf("sources %+v, want the repos listed in full without a token", incremental.DataSources)
+}
@@ -0,0 +1,77 @@
+// Package redisebert stores ebert's analysis results in Redis, so a cache
+// survives restarts and is shared across replicas, while keeping go-redis
+// out of builds that cache in memory or not at all.
+package redisebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache is an ebert.ResultCache keeping JSON-encoded analyses in Redis,
+// expired by Redis itself
+type Cache struct {
+	client redis.UniversalClient
+}
+
+var _ ebert.ResultCache = (*Cache)(nil)
+
+// New caches in the Redis server at addr, e.g. "localhost:6379"
+func New(addr string) *Cache {
+	return NewWithClient(redis.NewClient(&redis.Options{Addr: addr}))
+}
+
+// NewWithClient caches through an already configured client, for
+// authentication, TLS, Sentinel or Cluster setups
+func NewWithClient(client redis.UniversalClient) *Cache {
+	return &Cache{client: client}
+}
+
+// Ping checks the server is reachable, so a misconfigured address fails
+// at startup rather than as a cache miss on every analysis
+func (c *Cache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to reach redis: %w", err)
+	}
+	return nil
+}
+
+// Get implements ebert.ResultCache
+func (c *Cache) Get(ctx context.Context, key string) (*ebert.Analysis, bool, error) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cached analysis: %w", err)
+	}
+
+	var analysis ebert.Analysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached analysis: %w", err)
+	}
+	return &analysis, true, nil
+}
+
+// Set implements ebert.ResultCache
+func (c *Cache) Set(ctx context.Context, key string, a *ebert.Analysis, ttl time.Duration) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis: %w", err)
+	}
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache analysis: %w", err)
+	}
+	return nil
+}
+
+// Close closes the client
+func (c *Cache) Close() error {
+	return c.client.Close()
+}
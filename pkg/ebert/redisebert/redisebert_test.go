@@ -0,0 +1,148 @@
+package redisebert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	cache := New(server.Addr())
+	t.Cleanup(func() { _ = cache.Close() })
+	if err := cache.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return cache, server
+}
+
+// testAPI answers an unauthenticated analysis of octo, counting requests
+func testAPI(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var requests atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/octo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"octo","public_repos":1,"followers":40,"type":"User","created_at":"2016-03-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"tool","full_name":"octo/tool","language":"Go","size":2400,"stargazers_count":31,
+			"default_branch":"main","owner":{"login":"octo","type":"User"},
+			"created_at":"2018-01-01T00:00:00Z","updated_at":"2024-05-28T00:00:00Z","pushed_at":"2024-05-28T00:00:00Z"}]`)
+	})
+	mux.HandleFunc("/users/octo/events/public", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(api.Close)
+	return api, &requests
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "k", &ebert.Analysis{OverallScore: 42}, time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := server.TTL("k"); got != time.Hour {
+		t.Errorf("TTL = %s, want 1h", got)
+	}
+
+	cached, ok, err := cache.Get(ctx, "k")
+	if err != nil || !ok || cached.OverallScore != 42 {
+		t.Fatalf("Get = %+v, %v, %v; want the stored analysis", cached, ok, err)
+	}
+
+	server.FastForward(time.Hour)
+	if _, ok, err := cache.Get(ctx, "k"); ok || err != nil {
+		t.Errorf("Get after the TTL = %v, %v; want a miss", ok, err)
+	}
+	if _, ok, err := cache.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get of a missing key = %v, %v; want a miss", ok, err)
+	}
+}
+
+func TestCacheUndecodable(t *testing.T) {
+	cache, server := newTestCache(t)
+	if err := server.Set("k", `{"overall_score":"high"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := cache.Get(context.Background(), "k"); ok || err == nil {
+		t.Errorf("Get = %v, %v; want a decode error", ok, err)
+	}
+}
+
+func TestCacheSchemaInvalidation(t *testing.T) {
+	cache, server := newTestCache(t)
+	api, requests := testAPI(t)
+
+	analyzer, err := ebert.New("",
+		ebert.WithBaseURL(api.URL),
+		ebert.WithClock(func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }),
+		ebert.WithRequestRate(1000, 10000),
+		ebert.WithResultCache(cache, 10*time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ebert.ResultCacheKey("octo", analyzer.Options())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, fmt.Sprintf("ebert:v%d:", ebert.SchemaVersion)) {
+		t.Fatalf("key %q should carry the schema version", key)
+	}
+
+	// An entry left by a release with another schema is never read
+	stale := strings.Replace(key, fmt.Sprintf(":v%d:", ebert.SchemaVersion), fmt.Sprintf(":v%d:", ebert.SchemaVersion-1), 1)
+	if err := cache.Set(context.Background(), stale, &ebert.Analysis{OverallScore: 99}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := analyzer.Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if first.OverallScore == 99 {
+		t.Fatal("the analysis was served from the stale schema's entry")
+	}
+	if !server.Exists(key) {
+		t.Fatalf("the analysis wasn't stored under %s; keys %v", key, server.Keys())
+	}
+	if got := server.TTL(key); got != 10*time.Minute {
+		t.Errorf("TTL = %s, want the configured 10m", got)
+	}
+
+	sent := requests.Load()
+	second, err := analyzer.Analyze("octo")
+	if err != nil {
+		t.Fatalf("cached Analyze: %v", err)
+	}
+	if requests.Load() != sent {
+		t.Errorf("the cached analysis sent %d requests, want none", requests.Load()-sent)
+	}
+	if second.OverallScore != first.OverallScore {
+		t.Errorf("cached score = %.1f, want %.1f", second.OverallScore, first.OverallScore)
+	}
+
+	// Once the entry expires the account is analyzed afresh
+	server.FastForward(10 * time.Minute)
+	if _, err := analyzer.Analyze("octo"); err != nil {
+		t.Fatalf("Analyze after expiry: %v", err)
+	}
+	if requests.Load() == sent {
+		t.Error("an expired entry should be analyzed afresh")
+	}
+}
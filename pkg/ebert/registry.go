@@ -0,0 +1,121 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Public package registries, checked without credentials
+var (
+	npmRegistryURL  = "https://registry.npmjs.org"
+	pypiRegistryURL = "https://pypi.org/pypi"
+)
+
+// Ecosystem names a public package registry
+type Ecosystem string
+
+const (
+	EcosystemNPM  Ecosystem = "npm"
+	EcosystemPyPI Ecosystem = "pypi"
+
+	// EcosystemGo modules are counted but never looked up, since the Go
+	// module proxy serves any public repo
+	EcosystemGo Ecosystem = "go"
+)
+
+// repoEcosystem guesses which registry a repo would publish to from its
+// primary language
+func repoEcosystem(repo GitHubRepo) (Ecosystem, bool) {
+	switch repo.Language {
+	case "JavaScript", "TypeScript":
+		return EcosystemNPM, true
+	case "Python":
+		return EcosystemPyPI, true
+	default:
+		return "", false
+	}
+}
+
+// packageURL is the registry metadata URL for a package
+func packageURL(ecosystem Ecosystem, name string) string {
+	name = strings.ToLower(name)
+	if ecosystem == EcosystemPyPI {
+		return fmt.Sprintf("%s/%s/json", pypiRegistryURL, url.PathEscape(name))
+	}
+	return fmt.Sprintf("%s/%s", npmRegistryURL, url.PathEscape(name))
+}
+
+// packageExists reports whether name is published on the ecosystem's registry
+func (c *GitHubClient) packageExists(ctx context.Context, ecosystem Ecosystem, name string) (bool, error) {
+	status, err := c.headExternal(ctx, packageURL(ecosystem, name))
+	if err != nil {
+		return false, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s registry: HTTP %d", ecosystem, status)
+	}
+}
+
+// maxManifestBytes caps a published npm manifest
+const maxManifestBytes = 1 << 20
+
+// npmManifest is the part of a package.json the install-script check reads
+type npmManifest struct {
+	Name    string            `json:"name"`
+	Private bool              `json:"private"`
+	Scripts map[string]string `json:"scripts"`
+
+	// Workspaces are the package directories of a monorepo root
+	Workspaces npmWorkspaces `json:"workspaces"`
+
+	Maintainers []struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"maintainers"`
+
+	// NPMUser is the npm account that published the version
+	NPMUser struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"_npmUser"`
+
+	// Version, the entry points, the source repository and the tarball
+	// of a published version, for the artifact check
+	Version    string        `json:"version"`
+	Main       string        `json:"main"`
+	Bin        npmBin        `json:"bin"`
+	Repository npmRepository `json:"repository"`
+	Dist       struct {
+		Tarball string `json:"tarball"`
+	} `json:"dist"`
+}
+
+// getNPMManifest fetches the manifest of the latest published version of
+// name, reporting false if the package isn't published
+func (c *GitHubClient) getNPMManifest(ctx context.Context, name string) (*npmManifest, bool, error) {
+	exists, err := c.packageExists(ctx, EcosystemNPM, name)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	data, err := c.getExternal(ctx, packageURL(EcosystemNPM, name)+"/latest", maxManifestBytes)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var manifest npmManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, false, fmt.Errorf("failed to decode npm manifest for %s: %w", name, err)
+	}
+	return &manifest, true, nil
+}
@@ -0,0 +1,161 @@
+package ebert
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// maxTopRemediations is how many remediations Analysis.TopRemediations lists
+const maxTopRemediations = 3
+
+// Remediation is a fix for a finding and how much it would lower the
+// overall score
+type Remediation struct {
+	Code        string  `json:"code"`
+	Action      string  `json:"action"`
+	ScoreImpact float64 `json:"score_impact"`
+}
+
+// remedy describes how to resolve a finding code. resolve, when set,
+// edits a copy of the scoring input into the state where the finding no
+// longer applies, so the score can be recomputed; codes without it don't
+// feed the score.
+type remedy struct {
+	action  string
+	resolve func(in *scoringInput)
+}
+
+var remedies = map[string]remedy{
+	"LOW_FOLLOWERS": {
+		action: "Build a visible track record others follow, e.g. by contributing to established projects",
+		resolve: func(in *scoringInput) {
+			in.metrics.Followers = max(in.metrics.Followers, 10)
+		},
+	},
+	"NO_CONTACT_INFO": {
+		action: "Publish a contact email, website or company affiliation on the profile",
+		resolve: func(in *scoringInput) {
+			in.user.Email = "resolved"
+		},
+	},
+	"LOW_ACTIVITY": {
+		action: "Commit regularly to the account's public repos",
+		resolve: func(in *scoringInput) {
+			in.metrics.RecentCommits = max(in.metrics.RecentCommits, 10)
+		},
+	},
+	"EMAIL_PRIVACY_LEAK": {
+		action: "Commit with the GitHub noreply address, and block pushes that expose a personal email",
+		resolve: func(in *scoringInput) {
+			in.metrics.EmailConsistency = ""
+		},
+	},
+	"REGISTRY_EMAIL_UNMATCHED": {
+		action: "Publish under an email the profile or commits also use, or confirm the registry account is yours",
+		resolve: func(in *scoringInput) {
+			in.metrics.EmailConsistency = ""
+		},
+	},
+	"NO_RECENT_UPDATES": {
+		action: "Push an update to at least one maintained repository",
+		resolve: func(in *scoringInput) {
+			in.metrics.RecentlyUpdated = max(in.metrics.RecentlyUpdated, 1)
+			in.original.recentlyUpdated = max(in.original.recentlyUpdated, 1)
+		},
+	},
+	"HIGH_ARCHIVED_RATIO": {
+		action: "Hand archived projects to a co-maintainer or keep the flagship repos active",
+		resolve: func(in *scoringInput) {
+			in.metrics.Archived, in.metrics.RecentlyArchived = 0, 0
+			in.original.archived, in.original.recentlyArchived = 0, 0
+		},
+	},
+	"NO_SECURITY_POLICY": {
+		action: "Add a SECURITY.md with a private disclosure contact",
+		resolve: func(in *scoringInput) {
+			in.metrics.ReposWithSecurityPolicy = max(in.metrics.ReposWithSecurityPolicy, 1)
+			in.metrics.HasDisclosureContact = true
+		},
+	},
+	"DORMANT_POPULAR":       {action: "Archive the dormant popular repos or hand them to an active co-maintainer"},
+	"PADDED_WITH_TUTORIALS": {action: "Archive or make private the tutorial clones and README-only repos"},
+	"VENDORED_CODE":         {action: "Remove committed dependencies and build output, installing them from the package manager instead"},
+	"NO_LICENSE":            {action: "Add a LICENSE file to each original project"},
+	"SECURITY_POLICY_NO_CONTACT": {
+		action: "Name a private disclosure channel, such as an email or GitHub private reporting, in the security policy",
+		resolve: func(in *scoringInput) {
+			in.metrics.HasDisclosureContact = true
+		},
+	},
+	"DIRECT_PUSHES": {
+		action: "Require pull request reviews and add CODEOWNERS on the flagship repos",
+		resolve: func(in *scoringInput) {
+			in.metrics.ReposWithCodeowners = max(in.metrics.ReposWithCodeowners, 1)
+			workflows := slices.Clone(in.metrics.ReviewWorkflows)
+			for i := range workflows {
+				if workflows[i].Estimate == ReviewUnlikely {
+					workflows[i].Estimate = ReviewLikely
+				}
+			}
+			in.metrics.ReviewWorkflows = workflows
+		},
+	},
+	"FREQUENT_FORCE_PUSHES":       {action: "Protect default branches against force pushes"},
+	"HISTORY_REWRITE":             {action: "Protect default branches and release tags against rewrites"},
+	"NO_ISSUE_TRACKER":            {action: "Enable issues or discussions so users can report problems"},
+	"STALE_PULL_REQUESTS":         {action: "Review or close long-open pull requests"},
+	"UNMERGED_BOT_PRS":            {action: "Merge or close the pending dependency update pull requests"},
+	"STALE_LOCKFILES":             {action: "Refresh lockfiles and enable automated dependency updates"},
+	"SECRET_IN_GIST":              {action: "Revoke the exposed credential and delete the gist"},
+	"POSSIBLE_SECRET_GIST":        {action: "Check the gist for live credentials and revoke any found"},
+	"SUSPICIOUS_INSTALL_SCRIPT":   {action: "Remove the install script or document why it must fetch or execute code"},
+	"CRATE_OWNERSHIP_MISMATCH":    {action: "Add yourself as an owner of the crate on crates.io or fix its repository link"},
+	"PUBLISHED_MANIFEST_DIVERGES": {action: "Publish packages from the repository so the registry manifest matches it"},
+	"ARTIFACT_REPO_MISMATCH":      {action: "Publish from a clean checkout of the release tag, or commit the files the package ships"},
+	"DEP_CONFUSION_CANDIDATE":     {action: "Reserve the internal name on the public registry or move to a scoped name"},
+	"CONFUSABLE_NAME":             {action: "Rename the repo so it can't be mistaken for the popular project"},
+	"LOOKALIKE_NAME":              {action: "Rename the repo so it can't be mistaken for the popular project"},
+	"STALE_IMAGES":                {action: "Rebuild and push the images on a current base image"},
+	"ACTION_RELEASE_HYGIENE":      {action: "Cut regular releases and move a major version tag such as v1 with them"},
+	"UNPINNED_ACTION_IMAGE":       {action: "Pin the action's container image by sha256 digest"},
+}
+
+// remediate attaches a remediation and score impact to each finding with a
+// known remedy, re-running the scoring with that factor resolved, and
+// returns the highest-impact remedies
+func (a *Analyzer) remediate(findings []Finding, in scoringInput, overall float64) []Remediation {
+	var top []Remediation
+	for i := range findings {
+		fix, ok := remedies[findings[i].Code]
+		if !ok {
+			continue
+		}
+		findings[i].Remediation = fix.action
+
+		if fix.resolve == nil {
+			continue
+		}
+		whatIf := in
+		fix.resolve(&whatIf)
+		_, resolved := a.score(whatIf)
+
+		impact := math.Round((overall-resolved)*10) / 10
+		if impact <= 0 {
+			continue
+		}
+		findings[i].ScoreImpact = impact
+		top = append(top, Remediation{Code: findings[i].Code, Action: fix.action, ScoreImpact: impact})
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].ScoreImpact != top[j].ScoreImpact {
+			return top[i].ScoreImpact > top[j].ScoreImpact
+		}
+		return top[i].Code < top[j].Code
+	})
+	if len(top) > maxTopRemediations {
+		top = top[:maxTopRemediations]
+	}
+	return top
+}
@@ -0,0 +1,119 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRemediate(t *testing.T) {
+	a := NewAnalyzer("")
+	in := scoringInput{
+		user:    GitHubUser{Login: "octo", Type: "User", CreatedAt: fakeNow.Add(-days(3000))},
+		metrics: Metrics{AccountAgeDays: 3000, Repos: 4, Followers: 1},
+		cov:     coverage{repos: true, events: true},
+	}
+	_, overall := a.score(in)
+
+	findings := []Finding{{Code: "LOW_FOLLOWERS"}, {Code: "NO_SECURITY_POLICY"}, {Code: "NO_LICENSE"}, {Code: "NOT_A_CODE"}}
+	top := a.remediate(findings, in, overall)
+
+	for _, f := range findings[:2] {
+		fix := remedies[f.Code]
+		whatIf := in
+		fix.resolve(&whatIf)
+		_, resolved := a.score(whatIf)
+		if f.Remediation != fix.action || f.ScoreImpact <= 0 || f.ScoreImpact != math.Round((overall-resolved)*10)/10 {
+			t.Errorf("%s: remediation %q, impact %v; want %q and %.1f", f.Code, f.Remediation, f.ScoreImpact, fix.action, overall-resolved)
+		}
+	}
+	// A remedy that doesn't feed the score gets its action but no impact
+	if f := findings[2]; f.Remediation == "" || f.ScoreImpact != 0 {
+		t.Errorf("NO_LICENSE: remediation %q, impact %v", f.Remediation, f.ScoreImpact)
+	}
+	if f := findings[3]; f.Remediation != "" || f.ScoreImpact != 0 {
+		t.Errorf("an unknown code got remediation %q, impact %v", f.Remediation, f.ScoreImpact)
+	}
+
+	var codes []string
+	for _, item := range top {
+		codes = append(codes, item.Code)
+	}
+	if len(top) != 2 || !slices.Contains(codes, "LOW_FOLLOWERS") || !slices.Contains(codes, "NO_SECURITY_POLICY") || top[0].ScoreImpact < top[1].ScoreImpact {
+		t.Errorf("TopRemediations = %+v, want the two scored fixes, highest impact first", top)
+	}
+	// The what-if runs on a copy
+	if in.metrics.Followers != 1 || in.metrics.ReposWithSecurityPolicy != 0 {
+		t.Errorf("remediate changed the input metrics to %+v", in.metrics)
+	}
+}
+
+func TestRemediateTopThree(t *testing.T) {
+	a := NewAnalyzer("")
+	in := scoringInput{
+		user:    GitHubUser{Login: "octo", Type: "User", CreatedAt: fakeNow.Add(-days(3000))},
+		metrics: Metrics{AccountAgeDays: 3000, Repos: 4, Followers: 1, ReposWithSecurityPolicy: 1},
+		cov:     coverage{repos: true, events: true},
+	}
+	_, overall := a.score(in)
+	var findings []Finding
+	for _, code := range []string{"LOW_FOLLOWERS", "NO_CONTACT_INFO", "LOW_ACTIVITY", "NO_RECENT_UPDATES", "SECURITY_POLICY_NO_CONTACT"} {
+		findings = append(findings, Finding{Code: code})
+	}
+	top := a.remediate(findings, in, overall)
+	if len(top) != maxTopRemediations {
+		t.Fatalf("TopRemediations = %+v, want %d", top, maxTopRemediations)
+	}
+	// Highest impact first, ties by code, and nothing left out outranks them
+	for i := 1; i < len(top); i++ {
+		if top[i-1].ScoreImpact < top[i].ScoreImpact || top[i-1].ScoreImpact == top[i].ScoreImpact && top[i-1].Code > top[i].Code {
+			t.Errorf("TopRemediations out of order: %+v", top)
+		}
+	}
+	for _, f := range findings {
+		if f.ScoreImpact > top[len(top)-1].ScoreImpact && !slices.ContainsFunc(top, func(item Remediation) bool { return item.Code == f.Code }) {
+			t.Errorf("%s (impact %v) was left out of %+v", f.Code, f.ScoreImpact, top)
+		}
+	}
+}
+
+func TestAnalysisTopRemediations(t *testing.T) {
+	account := newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))})
+	account.User.Followers = 1
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(analysis.TopRemediations) == 0 {
+		t.Fatal("no remediations for an account with few followers and no security policy")
+	}
+	var report strings.Builder
+	FprintAnalysis(&report, analysis)
+	for _, item := range analysis.TopRemediations {
+		// Each is the remedy of a finding the analysis reported
+		flag := finding(analysis, item.Code)
+		if flag == nil || flag.Remediation != item.Action || flag.ScoreImpact != item.ScoreImpact {
+			t.Errorf("remediation %+v doesn't match finding %+v", item, flag)
+		}
+		if want := fmt.Sprintf("• %s (-%.1f) [%s]", item.Action, item.ScoreImpact, item.Code); !strings.Contains(report.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, report.String())
+		}
+	}
+	if !strings.Contains(report.String(), "🔧 TOP REMEDIATIONS") {
+		t.Errorf("report has no remediations section:\n%s", report.String())
+	}
+
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		TopRemediations []Remediation `json:"top_remediations"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || !slices.Equal(decoded.TopRemediations, analysis.TopRemediations) {
+		t.Errorf("JSON top_remediations = %+v, want %+v (%v)", decoded.TopRemediations, analysis.TopRemediations, err)
+	}
+}
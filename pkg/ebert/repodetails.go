@@ -0,0 +1,71 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// mercyPreview is the media type that returned repo topics before they
+// were generally available; GitHub Enterprise Server still wants it
+const mercyPreview = "application/vnd.github.mercy-preview+json"
+
+// RepoRef is the abbreviated repo GitHub nests as a fork's parent
+type RepoRef struct {
+	FullName        string `json:"full_name"`
+	HTMLURL         string `json:"html_url"`
+	StargazersCount int    `json:"stargazers_count"`
+	Fork            bool   `json:"fork"`
+}
+
+// GetRepo fetches the full repo object, which carries what the listing
+// leaves out: a fork's parent, and watcher and network counts
+func (c *GitHubClient) GetRepo(ctx context.Context, owner, repo string) (_ *GitHubRepo, err error) {
+	ctx, span := c.startSpan(ctx, "github.repo", "repos/:owner/:repo")
+	defer func() { endSpan(span, err) }()
+
+	data, err := c.getAccept(ctx, fmt.Sprintf("%s/repos/%s/%s", c.BaseURL, url.PathEscape(owner), url.PathEscape(repo)), mercyPreview)
+	if err != nil {
+		return nil, err
+	}
+
+	var full GitHubRepo
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+	return &full, nil
+}
+
+// enrichTopRepos replaces the top repos with their full objects, a request
+// each, so later checks see fork parents and watcher counts without
+// fetching every repo. A repo that fails keeps its listing entry; repos
+// listed over GraphQL are complete already.
+func (a *Analyzer) enrichTopRepos(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	var failed error
+	metrics := &r.acc.metrics
+	for _, repo := range r.checkable() {
+		full := &repo
+		if !repo.detailed {
+			owner, name := repoOwnerAndName(repo, r.username)
+			var err error
+			if full, err = a.client.GetRepo(ctx, owner, name); err != nil {
+				failed = err
+				continue
+			}
+			r.acc.top.replace(*full)
+		}
+
+		metrics.TopRepoWatchers += full.SubscribersCount
+		if full.Parent != nil {
+			metrics.TopRepoForks = append(metrics.TopRepoForks, fmt.Sprintf("%s (fork of %s)", full.Name, full.Parent.FullName))
+		}
+	}
+	if failed != nil {
+		r.log.fellBack("repo_details", fmt.Errorf("failed to fetch some top repos in full: %w", failed))
+	}
+}
@@ -0,0 +1,113 @@
+package ebert
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// serveRepo answers /repos/owner/name with repo in full
+func serveRepo(f *fakeGitHub, owner string, repo GitHubRepo) {
+	f.route("/repos/"+owner+"/"+repo.Name, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != mercyPreview {
+			http.Error(w, "want topics the mercy-preview way, got "+got, http.StatusUnsupportedMediaType)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(repo)
+	})
+}
+
+func TestGetRepo(t *testing.T) {
+	f := newFakeGitHub(t)
+	f.route("/repos/octo/lib", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"lib","full_name":"octo/lib","fork":true,"topics":["http","router"],
+			"subscribers_count":12,"network_count":340,
+			"parent":{"full_name":"upstream/lib","html_url":"https://github.com/upstream/lib","stargazers_count":5000,"fork":false}}`))
+	})
+
+	client := newFakeAnalyzer(f).client
+	repo, err := client.GetRepo(t.Context(), "octo", "lib")
+	if err != nil {
+		t.Fatalf("GetRepo: %v", err)
+	}
+	want := RepoRef{FullName: "upstream/lib", HTMLURL: "https://github.com/upstream/lib", StargazersCount: 5000}
+	if repo.Parent == nil || *repo.Parent != want {
+		t.Errorf("Parent = %+v, want %+v", repo.Parent, want)
+	}
+	if repo.SubscribersCount != 12 || repo.NetworkCount != 340 || !slices.Equal(repo.Topics, []string{"http", "router"}) {
+		t.Errorf("repo = %+v", repo)
+	}
+
+	if _, err := client.GetRepo(t.Context(), "octo", "missing"); err == nil {
+		t.Error("GetRepo of a missing repo succeeded")
+	}
+}
+
+func TestEnrichTopRepos(t *testing.T) {
+	tool := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}
+	lib := GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 30, Fork: true, UpdatedAt: fakeNow.Add(-days(3))}
+	kit := GitHubRepo{Name: "kit", Language: "Go", Size: 300, StargazersCount: 20, UpdatedAt: fakeNow.Add(-days(4))}
+
+	for _, tt := range []struct {
+		name     string
+		served   []GitHubRepo
+		watchers int
+		forks    []string
+		status   string
+	}{
+		{"all", []GitHubRepo{tool, lib, kit}, 17, []string{"lib (fork of upstream/lib)"}, SourceOK},
+		// kit keeps its listing entry
+		{"one missing", []GitHubRepo{tool, lib}, 15, []string{"lib (fork of upstream/lib)"}, SourceFallback},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newAccount("octo", days(3000), tool, lib, kit)
+			f := newFakeGitHub(t, account)
+			for _, repo := range tt.served {
+				full := repo
+				full.FullName = "octo/" + repo.Name
+				full.SubscribersCount = map[string]int{"tool": 10, "lib": 5, "kit": 2}[repo.Name]
+				if repo.Fork {
+					full.Parent = &RepoRef{FullName: "upstream/lib", StargazersCount: 5000}
+				}
+				serveRepo(f, "octo", full)
+			}
+
+			analysis, err := newFakeAnalyzer(f).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if analysis.Metrics.TopRepoWatchers != tt.watchers || !slices.Equal(analysis.Metrics.TopRepoForks, tt.forks) {
+				t.Errorf("%d watchers, forks %q; want %d and %q", analysis.Metrics.TopRepoWatchers, analysis.Metrics.TopRepoForks, tt.watchers, tt.forks)
+			}
+			for _, source := range analysis.DataSources {
+				if source.Name == "repo_details" && source.Status != tt.status {
+					t.Errorf("repo_details %s, want %s", source.Status, tt.status)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateRepoDetailsCost(t *testing.T) {
+	opts := defaultOptions()
+	count := func(e CostEstimate) int {
+		var count int
+		for _, request := range e.Requests {
+			if request.Step == "repo_details" {
+				count += request.Count
+			}
+		}
+		return count
+	}
+	for repos, want := range map[int]int{3: 3, 100: opts.TopRepos} {
+		// GraphQL lists repos in full, so only an anonymous run fetches them
+		estimate := EstimateCost(&GitHubUser{Login: "octo", PublicRepos: repos}, opts)
+		if got := count(estimate); got != 0 {
+			t.Errorf("%d repos cost %d repo_details requests with a token, want none", repos, got)
+		}
+		if got := count(estimate.withoutToken()); got != want {
+			t.Errorf("%d repos cost %d repo_details requests anonymously, want %d", repos, got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ebert
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxPerPage is the largest page size the API allows
+const maxPerPage = 100
+
+// RepoListOptions are the query options for listing a user's repos. Empty
+// fields leave the API default in place.
+type RepoListOptions struct {
+	Sort      string `json:"sort,omitempty"`      // created, updated, pushed or full_name
+	Direction string `json:"direction,omitempty"` // asc or desc
+	Type      string `json:"type,omitempty"`      // all, owner or member
+	PerPage   int    `json:"per_page,omitempty"`  // 1 to 100, 100 if zero
+}
+
+// legacyRepoListOptions reproduces the listing GetRepos always made
+var legacyRepoListOptions = RepoListOptions{Sort: "updated", PerPage: maxPerPage}
+
+// DefaultRepoListOptions lists only repos the user owns, so repos they
+// merely have access to aren't counted, most recently updated first
+func DefaultRepoListOptions() RepoListOptions {
+	return RepoListOptions{Sort: "updated", Type: "owner", PerPage: maxPerPage}
+}
+
+func (o RepoListOptions) validate() error {
+	check := func(field, value string, allowed ...string) error {
+		if value != "" && !slices.Contains(allowed, value) {
+			return fmt.Errorf("invalid repo list %s %q, want one of %s", field, value, strings.Join(allowed, ", "))
+		}
+		return nil
+	}
+
+	if err := check("sort", o.Sort, "created", "updated", "pushed", "full_name"); err != nil {
+		return err
+	}
+	if err := check("direction", o.Direction, "asc", "desc"); err != nil {
+		return err
+	}
+	if err := check("type", o.Type, "all", "owner", "member"); err != nil {
+		return err
+	}
+	if o.PerPage < 0 || o.PerPage > maxPerPage {
+		return fmt.Errorf("invalid repo list per_page %d, want 1 to %d", o.PerPage, maxPerPage)
+	}
+	return nil
+}
+
+// perPage is the effective page size
+func (o RepoListOptions) perPage() int {
+	if o.PerPage == 0 {
+		return maxPerPage
+	}
+	return o.PerPage
+}
+
+// query encodes the options for one page of the listing
+func (o RepoListOptions) query(page int) url.Values {
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(o.perPage()))
+	query.Set("page", strconv.Itoa(page))
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+	if o.Direction != "" {
+		query.Set("direction", o.Direction)
+	}
+	if o.Type != "" {
+		query.Set("type", o.Type)
+	}
+	return query
+}
+
+// String describes the options for the data-sources report
+func (o RepoListOptions) String() string {
+	query := o.query(1)
+	query.Del("page")
+	return query.Encode()
+}
+
+// repoListOptions picks the caller's options or the legacy listing
+func repoListOptions(opts []RepoListOptions) RepoListOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return legacyRepoListOptions
+}
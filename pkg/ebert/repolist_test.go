@@ -0,0 +1,130 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRepoListOptionsValidate(t *testing.T) {
+	for _, tt := range []struct {
+		opts RepoListOptions
+		err  string
+	}{
+		{RepoListOptions{}, ""},
+		{DefaultRepoListOptions(), ""},
+		{RepoListOptions{Sort: "full_name", Direction: "asc", Type: "all", PerPage: 1}, ""},
+		{RepoListOptions{Sort: "stars"}, `invalid repo list sort "stars"`},
+		{RepoListOptions{Direction: "up"}, `invalid repo list direction "up"`},
+		{RepoListOptions{Type: "private"}, `invalid repo list type "private"`},
+		{RepoListOptions{PerPage: 101}, "invalid repo list per_page 101"},
+		{RepoListOptions{PerPage: -1}, "invalid repo list per_page -1"},
+	} {
+		err := tt.opts.validate()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%+v: %v", tt.opts, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%+v: err = %v, want %s", tt.opts, err, tt.err)
+		}
+		if _, err := New("", WithRepoListOptions(tt.opts)); err == nil {
+			t.Errorf("New accepted %+v", tt.opts)
+		}
+	}
+}
+
+func TestRepoListOptionsString(t *testing.T) {
+	for _, tt := range []struct {
+		opts RepoListOptions
+		want string
+	}{
+		{RepoListOptions{}, "per_page=100"},
+		{DefaultRepoListOptions(), "per_page=100&sort=updated&type=owner"},
+		{RepoListOptions{Sort: "full_name", Direction: "asc", Type: "all", PerPage: 20}, "direction=asc&per_page=20&sort=full_name&type=all"},
+	} {
+		if got := tt.opts.String(); got != tt.want {
+			t.Errorf("%+v: String = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestRepoListQuery(t *testing.T) {
+	repos := make([]GitHubRepo, 5)
+	for i := range repos {
+		repos[i] = GitHubRepo{Name: fmt.Sprintf("repo-%d", i), Language: "Go", Size: 100, UpdatedAt: fakeNow.Add(-days(i))}
+	}
+	f := newFakeGitHub(t, newAccount("octo", days(3000), repos...))
+	var queries []url.Values
+	f.route("/users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		_ = json.NewEncoder(w).Encode(fakePage(f.account("octo").Repos, r))
+	})
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		// want is the query of the first page, and pages how many were asked for
+		want  string
+		pages int
+	}{
+		{"default", nil, "page=1&per_page=100&sort=updated&type=owner", 1},
+		{"all repos by name", []Option{WithRepoListOptions(RepoListOptions{Sort: "full_name", Direction: "asc", Type: "all"})},
+			"direction=asc&page=1&per_page=100&sort=full_name&type=all", 1},
+		// A short page ends the listing
+		{"small pages", []Option{WithRepoListOptions(RepoListOptions{Type: "member", PerPage: 2})}, "page=1&per_page=2&type=member", 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = nil
+			detailed, err := newFakeAnalyzer(f, tt.opts...).AnalyzeDetailed("octo")
+			if err != nil {
+				t.Fatalf("AnalyzeDetailed: %v", err)
+			}
+			if len(queries) != tt.pages || queries[0].Encode() != tt.want {
+				t.Fatalf("listed %d pages starting with %v, want %d starting with %s", len(queries), queries, tt.pages, tt.want)
+			}
+			for i, query := range queries {
+				if query.Get("page") != strconv.Itoa(i+1) {
+					t.Errorf("request %d asked for page %s", i, query.Get("page"))
+				}
+			}
+			if detailed.Metrics.Repos != 5 {
+				t.Errorf("Repos = %d, want all 5", detailed.Metrics.Repos)
+			}
+
+			// The data-sources report records the options the listing used
+			list := newFakeAnalyzer(f, tt.opts...).opts.RepoList
+			i := slices.IndexFunc(detailed.DataSources, func(s DataSource) bool { return s.Name == "repos" })
+			if i < 0 || detailed.DataSources[i].Detail != list.String()+" via "+repoPathREST {
+				t.Errorf("sources = %+v, want the repos listed with %s", detailed.DataSources, list)
+			}
+		})
+	}
+}
+
+func TestGetReposLegacyListing(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", UpdatedAt: fakeNow}))
+	var query string
+	f.route("/users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode(fakePage(f.account("octo").Repos, r))
+	})
+	client := newFakeAnalyzer(f).client
+	if _, _, err := client.GetRepos(t.Context(), "octo"); err != nil {
+		t.Fatalf("GetRepos: %v", err)
+	}
+	// Without options, GetRepos keeps the listing it always made
+	if query != "page=1&per_page=100&sort=updated" {
+		t.Errorf("listed with %q, want the legacy query", query)
+	}
+	if _, _, err := client.GetRepos(t.Context(), "octo", RepoListOptions{Sort: "stars"}); err == nil {
+		t.Error("GetRepos listed with an invalid sort")
+	}
+}
@@ -0,0 +1,313 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Repo listing paths named in the data-sources report
+const (
+	repoPathGraphQL = "graphql"
+	repoPathREST    = "rest"
+)
+
+// reposQuery lists an account's public repos with the fields the REST
+// listing carries plus the fork parent and watcher count, which REST
+// needs a request per repo for
+const reposQuery = `query($login: String!, $first: Int!, $after: String, $orderBy: RepositoryOrder) {
+  repositoryOwner(login: $login) {
+    repositories(first: $first, after: $after, privacy: PUBLIC, ownerAffiliations: [OWNER], orderBy: $orderBy) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name nameWithOwner description url
+        primaryLanguage { name }
+        stargazerCount forkCount diskUsage
+        isArchived isFork isDisabled isTemplate mirrorUrl
+        createdAt updatedAt pushedAt
+        hasIssuesEnabled hasWikiEnabled hasDiscussionsEnabled
+        defaultBranchRef { name }
+        repositoryTopics(first: 20) { nodes { topic { name } } }
+        watchers { totalCount }
+        parent { nameWithOwner url stargazerCount isFork }
+        deployments(environments: ["github-pages"], first: 1) { totalCount }
+      }
+    }
+  }
+}`
+
+// repoStatesQuery lists what can change on a repo without a push: its
+// archived and disabled flags, counts, topics and description. Archiving
+// moves updatedAt but not pushedAt.
+const repoStatesQuery = `query($login: String!, $first: Int!, $after: String, $orderBy: RepositoryOrder) {
+  repositoryOwner(login: $login) {
+    repositories(first: $first, after: $after, privacy: PUBLIC, ownerAffiliations: [OWNER], orderBy: $orderBy) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        nameWithOwner description
+        stargazerCount forkCount
+        isArchived isDisabled updatedAt
+        repositoryTopics(first: 20) { nodes { topic { name } } }
+        watchers { totalCount }
+      }
+    }
+  }
+}`
+
+// graphQLRepo is one node of reposQuery or repoStatesQuery
+type graphQLRepo struct {
+	Name            string `json:"name"`
+	NameWithOwner   string `json:"nameWithOwner"`
+	Description     string `json:"description"`
+	URL             string `json:"url"`
+	PrimaryLanguage *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	StargazerCount        int       `json:"stargazerCount"`
+	ForkCount             int       `json:"forkCount"`
+	DiskUsage             int       `json:"diskUsage"`
+	IsArchived            bool      `json:"isArchived"`
+	IsFork                bool      `json:"isFork"`
+	IsDisabled            bool      `json:"isDisabled"`
+	IsTemplate            bool      `json:"isTemplate"`
+	MirrorURL             string    `json:"mirrorUrl"`
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+	PushedAt              time.Time `json:"pushedAt"`
+	HasIssuesEnabled      bool      `json:"hasIssuesEnabled"`
+	HasWikiEnabled        bool      `json:"hasWikiEnabled"`
+	HasDiscussionsEnabled bool      `json:"hasDiscussionsEnabled"`
+	DefaultBranchRef      *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	Watchers struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"watchers"`
+	Parent *struct {
+		NameWithOwner  string `json:"nameWithOwner"`
+		URL            string `json:"url"`
+		StargazerCount int    `json:"stargazerCount"`
+		IsFork         bool   `json:"isFork"`
+	} `json:"parent"`
+	Deployments struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"deployments"`
+}
+
+// repo converts the node to the REST shape. GraphQL has no Pages flag, so
+// a repo that has deployed to the github-pages environment, as every Pages
+// build does, counts as having Pages.
+func (g graphQLRepo) repo() GitHubRepo {
+	repo := GitHubRepo{
+		Name:             g.Name,
+		FullName:         g.NameWithOwner,
+		Description:      g.Description,
+		StargazersCount:  g.StargazerCount,
+		ForksCount:       g.ForkCount,
+		Size:             g.DiskUsage,
+		Archived:         g.IsArchived,
+		Fork:             g.IsFork,
+		Disabled:         g.IsDisabled,
+		UpdatedAt:        g.UpdatedAt,
+		CreatedAt:        g.CreatedAt,
+		PushedAt:         g.PushedAt,
+		Topics:           []string{},
+		HasPages:         g.Deployments.TotalCount > 0,
+		HasIssues:        g.HasIssuesEnabled,
+		HasWiki:          g.HasWikiEnabled,
+		HasDiscussions:   g.HasDiscussionsEnabled,
+		IsTemplate:       g.IsTemplate,
+		MirrorURL:        g.MirrorURL,
+		HTMLURL:          g.URL,
+		SubscribersCount: g.Watchers.TotalCount,
+		detailed:         true,
+	}
+	if g.PrimaryLanguage != nil {
+		repo.Language = g.PrimaryLanguage.Name
+	}
+	if g.DefaultBranchRef != nil {
+		repo.DefaultBranch = g.DefaultBranchRef.Name
+	}
+	for _, node := range g.RepositoryTopics.Nodes {
+		repo.Topics = append(repo.Topics, node.Topic.Name)
+	}
+	if g.Parent != nil {
+		repo.Parent = &RepoRef{
+			FullName:        g.Parent.NameWithOwner,
+			HTMLURL:         g.Parent.URL,
+			StargazersCount: g.Parent.StargazerCount,
+			Fork:            g.Parent.IsFork,
+		}
+	}
+	return repo
+}
+
+// refresh copies the node's repoStatesQuery fields over repo
+func (g graphQLRepo) refresh(repo *GitHubRepo) {
+	repo.Description = g.Description
+	repo.StargazersCount = g.StargazerCount
+	repo.ForksCount = g.ForkCount
+	repo.Archived = g.IsArchived
+	repo.Disabled = g.IsDisabled
+	repo.UpdatedAt = g.UpdatedAt
+	repo.Topics = make([]string, 0, len(g.RepositoryTopics.Nodes))
+	for _, node := range g.RepositoryTopics.Nodes {
+		repo.Topics = append(repo.Topics, node.Topic.Name)
+	}
+	repo.SubscribersCount = g.Watchers.TotalCount
+}
+
+// graphQLOrder maps the REST listing's sort and direction to a
+// RepositoryOrder, with REST's defaults: by name ascending, and any other
+// field descending
+func (o RepoListOptions) graphQLOrder() map[string]string {
+	field, direction := "NAME", "ASC"
+	switch o.Sort {
+	case "created":
+		field, direction = "CREATED_AT", "DESC"
+	case "updated":
+		field, direction = "UPDATED_AT", "DESC"
+	case "pushed":
+		field, direction = "PUSHED_AT", "DESC"
+	}
+	if o.Direction != "" {
+		direction = strings.ToUpper(o.Direction)
+	}
+	return map[string]string{"field": field, "direction": direction}
+}
+
+// graphQLListable reports whether the GraphQL listing returns the same
+// repos as the REST one; only owned repos are listed over GraphQL
+func (o RepoListOptions) graphQLListable() bool {
+	return o.Type == "" || o.Type == "owner"
+}
+
+// EachRepoPageGraphQL is EachRepoPage over the GraphQL API, which needs a
+// token. Each page carries the fork parent and watcher count GetRepo would
+// otherwise be asked for.
+func (c *GitHubClient) EachRepoPageGraphQL(ctx context.Context, username string, fn func(page int, repos []GitHubRepo) error, opts ...RepoListOptions) (err error) {
+	ctx, span := c.startSpan(ctx, "github.repos", "graphql:repositories")
+	page := 1
+	defer func() {
+		span.SetAttributes(Attribute{Key: "github.pages", Value: page}, Attribute{Key: "github.cache_hit", Value: false})
+		endSpan(span, err)
+	}()
+
+	list := repoListOptions(opts)
+	if err := list.validate(); err != nil {
+		return err
+	}
+	if !list.graphQLListable() {
+		return fmt.Errorf("repo list type %q is not available over GraphQL", list.Type)
+	}
+
+	return c.eachGraphQLRepoPage(ctx, reposQuery, username, list, func(n int, nodes []graphQLRepo) error {
+		page = n
+		repos := make([]GitHubRepo, 0, len(nodes))
+		for _, node := range nodes {
+			repos = append(repos, node.repo())
+		}
+		return fn(page, repos)
+	})
+}
+
+// eachGraphQLRepoPage runs a repositories query, reposQuery or
+// repoStatesQuery, handing each page of nodes to fn
+func (c *GitHubClient) eachGraphQLRepoPage(ctx context.Context, query, username string, list RepoListOptions, fn func(page int, nodes []graphQLRepo) error) error {
+	var after *string
+	for page := 1; ; page++ {
+		var result struct {
+			RepositoryOwner *struct {
+				Repositories struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []graphQLRepo `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"repositoryOwner"`
+		}
+		variables := map[string]any{"login": username, "first": list.perPage(), "after": after, "orderBy": list.graphQLOrder()}
+		if err := c.graphQL(ctx, query, variables, &result); err != nil {
+			return err
+		}
+		if result.RepositoryOwner == nil {
+			return fmt.Errorf("no account %s in the GraphQL API", username)
+		}
+
+		connection := result.RepositoryOwner.Repositories
+		if len(connection.Nodes) == 0 {
+			return nil
+		}
+		if err := fn(page, connection.Nodes); err != nil {
+			return err
+		}
+
+		if !connection.PageInfo.HasNextPage {
+			return nil
+		}
+		cursor := connection.PageInfo.EndCursor
+		after = &cursor
+	}
+}
+
+// repoStates lists the changeable state of the user's repos over GraphQL,
+// by lowercased full name
+func (c *GitHubClient) repoStates(ctx context.Context, username string, list RepoListOptions) (_ map[string]graphQLRepo, err error) {
+	ctx, span := c.startSpan(ctx, "github.repo_states", "graphql:repositories")
+	defer func() { endSpan(span, err) }()
+
+	states := map[string]graphQLRepo{}
+	err = c.eachGraphQLRepoPage(ctx, repoStatesQuery, username, list, func(_ int, nodes []graphQLRepo) error {
+		for _, node := range nodes {
+			states[strings.ToLower(node.NameWithOwner)] = node
+		}
+		return nil
+	})
+	return states, err
+}
+
+// listRepos hands each page of the user's repos to fn, over GraphQL when
+// the client has a token and the listing options allow it, and over REST
+// otherwise. A GraphQL failure falls back to REST, which skips the repos
+// GraphQL already delivered. It returns the path the listing finished on.
+func (a *Analyzer) listRepos(ctx context.Context, r *analysisRun, fn func([]GitHubRepo) error) (string, error) {
+	seen := map[string]struct{}{}
+	var fnErr error
+	deliver := func(_ int, repos []GitHubRepo) error {
+		fresh := make([]GitHubRepo, 0, len(repos))
+		for _, repo := range repos {
+			key := strings.ToLower(repo.FullName)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			fresh = append(fresh, repo)
+		}
+		if len(fresh) == 0 && len(repos) > 0 {
+			return nil
+		}
+		fnErr = fn(fresh)
+		return fnErr
+	}
+
+	if a.client.authenticated() && a.opts.RepoList.graphQLListable() {
+		err := a.client.EachRepoPageGraphQL(ctx, r.username, deliver, a.opts.RepoList)
+		if err == nil || fnErr != nil || ctx.Err() != nil {
+			return repoPathGraphQL, err
+		}
+		r.log.fellBack("repos_graphql", fmt.Errorf("failed to list repos over GraphQL: %w", err))
+	}
+
+	bad, err := a.client.EachRepoPage(ctx, r.username, deliver, a.opts.RepoList)
+	r.decodeErrors += bad
+	return repoPathREST, err
+}
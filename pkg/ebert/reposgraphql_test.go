@@ -0,0 +1,80 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mixedAccount has more repos than a page, of assorted languages, ages,
+// stars and topics, some archived, so every repo metric has input
+func mixedAccount(login string) *fakeAccount {
+	var repos []GitHubRepo
+	for i := range 130 {
+		repo := GitHubRepo{
+			Name: fmt.Sprintf("repo-%03d", i), Language: []string{"Go", "Rust", "Python", ""}[i%4],
+			Size: 50 + i*37, StargazersCount: (i * 53) % 700, ForksCount: i % 11,
+			HasIssues: i%3 != 0, HasWiki: i%5 == 0, Archived: i%17 == 0,
+			UpdatedAt: fakeNow.Add(-days(i * 9)),
+		}
+		if i%6 == 0 {
+			repo.Topics = []string{"cli", "tool"}
+		}
+		repos = append(repos, repo)
+	}
+	return newAccount(login, days(3200), repos...)
+}
+
+func TestRepoListingPathsAgree(t *testing.T) {
+	unspaced(t)
+	analyze := func(graphQL bool) *Analysis {
+		t.Helper()
+		f := newFakeGitHub(t, mixedAccount("mixed"))
+		if graphQL {
+			f.route("/graphql", f.serveGraphQLRepos)
+		}
+		analysis, err := newFakeAnalyzerToken(f, "ghp_test").Analyze("mixed")
+		if err != nil {
+			t.Fatalf("analysis (GraphQL %t): %v", graphQL, err)
+		}
+		return analysis
+	}
+	// Without a /graphql route the fake 404s the query and the listing
+	// falls back to REST
+	viaGraphQL, viaREST := analyze(true), analyze(false)
+
+	path := func(analysis *Analysis) string {
+		i := slices.IndexFunc(analysis.DataSources, func(s DataSource) bool { return s.Name == "repos" })
+		if i < 0 {
+			return ""
+		}
+		return analysis.DataSources[i].Detail
+	}
+	if !strings.HasSuffix(path(viaGraphQL), "via "+repoPathGraphQL) || !strings.HasSuffix(path(viaREST), "via "+repoPathREST) {
+		t.Errorf("repos sources say %q and %q, want the GraphQL then the REST path", path(viaGraphQL), path(viaREST))
+	}
+
+	metrics := func(analysis *Analysis) string {
+		t.Helper()
+		codes := make([]string, 0, len(analysis.Findings))
+		for _, finding := range analysis.Findings {
+			codes = append(codes, finding.Code)
+		}
+		data, err := json.MarshalIndent(map[string]any{
+			"metrics": analysis.Metrics, "scores": analysis.Scores, "overall": analysis.OverallScore,
+			"risk": analysis.RiskLevel, "findings": codes,
+		}, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if got, want := metrics(viaGraphQL), metrics(viaREST); got != want {
+		t.Errorf("the GraphQL listing scored\n%s\nwhere REST scored\n%s", got, want)
+	}
+	if viaGraphQL.Metrics.Repos != 130 {
+		t.Errorf("counted %d repos, want all 130 across the pages", viaGraphQL.Metrics.Repos)
+	}
+}
@@ -0,0 +1,81 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// GitHub answers the statistics endpoints with 202 while it computes
+// them. A request is polled maxAcceptedPolls more times, acceptedPollInterval
+// apart, before the statistics are reported as not available yet.
+const maxAcceptedPolls = 3
+
+var acceptedPollInterval = 2 * time.Second
+
+// ContributorStats is one contributor's weekly history from the
+// repository statistics
+type ContributorStats struct {
+	Author GitHubUser          `json:"author"`
+	Total  int                 `json:"total"`
+	Weeks  []ContributorWeekly `json:"weeks"`
+}
+
+// ContributorWeekly is a contributor's additions, deletions and commits in
+// the week starting at Week, a Unix time
+type ContributorWeekly struct {
+	Week      int64 `json:"w"`
+	Additions int   `json:"a"`
+	Deletions int   `json:"d"`
+	Commits   int   `json:"c"`
+}
+
+// WeeklyCommits counts a repo's commits in the week starting at Week, a
+// Unix time, by day from Sunday
+type WeeklyCommits struct {
+	Week  int64 `json:"week"`
+	Total int   `json:"total"`
+	Days  []int `json:"days"`
+}
+
+// GetContributorStats returns the weekly history of a repo's top
+// contributors. ok is false when there is no data: the repo is empty or
+// GitHub is still computing the statistics.
+func (c *GitHubClient) GetContributorStats(ctx context.Context, owner, repo string) (_ []ContributorStats, ok bool, err error) {
+	ctx, span := c.startSpan(ctx, "github.stats_contributors", "repos/:owner/:repo/stats/contributors")
+	defer func() { endSpan(span, err) }()
+	return getStatistics[ContributorStats](ctx, c, fmt.Sprintf("%s/repos/%s/%s/stats/contributors", c.BaseURL, owner, repo))
+}
+
+// GetCommitActivity returns a repo's commits per week over the last year,
+// with ok false when there is no data, as for GetContributorStats
+func (c *GitHubClient) GetCommitActivity(ctx context.Context, owner, repo string) (_ []WeeklyCommits, ok bool, err error) {
+	ctx, span := c.startSpan(ctx, "github.stats_commit_activity", "repos/:owner/:repo/stats/commit_activity")
+	defer func() { endSpan(span, err) }()
+	return getStatistics[WeeklyCommits](ctx, c, fmt.Sprintf("%s/repos/%s/%s/stats/commit_activity", c.BaseURL, owner, repo))
+}
+
+// getStatistics fetches a statistics endpoint, mapping a persistent 202,
+// a 204 and an empty list to no data rather than an error
+func getStatistics[T any](ctx context.Context, c *GitHubClient, url string) ([]T, bool, error) {
+	data, err := c.get(ctx, url)
+	if statsPending(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	items, _, err := decodeElements[T](data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode statistics: %w", err)
+	}
+	return items, len(items) > 0, nil
+}
+
+// statsPending reports whether err is a 202 that outlasted the polling
+func statsPending(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusAccepted
+}
@@ -0,0 +1,68 @@
+package ebert
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetContributorStatsSequences(t *testing.T) {
+	interval := acceptedPollInterval
+	acceptedPollInterval = time.Millisecond
+	t.Cleanup(func() { acceptedPollInterval = interval })
+
+	const stats = `[{"author":{"login":"octo"},"total":12,"weeks":[{"w":1716681600,"a":40,"d":3,"c":12}]}]`
+	for _, tc := range []struct {
+		name string
+		// statuses are answered in turn, the last one from then on
+		statuses []int
+		body     string
+		requests int32
+		ok       bool
+	}{
+		{"202 then 200", []int{http.StatusAccepted, http.StatusOK}, stats, 2, true},
+		{"persistent 202", []int{http.StatusAccepted}, "", 1 + maxAcceptedPolls, false},
+		{"204", []int{http.StatusNoContent}, "", 1, false},
+		{"empty list", []int{http.StatusOK}, "[]", 1, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeGitHub(t)
+			var served atomic.Int32
+			f.route("/repos/octo/tool/stats/contributors", func(w http.ResponseWriter, r *http.Request) {
+				status := tc.statuses[min(int(served.Add(1)), len(tc.statuses))-1]
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(tc.body))
+				}
+			})
+
+			contributors, ok, err := newFakeAnalyzer(f).client.GetContributorStats(context.Background(), "octo", "tool")
+			if err != nil {
+				t.Fatalf("GetContributorStats: %v", err)
+			}
+			if ok != tc.ok || len(contributors) > 0 != tc.ok {
+				t.Errorf("returned %d contributors, ok %t; want ok %t", len(contributors), ok, tc.ok)
+			}
+			if ok && (contributors[0].Author.Login != "octo" || contributors[0].Weeks[0].Commits != 12) {
+				t.Errorf("contributors = %+v, want octo's 12 commits", contributors)
+			}
+			if served.Load() != tc.requests {
+				t.Errorf("served %d requests, want %d", served.Load(), tc.requests)
+			}
+		})
+	}
+}
+
+func TestGetCommitActivityError(t *testing.T) {
+	f := newFakeGitHub(t)
+	f.route("/repos/octo/tool/stats/commit_activity", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	// Only a 202, a 204 and an empty list mean no data; other failures
+	// stay errors
+	if _, ok, err := newFakeAnalyzer(f).client.GetCommitActivity(context.Background(), "octo", "tool"); err == nil || ok {
+		t.Errorf("a 403 returned ok %t, error %v; want an error", ok, err)
+	}
+}
@@ -0,0 +1,78 @@
+package ebert
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LoggedRequest is one request an analysis sent: when, where and the
+// status it got, or the error instead
+type LoggedRequest struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	URL    string    `json:"url"`
+	Status int       `json:"status,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// RequestLog is an http.RoundTripper that logs every request sent
+// through it for an audit bundle. Only the method, URL and outcome are
+// kept, never headers or bodies, and tokens are scrubbed from URLs.
+type RequestLog struct {
+	base http.RoundTripper
+	now  func() time.Time
+
+	mu       sync.Mutex
+	requests []LoggedRequest
+}
+
+// NewRequestLog logs the requests sent through base, or
+// http.DefaultTransport if nil, timed by clock, or time.Now if nil. A
+// replay passes its recording time, so the log comes out the same on
+// every run.
+func NewRequestLog(base http.RoundTripper, clock func() time.Time) *RequestLog {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return &RequestLog{base: base, now: clock}
+}
+
+// RoundTrip sends req through the base transport and logs it
+func (l *RequestLog) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.User = nil
+	entry := LoggedRequest{Time: l.now().UTC(), Method: req.Method, URL: tokenPattern.ReplaceAllString(u.String(), scrubbedToken)}
+
+	resp, err := l.base.RoundTrip(req)
+	if err != nil {
+		entry.Error = tokenPattern.ReplaceAllString(err.Error(), scrubbedToken)
+	} else {
+		entry.Status = resp.StatusCode
+	}
+
+	l.mu.Lock()
+	l.requests = append(l.requests, entry)
+	l.mu.Unlock()
+	return resp, err
+}
+
+// Requests lists the logged requests in time order. Requests sent
+// concurrently finish in any order, so ties are broken by URL, method and
+// outcome rather than by when they landed.
+func (l *RequestLog) Requests() []LoggedRequest {
+	l.mu.Lock()
+	requests := slices.Clone(l.requests)
+	l.mu.Unlock()
+
+	slices.SortFunc(requests, func(x, y LoggedRequest) int {
+		return cmp.Or(x.Time.Compare(y.Time), cmp.Compare(x.URL, y.URL), cmp.Compare(x.Method, y.Method),
+			cmp.Compare(x.Status, y.Status), cmp.Compare(x.Error, y.Error))
+	})
+	return requests
+}
@@ -0,0 +1,146 @@
+package ebert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaVersion is the version of the Analysis JSON format. Bump it with
+// any change that would make an older encoded analysis misread; cached
+// analyses under another version are never read.
+const SchemaVersion = 1
+
+// DefaultResultCacheTTL is how long WithResultCache keeps an analysis when
+// no TTL is given
+const DefaultResultCacheTTL = time.Hour
+
+// ResultCache stores finished analyses by key, so a repeat analysis of the
+// same account with the same options within the TTL costs no requests.
+// NewMemoryCache is the in-process implementation; see the redisebert
+// package for one shared across restarts and replicas.
+type ResultCache interface {
+	// Get returns the analysis stored under key, reporting false when there
+	// is none or it expired
+	Get(ctx context.Context, key string) (*Analysis, bool, error)
+
+	// Set stores a under key for ttl
+	Set(ctx context.Context, key string, a *Analysis, ttl time.Duration) error
+}
+
+// ResultCacheKey is the key an analysis of login with opts is cached
+// under. It carries SchemaVersion, so an upgrade that changes the format
+// leaves older entries unread, and a digest of the options that shape the
+// result, so differently configured analyzers don't share entries.
+func ResultCacheKey(login string, opts AnalyzerOptions) (string, error) {
+	data, err := json.Marshal(analysisMeta(opts))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode options: %w", err)
+	}
+	digest := sha256.Sum256(data)
+	return fmt.Sprintf("ebert:v%d:%s:%s", SchemaVersion, strings.ToLower(NormalizeUsername(login)), hex.EncodeToString(digest[:8])), nil
+}
+
+// MemoryCache is a ResultCache held in process memory. Analyses are stored
+// encoded, so callers mutating a returned analysis don't alter the cache.
+type MemoryCache struct {
+	// now is the clock expiry is judged by; nil means time.Now
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedAnalysis
+}
+
+type cachedAnalysis struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty in-process cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]cachedAnalysis{}}
+}
+
+func (c *MemoryCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Get implements ResultCache, dropping the entry once it has expired
+func (c *MemoryCache) Get(_ context.Context, key string) (*Analysis, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && !c.clock().Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	var analysis Analysis
+	if err := json.Unmarshal(entry.data, &analysis); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached analysis: %w", err)
+	}
+	return &analysis, true, nil
+}
+
+// Set implements ResultCache; expired entries are swept as new ones arrive
+func (c *MemoryCache) Set(_ context.Context, key string, a *Analysis, ttl time.Duration) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedAnalysis{data: data, expires: now.Add(ttl)}
+	return nil
+}
+
+// cachedAnalyze serves AnalyzeContext from the result cache when one is
+// configured, storing complete analyses on a miss. Cache failures are
+// logged and the analysis runs as if there were no cache.
+func (a *Analyzer) cachedAnalyze(ctx context.Context, username string) (*Analysis, error) {
+	cache := a.opts.ResultCache
+	if cache == nil {
+		return a.analyze(ctx, username, nil, nil, nil)
+	}
+
+	if err := ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	key, err := ResultCacheKey(username, a.opts)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok, err := cache.Get(ctx, key); err != nil {
+		a.opts.Logger.Warn("result cache lookup failed", "key", key, "error", err)
+	} else if ok {
+		return cached, nil
+	}
+
+	analysis, err := a.analyze(ctx, username, nil, nil, nil)
+	if err != nil || analysis.Partial {
+		// Only complete analyses are worth serving again
+		return analysis, err
+	}
+	if err := cache.Set(ctx, key, analysis, a.opts.ResultCacheTTL); err != nil {
+		a.opts.Logger.Warn("result cache store failed", "key", key, "error", err)
+	}
+	return analysis, nil
+}
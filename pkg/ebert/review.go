@@ -0,0 +1,196 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// reviewSampleCommits is how many default-branch commits the review
+// workflow estimate looks at
+const reviewSampleCommits = 30
+
+// Review workflow estimates recorded in RepoReviewWorkflow.Estimate
+const (
+	ReviewLikely   = "likely"
+	ReviewMixed    = "mixed"
+	ReviewUnlikely = "unlikely"
+	ReviewUnknown  = "unknown"
+)
+
+// codeownersPaths are where GitHub looks for a CODEOWNERS file
+var codeownersPaths = []string{"", ".github", "docs"}
+
+// pullRequestCommitPattern matches the subjects GitHub writes for merged
+// and squashed pull requests
+var pullRequestCommitPattern = regexp.MustCompile(`^Merge pull request #\d+|\(#\d+\)\s*$`)
+
+// GitHubCommit is one entry of a repository's commit list
+type GitHubCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+// RepoReviewWorkflow is the estimated review practice of one flagship repo.
+// Branch protection isn't visible without admin rights, so the estimate is
+// inferred from how commits reached the default branch and may be wrong.
+type RepoReviewWorkflow struct {
+	Repo          string  `json:"repo"`
+	Codeowners    bool    `json:"codeowners"`
+	Estimate      string  `json:"estimate"`
+	PRCommitRatio float64 `json:"pr_commit_ratio"`
+}
+
+// GetCommits lists up to n recent commits on branch
+func (c *GitHubClient) GetCommits(ctx context.Context, owner, repo, branch string, n int) (_ []GitHubCommit, err error) {
+	ctx, span := c.startSpan(ctx, "github.commits", "repos/:owner/:repo/commits")
+	defer func() { endSpan(span, err) }()
+
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(min(n, maxPerPage)))
+	if branch != "" {
+		query.Set("sha", branch)
+	}
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/repos/%s/%s/commits?%s", c.BaseURL, owner, repo, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []GitHubCommit
+	if err := json.Unmarshal(data, &commits); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// throughPullRequest reports whether a commit landed via a pull request,
+// judged by it being a merge or carrying GitHub's PR subject
+func (c GitHubCommit) throughPullRequest() bool {
+	subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+	return len(c.Parents) > 1 || pullRequestCommitPattern.MatchString(subject)
+}
+
+// estimateReview classifies a commit sample by the share landed via PRs
+func estimateReview(commits []GitHubCommit) (string, float64) {
+	if len(commits) == 0 {
+		return ReviewUnknown, 0
+	}
+
+	viaPR := 0
+	for _, commit := range commits {
+		if commit.throughPullRequest() {
+			viaPR++
+		}
+	}
+	ratio := float64(viaPR) / float64(len(commits))
+
+	switch {
+	case ratio >= 0.7:
+		return ReviewLikely, ratio
+	case ratio >= 0.3:
+		return ReviewMixed, ratio
+	default:
+		return ReviewUnlikely, ratio
+	}
+}
+
+// hasCodeowners looks for CODEOWNERS where GitHub reads it from
+func (a *Analyzer) hasCodeowners(ctx context.Context, r *analysisRun, repo GitHubRepo) bool {
+	root := a.directory(ctx, r, repo, "")
+	for _, dir := range codeownersPaths {
+		entries := root
+		if dir != "" {
+			if _, ok := hasEntry(root, dir); !ok {
+				continue
+			}
+			entries = a.directory(ctx, r, repo, dir)
+		}
+		if _, ok := hasEntry(entries, "CODEOWNERS"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReviewWorkflow records CODEOWNERS on the top repos and estimates
+// whether the flagship repos require review before merging
+func (a *Analyzer) checkReviewWorkflow(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos {
+		return
+	}
+
+	metrics := &r.acc.metrics
+	for _, repo := range r.checkable() {
+		if !repo.Fork && a.hasCodeowners(ctx, r, repo) {
+			metrics.ReposWithCodeowners++
+		}
+	}
+
+	var unreviewed []string
+	for _, repo := range r.flagships() {
+		if repo.Fork || repo.Archived {
+			continue
+		}
+
+		workflow := RepoReviewWorkflow{Repo: repo.Name, Codeowners: a.hasCodeowners(ctx, r, repo), Estimate: ReviewUnknown}
+		if r.contentsBudget.take() {
+			owner, name := repoOwnerAndName(repo, r.username)
+			if commits, err := a.client.GetCommits(ctx, owner, name, repo.DefaultBranch, reviewSampleCommits); err == nil {
+				workflow.Estimate, workflow.PRCommitRatio = estimateReview(commits)
+			}
+		}
+
+		metrics.ReviewWorkflows = append(metrics.ReviewWorkflows, workflow)
+		if workflow.Estimate == ReviewUnlikely {
+			unreviewed = append(unreviewed, fmt.Sprintf("%s (%.0f%% of recent commits via pull requests)", repo.Name, workflow.PRCommitRatio*100))
+		}
+	}
+
+	if len(unreviewed) > 0 {
+		r.addFinding(Finding{
+			Code:     "DIRECT_PUSHES",
+			Severity: SeverityWarning,
+			Message:  "Flagship repos appear to take unreviewed direct pushes (estimated from commit history; branch protection isn't visible)",
+			Evidence: unreviewed,
+		})
+	}
+}
+
+// reviewScore adjusts the security score by the review signals
+func reviewScore(metrics Metrics) float64 {
+	adjust := 0.0
+	if metrics.ReposWithCodeowners > 0 {
+		adjust -= 5
+	}
+
+	likely, unlikely, known := 0, 0, 0
+	for _, workflow := range metrics.ReviewWorkflows {
+		switch workflow.Estimate {
+		case ReviewLikely:
+			likely++
+		case ReviewUnlikely:
+			unlikely++
+		}
+		if workflow.Estimate != ReviewUnknown {
+			known++
+		}
+	}
+
+	// Estimates are uncertain, so they move the score only modestly
+	if known > 0 && likely*2 > known {
+		adjust -= 10
+	} else if known > 0 && unlikely == known {
+		adjust += 10
+	}
+	return adjust
+}
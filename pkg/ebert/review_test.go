@@ -0,0 +1,124 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// commitHistory has prs commits landed by pull request, alternately merged
+// and squashed, then direct ones pushed
+func commitHistory(prs, direct int) []GitHubCommit {
+	var commits []GitHubCommit
+	for i := range prs + direct {
+		var commit GitHubCommit
+		commit.SHA = fmt.Sprintf("%040d", i)
+		switch {
+		case i >= prs:
+			commit.Commit.Message = "fix the build\n\npushed straight to main"
+		case i%2 == 0:
+			commit.Commit.Message = fmt.Sprintf("Merge pull request #%d from alice/topic\n\nAdd a flag", i)
+			commit.Parents = make([]struct {
+				SHA string `json:"sha"`
+			}, 2)
+		default:
+			commit.Commit.Message = fmt.Sprintf("Add a flag (#%d)", i)
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+func TestEstimateReview(t *testing.T) {
+	for _, tt := range []struct {
+		prs, direct int
+		want        string
+	}{
+		{0, 0, ReviewUnknown},
+		{10, 0, ReviewLikely},
+		{7, 3, ReviewLikely},
+		{3, 7, ReviewMixed},
+		{2, 8, ReviewUnlikely},
+		{0, 5, ReviewUnlikely},
+	} {
+		got, ratio := estimateReview(commitHistory(tt.prs, tt.direct))
+		if got != tt.want {
+			t.Errorf("%d of %d via PRs: estimate = %s (%v), want %s", tt.prs, tt.prs+tt.direct, got, ratio, tt.want)
+		}
+	}
+
+	// A PR number mid-subject isn't GitHub's squash suffix
+	var commit GitHubCommit
+	commit.Commit.Message = "Revert the change from #12 for now"
+	if commit.throughPullRequest() {
+		t.Errorf("%q counted as landed by pull request", commit.Commit.Message)
+	}
+}
+
+func TestReviewWorkflow(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 500, UpdatedAt: fakeNow.Add(-days(5))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 900, StargazersCount: 400, UpdatedAt: fakeNow.Add(-days(9))},
+	))
+	serveDirectory(f, "tool", "", "README.md", ".github")
+	serveDirectory(f, "tool", ".github", "CODEOWNERS", "workflows")
+	history := map[string][]GitHubCommit{"tool": commitHistory(9, 1), "lib": commitHistory(1, 9)}
+	for repo, commits := range history {
+		f.route("/repos/octo/"+repo+"/commits", func(w http.ResponseWriter, r *http.Request) {
+			// The sample is of the default branch
+			if q := r.URL.Query(); q.Get("sha") != "main" || q.Get("per_page") != "30" {
+				t.Errorf("listed %s commits with %q", repo, r.URL.RawQuery)
+			}
+			_ = json.NewEncoder(w).Encode(commits)
+		})
+	}
+
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	metrics := analysis.Metrics
+	if metrics.ReposWithCodeowners != 1 {
+		t.Errorf("ReposWithCodeowners = %d, want 1", metrics.ReposWithCodeowners)
+	}
+	want := []RepoReviewWorkflow{
+		{Repo: "tool", Codeowners: true, Estimate: ReviewLikely, PRCommitRatio: 0.9},
+		{Repo: "lib", Estimate: ReviewUnlikely, PRCommitRatio: 0.1},
+	}
+	if !slices.Equal(metrics.ReviewWorkflows, want) {
+		t.Errorf("ReviewWorkflows = %+v, want %+v", metrics.ReviewWorkflows, want)
+	}
+	flag := finding(analysis, "DIRECT_PUSHES")
+	if flag == nil || flag.Severity != SeverityWarning || !slices.Equal(flag.Evidence, []string{"lib (10% of recent commits via pull requests)"}) {
+		t.Errorf("DIRECT_PUSHES = %+v, want lib alone", flag)
+	}
+}
+
+func TestReviewScore(t *testing.T) {
+	workflows := func(estimates ...string) []RepoReviewWorkflow {
+		var out []RepoReviewWorkflow
+		for _, estimate := range estimates {
+			out = append(out, RepoReviewWorkflow{Estimate: estimate})
+		}
+		return out
+	}
+	for _, tt := range []struct {
+		name    string
+		metrics Metrics
+		want    float64
+	}{
+		{"no signals", Metrics{}, 0},
+		{"codeowners", Metrics{ReposWithCodeowners: 2}, -5},
+		{"mostly reviewed", Metrics{ReviewWorkflows: workflows(ReviewLikely, ReviewLikely, ReviewMixed)}, -10},
+		{"all unreviewed", Metrics{ReviewWorkflows: workflows(ReviewUnlikely, ReviewUnlikely, ReviewUnknown)}, 10},
+		// Uncertain estimates leave the score alone
+		{"unknown", Metrics{ReviewWorkflows: workflows(ReviewUnknown, ReviewUnknown)}, 0},
+		{"split", Metrics{ReposWithCodeowners: 1, ReviewWorkflows: workflows(ReviewLikely, ReviewUnlikely)}, -5},
+	} {
+		if got := reviewScore(tt.metrics); got != tt.want {
+			t.Errorf("%s: reviewScore = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
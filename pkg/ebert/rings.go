@@ -0,0 +1,220 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ringSampleSize is how many stargazers per flagship, and followers and
+	// following, the ring check reads: one page of each
+	ringSampleSize = maxPerPage
+
+	// ringMinSample is the fewest stargazers and social accounts the
+	// overlap is judged on
+	ringMinSample = 20
+
+	// ringMembersChecked is how many shared accounts have their stars read
+	ringMembersChecked = 5
+
+	// ringOverlap and ringReciprocity are the overlap and the share of
+	// checked accounts starring each other's repos that suggest a ring
+	ringOverlap     = 0.5
+	ringReciprocity = 0.5
+)
+
+// GetStargazers lists up to n of a repo's stargazers, earliest star first
+func (c *GitHubClient) GetStargazers(ctx context.Context, owner, repo string, n int) (_ []GitHubUser, err error) {
+	ctx, span := c.startSpan(ctx, "github.stargazers", "repos/:owner/:repo/stargazers")
+	defer func() { endSpan(span, err) }()
+	return c.listUsers(ctx, fmt.Sprintf("%s/repos/%s/%s/stargazers", c.BaseURL, owner, repo), n)
+}
+
+// GetFollowers lists up to n of the user's followers. Unlike
+// GetRecentFollowers it needs no token, but carries no account dates.
+func (c *GitHubClient) GetFollowers(ctx context.Context, login string, n int) (_ []GitHubUser, err error) {
+	ctx, span := c.startSpan(ctx, "github.followers", "users/:user/followers")
+	defer func() { endSpan(span, err) }()
+	return c.listUsers(ctx, fmt.Sprintf("%s/users/%s/followers", c.BaseURL, url.PathEscape(login)), n)
+}
+
+// GetFollowing lists up to n of the accounts the user follows
+func (c *GitHubClient) GetFollowing(ctx context.Context, login string, n int) (_ []GitHubUser, err error) {
+	ctx, span := c.startSpan(ctx, "github.following", "users/:user/following")
+	defer func() { endSpan(span, err) }()
+	return c.listUsers(ctx, fmt.Sprintf("%s/users/%s/following", c.BaseURL, url.PathEscape(login)), n)
+}
+
+// GetStarred lists up to n of the repos the user has starred, most recent
+// star first
+func (c *GitHubClient) GetStarred(ctx context.Context, login string, n int) (_ []GitHubRepo, err error) {
+	ctx, span := c.startSpan(ctx, "github.starred", "users/:user/starred")
+	defer func() { endSpan(span, err) }()
+
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(min(n, maxPerPage)))
+
+	data, err := c.get(ctx, fmt.Sprintf("%s/users/%s/starred?%s", c.BaseURL, url.PathEscape(login), query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	repos, _, err := decodeElements[GitHubRepo](data)
+	return repos, err
+}
+
+// listUsers reads one page of up to n users from a list endpoint
+func (c *GitHubClient) listUsers(ctx context.Context, endpoint string, n int) ([]GitHubUser, error) {
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(min(n, maxPerPage)))
+
+	data, err := c.get(ctx, endpoint+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	users, _, err := decodeElements[GitHubUser](data)
+	return users, err
+}
+
+// loginSet collects users by lowercased login, leaving out self and bots
+func loginSet(self string, lists ...[]GitHubUser) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, users := range lists {
+		for _, user := range users {
+			if user.Login == "" || isBotLogin(user) || strings.EqualFold(user.Login, self) {
+				continue
+			}
+			set[strings.ToLower(user.Login)] = struct{}{}
+		}
+	}
+	return set
+}
+
+// ringMember is a shared account and the other shared accounts whose
+// repos it stars
+type ringMember struct {
+	login string
+	stars []string
+}
+
+// reciprocalStars reads the stars of up to ringMembersChecked shared
+// accounts, drawn at random from rng, and notes which star repos owned by
+// another shared account. Stars of the analyzed user's own repos are what
+// made them shared, so they don't count.
+func (a *Analyzer) reciprocalStars(ctx context.Context, rng *rand.Rand, shared []string) []ringMember {
+	members := map[string]struct{}{}
+	for _, login := range shared {
+		members[login] = struct{}{}
+	}
+
+	checked := make([]ringMember, 0, ringMembersChecked)
+	for _, login := range sampleOrder(rng, shared) {
+		if len(checked) == ringMembersChecked || ctx.Err() != nil {
+			break
+		}
+		starred, err := a.client.GetStarred(ctx, login, maxPerPage)
+		if err != nil {
+			continue
+		}
+		member := ringMember{login: login}
+		for _, repo := range starred {
+			owner, _, _ := strings.Cut(strings.ToLower(repo.FullName), "/")
+			if _, ok := members[owner]; ok && owner != login {
+				member.stars = append(member.stars, repo.FullName)
+			}
+		}
+		checked = append(checked, member)
+	}
+	return checked
+}
+
+// checkEngagementRings compares a sample of the flagships' stargazers with
+// the user's followers and following. Organic audiences overlap a little;
+// a ring of accounts that star and follow back overlaps most of the way,
+// and its members star each other's repos too.
+func (a *Analyzer) checkEngagementRings(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !a.opts.EngagementRings || !r.log.coverage().repos {
+		return
+	}
+
+	var stargazers [][]GitHubUser
+	for _, repo := range r.flagships() {
+		if repo.Fork || repo.StargazersCount == 0 {
+			continue
+		}
+		owner, name := repoOwnerAndName(repo, r.username)
+		users, err := a.client.GetStargazers(ctx, owner, name, ringSampleSize)
+		if err != nil {
+			r.log.fellBack("engagement_rings", fmt.Errorf("failed to sample stargazers: %w", err))
+			return
+		}
+		stargazers = append(stargazers, users)
+	}
+	starred := loginSet(r.user.Login, stargazers...)
+	if len(starred) < ringMinSample {
+		return
+	}
+
+	followers, err := a.client.GetFollowers(ctx, r.user.Login, ringSampleSize)
+	if err != nil {
+		r.log.fellBack("engagement_rings", fmt.Errorf("failed to sample followers: %w", err))
+		return
+	}
+	following, err := a.client.GetFollowing(ctx, r.user.Login, ringSampleSize)
+	if err != nil {
+		r.log.fellBack("engagement_rings", fmt.Errorf("failed to sample following: %w", err))
+		return
+	}
+	social := loginSet(r.user.Login, followers, following)
+	if len(social) < ringMinSample {
+		return
+	}
+
+	overlap := jaccard(starred, social)
+	if overlap < ringOverlap {
+		// Too little overlap to be worth reading stars; it bounds the score
+		r.acc.metrics.EngagementRingScore = overlap
+		return
+	}
+
+	shared := make([]string, 0, len(starred))
+	for login := range starred {
+		if _, ok := social[login]; ok {
+			shared = append(shared, login)
+		}
+	}
+	members := a.reciprocalStars(ctx, r.rng, shared)
+	if len(members) == 0 {
+		r.acc.metrics.EngagementRingScore = overlap
+		return
+	}
+	var reciprocal []ringMember
+	for _, member := range members {
+		if len(member.stars) > 0 {
+			reciprocal = append(reciprocal, member)
+		}
+	}
+	reciprocity := float64(len(reciprocal)) / float64(len(members))
+	r.acc.metrics.EngagementRingScore = overlap * reciprocity
+	if reciprocity < ringReciprocity {
+		return
+	}
+
+	examples := make([]string, 0, len(reciprocal))
+	for _, member := range reciprocal {
+		examples = append(examples, fmt.Sprintf("%s stars %s", member.login, strings.Join(member.stars[:min(len(member.stars), 2)], ", ")))
+	}
+	r.addFinding(Finding{
+		Code:     "ENGAGEMENT_RING",
+		Severity: SeverityWarning,
+		Message:  "The flagships' stargazers are largely the accounts the user follows or is followed by, and they star each other's repos",
+		Evidence: []string{
+			fmt.Sprintf("%d of %d sampled stargazers follow or are followed by the user (%.0f%% overlap)", len(shared), len(starred), overlap*100),
+			fmt.Sprintf("%d of %d shared accounts checked star repos of other shared accounts", len(reciprocal), len(members)),
+			"e.g. " + strings.Join(examples, "; "),
+		},
+	})
+}
@@ -0,0 +1,212 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// ringMembersRead runs the engagement ring check on an account whose
+// flagship's 30 stargazers all follow it and star each other's repos,
+// listed in the order given, and returns whose stars it read
+func ringMembersRead(t *testing.T, members []string, opts ...Option) []string {
+	t.Helper()
+	f := newFakeGitHub(t, newAccount("hub", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, StargazersCount: 300, UpdatedAt: fakeNow.Add(-days(1))}))
+	users := make([]GitHubUser, 0, len(members))
+	for _, login := range members {
+		users = append(users, GitHubUser{Login: login, Type: "User"})
+	}
+	list := func(w http.ResponseWriter, r *http.Request) { _ = json.NewEncoder(w).Encode(users) }
+	f.route("/repos/hub/tool/stargazers", list)
+	f.route("/users/hub/followers", list)
+	f.route("/users/hub/following", list)
+
+	var mu sync.Mutex
+	var read []string
+	for i, login := range members {
+		f.route("/users/"+login+"/starred", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			read = append(read, login)
+			mu.Unlock()
+			other := members[(i+1)%len(members)]
+			_ = json.NewEncoder(w).Encode([]GitHubRepo{{Name: "lib", FullName: other + "/lib"}})
+		})
+	}
+
+	opts = append([]Option{WithDeepChecks(true), WithEngagementRings(true)}, opts...)
+	analysis, err := newFakeAnalyzer(f, opts...).Analyze("hub")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if finding(analysis, "ENGAGEMENT_RING") == nil {
+		t.Fatal("the ring wasn't found")
+	}
+	if len(read) != ringMembersChecked {
+		t.Fatalf("read the stars of %d members, want %d", len(read), ringMembersChecked)
+	}
+	return read
+}
+
+func TestRingSampleSeeded(t *testing.T) {
+	unspaced(t)
+	members := make([]string, 30)
+	for i := range members {
+		members[i] = fmt.Sprintf("ring%02d", i)
+	}
+	reversed := slices.Clone(members)
+	slices.Reverse(reversed)
+
+	// The same seed draws the same members however the API lists them
+	first := ringMembersRead(t, members, WithSeed(42))
+	second := ringMembersRead(t, reversed, WithSeed(42))
+	if !slices.Equal(first, second) {
+		t.Errorf("seed 42 read %q, then %q from the reversed listing", first, second)
+	}
+	if slices.Equal(first, members[:ringMembersChecked]) {
+		t.Errorf("read the first %d members in login order, want a random draw", ringMembersChecked)
+	}
+	if other := ringMembersRead(t, members, WithSeed(7)); slices.Equal(first, other) {
+		t.Errorf("seeds 42 and 7 both read %q", first)
+	}
+
+	// Unpinned, the seed comes from the login and date, so reruns match
+	if a, b := ringMembersRead(t, members), ringMembersRead(t, reversed); !slices.Equal(a, b) {
+		t.Errorf("same-day reruns read %q and %q", a, b)
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	set := func(keys ...string) map[string]struct{} {
+		s := map[string]struct{}{}
+		for _, key := range keys {
+			s[key] = struct{}{}
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		a, b map[string]struct{}
+		want float64
+	}{
+		{set(), set(), 0},
+		{nil, nil, 0},
+		{set("a"), set(), 0},
+		{set(), set("a", "b"), 0},
+		{set("a", "b"), set("c", "d"), 0},
+		{set("a", "b"), set("b", "a"), 1},
+		{set("a", "b", "c"), set("b", "c", "d"), 0.5},
+		{set("a"), set("a", "b", "c", "d"), 0.25},
+	} {
+		if got := jaccard(tc.a, tc.b); got != tc.want {
+			t.Errorf("jaccard(%v, %v) = %g, want %g", tc.a, tc.b, got, tc.want)
+		}
+		if got := jaccard(tc.b, tc.a); got != tc.want {
+			t.Errorf("jaccard(%v, %v) = %g, want %g: it isn't symmetric", tc.b, tc.a, got, tc.want)
+		}
+	}
+}
+
+func TestLoginSet(t *testing.T) {
+	got := loginSet("Hub",
+		[]GitHubUser{{Login: "Octo"}, {Login: "hub"}, {Login: "dependabot[bot]"}, {Login: "ci", Type: "Bot"}},
+		[]GitHubUser{{Login: "octo"}, {Login: ""}, {Login: "Mona"}},
+	)
+	want := map[string]struct{}{"octo": {}, "mona": {}}
+	if len(got) != len(want) {
+		t.Fatalf("loginSet = %v, want %v", got, want)
+	}
+	for login := range want {
+		if _, ok := got[login]; !ok {
+			t.Errorf("loginSet = %v, want %v", got, want)
+		}
+	}
+}
+
+// analyzeRingGraph analyzes hub, whose flagship is starred by stargazers
+// and who follows and is followed by social; with reciprocal, every
+// account in both stars a repo of the next one
+func analyzeRingGraph(t *testing.T, stargazers, social []string, reciprocal bool) (*Analysis, int) {
+	t.Helper()
+	f := newFakeGitHub(t, newAccount("hub", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, StargazersCount: 300, UpdatedAt: fakeNow.Add(-days(1))}))
+	users := func(logins []string) http.HandlerFunc {
+		list := make([]GitHubUser, 0, len(logins))
+		for _, login := range logins {
+			list = append(list, GitHubUser{Login: login, Type: "User"})
+		}
+		return func(w http.ResponseWriter, r *http.Request) { _ = json.NewEncoder(w).Encode(fakePage(list, r)) }
+	}
+	f.route("/repos/hub/tool/stargazers", users(stargazers))
+	f.route("/users/hub/followers", users(social))
+	f.route("/users/hub/following", users(social))
+
+	var shared []string
+	for _, login := range stargazers {
+		if slices.Contains(social, login) {
+			shared = append(shared, login)
+		}
+	}
+	var read atomic.Int32
+	for _, login := range slices.Concat(stargazers, social) {
+		f.route("/users/"+login+"/starred", func(w http.ResponseWriter, r *http.Request) {
+			read.Add(1)
+			starred := []GitHubRepo{}
+			if i := slices.Index(shared, login); reciprocal && i >= 0 {
+				other := shared[(i+1)%len(shared)]
+				starred = append(starred, GitHubRepo{Name: "lib", FullName: other + "/lib"})
+			}
+			_ = json.NewEncoder(w).Encode(starred)
+		})
+	}
+
+	analysis, err := newFakeAnalyzer(f, WithDeepChecks(true), WithEngagementRings(true)).Analyze("hub")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	return analysis, int(read.Load())
+}
+
+func TestEngagementRingGraphs(t *testing.T) {
+	unspaced(t)
+	logins := func(prefix string, n int) []string {
+		list := make([]string, n)
+		for i := range list {
+			list[i] = fmt.Sprintf("%s%02d", prefix, i)
+		}
+		return list
+	}
+	ring := logins("ring", 30)
+	for _, tc := range []struct {
+		name       string
+		stargazers []string
+		social     []string
+		reciprocal bool
+		score      float64
+		read       int
+		finding    bool
+	}{
+		// Six of 54 accounts in both, too few to read anyone's stars
+		{"organic", slices.Concat(logins("fan", 24), ring[:6]), slices.Concat(logins("pal", 24), ring[:6]), true, 6.0 / 54, 0, false},
+		{"follow-back without star rings", ring, ring, false, 0, ringMembersChecked, false},
+		{"ring", ring, ring, true, 1, ringMembersChecked, true},
+		// Two thirds shared, and every member checked stars another's repo
+		{"ring among fans", slices.Concat(ring[:20], logins("fan", 5)), slices.Concat(ring[:20], logins("pal", 5)), true, 20.0 / 30, ringMembersChecked, true},
+		{"sample too small", ring[:ringMinSample-1], ring[:ringMinSample-1], true, 0, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			analysis, read := analyzeRingGraph(t, tc.stargazers, tc.social, tc.reciprocal)
+			if got := analysis.Metrics.EngagementRingScore; math.Abs(got-tc.score) > 1e-9 {
+				t.Errorf("EngagementRingScore = %g, want %g", got, tc.score)
+			}
+			if read != tc.read {
+				t.Errorf("read the stars of %d accounts, want %d", read, tc.read)
+			}
+			if found := finding(analysis, "ENGAGEMENT_RING") != nil; found != tc.finding {
+				t.Errorf("ENGAGEMENT_RING found %t, want %t", found, tc.finding)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package ebert
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Rule is one check that turns an account's data into findings. The
+// built-in rules are the checks Analyze runs on data it has already
+// fetched; they are exported so other tools can run any subset of them on
+// data they hold themselves.
+type Rule interface {
+	// Code is the finding code the rule emits, the one suppressions name
+	Code() string
+	Description() string
+	Evaluate(RuleContext) []Finding
+}
+
+// RuleContext is what rules evaluate: a user with their repos and events
+// folded into metrics. Build one with NewRuleContext.
+type RuleContext struct {
+	run  *analysisRun
+	opts *AnalyzerOptions
+}
+
+// NewRuleContext folds a user's repos and events into the metrics the
+// built-in rules read, as Analyze would after fetching them. A nil repos
+// or events slice marks that source as missing, so rules that need it
+// stay silent.
+func NewRuleContext(user *GitHubUser, repos []GitHubRepo, events []GitHubEvent, opts ...Option) (RuleContext, error) {
+	if user == nil {
+		return RuleContext{}, errors.New("user must not be nil")
+	}
+	options := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return RuleContext{}, err
+		}
+	}
+
+	now := options.now()
+	username := NormalizeUsername(user.Login)
+	seed := sampleSeed(options.Seed, username, now)
+	r := &analysisRun{
+		username: username,
+		now:      now,
+		seed:     seed,
+		rng:      sampleRand(seed),
+		user:     user,
+		acc:      newMetricsAccumulator(user, now, &options),
+	}
+	if repos != nil {
+		r.acc.addRepos(repos)
+		r.log.ok("repos")
+	}
+	if events != nil {
+		r.acc.addEvents(events)
+		r.acc.eventsCoverage()
+		r.acc.metrics.CommitCountMethod = CommitCountEvents
+		r.acc.metrics.CommitCountLowerBound = r.acc.metrics.EventsReceived >= eventsFeedCeiling
+		r.log.ok("events")
+	}
+
+	c := RuleContext{run: r, opts: &options}
+	c.deriveMetrics()
+	return c, nil
+}
+
+// User is the account under evaluation
+func (c RuleContext) User() GitHubUser {
+	return *c.run.user
+}
+
+// Metrics are the figures the rules read
+func (c RuleContext) Metrics() Metrics {
+	return c.run.acc.metrics
+}
+
+// deriveMetrics fills the metrics that come from looking across the
+// fetched data rather than from one record at a time
+func (c RuleContext) deriveMetrics() {
+	c.countActiveFlagships()
+	c.countRepoChurn()
+	c.inferTimezone()
+}
+
+// rule is a built-in Rule
+type rule struct {
+	code        string
+	description string
+	evaluate    func(RuleContext) []Finding
+}
+
+func (r rule) Code() string                     { return r.code }
+func (r rule) Description() string              { return r.description }
+func (r rule) Evaluate(c RuleContext) []Finding { return r.evaluate(c) }
+
+// flagRule is a rule emitting at most one finding without evidence. The
+// message is a format and its arguments, kept on the finding so the
+// printed report can be localized.
+func flagRule(code string, severity Severity, description string, message func(RuleContext) (string, []any, bool)) rule {
+	return rule{code: code, description: description, evaluate: func(c RuleContext) []Finding {
+		format, args, ok := message(c)
+		if !ok {
+			return nil
+		}
+		finding := Finding{Code: code, Severity: severity, Message: fmt.Sprintf(format, args...)}
+		for _, arg := range args {
+			finding.Args = append(finding.Args, fmt.Sprint(arg))
+		}
+		return []Finding{finding}
+	}}
+}
+
+// BuiltinRules lists every rule Analyze runs on already-fetched data. The
+// checks that make requests of their own aren't rules.
+func BuiltinRules() []Rule {
+	return append(slices.Clone(flagRules),
+		flagshipArchivedRule, dormantPopularRule, confusableNameRule, lookalikeNameRule, timezoneMismatchRule, repoChurnRule)
+}
+
+// flagRules are the rules evaluated once every stage has run
+var flagRules = []Rule{
+	noPublicCodeRule,
+	newAccountRule, youngAccountRule, establishedAccountRule,
+	lowFollowersRule, strongFollowingRule,
+	lowActivityRule, activeContributorRule, forcePushesRule, eventsTruncatedRule,
+	archivedRatioRule, paddedRule,
+	noContactInfoRule, affiliatedRule, hasWebsiteRule,
+	noRecentUpdatesRule, lowEngagementRule,
+}
+
+var (
+	newAccountRule = rule{
+		code:        "NEW_ACCOUNT",
+		description: "Account is younger than the new-account threshold, so its scores are capped and low-confidence",
+		evaluate: func(c RuleContext) []Finding {
+			metrics := c.Metrics()
+			if !isNewAccount(metrics, c.opts.NewAccountThreshold) {
+				return nil
+			}
+			return []Finding{newAccountFinding(metrics, c.opts.NewAccountThreshold)}
+		},
+	}
+	youngAccountRule = flagRule("YOUNG_ACCOUNT", SeverityRedFlag, "Account is under six months old",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			age := metrics.AccountAgeDays
+			return "Account only %d months old - limited history", []any{age / 30},
+				!isNewAccount(metrics, c.opts.NewAccountThreshold) && age < 180
+		})
+	establishedAccountRule = flagRule("ESTABLISHED_ACCOUNT", SeverityPositive, "Account is over a year old",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			age := metrics.AccountAgeDays
+			return "Established account (%d years)", []any{age / 365},
+				!isNewAccount(metrics, c.opts.NewAccountThreshold) && age > 365
+		})
+
+	lowFollowersRule = flagRule("LOW_FOLLOWERS", SeverityWarning, "Fewer than 10 followers",
+		func(c RuleContext) (string, []any, bool) {
+			return "Low follower count - limited community validation", nil, c.Metrics().Followers < 10
+		})
+	strongFollowingRule = flagRule("STRONG_FOLLOWING", SeverityPositive, "More than 100 followers",
+		func(c RuleContext) (string, []any, bool) {
+			followers := c.Metrics().Followers
+			return "Strong community following (%d followers)", []any{followers}, followers > 100
+		})
+
+	lowActivityRule = flagRule("LOW_ACTIVITY", SeverityWarning, "Fewer than 10 commits in the activity window",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "Low recent activity (last %d days)", []any{metrics.ActivityWindowDays},
+				c.coverage().events && metrics.RecentCommits < 10 && !metrics.CommitCountLowerBound && !c.run.noPublicCode()
+		})
+	activeContributorRule = flagRule("ACTIVE_CONTRIBUTOR", SeverityPositive, "More than 50 commits in the activity window",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "Active contributor (%d commits in %d days)", []any{metrics.RecentCommits, metrics.ActivityWindowDays},
+				c.coverage().events && metrics.RecentCommits > 50
+		})
+	forcePushesRule = flagRule("FREQUENT_FORCE_PUSHES", SeverityWarning, "Repeated force pushes rewriting history in the activity window",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "%d force pushes in the last %d days - history is being rewritten", []any{metrics.ForcePushes, metrics.ActivityWindowDays},
+				c.coverage().events && metrics.ForcePushes >= forcePushWarnThreshold
+		})
+	eventsTruncatedRule = flagRule("EVENTS_TRUNCATED", SeverityInfo, "The events feed stopped short of the activity window",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "Events cover %d days of the requested %d - event-based activity figures are partial",
+				[]any{metrics.EventsCoverageDays, metrics.ActivityWindowDays}, c.coverage().events && metrics.EventsCoverageDays > 0
+		})
+
+	archivedRatioRule = flagRule("HIGH_ARCHIVED_RATIO", SeverityRedFlag, "Many repos archived recently, or old archives with no flagship maintained",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "High proportion of archived repos (%d/%d)", []any{metrics.Archived, metrics.Repos},
+				c.coverage().repos && windingDown(metrics)
+		})
+
+	noContactInfoRule = flagRule("NO_CONTACT_INFO", SeverityWarning, "Profile has no company, website or email",
+		func(c RuleContext) (string, []any, bool) {
+			user := c.run.user
+			return "No verifiable contact information or affiliation", nil, user.Company == "" && user.Blog == "" && user.Email == ""
+		})
+	affiliatedRule = flagRule("AFFILIATED", SeverityPositive, "Profile names a company",
+		func(c RuleContext) (string, []any, bool) {
+			return "Affiliated with: %s", []any{c.run.user.Company}, c.run.user.Company != ""
+		})
+	hasWebsiteRule = flagRule("HAS_WEBSITE", SeverityPositive, "Profile links a website or blog",
+		func(c RuleContext) (string, []any, bool) {
+			return "Has published website/blog", nil, c.run.user.Blog != ""
+		})
+
+	noRecentUpdatesRule = flagRule("NO_RECENT_UPDATES", SeverityRedFlag, "No repo updated in the last 30 days",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "No repositories updated in last 30 days", nil, c.coverage().repos && metrics.RecentlyUpdated == 0 && metrics.Repos > 0
+		})
+	lowEngagementRule = flagRule("LOW_ENGAGEMENT", SeverityWarning, "Fewer than 10 stars across more than 5 repos",
+		func(c RuleContext) (string, []any, bool) {
+			metrics := c.Metrics()
+			return "Low community engagement (stars/repos ratio)", nil, c.coverage().repos && metrics.Stars < 10 && metrics.Repos > 5
+		})
+)
+
+// coverage says which sources the context was built from
+func (c RuleContext) coverage() coverage {
+	return c.run.log.coverage()
+}
+
+// applyRules evaluates rules against the run and records their findings
+func (a *Analyzer) applyRules(r *analysisRun, rules ...Rule) {
+	c := a.ruleContext(r)
+	for _, rule := range rules {
+		findings := rule.Evaluate(c)
+		if indexed, ok := rule.(IndexedRule); ok {
+			for i := range findings {
+				findings[i].Index = cmp.Or(findings[i].Index, indexed.Index())
+			}
+		}
+		r.findings = append(r.findings, findings...)
+	}
+}
+
+func (a *Analyzer) ruleContext(r *analysisRun) RuleContext {
+	return RuleContext{run: r, opts: &a.opts}
+}
@@ -0,0 +1,69 @@
+package ebert_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// builtinRule is the built-in rule emitting code
+func builtinRule(t *testing.T, code string) ebert.Rule {
+	t.Helper()
+	for _, rule := range ebert.BuiltinRules() {
+		if rule.Code() == code {
+			return rule
+		}
+	}
+	t.Fatalf("no built-in rule %s", code)
+	return nil
+}
+
+func TestRunOneRule(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	user := &ebert.GitHubUser{Login: "octo", CreatedAt: now.AddDate(-4, 0, 0), Type: "User"}
+	repos := []ebert.GitHubRepo{
+		{Name: "tool", FullName: "octo/tool", Language: "Go", Size: 500, UpdatedAt: now.AddDate(0, 0, -3)},
+		// A Cyrillic а in the name
+		{Name: "pаypal-sdk", FullName: "octo/pаypal-sdk", Language: "Go", Size: 40, UpdatedAt: now.AddDate(0, 0, -9)},
+	}
+	c, err := ebert.NewRuleContext(user, repos, nil, ebert.WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Metrics().Repos; got != 2 {
+		t.Errorf("the context counted %d repos, want 2", got)
+	}
+
+	findings := builtinRule(t, "CONFUSABLE_NAME").Evaluate(c)
+	if len(findings) != 1 {
+		t.Fatalf("CONFUSABLE_NAME found %+v, want one finding", findings)
+	}
+	finding := findings[0]
+	if finding.Code != "CONFUSABLE_NAME" || finding.Severity != ebert.SeverityRedFlag ||
+		len(finding.Evidence) != 1 || !strings.Contains(finding.Evidence[0], "pаypal-sdk") {
+		t.Errorf("finding = %+v, want a red flag naming the repo", finding)
+	}
+
+	// Without events the activity rules have nothing to say
+	if findings := builtinRule(t, "LOW_ACTIVITY").Evaluate(c); len(findings) != 0 {
+		t.Errorf("LOW_ACTIVITY found %+v without events", findings)
+	}
+	if _, err := ebert.NewRuleContext(nil, repos, nil); err == nil {
+		t.Error("NewRuleContext accepted a nil user")
+	}
+}
+
+func TestBuiltinRulesListed(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rule := range ebert.BuiltinRules() {
+		if seen[rule.Code()] {
+			t.Errorf("rule %s is listed twice", rule.Code())
+		}
+		seen[rule.Code()] = true
+		if rule.Code() != strings.ToUpper(rule.Code()) || rule.Description() == "" {
+			t.Errorf("rule %q has description %q, want an upper-case code described", rule.Code(), rule.Description())
+		}
+	}
+}
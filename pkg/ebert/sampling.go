@@ -0,0 +1,118 @@
+package ebert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// DefaultOrgMaxRepos caps how many repos of an organization are analyzed
+// when MaxRepos is left at zero; user accounts are unlimited by default
+const DefaultOrgMaxRepos = 1000
+
+// minSampleConfidence is the most a sampled analysis loses in confidence
+const minSampleConfidence = 0.5
+
+// errRepoLimit stops repo paging once the sample is full
+var errRepoLimit = errors.New("repo limit reached")
+
+// SearchTopRepos returns up to n of the user's most-starred repos via the
+// search API, which shares the search rate limiter
+func (c *GitHubClient) SearchTopRepos(ctx context.Context, username string, n int) (_ []GitHubRepo, _ int, err error) {
+	ctx, span := c.startSpan(ctx, "github.search_repos", "search/repositories")
+	defer func() { endSpan(span, err) }()
+
+	var repos []GitHubRepo
+	skipped := 0
+	for page := 1; len(repos) < n; page++ {
+		if err := c.shared().search.wait(ctx); err != nil {
+			return nil, skipped, err
+		}
+
+		query := url.Values{}
+		query.Set("q", "user:"+username)
+		query.Set("sort", "stars")
+		query.Set("order", "desc")
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+
+		data, err := c.get(ctx, c.BaseURL+"/search/repositories?"+query.Encode())
+		if err != nil {
+			return nil, skipped, err
+		}
+
+		var result struct {
+			Items json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, skipped, err
+		}
+		items, bad, err := decodeElements[GitHubRepo](result.Items)
+		if err != nil {
+			return nil, skipped, err
+		}
+		skipped += bad
+
+		repos = append(repos, items...)
+		if len(items)+bad < 100 {
+			break
+		}
+	}
+
+	if len(repos) > n {
+		repos = repos[:n]
+	}
+	return repos, skipped, nil
+}
+
+// maxRepos is the repo cap for user, or zero for no cap
+func (a *Analyzer) maxRepos(user *GitHubUser) int {
+	return repoCap(user, a.opts)
+}
+
+// repoCap is the repo cap opts set for user, or zero for no cap
+func repoCap(user *GitHubUser, opts AnalyzerOptions) int {
+	switch {
+	case opts.MaxRepos < 0:
+		return 0
+	case opts.MaxRepos > 0:
+		return opts.MaxRepos
+	case user.Type == "Organization":
+		return DefaultOrgMaxRepos
+	default:
+		return 0
+	}
+}
+
+// repoSample tracks which repos have gone into a capped analysis
+type repoSample struct {
+	limit int
+	seen  map[string]struct{}
+}
+
+// take filters repos down to those not yet sampled, up to the limit,
+// reporting whether the sample is now full
+func (s *repoSample) take(repos []GitHubRepo) ([]GitHubRepo, bool) {
+	kept := repos[:0:0]
+	for _, repo := range repos {
+		if len(s.seen) >= s.limit {
+			break
+		}
+		if _, ok := s.seen[repo.FullName]; ok {
+			continue
+		}
+		s.seen[repo.FullName] = struct{}{}
+		kept = append(kept, repo)
+	}
+	return kept, len(s.seen) >= s.limit
+}
+
+// sampleConfidence scales confidence by the share of repos analyzed
+func sampleConfidence(metrics Metrics) float64 {
+	if !metrics.ReposSampled || metrics.ReposTotal == 0 {
+		return 1
+	}
+	return clamp(float64(metrics.Repos)/float64(metrics.ReposTotal), minSampleConfidence, 1)
+}
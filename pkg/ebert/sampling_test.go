@@ -0,0 +1,147 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRepoCap(t *testing.T) {
+	user := &GitHubUser{Type: "User"}
+	org := &GitHubUser{Type: "Organization"}
+	for _, tt := range []struct {
+		name     string
+		user     *GitHubUser
+		maxRepos int
+		want     int
+	}{
+		{"user default", user, 0, 0},
+		{"org default", org, 0, DefaultOrgMaxRepos},
+		{"user capped", user, 200, 200},
+		{"org capped", org, 5000, 5000},
+		// A negative cap lifts the organization default too
+		{"org unlimited", org, -1, 0},
+	} {
+		opts := defaultOptions()
+		opts.MaxRepos = tt.maxRepos
+		if got := repoCap(tt.user, opts); got != tt.want {
+			t.Errorf("%s: repoCap = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepoSampleTake(t *testing.T) {
+	repos := func(names ...string) []GitHubRepo {
+		var out []GitHubRepo
+		for _, name := range names {
+			out = append(out, GitHubRepo{FullName: "octo/" + name})
+		}
+		return out
+	}
+	s := &repoSample{limit: 4, seen: map[string]struct{}{}}
+	if kept, full := s.take(repos("a", "b")); len(kept) != 2 || full {
+		t.Errorf("first take kept %d, full %t", len(kept), full)
+	}
+	// Repeats from the search are dropped, and the limit stops the rest
+	kept, full := s.take(repos("b", "c", "a", "d", "e"))
+	var names []string
+	for _, repo := range kept {
+		names = append(names, repo.FullName)
+	}
+	if !slices.Equal(names, []string{"octo/c", "octo/d"}) || !full {
+		t.Errorf("second take kept %q, full %t; want c and d, full", names, full)
+	}
+}
+
+// bigAccount has n repos listed newest first, the older the more starred
+func bigAccount(n int) *fakeAccount {
+	repos := make([]GitHubRepo, n)
+	for i := range repos {
+		repos[i] = GitHubRepo{Name: fmt.Sprintf("repo-%03d", i), Language: "Go", Size: 100, StargazersCount: i, UpdatedAt: fakeNow.Add(-days(i))}
+	}
+	return newAccount("octo", days(4000), repos...)
+}
+
+func TestSampledAnalysis(t *testing.T) {
+	account := bigAccount(300)
+	f := newFakeGitHub(t, account)
+	var searches int
+	f.route("/search/repositories", func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		if q := r.URL.Query(); q.Get("q") != "user:octo" || q.Get("sort") != "stars" || q.Get("order") != "desc" {
+			t.Errorf("searched %q, want octo's repos by stars", r.URL.RawQuery)
+		}
+		top := slices.Clone(account.Repos)
+		slices.Reverse(top)
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": top[:100]})
+	})
+
+	full, err := newFakeAnalyzer(f, WithMaxRepos(-1)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if full.Metrics.ReposSampled || full.Metrics.Repos != 300 || searches != 0 {
+		t.Fatalf("uncapped: sampled %t, %d repos, %d searches", full.Metrics.ReposSampled, full.Metrics.Repos, searches)
+	}
+
+	detailed, err := newFakeAnalyzer(f, WithMaxRepos(100)).AnalyzeDetailed("octo")
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed: %v", err)
+	}
+	metrics := detailed.Metrics
+	if !metrics.ReposSampled || metrics.Repos != 100 || metrics.ReposTotal != 300 {
+		t.Errorf("sampled %t, %d of %d repos; want 100 of 300", metrics.ReposSampled, metrics.Repos, metrics.ReposTotal)
+	}
+	// The 50 most starred, then the 50 most recently updated
+	var want []string
+	for i := 299; i >= 250; i-- {
+		want = append(want, fmt.Sprintf("octo/repo-%03d", i))
+	}
+	for i := range 50 {
+		want = append(want, fmt.Sprintf("octo/repo-%03d", i))
+	}
+	var got []string
+	for _, repo := range detailed.Raw.Repos {
+		got = append(got, repo.FullName)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("sampled %q, want the top-starred half then the newest", got)
+	}
+
+	statuses := map[string]string{}
+	for _, source := range detailed.DataSources {
+		statuses[source.Name] = source.Status
+	}
+	if statuses["repo_search"] != SourceOK || statuses["repo_sample"] != SourceFallback || detailed.Partial {
+		t.Errorf("sources = %v, partial %t; want the search and the sample recorded, and a complete analysis", statuses, detailed.Partial)
+	}
+	var report strings.Builder
+	FprintAnalysis(&report, detailed.Analysis)
+	if !strings.Contains(report.String(), "Repositories:       100 sampled of 300") {
+		t.Errorf("the report doesn't label the repo count as a sample:\n%s", report.String())
+	}
+	// A third of the repos costs confidence down to its floor
+	if want := full.Confidence * minSampleConfidence; math.Abs(detailed.Confidence-want) > 1e-9 {
+		t.Errorf("confidence = %v sampled, %v in full; want %v", detailed.Confidence, full.Confidence, want)
+	}
+}
+
+func TestSampleConfidence(t *testing.T) {
+	for _, tt := range []struct {
+		metrics Metrics
+		want    float64
+	}{
+		{Metrics{Repos: 300}, 1},
+		{Metrics{Repos: 900, ReposTotal: 1000, ReposSampled: true}, 0.9},
+		{Metrics{Repos: 100, ReposTotal: 1000, ReposSampled: true}, minSampleConfidence},
+		{Metrics{ReposSampled: true}, 1},
+	} {
+		if got := sampleConfidence(tt.metrics); got != tt.want {
+			t.Errorf("sampleConfidence(%d of %d) = %v, want %v", tt.metrics.Repos, tt.metrics.ReposTotal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// securityPolicyNames are the filenames GitHub recognizes as a security policy
+var securityPolicyNames = []string{"SECURITY.md", "security.md", "SECURITY.txt", "SECURITY"}
+
+// securityPolicyDirs are where GitHub looks for a security policy besides the root
+var securityPolicyDirs = []string{".github", "docs"}
+
+var (
+	policyEmailPattern    = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	policyAdvisoryPattern = regexp.MustCompile(`(?i)security/advisories|private vulnerability report|report a vulnerability`)
+	policyBountyPattern   = regexp.MustCompile(`(?i)bug bounty|hackerone|bugcrowd|intigriti`)
+)
+
+// securityPolicyPath finds a repo's security policy in the root, .github
+// or docs directory. A community profile that reports the policy saves
+// listing the directories.
+func (a *Analyzer) securityPolicyPath(ctx context.Context, r *analysisRun, repo GitHubRepo) (string, bool) {
+	if profile := r.communityProfiles[repo.FullName]; profile != nil && profile.Files.Security != nil {
+		if path, ok := profile.Files.Security.path(); ok {
+			return path, true
+		}
+	}
+	root := a.directory(ctx, r, repo, "")
+	if path, ok := hasEntry(root, securityPolicyNames...); ok {
+		return path, true
+	}
+	for _, dir := range securityPolicyDirs {
+		if _, ok := hasEntry(root, dir); !ok {
+			continue
+		}
+		if path, ok := hasEntry(a.directory(ctx, r, repo, dir), securityPolicyNames...); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// disclosureChannels lists the ways a security policy says to report a
+// vulnerability
+func disclosureChannels(policy string) []string {
+	var channels []string
+	if policyEmailPattern.MatchString(policy) {
+		channels = append(channels, "email")
+	}
+	if policyAdvisoryPattern.MatchString(policy) {
+		channels = append(channels, "security advisory")
+	}
+	if policyBountyPattern.MatchString(policy) {
+		channels = append(channels, "bug bounty")
+	}
+	return channels
+}
+
+// checkSecurityPolicy looks for security policies in the account-level
+// .github repo and the flagship repos and works out whether any names a
+// disclosure channel
+func (a *Analyzer) checkSecurityPolicy(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().repos || r.noPublicCode() {
+		return
+	}
+
+	repos := r.checkable()
+	if meta := r.acc.communityRepo; meta != nil && !slices.ContainsFunc(repos, func(repo GitHubRepo) bool {
+		return repo.FullName == meta.FullName
+	}) {
+		repos = append([]GitHubRepo{*meta}, repos...)
+	}
+
+	metrics := &r.acc.metrics
+	var policies, noContact []string
+
+	for _, repo := range repos {
+		if repo.PrivateVulnerabilityReporting != nil && *repo.PrivateVulnerabilityReporting {
+			metrics.ReposWithPrivateReporting++
+			metrics.HasDisclosureContact = true
+		}
+
+		path, ok := a.securityPolicyPath(ctx, r, repo)
+		if !ok {
+			continue
+		}
+		metrics.ReposWithSecurityPolicy++
+
+		var channels []string
+		if r.contentsBudget.take() {
+			owner, name := repoOwnerAndName(repo, r.username)
+			if policy, err := a.client.GetFile(ctx, owner, name, path); err == nil {
+				channels = disclosureChannels(string(policy))
+			}
+		}
+
+		if len(channels) == 0 {
+			noContact = append(noContact, repo.Name+"/"+path)
+			continue
+		}
+		metrics.HasDisclosureContact = true
+		policies = append(policies, fmt.Sprintf("%s/%s (%s)", repo.Name, path, strings.Join(channels, ", ")))
+	}
+
+	switch {
+	case len(policies) > 0:
+		r.addFinding(Finding{
+			Code:     "SECURITY_POLICY",
+			Severity: SeverityPositive,
+			Message:  "Security policy names a vulnerability disclosure channel",
+			Evidence: policies,
+		})
+	case metrics.ReposWithSecurityPolicy > 0:
+		r.addFinding(Finding{
+			Code:     "SECURITY_POLICY_NO_CONTACT",
+			Severity: SeverityWarning,
+			Message:  "Security policy found but it names no email, advisory link or bug bounty - add a disclosure contact",
+			Evidence: noContact,
+		})
+	case metrics.HasDisclosureContact:
+		// Private vulnerability reporting is a channel on its own
+	default:
+		r.addFinding(Finding{
+			Code:     "NO_SECURITY_POLICY",
+			Severity: SeverityWarning,
+			Message:  "No security policy found in any repository - no clear disclosure channel",
+		})
+	}
+}
+
+func (a *Analyzer) calculateSecurityScore(metrics Metrics) float64 {
+	score := 50.0
+
+	if metrics.ReposWithSecurityPolicy > 0 {
+		score -= 15
+	} else {
+		score += 15
+	}
+
+	if metrics.HasDisclosureContact {
+		score -= 10
+	}
+	if metrics.ReposWithPrivateReporting > 0 {
+		score -= 5
+	}
+
+	score += reviewScore(metrics)
+
+	return clamp(score, 0, 100)
+}
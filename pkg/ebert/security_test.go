@@ -0,0 +1,121 @@
+package ebert
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestDisclosureChannels(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		want   []string
+	}{
+		{"Please be responsible.", nil},
+		{"Mail security@example.com with details.", []string{"email"}},
+		{"Use https://github.com/octo/tool/security/advisories/new", []string{"security advisory"}},
+		{"We run a bug bounty on HackerOne; or write to sec@example.org", []string{"email", "bug bounty"}},
+	} {
+		if got := disclosureChannels(tt.policy); !slices.Equal(got, tt.want) {
+			t.Errorf("disclosureChannels(%q) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+// serveFile answers the raw content of path in octo/repo
+func serveFile(f *fakeGitHub, repo, path, content string) {
+	f.route("/repos/octo/"+repo+"/contents/"+path, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	})
+}
+
+func TestSecurityPolicy(t *testing.T) {
+	enabled := true
+	for _, tt := range []struct {
+		name  string
+		setup func(f *fakeGitHub)
+		// private turns private vulnerability reporting on for tool
+		private  bool
+		policies int
+		contact  bool
+		code     string
+		evidence []string
+	}{
+		{"root and account-level policies", func(f *fakeGitHub) {
+			serveDirectory(f, "tool", "", "README.md", "SECURITY.md")
+			serveFile(f, "tool", "SECURITY.md", "Mail security@example.com")
+			serveDirectory(f, ".github", "", "SECURITY.md")
+			serveFile(f, ".github", "SECURITY.md", "Report a vulnerability through GitHub.")
+		}, false, 2, true, "SECURITY_POLICY", []string{"tool/SECURITY.md (email)", ".github/SECURITY.md (security advisory)"}},
+		{"policy without a contact", func(f *fakeGitHub) {
+			serveDirectory(f, "lib", "", "README.md", ".github")
+			serveDirectory(f, "lib", ".github", "SECURITY.md")
+			serveFile(f, "lib", ".github/SECURITY.md", "Please be responsible.")
+		}, false, 1, false, "SECURITY_POLICY_NO_CONTACT", []string{"lib/.github/SECURITY.md"}},
+		// The profile names the file, so docs isn't listed
+		{"policy from the community profile", func(f *fakeGitHub) {
+			f.route("/repos/octo/lib/community/profile", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"health_percentage":60,"files":{"security":{"html_url":"https://github.com/octo/lib/blob/main/docs/SECURITY.md"}}}`))
+			})
+			serveFile(f, "lib", "docs/SECURITY.md", "Our bug bounty is on Bugcrowd.")
+		}, false, 1, true, "SECURITY_POLICY", []string{"lib/docs/SECURITY.md (bug bounty)"}},
+		{"private reporting alone", func(f *fakeGitHub) {}, true, 0, true, "", nil},
+		{"no policy", func(f *fakeGitHub) {}, false, 0, false, "NO_SECURITY_POLICY", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 500, UpdatedAt: fakeNow.Add(-days(5))}
+			if tt.private {
+				tool.PrivateVulnerabilityReporting = &enabled
+			}
+			f := newFakeGitHub(t, newAccount("octo", days(3000), tool,
+				GitHubRepo{Name: "lib", Language: "Go", Size: 900, StargazersCount: 400, UpdatedAt: fakeNow.Add(-days(9))},
+				GitHubRepo{Name: ".github", Size: 10, UpdatedAt: fakeNow.Add(-days(300))},
+			))
+			tt.setup(f)
+
+			analysis, err := newFakeAnalyzer(f).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			metrics := analysis.Metrics
+			if metrics.ReposWithSecurityPolicy != tt.policies || metrics.HasDisclosureContact != tt.contact {
+				t.Errorf("ReposWithSecurityPolicy = %d, HasDisclosureContact = %t; want %d and %t",
+					metrics.ReposWithSecurityPolicy, metrics.HasDisclosureContact, tt.policies, tt.contact)
+			}
+			if got := metrics.ReposWithPrivateReporting; got != 0 != tt.private {
+				t.Errorf("ReposWithPrivateReporting = %d with private reporting %t", got, tt.private)
+			}
+			for _, code := range []string{"SECURITY_POLICY", "SECURITY_POLICY_NO_CONTACT", "NO_SECURITY_POLICY"} {
+				flag := finding(analysis, code)
+				if code != tt.code {
+					if flag != nil {
+						t.Errorf("unexpected %+v", flag)
+					}
+					continue
+				}
+				if flag == nil || !slices.Equal(flag.Evidence, tt.evidence) {
+					t.Errorf("%s = %+v, want evidence %q", code, flag, tt.evidence)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityScore(t *testing.T) {
+	a := NewAnalyzer("")
+	none := a.calculateSecurityScore(Metrics{})
+	for _, tt := range []struct {
+		name    string
+		metrics Metrics
+		less    float64
+	}{
+		{"policy", Metrics{ReposWithSecurityPolicy: 1}, 30},
+		{"policy with a contact", Metrics{ReposWithSecurityPolicy: 1, HasDisclosureContact: true}, 40},
+		{"private reporting", Metrics{HasDisclosureContact: true, ReposWithPrivateReporting: 1}, 15},
+		{"everything", Metrics{ReposWithSecurityPolicy: 2, HasDisclosureContact: true, ReposWithPrivateReporting: 1}, 45},
+	} {
+		if got := a.calculateSecurityScore(tt.metrics); got != none-tt.less {
+			t.Errorf("%s: security risk = %v, want %v less than the %v without a policy", tt.name, got, tt.less, none)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// Package sigstoreebert verifies release signatures and provenance for
+// ebert against Sigstore, keeping sigstore-go out of builds that don't
+// verify.
+package sigstoreebert
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+)
+
+// Verifier checks signatures against a Sigstore trusted root
+type Verifier struct {
+	load func() (root.TrustedMaterial, error)
+
+	once     sync.Once
+	material root.TrustedMaterial
+	err      error
+}
+
+// New verifies against the public-good Fulcio and Rekor instances, whose
+// trusted root is fetched through TUF on first use
+func New() *Verifier {
+	return NewWithClient(nil)
+}
+
+// NewWithClient is New fetching the trusted root through hc, so the fetch
+// keeps to the caller's transport: its CA bundle and host allowlist. A
+// fetch the allowlist refuses fails with ebert.ErrHostNotAllowed, which
+// ebert counts as a skipped integration rather than a bad signature.
+func NewWithClient(hc *http.Client) *Verifier {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Verifier{load: func() (root.TrustedMaterial, error) {
+		client := &refusalClient{client: hc}
+		opts := tuf.DefaultOptions()
+		fetch := fetcher.NewDefaultFetcher()
+		fetch.SetHTTPClient(client)
+		opts.WithFetcher(fetch)
+
+		trusted, err := root.FetchTrustedRootWithOptions(opts)
+		if err != nil && client.refused != nil {
+			// TUF's errors don't always wrap the transport's
+			return nil, fmt.Errorf("%w: %w", client.refused, err)
+		}
+		return trusted, err
+	}}
+}
+
+// refusalClient remembers a request the host allowlist refused
+type refusalClient struct {
+	client  *http.Client
+	refused error
+}
+
+func (c *refusalClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if errors.Is(err, ebert.ErrHostNotAllowed) && c.refused == nil {
+		c.refused = err
+	}
+	return resp, err
+}
+
+// NewWithTrustedRoot verifies against the trusted root JSON at path, for
+// private Sigstore deployments, air-gapped runs and fixtures
+func NewWithTrustedRoot(path string) (*Verifier, error) {
+	trusted, err := root.NewTrustedRootFromPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted root: %w", err)
+	}
+	return &Verifier{load: func() (root.TrustedMaterial, error) { return trusted, nil }}, nil
+}
+
+func (v *Verifier) trustedMaterial() (root.TrustedMaterial, error) {
+	v.once.Do(func() {
+		v.material, v.err = v.load()
+		if v.err != nil {
+			v.err = fmt.Errorf("failed to fetch Sigstore trusted root: %w", v.err)
+		}
+	})
+	return v.material, v.err
+}
+
+// Verify checks a Sigstore bundle, an SLSA provenance file holding one, or
+// a cosign blob signature with its certificate
+func (v *Verifier) Verify(_ context.Context, artifact ebert.SignedArtifact) (*ebert.SignerIdentity, error) {
+	material, err := v.trustedMaterial()
+	if err != nil {
+		return nil, err
+	}
+
+	if artifact.Kind == ebert.SignatureCosign {
+		return verifyBlob(artifact, material)
+	}
+	return verifyBundle(artifact, material)
+}
+
+// verifyBundle checks a bundle's certificate chain, SCT and transparency
+// log entry, and that it was made for the signed asset or, for an
+// attestation, for release assets its statement names. A bundle that
+// verifies but binds to no asset is returned with ebert.ErrUnboundSignature.
+func verifyBundle(artifact ebert.SignedArtifact, material root.TrustedMaterial) (*ebert.SignerIdentity, error) {
+	data := artifact.Signature
+	if artifact.Kind == ebert.SignatureInToto {
+		// Provenance files hold one JSON document per line; the first is the
+		// release's attestation
+		data, _, _ = bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+		if envelope, ok := bareEnvelope(data); ok {
+			return verifyEnvelope(artifact, envelope, material)
+		}
+	}
+
+	var b bundle.Bundle
+	if err := b.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("not a Sigstore bundle: %w", err)
+	}
+
+	verifier, err := verify.NewVerifier(material,
+		verify.WithSignedCertificateTimestamps(1),
+		verify.WithTransparencyLog(1),
+		verify.WithObserverTimestamps(1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []subject
+	if envelope, err := b.Envelope(); err == nil && envelope != nil {
+		if statement, err := envelope.Statement(); err == nil {
+			for _, s := range statement.GetSubject() {
+				subjects = append(subjects, subject{Name: s.GetName(), Digest: s.GetDigest()})
+			}
+		}
+	}
+	digests := binding(artifact, subjects)
+
+	// Without digests to bind to, the signature is still checked so the
+	// finding can name who made it, but the result is reported unbound
+	artifactPolicy := verify.WithoutArtifactUnsafe()
+	if len(digests) > 0 {
+		artifactPolicy = verify.WithArtifactDigests(digests)
+	}
+	// Identity is judged by ebert against the repo, so any is accepted here
+	result, err := verifier.Verify(&b, verify.NewPolicy(artifactPolicy, verify.WithoutIdentitiesUnsafe()))
+	if err != nil {
+		return nil, err
+	}
+	if result.Signature == nil || result.Signature.Certificate == nil {
+		return nil, errors.New("bundle is signed with a key, not a certificate")
+	}
+	signer := identity(*result.Signature.Certificate)
+	if len(digests) == 0 {
+		return signer, unbound(artifact)
+	}
+	return signer, nil
+}
+
+// subject is an artifact an in-toto statement attests to
+type subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// binding is what a signature must have been made for: the digest GitHub
+// records for the asset it sits beside, or else the recorded digests of
+// the release assets its statement names, where they agree with it. The
+// statement is still unverified here; the verifier checks the signed one
+// holds every digest returned.
+func binding(artifact ebert.SignedArtifact, subjects []subject) []verify.ArtifactDigest {
+	if algorithm, digest, ok := parseDigest(artifact.ArtifactDigest); ok {
+		return []verify.ArtifactDigest{{Algorithm: algorithm, Digest: digest}}
+	}
+
+	var digests []verify.ArtifactDigest
+	for _, s := range subjects {
+		algorithm, digest, ok := parseDigest(artifact.ReleaseDigests[s.Name])
+		if ok && strings.EqualFold(s.Digest[algorithm], hex.EncodeToString(digest)) {
+			digests = append(digests, verify.ArtifactDigest{Algorithm: algorithm, Digest: digest})
+		}
+	}
+	return digests
+}
+
+// unbound is the error for a signature over none of the release's assets
+func unbound(artifact ebert.SignedArtifact) error {
+	if artifact.Kind == ebert.SignatureInToto {
+		return fmt.Errorf("%w: the attestation names none of the release's assets", ebert.ErrUnboundSignature)
+	}
+	return fmt.Errorf("%w: GitHub records no digest for the signed asset", ebert.ErrUnboundSignature)
+}
+
+// dsseEnvelope is a bare DSSE envelope, as SLSA generators write to
+// .intoto.jsonl, each signature carrying its Fulcio certificate
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     []byte `json:"payload"`
+	Signatures  []struct {
+		Sig  []byte `json:"sig"`
+		Cert string `json:"cert"`
+	} `json:"signatures"`
+}
+
+// inTotoPayloadType is the DSSE payload type of an in-toto statement
+const inTotoPayloadType = "application/vnd.in-toto+json"
+
+// bareEnvelope decodes data as a DSSE envelope rather than a bundle
+func bareEnvelope(data []byte) (*dsseEnvelope, bool) {
+	var envelope dsseEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.PayloadType == "" {
+		return nil, false
+	}
+	return &envelope, true
+}
+
+// verifyEnvelope checks a bare DSSE envelope's signature over its
+// statement and its certificate's chain to Fulcio, then binds the
+// statement to the release's assets. Like a detached cosign signature it
+// carries no transparency log entry, so the certificate is checked at its
+// issue time.
+func verifyEnvelope(artifact ebert.SignedArtifact, envelope *dsseEnvelope, material root.TrustedMaterial) (*ebert.SignerIdentity, error) {
+	if envelope.PayloadType != inTotoPayloadType {
+		return nil, fmt.Errorf("envelope holds %s, not an in-toto statement", envelope.PayloadType)
+	}
+	if len(envelope.Signatures) == 0 || envelope.Signatures[0].Cert == "" {
+		return nil, errors.New("envelope signature has no certificate, so it was made with a key ebert can't know")
+	}
+
+	cert, err := parseCertificate([]byte(envelope.Signatures[0].Cert))
+	if err != nil {
+		return nil, err
+	}
+	key, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported certificate key type %T", cert.PublicKey)
+	}
+	digest := sha256.Sum256(pae(envelope.PayloadType, envelope.Payload))
+	if !ecdsa.VerifyASN1(key, digest[:], envelope.Signatures[0].Sig) {
+		return nil, errors.New("signature doesn't match the envelope")
+	}
+	if _, err := verify.VerifyLeafCertificate(cert.NotBefore, cert, material); err != nil {
+		return nil, fmt.Errorf("certificate doesn't chain to Fulcio: %w", err)
+	}
+
+	summary, err := certificate.SummarizeCertificate(cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate identity: %w", err)
+	}
+	signer := identity(summary)
+
+	// The payload is signed now, so its subjects bind on their own
+	var statement struct {
+		Subject []subject `json:"subject"`
+	}
+	if err := json.Unmarshal(envelope.Payload, &statement); err != nil {
+		return nil, fmt.Errorf("failed to decode the attested statement: %w", err)
+	}
+	if len(binding(ebert.SignedArtifact{Kind: artifact.Kind, ReleaseDigests: artifact.ReleaseDigests}, statement.Subject)) == 0 {
+		return signer, unbound(artifact)
+	}
+	return signer, nil
+}
+
+// pae is DSSE's pre-authentication encoding of a payload, what its
+// signatures are made over
+func pae(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// verifyBlob checks a detached cosign signature over the artifact digest
+// and its certificate's chain to Fulcio. Detached signatures carry no
+// transparency log entry, so the certificate is checked at its issue time.
+func verifyBlob(artifact ebert.SignedArtifact, material root.TrustedMaterial) (*ebert.SignerIdentity, error) {
+	if len(artifact.Certificate) == 0 {
+		return nil, errors.New("signature has no certificate, so it was made with a key ebert can't know")
+	}
+	algorithm, digest, ok := parseDigest(artifact.ArtifactDigest)
+	if !ok || algorithm != "sha256" {
+		return nil, errors.New("GitHub records no digest for the signed asset")
+	}
+
+	cert, err := parseCertificate(artifact.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	signature := decodeMaybeBase64(artifact.Signature)
+
+	key, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported certificate key type %T", cert.PublicKey)
+	}
+	if !ecdsa.VerifyASN1(key, digest, signature) {
+		return nil, errors.New("signature doesn't match the artifact")
+	}
+	if _, err := verify.VerifyLeafCertificate(cert.NotBefore, cert, material); err != nil {
+		return nil, fmt.Errorf("certificate doesn't chain to Fulcio: %w", err)
+	}
+
+	summary, err := certificate.SummarizeCertificate(cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate identity: %w", err)
+	}
+	return identity(summary), nil
+}
+
+func identity(summary certificate.Summary) *ebert.SignerIdentity {
+	return &ebert.SignerIdentity{
+		Issuer:           summary.Issuer,
+		Subject:          summary.SubjectAlternativeName,
+		SourceRepository: summary.SourceRepositoryURI,
+	}
+}
+
+// parseDigest splits GitHub's "sha256:<hex>" asset digest
+func parseDigest(digest string) (string, []byte, bool) {
+	algorithm, value, ok := strings.Cut(digest, ":")
+	if !ok {
+		return "", nil, false
+	}
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return "", nil, false
+	}
+	return algorithm, decoded, true
+}
+
+// parseCertificate reads a PEM certificate, which cosign writes either
+// raw or base64 encoded
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(decodeMaybeBase64(data))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("certificate is not PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// decodeMaybeBase64 undoes the base64 encoding cosign applies to
+// signatures and certificates, leaving PEM and raw DER untouched
+func decodeMaybeBase64(data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		return data
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(string(data)); err == nil {
+		return decoded
+	}
+	return data
+}
@@ -0,0 +1,204 @@
+package sigstoreebert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// The fixtures in testdata are made by gen.go, around a fixture Fulcio CA
+// the trusted root lists
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func fixtureVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	v, err := NewWithTrustedRoot("testdata/trusted_root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func assetDigest(t *testing.T) string {
+	sum := sha256.Sum256(fixture(t, "tool_linux_amd64.tar.gz"))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestVerifyEnvelope(t *testing.T) {
+	v := fixtureVerifier(t)
+	provenance := fixture(t, "provenance.intoto.jsonl")
+
+	tampered := func() []byte {
+		var envelope map[string]any
+		if err := json.Unmarshal(provenance, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		envelope["payload"] = []byte(`{"subject":[{"name":"tool_linux_amd64.tar.gz","digest":{"sha256":"00"}}]}`)
+		data, _ := json.Marshal(envelope)
+		return data
+	}
+
+	for _, tt := range []struct {
+		name      string
+		signature []byte
+		digests   map[string]string
+		wantErr   string
+		unbound   bool
+	}{
+		{name: "bound to the release asset", signature: provenance, digests: map[string]string{"tool_linux_amd64.tar.gz": assetDigest(t)}},
+		{name: "asset with another digest", signature: provenance, digests: map[string]string{"tool_linux_amd64.tar.gz": "sha256:" + strings.Repeat("ab", 32)}, unbound: true},
+		{name: "asset missing from the release", signature: provenance, digests: map[string]string{"other.tar.gz": assetDigest(t)}, unbound: true},
+		{name: "tampered statement", signature: tampered(), digests: map[string]string{"tool_linux_amd64.tar.gz": assetDigest(t)}, wantErr: "doesn't match the envelope"},
+		{name: "untrusted CA", signature: fixture(t, "untrusted.intoto.jsonl"), digests: map[string]string{"tool_linux_amd64.tar.gz": assetDigest(t)}, wantErr: "doesn't chain to Fulcio"},
+		{name: "not an envelope or bundle", signature: []byte(`{"hello":"world"}`), wantErr: "not a Sigstore bundle"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := v.Verify(context.Background(), ebert.SignedArtifact{
+				Repo: "acme/tool", Tag: "v1.0.0", Asset: "tool.intoto.jsonl", Kind: ebert.SignatureInToto,
+				Signature: tt.signature, ReleaseDigests: tt.digests,
+			})
+			switch {
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			case tt.unbound:
+				if !errors.Is(err, ebert.ErrUnboundSignature) {
+					t.Fatalf("err = %v, want ErrUnboundSignature", err)
+				}
+			case err != nil:
+				t.Fatalf("Verify: %v", err)
+			}
+			if identity == nil || identity.SourceRepository != "https://github.com/acme/tool" ||
+				identity.Issuer != "https://token.actions.githubusercontent.com" ||
+				!strings.HasPrefix(identity.Subject, "https://github.com/acme/tool/.github/workflows/release.yml@") {
+				t.Errorf("identity = %+v", identity)
+			}
+		})
+	}
+}
+
+func TestVerifyBlob(t *testing.T) {
+	v := fixtureVerifier(t)
+	for _, tt := range []struct {
+		name    string
+		digest  string
+		wantErr string
+	}{
+		{"signed asset", assetDigest(t), ""},
+		{"another asset", "sha256:" + strings.Repeat("cd", 32), "doesn't match the artifact"},
+		{"no recorded digest", "", "no digest"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := v.Verify(context.Background(), ebert.SignedArtifact{
+				Repo: "acme/tool", Kind: ebert.SignatureCosign, Asset: "tool_linux_amd64.tar.gz.sig",
+				Signature:      fixture(t, "tool_linux_amd64.tar.gz.sig"),
+				Certificate:    fixture(t, "tool_linux_amd64.tar.gz.pem"),
+				ArtifactDigest: tt.digest,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || identity.SourceRepository != "https://github.com/acme/tool" {
+				t.Fatalf("Verify = %+v, %v", identity, err)
+			}
+		})
+	}
+}
+
+func TestBinding(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	subjects := []subject{
+		{Name: "tool.tar.gz", Digest: map[string]string{"sha256": digest}},
+		{Name: "tool.zip", Digest: map[string]string{"sha256": strings.Repeat("ef", 32)}},
+		{Name: "unlisted", Digest: map[string]string{"sha256": digest}},
+	}
+	for _, tt := range []struct {
+		name     string
+		artifact ebert.SignedArtifact
+		want     []string
+	}{
+		{"signed asset's digest", ebert.SignedArtifact{ArtifactDigest: "sha256:" + strings.Repeat("12", 32)}, []string{strings.Repeat("12", 32)}},
+		{"subjects matching the release", ebert.SignedArtifact{ReleaseDigests: map[string]string{
+			"tool.tar.gz": "sha256:" + strings.ToUpper(digest),
+			"tool.zip":    "sha256:" + strings.Repeat("00", 32),
+		}}, []string{digest}},
+		{"nothing recorded", ebert.SignedArtifact{}, nil},
+		{"malformed digest", ebert.SignedArtifact{ArtifactDigest: "sha256:zz", ReleaseDigests: map[string]string{"tool.tar.gz": "md5"}}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range binding(tt.artifact, subjects) {
+				got = append(got, hex.EncodeToString(d.Digest))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("bound %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// hostsSeen answers every request with a 503, recording the hosts asked
+type hostsSeen struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (h *hostsSeen) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	h.hosts = append(h.hosts, req.URL.Hostname())
+	h.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Request: req}, nil
+}
+
+func TestNewWithClient(t *testing.T) {
+	// TUF caches under the home directory
+	t.Setenv("HOME", t.TempDir())
+	artifact := ebert.SignedArtifact{Kind: ebert.SignatureBundle, Signature: []byte("{}")}
+
+	t.Run("refused by the allowlist", func(t *testing.T) {
+		seen := &hostsSeen{}
+		v := NewWithClient(&http.Client{Transport: ebert.NewHostAllowlist(seen, "api.github.com")})
+		_, err := v.Verify(context.Background(), artifact)
+		if !errors.Is(err, ebert.ErrHostNotAllowed) {
+			t.Errorf("err = %v, want ErrHostNotAllowed", err)
+		}
+		if len(seen.hosts) != 0 {
+			t.Errorf("requests reached %v past the allowlist", seen.hosts)
+		}
+	})
+
+	t.Run("through the client", func(t *testing.T) {
+		seen := &hostsSeen{}
+		v := NewWithClient(&http.Client{Transport: seen})
+		_, err := v.Verify(context.Background(), artifact)
+		if err == nil || errors.Is(err, ebert.ErrHostNotAllowed) {
+			t.Errorf("err = %v, want a failed fetch", err)
+		}
+		if !slices.Contains(seen.hosts, "tuf-repo-cdn.sigstore.dev") {
+			t.Errorf("the trusted root was fetched from %v, not through the client", seen.hosts)
+		}
+	})
+}
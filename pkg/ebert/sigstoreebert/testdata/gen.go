@@ -0,0 +1,161 @@
+//go:build ignore
+
+// gen writes the verification fixtures: a trusted root with a fixture
+// Fulcio CA, an SLSA-style bare DSSE envelope and a cosign blob signature
+// issued to acme/tool's release workflow, and an envelope from a CA the
+// root doesn't trust. Run it from this directory with go run gen.go.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"os"
+	"time"
+)
+
+var (
+	oidIssuerV2         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	oidSourceRepository = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+
+	notBefore = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter  = time.Date(2124, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func main() {
+	trustedCA, trustedKey := newCA("ebert fixture CA")
+	untrustedCA, untrustedKey := newCA("untrusted CA")
+
+	write("trusted_root.json", trustedRoot(trustedCA))
+
+	leaf, leafKey := newLeaf(trustedCA, trustedKey)
+	artifact := []byte("tool binary\n")
+	write("tool_linux_amd64.tar.gz", artifact)
+	write("provenance.intoto.jsonl", envelope(leaf, leafKey, artifact))
+
+	digest := sha256.Sum256(artifact)
+	signature, err := ecdsa.SignASN1(rand.Reader, leafKey, digest[:])
+	check(err)
+	write("tool_linux_amd64.tar.gz.sig", []byte(base64.StdEncoding.EncodeToString(signature)))
+	write("tool_linux_amd64.tar.gz.pem", []byte(base64.StdEncoding.EncodeToString(pemCert(leaf))))
+
+	strayLeaf, strayKey := newLeaf(untrustedCA, untrustedKey)
+	write("untrusted.intoto.jsonl", envelope(strayLeaf, strayKey, artifact))
+}
+
+func newCA(name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	check(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"ebert"}, CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	check(err)
+	cert, err := x509.ParseCertificate(der)
+	check(err)
+	return cert, key
+}
+
+func newLeaf(ca *x509.Certificate, caKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	check(err)
+	workflow, _ := url.Parse("https://github.com/acme/tool/.github/workflows/release.yml@refs/tags/v1.0.0")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(10 * time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:         []*url.URL{workflow},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidIssuerV2, Value: utf8("https://token.actions.githubusercontent.com")},
+			{Id: oidSourceRepository, Value: utf8("https://github.com/acme/tool")},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	check(err)
+	cert, err := x509.ParseCertificate(der)
+	check(err)
+	return cert, key
+}
+
+// envelope is a one-line .intoto.jsonl attesting to artifact
+func envelope(cert *x509.Certificate, key *ecdsa.PrivateKey, artifact []byte) []byte {
+	digest := sha256.Sum256(artifact)
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"subject":       []map[string]any{{"name": "tool_linux_amd64.tar.gz", "digest": map[string]string{"sha256": fmt.Sprintf("%x", digest)}}},
+		"predicate":     map[string]any{"builder": map[string]string{"id": "https://github.com/slsa-framework/slsa-github-generator"}},
+	})
+	check(err)
+
+	payloadType := "application/vnd.in-toto+json"
+	pae := fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(statement), statement)
+	sum := sha256.Sum256(pae)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	check(err)
+
+	line, err := json.Marshal(map[string]any{
+		"payloadType": payloadType,
+		"payload":     statement,
+		"signatures":  []map[string]any{{"keyid": "", "sig": signature, "cert": string(pemCert(cert))}},
+	})
+	check(err)
+	return append(line, '\n')
+}
+
+func trustedRoot(ca *x509.Certificate) []byte {
+	data, err := json.MarshalIndent(map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+		"certificateAuthorities": []map[string]any{{
+			"subject":   map[string]string{"organization": "ebert", "commonName": "ebert fixture CA"},
+			"uri":       "https://fulcio.example",
+			"certChain": map[string]any{"certificates": []map[string]any{{"rawBytes": ca.Raw}}},
+			"validFor":  map[string]string{"start": notBefore.Format(time.RFC3339)},
+		}},
+		"tlogs":                []any{},
+		"ctlogs":               []any{},
+		"timestampAuthorities": []any{},
+	}, "", "  ")
+	check(err)
+	return append(data, '\n')
+}
+
+func pemCert(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func utf8(value string) []byte {
+	der, err := asn1.MarshalWithParams(value, "utf8")
+	check(err)
+	return der
+}
+
+func write(name string, data []byte) {
+	check(os.WriteFile(name, data, 0o644))
+}
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package ebert
+
+import (
+	"errors"
+	"strings"
+)
+
+// Data source statuses recorded in Analysis.DataSources
+const (
+	SourceOK       = "ok"
+	SourceFailed   = "failed"
+	SourceFallback = "fallback"
+	SourceSkipped  = "skipped"
+)
+
+// DataSource records whether one input to the analysis was fetched
+type DataSource struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// coverage tracks which sources an analysis has data for
+type coverage struct {
+	repos  bool
+	events bool
+}
+
+// sourceLog accumulates per-source outcomes and errors during one analysis
+type sourceLog struct {
+	sources []DataSource
+	errs    []error
+}
+
+func (l *sourceLog) ok(name string) {
+	l.sources = append(l.sources, DataSource{Name: name, Status: SourceOK})
+}
+
+// okWith records a fetched source along with how it was fetched
+func (l *sourceLog) okWith(name, detail string) {
+	l.sources = append(l.sources, DataSource{Name: name, Status: SourceOK, Detail: detail})
+}
+
+func (l *sourceLog) failed(name string, err error) {
+	l.sources = append(l.sources, DataSource{Name: name, Status: SourceFailed, Detail: err.Error()})
+	l.errs = append(l.errs, err)
+}
+
+// fellBack records a source that failed without making the analysis
+// partial, because a lower-fidelity substitute was used instead
+func (l *sourceLog) fellBack(name string, err error) {
+	l.sources = append(l.sources, DataSource{Name: name, Status: SourceFallback, Detail: err.Error()})
+}
+
+// skipped records an external host whose requests were skipped because
+// its circuit breaker is open. Its checks are missing their input but the
+// analysis isn't partial; the confidence is lowered instead.
+func (l *sourceLog) skipped(name string, err error) {
+	l.sources = append(l.sources, DataSource{Name: name, Status: SourceSkipped, Detail: err.Error()})
+}
+
+// skippedCount is how many sources were skipped
+func (l *sourceLog) skippedCount() int {
+	n := 0
+	for _, source := range l.sources {
+		if source.Status == SourceSkipped {
+			n++
+		}
+	}
+	return n
+}
+
+func (l *sourceLog) coverage() coverage {
+	cov := coverage{}
+	for _, source := range l.sources {
+		ok := source.Status == SourceOK
+		switch source.Name {
+		case "repos":
+			cov.repos = ok
+		case "events":
+			cov.events = ok
+		}
+	}
+	return cov
+}
+
+// finish attaches the source outcomes to the analysis and returns the joined
+// source errors alongside it
+func (l *sourceLog) finish(analysis *Analysis) (*Analysis, error) {
+	analysis.DataSources = l.sources
+	analysis.Partial = len(l.errs) > 0
+	return analysis, errors.Join(l.errs...)
+}
+
+// SkippedIntegrations lists the external hosts skipped after repeated
+// failures or because the allowlist refused them
+func (a *Analysis) SkippedIntegrations() []string {
+	var skipped []string
+	for _, source := range a.DataSources {
+		if source.Status == SourceSkipped {
+			skipped = append(skipped, strings.TrimPrefix(source.Name, "integration:"))
+		}
+	}
+	return skipped
+}
+
+// MissingSources lists the data sources that could not be fetched
+func (a *Analysis) MissingSources() []string {
+	var missing []string
+	for _, source := range a.DataSources {
+		if source.Status == SourceFailed {
+			missing = append(missing, source.Name)
+		}
+	}
+	return missing
+}
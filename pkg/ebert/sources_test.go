@@ -0,0 +1,68 @@
+package ebert
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPartialAnalysis(t *testing.T) {
+	unspaced(t)
+	failing := func(w http.ResponseWriter, r *http.Request) { http.Error(w, "{}", http.StatusUnprocessableEntity) }
+	for _, tt := range []struct {
+		name    string
+		routes  []string
+		missing []string
+		// computed lists the sub-scores that survive
+		computed []string
+	}{
+		{"complete", nil, nil, []string{"identity", "activity", "quality", "maintenance", "community", "security"}},
+		{"events", []string{"/users/octo/events/public"}, []string{"events"}, []string{"identity", "quality", "maintenance", "community", "security"}},
+		{"repos", []string{"/users/octo/repos"}, []string{"repos"}, []string{"identity"}},
+		{"repos and events", []string{"/users/octo/repos", "/users/octo/events/public"}, []string{"repos", "events"}, []string{"identity"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGitHub(t, newAccount("octo", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}))
+			for _, route := range tt.routes {
+				f.route(route, failing)
+			}
+
+			analysis, err := newFakeAnalyzer(f).Analyze("octo")
+			if analysis == nil {
+				t.Fatalf("Analyze returned no analysis: %v", err)
+			}
+			if (err != nil) != (len(tt.missing) > 0) || analysis.Partial != (len(tt.missing) > 0) {
+				t.Errorf("err = %v, Partial = %t; want both to say whether sources are missing", err, analysis.Partial)
+			}
+			if got := analysis.MissingSources(); !slices.Equal(got, tt.missing) {
+				t.Errorf("MissingSources = %q, want %q", got, tt.missing)
+			}
+
+			// The profile survives every failure
+			if analysis.User.Login != "octo" || analysis.Metrics.AccountAgeDays != 2000 {
+				t.Errorf("user %q aged %d days, want octo's profile kept", analysis.User.Login, analysis.Metrics.AccountAgeDays)
+			}
+			if reposKept := !slices.Contains(tt.missing, "repos"); (analysis.Metrics.Repos == 1) != reposKept {
+				t.Errorf("Repos = %d with repos missing: %t", analysis.Metrics.Repos, !reposKept)
+			}
+
+			scores := analysis.Scores
+			for name, score := range map[string]*float64{
+				"identity": scores.Identity, "activity": scores.Activity, "quality": scores.Quality,
+				"maintenance": scores.Maintenance, "community": scores.Community, "security": scores.Security,
+			} {
+				if want := slices.Contains(tt.computed, name); (score != nil) != want {
+					t.Errorf("%s computed = %t, want %t", name, score != nil, want)
+				}
+			}
+
+			var out bytes.Buffer
+			FprintAnalysis(&out, analysis)
+			if banner := "PARTIAL REPORT - missing data: " + strings.Join(tt.missing, ", "); strings.Contains(out.String(), banner) != (len(tt.missing) > 0) {
+				t.Errorf("with %q missing the report banner is wrong:\n%s", tt.missing, out.String())
+			}
+		})
+	}
+}
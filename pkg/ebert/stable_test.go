@@ -0,0 +1,46 @@
+package ebert
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStableJSONRepeatable(t *testing.T) {
+	unspaced(t)
+	f := newFakeGitHub(t, syntheticAccount("repeat", 120))
+
+	first, err := newFakeAnalyzer(f, WithDeepChecks(true)).Analyze("repeat")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	// The same data listed in another order, reported a minute later
+	slices.Reverse(f.account("repeat").Repos)
+	second, err := newFakeAnalyzer(f, WithDeepChecks(true)).Analyze("repeat")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	second.Timestamp = second.Timestamp.Add(time.Minute)
+
+	a, err := StableJSON(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := StableJSON(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("identical data serialized differently:\nfirst:  %s\nsecond: %s", a, b)
+	}
+
+	if len(first.Findings) < 3 {
+		t.Fatalf("the fixture raises %d findings, too few to check their order", len(first.Findings))
+	}
+	sorted := slices.Clone(first.Findings)
+	sortFindings(sorted)
+	if !slices.EqualFunc(first.Findings, sorted, func(x, y Finding) bool { return x.Code == y.Code && x.Message == y.Message }) {
+		t.Errorf("findings aren't in canonical order: %v", first.Findings)
+	}
+}
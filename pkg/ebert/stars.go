@@ -0,0 +1,115 @@
+package ebert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Star falloffs selectable with WithStarAgeWeighting
+const (
+	// StarFalloffLinear discounts a dormant repo's stars evenly, reaching
+	// nothing one more horizon past the horizon
+	StarFalloffLinear = "linear"
+
+	// StarFalloffExponential halves a dormant repo's stars with every
+	// horizon past the horizon
+	StarFalloffExponential = "exponential"
+)
+
+// repoSum totals a fractional per-repo term, such as weighted stars, in
+// fixed point. Integer addition doesn't depend on the order the repos are
+// listed in, where the last digits of a float total do.
+type repoSum int64
+
+// repoSumScale is the fixed point's units per one
+const repoSumScale = 1e9
+
+func (s *repoSum) add(v float64) {
+	*s += repoSum(math.Round(v * repoSumScale))
+}
+
+func (s repoSum) value() float64 {
+	return float64(s) / repoSumScale
+}
+
+// DefaultStarHorizon is how long after its last push a repo's stars count
+// in full toward WeightedStars
+const DefaultStarHorizon = 365 * 24 * time.Hour
+
+const (
+	// dormantPopularStars is how many stars make a dormant repo worth
+	// warning about
+	dormantPopularStars = 1000
+
+	// dormantPopularAge is how long without a push makes a popular repo
+	// dormant
+	dormantPopularAge = 2 * 365 * 24 * time.Hour
+)
+
+// lastPush is when the repo was last pushed to, falling back to its
+// update time
+func lastPush(repo GitHubRepo) time.Time {
+	if repo.PushedAt.IsZero() {
+		return repo.UpdatedAt
+	}
+	return repo.PushedAt
+}
+
+// starWeight is the share of a repo's stars that count toward
+// WeightedStars: all of them until horizon after its last push, then
+// falling off. Repos without a push or update time count in full.
+func starWeight(repo GitHubRepo, now time.Time, horizon time.Duration, falloff string) float64 {
+	last := lastPush(repo)
+	if last.IsZero() {
+		return 1
+	}
+	overdue := now.Sub(last) - horizon
+	if overdue <= 0 {
+		return 1
+	}
+	if falloff == StarFalloffExponential {
+		return math.Exp2(-float64(overdue) / float64(horizon))
+	}
+	return max(0, 1-float64(overdue)/float64(horizon))
+}
+
+// formatStars renders a star count compactly, e.g. 3.1k
+func formatStars(n int) string {
+	if n < 1000 {
+		return strconv.Itoa(n)
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000), ".0") + "k"
+}
+
+// dormantPopularRule warns about popular repos nobody has pushed to in
+// years: their stars flatter the account while they are the dependencies
+// most likely to bite. Archived repos already say they are unmaintained.
+var dormantPopularRule = rule{
+	code:        "DORMANT_POPULAR",
+	description: "A repo with many stars has had no push in over two years",
+	evaluate: func(c RuleContext) []Finding {
+		if !c.coverage().repos {
+			return nil
+		}
+		var dormant []string
+		for _, repo := range c.run.checkable() {
+			last := lastPush(repo)
+			if repo.Fork || repo.Archived || repo.StargazersCount < dormantPopularStars || last.IsZero() || c.run.now.Sub(last) <= dormantPopularAge {
+				continue
+			}
+			dormant = append(dormant, fmt.Sprintf("%s (%s stars, last push %d)", repo.Name, formatStars(repo.StargazersCount), last.Year()))
+		}
+		if len(dormant) == 0 {
+			return nil
+		}
+		return []Finding{{
+			Code:     "DORMANT_POPULAR",
+			Severity: SeverityWarning,
+			Message:  "Popular but dormant: highly starred repos have had no push in over two years",
+			Evidence: dormant,
+		}}
+	},
+}
@@ -0,0 +1,133 @@
+package ebert
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStarWeight(t *testing.T) {
+	horizon := DefaultStarHorizon
+	for _, tt := range []struct {
+		name    string
+		repo    GitHubRepo
+		falloff string
+		want    float64
+	}{
+		{"within the horizon", GitHubRepo{PushedAt: fakeNow.Add(-horizon)}, StarFalloffLinear, 1},
+		{"linear, half past", GitHubRepo{PushedAt: fakeNow.Add(-horizon * 3 / 2)}, StarFalloffLinear, 0.5},
+		{"linear, long past", GitHubRepo{PushedAt: fakeNow.Add(-horizon * 3)}, StarFalloffLinear, 0},
+		{"exponential, one past", GitHubRepo{PushedAt: fakeNow.Add(-horizon * 2)}, StarFalloffExponential, 0.5},
+		{"exponential, two past", GitHubRepo{PushedAt: fakeNow.Add(-horizon * 3)}, StarFalloffExponential, 0.25},
+		// The update time stands in for a missing push time
+		{"updated only", GitHubRepo{UpdatedAt: fakeNow.Add(-horizon * 3 / 2)}, StarFalloffLinear, 0.5},
+		{"pushed wins", GitHubRepo{PushedAt: fakeNow, UpdatedAt: fakeNow.Add(-horizon * 3)}, StarFalloffLinear, 1},
+		{"never", GitHubRepo{}, StarFalloffLinear, 1},
+	} {
+		if got := starWeight(tt.repo, fakeNow, horizon, tt.falloff); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: starWeight = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatStars(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1k", 3100: "3.1k", 3140: "3.1k", 12000: "12k", 12345: "12.3k"} {
+		if got := formatStars(n); got != want {
+			t.Errorf("formatStars(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestWithStarAgeWeighting(t *testing.T) {
+	for _, tt := range []struct {
+		horizon time.Duration
+		falloff string
+	}{
+		{0, StarFalloffLinear},
+		{-time.Hour, StarFalloffExponential},
+		{DefaultStarHorizon, "logarithmic"},
+		{DefaultStarHorizon, ""},
+	} {
+		if _, err := New("", WithStarAgeWeighting(tt.horizon, tt.falloff)); err == nil {
+			t.Errorf("WithStarAgeWeighting(%s, %q) was accepted", tt.horizon, tt.falloff)
+		}
+	}
+	a, err := New("", WithStarAgeWeighting(90*24*time.Hour, StarFalloffExponential))
+	if err != nil || a.opts.StarHorizon != 90*24*time.Hour || a.opts.StarFalloff != StarFalloffExponential {
+		t.Errorf("New = %v, options %+v", err, a.opts)
+	}
+	if opts := NewAnalyzer("").opts; opts.StarHorizon != DefaultStarHorizon || opts.StarFalloff != StarFalloffLinear {
+		t.Errorf("default star weighting %s, %q", opts.StarHorizon, opts.StarFalloff)
+	}
+}
+
+// dormantPopularAccount owns classic, a repo starred in the thousands and
+// left untouched since 2019, beside two small active ones
+func dormantPopularAccount() *fakeAccount {
+	return newAccount("octo", days(4000),
+		GitHubRepo{Name: "classic", Language: "Go", Size: 900, StargazersCount: 3100, UpdatedAt: time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)},
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 20, UpdatedAt: fakeNow.Add(-days(3))},
+		GitHubRepo{Name: "lib", Language: "Go", Size: 500, StargazersCount: 10, UpdatedAt: fakeNow.Add(-days(5))},
+	)
+}
+
+func TestQualityScoreVersions(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		opts     []Option
+		weighted float64
+		quality  float64
+	}{
+		// v1 credits the dormant repo's stars in full
+		{"v1", nil, 30, 30},
+		{"v2", []Option{WithScoringVersion(ScoringV2)}, 30, 50},
+		// classic was pushed 1919.5 days ago; halving its stars every year
+		// past the first still leaves enough to count
+		{"v2 exponential", []Option{WithScoringVersion(ScoringV2), WithStarAgeWeighting(DefaultStarHorizon, StarFalloffExponential)}, 30 + 3100*math.Exp2(-(1919.5-365)/365), 30},
+	} {
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, dormantPopularAccount()), tt.opts...).Analyze("octo")
+		if err != nil {
+			t.Fatalf("%s: Analyze: %v", tt.name, err)
+		}
+		metrics := analysis.Metrics
+		if metrics.Stars != 3130 {
+			t.Errorf("%s: Stars = %d, want the raw 3130", tt.name, metrics.Stars)
+		}
+		if math.Abs(metrics.WeightedStars-tt.weighted) > 1e-6 {
+			t.Errorf("%s: WeightedStars = %v, want %v", tt.name, metrics.WeightedStars, tt.weighted)
+		}
+		if analysis.Scores.Quality == nil || *analysis.Scores.Quality != tt.quality {
+			t.Errorf("%s: quality %s, want %v", tt.name, formatScore(analysis.Scores.Quality), tt.quality)
+		}
+	}
+}
+
+func TestDormantPopular(t *testing.T) {
+	account := newAccount("octo", days(4000), append(dormantPopularAccount().Repos,
+		// Archiving already says as much
+		GitHubRepo{Name: "retired", Language: "Go", Size: 900, StargazersCount: 5000, Archived: true, UpdatedAt: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)},
+		// Under two years, and under a thousand stars
+		GitHubRepo{Name: "popular", Language: "Go", Size: 900, StargazersCount: 12000, UpdatedAt: fakeNow.Add(-days(700))},
+		GitHubRepo{Name: "old-star", Language: "Go", Size: 900, StargazersCount: 999, UpdatedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+	)...)
+
+	analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	flag := finding(analysis, "DORMANT_POPULAR")
+	if want := []string{"classic (3.1k stars, last push 2019)"}; flag == nil || flag.Severity != SeverityWarning || flag.Index != IndexAbandonment || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("DORMANT_POPULAR = %+v, want %q", flag, want)
+	}
+
+	analysis, err = newFakeAnalyzer(newFakeGitHub(t, newAccount("octo", days(4000),
+		GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 4000, UpdatedAt: fakeNow.Add(-days(3))},
+	))).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if flag := finding(analysis, "DORMANT_POPULAR"); flag != nil {
+		t.Errorf("unexpected %+v", flag)
+	}
+}
@@ -0,0 +1,223 @@
+package ebert
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointStats aggregates the requests made to one endpoint template
+type EndpointStats struct {
+	Requests       int64         `json:"requests"`
+	TotalLatencyNS time.Duration `json:"total_latency_ns"`
+	MaxLatencyNS   time.Duration `json:"max_latency_ns"`
+}
+
+// RequestStats summarizes the requests made through a client or during
+// a single analysis
+type RequestStats struct {
+	Requests               int64                    `json:"requests"`
+	Bytes                  int64                    `json:"bytes"`
+	CacheHits              int64                    `json:"cache_hits"`
+	Retries                int64                    `json:"retries"`
+	SecondaryRateLimitHits int64                    `json:"secondary_rate_limit_hits"`
+	DuplicateEvents        int64                    `json:"duplicate_events"`
+	RateLimit              *RateLimit               `json:"rate_limit,omitempty"`
+	Endpoints              map[string]EndpointStats `json:"endpoints,omitempty"`
+}
+
+// RateLimit is the core API quota as last reported by GitHub
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// Add folds other into s, e.g. to total the stats of a batch. The rate
+// limit kept is the most recent one.
+func (s *RequestStats) Add(other RequestStats) {
+	s.Requests += other.Requests
+	s.Bytes += other.Bytes
+	s.CacheHits += other.CacheHits
+	s.Retries += other.Retries
+	s.SecondaryRateLimitHits += other.SecondaryRateLimitHits
+	s.DuplicateEvents += other.DuplicateEvents
+	s.RateLimit = laterRateLimit(s.RateLimit, other.RateLimit)
+
+	if len(other.Endpoints) > 0 && s.Endpoints == nil {
+		s.Endpoints = map[string]EndpointStats{}
+	}
+	for name, o := range other.Endpoints {
+		e := s.Endpoints[name]
+		e.Requests += o.Requests
+		e.TotalLatencyNS += o.TotalLatencyNS
+		e.MaxLatencyNS = max(e.MaxLatencyNS, o.MaxLatencyNS)
+		s.Endpoints[name] = e
+	}
+}
+
+// laterRateLimit picks the observation describing the quota now: the one
+// with the later reset, or within one window the lower remaining count
+func laterRateLimit(a, b *RateLimit) *RateLimit {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case b.Reset.After(a.Reset), b.Reset.Equal(a.Reset) && b.Remaining < a.Remaining:
+		return b
+	default:
+		return a
+	}
+}
+
+// parseRateLimit reads the core quota headers from a response, ignoring
+// the separate search and GraphQL quotas
+func parseRateLimit(header http.Header) *RateLimit {
+	if resource := header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return nil
+	}
+
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	return &RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0).UTC()}
+}
+
+// statsRecorder accumulates RequestStats safely across goroutines
+type statsRecorder struct {
+	mu    sync.Mutex
+	stats RequestStats
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{stats: RequestStats{Endpoints: map[string]EndpointStats{}}}
+}
+
+func (r *statsRecorder) recordRequest(endpoint string, attempt int, bytes int, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Requests++
+	r.stats.Bytes += int64(bytes)
+	if attempt > 1 {
+		r.stats.Retries++
+	}
+
+	e := r.stats.Endpoints[endpoint]
+	e.Requests++
+	e.TotalLatencyNS += latency
+	e.MaxLatencyNS = max(e.MaxLatencyNS, latency)
+	r.stats.Endpoints[endpoint] = e
+}
+
+func (r *statsRecorder) recordRateLimit(limit *RateLimit) {
+	if limit == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.RateLimit = laterRateLimit(r.stats.RateLimit, limit)
+}
+
+func (r *statsRecorder) recordCacheHit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.CacheHits++
+}
+
+func (r *statsRecorder) recordSecondaryLimit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.SecondaryRateLimitHits++
+}
+
+// recordDuplicateEvents counts events the feed repeated across pages
+func (r *statsRecorder) recordDuplicateEvents(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.DuplicateEvents += int64(n)
+}
+
+// snapshot returns a copy that is safe to hand to callers
+func (r *statsRecorder) snapshot() RequestStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	if r.stats.RateLimit != nil {
+		limit := *r.stats.RateLimit
+		stats.RateLimit = &limit
+	}
+	stats.Endpoints = make(map[string]EndpointStats, len(r.stats.Endpoints))
+	for name, e := range r.stats.Endpoints {
+		stats.Endpoints[name] = e
+	}
+	return stats
+}
+
+type statsKey struct{}
+
+// withStatsRecorder attaches a recorder to ctx alongside any already
+// attached, so requests are attributed to the analysis and its batch as
+// well as the client totals
+func withStatsRecorder(ctx context.Context, r *statsRecorder) context.Context {
+	return context.WithValue(ctx, statsKey{}, append(statsRecordersFrom(ctx), r))
+}
+
+func statsRecordersFrom(ctx context.Context) []*statsRecorder {
+	recorders, _ := ctx.Value(statsKey{}).([]*statsRecorder)
+	return slices.Clip(recorders)
+}
+
+type attemptKey struct{}
+
+// RequestAttempt returns which attempt at a request r is, starting at 1,
+// for use in OnRequest and OnResponse hooks
+func RequestAttempt(r *http.Request) int {
+	if attempt, ok := r.Context().Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// endpointName reduces a request URL to its endpoint template, replacing
+// user, org and repo path segments with placeholders
+func endpointName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "unknown"
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	// Skip any API prefix such as GitHub Enterprise's /api/v3
+	for i, segment := range segments {
+		if segment == "users" || segment == "orgs" || segment == "repos" {
+			segments = segments[i:]
+			break
+		}
+	}
+
+	if len(segments) > 1 {
+		switch segments[0] {
+		case "users", "orgs":
+			segments[1] = ":" + strings.TrimSuffix(segments[0], "s")
+		case "repos":
+			segments[1] = ":owner"
+			if len(segments) > 2 {
+				segments[2] = ":repo"
+			}
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,76 @@
+package ebert
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestFooter(t *testing.T) {
+	reset := time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name  string
+		stats *RequestStats
+		want  string
+	}{
+		{"no stats", nil, ""},
+		{"requests only", &RequestStats{Requests: 38}, "38 API calls"},
+		{"thousands", &RequestStats{Requests: 1234567}, "1,234,567 API calls"},
+		{"cache hits", &RequestStats{Requests: 38, CacheHits: 1200}, "38 API calls (1,200 cached)"},
+		{"rate limit", &RequestStats{Requests: 38, RateLimit: &RateLimit{Limit: 5000, Remaining: 4812, Reset: reset}}, "38 API calls, 4,812 remaining until 16:00 UTC"},
+		// The reset is shown in UTC whatever zone it was parsed in
+		{"reset zone", &RequestStats{Requests: 38, RateLimit: &RateLimit{Remaining: 0, Reset: reset.In(time.FixedZone("PDT", -7*3600))}}, "38 API calls, 0 remaining until 16:00 UTC"},
+		{"everything", &RequestStats{Requests: 2048, CacheHits: 4, RateLimit: &RateLimit{Remaining: 12, Reset: reset}}, "2,048 API calls (4 cached), 12 remaining until 16:00 UTC"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestFooter(tt.stats, englishCatalog); got != tt.want {
+				t.Errorf("requestFooter = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestStatsAdd(t *testing.T) {
+	reset := time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)
+	total := RequestStats{}
+	for _, stats := range []RequestStats{
+		{Requests: 10, Bytes: 2000, CacheHits: 1, RateLimit: &RateLimit{Remaining: 4900, Reset: reset},
+			Endpoints: map[string]EndpointStats{"users/:user": {Requests: 1, TotalLatencyNS: 30, MaxLatencyNS: 30}}},
+		// Later in the same window, with less left
+		{Requests: 5, Retries: 2, RateLimit: &RateLimit{Remaining: 4850, Reset: reset},
+			Endpoints: map[string]EndpointStats{"users/:user": {Requests: 2, TotalLatencyNS: 50, MaxLatencyNS: 40}}},
+		// Finished before the others, so its quota is stale
+		{Requests: 1, RateLimit: &RateLimit{Remaining: 4999, Reset: reset.Add(-time.Hour)}},
+	} {
+		total.Add(stats)
+	}
+
+	if total.Requests != 16 || total.Bytes != 2000 || total.CacheHits != 1 || total.Retries != 2 {
+		t.Errorf("total = %+v, want the counts summed", total)
+	}
+	if total.RateLimit == nil || total.RateLimit.Remaining != 4850 {
+		t.Errorf("rate limit = %+v, want the latest observation", total.RateLimit)
+	}
+	if got := total.Endpoints["users/:user"]; got != (EndpointStats{Requests: 3, TotalLatencyNS: 80, MaxLatencyNS: 40}) {
+		t.Errorf("users/:user = %+v, want 3 requests, 80ns in all and 40ns at most", got)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "4812")
+	header.Set("X-RateLimit-Reset", "1717257600")
+	if got := parseRateLimit(header); got == nil || *got != (RateLimit{Limit: 5000, Remaining: 4812, Reset: time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)}) {
+		t.Errorf("parseRateLimit = %+v, want 4,812 of 5,000 until 16:00 UTC", got)
+	}
+
+	// The search quota isn't the core one
+	header.Set("X-RateLimit-Resource", "search")
+	if got := parseRateLimit(header); got != nil {
+		t.Errorf("parseRateLimit of a search response = %+v, want nil", got)
+	}
+	if got := parseRateLimit(http.Header{}); got != nil {
+		t.Errorf("parseRateLimit without headers = %+v, want nil", got)
+	}
+}
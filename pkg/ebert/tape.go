@@ -0,0 +1,245 @@
+package ebert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// tapeVersion is the tape format written by Recorder
+const tapeVersion = 1
+
+// tapeHeaders are the response headers a tape keeps; the rest are noise
+// the analysis never reads
+var tapeHeaders = []string{"Content-Type", "ETag", "Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-RateLimit-Resource", "X-RateLimit-Used"}
+
+// tokenPattern matches GitHub token formats so one echoed back in a body
+// can't reach a tape
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`)
+
+// scrubbedToken replaces tokens in recorded data
+const scrubbedToken = "REDACTED"
+
+// Tape is a recorded set of HTTP interactions that can replay an analysis
+// without the network. Authenticated says a token was sent, so replays
+// know to run the checks that need one.
+type Tape struct {
+	Version       int           `json:"version"`
+	RecordedAt    time.Time     `json:"recorded_at"`
+	Authenticated bool          `json:"authenticated"`
+	Interactions  []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response. Body is base64
+// when Base64 is set, for responses that aren't valid UTF-8.
+type Interaction struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	RequestBody string            `json:"request_body,omitempty"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body"`
+	Base64      bool              `json:"base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that captures every request it sends
+// onto a Tape. The Authorization header is never recorded and the token is
+// scrubbed from URLs and bodies.
+type Recorder struct {
+	base  http.RoundTripper
+	token string
+
+	mu   sync.Mutex
+	tape Tape
+}
+
+// NewRecorder records the requests sent through base, or
+// http.DefaultTransport if nil, scrubbing token from what it writes
+func NewRecorder(base http.RoundTripper, token string) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{base: base, token: token, tape: Tape{Version: tapeVersion, RecordedAt: time.Now().UTC(), Authenticated: token != ""}}
+}
+
+// RoundTrip sends req and records it with its response
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		requestBody, err = io.ReadAll(body)
+		_ = body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	resp, err := rec.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	interaction := Interaction{
+		Method:      req.Method,
+		URL:         rec.scrub(req.URL.String()),
+		RequestBody: rec.scrub(string(requestBody)),
+		Status:      resp.StatusCode,
+		Headers:     map[string]string{},
+	}
+	for _, name := range tapeHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			interaction.Headers[name] = value
+		}
+	}
+	if utf8.Valid(data) {
+		interaction.Body = rec.scrub(string(data))
+	} else {
+		interaction.Body, interaction.Base64 = base64.StdEncoding.EncodeToString(data), true
+	}
+
+	rec.mu.Lock()
+	rec.tape.Interactions = append(rec.tape.Interactions, interaction)
+	rec.mu.Unlock()
+	return resp, nil
+}
+
+// scrub removes the token and anything shaped like a GitHub token
+func (rec *Recorder) scrub(s string) string {
+	if rec.token != "" {
+		s = strings.ReplaceAll(s, rec.token, scrubbedToken)
+	}
+	return tokenPattern.ReplaceAllString(s, scrubbedToken)
+}
+
+// Save writes the tape recorded so far to path
+func (rec *Recorder) Save(path string) error {
+	rec.mu.Lock()
+	data, err := json.MarshalIndent(rec.tape, "", "  ")
+	rec.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode tape: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write tape: %w", err)
+	}
+	return nil
+}
+
+// Replayer is an http.RoundTripper that answers from a Tape and fails any
+// request the tape doesn't hold. Repeated requests get their recorded
+// responses in order, then the last one again.
+type Replayer struct {
+	tape Tape
+
+	mu        sync.Mutex
+	responses map[string][]Interaction
+}
+
+// LoadTape reads a tape written by Recorder.Save
+func LoadTape(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tape: %w", err)
+	}
+	var tape Tape
+	if err := json.Unmarshal(data, &tape); err != nil {
+		return nil, fmt.Errorf("failed to decode tape %s: %w", path, err)
+	}
+	if tape.Version != tapeVersion {
+		return nil, fmt.Errorf("unsupported tape version %d in %s", tape.Version, path)
+	}
+	return NewReplayer(tape), nil
+}
+
+// NewReplayer replays tape
+func NewReplayer(tape Tape) *Replayer {
+	responses := map[string][]Interaction{}
+	for _, interaction := range tape.Interactions {
+		key := interactionKey(interaction.Method, interaction.URL, interaction.RequestBody)
+		responses[key] = append(responses[key], interaction)
+	}
+	return &Replayer{tape: tape, responses: responses}
+}
+
+// RecordedAt is when the tape was recorded; replaying with it as the clock
+// keeps age-based checks stable
+func (p *Replayer) RecordedAt() time.Time {
+	return p.tape.RecordedAt
+}
+
+// Authenticated reports whether the tape was recorded with a token
+func (p *Replayer) Authenticated() bool {
+	return p.tape.Authenticated
+}
+
+// RoundTrip answers req from the tape
+func (p *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	key := interactionKey(req.Method, req.URL.String(), string(requestBody))
+	p.mu.Lock()
+	queue := p.responses[key]
+	if len(queue) == 0 {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	interaction := queue[0]
+	if len(queue) > 1 {
+		p.responses[key] = queue[1:]
+	}
+	p.mu.Unlock()
+
+	body := []byte(interaction.Body)
+	if interaction.Base64 {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(interaction.Body); err != nil {
+			return nil, fmt.Errorf("failed to decode recorded body for %s: %w", req.URL, err)
+		}
+	}
+	header := http.Header{}
+	for name, value := range interaction.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// interactionKey identifies a request on a tape
+func interactionKey(method, url, body string) string {
+	return method + " " + url + "\n" + body
+}
@@ -0,0 +1,112 @@
+package ebert
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// maxTarballEntries bounds the entries listed from one tarball
+	maxTarballEntries = 10000
+
+	// maxTarballUnpacked caps the bytes decompressed from one tarball, so
+	// a compression bomb fails rather than spinning
+	maxTarballUnpacked = 256 << 20
+)
+
+// errTarballTooLarge is returned for a tarball past either bound
+var errTarballTooLarge = errors.New("tarball too large to list")
+
+// tarballListing is what a gzipped package tarball holds: the paths of
+// its files and links relative to the package root, and the entries whose
+// path or link target escapes it, which no honest package ships
+type tarballListing struct {
+	files  []string
+	unsafe []string
+}
+
+// listTarball lists a gzipped tarball without extracting it. The top
+// directory every registry wraps a package in, such as npm's "package/",
+// is stripped from each path.
+func listTarball(data []byte) (*tarballListing, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tarball: %w", err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	unpacked := &io.LimitedReader{R: gz, N: maxTarballUnpacked + 1}
+	tr := tar.NewReader(unpacked)
+	listing := &tarballListing{}
+	for entries := 0; ; entries++ {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return listing, nil
+		}
+		if unpacked.N <= 0 || entries == maxTarballEntries {
+			return nil, errTarballTooLarge
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tarball: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+		default:
+			continue
+		}
+		name, ok := packagePath(header.Name)
+		if !ok {
+			listing.unsafe = append(listing.unsafe, header.Name)
+			continue
+		}
+		if header.Typeflag != tar.TypeReg && !linkStaysInside(name, header.Linkname, header.Typeflag) {
+			listing.unsafe = append(listing.unsafe, name+" -> "+header.Linkname)
+			continue
+		}
+		listing.files = append(listing.files, name)
+	}
+}
+
+// packagePath is a tarball entry's path below its top directory,
+// reporting false for one that is absolute or climbs out of the package
+func packagePath(name string) (string, bool) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || len(name) > 1 && name[1] == ':' {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	name = path.Clean(name)
+	if _, rest, ok := strings.Cut(name, "/"); ok {
+		name = rest
+	}
+	return name, name != "."
+}
+
+// linkStaysInside reports whether a link at name points within the
+// package: symlink targets are relative to the link, hard link targets to
+// the tarball root
+func linkStaysInside(name, target string, kind byte) bool {
+	target = strings.ReplaceAll(target, `\`, "/")
+	if path.IsAbs(target) {
+		return false
+	}
+	if kind == tar.TypeLink {
+		_, ok := packagePath(target)
+		return ok
+	}
+	resolved := path.Join(path.Dir(name), target)
+	return resolved != ".." && !strings.HasPrefix(resolved, "../")
+}
@@ -0,0 +1,194 @@
+package ebert
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+// tarEntry is one crafted tarball entry, a regular file unless kind says
+// otherwise
+type tarEntry struct {
+	name string
+	kind byte
+	link string
+}
+
+// craftTarball gzips a tarball of entries, each regular file holding its
+// own name
+func craftTarball(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: cmp.Or(entry.kind, tar.TypeReg), Linkname: entry.link, Mode: 0o644}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(entry.name))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestListTarball(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		entries []tarEntry
+		files   []string
+		unsafe  []string
+	}{
+		{
+			name:    "top directory stripped",
+			entries: []tarEntry{{name: "package/", kind: tar.TypeDir}, {name: "package/index.js"}, {name: "package/lib/util.js"}, {name: "pkg-1.0/setup.py"}},
+			files:   []string{"index.js", "lib/util.js", "setup.py"},
+		},
+		{
+			name:    "parent directory",
+			entries: []tarEntry{{name: "package/../evil.js"}, {name: "../outside.js"}, {name: "package/lib/../../evil.js"}, {name: "package/ok.js"}},
+			files:   []string{"ok.js"},
+			unsafe:  []string{"package/../evil.js", "../outside.js", "package/lib/../../evil.js"},
+		},
+		{
+			name:    "absolute paths",
+			entries: []tarEntry{{name: "/etc/cron.d/evil"}, {name: "//server/share/evil"}},
+			unsafe:  []string{"/etc/cron.d/evil", "//server/share/evil"},
+		},
+		{
+			name:    "drive letters",
+			entries: []tarEntry{{name: "C:/Windows/evil.dll"}, {name: `C:\Windows\evil.dll`}, {name: "c:evil.js"}},
+			unsafe:  []string{"C:/Windows/evil.dll", `C:\Windows\evil.dll`, "c:evil.js"},
+		},
+		{
+			name:    "backslashes",
+			entries: []tarEntry{{name: `package\lib\win.js`}, {name: `package\..\evil.js`}, {name: `\evil.js`}},
+			files:   []string{"lib/win.js"},
+			unsafe:  []string{`package\..\evil.js`, `\evil.js`},
+		},
+		{
+			name: "symlinks",
+			entries: []tarEntry{
+				{name: "package/lib/alias.js", kind: tar.TypeSymlink, link: "../index.js"},
+				{name: "package/lib/deep/up.js", kind: tar.TypeSymlink, link: "../../../../etc/passwd"},
+				{name: "package/root", kind: tar.TypeSymlink, link: "/"},
+				{name: "package/win", kind: tar.TypeSymlink, link: `..\..\evil`},
+				{name: "package/top.js", kind: tar.TypeSymlink, link: "../index.js"},
+			},
+			files:  []string{"lib/alias.js"},
+			unsafe: []string{"lib/deep/up.js -> ../../../../etc/passwd", "root -> /", `win -> ..\..\evil`, "top.js -> ../index.js"},
+		},
+		{
+			// Hard link targets are named from the tarball root
+			name: "hard links",
+			entries: []tarEntry{
+				{name: "package/index.js"},
+				{name: "package/copy.js", kind: tar.TypeLink, link: "package/index.js"},
+				{name: "package/shadow", kind: tar.TypeLink, link: "/etc/shadow"},
+				{name: "package/up", kind: tar.TypeLink, link: "package/../../etc/passwd"},
+			},
+			files:  []string{"index.js", "copy.js"},
+			unsafe: []string{"shadow -> /etc/shadow", "up -> package/../../etc/passwd"},
+		},
+		{
+			name:    "devices and fifos skipped",
+			entries: []tarEntry{{name: "package/dev", kind: tar.TypeChar}, {name: "package/fifo", kind: tar.TypeFifo}, {name: "package/a.js"}},
+			files:   []string{"a.js"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			listing, err := listTarball(craftTarball(t, tt.entries...))
+			if err != nil {
+				t.Fatalf("listTarball: %v", err)
+			}
+			if !slices.Equal(listing.files, tt.files) {
+				t.Errorf("files = %q, want %q", listing.files, tt.files)
+			}
+			if !slices.Equal(listing.unsafe, tt.unsafe) {
+				t.Errorf("unsafe = %q, want %q", listing.unsafe, tt.unsafe)
+			}
+		})
+	}
+}
+
+func TestListTarballEntryCap(t *testing.T) {
+	entries := make([]tarEntry, maxTarballEntries)
+	for i := range entries {
+		entries[i] = tarEntry{name: fmt.Sprintf("package/f%05d.js", i)}
+	}
+	listing, err := listTarball(craftTarball(t, entries...))
+	if err != nil || len(listing.files) != maxTarballEntries {
+		t.Fatalf("a tarball at the cap: %d files, %v; want all %d", len(listing.files), err, maxTarballEntries)
+	}
+
+	entries = append(entries, tarEntry{name: "package/one-more.js"})
+	if _, err := listTarball(craftTarball(t, entries...)); !errors.Is(err, errTarballTooLarge) {
+		t.Errorf("a tarball past the cap: %v, want errTarballTooLarge", err)
+	}
+}
+
+func TestListTarballUnpackedCap(t *testing.T) {
+	// A download within maxArtifactBytes that unpacks past the cap
+	var buf bytes.Buffer
+	gz, _ := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	tw := tar.NewWriter(gz)
+	size := int64(maxTarballUnpacked)
+	if err := tw.WriteHeader(&tar.Header{Name: "package/bomb.bin", Typeflag: tar.TypeReg, Size: size, Mode: 0o644}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.CopyN(tw, zeros{}, size); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "package/after.js", Typeflag: tar.TypeReg, Mode: 0o644}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() > maxArtifactBytes {
+		t.Fatalf("the bomb is %d bytes, more than a download may be", buf.Len())
+	}
+	if _, err := listTarball(buf.Bytes()); !errors.Is(err, errTarballTooLarge) {
+		t.Errorf("a compression bomb: %v, want errTarballTooLarge", err)
+	}
+}
+
+func TestListTarballMalformed(t *testing.T) {
+	if _, err := listTarball([]byte("PK\x03\x04 a zip, not a tarball")); err == nil {
+		t.Error("want an error for data that isn't gzip")
+	}
+	data := craftTarball(t, tarEntry{name: "package/index.js"})
+	if _, err := listTarball(data[:len(data)/2]); err == nil {
+		t.Error("want an error for a truncated tarball")
+	}
+}
+
+// zeros reads as an endless run of zero bytes
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
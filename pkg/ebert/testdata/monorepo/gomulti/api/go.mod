@@ -0,0 +1,3 @@
+module example.com/gomulti/api
+
+go 1.22
@@ -0,0 +1,4 @@
+// Module of the SDK
+module "example.com/gomulti/sdk"
+
+go 1.22
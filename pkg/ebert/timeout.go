@@ -0,0 +1,61 @@
+package ebert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultAnalysisTimeout bounds a whole analysis, retries and rate-limit
+// waits included
+const DefaultAnalysisTimeout = 2 * time.Minute
+
+// DefaultRequestTimeout bounds each individual API request
+const DefaultRequestTimeout = 10 * time.Second
+
+// errAnalysisTimeout is the cause of a context cancelled by AnalysisTimeout
+var errAnalysisTimeout = errors.New("analysis timeout exceeded")
+
+type giveUpKey struct{}
+
+// withGiveUp lets a wait that can't end before ctx's deadline stop the
+// whole analysis at once, through giveUp, instead of failing one request
+// and leaving the pipeline to queue up more waits
+func withGiveUp(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, giveUpKey{}, cancel)
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// giveUp ends the analysis running under ctx as timed out, for reason
+func giveUp(ctx context.Context, reason error) {
+	if cancel, ok := ctx.Value(giveUpKey{}).(context.CancelCauseFunc); ok {
+		cancel(fmt.Errorf("%w: %w", errAnalysisTimeout, reason))
+	}
+}
+
+// completed records that a pipeline stage ran to its end within the deadline
+func (r *analysisRun) completed(stage string) {
+	r.stages = append(r.stages, stage)
+}
+
+// checkTimeout marks the analysis partial with a TIMEOUT warning when the
+// analysis deadline, or a wait that would have outlasted it, cut the
+// pipeline short
+func (a *Analyzer) checkTimeout(ctx context.Context, r *analysisRun) {
+	cause := context.Cause(ctx)
+	if !errors.Is(cause, errAnalysisTimeout) {
+		return
+	}
+
+	r.log.failed("deadline", fmt.Errorf("%w (limit %s); completed stages: %s",
+		cause, a.opts.AnalysisTimeout, strings.Join(r.stages, ", ")))
+	r.addFinding(Finding{
+		Code:     "TIMEOUT",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("Analysis stopped at the %s deadline - only the listed stages completed", a.opts.AnalysisTimeout),
+		Evidence: r.stages,
+	})
+}
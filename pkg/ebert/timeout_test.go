@@ -0,0 +1,44 @@
+package ebert
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRefusedWaitTimesOutAnalysis(t *testing.T) {
+	account := newAccount("throttled", days(2000), GitHubRepo{Name: "tool", Language: "Go", Size: 500, UpdatedAt: fakeNow.Add(-days(2))})
+	f := newFakeGitHub(t, account)
+	f.route("/users/throttled/gists", func(w http.ResponseWriter, r *http.Request) {
+		// A secondary limit asking for a minute's back-off
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, `{"message":"You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+	})
+
+	start := time.Now()
+	analysis, err := newFakeAnalyzer(f, WithAnalysisTimeout(20*time.Second)).Analyze("throttled")
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("analysis took %s, want it to stop without waiting", elapsed)
+	}
+	if analysis == nil {
+		t.Fatalf("no analysis: %v", err)
+	}
+	if !errors.Is(err, ErrWaitExceedsDeadline) && !errors.Is(err, errAnalysisTimeout) {
+		t.Errorf("err = %v, want the refused wait reported", err)
+	}
+
+	timeout := finding(analysis, "TIMEOUT")
+	if timeout == nil {
+		t.Fatal("want a TIMEOUT finding")
+	}
+	if !slices.Contains(timeout.Evidence, "events") || !slices.Contains(timeout.Evidence, "repo_churn") {
+		t.Errorf("completed stages %v should include those before gists", timeout.Evidence)
+	}
+	for _, stage := range []string{"gists", "pull_requests", "denylist"} {
+		if slices.Contains(timeout.Evidence, stage) {
+			t.Errorf("stage %s is listed as completed in %v", stage, timeout.Evidence)
+		}
+	}
+}
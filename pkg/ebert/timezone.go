@@ -0,0 +1,133 @@
+package ebert
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// minTimezoneEvidence is how many events the timezone inference needs
+	minTimezoneEvidence = 100
+
+	// timezoneMismatchHours is how far apart the claimed and inferred
+	// offsets must be before it's worth a note
+	timezoneMismatchHours = 6
+
+	// typicalActivityHour is the local hour around which developer
+	// activity usually centers
+	typicalActivityHour = 15
+)
+
+// locationOffsets maps a small set of city, region and country names to
+// their standard-time UTC offset in hours
+var locationOffsets = map[string]float64{
+	"san francisco": -8, "los angeles": -8, "seattle": -8, "portland": -8, "vancouver": -8, "california": -8, "bay area": -8,
+	"denver": -7, "phoenix": -7, "chicago": -6, "austin": -6, "dallas": -6, "texas": -6, "mexico": -6,
+	"new york": -5, "nyc": -5, "boston": -5, "toronto": -5, "montreal": -5, "washington": -5, "atlanta": -5, "miami": -5,
+	"são paulo": -3, "sao paulo": -3, "brazil": -3, "buenos aires": -3, "argentina": -3,
+	"london": 0, "uk": 0, "united kingdom": 0, "dublin": 0, "ireland": 0, "lisbon": 0, "portugal": 0,
+	"berlin": 1, "germany": 1, "paris": 1, "france": 1, "amsterdam": 1, "netherlands": 1, "madrid": 1, "spain": 1,
+	"rome": 1, "italy": 1, "stockholm": 1, "sweden": 1, "zurich": 1, "switzerland": 1, "vienna": 1, "warsaw": 1, "poland": 1,
+	"helsinki": 2, "finland": 2, "kyiv": 2, "ukraine": 2, "athens": 2, "greece": 2, "cairo": 2, "tel aviv": 2, "israel": 2,
+	"moscow": 3, "russia": 3, "istanbul": 3, "turkey": 3, "nairobi": 3, "dubai": 4,
+	"india": 5.5, "bangalore": 5.5, "bengaluru": 5.5, "mumbai": 5.5, "delhi": 5.5, "hyderabad": 5.5, "pune": 5.5, "chennai": 5.5,
+	"singapore": 8, "china": 8, "beijing": 8, "shanghai": 8, "shenzhen": 8, "hangzhou": 8, "hong kong": 8, "taipei": 8, "taiwan": 8,
+	"tokyo": 9, "japan": 9, "seoul": 9, "korea": 9,
+	"sydney": 10, "melbourne": 10, "australia": 10, "auckland": 12, "new zealand": 12,
+}
+
+// locationOffset maps a free-text profile location to a UTC offset using
+// the longest matching name in the table
+func locationOffset(location string) (float64, bool) {
+	location = strings.ToLower(strings.TrimSpace(location))
+	if location == "" {
+		return 0, false
+	}
+
+	best, offset := "", 0.0
+	for name, o := range locationOffsets {
+		if len(name) > len(best) && containsWord(location, name) {
+			best, offset = name, o
+		}
+	}
+	return offset, best != ""
+}
+
+// containsWord reports whether name appears in s on word boundaries, so
+// "uk" doesn't match "milwaukee"
+func containsWord(s, name string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], name)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(name)
+		if (start == 0 || !isLetter(s[start-1])) && (end == len(s) || !isLetter(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z'
+}
+
+// inferUTCOffset estimates an offset from the UTC hour-of-day histogram,
+// assuming activity centers on typicalActivityHour local time
+func inferUTCOffset(hours [24]int) (float64, int) {
+	var x, y float64
+	total := 0
+	for hour, count := range hours {
+		angle := 2 * math.Pi * float64(hour) / 24
+		x += float64(count) * math.Cos(angle)
+		y += float64(count) * math.Sin(angle)
+		total += count
+	}
+
+	meanHour := math.Atan2(y, x) * 24 / (2 * math.Pi)
+	return math.Round(wrapHours(typicalActivityHour - meanHour)), total
+}
+
+// wrapHours normalizes an hour difference into [-12, 12)
+func wrapHours(h float64) float64 {
+	return math.Mod(math.Mod(h+12, 24)+24, 24) - 12
+}
+
+// inferTimezone records the UTC offset the event hours suggest, when
+// there were enough events to tell
+func (c RuleContext) inferTimezone() {
+	if !c.coverage().events {
+		return
+	}
+	if inferred, evidence := inferUTCOffset(c.run.acc.eventHours); evidence >= minTimezoneEvidence {
+		c.run.acc.metrics.InferredUTCOffset = &inferred
+	}
+}
+
+// timezoneMismatchRule notes when the claimed location and the hours the
+// account is active in are far apart. It is informational only: travel,
+// night owls and remote work all explain it innocently.
+var timezoneMismatchRule = rule{
+	code:        "TIMEZONE_MISMATCH",
+	description: "Active hours suggest a timezone far from the profile location",
+	evaluate: func(c RuleContext) []Finding {
+		inferred := c.run.acc.metrics.InferredUTCOffset
+		if !c.coverage().events || inferred == nil {
+			return nil
+		}
+		claimed, ok := locationOffset(c.run.user.Location)
+		if !ok || math.Abs(wrapHours(*inferred-claimed)) <= timezoneMismatchHours {
+			return nil
+		}
+		_, evidence := inferUTCOffset(c.run.acc.eventHours)
+		return []Finding{{
+			Code:     "TIMEZONE_MISMATCH",
+			Severity: SeverityInfo,
+			Message: fmt.Sprintf("Activity suggests UTC%+g but the profile location %q is around UTC%+g",
+				*inferred, c.run.user.Location, claimed),
+			Evidence: []string{fmt.Sprintf("%d events", evidence)},
+		}}
+	},
+}
@@ -0,0 +1,131 @@
+package ebert
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLocationOffset(t *testing.T) {
+	for _, tt := range []struct {
+		location string
+		want     float64
+		ok       bool
+	}{
+		{"San Francisco, CA", -8, true},
+		{"  LONDON, UK ", 0, true},
+		{"Bengaluru, India", 5.5, true},
+		{"São Paulo, Brazil", -3, true},
+		{"Mexico City, Mexico", -6, true},
+		{"New York City", -5, true},
+		// Names only match whole words
+		{"Milwaukee", 0, false},
+		{"Remote", 0, false},
+		{"", 0, false},
+	} {
+		got, ok := locationOffset(tt.location)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("locationOffset(%q) = %v, %t; want %v, %t", tt.location, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestInferUTCOffset(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		hours map[int]int
+		want  float64
+	}{
+		{"afternoon UTC", map[int]int{14: 10, 15: 20, 16: 10}, 0},
+		{"Pacific afternoons", map[int]int{22: 10, 23: 20, 0: 10}, -8},
+		{"Tokyo afternoons", map[int]int{5: 10, 6: 20, 7: 10}, 9},
+	} {
+		var hours [24]int
+		total := 0
+		for hour, n := range tt.hours {
+			hours[hour] = n
+			total += n
+		}
+		got, evidence := inferUTCOffset(hours)
+		if got != tt.want || evidence != total {
+			t.Errorf("%s: inferUTCOffset = %v from %d events, want %v from %d", tt.name, got, evidence, tt.want, total)
+		}
+	}
+
+	for _, tt := range []struct{ in, want float64 }{{0, 0}, {13, -11}, {-13, 11}, {12, -12}, {-30, -6}} {
+		if got := wrapHours(tt.in); got != tt.want {
+			t.Errorf("wrapHours(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// dayShiftFeed is a feed of n pushes between 10:00 and 18:00 UTC
+func dayShiftFeed(n int) []GitHubEvent {
+	events := pushFeed("octo", n, 1)
+	for i := range events {
+		day := fakeNow.Truncate(24*time.Hour).AddDate(0, 0, -1-i/9)
+		events[i].CreatedAt = day.Add(time.Duration(10+i%9) * time.Hour)
+	}
+	return events
+}
+
+func TestTimezoneMismatch(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		location string
+		events   int
+		// inferred is whether there were enough events to tell; the
+		// feed suggests UTC+1 when there were
+		inferred bool
+		message  string
+	}{
+		{"far from the location", "San Francisco, CA", 150, true,
+			`Activity suggests UTC+1 but the profile location "San Francisco, CA" is around UTC-8`},
+		{"matching the location", "Berlin, Germany", 150, true, ""},
+		{"no location", "", 150, true, ""},
+		{"unknown location", "Somewhere", 150, true, ""},
+		{"too few events", "San Francisco, CA", 99, false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, UpdatedAt: fakeNow.Add(-days(2))})
+			account.User.Location = tt.location
+			account.Events = dayShiftFeed(tt.events)
+
+			analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			inferred := analysis.Metrics.InferredUTCOffset
+			if (inferred != nil) != tt.inferred || inferred != nil && *inferred != 1 {
+				t.Errorf("InferredUTCOffset = %v, inferred %t", inferred, tt.inferred)
+			}
+			flag := finding(analysis, "TIMEZONE_MISMATCH")
+			if tt.message == "" {
+				if flag != nil {
+					t.Errorf("unexpected %+v", flag)
+				}
+				return
+			}
+			// Never more than a note
+			if flag == nil || flag.Severity != SeverityInfo || flag.Message != tt.message || !slices.Equal(flag.Evidence, []string{"150 events"}) {
+				t.Errorf("TIMEZONE_MISMATCH = %+v, want an informational %q", flag, tt.message)
+			}
+		})
+	}
+}
+
+func TestTimezoneMismatchUnscored(t *testing.T) {
+	score := func(location string) float64 {
+		account := newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, UpdatedAt: fakeNow.Add(-days(2))})
+		account.User.Location = location
+		account.Events = dayShiftFeed(150)
+		analysis, err := newFakeAnalyzer(newFakeGitHub(t, account)).Analyze("octo")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		return analysis.OverallScore
+	}
+	if far, near := score("San Francisco"), score("Berlin"); far != near {
+		t.Errorf("overall risk is %v far from the location and %v near it, want the mismatch unscored", far, near)
+	}
+}
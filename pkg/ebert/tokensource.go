@@ -0,0 +1,244 @@
+package ebert
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime is how long a minted app JWT is valid; GitHub refuses
+	// any over ten minutes
+	appJWTLifetime = 9 * time.Minute
+
+	// appJWTBackdate backdates the JWT's issue time against clock drift
+	appJWTBackdate = time.Minute
+
+	// installationTokenRefresh is how long before expiry an installation
+	// token is replaced, so no request goes out with one about to lapse
+	installationTokenRefresh = 5 * time.Minute
+)
+
+// TokenSource supplies the token each request is authenticated with, so
+// static tokens and short-lived ones refreshed mid-run share the client
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token, such as
+// a personal access token
+type StaticToken string
+
+// Token implements TokenSource
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// hasToken reports whether the client was configured with any token
+func (c *GitHubClient) hasToken() bool {
+	return c.Token != "" || c.TokenSource != nil
+}
+
+// token returns the token for the next request from TokenSource, or Token
+func (c *GitHubClient) token(ctx context.Context) (string, error) {
+	if c.TokenSource == nil {
+		return c.Token, nil
+	}
+	token, err := c.TokenSource.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get a token: %w", err)
+	}
+	return token, nil
+}
+
+// AppTokenSource authenticates as a GitHub App installation: it signs a
+// short-lived JWT with the app's private key, exchanges it for an
+// installation token, and mints a new one shortly before each expires, so
+// analyses outliving the one-hour token keep going. It is safe for
+// concurrent use.
+type AppTokenSource struct {
+	// AppID is the App's numeric ID
+	AppID int64
+
+	// InstallationID is the installation to act as; when zero it is
+	// discovered from Account's installation of the App
+	InstallationID int64
+	Account        string
+
+	// BaseURL is the REST API root, https://api.github.com if empty
+	BaseURL string
+
+	// HTTPClient sends the token requests; a shared client with a ten
+	// second timeout is used if nil
+	HTTPClient *http.Client
+
+	key *rsa.PrivateKey
+
+	// now is the clock expiry is judged by; nil means time.Now
+	now func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewAppTokenSource returns a source for appID signing with the PEM
+// private key downloaded from the App's settings, PKCS #1 or PKCS #8
+func NewAppTokenSource(appID int64, privateKeyPEM []byte) (*AppTokenSource, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("failed to parse app private key: %w", err)
+		}
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("app private key is not an RSA key")
+		}
+		key = rsaKey
+	}
+	return &AppTokenSource{AppID: appID, key: key}, nil
+}
+
+func (s *AppTokenSource) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// Token implements TokenSource, returning the cached installation token
+// until it is within installationTokenRefresh of expiring
+func (s *AppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.clock().Before(s.expires.Add(-installationTokenRefresh)) {
+		return s.token, nil
+	}
+	jwt, err := s.jwt()
+	if err != nil {
+		return "", err
+	}
+	if s.InstallationID == 0 {
+		if s.InstallationID, err = s.discoverInstallation(ctx, jwt); err != nil {
+			return "", err
+		}
+	}
+
+	var minted struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.baseURL(), s.InstallationID)
+	if err := s.call(ctx, http.MethodPost, url, jwt, &minted); err != nil {
+		return "", fmt.Errorf("failed to mint installation token: %w", err)
+	}
+	if minted.Token == "" {
+		return "", errors.New("failed to mint installation token: empty token")
+	}
+	s.token, s.expires = minted.Token, minted.ExpiresAt
+	return s.token, nil
+}
+
+// discoverInstallation looks up the App's installation on Account
+func (s *AppTokenSource) discoverInstallation(ctx context.Context, jwt string) (int64, error) {
+	if s.Account == "" {
+		return 0, errors.New("app installation ID or account required")
+	}
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	endpoint := fmt.Sprintf("%s/users/%s/installation", s.baseURL(), url.PathEscape(s.Account))
+	if err := s.call(ctx, http.MethodGet, endpoint, jwt, &installation); err != nil {
+		return 0, fmt.Errorf("failed to find the app's installation on %s: %w", s.Account, err)
+	}
+	return installation.ID, nil
+}
+
+func (s *AppTokenSource) baseURL() string {
+	if s.BaseURL == "" {
+		return "https://api.github.com"
+	}
+	return s.BaseURL
+}
+
+// call sends one request authenticated as the App and decodes the answer
+func (s *AppTokenSource) call(ctx context.Context, method, url, jwt string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", defaultAccept)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	client := s.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return connectionHint(err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return &APIError{StatusCode: resp.StatusCode, URL: url}
+	}
+	return json.Unmarshal(data, out)
+}
+
+// jwt signs the RS256 JWT identifying the App
+func (s *AppTokenSource) jwt() (string, error) {
+	now := s.clock()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTBackdate).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(s.AppID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var signed bytes.Buffer
+	signed.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	signed.WriteByte('.')
+	signed.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+	digest := sha256.Sum256(signed.Bytes())
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app JWT: %w", err)
+	}
+	signed.WriteByte('.')
+	signed.WriteString(base64.RawURLEncoding.EncodeToString(signature))
+	return signed.String(), nil
+}
@@ -0,0 +1,171 @@
+package ebert
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeApp is the GitHub side of an App installation on acme, minting
+// hour-long installation tokens numbered in order
+type fakeApp struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	now      func() time.Time
+	minted   atomic.Int32
+	lookups  atomic.Int32
+	sentWith []string
+}
+
+func newFakeApp(t *testing.T, now func() time.Time) *fakeApp {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &fakeApp{key: key, now: now}
+	app.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/acme/installation":
+			if err := app.verify(r); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			app.lookups.Add(1)
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/app/installations/7/access_tokens":
+			if err := app.verify(r); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"token":      fmt.Sprintf("ghs_%d", app.minted.Add(1)),
+				"expires_at": app.now().Add(time.Hour),
+			})
+		default:
+			// An API call made with an installation token
+			app.sentWith = append(app.sentWith, r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"login":"octo"}`))
+		}
+	}))
+	t.Cleanup(app.Close)
+	return app
+}
+
+// verify checks r carries a JWT the App's key signed, issued by app 42 and
+// valid now
+func (app *fakeApp) verify(r *http.Request) error {
+	jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(jwt, ".")
+	if !ok || len(parts) != 3 {
+		return fmt.Errorf("no JWT in %q", r.Header.Get("Authorization"))
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&app.key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	now := app.now().Unix()
+	if claims.Issuer != "42" || claims.IssuedAt > now || claims.ExpiresAt <= now || claims.ExpiresAt-claims.IssuedAt > 600 {
+		return fmt.Errorf("claims %+v aren't valid at %d", claims, now)
+	}
+	return nil
+}
+
+// keyPEM is the App's private key as downloaded from its settings
+func (app *fakeApp) keyPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(app.key)})
+}
+
+func TestAppTokenSourceRefreshesNearExpiry(t *testing.T) {
+	now := fakeNow
+	clock := func() time.Time { return now }
+	app := newFakeApp(t, clock)
+
+	source, err := NewAppTokenSource(42, app.keyPEM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.Account, source.BaseURL, source.now = "acme", app.URL, clock
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		want    string
+	}{
+		{0, "ghs_1"},
+		// Cached while more than installationTokenRefresh is left
+		{time.Hour - installationTokenRefresh - time.Second, "ghs_1"},
+		{time.Hour - installationTokenRefresh, "ghs_2"},
+		{time.Hour, "ghs_2"},
+		// Long past the second token's expiry
+		{3 * time.Hour, "ghs_3"},
+	} {
+		now = fakeNow.Add(tc.elapsed)
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("after %s: Token: %v", tc.elapsed, err)
+		}
+		if token != tc.want {
+			t.Errorf("after %s: token %s, want %s", tc.elapsed, token, tc.want)
+		}
+	}
+	if app.lookups.Load() != 1 {
+		t.Errorf("looked the installation up %d times, want once", app.lookups.Load())
+	}
+}
+
+func TestAppTokenSourceMidRun(t *testing.T) {
+	now := fakeNow
+	clock := func() time.Time { return now }
+	app := newFakeApp(t, clock)
+	source, err := NewAppTokenSource(42, app.keyPEM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.InstallationID, source.BaseURL, source.now = 7, app.URL, clock
+
+	client := NewGitHubClient("")
+	client.BaseURL, client.TokenSource = app.URL, source
+	for _, elapsed := range []time.Duration{0, 30 * time.Minute, 58 * time.Minute} {
+		now = fakeNow.Add(elapsed)
+		if _, err := client.GetUser(context.Background(), "octo"); err != nil {
+			t.Fatalf("after %s: %v", elapsed, err)
+		}
+	}
+	want := []string{"token ghs_1", "token ghs_1", "token ghs_2"}
+	if strings.Join(app.sentWith, " ") != strings.Join(want, " ") {
+		t.Errorf("requests sent %q, want %q", app.sentWith, want)
+	}
+	if app.lookups.Load() != 0 {
+		t.Error("looked up the installation despite its ID")
+	}
+}
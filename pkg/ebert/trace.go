@@ -0,0 +1,58 @@
+package ebert
+
+import "context"
+
+// Tracer starts spans around analyses and API calls. It is deliberately
+// small so tracing backends can be adapted without ebert depending on them;
+// see the otelebert package for OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced unit of work
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair recorded on a span. Values are strings,
+// bools, ints, int64s or float64s.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// noopTracer is used when no Tracer is configured
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// endSpan records err, if any, and ends the span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type spanKey struct{}
+
+// withSpan remembers the endpoint span on ctx so individual requests can
+// annotate it
+func withSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func spanFrom(ctx context.Context) Span {
+	span, _ := ctx.Value(spanKey{}).(Span)
+	return span
+}
@@ -0,0 +1,103 @@
+package ebert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TransportOptions configure the transport built by NewTransport
+type TransportOptions struct {
+	// CABundles are PEM files of extra trusted roots appended to the
+	// system pool, e.g. the CA of a TLS-intercepting proxy. SSL_CERT_FILE
+	// is appended too when set.
+	CABundles []string
+
+	// InsecureSkipVerify disables certificate verification entirely. It
+	// exposes the token to anyone on the path and is only for labs.
+	InsecureSkipVerify bool
+}
+
+// NewTransport builds an HTTP transport that honors HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY and trusts the system roots plus opts.CABundles
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	transport := newProxyTransport()
+
+	bundles := opts.CABundles
+	if path := os.Getenv("SSL_CERT_FILE"); path != "" {
+		bundles = append(bundles[:len(bundles):len(bundles)], path)
+	}
+	if len(bundles) == 0 && !opts.InsecureSkipVerify {
+		return transport, nil
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
+	for _, path := range bundles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", path)
+		}
+	}
+
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            roots,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	return transport, nil
+}
+
+// newProxyTransport is a copy of http.DefaultTransport that explicitly
+// routes through the proxy the environment names
+func newProxyTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}
+
+// connectionHint adds the likely cause to a failed connection: an
+// untrusted certificate usually means a TLS-intercepting proxy, and a
+// proxyconnect failure a misconfigured HTTPS_PROXY
+func connectionHint(err error) error {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var verification *tls.CertificateVerificationError
+	var record tls.RecordHeaderError
+	switch {
+	case errors.As(err, &unknownAuthority), errors.As(err, &invalid), errors.As(err, &verification):
+		return fmt.Errorf("%w (TLS verification failed; behind an intercepting proxy, trust its CA with --ca-bundle or SSL_CERT_FILE)", err)
+	case errors.As(err, &hostname):
+		return fmt.Errorf("%w (certificate is for another host; a proxy may be intercepting TLS)", err)
+	case errors.As(err, &record), strings.Contains(err.Error(), plainHTTPResponse):
+		return fmt.Errorf("%w (the server didn't speak TLS; check HTTPS_PROXY points at a proxy, not a plain HTTP port)", err)
+	}
+
+	var urlErr *url.Error
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" || errors.As(err, &urlErr) && urlErr.Op == "proxyconnect" {
+		return fmt.Errorf("%w (couldn't reach the proxy; check HTTPS_PROXY and NO_PROXY)", err)
+	}
+	return err
+}
+
+// plainHTTPResponse is how net/http reports the record header error of a
+// TLS request answered in plain HTTP, without wrapping it
+const plainHTTPResponse = "server gave HTTP response to HTTPS client"
+
+// defaultHTTPClient is shared by clients without their own HTTPClient so
+// connections are pooled across concurrent analyses
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second, Transport: newProxyTransport()}
@@ -0,0 +1,136 @@
+package ebert
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTLSAPI serves a user over TLS with a certificate from its own CA,
+// returning the server and a PEM bundle of that CA
+func newTLSAPI(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"login":"octo","type":"User"}`))
+	}))
+	// Untrusted clients fail the handshake, which the server would log
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return srv, bundle
+}
+
+// getUser fetches octo from srv through transport, as an analysis would
+func getUser(srv *httptest.Server, transport http.RoundTripper) error {
+	client := NewGitHubClient("")
+	client.BaseURL = srv.URL
+	client.HTTPClient = &http.Client{Transport: transport}
+	_, err := client.GetUser(context.Background(), "octo")
+	return err
+}
+
+func TestNewTransportCABundle(t *testing.T) {
+	t.Setenv("SSL_CERT_FILE", "")
+	srv, bundle := newTLSAPI(t)
+
+	untrusted, err := NewTransport(TransportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = getUser(srv, untrusted)
+	if err == nil {
+		t.Fatal("the server's private CA was trusted without its bundle")
+	}
+	if !strings.Contains(err.Error(), "--ca-bundle") {
+		t.Errorf("the failure %q doesn't hint at the CA bundle", err)
+	}
+
+	trusted, err := NewTransport(TransportOptions{CABundles: []string{bundle}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := getUser(srv, trusted); err != nil {
+		t.Errorf("the bundle's CA wasn't trusted: %v", err)
+	}
+
+	t.Setenv("SSL_CERT_FILE", bundle)
+	fromEnv, err := NewTransport(TransportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := getUser(srv, fromEnv); err != nil {
+		t.Errorf("SSL_CERT_FILE's CA wasn't trusted: %v", err)
+	}
+}
+
+func TestNewTransportInsecureSkipVerify(t *testing.T) {
+	t.Setenv("SSL_CERT_FILE", "")
+	srv, _ := newTLSAPI(t)
+	transport, err := NewTransport(TransportOptions{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := getUser(srv, transport); err != nil {
+		t.Errorf("verification wasn't skipped: %v", err)
+	}
+}
+
+func TestNewTransportBadBundle(t *testing.T) {
+	t.Setenv("SSL_CERT_FILE", "")
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{notPEM, filepath.Join(dir, "missing.pem")} {
+		if _, err := NewTransport(TransportOptions{CABundles: []string{path}}); err == nil {
+			t.Errorf("NewTransport accepted the bundle %s", filepath.Base(path))
+		}
+	}
+}
+
+func TestConnectionHint(t *testing.T) {
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	// A TLS request to a plain HTTP port fails on the record header, which
+	// net/http doesn't wrap
+	_, tlsErr := http.Get(strings.Replace(plain.URL, "http://", "https://", 1))
+	if tlsErr == nil {
+		t.Fatal("a plain HTTP server answered over TLS")
+	}
+
+	proxyErr := &url.Error{Op: "Get", URL: "https://api.github.com", Err: &net.OpError{Op: "proxyconnect", Net: "tcp", Err: errors.New("connection refused")}}
+	other := errors.New("connection reset by peer")
+	for _, tc := range []struct {
+		err  error
+		hint string
+	}{
+		{tlsErr, "check HTTPS_PROXY points at a proxy"},
+		{proxyErr, "couldn't reach the proxy"},
+		{other, ""},
+	} {
+		got := connectionHint(tc.err)
+		if !errors.Is(got, tc.err) {
+			t.Errorf("connectionHint(%v) = %v, which doesn't wrap the error", tc.err, got)
+		}
+		if tc.hint == "" && got != tc.err || !strings.Contains(got.Error(), tc.hint) {
+			t.Errorf("connectionHint(%v) = %q, want the hint %q", tc.err, got, tc.hint)
+		}
+	}
+}
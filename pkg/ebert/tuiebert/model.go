@@ -0,0 +1,161 @@
+package tuiebert
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// Pane is the list the cursor moves in
+type Pane int
+
+// Panes, in tab order
+const (
+	PaneFindings Pane = iota
+	PaneRepos
+
+	paneCount = 2
+)
+
+// RepoSort orders the repos table
+type RepoSort int
+
+// Repo orders, cycled by the s key
+const (
+	SortStars RepoSort = iota
+	SortUpdated
+	SortName
+
+	sortCount = 3
+)
+
+func (s RepoSort) String() string {
+	return [...]string{"stars", "updated", "name"}[s]
+}
+
+// Action is what a key press asks the program to do beyond redrawing
+type Action struct {
+	Quit bool
+
+	// Open is a URL to open in the browser
+	Open string
+}
+
+// Model is the explorer's state. It is driven by Press alone, so a test
+// can script key presses against it without a terminal.
+type Model struct {
+	Analysis *ebert.Analysis
+	Repos    []ebert.GitHubRepo
+
+	Pane    Pane
+	Sort    RepoSort
+	Finding int
+	Repo    int
+}
+
+// NewModel explores analysis; repos fills the repos table and may be nil
+// when the analysis was saved without raw data
+func NewModel(analysis *ebert.Analysis, repos []ebert.GitHubRepo) *Model {
+	m := &Model{Analysis: analysis, Repos: append([]ebert.GitHubRepo(nil), repos...)}
+	m.sortRepos()
+	return m
+}
+
+// Press applies one key, named as bubbletea names them: "up", "down",
+// "left", "right", "tab", "o", "s", "q", "ctrl+c"
+func (m *Model) Press(key string) Action {
+	switch key {
+	case "q", "ctrl+c", "esc":
+		return Action{Quit: true}
+	case "tab", "right", "l":
+		m.Pane = (m.Pane + 1) % paneCount
+	case "shift+tab", "left", "h":
+		m.Pane = (m.Pane + paneCount - 1) % paneCount
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "home", "g":
+		m.move(-len(m.Analysis.Findings) - len(m.Repos))
+	case "end", "G":
+		m.move(len(m.Analysis.Findings) + len(m.Repos))
+	case "s":
+		if m.Pane == PaneRepos {
+			m.Sort = (m.Sort + 1) % sortCount
+			m.sortRepos()
+		}
+	case "o":
+		if url := m.selectedURL(); url != "" {
+			return Action{Open: url}
+		}
+	}
+	return Action{}
+}
+
+// move shifts the cursor of the active pane by delta, clamped to its list
+func (m *Model) move(delta int) {
+	if m.Pane == PaneFindings {
+		m.Finding = clamp(m.Finding+delta, len(m.Analysis.Findings))
+		return
+	}
+	m.Repo = clamp(m.Repo+delta, len(m.Repos))
+}
+
+func clamp(i, n int) int {
+	return max(0, min(i, n-1))
+}
+
+// SelectedFinding is the finding under the cursor, if any
+func (m *Model) SelectedFinding() (ebert.Finding, bool) {
+	if len(m.Analysis.Findings) == 0 {
+		return ebert.Finding{}, false
+	}
+	return m.Analysis.Findings[m.Finding], true
+}
+
+// SelectedRepo is the repo under the cursor, if any
+func (m *Model) SelectedRepo() (ebert.GitHubRepo, bool) {
+	if len(m.Repos) == 0 {
+		return ebert.GitHubRepo{}, false
+	}
+	return m.Repos[m.Repo], true
+}
+
+// selectedURL is what o opens: the selected repo, or the profile from the
+// findings pane
+func (m *Model) selectedURL() string {
+	if m.Pane == PaneRepos {
+		if repo, ok := m.SelectedRepo(); ok {
+			return repo.HTMLURL
+		}
+		return ""
+	}
+	return m.Analysis.User.HTMLURL
+}
+
+// sortRepos orders the table by the current sort, keeping the cursor on
+// the repo it was on
+func (m *Model) sortRepos() {
+	selected, _ := m.SelectedRepo()
+	sort.SliceStable(m.Repos, func(i, j int) bool {
+		a, b := m.Repos[i], m.Repos[j]
+		switch m.Sort {
+		case SortUpdated:
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+		case SortStars:
+			if a.StargazersCount != b.StargazersCount {
+				return a.StargazersCount > b.StargazersCount
+			}
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	for i, repo := range m.Repos {
+		if repo.FullName == selected.FullName && repo.Name == selected.Name {
+			m.Repo = i
+			return
+		}
+	}
+}
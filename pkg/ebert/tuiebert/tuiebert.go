@@ -0,0 +1,132 @@
+// Package tuiebert is an interactive terminal explorer for a completed
+// analysis, keeping the terminal UI dependencies out of the core package.
+// The Model holds the state and Render draws it; Run wires both to a
+// bubbletea program.
+package tuiebert
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// ErrNotTerminal is returned by Run when stdin or stdout isn't a terminal
+var ErrNotTerminal = errors.New("the explorer needs an interactive terminal; use --json or the text report instead")
+
+// CheckTerminal returns ErrNotTerminal unless stdin and stdout are both
+// terminals
+func CheckTerminal() error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return ErrNotTerminal
+	}
+	return nil
+}
+
+// DefaultHost is the GitHub web host whose links the explorer opens
+const DefaultHost = "github.com"
+
+// ErrUnsafeURL is returned for a link the explorer won't hand to the
+// platform's URL handler
+var ErrUnsafeURL = errors.New("refusing to open a link that isn't https on the GitHub host")
+
+// Run explores analysis, with repos in the repos table, until the user
+// quits. It refuses to start outside a terminal.
+func Run(analysis *ebert.Analysis, repos []ebert.GitHubRepo) error {
+	return RunOn(DefaultHost, analysis, repos)
+}
+
+// RunOn is Run for a GitHub Enterprise instance at host, whose links
+// alone the explorer opens
+func RunOn(host string, analysis *ebert.Analysis, repos []ebert.GitHubRepo) error {
+	if err := CheckTerminal(); err != nil {
+		return err
+	}
+	open := func(link string) error { return OpenOn(host, link) }
+	_, err := tea.NewProgram(&program{model: NewModel(analysis, repos), open: open}, tea.WithAltScreen()).Run()
+	return err
+}
+
+// program adapts a Model to bubbletea
+type program struct {
+	model         *Model
+	width, height int
+	open          func(url string) error
+}
+
+func (p *program) Init() tea.Cmd {
+	return nil
+}
+
+func (p *program) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width, p.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		action := p.model.Press(msg.String())
+		if action.Quit {
+			return p, tea.Quit
+		}
+		if action.Open != "" {
+			// A browser that fails to start leaves the explorer usable
+			_ = p.open(action.Open)
+		}
+	}
+	return p, nil
+}
+
+func (p *program) View() string {
+	return Render(p.model, p.width, p.height)
+}
+
+// OpenBrowser opens an https link on github.com with the platform's
+// default handler
+func OpenBrowser(link string) error {
+	return OpenOn(DefaultHost, link)
+}
+
+// OpenOn opens link with the platform's default handler if it is an https
+// link on host. The links come from API data, and the handler would as
+// happily run a file: or custom-scheme URL, so nothing else is passed on.
+func OpenOn(host, link string) error {
+	if err := checkLink(host, link); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// The handler exits once the browser has the link; reap it so repeated
+	// opens don't leave zombies behind
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// checkLink accepts only a plain https URL on host
+func checkLink(host, link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnsafeURL, err)
+	}
+	if u.Scheme != "https" || u.User != nil || u.Port() != "" || !strings.EqualFold(u.Hostname(), host) {
+		return fmt.Errorf("%w: %q", ErrUnsafeURL, link)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package tuiebert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckLink(t *testing.T) {
+	for _, tt := range []struct {
+		host, link string
+		ok         bool
+	}{
+		{"github.com", "https://github.com/octo/tool", true},
+		{"github.com", "https://GitHub.com/octo", true},
+		{"ghe.example", "https://ghe.example/octo/tool", true},
+		{"github.com", "http://github.com/octo", false},
+		{"github.com", "file:///etc/passwd", false},
+		{"github.com", "javascript:alert(1)", false},
+		{"github.com", "vscode://file/tmp/x", false},
+		{"github.com", "https://github.com.evil.example/octo", false},
+		{"github.com", "https://evil.example/github.com", false},
+		{"github.com", "https://user@github.com/octo", false},
+		{"github.com", "https://github.com:8443/octo", false},
+		{"github.com", "--help", false},
+		{"github.com", "", false},
+		{"ghe.example", "https://github.com/octo", false},
+	} {
+		err := checkLink(tt.host, tt.link)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s on %s: err = %v, want ok %v", tt.link, tt.host, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrUnsafeURL) {
+			t.Errorf("%s: err = %v, want ErrUnsafeURL", tt.link, err)
+		}
+	}
+}
+
+func TestOpenOnRefusesBeforeStarting(t *testing.T) {
+	// Nothing is started for a refused link, so this is safe to run anywhere
+	if err := OpenOn("github.com", "file:///etc/passwd"); !errors.Is(err, ErrUnsafeURL) {
+		t.Fatalf("err = %v, want ErrUnsafeURL", err)
+	}
+}
@@ -0,0 +1,219 @@
+package tuiebert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JamesWoolfenden/ebert/pkg/ebert"
+)
+
+// gaugeWidth is how many cells a score gauge spans
+const gaugeWidth = 20
+
+// severityTags label findings in the list
+var severityTags = map[ebert.Severity]string{
+	ebert.SeverityRedFlag:  "FLAG",
+	ebert.SeverityWarning:  "WARN",
+	ebert.SeverityInfo:     "INFO",
+	ebert.SeverityPositive: " OK ",
+}
+
+// Render draws the model into a width by height screen. It only reads
+// the model, so the same state always renders the same text.
+func Render(m *Model, width, height int) string {
+	width, height = max(width, 40), max(height, 16)
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, truncate(fmt.Sprintf(format, args...), width))
+	}
+
+	a := m.Analysis
+	add("ebert: @%s  risk %s  score %.1f/100 (lower is better)  confidence %.0f%%",
+		a.User.Login, strings.ToUpper(a.RiskLevel), a.OverallScore, a.Confidence*100)
+	if missing := a.MissingSources(); len(missing) > 0 {
+		add("partial report - missing data: %s", strings.Join(missing, ", "))
+	}
+	add("")
+	for _, row := range []struct {
+		name  string
+		score *float64
+	}{
+		{"Identity", a.Scores.Identity},
+		{"Activity", a.Scores.Activity},
+		{"Quality", a.Scores.Quality},
+		{"Maintenance", a.Scores.Maintenance},
+		{"Community", a.Scores.Community},
+		{"Security", a.Scores.Security},
+	} {
+		add("  %-12s %s", row.name, gauge(row.score))
+	}
+	add("")
+	add("%s", tabs(m))
+	add("%s", strings.Repeat("─", width))
+
+	// The footer takes the last line; the pane gets what is left
+	body := max(height-len(lines)-1, 1)
+	if m.Pane == PaneFindings {
+		lines = append(lines, findingsPane(m, width, body)...)
+	} else {
+		lines = append(lines, reposPane(m, width, body)...)
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:height-1], truncate(footer(m), width))
+	return strings.Join(lines, "\n")
+}
+
+// gauge draws a 0-100 risk score as a bar, or n/a when not computed
+func gauge(score *float64) string {
+	if score == nil {
+		return strings.Repeat("·", gaugeWidth) + "   n/a"
+	}
+	filled := int(*score/100*gaugeWidth + 0.5)
+	filled = max(0, min(filled, gaugeWidth))
+	return strings.Repeat("█", filled) + strings.Repeat("░", gaugeWidth-filled) + fmt.Sprintf(" %5.1f", *score)
+}
+
+func tabs(m *Model) string {
+	findings := fmt.Sprintf(" Findings (%d) ", len(m.Analysis.Findings))
+	repos := fmt.Sprintf(" Repos (%d, by %s) ", len(m.Repos), m.Sort)
+	if m.Pane == PaneFindings {
+		return "[" + findings + "]  " + repos
+	}
+	return " " + findings + "  [" + repos + "]"
+}
+
+func footer(m *Model) string {
+	if m.Pane == PaneRepos {
+		return "↑/↓ move  ←/→ switch pane  s sort  o open repo  q quit"
+	}
+	return "↑/↓ move  ←/→ switch pane  o open profile  q quit"
+}
+
+// findingsPane lists the findings with the selected one's evidence below
+func findingsPane(m *Model, width, height int) []string {
+	findings := m.Analysis.Findings
+	if len(findings) == 0 {
+		return []string{"  No findings"}
+	}
+
+	// Half the pane lists findings, the rest shows the selected one
+	listHeight := max(min(len(findings), height/2), 1)
+	var lines []string
+	for i := window(m.Finding, len(findings), listHeight); i < len(findings) && len(lines) < listHeight; i++ {
+		cursor := "  "
+		if i == m.Finding {
+			cursor = "> "
+		}
+		lines = append(lines, truncate(fmt.Sprintf("%s%s %-22s %s", cursor, severityTags[findings[i].Severity], findings[i].Code, findings[i].Message), width))
+	}
+
+	selected, _ := m.SelectedFinding()
+	lines = append(lines, "")
+	for _, line := range wrap(selected.Message, width-2) {
+		lines = append(lines, "  "+line)
+	}
+	for _, evidence := range selected.Evidence {
+		for i, line := range wrap(evidence, width-6) {
+			prefix := "    • "
+			if i > 0 {
+				prefix = "      "
+			}
+			lines = append(lines, prefix+line)
+		}
+	}
+	if selected.Remediation != "" {
+		lines = append(lines, "")
+		for _, line := range wrap("Fix: "+selected.Remediation, width-2) {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return lines[:min(len(lines), height)]
+}
+
+// reposPane is the repos table, scrolled to keep the cursor in view
+func reposPane(m *Model, width, height int) []string {
+	if len(m.Repos) == 0 {
+		return []string{"  No repos - analyze with --raw or view a report saved with --raw to list them"}
+	}
+
+	lines := []string{truncate(fmt.Sprintf("  %-40s %7s %6s  %-10s  %s", "REPO", "STARS", "FORKS", "UPDATED", "FLAGS"), width)}
+	rows := max(height-1, 1)
+	for i := window(m.Repo, len(m.Repos), rows); i < len(m.Repos) && len(lines) <= rows; i++ {
+		repo := m.Repos[i]
+		cursor := "  "
+		if i == m.Repo {
+			cursor = "> "
+		}
+		updated := ""
+		if !repo.UpdatedAt.IsZero() {
+			updated = repo.UpdatedAt.Format("2006-01-02")
+		}
+		lines = append(lines, truncate(fmt.Sprintf("%s%-40s %7d %6d  %-10s  %s", cursor, truncate(repo.Name, 40), repo.StargazersCount, repo.ForksCount, updated, repoFlags(repo)), width))
+	}
+	return lines
+}
+
+// repoFlags marks forks, archives and the like in the table
+func repoFlags(repo ebert.GitHubRepo) string {
+	var flags []string
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{repo.Fork, "fork"},
+		{repo.Archived, "archived"},
+		{repo.IsTemplate, "template"},
+		{repo.MirrorURL != "", "mirror"},
+		{repo.Disabled, "disabled"},
+	} {
+		if flag.set {
+			flags = append(flags, flag.name)
+		}
+	}
+	return strings.Join(flags, ",")
+}
+
+// window is the first row to draw so that cursor shows in a list of n
+// rows given height lines
+func window(cursor, n, height int) int {
+	return max(0, min(cursor-height/2, n-height))
+}
+
+// truncate cuts s to width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// wrap breaks s into lines of at most width runes at spaces
+func wrap(s string, width int) []string {
+	width = max(width, 10)
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	return lines
+}
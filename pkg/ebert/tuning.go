@@ -0,0 +1,250 @@
+package ebert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+const (
+	// DefaultTuneIterations bounds the weightings TuneWeights tries
+	DefaultTuneIterations = 2000
+
+	// DefaultMinWeight is the share of the total weight every dimension
+	// keeps, so tuning never drops one entirely
+	DefaultMinWeight = 0.05
+
+	// tuneGridSteps divides each weight of the coarse grid searched before
+	// the random samples
+	tuneGridSteps = 4
+)
+
+// TuneOptions bounds and seeds a weight search
+type TuneOptions struct {
+	// Iterations bounds the candidate weightings tried, DefaultTuneIterations
+	// when zero
+	Iterations int
+
+	// Seed seeds the random samples; the same seed and results always
+	// find the same weights
+	Seed uint64
+
+	// MinWeight is the share of the total every dimension keeps
+	MinWeight float64
+}
+
+// TuneResult is the best weighting found for a labeled set, with the
+// calibration it scores and the calibration of the weights the accounts
+// were analyzed with. Weights sum to one.
+type TuneResult struct {
+	Weights    Weights           `json:"weights"`
+	Separation float64           `json:"separation"`
+	Report     CalibrationReport `json:"report"`
+	Baseline   CalibrationReport `json:"baseline"`
+	Candidates int               `json:"candidates"`
+}
+
+// tuneCandidate scores one weighting: how many accounts land in their
+// band, then how well scores order accounts of different bands
+type tuneCandidate struct {
+	weights    Weights
+	correct    int
+	separation float64
+}
+
+func (c tuneCandidate) better(than tuneCandidate) bool {
+	if c.correct != than.correct {
+		return c.correct > than.correct
+	}
+	return c.separation > than.separation
+}
+
+// TuneWeights searches the weight simplex for the weighting that best
+// separates the labeled accounts, rescoring the sub-scores each analysis
+// already holds rather than fetching anything again. A coarse grid is
+// tried first, then random weightings drawn from the seed, up to
+// Iterations in all. An account the denylist pinned high stays high
+// under any weighting.
+func TuneWeights(results []LabeledResult, opts TuneOptions) (*TuneResult, error) {
+	if opts.Iterations == 0 {
+		opts.Iterations = DefaultTuneIterations
+	}
+	if opts.Iterations < 0 {
+		return nil, errors.New("tuning iterations must be positive")
+	}
+	if opts.MinWeight < 0 || opts.MinWeight*6 >= 1 {
+		return nil, fmt.Errorf("minimum weight must be in [0, %.3f), got %g", 1.0/6, opts.MinWeight)
+	}
+
+	var analyzed []LabeledResult
+	for _, result := range results {
+		if result.Analysis != nil {
+			analyzed = append(analyzed, result)
+		}
+	}
+	if len(analyzed) == 0 {
+		return nil, errors.New("no labeled account was analyzed")
+	}
+
+	best := tuneCandidate{correct: -1, separation: math.Inf(-1)}
+	candidates := 0
+	try := func(shares [6]float64) {
+		candidates++
+		w := simplexWeights(shares, opts.MinWeight)
+		if c := evaluateWeights(analyzed, w); c.better(best) {
+			best = c
+		}
+	}
+
+	for _, shares := range simplexGrid(tuneGridSteps) {
+		if candidates == opts.Iterations {
+			break
+		}
+		try(shares)
+	}
+	rng := sampleRand(opts.Seed)
+	for candidates < opts.Iterations {
+		try(randomShares(rng))
+	}
+
+	return &TuneResult{
+		Weights:    best.weights,
+		Separation: best.separation,
+		Report:     Calibrate(rescored(results, best.weights)),
+		Baseline:   Calibrate(results),
+		Candidates: candidates,
+	}, nil
+}
+
+// simplexWeights spreads shares summing to one over what the minimum
+// weights leave
+func simplexWeights(shares [6]float64, minWeight float64) Weights {
+	free := 1 - 6*minWeight
+	v := func(i int) float64 { return minWeight + free*shares[i] }
+	return Weights{Identity: v(0), Activity: v(1), Quality: v(2), Maintenance: v(3), Community: v(4), Security: v(5)}
+}
+
+// simplexGrid lists every split of steps equal shares across the six
+// dimensions
+func simplexGrid(steps int) [][6]float64 {
+	var grid [][6]float64
+	var counts [6]int
+	var walk func(dim, left int)
+	walk = func(dim, left int) {
+		if dim == 5 {
+			counts[5] = left
+			var shares [6]float64
+			for i, n := range counts {
+				shares[i] = float64(n) / float64(steps)
+			}
+			grid = append(grid, shares)
+			return
+		}
+		for n := 0; n <= left; n++ {
+			counts[dim] = n
+			walk(dim+1, left-n)
+		}
+	}
+	walk(0, steps)
+	return grid
+}
+
+// randomShares draws shares uniformly from the simplex
+func randomShares(rng *rand.Rand) [6]float64 {
+	var shares [6]float64
+	total := 0.0
+	for i := range shares {
+		shares[i] = rng.ExpFloat64()
+		total += shares[i]
+	}
+	for i := range shares {
+		shares[i] /= total
+	}
+	return shares
+}
+
+// rescore is an analysis's overall score and band under w, weighing
+// activity by the events coverage as the analysis itself did
+func rescore(analysis *Analysis, w Weights) (float64, string) {
+	w.Activity *= activityCoverage(analysis.Metrics)
+	score := weightedScore(analysis.Scores, w)
+	band := riskBand(score)
+	if pinned := riskBand(analysis.OverallScore) != analysis.RiskLevel; pinned {
+		band = analysis.RiskLevel
+	}
+	return score, band
+}
+
+// rescored copies results with each analysis rescored under w
+func rescored(results []LabeledResult, w Weights) []LabeledResult {
+	out := slices.Clone(results)
+	for i, result := range out {
+		if result.Analysis == nil {
+			continue
+		}
+		analysis := *result.Analysis
+		analysis.OverallScore, analysis.RiskLevel = rescore(result.Analysis, w)
+		out[i].Analysis = &analysis
+	}
+	return out
+}
+
+// evaluateWeights counts the accounts w puts in their band and measures
+// its separation: the share of account pairs labeled in different bands
+// whose scores are ordered the same way, ties counting half
+func evaluateWeights(results []LabeledResult, w Weights) tuneCandidate {
+	c := tuneCandidate{weights: w}
+	scores := make([]float64, len(results))
+	for i, result := range results {
+		score, band := rescore(result.Analysis, w)
+		scores[i] = score
+		if band == result.Expected {
+			c.correct++
+		}
+	}
+
+	pairs, concordant := 0, 0.0
+	for i := range results {
+		for j := range results {
+			if slices.Index(RiskBands, results[i].Expected) >= slices.Index(RiskBands, results[j].Expected) {
+				continue
+			}
+			pairs++
+			switch {
+			case scores[i] < scores[j]:
+				concordant++
+			case scores[i] == scores[j]:
+				concordant += 0.5
+			}
+		}
+	}
+	if pairs > 0 {
+		c.separation = concordant / float64(pairs)
+	}
+	return c
+}
+
+// FprintTuning writes the tuned weights and their calibration to w
+func FprintTuning(w io.Writer, result *TuneResult) {
+	fmt.Fprintf(w, "\n⚖️  TUNED WEIGHTS (best of %d weightings)\n", result.Candidates)
+	weights := result.Weights
+	for _, dim := range []struct {
+		name  string
+		value float64
+	}{
+		{"identity", weights.Identity},
+		{"activity", weights.Activity},
+		{"quality", weights.Quality},
+		{"maintenance", weights.Maintenance},
+		{"community", weights.Community},
+		{"security", weights.Security},
+	} {
+		fmt.Fprintf(w, "   %-12s %.3f\n", dim.name, dim.value)
+	}
+	fmt.Fprintf(w, "   Separation:  %.0f%% of differently labeled pairs ordered correctly\n", result.Separation*100)
+	fmt.Fprintf(w, "   Accuracy:    %.0f%%, against %.0f%% with the weights analyzed under\n", result.Report.Accuracy*100, result.Baseline.Accuracy*100)
+	FprintCalibration(w, result.Report)
+}
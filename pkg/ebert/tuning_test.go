@@ -0,0 +1,117 @@
+package ebert
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// plantedResults are n labeled accounts with random sub-scores, each
+// labeled with the band planted gives it and analyzed under the default
+// weights. Accounts within a point of a band edge are left out, so the
+// planted weighting isn't the only one that can score them.
+func plantedResults(n int, planted Weights) []LabeledResult {
+	rng := rand.New(rand.NewPCG(7, 11))
+	var results []LabeledResult
+	for len(results) < n {
+		sub := func() *float64 {
+			v := math.Round(rng.Float64() * 100)
+			return &v
+		}
+		scores := RiskScores{Identity: sub(), Activity: sub(), Quality: sub(), Maintenance: sub(), Community: sub(), Security: sub()}
+		score := weightedScore(scores, planted)
+		if math.Abs(score-30) < 1 || math.Abs(score-60) < 1 {
+			continue
+		}
+		overall := weightedScore(scores, DefaultWeights())
+		results = append(results, LabeledResult{
+			Login:    "fixture",
+			Expected: riskBand(score),
+			Analysis: &Analysis{Scores: scores, OverallScore: overall, RiskLevel: riskBand(overall)},
+		})
+	}
+	return results
+}
+
+// weightsDistance is the largest difference between two weightings'
+// shares of their totals
+func weightsDistance(a, b Weights) float64 {
+	as, bs := weightedScores(RiskScores{}, a), weightedScores(RiskScores{}, b)
+	totalA, totalB := 0.0, 0.0
+	for i := range as {
+		totalA += as[i].weight
+		totalB += bs[i].weight
+	}
+	distance := 0.0
+	for i := range as {
+		distance = max(distance, math.Abs(as[i].weight/totalA-bs[i].weight/totalB))
+	}
+	return distance
+}
+
+func TestTuneWeightsRecoversPlanted(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		planted   Weights
+		tolerance float64
+	}{
+		// A point of the coarse grid, found exactly
+		{"on the grid", simplexWeights([6]float64{0, 0, 0.5, 0, 0, 0.5}, DefaultMinWeight), 1e-9},
+		// Between grid points, approached by the random samples
+		{"off the grid", Weights{Identity: 0.3, Activity: 0.08, Quality: 0.1, Maintenance: 0.12, Community: 0.06, Security: 0.34}, 0.08},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			results := plantedResults(120, tt.planted)
+			result, err := TuneWeights(results, TuneOptions{Seed: 1, MinWeight: DefaultMinWeight})
+			if err != nil {
+				t.Fatalf("TuneWeights: %v", err)
+			}
+			if result.Candidates != DefaultTuneIterations {
+				t.Errorf("tried %d weightings, want %d", result.Candidates, DefaultTuneIterations)
+			}
+			if result.Baseline.Accuracy >= 0.9 {
+				t.Fatalf("the default weights already score %.0f%%; the fixture plants nothing", result.Baseline.Accuracy*100)
+			}
+			if result.Report.Accuracy < 0.97 {
+				t.Errorf("tuned accuracy %.1f%%, want the planted weighting's near-perfect banding", result.Report.Accuracy*100)
+			}
+			if d := weightsDistance(result.Weights, tt.planted); d > tt.tolerance {
+				t.Errorf("tuned weights %+v are %.3f from the planted %+v", result.Weights, d, tt.planted)
+			}
+			for _, share := range weightedScores(RiskScores{}, result.Weights) {
+				if share.weight < DefaultMinWeight-1e-9 {
+					t.Errorf("tuned weights %+v drop below the minimum %g", result.Weights, DefaultMinWeight)
+				}
+			}
+		})
+	}
+}
+
+func TestTuneWeightsPinnedBand(t *testing.T) {
+	// The denylist pinned an account high whatever its sub-scores say
+	low := 10.0
+	scores := RiskScores{Identity: &low, Activity: &low, Quality: &low, Maintenance: &low, Community: &low, Security: &low}
+	results := []LabeledResult{{Login: "pinned", Expected: "high", Analysis: &Analysis{Scores: scores, OverallScore: 10, RiskLevel: "high"}}}
+	result, err := TuneWeights(results, TuneOptions{Iterations: 50})
+	if err != nil {
+		t.Fatalf("TuneWeights: %v", err)
+	}
+	if result.Report.Accuracy != 1 {
+		t.Errorf("a pinned account should keep its band under any weighting, accuracy %.0f%%", result.Report.Accuracy*100)
+	}
+}
+
+func TestTuneWeightsOptions(t *testing.T) {
+	results := plantedResults(10, DefaultWeights())
+	for _, opts := range []TuneOptions{{Iterations: -1}, {MinWeight: -0.1}, {MinWeight: 1.0 / 6}} {
+		if _, err := TuneWeights(results, opts); err == nil {
+			t.Errorf("TuneWeights(%+v) should fail", opts)
+		}
+	}
+	if _, err := TuneWeights([]LabeledResult{{Login: "gone", Expected: "low", Error: "not found"}}, TuneOptions{}); err == nil {
+		t.Error("TuneWeights without an analyzed account should fail")
+	}
+	if result, err := TuneWeights(results, TuneOptions{Iterations: 3}); err != nil || result.Candidates != 3 {
+		t.Errorf("Iterations 3 tried %v weightings (%v), want 3", result, err)
+	}
+}
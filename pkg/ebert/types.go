@@ -0,0 +1,406 @@
+package ebert
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// GitHub API structures
+
+// GitHubUser type
+//
+//goland:noinspection SpellCheckingInspection
+type GitHubUser struct {
+	Login           string    `json:"login"`
+	Name            string    `json:"name"`
+	Company         string    `json:"company"`
+	Blog            string    `json:"blog"`
+	Email           string    `json:"email"`
+	Bio             string    `json:"bio"`
+	PublicRepos     int       `json:"public_repos"`
+	Followers       int       `json:"followers"`
+	Following       int       `json:"following"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	AvatarURL       string    `json:"avatar_url"`
+	HTMLURL         string    `json:"html_url"`
+	TwitterUsername string    `json:"twitter_username"`
+	Location        string    `json:"location"`
+	Type            string    `json:"type"` // "User" or "Organization"
+
+	// SuspendedAt is set on GitHub Enterprise Server for suspended accounts
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
+
+	// Contributions is only set in contributor listings
+	Contributions int `json:"contributions,omitempty"`
+}
+
+type Analysis struct {
+	User         GitHubUser `json:"user"`
+	Scores       RiskScores `json:"scores"`
+	OverallScore float64    `json:"overall_score"`
+	RiskLevel    string     `json:"risk_level"`
+
+	// TrustIndex and AbandonmentIndex split the overall risk into
+	// takeover or impersonation risk and plain bit-rot, on the same scale;
+	// see CompositeIndices. Nil when none of their sub-scores was computed.
+	TrustIndex       *float64 `json:"trust_index"`
+	AbandonmentIndex *float64 `json:"abandonment_index"`
+
+	// Confidence runs from 0 to 1 and drops when data sources are missing
+	// or the account is too new to have much history
+	Confidence float64 `json:"confidence"`
+
+	Metrics   Metrics   `json:"metrics"`
+	Findings  []Finding `json:"findings"`
+	RedFlags  []string  `json:"red_flags"`
+	Warnings  []string  `json:"warnings"`
+	Positives []string  `json:"positives"`
+
+	// TopRemediations lists the fixes that would lower the overall score
+	// the most, highest impact first
+	TopRemediations []Remediation `json:"top_remediations,omitempty"`
+
+	Timestamp time.Time `json:"timestamp,omitzero"`
+
+	// CoMaintainers are the other accounts committing to or releasing the
+	// flagship repos
+	CoMaintainers []CoMaintainer `json:"co_maintainers,omitempty"`
+
+	// PackageCoMaintainers are the other npm users listed as maintainers,
+	// and so able to publish, the user's published npm packages
+	PackageCoMaintainers []string `json:"package_co_maintainers,omitempty"`
+
+	// SelfAnalysis is set when the account analyzed is the token's own
+	SelfAnalysis bool `json:"self_analysis,omitempty"`
+
+	// Meta is the ebert version and effective options behind the analysis
+	Meta *AnalysisMeta `json:"meta,omitempty"`
+
+	DataSources  []DataSource  `json:"data_sources"`
+	Partial      bool          `json:"partial,omitempty"`
+	RequestStats *RequestStats `json:"request_stats,omitempty"`
+}
+
+// RiskScores holds the sub-scores; a nil score was not computed because
+// its input data was unavailable
+type RiskScores struct {
+	Identity    *float64 `json:"identity"`
+	Activity    *float64 `json:"activity"`
+	Quality     *float64 `json:"quality"`
+	Maintenance *float64 `json:"maintenance"`
+	Community   *float64 `json:"community"`
+	Security    *float64 `json:"security"`
+}
+
+type Metrics struct {
+	AccountAgeDays int `json:"account_age_days"`
+	Repos          int `json:"repos"`
+
+	// RepoClasses breaks Repos down by class; quality and maintenance are
+	// scored on the original repos only unless ScoreAllRepos is set
+	RepoClasses RepoClassCounts `json:"repo_classes"`
+
+	// TutorialRepos and ContentOnlyRepos count the original repos that look
+	// like course projects or hold no code; Quality leaves both out
+	TutorialRepos    int `json:"tutorial_repos"`
+	ContentOnlyRepos int `json:"content_only_repos"`
+
+	// VendoredRepos counts the top repos deep mode found to be mostly
+	// committed dependencies or minified bundles
+	VendoredRepos int `json:"vendored_repos"`
+
+	// NonDefaultBranchRepos counts original repos whose default branch is
+	// neither main nor master, for information only; every per-repo check
+	// reads the repo's own default branch
+	NonDefaultBranchRepos int `json:"non_default_branch_repos"`
+
+	// ReposSampled is set when only a sample of ReposTotal repos was
+	// analyzed; Repos, Stars and the other repo counts are then lower bounds
+	ReposSampled bool `json:"repos_sampled,omitempty"`
+	ReposTotal   int  `json:"repos_total,omitempty"`
+
+	Stars              int `json:"stars"`
+	Forks              int `json:"forks"`
+	Followers          int `json:"followers"`
+	RecentCommits      int `json:"recent_commits"`
+	ActivityWindowDays int `json:"activity_window_days"`
+	EventsReceived     int `json:"events_received"`
+
+	// WeightedStars is Stars with the stars of repos not pushed to within
+	// AnalyzerOptions.StarHorizon discounted by age; ScoringV2 scores
+	// quality on it
+	WeightedStars float64 `json:"weighted_stars"`
+
+	// ActiveRepos counts distinct repos pushed to in the activity window;
+	// ForcePushes counts pushes that rewrote history
+	ActiveRepos int `json:"active_repos"`
+	ForcePushes int `json:"force_pushes"`
+
+	// ContributedCommits are the RecentCommits pushed to repos the user
+	// doesn't own, and ContributedPRs the pull requests opened on them.
+	// WorksIn are the organization repos among them the user actively
+	// works in, with WorksInStars their stars, kept apart from Stars,
+	// which counts owned repos only.
+	ContributedCommits int      `json:"contributed_commits"`
+	ContributedPRs     int      `json:"contributed_prs"`
+	WorksIn            []string `json:"works_in,omitempty"`
+	WorksInStars       int      `json:"works_in_stars"`
+
+	// HistoryRewrites counts the rewrites of published history found on
+	// repos with five or more stars
+	HistoryRewrites int `json:"history_rewrites,omitempty"`
+
+	// TopRepoCommitShare is the fraction of pushed commits that went to the
+	// most-pushed repo; FarmedCommits is the part of RecentCommits set aside
+	// as activity farming and left out of the Activity score
+	TopRepoCommitShare float64 `json:"top_repo_commit_share,omitempty"`
+	FarmedCommits      int     `json:"farmed_commits,omitempty"`
+
+	// CommitMessageUniqueness is the share of distinct subjects among the
+	// SampledCommitMessages; TemplatedReadmes counts the repos sharing the
+	// most-cloned README. Set in deep mode.
+	CommitMessageUniqueness float64 `json:"commit_message_uniqueness,omitempty"`
+	SampledCommitMessages   int     `json:"sampled_commit_messages,omitempty"`
+	TemplatedReadmes        int     `json:"templated_readmes,omitempty"`
+
+	// SampledFollowers is how many recent follows the deep follower
+	// growth check read
+	SampledFollowers int `json:"sampled_followers,omitempty"`
+
+	// EngagementRingScore is the Jaccard overlap of the flagships'
+	// stargazers with the user's followers and following, weighted by the
+	// share of shared accounts that star each other's repos once the
+	// overlap is high enough to check. Set by the deep ring check.
+	EngagementRingScore float64 `json:"engagement_ring_score,omitempty"`
+
+	// TopRepoWatchers sums the watchers of the top repos, and
+	// TopRepoForks names the forks among them and what they fork
+	TopRepoWatchers int      `json:"top_repo_watchers,omitempty"`
+	TopRepoForks    []string `json:"top_repo_forks,omitempty"`
+
+	// InferredUTCOffset is the timezone the event hours suggest, when
+	// there were enough events to tell
+	InferredUTCOffset *float64 `json:"inferred_utc_offset,omitempty"`
+
+	// CommitCountMethod says whether RecentCommits came from commit search
+	// or the events heuristic; CommitCountLowerBound is set when the events
+	// feed hit its ceiling so the true count is likely higher
+	CommitCountMethod     string `json:"commit_count_method"`
+	CommitCountLowerBound bool   `json:"commit_count_lower_bound,omitempty"`
+
+	// OldestEvent is the timestamp of the oldest event received;
+	// EventsCoverageDays is set when the feed stopped short of the activity
+	// window and says how many days it actually covers
+	OldestEvent        *time.Time `json:"oldest_event,omitempty"`
+	EventsCoverageDays int        `json:"events_coverage_days,omitempty"`
+
+	RecentlyUpdated int `json:"recently_updated"`
+	Archived        int `json:"archived"`
+
+	// MaintenanceFreshness is the mean freshness of the non-fork repos,
+	// from 1 when all were just pushed toward 0, halving with every
+	// FreshnessHalfLife since each repo's last push
+	MaintenanceFreshness float64 `json:"maintenance_freshness"`
+
+	// RepoChurn counts repos the events feed shows deleted or recreated
+	RepoChurn int `json:"repo_churn"`
+
+	// BlockedRepos counts repos GitHub has disabled or that answered 451
+	// Unavailable For Legal Reasons, typically after a DMCA takedown
+	BlockedRepos int `json:"blocked_repos,omitempty"`
+
+	// RecentlyArchived counts repos archived in the last year;
+	// ActiveFlagships counts top-starred repos updated in the last 90 days
+	RecentlyArchived int `json:"recently_archived"`
+	ActiveFlagships  int `json:"active_flagships"`
+
+	// NPMPackages, PythonPackages and GoModules count repos by primary
+	// language, except that deep mode counts the packages in the top repos,
+	// each workspace or module of a monorepo included
+	NPMPackages    int `json:"npm_packages"`
+	PythonPackages int `json:"python_packages"`
+	GoModules      int `json:"go_modules"`
+	DecodeErrors   int `json:"decode_errors"`
+
+	// CratesPackages counts the crates on crates.io that link back to the
+	// user's Rust repos, found in deep mode
+	CratesPackages int `json:"crates_packages,omitempty"`
+
+	// NPMUsername is the npm account resolved from published manifests;
+	// ContainerImages counts container packages on GitHub Packages. Both
+	// are filled in by the deep package check.
+	NPMUsername     string `json:"npm_username,omitempty"`
+	ContainerImages int    `json:"container_images"`
+
+	// EmailIdentities are the addresses seen on the profile, the user's
+	// pushed commits and the npm registry, each with where it was seen;
+	// EmailConsistency is whether they agree, or empty with too few to say
+	EmailIdentities  []string `json:"email_identities,omitempty"`
+	EmailConsistency string   `json:"email_consistency,omitempty"`
+
+	// PublishedActions counts flagship repos published as GitHub Actions
+	PublishedActions int          `json:"published_actions"`
+	ActionRepos      []ActionRepo `json:"action_repos,omitempty"`
+
+	// AttestedReleases counts flagship latest releases with provenance that
+	// verified and was issued to the repo's own workflow
+	AttestedReleases  int                 `json:"attested_releases"`
+	ReleaseSignatures []ReleaseProvenance `json:"release_signatures,omitempty"`
+
+	// DockerfileRepos counts flagship repos with a Dockerfile;
+	// PublishedImages counts images found on Docker Hub and GHCR
+	DockerfileRepos int              `json:"dockerfile_repos"`
+	PublishedImages int              `json:"published_images"`
+	Images          []PublishedImage `json:"images,omitempty"`
+
+	PublicGists int       `json:"public_gists"`
+	LastGistAt  time.Time `json:"last_gist_at,omitzero"`
+
+	StalePRs         int `json:"stale_prs"`
+	StaleBotPRs      int `json:"stale_bot_prs"`
+	OldestOpenPRDays int `json:"oldest_open_pr_days"`
+
+	ReposWithDepAutomation int `json:"repos_with_dep_automation"`
+
+	// DocsSites counts repos with GitHub Pages enabled; MaintainedDocsSites
+	// counts flagship repos whose docs site is maintained
+	DocsSites           int  `json:"docs_sites"`
+	MaintainedDocsSites int  `json:"maintained_docs_sites"`
+	UserPagesSite       bool `json:"user_pages_site"`
+
+	// IssuesEnabledRatio is the fraction of non-fork repos with issues enabled
+	IssuesEnabledRatio float64 `json:"issues_enabled_ratio"`
+	ActiveDiscussions  int     `json:"active_discussions"`
+
+	// AvgCommunityHealth is the mean community profile health percentage
+	// of the checked repos, counting empty repos as 0
+	AvgCommunityHealth float64 `json:"avg_community_health"`
+
+	// ReposWithSecurityPolicy counts checked repos with a SECURITY.md;
+	// HasDisclosureContact is set when one names an email, advisory link or
+	// bug bounty
+	ReposWithSecurityPolicy   int  `json:"repos_with_security_policy"`
+	HasDisclosureContact      bool `json:"has_disclosure_contact"`
+	ReposWithPrivateReporting int  `json:"repos_with_private_reporting"`
+
+	// ReviewWorkflows estimates per flagship repo whether changes are
+	// reviewed before merging; see RepoReviewWorkflow for the caveats
+	ReposWithCodeowners int                  `json:"repos_with_codeowners"`
+	ReviewWorkflows     []RepoReviewWorkflow `json:"review_workflows,omitempty"`
+}
+
+//goland:noinspection SpellCheckingInspection
+type GitHubRepo struct {
+	Name            string    `json:"name"`
+	FullName        string    `json:"full_name"`
+	Description     string    `json:"description"`
+	Language        string    `json:"language"`
+	StargazersCount int       `json:"stargazers_count"`
+	ForksCount      int       `json:"forks_count"`
+	Size            int       `json:"size"` // in KB
+	Archived        bool      `json:"archived"`
+	Fork            bool      `json:"fork"`
+	Disabled        bool      `json:"disabled"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	PushedAt        time.Time `json:"pushed_at"`
+	Topics          []string  `json:"topics"`
+	HasPages        bool      `json:"has_pages"`
+	HasIssues       bool      `json:"has_issues"`
+	HasWiki         bool      `json:"has_wiki"`
+	HasDiscussions  bool      `json:"has_discussions"`
+	IsTemplate      bool      `json:"is_template"`
+	MirrorURL       string    `json:"mirror_url"`
+	HTMLURL         string    `json:"html_url"`
+	DefaultBranch   string    `json:"default_branch"`
+
+	// PrivateVulnerabilityReporting is only present on some API responses
+	PrivateVulnerabilityReporting *bool `json:"private_vulnerability_reporting,omitempty"`
+
+	// Owner is the account owning the repo
+	Owner *RepoOwner `json:"owner,omitempty"`
+
+	// Parent, SubscribersCount and NetworkCount are only set by GetRepo,
+	// not the listing
+	Parent           *RepoRef `json:"parent,omitempty"`
+	SubscribersCount int      `json:"subscribers_count,omitempty"`
+	NetworkCount     int      `json:"network_count,omitempty"`
+
+	// detailed marks a repo listed over GraphQL, which already carries
+	// what GetRepo adds
+	detailed bool
+}
+
+type GitHubEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Repo      struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"repo"`
+	Actor struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+	Payload json.RawMessage `json:"payload"` // Use RawMessage to handle different payload types
+}
+
+// UnmarshalJSON tolerates null or empty timestamps
+func (e *GitHubEvent) UnmarshalJSON(data []byte) error {
+	type alias GitHubEvent
+	aux := struct {
+		*alias
+		CreatedAt flexTime `json:"created_at"`
+	}{alias: (*alias)(e)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	e.CreatedAt = time.Time(aux.CreatedAt)
+	return nil
+}
+
+// UnmarshalJSON tolerates null or empty timestamps
+func (r *GitHubRepo) UnmarshalJSON(data []byte) error {
+	type alias GitHubRepo
+	aux := struct {
+		*alias
+		UpdatedAt flexTime `json:"updated_at"`
+		CreatedAt flexTime `json:"created_at"`
+		PushedAt  flexTime `json:"pushed_at"`
+	}{alias: (*alias)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.UpdatedAt = time.Time(aux.UpdatedAt)
+	r.CreatedAt = time.Time(aux.CreatedAt)
+	r.PushedAt = time.Time(aux.PushedAt)
+	return nil
+}
+
+// flexTime decodes RFC 3339 timestamps, treating null and "" as the zero time
+type flexTime time.Time
+
+func (t *flexTime) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	if s == nil || *s == "" {
+		*t = flexTime{}
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return err
+	}
+
+	*t = flexTime(parsed)
+	return nil
+}
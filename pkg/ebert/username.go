@@ -0,0 +1,62 @@
+package ebert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidUsername is wrapped by every ValidateUsername failure
+var ErrInvalidUsername = errors.New("invalid username")
+
+// maxUsernameLength is GitHub's limit on login length
+const maxUsernameLength = 39
+
+// reservedUsernames are top-level github.com paths that can never be accounts
+var reservedUsernames = map[string]bool{
+	"about": true, "api": true, "codespaces": true, "collections": true,
+	"dashboard": true, "enterprise": true, "explore": true, "features": true,
+	"issues": true, "join": true, "login": true, "logout": true,
+	"marketplace": true, "new": true, "notifications": true, "organizations": true,
+	"orgs": true, "pricing": true, "pulls": true, "search": true,
+	"security": true, "sessions": true, "settings": true, "site": true,
+	"sponsors": true, "topics": true, "trending": true, "users": true,
+}
+
+// NormalizeUsername trims surrounding whitespace and a leading '@'
+func NormalizeUsername(username string) string {
+	return strings.TrimPrefix(strings.TrimSpace(username), "@")
+}
+
+// ValidateUsername checks the normalized username against GitHub's login
+// rules: 1-39 alphanumerics or single hyphens, not starting or ending with a
+// hyphen, and not a reserved path
+func ValidateUsername(username string) error {
+	name := NormalizeUsername(username)
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w '%s': %s", ErrInvalidUsername, username, reason)
+	}
+
+	switch {
+	case name == "":
+		return invalid("usernames cannot be empty")
+	case len(name) > maxUsernameLength:
+		return invalid(fmt.Sprintf("usernames cannot be longer than %d characters", maxUsernameLength))
+	case strings.ContainsAny(name, " \t\n"):
+		return invalid("usernames cannot contain spaces")
+	case strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-"):
+		return invalid("usernames cannot start or end with a hyphen")
+	case strings.Contains(name, "--"):
+		return invalid("usernames cannot contain consecutive hyphens")
+	case reservedUsernames[strings.ToLower(name)]:
+		return invalid("this is a reserved GitHub path, not an account")
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return invalid(fmt.Sprintf("usernames may only contain alphanumerics and hyphens, found %q", r))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package ebert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	for _, tc := range []struct {
+		username string
+		// reason is part of the error, empty for a valid username
+		reason string
+	}{
+		{"octocat", ""},
+		{"a", ""},
+		{"Octo-Cat-42", ""},
+		{"  @octocat\n", ""},
+		{strings.Repeat("a", maxUsernameLength), ""},
+		{"", "cannot be empty"},
+		{" @ ", "cannot be empty"},
+		{strings.Repeat("a", maxUsernameLength+1), "longer than 39"},
+		{"octo cat", "cannot contain spaces"},
+		{"-octocat", "start or end with a hyphen"},
+		{"octocat-", "start or end with a hyphen"},
+		{"octo--cat", "consecutive hyphens"},
+		{"octo_cat", "alphanumerics and hyphens"},
+		{"octo.cat", "alphanumerics and hyphens"},
+		{"octocät", `found 'ä'`},
+		// A Cyrillic о that looks like the Latin one
+		{"оctocat", `found 'о'`},
+		{"settings", "reserved GitHub path"},
+		{"Orgs", "reserved GitHub path"},
+		{"@login", "reserved GitHub path"},
+	} {
+		err := ValidateUsername(tc.username)
+		if tc.reason == "" {
+			if err != nil {
+				t.Errorf("ValidateUsername(%q) = %v, want valid", tc.username, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidUsername) || !strings.Contains(err.Error(), tc.reason) {
+			t.Errorf("ValidateUsername(%q) = %v, want an invalid username %s", tc.username, err, tc.reason)
+		}
+	}
+}
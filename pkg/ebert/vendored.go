@@ -0,0 +1,192 @@
+package ebert
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxVendoredChecked is how many top repos deep mode inspects for
+	// committed dependencies and bundles
+	maxVendoredChecked = 5
+
+	// maxVendoredSubtrees bounds the top-level directories listed one level
+	// down per repo, vendored ones first
+	maxVendoredSubtrees = 8
+
+	// vendoredShare is the share of the entries seen, or of the blob bytes
+	// for bundles, above which a repo counts as a dump
+	vendoredShare = 0.8
+
+	// largeBlobBytes is the size above which one file counts as a bundle
+	largeBlobBytes = 512 << 10
+)
+
+// vendoredDirs are directory names package managers install into
+var vendoredDirs = map[string]bool{
+	"node_modules":     true,
+	"vendor":           true,
+	"bower_components": true,
+	"jspm_packages":    true,
+	"third_party":      true,
+	"thirdparty":       true,
+	"pods":             true,
+	"site-packages":    true,
+}
+
+// singleFileLanguages are primary languages whose repos are naturally a
+// few large files, so a large blob says nothing
+var singleFileLanguages = map[string]bool{
+	"Jupyter Notebook": true,
+	"HTML":             true,
+	"TeX":              true,
+	"PostScript":       true,
+	"SVG":              true,
+	"Roff":             true,
+}
+
+// bundleFile reports whether a blob is a minified bundle or large enough
+// to be one
+func bundleFile(entry GitHubTreeEntry) bool {
+	base := strings.ToLower(path.Base(entry.Path))
+	return strings.HasSuffix(base, ".min.js") || strings.HasSuffix(base, ".min.css") || entry.Size > largeBlobBytes
+}
+
+// treeSample is what the top level of a repo and one level below it hold
+type treeSample struct {
+	entries, vendoredEntries int
+	bytes, bundleBytes       int64
+	vendored, bundles        []string
+}
+
+// add counts the entries of one listed tree, under dir when it isn't the
+// root
+func (s *treeSample) add(dir string, entries []GitHubTreeEntry) {
+	vendored := vendoredDirs[strings.ToLower(dir)]
+	for _, entry := range entries {
+		s.entries++
+		if vendored {
+			s.vendoredEntries++
+		}
+		if entry.Type != "blob" {
+			continue
+		}
+		s.bytes += entry.Size
+		if bundleFile(entry) {
+			s.bundleBytes += entry.Size
+			s.bundles = append(s.bundles, path.Join(dir, entry.Path))
+		}
+	}
+}
+
+// describe names why the sample is a dump, or reports it isn't one
+func (s *treeSample) describe() (string, bool) {
+	if s.entries > 0 && len(s.vendored) > 0 && float64(s.vendoredEntries) >= float64(s.entries)*vendoredShare {
+		return fmt.Sprintf("%s: %.0f%% of entries", strings.Join(s.vendored, ", "), 100*float64(s.vendoredEntries)/float64(s.entries)), true
+	}
+	if s.bytes > 0 && float64(s.bundleBytes) >= float64(s.bytes)*vendoredShare {
+		names := s.bundles[:min(len(s.bundles), 3)]
+		return fmt.Sprintf("%s: %.0f%% of bytes", strings.Join(names, ", "), 100*float64(s.bundleBytes)/float64(s.bytes)), true
+	}
+	return "", false
+}
+
+// sampleTree lists a repo's top-level tree and, within the contents
+// budget, its directories one level down, vendored ones first. Trees are
+// listed without recursion so a huge node_modules can't blow the payload.
+func (a *Analyzer) sampleTree(ctx context.Context, r *analysisRun, owner, name, ref string) (*treeSample, error) {
+	root, _, err := a.client.GetTree(ctx, owner, name, ref, false)
+	if err != nil {
+		return nil, err
+	}
+	sample := &treeSample{}
+	sample.add("", root)
+
+	var dirs []GitHubTreeEntry
+	for _, entry := range root {
+		if entry.Type == "tree" && !strings.HasPrefix(entry.Path, ".") {
+			dirs = append(dirs, entry)
+		}
+	}
+	slices.SortStableFunc(dirs, func(x, y GitHubTreeEntry) int {
+		return boolOrder(vendoredDirs[strings.ToLower(y.Path)]) - boolOrder(vendoredDirs[strings.ToLower(x.Path)])
+	})
+	for _, dir := range dirs[:min(len(dirs), maxVendoredSubtrees)] {
+		if ctx.Err() != nil || !r.contentsBudget.take() {
+			break
+		}
+		entries, _, err := a.client.GetTree(ctx, owner, name, dir.SHA, false)
+		if err != nil {
+			continue
+		}
+		if vendoredDirs[strings.ToLower(dir.Path)] {
+			sample.vendored = append(sample.vendored, dir.Path+"/")
+		}
+		sample.add(dir.Path, entries)
+	}
+	return sample, nil
+}
+
+// boolOrder is 1 for true, for sorting true first
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// checkVendoredCode flags top repos in deep mode that are mostly committed
+// dependencies or minified bundles: not original work, and a place
+// obfuscated payloads hide
+func (a *Analyzer) checkVendoredCode(ctx context.Context, r *analysisRun) {
+	if !a.opts.DeepChecks || !r.log.coverage().repos {
+		return
+	}
+
+	var failed error
+	var dumps []string
+	checked := 0
+	for _, repo := range r.checkable() {
+		if checked == maxVendoredChecked || ctx.Err() != nil {
+			break
+		}
+		if repo.DefaultBranch == "" || repo.Fork || singleFileLanguages[repo.Language] {
+			continue
+		}
+		if !r.contentsBudget.take() {
+			break
+		}
+		checked++
+		owner, name := repoOwnerAndName(repo, r.username)
+		sample, err := a.sampleTree(ctx, r, owner, name, repo.DefaultBranch)
+		if err != nil {
+			if !isNotFound(err) {
+				failed = err
+			}
+			continue
+		}
+		if reason, ok := sample.describe(); ok {
+			dumps = append(dumps, fmt.Sprintf("%s (%s)", repo.FullName, reason))
+		}
+	}
+
+	switch {
+	case failed != nil:
+		r.log.fellBack("vendored_code", fmt.Errorf("failed to list some repo trees: %w", failed))
+	case checked > 0:
+		r.log.ok("vendored_code")
+	}
+	r.acc.metrics.VendoredRepos = len(dumps)
+
+	if len(dumps) > 0 {
+		r.addFinding(Finding{
+			Code:     "VENDORED_CODE",
+			Severity: SeverityWarning,
+			Message:  "Top repos are mostly committed dependencies or minified bundles rather than original source",
+			Evidence: dumps,
+		})
+	}
+}
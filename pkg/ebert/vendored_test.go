@@ -0,0 +1,174 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBundleFile(t *testing.T) {
+	for _, tt := range []struct {
+		entry GitHubTreeEntry
+		want  bool
+	}{
+		{GitHubTreeEntry{Path: "app.min.js", Size: 300}, true},
+		{GitHubTreeEntry{Path: "dist/Theme.MIN.CSS", Size: 300}, true},
+		{GitHubTreeEntry{Path: "data.bin", Size: largeBlobBytes + 1}, true},
+		{GitHubTreeEntry{Path: "data.bin", Size: largeBlobBytes}, false},
+		{GitHubTreeEntry{Path: "app.js", Size: 300}, false},
+		{GitHubTreeEntry{Path: "min.json", Size: 300}, false},
+	} {
+		if got := bundleFile(tt.entry); got != tt.want {
+			t.Errorf("bundleFile(%+v) = %t, want %t", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestTreeSampleDescribe(t *testing.T) {
+	blob := func(name string, size int64) GitHubTreeEntry {
+		return GitHubTreeEntry{Path: name, Type: "blob", Size: size}
+	}
+	for _, tt := range []struct {
+		name   string
+		sample func(s *treeSample)
+		want   string
+		ok     bool
+	}{
+		{"vendored", func(s *treeSample) {
+			s.add("", []GitHubTreeEntry{blob("main.go", 100), {Path: "vendor", Type: "tree"}})
+			s.vendored = []string{"vendor/"}
+			s.add("vendor", []GitHubTreeEntry{blob("a.go", 10), blob("b.go", 10), blob("c.go", 10), blob("d.go", 10), blob("e.go", 10), blob("f.go", 10), blob("g.go", 10), blob("h.go", 10)})
+		}, "vendor/: 80% of entries", true},
+		{"bundled", func(s *treeSample) {
+			s.add("", []GitHubTreeEntry{blob("index.js", 100), {Path: "dist", Type: "tree"}})
+			s.add("dist", []GitHubTreeEntry{blob("app.min.js", 400), blob("site.min.css", 500)})
+		}, "dist/app.min.js, dist/site.min.css: 90% of bytes", true},
+		// The vendored share counts only when a vendored directory was listed
+		{"unlisted vendor", func(s *treeSample) {
+			s.add("", []GitHubTreeEntry{blob("main.go", 100), {Path: "vendor", Type: "tree"}})
+		}, "", false},
+		{"mostly source", func(s *treeSample) {
+			s.add("", []GitHubTreeEntry{blob("main.go", 800), blob("app.min.js", 200), {Path: "vendor", Type: "tree"}})
+			s.vendored = []string{"vendor/"}
+			s.add("vendor", []GitHubTreeEntry{blob("a.go", 10)})
+		}, "", false},
+		{"empty", func(s *treeSample) {}, "", false},
+	} {
+		sample := &treeSample{}
+		tt.sample(sample)
+		if got, ok := sample.describe(); got != tt.want || ok != tt.ok {
+			t.Errorf("%s: describe = %q, %t; want %q, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// serveTrees answers octo/repo's trees by SHA, the root's as main, and
+// records which trees were listed and with what query
+func serveTrees(f *fakeGitHub, repo string, trees map[string][]GitHubTreeEntry, mu *sync.Mutex, listed *[]string) {
+	for sha, entries := range trees {
+		f.route("/repos/octo/"+repo+"/git/trees/"+sha, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			*listed = append(*listed, repo+"/"+sha+"?"+r.URL.RawQuery)
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"tree": entries, "truncated": false})
+		})
+	}
+}
+
+// vendoredFake serves octo's repos: bundle is mostly a minified bundle,
+// deps mostly a vendor directory listed after nine others, tool original
+// source, and the notebook and the fork would be dumps but aren't checked
+func vendoredFake(t *testing.T) (*fakeGitHub, func() []string) {
+	repo := func(name, language string, stars int) GitHubRepo {
+		return GitHubRepo{Name: name, Language: language, Size: 900, StargazersCount: stars, UpdatedAt: fakeNow.Add(-days(3))}
+	}
+	forked := repo("forked", "JavaScript", 60)
+	forked.Fork = true
+	f := newFakeGitHub(t, newAccount("octo", days(3000),
+		repo("bundle", "JavaScript", 50), repo("deps", "Go", 40), repo("tool", "Go", 30), repo("notebook", "Jupyter Notebook", 20), forked))
+
+	blob := func(name string, size int64) GitHubTreeEntry {
+		return GitHubTreeEntry{Path: name, Type: "blob", SHA: name, Size: size}
+	}
+	dir := func(name string) GitHubTreeEntry {
+		return GitHubTreeEntry{Path: name, Type: "tree", SHA: name}
+	}
+	var mu sync.Mutex
+	var listed []string
+	serveTrees(f, "bundle", map[string][]GitHubTreeEntry{
+		"main": {blob("README.md", 10<<10), blob("index.js", 90<<10), dir("dist")},
+		"dist": {blob("app.min.js", 900<<10)},
+	}, &mu, &listed)
+
+	deps := map[string][]GitHubTreeEntry{"main": {blob("go.mod", 100)}}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"} {
+		deps["main"] = append(deps["main"], dir(name))
+		deps[name] = []GitHubTreeEntry{blob(name+".go", 100)}
+	}
+	deps["main"] = append(deps["main"], dir("vendor"))
+	for i := range 80 {
+		deps["vendor"] = append(deps["vendor"], dir(fmt.Sprintf("module-%02d", i)))
+	}
+	serveTrees(f, "deps", deps, &mu, &listed)
+
+	serveTrees(f, "tool", map[string][]GitHubTreeEntry{
+		"main":     {blob("main.go", 20<<10), blob("go.mod", 100), dir("internal"), dir(".github")},
+		"internal": {blob("a.go", 8<<10), blob("b.go", 8<<10), blob("c.go", 8<<10)},
+	}, &mu, &listed)
+	serveTrees(f, "notebook", map[string][]GitHubTreeEntry{"main": {blob("analysis.ipynb", 5<<20)}}, &mu, &listed)
+	serveTrees(f, "forked", map[string][]GitHubTreeEntry{
+		"main":         {dir("node_modules")},
+		"node_modules": {blob("a.js", 100), blob("b.js", 100)},
+	}, &mu, &listed)
+
+	return f, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(listed)
+	}
+}
+
+func TestCheckVendoredCode(t *testing.T) {
+	f, listed := vendoredFake(t)
+	analysis, err := newFakeAnalyzerToken(f, "token", WithDeepChecks(true)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if analysis.Metrics.VendoredRepos != 2 {
+		t.Errorf("VendoredRepos = %d, want 2", analysis.Metrics.VendoredRepos)
+	}
+	// vendor/ is listed ahead of the directories that outnumber the budget;
+	// 80 of the 98 entries seen are under it
+	flag := finding(analysis, "VENDORED_CODE")
+	want := []string{"octo/bundle (dist/app.min.js: 90% of bytes)", "octo/deps (vendor/: 82% of entries)"}
+	if flag == nil || flag.Severity != SeverityWarning || flag.Index != IndexTrust || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("VENDORED_CODE = %+v, want %q", flag, want)
+	}
+	if !slices.Contains(analysis.DataSources, DataSource{Name: "vendored_code", Status: SourceOK}) {
+		t.Errorf("no ok vendored_code source in %+v", analysis.DataSources)
+	}
+
+	for _, tree := range listed() {
+		if !strings.HasSuffix(tree, "?") {
+			t.Errorf("tree %s was listed recursively", tree)
+		}
+		if slices.Contains([]string{"notebook/main?", "forked/main?", "forked/node_modules?"}, tree) {
+			t.Errorf("tree %s was listed", tree)
+		}
+	}
+}
+
+func TestCheckVendoredCodeGated(t *testing.T) {
+	f, listed := vendoredFake(t)
+	analysis, err := newFakeAnalyzerToken(f, "token").Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if trees := listed(); len(trees) != 0 || analysis.Metrics.VendoredRepos != 0 || finding(analysis, "VENDORED_CODE") != nil {
+		t.Errorf("without deep checks: trees %q listed, %d vendored repos", trees, analysis.Metrics.VendoredRepos)
+	}
+}
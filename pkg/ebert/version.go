@@ -0,0 +1,184 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// modulePath is this module, looked up in the build info of binaries that
+// embed ebert as a library
+const modulePath = "github.com/JamesWoolfenden/ebert"
+
+// Version is the ebert release, set at build time with
+// -ldflags "-X github.com/JamesWoolfenden/ebert/pkg/ebert.Version=v0.7.0".
+// When unset, BuildVersion falls back to the module build info.
+var Version = ""
+
+// BuildVersion is the version of ebert doing the analysis: Version when
+// set, else the module version Go recorded at build, else "devel"
+func BuildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	module := &info.Main
+	if module.Path != modulePath {
+		module = nil
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+				break
+			}
+		}
+	}
+	if module == nil || module.Version == "" || module.Version == "(devel)" {
+		return "devel"
+	}
+	return module.Version
+}
+
+// AnalysisMeta records which ebert and which options produced an
+// analysis, so reports made months apart can be compared fairly
+type AnalysisMeta struct {
+	Version string          `json:"version"`
+	Options AnalyzerOptions `json:"options"`
+
+	// Seed is the sampling seed the analysis ran with: Options.Seed when
+	// pinned, else derived from the login and UTC date, so same-day reruns
+	// and replays draw the same samples
+	Seed uint64 `json:"seed,omitempty"`
+}
+
+// analysisMeta normalizes opts for the report: list options are sorted so
+// reordering them doesn't read as a configuration change
+func analysisMeta(opts AnalyzerOptions) *AnalysisMeta {
+	opts.InternalNamePatterns = slices.Clone(opts.InternalNamePatterns)
+	sort.Strings(opts.InternalNamePatterns)
+	opts.Denylist = slices.Clone(opts.Denylist)
+	sort.SliceStable(opts.Denylist, func(i, j int) bool { return opts.Denylist[i].Login < opts.Denylist[j].Login })
+	return &AnalysisMeta{Version: BuildVersion(), Options: opts}
+}
+
+// runMeta is the metadata of one analysis, with the seed it sampled with
+func (a *Analyzer) runMeta(r *analysisRun) *AnalysisMeta {
+	meta := analysisMeta(a.opts)
+	meta.Seed = r.seed
+	return meta
+}
+
+// sampleSeed is seed, or when zero a hash of the login and the UTC date
+func sampleSeed(seed uint64, login string, now time.Time) uint64 {
+	if seed != 0 {
+		return seed
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.ToLower(login) + "@" + now.UTC().Format(time.DateOnly)))
+	return h.Sum64()
+}
+
+// sampleRand is the random source seeded by seed
+func sampleRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// sampleOrder is logins in an order drawn from rng; the order depends only
+// on the set of logins, not how they arrived
+func sampleOrder(rng *rand.Rand, logins []string) []string {
+	order := slices.Clone(logins)
+	slices.Sort(order)
+	rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	return order
+}
+
+// Summary is a one-line description of the build and the options that
+// matter most, e.g. "ebert v0.7.0, scoring v1, window 90d, deep checks off"
+func (m *AnalysisMeta) Summary() string {
+	return m.summary(englishCatalog)
+}
+
+// summary is Summary in the catalog's language
+func (m *AnalysisMeta) summary(catalog *Catalog) string {
+	deep := catalog.Text("meta.deep_checks_off")
+	if m.Options.DeepChecks {
+		deep = catalog.Text("meta.deep_checks_on")
+	}
+	return catalog.Text("meta.summary", m.Version, max(m.Options.ScoringVersion, ScoringV1), int(m.Options.ActivityWindow.Hours()/24), deep)
+}
+
+// MetaDifferences lists how the versions and options behind two analyses
+// differ; a score shift between them may come from ebert rather than the
+// account. Analyses without metadata predate it and compare as unknown.
+func MetaDifferences(before, after *Analysis) []string {
+	if before.Meta == nil || after.Meta == nil {
+		if before.Meta != after.Meta {
+			return []string{"version and options unknown for one analysis"}
+		}
+		return nil
+	}
+
+	var diffs []string
+	if before.Meta.Version != after.Meta.Version {
+		diffs = append(diffs, fmt.Sprintf("version %s -> %s", before.Meta.Version, after.Meta.Version))
+	}
+	old, err := optionFields(before.Meta.Options)
+	if err != nil {
+		return append(diffs, err.Error())
+	}
+	current, err := optionFields(after.Meta.Options)
+	if err != nil {
+		return append(diffs, err.Error())
+	}
+
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	for name := range old {
+		if _, ok := current[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !reflect.DeepEqual(old[name], current[name]) {
+			diffs = append(diffs, fmt.Sprintf("%s %s -> %s", name, compactJSON(old[name]), compactJSON(current[name])))
+		}
+	}
+	return diffs
+}
+
+// optionFields flattens options to their JSON fields
+func optionFields(opts AnalyzerOptions) (map[string]any, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode options: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode options: %w", err)
+	}
+	return fields, nil
+}
+
+// compactJSON renders a decoded JSON value on one line
+func compactJSON(value any) string {
+	if value == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(data))
+}
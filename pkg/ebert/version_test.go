@@ -0,0 +1,115 @@
+package ebert
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildVersion(t *testing.T) {
+	// Test binaries are built from the working tree, without a module version
+	if got := BuildVersion(); got != "devel" {
+		t.Errorf("BuildVersion = %q, want devel", got)
+	}
+	old := Version
+	t.Cleanup(func() { Version = old })
+	Version = "v0.7.0"
+	if got := BuildVersion(); got != "v0.7.0" {
+		t.Errorf("BuildVersion = %q, want the -ldflags version", got)
+	}
+}
+
+func TestAnalysisMeta(t *testing.T) {
+	opts := defaultOptions()
+	opts.InternalNamePatterns = []string{"zeta-", "acme-"}
+	opts.Denylist = []DenylistEntry{{Login: "zed", Reference: "r"}, {Login: "ann", Reference: "r"}}
+	meta := analysisMeta(opts)
+
+	// Order isn't configuration, and the options themselves are left alone
+	if !slices.Equal(meta.Options.InternalNamePatterns, []string{"acme-", "zeta-"}) || meta.Options.Denylist[0].Login != "ann" {
+		t.Errorf("meta options %+v aren't sorted", meta.Options)
+	}
+	if opts.InternalNamePatterns[0] != "zeta-" || opts.Denylist[0].Login != "zed" {
+		t.Errorf("analysisMeta reordered the analyzer's options")
+	}
+	if meta.Version != BuildVersion() {
+		t.Errorf("Version = %q, want %q", meta.Version, BuildVersion())
+	}
+}
+
+func TestAnalysisMetaSummary(t *testing.T) {
+	opts := defaultOptions()
+	meta := &AnalysisMeta{Version: "v0.7.0", Options: opts}
+	if got, want := meta.Summary(), "ebert v0.7.0, scoring v1, window 90d, deep checks off"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	meta.Options.DeepChecks = true
+	meta.Options.ActivityWindow = 30 * 24 * time.Hour
+	if got, want := meta.Summary(), "ebert v0.7.0, scoring v1, window 30d, deep checks on"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestMetaDifferences(t *testing.T) {
+	analysis := func(version string, change func(*AnalyzerOptions)) *Analysis {
+		opts := defaultOptions()
+		if change != nil {
+			change(&opts)
+		}
+		meta := analysisMeta(opts)
+		meta.Version = version
+		return &Analysis{Meta: meta}
+	}
+	patterns := func(patterns ...string) func(*AnalyzerOptions) {
+		return func(o *AnalyzerOptions) { o.InternalNamePatterns = patterns }
+	}
+	for _, tt := range []struct {
+		name          string
+		before, after *Analysis
+		want          []string
+	}{
+		{"same", analysis("v0.7.0", nil), analysis("v0.7.0", nil), nil},
+		{"reordered", analysis("v0.7.0", patterns("b-", "a-")), analysis("v0.7.0", patterns("a-", "b-")), nil},
+		{"version", analysis("v0.6.0", nil), analysis("v0.7.0", nil), []string{"version v0.6.0 -> v0.7.0"}},
+		{"options", analysis("v0.7.0", nil), analysis("v0.7.0", func(o *AnalyzerOptions) {
+			o.DeepChecks = true
+			o.ActivityWindow = 30 * 24 * time.Hour
+		}), []string{"activity_window 7776000000000000 -> 2592000000000000", "deep_checks false -> true"}},
+		// Fields only one side sets still show
+		{"added", analysis("v0.7.0", nil), analysis("v0.7.0", patterns("acme-")), []string{`internal_name_patterns unset -> ["acme-"]`}},
+		{"before meta", &Analysis{}, analysis("v0.7.0", nil), []string{"version and options unknown for one analysis"}},
+		{"neither has meta", &Analysis{}, &Analysis{}, nil},
+	} {
+		if got := MetaDifferences(tt.before, tt.after); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: MetaDifferences = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnalysisRecordsMeta(t *testing.T) {
+	f := newFakeGitHub(t, newAccount("octo", days(3000), GitHubRepo{Name: "tool", Language: "Go", Size: 900, StargazersCount: 40, UpdatedAt: fakeNow.Add(-days(2))}))
+	analysis, err := newFakeAnalyzer(f, WithActivityWindow(30*24*time.Hour)).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if analysis.Meta == nil || analysis.Meta.Version != BuildVersion() || analysis.Meta.Options.ActivityWindow != 30*24*time.Hour {
+		t.Fatalf("Meta = %+v, want the version and the 30-day window", analysis.Meta)
+	}
+
+	var report strings.Builder
+	FprintAnalysis(&report, analysis)
+	if want := "ebert devel, scoring v1, window 30d, deep checks off"; !strings.Contains(report.String(), want) {
+		t.Errorf("report is missing the footer %q:\n%s", want, report.String())
+	}
+
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Analysis
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Meta == nil || MetaDifferences(analysis, &decoded) != nil {
+		t.Errorf("meta doesn't survive a JSON round trip: %+v, %v", decoded.Meta, err)
+	}
+}
@@ -0,0 +1,111 @@
+package ebert
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxWorksIn bounds the contributed repos whose metadata is fetched
+	maxWorksIn = 3
+
+	// minWorksInContributions is the fewest commits and pull requests in
+	// the activity window for a repo to count as one the user works in
+	minWorksInContributions = 5
+
+	// popularWorksInStars is when the organization repos a user works in
+	// count as a well-known community project
+	popularWorksInStars = 1000
+)
+
+// RepoOwner is the account owning a repo, a "User" or an "Organization"
+type RepoOwner struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+// owns reports whether the analyzed account owns the repo with fullName
+func (m *metricsAccumulator) owns(fullName string) bool {
+	owner, _, _ := strings.Cut(fullName, "/")
+	return strings.EqualFold(owner, m.login)
+}
+
+// addPullRequest counts a pull request the user opened on another
+// account's repo
+func (m *metricsAccumulator) addPullRequest(event *GitHubEvent) {
+	var payload struct {
+		Action string `json:"action"`
+	}
+	if event.Repo.Name == "" || m.owns(event.Repo.Name) || json.Unmarshal(event.Payload, &payload) != nil || payload.Action != "opened" {
+		return
+	}
+	m.metrics.ContributedPRs++
+	m.contributed[event.Repo.Name]++
+}
+
+// checkWorksIn finds the organization repos the user does their work in,
+// such as an employer's open source, from the pushes and pull requests of
+// the events feed. The repos contributed to most are fetched to confirm an
+// organization owns them; the user's own repos then look idle without
+// the account being inactive, so Activity and Community take the work
+// into account instead of scoring the sparse personal repos alone.
+func (a *Analyzer) checkWorksIn(ctx context.Context, r *analysisRun) {
+	if !r.log.coverage().events || len(r.acc.contributed) == 0 {
+		return
+	}
+
+	contributed := r.acc.contributed
+	repos := slices.Collect(maps.Keys(contributed))
+	slices.SortFunc(repos, func(x, y string) int {
+		return cmp.Or(cmp.Compare(contributed[y], contributed[x]), cmp.Compare(x, y))
+	})
+
+	metrics := &r.acc.metrics
+	var evidence []string
+	var failed error
+	for _, fullName := range repos[:min(len(repos), maxWorksIn)] {
+		if contributed[fullName] < minWorksInContributions {
+			break
+		}
+		owner, name, _ := strings.Cut(fullName, "/")
+		repo, err := a.client.GetRepo(ctx, owner, name)
+		if err != nil {
+			failed = cmp.Or(failed, fmt.Errorf("failed to fetch %s: %w", fullName, err))
+			continue
+		}
+		if repo.Owner == nil || repo.Owner.Type != "Organization" || repo.Fork {
+			continue
+		}
+		metrics.WorksIn = append(metrics.WorksIn, fullName)
+		metrics.WorksInStars += repo.StargazersCount
+		evidence = append(evidence, fmt.Sprintf("%s (%d contributions, %d stars)", fullName, contributed[fullName], repo.StargazersCount))
+	}
+	if failed != nil {
+		r.log.fellBack("works_in", failed)
+	} else {
+		r.log.ok("works_in")
+	}
+	if len(metrics.WorksIn) == 0 {
+		return
+	}
+
+	// Primary when the top organization repo gets more than any owned one
+	top := metrics.WorksIn[0]
+	for repo, commits := range r.acc.pushRepos {
+		if r.acc.owns(repo) && commits >= contributed[top] {
+			return
+		}
+	}
+	r.addFinding(Finding{
+		Code:     "WORKS_IN_ORG_REPOS",
+		Severity: SeverityPositive,
+		Message:  fmt.Sprintf("Primary activity is in %s", top),
+		Evidence: evidence,
+		Args:     []string{top},
+	})
+}
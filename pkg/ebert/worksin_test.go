@@ -0,0 +1,128 @@
+package ebert
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// contributionFeed pushes commits[repo] to each repo, one push a day, and
+// opens prs[repo] pull requests on each, plus one closed on kubernetes
+func contributionFeed(commits, prs map[string]int) []GitHubEvent {
+	var events []GitHubEvent
+	add := func(typ, repo, payload string) {
+		event := GitHubEvent{ID: fmt.Sprint(8000 + len(events)), Type: typ, CreatedAt: fakeNow.Add(-days(len(events) + 1)), Payload: json.RawMessage(payload)}
+		event.Repo.Name = repo
+		events = append(events, event)
+	}
+	for _, repo := range slices.Sorted(maps.Keys(commits)) {
+		add("PushEvent", repo, fmt.Sprintf(`{"push_id":%d,"size":%d,"distinct_size":%[2]d,"ref":"refs/heads/main"}`, 800+len(events), commits[repo]))
+	}
+	for _, repo := range slices.Sorted(maps.Keys(prs)) {
+		for range prs[repo] {
+			add("PullRequestEvent", repo, `{"action":"opened"}`)
+		}
+	}
+	add("PullRequestEvent", "kubernetes/kubernetes", `{"action":"closed"}`)
+	return events
+}
+
+// contributedRepo is owner/name as GetRepo returns it, owned by an
+// account of ownerType
+func contributedRepo(owner, name, ownerType string, stars int, fork bool) GitHubRepo {
+	return GitHubRepo{Name: name, FullName: owner + "/" + name, Fork: fork, StargazersCount: stars, Owner: &RepoOwner{Login: owner, Type: ownerType}}
+}
+
+// worksInAccount has six idle repos of its own, few stars between them,
+// and pushes commits[repo] and opens prs[repo] pull requests
+func worksInAccount(commits, prs map[string]int) *fakeAccount {
+	var repos []GitHubRepo
+	for i := range 6 {
+		repos = append(repos, GitHubRepo{Name: fmt.Sprintf("repo%d", i), Language: "Go", Size: 300, StargazersCount: 1, UpdatedAt: fakeNow.Add(-days(200 + i))})
+	}
+	account := newAccount("octo", days(3000), repos...)
+	account.Events = contributionFeed(commits, prs)
+	return account
+}
+
+func TestCheckWorksIn(t *testing.T) {
+	commits := map[string]int{
+		"kubernetes/kubernetes": 8,
+		// A fork, one that can't be fetched and one contributed to too little
+		"other/fork":    7,
+		"gone/project":  5,
+		"small-org/x":   2,
+		"octo/repo0":    3,
+		"kubernetes/kb": 1,
+	}
+	prs := map[string]int{"kubernetes/kubernetes": 2, "octo/repo0": 1}
+	f := newFakeGitHub(t, worksInAccount(commits, prs))
+	serveRepo(f, "kubernetes", contributedRepo("kubernetes", "kubernetes", "Organization", 100000, false))
+	serveRepo(f, "other", contributedRepo("other", "fork", "Organization", 50, true))
+
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	metrics := analysis.Metrics
+	// Owned and contributed activity stay apart
+	if metrics.RecentCommits != 26 || metrics.ContributedCommits != 23 || metrics.ContributedPRs != 2 {
+		t.Errorf("%d commits, %d contributed, %d pull requests; want 26, 23 and 2", metrics.RecentCommits, metrics.ContributedCommits, metrics.ContributedPRs)
+	}
+	if !slices.Equal(metrics.WorksIn, []string{"kubernetes/kubernetes"}) || metrics.WorksInStars != 100000 || metrics.Stars != 6 {
+		t.Errorf("works in %q with %d stars, %d stars owned; want kubernetes/kubernetes, 100000 and 6", metrics.WorksIn, metrics.WorksInStars, metrics.Stars)
+	}
+
+	flag := finding(analysis, "WORKS_IN_ORG_REPOS")
+	want := []string{"kubernetes/kubernetes (10 contributions, 100000 stars)"}
+	if flag == nil || flag.Severity != SeverityPositive || flag.Message != "Primary activity is in kubernetes/kubernetes" || !slices.Equal(flag.Evidence, want) {
+		t.Errorf("WORKS_IN_ORG_REPOS = %+v, want %q", flag, want)
+	}
+	if !slices.ContainsFunc(analysis.DataSources, func(s DataSource) bool { return s.Name == "works_in" && s.Status == SourceFallback }) {
+		t.Errorf("sources = %+v, want works_in fallen back for gone/project", analysis.DataSources)
+	}
+}
+
+func TestCheckWorksInOwnedPrimary(t *testing.T) {
+	// More pushed to an owned repo than to the organization's
+	f := newFakeGitHub(t, worksInAccount(map[string]int{"kubernetes/kubernetes": 8, "octo/repo0": 12}, nil))
+	serveRepo(f, "kubernetes", contributedRepo("kubernetes", "kubernetes", "Organization", 100000, false))
+	analysis, err := newFakeAnalyzer(f).Analyze("octo")
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if !slices.Equal(analysis.Metrics.WorksIn, []string{"kubernetes/kubernetes"}) {
+		t.Errorf("WorksIn = %q, want kubernetes/kubernetes", analysis.Metrics.WorksIn)
+	}
+	if flag := finding(analysis, "WORKS_IN_ORG_REPOS"); flag != nil {
+		t.Errorf("unexpected %+v", flag)
+	}
+	if !slices.Contains(analysis.DataSources, DataSource{Name: "works_in", Status: SourceOK}) {
+		t.Errorf("sources = %+v, want works_in ok", analysis.DataSources)
+	}
+}
+
+func TestWorksInScores(t *testing.T) {
+	analyze := func(ownerType string) *Analysis {
+		f := newFakeGitHub(t, worksInAccount(map[string]int{"kubernetes/kubernetes": 30}, nil))
+		serveRepo(f, "kubernetes", contributedRepo("kubernetes", "kubernetes", ownerType, 100000, false))
+		analysis, err := newFakeAnalyzer(f).Analyze("octo")
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		return analysis
+	}
+	// The same pushes, to a repo that turns out to be a user's
+	personal, org := analyze("User"), analyze("Organization")
+	if len(personal.Metrics.WorksIn) != 0 || len(org.Metrics.WorksIn) != 1 {
+		t.Fatalf("works in %q and %q, want nothing and kubernetes/kubernetes", personal.Metrics.WorksIn, org.Metrics.WorksIn)
+	}
+	// The idle personal repos and sparse account are no longer held
+	// against the user, and the well-known project counts
+	if *org.Scores.Activity >= *personal.Scores.Activity || *org.Scores.Community >= *personal.Scores.Community {
+		t.Errorf("activity %v, community %v working in the org; %v and %v without",
+			*org.Scores.Activity, *org.Scores.Community, *personal.Scores.Activity, *personal.Scores.Community)
+	}
+}